@@ -17,9 +17,18 @@ limitations under the License.
 package v1alpha4
 
 import (
+	"fmt"
+	"net"
+	"reflect"
+
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/cluster-api/feature"
+	"sigs.k8s.io/cluster-api/util/version"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
@@ -36,6 +45,14 @@ func (c *Cluster) SetupWebhookWithManager(mgr ctrl.Manager) error {
 var _ webhook.Defaulter = &Cluster{}
 var _ webhook.Validator = &Cluster{}
 
+const (
+	// DefaultAPIServerPort is the default port the API Server binds to.
+	DefaultAPIServerPort = 6443
+
+	// DefaultServiceDomain is the default domain name for services.
+	DefaultServiceDomain = "cluster.local"
+)
+
 func (c *Cluster) Default() {
 	if c.Spec.InfrastructureRef != nil && len(c.Spec.InfrastructureRef.Namespace) == 0 {
 		c.Spec.InfrastructureRef.Namespace = c.Namespace
@@ -44,16 +61,33 @@ func (c *Cluster) Default() {
 	if c.Spec.ControlPlaneRef != nil && len(c.Spec.ControlPlaneRef.Namespace) == 0 {
 		c.Spec.ControlPlaneRef.Namespace = c.Namespace
 	}
+
+	if c.Spec.ClusterNetwork != nil {
+		if c.Spec.ClusterNetwork.APIServerPort == nil {
+			c.Spec.ClusterNetwork.APIServerPort = pointer.Int32Ptr(DefaultAPIServerPort)
+		}
+		if c.Spec.ClusterNetwork.ServiceDomain == "" {
+			c.Spec.ClusterNetwork.ServiceDomain = DefaultServiceDomain
+		}
+	}
 }
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
 func (c *Cluster) ValidateCreate() error {
-	return c.validate()
+	return c.validate(nil)
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
 func (c *Cluster) ValidateUpdate(old runtime.Object) error {
-	return c.validate()
+	var oldCluster *Cluster
+	if old != nil {
+		var ok bool
+		oldCluster, ok = old.(*Cluster)
+		if !ok {
+			return apierrors.NewBadRequest(fmt.Sprintf("expected a Cluster but got a %T", old))
+		}
+	}
+	return c.validate(oldCluster)
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
@@ -61,7 +95,7 @@ func (c *Cluster) ValidateDelete() error {
 	return nil
 }
 
-func (c *Cluster) validate() error {
+func (c *Cluster) validate(old *Cluster) error {
 	var allErrs field.ErrorList
 	if c.Spec.InfrastructureRef != nil && c.Spec.InfrastructureRef.Namespace != c.Namespace {
 		allErrs = append(
@@ -85,8 +119,180 @@ func (c *Cluster) validate() error {
 		)
 	}
 
+	// Validate the cluster network, if defined; on update, it is validated only if changed, so existing Clusters
+	// with an invalid cluster network can still be updated, e.g. for removing finalizers.
+	if c.Spec.ClusterNetwork != nil && (old == nil || !reflect.DeepEqual(old.Spec.ClusterNetwork, c.Spec.ClusterNetwork)) {
+		allErrs = append(allErrs, validateClusterNetwork(c.Spec.ClusterNetwork, field.NewPath("spec", "clusterNetwork"))...)
+	}
+
+	// Validate the managed topology, if defined.
+	if c.Spec.Topology != nil {
+		allErrs = append(allErrs, c.validateTopology(old)...)
+	}
+
+	if old != nil && old.Spec.Topology != nil && c.Spec.Topology == nil {
+		allErrs = append(
+			allErrs,
+			field.Forbidden(
+				field.NewPath("spec", "topology"),
+				"cannot be removed from an existing Cluster",
+			),
+		)
+	}
+
 	if len(allErrs) == 0 {
 		return nil
 	}
 	return apierrors.NewInvalid(GroupVersion.WithKind("Cluster").GroupKind(), c.Name, allErrs)
 }
+
+func (c *Cluster) validateTopology(old *Cluster) field.ErrorList {
+	var allErrs field.ErrorList
+
+	// NOTE: ClusterClass and managed topologies are behind ClusterTopology feature gate flag; the web hook
+	// must prevent the usage of Cluster.Topology in case the feature flag is disabled.
+	if !feature.Gates.Enabled(feature.ClusterTopology) {
+		allErrs = append(
+			allErrs,
+			field.Forbidden(
+				field.NewPath("spec", "topology"),
+				"can be set only if the ClusterTopology feature flag is enabled",
+			),
+		)
+		return allErrs
+	}
+
+	if c.Spec.Topology.Class == "" {
+		allErrs = append(
+			allErrs,
+			field.Required(
+				field.NewPath("spec", "topology", "class"),
+				"cannot be empty",
+			),
+		)
+	}
+
+	if !version.KubeSemver.MatchString(c.Spec.Topology.Version) {
+		allErrs = append(
+			allErrs,
+			field.Invalid(
+				field.NewPath("spec", "topology", "version"),
+				c.Spec.Topology.Version,
+				"must be a valid semantic version",
+			),
+		)
+	}
+
+	if old != nil && old.Spec.Topology != nil && old.Spec.Topology.Class != c.Spec.Topology.Class {
+		allErrs = append(
+			allErrs,
+			field.Invalid(
+				field.NewPath("spec", "topology", "class"),
+				c.Spec.Topology.Class,
+				"field is immutable",
+			),
+		)
+	}
+
+	if c.Spec.Topology.Workers != nil {
+		names := sets.NewString()
+		for i, md := range c.Spec.Topology.Workers.MachineDeployments {
+			if names.Has(md.Name) {
+				allErrs = append(
+					allErrs,
+					field.Invalid(
+						field.NewPath("spec", "topology", "workers", "machineDeployments").Index(i).Child("name"),
+						md.Name,
+						"must be unique",
+					),
+				)
+			}
+			names.Insert(md.Name)
+		}
+	}
+
+	return allErrs
+}
+
+func validateClusterNetwork(n *ClusterNetwork, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if n.APIServerPort != nil {
+		for _, msg := range validation.IsValidPortNum(int(*n.APIServerPort)) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("apiServerPort"), *n.APIServerPort, msg))
+		}
+	}
+
+	if n.ServiceDomain != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(n.ServiceDomain) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("serviceDomain"), n.ServiceDomain, msg))
+		}
+	}
+
+	podsPath := fldPath.Child("pods", "cidrBlocks")
+	pods, podsErrs := validateNetworkRanges(n.Pods, podsPath)
+	allErrs = append(allErrs, podsErrs...)
+
+	servicesPath := fldPath.Child("services", "cidrBlocks")
+	services, servicesErrs := validateNetworkRanges(n.Services, servicesPath)
+	allErrs = append(allErrs, servicesErrs...)
+
+	// Consistency between pods and services can be checked only if both are valid.
+	if len(podsErrs) > 0 || len(servicesErrs) > 0 || len(pods) == 0 || len(services) == 0 {
+		return allErrs
+	}
+
+	if isIPv4(pods[0]) != isIPv4(services[0]) {
+		allErrs = append(allErrs, field.Invalid(servicesPath, n.Services.CIDRBlocks,
+			fmt.Sprintf("must have the same primary IP family of %s", podsPath)))
+	}
+	if len(services) > len(pods) {
+		allErrs = append(allErrs, field.Invalid(servicesPath, n.Services.CIDRBlocks,
+			fmt.Sprintf("can be dual-stack only if %s is dual-stack", podsPath)))
+	}
+
+	for i, service := range services {
+		for _, pod := range pods {
+			if service.Contains(pod.IP) || pod.Contains(service.IP) {
+				allErrs = append(allErrs, field.Invalid(servicesPath.Index(i), n.Services.CIDRBlocks[i],
+					fmt.Sprintf("must not overlap with %s in %s", pod, podsPath)))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// validateNetworkRanges validates the CIDR blocks of a network range, which can be either single-stack or dual-stack,
+// and returns them parsed.
+func validateNetworkRanges(r *NetworkRanges, fldPath *field.Path) ([]*net.IPNet, field.ErrorList) {
+	if r == nil {
+		return nil, nil
+	}
+
+	var allErrs field.ErrorList
+	var cidrs []*net.IPNet
+	for i, block := range r.CIDRBlocks {
+		_, cidr, err := net.ParseCIDR(block)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i), block, "must be a valid CIDR block"))
+			continue
+		}
+		cidrs = append(cidrs, cidr)
+	}
+	if len(allErrs) > 0 {
+		return nil, allErrs
+	}
+
+	switch {
+	case len(cidrs) > 2:
+		allErrs = append(allErrs, field.TooMany(fldPath, len(cidrs), 2))
+	case len(cidrs) == 2 && isIPv4(cidrs[0]) == isIPv4(cidrs[1]):
+		allErrs = append(allErrs, field.Invalid(fldPath, r.CIDRBlocks, "dual-stack CIDR blocks must be one IPv4 and one IPv6"))
+	}
+	return cidrs, allErrs
+}
+
+func isIPv4(cidr *net.IPNet) bool {
+	return cidr.IP.To4() != nil
+}
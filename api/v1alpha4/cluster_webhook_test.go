@@ -23,6 +23,9 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilfeature "k8s.io/component-base/featuregate/testing"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/cluster-api/feature"
 	utildefaulting "sigs.k8s.io/cluster-api/util/defaulting"
 )
 
@@ -44,6 +47,26 @@ func TestClusterDefault(t *testing.T) {
 
 	g.Expect(c.Spec.InfrastructureRef.Namespace).To(Equal(c.Namespace))
 	g.Expect(c.Spec.ControlPlaneRef.Namespace).To(Equal(c.Namespace))
+	g.Expect(c.Spec.ClusterNetwork).To(BeNil())
+}
+
+func TestClusterDefaultClusterNetwork(t *testing.T) {
+	g := NewWithT(t)
+
+	c := &Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "fooboo",
+		},
+		Spec: ClusterSpec{
+			ClusterNetwork: &ClusterNetwork{},
+		},
+	}
+
+	t.Run("for Cluster", utildefaulting.DefaultValidateTest(c))
+	c.Default()
+
+	g.Expect(c.Spec.ClusterNetwork.APIServerPort).To(Equal(pointer.Int32Ptr(DefaultAPIServerPort)))
+	g.Expect(c.Spec.ClusterNetwork.ServiceDomain).To(Equal(DefaultServiceDomain))
 }
 
 func TestClusterValidation(t *testing.T) {
@@ -102,3 +125,238 @@ func TestClusterValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestClusterNetworkValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		network   *ClusterNetwork
+		expectErr bool
+	}{
+		{
+			name: "should succeed with valid single-stack ranges",
+			network: &ClusterNetwork{
+				APIServerPort: pointer.Int32Ptr(6443),
+				Pods:          &NetworkRanges{CIDRBlocks: []string{"192.168.0.0/16"}},
+				Services:      &NetworkRanges{CIDRBlocks: []string{"10.128.0.0/12"}},
+				ServiceDomain: "cluster.local",
+			},
+		},
+		{
+			name: "should succeed with valid dual-stack ranges",
+			network: &ClusterNetwork{
+				Pods:     &NetworkRanges{CIDRBlocks: []string{"192.168.0.0/16", "fd00:100:96::/48"}},
+				Services: &NetworkRanges{CIDRBlocks: []string{"10.128.0.0/12", "fd00:100:64::/108"}},
+			},
+		},
+		{
+			name: "should succeed with single-stack services in a dual-stack cluster",
+			network: &ClusterNetwork{
+				Pods:     &NetworkRanges{CIDRBlocks: []string{"192.168.0.0/16", "fd00:100:96::/48"}},
+				Services: &NetworkRanges{CIDRBlocks: []string{"10.128.0.0/12"}},
+			},
+		},
+		{
+			name: "should return error for an invalid CIDR block",
+			network: &ClusterNetwork{
+				Pods: &NetworkRanges{CIDRBlocks: []string{"192.168.0.0/33"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "should return error for more than two CIDR blocks",
+			network: &ClusterNetwork{
+				Pods: &NetworkRanges{CIDRBlocks: []string{"192.168.0.0/16", "fd00:100:96::/48", "172.16.0.0/16"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "should return error for dual-stack CIDR blocks of the same family",
+			network: &ClusterNetwork{
+				Pods: &NetworkRanges{CIDRBlocks: []string{"192.168.0.0/16", "172.16.0.0/16"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "should return error when pods and services have different primary IP families",
+			network: &ClusterNetwork{
+				Pods:     &NetworkRanges{CIDRBlocks: []string{"192.168.0.0/16", "fd00:100:96::/48"}},
+				Services: &NetworkRanges{CIDRBlocks: []string{"fd00:100:64::/108", "10.128.0.0/12"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "should return error for dual-stack services in a single-stack cluster",
+			network: &ClusterNetwork{
+				Pods:     &NetworkRanges{CIDRBlocks: []string{"192.168.0.0/16"}},
+				Services: &NetworkRanges{CIDRBlocks: []string{"10.128.0.0/12", "fd00:100:64::/108"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "should return error when pods and services overlap",
+			network: &ClusterNetwork{
+				Pods:     &NetworkRanges{CIDRBlocks: []string{"10.0.0.0/8"}},
+				Services: &NetworkRanges{CIDRBlocks: []string{"10.128.0.0/12"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "should return error for an invalid service domain",
+			network: &ClusterNetwork{
+				ServiceDomain: "Cluster_Local",
+			},
+			expectErr: true,
+		},
+		{
+			name: "should return error for an invalid API server port",
+			network: &ClusterNetwork{
+				APIServerPort: pointer.Int32Ptr(0),
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c := &Cluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "foo",
+				},
+				Spec: ClusterSpec{
+					ClusterNetwork: tt.network,
+				},
+			}
+
+			if tt.expectErr {
+				g.Expect(c.ValidateCreate()).NotTo(Succeed())
+				g.Expect(c.ValidateUpdate(&Cluster{})).NotTo(Succeed())
+				// An unchanged cluster network is not validated on update.
+				g.Expect(c.ValidateUpdate(c.DeepCopy())).To(Succeed())
+			} else {
+				g.Expect(c.ValidateCreate()).To(Succeed())
+				g.Expect(c.ValidateUpdate(&Cluster{})).To(Succeed())
+			}
+		})
+	}
+}
+
+func TestClusterTopologyValidation(t *testing.T) {
+	defer utilfeature.SetFeatureGateDuringTest(t, feature.Gates, feature.ClusterTopology, true)()
+
+	valid := &Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "foo",
+		},
+		Spec: ClusterSpec{
+			Topology: &Topology{
+				Class:   "foo",
+				Version: "v1.19.1",
+				Workers: &WorkersTopology{
+					MachineDeployments: []MachineDeploymentTopology{
+						{Class: "aa", Name: "md-0"},
+						{Class: "aa", Name: "md-1"},
+					},
+				},
+			},
+		},
+	}
+
+	emptyClass := valid.DeepCopy()
+	emptyClass.Spec.Topology.Class = ""
+
+	invalidVersion := valid.DeepCopy()
+	invalidVersion.Spec.Topology.Version = "1.19.1"
+
+	duplicateMachineDeploymentNames := valid.DeepCopy()
+	duplicateMachineDeploymentNames.Spec.Topology.Workers.MachineDeployments[1].Name = "md-0"
+
+	changedClass := valid.DeepCopy()
+	changedClass.Spec.Topology.Class = "bar"
+
+	removedTopology := valid.DeepCopy()
+	removedTopology.Spec.Topology = nil
+
+	tests := []struct {
+		name      string
+		expectErr bool
+		old       *Cluster
+		c         *Cluster
+	}{
+		{
+			name:      "should succeed with a valid topology",
+			expectErr: false,
+			c:         valid,
+		},
+		{
+			name:      "should return error when the class is empty",
+			expectErr: true,
+			c:         emptyClass,
+		},
+		{
+			name:      "should return error when the version is not a valid semantic version",
+			expectErr: true,
+			c:         invalidVersion,
+		},
+		{
+			name:      "should return error when MachineDeployment topology names are not unique",
+			expectErr: true,
+			c:         duplicateMachineDeploymentNames,
+		},
+		{
+			name:      "should return error when the class is changed",
+			expectErr: true,
+			old:       valid,
+			c:         changedClass,
+		},
+		{
+			name:      "should return error when the topology is removed",
+			expectErr: true,
+			old:       valid,
+			c:         removedTopology,
+		},
+		{
+			name:      "should succeed when the topology is unchanged",
+			expectErr: false,
+			old:       valid,
+			c:         valid.DeepCopy(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			var err error
+			if tt.old == nil {
+				err = tt.c.ValidateCreate()
+			} else {
+				err = tt.c.ValidateUpdate(tt.old)
+			}
+			if tt.expectErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestClusterTopologyValidationWithoutFeatureGate(t *testing.T) {
+	g := NewWithT(t)
+
+	c := &Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "foo",
+		},
+		Spec: ClusterSpec{
+			Topology: &Topology{
+				Class:   "foo",
+				Version: "v1.19.1",
+			},
+		},
+	}
+
+	g.Expect(c.ValidateCreate()).NotTo(Succeed())
+}
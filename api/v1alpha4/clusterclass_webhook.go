@@ -0,0 +1,174 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha4
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/cluster-api/feature"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+func (in *ClusterClass) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(in).
+		Complete()
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-cluster-x-k8s-io-v1alpha4-clusterclass,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,groups=cluster.x-k8s.io,resources=clusterclasses,versions=v1alpha4,name=validation.clusterclass.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1beta1
+// +kubebuilder:webhook:verbs=create;update,path=/mutate-cluster-x-k8s-io-v1alpha4-clusterclass,mutating=true,failurePolicy=fail,matchPolicy=Equivalent,groups=cluster.x-k8s.io,resources=clusterclasses,versions=v1alpha4,name=default.clusterclass.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1beta1
+
+var _ webhook.Validator = &ClusterClass{}
+var _ webhook.Defaulter = &ClusterClass{}
+
+// Default satisfies the defaulting webhook interface.
+func (in *ClusterClass) Default() {
+	// Default all namespaces in the references to the object namespace.
+	defaultNamespace(in.Spec.Infrastructure.Ref, in.Namespace)
+	defaultNamespace(in.Spec.ControlPlane.Ref, in.Namespace)
+
+	for i := range in.Spec.Workers.MachineDeployments {
+		defaultNamespace(in.Spec.Workers.MachineDeployments[i].Template.Bootstrap.Ref, in.Namespace)
+		defaultNamespace(in.Spec.Workers.MachineDeployments[i].Template.Infrastructure.Ref, in.Namespace)
+	}
+}
+
+// ValidateCreate implements validation for ClusterClass create.
+func (in *ClusterClass) ValidateCreate() error {
+	return in.validate()
+}
+
+// ValidateUpdate implements validation for ClusterClass update.
+func (in *ClusterClass) ValidateUpdate(old runtime.Object) error {
+	return in.validate()
+}
+
+// ValidateDelete implements validation for ClusterClass delete.
+func (in *ClusterClass) ValidateDelete() error {
+	return nil
+}
+
+func (in *ClusterClass) validate() error {
+	// NOTE: ClusterClass and managed topologies are behind ClusterTopology feature gate flag; the web hook
+	// must prevent creating new objects in case the feature flag is disabled.
+	if !feature.Gates.Enabled(feature.ClusterTopology) {
+		return apierrors.NewInvalid(
+			GroupVersion.WithKind("ClusterClass").GroupKind(),
+			in.Name,
+			field.ErrorList{field.Forbidden(
+				field.NewPath("spec"),
+				"can be set only if the ClusterTopology feature flag is enabled",
+			)},
+		)
+	}
+
+	var allErrs field.ErrorList
+	allErrs = append(allErrs, in.Spec.Infrastructure.validate(in.Namespace, field.NewPath("spec", "infrastructure"))...)
+	allErrs = append(allErrs, in.Spec.ControlPlane.validate(in.Namespace, field.NewPath("spec", "controlPlane"))...)
+
+	classes := sets.NewString()
+	for i, class := range in.Spec.Workers.MachineDeployments {
+		fldPath := field.NewPath("spec", "workers", "machineDeployments").Index(i)
+		if class.Class == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("class"), "cannot be empty"))
+		} else if classes.Has(class.Class) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("class"), class.Class, "must be unique"))
+		}
+		classes.Insert(class.Class)
+
+		allErrs = append(allErrs, class.Template.Bootstrap.validate(in.Namespace, fldPath.Child("template", "bootstrap"))...)
+		allErrs = append(allErrs, class.Template.Infrastructure.validate(in.Namespace, fldPath.Child("template", "infrastructure"))...)
+	}
+
+	if len(allErrs) > 0 {
+		return apierrors.NewInvalid(GroupVersion.WithKind("ClusterClass").GroupKind(), in.Name, allErrs)
+	}
+	return nil
+}
+
+func (r *LocalObjectTemplate) validate(namespace string, pathPrefix *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	// check if ref is not nil.
+	if r.Ref == nil {
+		return field.ErrorList{field.Invalid(
+			pathPrefix.Child("ref"),
+			r.Ref,
+			"cannot be nil",
+		)}
+	}
+
+	// check if a name is provided
+	if r.Ref.Name == "" {
+		allErrs = append(allErrs,
+			field.Invalid(
+				pathPrefix.Child("ref", "name"),
+				r.Ref.Name,
+				"cannot be empty",
+			),
+		)
+	}
+
+	// validate if namespace matches the provided namespace
+	if namespace != "" && r.Ref.Namespace != namespace {
+		allErrs = append(
+			allErrs,
+			field.Invalid(
+				pathPrefix.Child("ref", "namespace"),
+				r.Ref.Namespace,
+				"must match metadata.namespace",
+			),
+		)
+	}
+
+	// check if kind is a template
+	if !strings.HasSuffix(r.Ref.Kind, "Template") || r.Ref.Kind == "Template" {
+		allErrs = append(allErrs,
+			field.Invalid(
+				pathPrefix.Child("ref", "kind"),
+				r.Ref.Kind,
+				"kind must be of form '<name>Template'",
+			),
+		)
+	}
+
+	// check if apiVersion is valid
+	if _, err := schema.ParseGroupVersion(r.Ref.APIVersion); err != nil || r.Ref.APIVersion == "" {
+		allErrs = append(allErrs,
+			field.Invalid(
+				pathPrefix.Child("ref", "apiVersion"),
+				r.Ref.APIVersion,
+				"must be a valid apiVersion",
+			),
+		)
+	}
+
+	return allErrs
+}
+
+func defaultNamespace(ref *corev1.ObjectReference, namespace string) {
+	if ref != nil && len(ref.Namespace) == 0 {
+		ref.Namespace = namespace
+	}
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha4
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilfeature "k8s.io/component-base/featuregate/testing"
+	"sigs.k8s.io/cluster-api/feature"
+)
+
+func TestClusterClassDefaultNamespaces(t *testing.T) {
+	g := NewWithT(t)
+
+	in := &ClusterClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "class1",
+			Namespace: "default",
+		},
+		Spec: ClusterClassSpec{
+			Infrastructure: LocalObjectTemplate{Ref: &corev1.ObjectReference{}},
+			ControlPlane:   LocalObjectTemplate{Ref: &corev1.ObjectReference{}},
+			Workers: WorkersClass{
+				MachineDeployments: []MachineDeploymentClass{
+					{
+						Class: "aa",
+						Template: MachineDeploymentClassTemplate{
+							Bootstrap:      LocalObjectTemplate{Ref: &corev1.ObjectReference{}},
+							Infrastructure: LocalObjectTemplate{Ref: &corev1.ObjectReference{}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	in.Default()
+
+	g.Expect(in.Spec.Infrastructure.Ref.Namespace).To(Equal(in.Namespace))
+	g.Expect(in.Spec.ControlPlane.Ref.Namespace).To(Equal(in.Namespace))
+	for i := range in.Spec.Workers.MachineDeployments {
+		g.Expect(in.Spec.Workers.MachineDeployments[i].Template.Bootstrap.Ref.Namespace).To(Equal(in.Namespace))
+		g.Expect(in.Spec.Workers.MachineDeployments[i].Template.Infrastructure.Ref.Namespace).To(Equal(in.Namespace))
+	}
+}
+
+func TestClusterClassValidation(t *testing.T) {
+	defer utilfeature.SetFeatureGateDuringTest(t, feature.Gates, feature.ClusterTopology, true)()
+
+	ref := &corev1.ObjectReference{
+		APIVersion: "foo.bar/v1alpha4",
+		Kind:       "FooTemplate",
+		Name:       "baz",
+		Namespace:  "default",
+	}
+
+	valid := &ClusterClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "class1",
+			Namespace: "default",
+		},
+		Spec: ClusterClassSpec{
+			Infrastructure: LocalObjectTemplate{Ref: ref.DeepCopy()},
+			ControlPlane:   LocalObjectTemplate{Ref: ref.DeepCopy()},
+			Workers: WorkersClass{
+				MachineDeployments: []MachineDeploymentClass{
+					{
+						Class: "aa",
+						Template: MachineDeploymentClassTemplate{
+							Bootstrap:      LocalObjectTemplate{Ref: ref.DeepCopy()},
+							Infrastructure: LocalObjectTemplate{Ref: ref.DeepCopy()},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	missingRef := valid.DeepCopy()
+	missingRef.Spec.ControlPlane.Ref = nil
+
+	invalidNamespace := valid.DeepCopy()
+	invalidNamespace.Spec.Infrastructure.Ref.Namespace = "foo"
+
+	invalidKind := valid.DeepCopy()
+	invalidKind.Spec.Workers.MachineDeployments[0].Template.Bootstrap.Ref.Kind = "Foo"
+
+	invalidAPIVersion := valid.DeepCopy()
+	invalidAPIVersion.Spec.Workers.MachineDeployments[0].Template.Infrastructure.Ref.APIVersion = ""
+
+	duplicateClasses := valid.DeepCopy()
+	duplicateClasses.Spec.Workers.MachineDeployments = append(duplicateClasses.Spec.Workers.MachineDeployments,
+		*duplicateClasses.Spec.Workers.MachineDeployments[0].DeepCopy())
+
+	tests := []struct {
+		name      string
+		expectErr bool
+		in        *ClusterClass
+	}{
+		{
+			name:      "should succeed with valid references",
+			expectErr: false,
+			in:        valid,
+		},
+		{
+			name:      "should return error when a reference is missing",
+			expectErr: true,
+			in:        missingRef,
+		},
+		{
+			name:      "should return error when a reference namespace does not match the ClusterClass namespace",
+			expectErr: true,
+			in:        invalidNamespace,
+		},
+		{
+			name:      "should return error when a reference kind is not a template",
+			expectErr: true,
+			in:        invalidKind,
+		},
+		{
+			name:      "should return error when a reference apiVersion is empty",
+			expectErr: true,
+			in:        invalidAPIVersion,
+		},
+		{
+			name:      "should return error when MachineDeployment classes are not unique",
+			expectErr: true,
+			in:        duplicateClasses,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			if tt.expectErr {
+				g.Expect(tt.in.ValidateCreate()).NotTo(Succeed())
+				g.Expect(tt.in.ValidateUpdate(nil)).NotTo(Succeed())
+			} else {
+				g.Expect(tt.in.ValidateCreate()).To(Succeed())
+				g.Expect(tt.in.ValidateUpdate(nil)).To(Succeed())
+			}
+		})
+	}
+}
+
+func TestClusterClassValidationWithoutFeatureGate(t *testing.T) {
+	g := NewWithT(t)
+
+	in := &ClusterClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "class1",
+			Namespace: "default",
+		},
+	}
+
+	g.Expect(in.ValidateCreate()).NotTo(Succeed())
+}
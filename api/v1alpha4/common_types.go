@@ -25,6 +25,13 @@ const (
 	// external objects(bootstrap and infrastructure providers).
 	ClusterLabelName = "cluster.x-k8s.io/cluster-name"
 
+	// ClusterTopologyOwnedLabel is the label set on all the objects which are managed as part of a ClusterTopology.
+	ClusterTopologyOwnedLabel = "topology.cluster.x-k8s.io/owned"
+
+	// ClusterTopologyMachineDeploymentLabelName is the label set on the generated MachineDeployment objects
+	// to track the name of the MachineDeployment topology it represents.
+	ClusterTopologyMachineDeploymentLabelName = "topology.cluster.x-k8s.io/deployment-name"
+
 	// ProviderLabelName is the label set on components in the provider manifest.
 	// This label allows to easily identify all the components belonging to a provider; the clusterctl
 	// tool uses this label for implementing provider's lifecycle operations.
@@ -75,14 +82,36 @@ const (
 	// that was cloned for the machine. This annotation is set only during cloning a template. Older/adopted machines will not have this annotation.
 	TemplateClonedFromGroupKindAnnotation = "cluster.x-k8s.io/cloned-from-groupkind"
 
+	// BootstrapTemplateHashAnnotation is the machine template annotation that stores the hash of the bootstrap template
+	// resource of a MachineDeployment, so changes to the bootstrap template roll out new machines. It is propagated to
+	// the MachineSets and machines created from the machine template.
+	BootstrapTemplateHashAnnotation = "cluster.x-k8s.io/bootstrap-template-hash"
+
 	// MachineSkipRemediationAnnotation is the annotation used to mark the machines that should not be considered for remediation by MachineHealthCheck reconciler.
 	MachineSkipRemediationAnnotation = "cluster.x-k8s.io/skip-remediation"
 
+	// AutoscalerMinSizeAnnotation is the annotation set on MachineDeployments and MachinePools defining the minimum number
+	// of replicas the cluster-autoscaler can scale them down to; the cluster-autoscaler ignores objects without both
+	// AutoscalerMinSizeAnnotation and AutoscalerMaxSizeAnnotation.
+	AutoscalerMinSizeAnnotation = "cluster.x-k8s.io/cluster-api-autoscaler-node-group-min-size"
+
+	// AutoscalerMaxSizeAnnotation is the annotation set on MachineDeployments and MachinePools defining the maximum number
+	// of replicas the cluster-autoscaler can scale them up to.
+	AutoscalerMaxSizeAnnotation = "cluster.x-k8s.io/cluster-api-autoscaler-node-group-max-size"
+
 	// ClusterSecretType defines the type of secret created by core components.
 	ClusterSecretType corev1.SecretType = "cluster.x-k8s.io/secret" //nolint:gosec
 
 	// InterruptibleLabel is the label used to mark the nodes that run on interruptible instances.
 	InterruptibleLabel = "cluster.x-k8s.io/interruptible"
+
+	// NodeTerminationRequestedCondition is the condition set on the nodes running on interruptible instances,
+	// e.g. by a termination handler DaemonSet, when the infrastructure provider signals the imminent termination of the instance.
+	NodeTerminationRequestedCondition corev1.NodeConditionType = "TerminationRequested"
+
+	// NodeTerminationRequestedAnnotation is the annotation set on control plane machines whose interruptible node reports
+	// an imminent termination, so the termination is reported only once; it is removed when the termination is no longer requested.
+	NodeTerminationRequestedAnnotation = "cluster.x-k8s.io/node-termination-requested"
 )
 
 // MachineAddressType describes a valid MachineAddress type.
@@ -97,6 +126,13 @@ const (
 
 	// MachineNodeNameIndex is used by the Machine Controller to index Machines by Node name, and add a watch on Nodes.
 	MachineNodeNameIndex = "status.nodeRef.name"
+
+	// ClusterNameIndex is used to index MachineDeployments, MachineSets and Machines by cluster name, and list the
+	// objects of a Cluster.
+	ClusterNameIndex = "spec.clusterName"
+
+	// ClusterUIDIndex is used to index Clusters by UID, and get a Cluster by UID.
+	ClusterUIDIndex = "metadata.uid"
 )
 
 // MachineAddress contains information for the node's address.
@@ -89,6 +89,27 @@ const (
 	// NOTE: Having the control plane machine available is a pre-condition for joining additional control planes
 	// or workers nodes.
 	WaitingForControlPlaneAvailableReason = "WaitingForControlPlaneAvailable"
+
+	// DescendantsDeletedCondition reports if all the descendants of a Cluster being deleted, e.g. MachineDeployments,
+	// MachinePools, Machines, MachineHealthChecks or ClusterResourceSetBindings, have been deleted.
+	DescendantsDeletedCondition ConditionType = "DescendantsDeleted"
+
+	// WaitingForDescendantsDeletionReason (Severity=Info) documents a Cluster being deleted waiting for
+	// its descendants to be deleted; the condition message lists the remaining descendants.
+	WaitingForDescendantsDeletionReason = "WaitingForDescendantsDeletion"
+
+	// WorkersReadyCondition reports an aggregate of the Ready condition of the MachineDeployments of a Cluster.
+	// The condition is not set on Clusters without MachineDeployments.
+	WorkersReadyCondition ConditionType = "WorkersReady"
+
+	// InfrastructureMachinesOwnedCondition reports if all the infrastructure machines of a Cluster are owned by a Machine;
+	// infrastructure machines without an owner Machine, e.g. left behind by a failed move, are orphaned.
+	// The condition is not set on Clusters without infrastructure machines.
+	InfrastructureMachinesOwnedCondition ConditionType = "InfrastructureMachinesOwned"
+
+	// OrphanedInfrastructureReason (Severity=Warning) documents a Cluster with infrastructure machines not owned by
+	// any Machine; the condition message lists the orphaned infrastructure machines.
+	OrphanedInfrastructureReason = "OrphanedInfrastructure"
 )
 
 // Conditions and condition Reasons for the Machine object
@@ -105,6 +126,17 @@ const (
 	// NOTE: This reason is used only as a fallback when the bootstrap object is not reporting its own ready condition.
 	WaitingForDataSecretFallbackReason = "WaitingForDataSecret"
 
+	// BootstrapDataUpToDateCondition documents if the bootstrap data used by a machine reflects the current
+	// bootstrap configuration. This condition is copied from the bootstrap ref object, where bootstrap providers can
+	// set it to false to signal stale bootstrap data, e.g. because the bootstrap configuration of the machine has been
+	// changed directly. NOTE: MachineDeployments roll out only the changes to the bootstrap template; changes to the
+	// bootstrap configuration of a single machine are not rolled out.
+	BootstrapDataUpToDateCondition ConditionType = "BootstrapDataUpToDate"
+
+	// BootstrapDataStaleReason (Severity=Warning) documents a machine using bootstrap data generated from
+	// a previous version of the bootstrap configuration.
+	BootstrapDataStaleReason = "BootstrapDataStale"
+
 	// DrainingSucceededCondition provide evidence of the status of the node drain operation which happens during the machine
 	// deletion process.
 	DrainingSucceededCondition ConditionType = "DrainingSucceeded"
@@ -123,6 +155,22 @@ const (
 
 	// WaitingExternalHookReason (Severity=Info) provide evidence that we are waiting for an external hook to complete.
 	WaitingExternalHookReason = "WaitingExternalHook"
+
+	// InfrastructureHealthyCondition documents the terminal failures reported by the infrastructure machine,
+	// and the decisions taken according to the Machine's infrastructure failure policy.
+	// NOTE: This condition is added only after the first failure has been reported.
+	InfrastructureHealthyCondition ConditionType = "InfrastructureHealthy"
+
+	// InfrastructureLeftForInspectionReason (Severity=Error) documents a failed infrastructure machine left in place
+	// for inspection; the Machine is not remediated by MachineHealthChecks.
+	InfrastructureLeftForInspectionReason = "LeftForInspection"
+
+	// InfrastructureRecreatingReason (Severity=Warning) documents a failed infrastructure machine being re-created.
+	InfrastructureRecreatingReason = "Recreating"
+
+	// InfrastructureFailureEscalatedReason (Severity=Error) documents a failed infrastructure machine escalated
+	// to MachineHealthCheck remediation.
+	InfrastructureFailureEscalatedReason = "EscalatedToRemediation"
 )
 
 const (
@@ -138,6 +186,10 @@ const (
 
 	// UnhealthyNodeConditionReason is the reason used when a machine's node has one of the MachineHealthCheck's unhealthy conditions.
 	UnhealthyNodeConditionReason = "UnhealthyNode"
+
+	// UnhealthyMachineConditionReason is the reason used when a machine, or the infrastructure machine it references,
+	// has one of the MachineHealthCheck's unhealthy conditions.
+	UnhealthyMachineConditionReason = "UnhealthyMachine"
 )
 
 const (
@@ -201,3 +253,23 @@ const (
 	// from making any further remediations.
 	TooManyUnhealthyReason = "TooManyUnhealthy"
 )
+
+// Conditions and condition Reasons for the MachineSet object
+
+const (
+	// MachinesReadyCondition reports an aggregate of the Ready condition of the Machines controlled by a MachineSet.
+	// The condition is true on MachineSets without Machines.
+	MachinesReadyCondition ConditionType = "MachinesReady"
+)
+
+// Conditions and condition Reasons for the MachineDeployment object
+
+const (
+	// MachineDeploymentAvailableCondition documents that a MachineDeployment has at least the minimum number of
+	// available Machines required by its rolling update strategy, i.e. the desired replicas minus maxUnavailable.
+	MachineDeploymentAvailableCondition ConditionType = "Available"
+
+	// WaitingForAvailableMachinesReason (Severity=Warning) documents a MachineDeployment waiting for its Machines
+	// to become available.
+	WaitingForAvailableMachinesReason = "WaitingForAvailableMachines"
+)
@@ -16,6 +16,10 @@ limitations under the License.
 
 package v1alpha4
 
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
 // MachinePhase is a string representation of a Machine Phase.
 //
 // This type is a high-level indicator of the status of the Machine as it is provisioned,
@@ -62,3 +66,12 @@ const (
 	// MachinePhaseUnknown is returned if the Machine state cannot be determined.
 	MachinePhaseUnknown = MachinePhase("Unknown")
 )
+
+// MachinePhaseTransition records the last time a Machine entered a phase.
+type MachinePhaseTransition struct {
+	// Phase is the phase the Machine entered.
+	Phase string `json:"phase"`
+
+	// LastTransitionTime is the last time the Machine entered the phase.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+}
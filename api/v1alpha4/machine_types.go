@@ -95,10 +95,59 @@ type MachineSpec struct {
 	// NOTE: NodeDrainTimeout is different from `kubectl drain --timeout`
 	// +optional
 	NodeDrainTimeout *metav1.Duration `json:"nodeDrainTimeout,omitempty"`
+
+	// InfrastructureFailurePolicy defines what the controller does when the infrastructure machine
+	// reports a terminal failure. If unset, the failure is escalated to MachineHealthCheck remediation.
+	// +optional
+	InfrastructureFailurePolicy *MachineInfrastructureFailurePolicy `json:"infrastructureFailurePolicy,omitempty"`
 }
 
 // ANCHOR_END: MachineSpec
 
+// ANCHOR: MachineInfrastructureFailurePolicy
+
+// MachineInfrastructureFailureAction defines the action taken when the infrastructure machine
+// reports a terminal failure.
+type MachineInfrastructureFailureAction string
+
+const (
+	// InspectInfrastructureFailureAction leaves the failed infrastructure machine in place for inspection;
+	// the Machine is marked as failed, but it is not remediated by MachineHealthChecks.
+	InspectInfrastructureFailureAction = MachineInfrastructureFailureAction("Inspect")
+
+	// RecreateInfrastructureFailureAction deletes the failed infrastructure machine and creates a new one
+	// from the same template, up to MaxRecreations times; after that the failure is escalated to
+	// MachineHealthCheck remediation.
+	// NOTE: Only infrastructure machines cloned from a template and not yet backing a Node can be re-created.
+	RecreateInfrastructureFailureAction = MachineInfrastructureFailureAction("Recreate")
+
+	// RemediateInfrastructureFailureAction marks the Machine as failed, so it can be remediated by
+	// MachineHealthChecks.
+	RemediateInfrastructureFailureAction = MachineInfrastructureFailureAction("Remediate")
+
+	// DefaultMaxInfrastructureRecreations is the default number of times an infrastructure machine
+	// is re-created when using the Recreate action.
+	DefaultMaxInfrastructureRecreations = int32(3)
+)
+
+// MachineInfrastructureFailurePolicy defines what the controller does when the infrastructure machine
+// reports a terminal failure, i.e. when it sets status.failureReason or status.failureMessage.
+type MachineInfrastructureFailurePolicy struct {
+	// Action is the action taken when the infrastructure machine reports a terminal failure.
+	// Valid values are "Inspect", "Recreate" and "Remediate" (default).
+	// +kubebuilder:validation:Enum=Inspect;Recreate;Remediate
+	// +optional
+	Action MachineInfrastructureFailureAction `json:"action,omitempty"`
+
+	// MaxRecreations is the maximum number of times the infrastructure machine is re-created
+	// when using the Recreate action. Defaults to 3.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxRecreations *int32 `json:"maxRecreations,omitempty"`
+}
+
+// ANCHOR_END: MachineInfrastructureFailurePolicy
+
 // ANCHOR: MachineStatus
 
 // MachineStatus defines the observed state of Machine.
@@ -166,6 +215,11 @@ type MachineStatus struct {
 	// +optional
 	Phase string `json:"phase,omitempty"`
 
+	// PhaseTransitions records the last time the Machine entered each phase,
+	// in the order the phases have been entered for the last time.
+	// +optional
+	PhaseTransitions []MachinePhaseTransition `json:"phaseTransitions,omitempty"`
+
 	// BootstrapReady is the state of the bootstrap provider.
 	// +optional
 	BootstrapReady bool `json:"bootstrapReady"`
@@ -174,6 +228,11 @@ type MachineStatus struct {
 	// +optional
 	InfrastructureReady bool `json:"infrastructureReady"`
 
+	// InfrastructureRecreations is the number of times the infrastructure machine has been re-created
+	// because of a terminal failure.
+	// +optional
+	InfrastructureRecreations int32 `json:"infrastructureRecreations,omitempty"`
+
 	// ObservedGeneration is the latest generation observed by the controller.
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
@@ -18,6 +18,8 @@ package v1alpha4
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -26,6 +28,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/utils/pointer"
+	"sigs.k8s.io/cluster-api/util/version"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
@@ -85,6 +88,8 @@ func (m *MachineDeployment) validate(old *MachineDeployment) error {
 		)
 	}
 
+	allErrs = append(allErrs, m.validateClusterName()...)
+
 	if old != nil && old.Spec.ClusterName != m.Spec.ClusterName {
 		allErrs = append(
 			allErrs,
@@ -92,6 +97,19 @@ func (m *MachineDeployment) validate(old *MachineDeployment) error {
 		)
 	}
 
+	if m.Spec.Strategy != nil && m.Spec.Strategy.RollingUpdate != nil {
+		allErrs = append(allErrs, validateRollingUpdate(m.Spec.Strategy.RollingUpdate, field.NewPath("spec", "strategy", "rollingUpdate"))...)
+	}
+
+	// NB. The "v" prefix is not required here, given that it is added by the Machine webhook, and adding it to the
+	// MachineDeployment would change the template hash, thus triggering a rollout.
+	if m.Spec.Template.Spec.Version != nil && !version.KubeSemverTolerant.MatchString(*m.Spec.Template.Spec.Version) {
+		allErrs = append(
+			allErrs,
+			field.Invalid(field.NewPath("spec", "template", "spec", "version"), *m.Spec.Template.Spec.Version, "must be a valid semantic version"),
+		)
+	}
+
 	if len(allErrs) == 0 {
 		return nil
 	}
@@ -99,6 +117,86 @@ func (m *MachineDeployment) validate(old *MachineDeployment) error {
 	return apierrors.NewInvalid(GroupVersion.WithKind("MachineDeployment").GroupKind(), m.Name, allErrs)
 }
 
+// validateClusterName validates that the cluster-name label on the MachineDeployment, its selector and its template,
+// as well as the template's clusterName, are consistent with spec.clusterName when set.
+func (m *MachineDeployment) validateClusterName() field.ErrorList {
+	var allErrs field.ErrorList
+	labelMsg := fmt.Sprintf("%s label must match spec.clusterName %q", ClusterLabelName, m.Spec.ClusterName)
+
+	if v, ok := m.Labels[ClusterLabelName]; ok && v != m.Spec.ClusterName {
+		allErrs = append(
+			allErrs,
+			field.Invalid(field.NewPath("metadata", "labels").Key(ClusterLabelName), v, labelMsg),
+		)
+	}
+	if v, ok := m.Spec.Selector.MatchLabels[ClusterLabelName]; ok && v != m.Spec.ClusterName {
+		allErrs = append(
+			allErrs,
+			field.Invalid(field.NewPath("spec", "selector", "matchLabels").Key(ClusterLabelName), v, labelMsg),
+		)
+	}
+	if v, ok := m.Spec.Template.Labels[ClusterLabelName]; ok && v != m.Spec.ClusterName {
+		allErrs = append(
+			allErrs,
+			field.Invalid(field.NewPath("spec", "template", "metadata", "labels").Key(ClusterLabelName), v, labelMsg),
+		)
+	}
+	if m.Spec.Template.Spec.ClusterName != "" && m.Spec.Template.Spec.ClusterName != m.Spec.ClusterName {
+		allErrs = append(
+			allErrs,
+			field.Invalid(
+				field.NewPath("spec", "template", "spec", "clusterName"),
+				m.Spec.Template.Spec.ClusterName,
+				fmt.Sprintf("must match spec.clusterName %q", m.Spec.ClusterName),
+			),
+		)
+	}
+	return allErrs
+}
+
+// validateRollingUpdate validates MaxSurge and MaxUnavailable the same way they are validated for Deployments.
+func validateRollingUpdate(rollingUpdate *MachineRollingUpdateDeployment, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	maxUnavailable, maxUnavailableIsPercent, errs := validateIntOrPercent(rollingUpdate.MaxUnavailable, fldPath.Child("maxUnavailable"))
+	allErrs = append(allErrs, errs...)
+	maxSurge, _, errs := validateIntOrPercent(rollingUpdate.MaxSurge, fldPath.Child("maxSurge"))
+	allErrs = append(allErrs, errs...)
+	if len(allErrs) > 0 {
+		return allErrs
+	}
+
+	if maxUnavailableIsPercent && maxUnavailable > 100 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxUnavailable"), rollingUpdate.MaxUnavailable.String(), "must not be greater than 100%"))
+	}
+	if rollingUpdate.MaxUnavailable != nil && rollingUpdate.MaxSurge != nil && maxUnavailable == 0 && maxSurge == 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxUnavailable"), rollingUpdate.MaxUnavailable.String(), "may not be 0 when maxSurge is 0"))
+	}
+	return allErrs
+}
+
+// validateIntOrPercent validates an IntOrString is a non negative integer or percentage, e.g. 10%, returning its value
+// and whether it is a percentage.
+func validateIntOrPercent(value *intstr.IntOrString, fldPath *field.Path) (int, bool, field.ErrorList) {
+	if value == nil {
+		return 0, false, nil
+	}
+
+	v, isPercent := value.IntValue(), false
+	if value.Type == intstr.String {
+		isPercent = true
+		i, err := strconv.Atoi(strings.TrimSuffix(value.StrVal, "%"))
+		if err != nil || !strings.HasSuffix(value.StrVal, "%") {
+			return 0, false, field.ErrorList{field.Invalid(fldPath, value.StrVal, "must be an integer or a percentage, e.g. 10%")}
+		}
+		v = i
+	}
+	if v < 0 {
+		return 0, false, field.ErrorList{field.Invalid(fldPath, value.String(), "must be greater than or equal to 0")}
+	}
+	return v, isPercent, nil
+}
+
 // PopulateDefaultsMachineDeployment fills in default field values.
 // This is also called during MachineDeployment sync.
 func PopulateDefaultsMachineDeployment(d *MachineDeployment) {
@@ -135,6 +233,10 @@ func PopulateDefaultsMachineDeployment(d *MachineDeployment) {
 		d.Spec.Template.Labels = make(map[string]string)
 	}
 
+	if d.Spec.Template.Spec.ClusterName == "" {
+		d.Spec.Template.Spec.ClusterName = d.Spec.ClusterName
+	}
+
 	// Default RollingUpdate strategy only if strategy type is RollingUpdate.
 	if d.Spec.Strategy.Type == RollingUpdateMachineDeploymentStrategyType {
 		if d.Spec.Strategy.RollingUpdate == nil {
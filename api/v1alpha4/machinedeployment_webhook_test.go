@@ -22,6 +22,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/pointer"
 	utildefaulting "sigs.k8s.io/cluster-api/util/defaulting"
 )
@@ -114,6 +115,150 @@ func TestMachineDeploymentValidation(t *testing.T) {
 	}
 }
 
+func TestMachineDeploymentRollingUpdateValidation(t *testing.T) {
+	intOrStr := func(s string) *intstr.IntOrString {
+		v := intstr.Parse(s)
+		return &v
+	}
+
+	tests := []struct {
+		name           string
+		maxSurge       *intstr.IntOrString
+		maxUnavailable *intstr.IntOrString
+		expectErr      bool
+	}{
+		{
+			name:           "should accept integers",
+			maxSurge:       intOrStr("1"),
+			maxUnavailable: intOrStr("0"),
+		},
+		{
+			name:           "should accept percentages",
+			maxSurge:       intOrStr("10%"),
+			maxUnavailable: intOrStr("25%"),
+		},
+		{
+			name:           "should accept maxUnavailable equal to 100%",
+			maxSurge:       intOrStr("0"),
+			maxUnavailable: intOrStr("100%"),
+		},
+		{
+			name:           "should return error for percentages without the percent sign",
+			maxSurge:       intOrStr("10a"),
+			maxUnavailable: intOrStr("0"),
+			expectErr:      true,
+		},
+		{
+			name:           "should return error for negative values",
+			maxSurge:       intOrStr("-1"),
+			maxUnavailable: intOrStr("0"),
+			expectErr:      true,
+		},
+		{
+			name:           "should return error for negative percentages",
+			maxSurge:       intOrStr("1"),
+			maxUnavailable: intOrStr("-10%"),
+			expectErr:      true,
+		},
+		{
+			name:           "should return error for maxUnavailable greater than 100%",
+			maxSurge:       intOrStr("1"),
+			maxUnavailable: intOrStr("110%"),
+			expectErr:      true,
+		},
+		{
+			name:           "should return error if both maxSurge and maxUnavailable are 0",
+			maxSurge:       intOrStr("0%"),
+			maxUnavailable: intOrStr("0"),
+			expectErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			md := &MachineDeployment{
+				Spec: MachineDeploymentSpec{
+					Strategy: &MachineDeploymentStrategy{
+						Type: RollingUpdateMachineDeploymentStrategyType,
+						RollingUpdate: &MachineRollingUpdateDeployment{
+							MaxSurge:       tt.maxSurge,
+							MaxUnavailable: tt.maxUnavailable,
+						},
+					},
+				},
+			}
+			if tt.expectErr {
+				g.Expect(md.ValidateCreate()).NotTo(Succeed())
+				g.Expect(md.ValidateUpdate(md)).NotTo(Succeed())
+			} else {
+				g.Expect(md.ValidateCreate()).To(Succeed())
+				g.Expect(md.ValidateUpdate(md)).To(Succeed())
+			}
+		})
+	}
+}
+
+func TestMachineDeploymentVersionValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		version   string
+		expectErr bool
+	}{
+		{
+			name:      "should succeed when given a valid semantic version with prepended 'v'",
+			version:   "v1.17.2",
+			expectErr: false,
+		},
+		{
+			name:      "should succeed when given a valid semantic version without 'v'",
+			version:   "1.17.2",
+			expectErr: false,
+		},
+		{
+			name:      "should succeed when given a valid semantic version with a pre-release",
+			version:   "v1.21.0-beta.0",
+			expectErr: false,
+		},
+		{
+			name:      "should return error when given an invalid semantic version",
+			version:   "1",
+			expectErr: true,
+		},
+		{
+			name:      "should return error when given a version which is not a semantic version",
+			version:   "latest",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			md := &MachineDeployment{
+				Spec: MachineDeploymentSpec{
+					Template: MachineTemplateSpec{
+						Spec: MachineSpec{
+							Version: pointer.StringPtr(tt.version),
+						},
+					},
+				},
+			}
+
+			if tt.expectErr {
+				err := md.ValidateCreate()
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring("spec.template.spec.version"))
+				g.Expect(md.ValidateUpdate(md)).NotTo(Succeed())
+			} else {
+				g.Expect(md.ValidateCreate()).To(Succeed())
+				g.Expect(md.ValidateUpdate(md)).To(Succeed())
+			}
+		})
+	}
+}
+
 func TestMachineDeploymentWithSpec(t *testing.T) {
 	g := NewWithT(t)
 	md := MachineDeployment{
@@ -132,6 +277,105 @@ func TestMachineDeploymentWithSpec(t *testing.T) {
 	g.Expect(md.Spec.Template.Labels).To(HaveKeyWithValue(ClusterLabelName, "test-cluster"))
 }
 
+func TestMachineDeploymentDefaultTemplateClusterName(t *testing.T) {
+	g := NewWithT(t)
+	md := &MachineDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-md",
+		},
+		Spec: MachineDeploymentSpec{
+			ClusterName: "test-cluster",
+		},
+	}
+	t.Run("for MachineDeployment", utildefaulting.DefaultValidateTest(md))
+	md.Default()
+
+	g.Expect(md.Labels).To(HaveKeyWithValue(ClusterLabelName, "test-cluster"))
+	g.Expect(md.Spec.Template.Spec.ClusterName).To(Equal("test-cluster"))
+}
+
+func TestMachineDeploymentClusterNameValidation(t *testing.T) {
+	tests := []struct {
+		name                string
+		labels              map[string]string
+		selectors           map[string]string
+		templateLabels      map[string]string
+		templateClusterName string
+		expectErr           bool
+	}{
+		{
+			name:                "should succeed when everything matches spec.clusterName",
+			labels:              map[string]string{ClusterLabelName: "foo"},
+			selectors:           map[string]string{ClusterLabelName: "foo"},
+			templateLabels:      map[string]string{ClusterLabelName: "foo"},
+			templateClusterName: "foo",
+			expectErr:           false,
+		},
+		{
+			name:      "should succeed when cluster-name labels are not set",
+			selectors: map[string]string{"app": "md"},
+			templateLabels: map[string]string{
+				"app": "md",
+			},
+			expectErr: false,
+		},
+		{
+			name:      "should return error when the object label does not match",
+			labels:    map[string]string{ClusterLabelName: "bar"},
+			expectErr: true,
+		},
+		{
+			name:           "should return error when the selector and template labels do not match",
+			selectors:      map[string]string{ClusterLabelName: "bar"},
+			templateLabels: map[string]string{ClusterLabelName: "bar"},
+			expectErr:      true,
+		},
+		{
+			name:           "should return error when only the template label does not match",
+			templateLabels: map[string]string{ClusterLabelName: "bar"},
+			expectErr:      true,
+		},
+		{
+			name:                "should return error when template clusterName does not match",
+			templateClusterName: "bar",
+			expectErr:           true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			md := &MachineDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: tt.labels,
+				},
+				Spec: MachineDeploymentSpec{
+					ClusterName: "foo",
+					Selector: metav1.LabelSelector{
+						MatchLabels: tt.selectors,
+					},
+					Template: MachineTemplateSpec{
+						ObjectMeta: ObjectMeta{
+							Labels: tt.templateLabels,
+						},
+						Spec: MachineSpec{
+							ClusterName: tt.templateClusterName,
+						},
+					},
+				},
+			}
+
+			if tt.expectErr {
+				g.Expect(md.ValidateCreate()).NotTo(Succeed())
+				g.Expect(md.ValidateUpdate(md)).NotTo(Succeed())
+			} else {
+				g.Expect(md.ValidateCreate()).To(Succeed())
+				g.Expect(md.ValidateUpdate(md)).To(Succeed())
+			}
+		})
+	}
+}
+
 func TestMachineDeploymentClusterNameImmutable(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -45,6 +45,14 @@ type MachineHealthCheckSpec struct {
 	// +optional
 	MaxUnhealthy *intstr.IntOrString `json:"maxUnhealthy,omitempty"`
 
+	// Any further remediation of machines in a failure domain is only allowed if at most
+	// "MaxUnhealthyPerFailureDomain" machines selected by "selector" in the same failure domain are not healthy.
+	// Percentages are calculated on the number of machines in the failure domain; machines without
+	// a failure domain are considered as a failure domain on their own.
+	// This limit applies in addition to MaxUnhealthy and UnhealthyRange.
+	// +optional
+	MaxUnhealthyPerFailureDomain *intstr.IntOrString `json:"maxUnhealthyPerFailureDomain,omitempty"`
+
 	// Any further remediation is only allowed if the number of machines selected by "selector" as not healthy
 	// is within the range of "UnhealthyRange". Takes precedence over MaxUnhealthy.
 	// Eg. "[3-5]" - This means that remediation will be allowed only when:
@@ -59,6 +67,13 @@ type MachineHealthCheckSpec struct {
 	// +optional
 	NodeStartupTimeout *metav1.Duration `json:"nodeStartupTimeout,omitempty"`
 
+	// Machines whose node becomes unhealthy after having been healthy, e.g. because of a reboot or
+	// of maintenance, will be considered to have failed only if the unhealthy conditions reported by
+	// the node last longer than this duration, which replaces the timeouts of the unhealthy conditions.
+	// If not set, the timeouts of the unhealthy conditions apply to all the machines.
+	// +optional
+	NodeRestartTimeout *metav1.Duration `json:"nodeRestartTimeout,omitempty"`
+
 	// RemediationTemplate is a reference to a remediation template
 	// provided by an infrastructure provider.
 	//
@@ -73,7 +88,7 @@ type MachineHealthCheckSpec struct {
 
 // ANCHOR: UnhealthyCondition
 
-// UnhealthyCondition represents a Node condition type and value with a timeout
+// UnhealthyCondition represents a condition type and value with a timeout
 // specified as a duration.  When the named condition has been in the given
 // status for at least the timeout value, a node is considered unhealthy.
 type UnhealthyCondition struct {
@@ -86,8 +101,29 @@ type UnhealthyCondition struct {
 	Status corev1.ConditionStatus `json:"status"`
 
 	Timeout metav1.Duration `json:"timeout"`
+
+	// Source is the object reporting the condition, either the Node (default), the Machine
+	// or the InfrastructureMachine referenced by the Machine.
+	// +optional
+	// +kubebuilder:validation:Enum=Node;Machine;InfrastructureMachine
+	Source UnhealthyConditionSource `json:"source,omitempty"`
 }
 
+// UnhealthyConditionSource defines the object reporting an unhealthy condition.
+type UnhealthyConditionSource string
+
+const (
+	// NodeUnhealthyConditionSource is used for conditions reported by the Node.
+	NodeUnhealthyConditionSource = UnhealthyConditionSource("Node")
+
+	// MachineUnhealthyConditionSource is used for conditions reported by the Machine.
+	MachineUnhealthyConditionSource = UnhealthyConditionSource("Machine")
+
+	// InfrastructureMachineUnhealthyConditionSource is used for conditions reported by the InfrastructureMachine
+	// referenced by the Machine, e.g. a condition reporting the status of the instance in the cloud provider.
+	InfrastructureMachineUnhealthyConditionSource = UnhealthyConditionSource("InfrastructureMachine")
+)
+
 // ANCHOR_END: UnhealthyCondition
 
 // ANCHOR: MachineHealthCheckStatus
@@ -102,6 +138,18 @@ type MachineHealthCheckStatus struct {
 	// +kubebuilder:validation:Minimum=0
 	CurrentHealthy int32 `json:"currentHealthy,omitempty"`
 
+	// StartingMachines is the number of machines without a node which are not yet considered
+	// unhealthy, because the nodeStartupTimeout has not expired yet.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	StartingMachines int32 `json:"startingMachines,omitempty"`
+
+	// RestartingMachines is the number of machines whose node became unhealthy after having been healthy
+	// which are not yet considered unhealthy, because the nodeRestartTimeout has not expired yet.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	RestartingMachines int32 `json:"restartingMachines,omitempty"`
+
 	// RemediationsAllowed is the number of further remediations allowed by this machine health check before
 	// maxUnhealthy short circuiting will be applied
 	// +kubebuilder:validation:Minimum=0
@@ -18,6 +18,8 @@ package v1alpha4
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
 	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -137,24 +139,56 @@ func (m *MachineHealthCheck) validate(old *MachineHealthCheck) error {
 		)
 	}
 
-	if m.Spec.MaxUnhealthy != nil {
-		if _, err := intstr.GetValueFromIntOrPercent(m.Spec.MaxUnhealthy, 0, false); err != nil {
-			allErrs = append(
-				allErrs,
-				field.Invalid(field.NewPath("spec", "maxUnhealthy"), m.Spec.MaxUnhealthy, "must be either an int or a percentage"),
-			)
-		} else if m.Spec.MaxUnhealthy.Type == intstr.String {
-			if len(validation.IsValidPercent(m.Spec.MaxUnhealthy.StrVal)) != 0 {
-				allErrs = append(
-					allErrs,
-					field.Invalid(field.NewPath("spec", "maxUnhealthy"), m.Spec.MaxUnhealthy, "must be either an int or a percentage"),
-				)
-			}
-		}
+	if m.Spec.NodeRestartTimeout != nil && m.Spec.NodeRestartTimeout.Duration < 0 {
+		allErrs = append(
+			allErrs,
+			field.Invalid(field.NewPath("spec", "nodeRestartTimeout"), m.Spec.NodeRestartTimeout.Seconds(), "must not be negative"),
+		)
 	}
 
+	allErrs = append(allErrs, validateMaxUnhealthy(m.Spec.MaxUnhealthy, field.NewPath("spec", "maxUnhealthy"))...)
+	allErrs = append(allErrs, validateMaxUnhealthy(m.Spec.MaxUnhealthyPerFailureDomain, field.NewPath("spec", "maxUnhealthyPerFailureDomain"))...)
+	allErrs = append(allErrs, validateUnhealthyRange(m.Spec.UnhealthyRange, field.NewPath("spec", "unhealthyRange"))...)
+
 	if len(allErrs) == 0 {
 		return nil
 	}
 	return apierrors.NewInvalid(GroupVersion.WithKind("MachineHealthCheck").GroupKind(), m.Name, allErrs)
 }
+
+// validateMaxUnhealthy validates that a max unhealthy value is either an int or a percentage.
+func validateMaxUnhealthy(maxUnhealthy *intstr.IntOrString, fldPath *field.Path) field.ErrorList {
+	if maxUnhealthy == nil {
+		return nil
+	}
+	if _, err := intstr.GetValueFromIntOrPercent(maxUnhealthy, 0, false); err != nil {
+		return field.ErrorList{field.Invalid(fldPath, maxUnhealthy, "must be either an int or a percentage")}
+	}
+	if maxUnhealthy.Type == intstr.String && len(validation.IsValidPercent(maxUnhealthy.StrVal)) != 0 {
+		return field.ErrorList{field.Invalid(fldPath, maxUnhealthy, "must be either an int or a percentage")}
+	}
+	return nil
+}
+
+// unhealthyRangeRegexp matches an unhealthy range, e.g. "[3-5]".
+var unhealthyRangeRegexp = regexp.MustCompile(`^\[([0-9]+)-([0-9]+)\]$`)
+
+// validateUnhealthyRange validates that an unhealthy range is in the form "[min-max]", with min not greater than max.
+func validateUnhealthyRange(unhealthyRange *string, fldPath *field.Path) field.ErrorList {
+	if unhealthyRange == nil {
+		return nil
+	}
+	parts := unhealthyRangeRegexp.FindStringSubmatch(*unhealthyRange)
+	if parts == nil {
+		return field.ErrorList{field.Invalid(fldPath, *unhealthyRange, "must be in the form [min-max]")}
+	}
+	min, minErr := strconv.ParseUint(parts[1], 10, 32)
+	max, maxErr := strconv.ParseUint(parts[2], 10, 32)
+	if minErr != nil || maxErr != nil {
+		return field.ErrorList{field.Invalid(fldPath, *unhealthyRange, "min and max must be valid 32-bit unsigned integers")}
+	}
+	if max < min {
+		return field.ErrorList{field.Invalid(fldPath, *unhealthyRange, "max cannot be less than min")}
+	}
+	return nil
+}
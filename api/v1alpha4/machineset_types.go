@@ -86,8 +86,8 @@ type MachineTemplateSpec struct {
 type MachineSetDeletePolicy string
 
 const (
-	// RandomMachineSetDeletePolicy prioritizes both Machines that have the annotation
-	// "cluster.x-k8s.io/delete-machine=yes" and Machines that are unhealthy
+	// RandomMachineSetDeletePolicy prioritizes Machines that have the annotation
+	// "cluster.x-k8s.io/delete-machine=yes", and then Machines that are unhealthy
 	// (Status.FailureReason or Status.FailureMessage are set to a non-empty value).
 	// Finally, it picks Machines at random to delete.
 	RandomMachineSetDeletePolicy MachineSetDeletePolicy = "Random"
@@ -157,6 +157,10 @@ type MachineSetStatus struct {
 	FailureReason *capierrors.MachineSetStatusError `json:"failureReason,omitempty"`
 	// +optional
 	FailureMessage *string `json:"failureMessage,omitempty"`
+
+	// Conditions defines current service state of the MachineSet.
+	// +optional
+	Conditions Conditions `json:"conditions,omitempty"`
 }
 
 // ANCHOR_END: MachineSetStatus
@@ -202,6 +206,14 @@ type MachineSet struct {
 	Status MachineSetStatus `json:"status,omitempty"`
 }
 
+func (m *MachineSet) GetConditions() Conditions {
+	return m.Status.Conditions
+}
+
+func (m *MachineSet) SetConditions(conditions Conditions) {
+	m.Status.Conditions = conditions
+}
+
 // +kubebuilder:object:root=true
 
 // MachineSetList contains a list of MachineSet.
@@ -19,19 +19,49 @@ package v1alpha3
 import (
 	apiconversion "k8s.io/apimachinery/pkg/conversion"
 	kubeadmbootstrapv1alpha4 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha4"
+	utilconversion "sigs.k8s.io/cluster-api/util/conversion"
 	"sigs.k8s.io/controller-runtime/pkg/conversion"
 )
 
 // ConvertTo converts this KubeadmConfig to the Hub version (v1alpha4).
 func (src *KubeadmConfig) ConvertTo(dstRaw conversion.Hub) error {
 	dst := dstRaw.(*kubeadmbootstrapv1alpha4.KubeadmConfig)
-	return Convert_v1alpha3_KubeadmConfig_To_v1alpha4_KubeadmConfig(src, dst, nil)
+
+	if err := Convert_v1alpha3_KubeadmConfig_To_v1alpha4_KubeadmConfig(src, dst, nil); err != nil {
+		return err
+	}
+
+	// Manually restore data.
+	restored := &kubeadmbootstrapv1alpha4.KubeadmConfig{}
+	if ok, err := utilconversion.UnmarshalData(src, restored); err != nil || !ok {
+		return err
+	}
+
+	dst.Spec.ContainerRuntime = restored.Spec.ContainerRuntime
+	dst.Spec.Proxy = restored.Spec.Proxy
+	dst.Spec.AdditionalCACertificates = restored.Spec.AdditionalCACertificates
+	dst.Spec.Patches = restored.Spec.Patches
+	restoreFileSources(dst.Spec.Files, restored.Spec.Files)
+	restoreIgnorePreflightErrors(&dst.Spec, &restored.Spec)
+	dst.Status.DataSecretHash = restored.Status.DataSecretHash
+
+	return nil
 }
 
 // ConvertFrom converts from the KubeadmConfig Hub version (v1alpha4) to this version.
 func (dst *KubeadmConfig) ConvertFrom(srcRaw conversion.Hub) error {
 	src := srcRaw.(*kubeadmbootstrapv1alpha4.KubeadmConfig)
-	return Convert_v1alpha4_KubeadmConfig_To_v1alpha3_KubeadmConfig(src, dst, nil)
+
+	if err := Convert_v1alpha4_KubeadmConfig_To_v1alpha3_KubeadmConfig(src, dst, nil); err != nil {
+		return err
+	}
+
+	// Preserve Hub data on down-conversion except for metadata
+	if err := utilconversion.MarshalData(src, dst); err != nil {
+		return err
+	}
+
+	return nil
 }
 
 // ConvertTo converts this KubeadmConfigList to the Hub version (v1alpha4).
@@ -49,13 +79,41 @@ func (dst *KubeadmConfigList) ConvertFrom(srcRaw conversion.Hub) error {
 // ConvertTo converts this KubeadmConfigTemplate to the Hub version (v1alpha4).
 func (src *KubeadmConfigTemplate) ConvertTo(dstRaw conversion.Hub) error {
 	dst := dstRaw.(*kubeadmbootstrapv1alpha4.KubeadmConfigTemplate)
-	return Convert_v1alpha3_KubeadmConfigTemplate_To_v1alpha4_KubeadmConfigTemplate(src, dst, nil)
+
+	if err := Convert_v1alpha3_KubeadmConfigTemplate_To_v1alpha4_KubeadmConfigTemplate(src, dst, nil); err != nil {
+		return err
+	}
+
+	// Manually restore data.
+	restored := &kubeadmbootstrapv1alpha4.KubeadmConfigTemplate{}
+	if ok, err := utilconversion.UnmarshalData(src, restored); err != nil || !ok {
+		return err
+	}
+
+	dst.Spec.Template.Spec.ContainerRuntime = restored.Spec.Template.Spec.ContainerRuntime
+	dst.Spec.Template.Spec.Proxy = restored.Spec.Template.Spec.Proxy
+	dst.Spec.Template.Spec.AdditionalCACertificates = restored.Spec.Template.Spec.AdditionalCACertificates
+	dst.Spec.Template.Spec.Patches = restored.Spec.Template.Spec.Patches
+	restoreFileSources(dst.Spec.Template.Spec.Files, restored.Spec.Template.Spec.Files)
+	restoreIgnorePreflightErrors(&dst.Spec.Template.Spec, &restored.Spec.Template.Spec)
+
+	return nil
 }
 
 // ConvertFrom converts from the KubeadmConfigTemplate Hub version (v1alpha4) to this version.
 func (dst *KubeadmConfigTemplate) ConvertFrom(srcRaw conversion.Hub) error {
 	src := srcRaw.(*kubeadmbootstrapv1alpha4.KubeadmConfigTemplate)
-	return Convert_v1alpha4_KubeadmConfigTemplate_To_v1alpha3_KubeadmConfigTemplate(src, dst, nil)
+
+	if err := Convert_v1alpha4_KubeadmConfigTemplate_To_v1alpha3_KubeadmConfigTemplate(src, dst, nil); err != nil {
+		return err
+	}
+
+	// Preserve Hub data on down-conversion except for metadata
+	if err := utilconversion.MarshalData(src, dst); err != nil {
+		return err
+	}
+
+	return nil
 }
 
 // ConvertTo converts this KubeadmConfigTemplateList to the Hub version (v1alpha3).
@@ -75,3 +133,65 @@ func Convert_v1alpha3_KubeadmConfigStatus_To_v1alpha4_KubeadmConfigStatus(in *Ku
 	// KubeadmConfigStatus.BootstrapData has been removed in v1alpha4 because its content has been moved to the bootstrap data secret, value will be lost during conversion.
 	return autoConvert_v1alpha3_KubeadmConfigStatus_To_v1alpha4_KubeadmConfigStatus(in, out, s)
 }
+
+// Convert_v1alpha4_KubeadmConfigStatus_To_v1alpha3_KubeadmConfigStatus is an autogenerated conversion function.
+func Convert_v1alpha4_KubeadmConfigStatus_To_v1alpha3_KubeadmConfigStatus(in *kubeadmbootstrapv1alpha4.KubeadmConfigStatus, out *KubeadmConfigStatus, s apiconversion.Scope) error {
+	// KubeadmConfigStatus.DataSecretHash does not exist in v1alpha3, it is preserved in the conversion data annotation.
+	return autoConvert_v1alpha4_KubeadmConfigStatus_To_v1alpha3_KubeadmConfigStatus(in, out, s)
+}
+
+// Convert_v1alpha4_KubeadmConfigSpec_To_v1alpha3_KubeadmConfigSpec is an autogenerated conversion function.
+func Convert_v1alpha4_KubeadmConfigSpec_To_v1alpha3_KubeadmConfigSpec(in *kubeadmbootstrapv1alpha4.KubeadmConfigSpec, out *KubeadmConfigSpec, s apiconversion.Scope) error {
+	// KubeadmConfigSpec.ContainerRuntime, KubeadmConfigSpec.Proxy, KubeadmConfigSpec.AdditionalCACertificates and KubeadmConfigSpec.Patches
+	// do not exist in v1alpha3, they are preserved in the conversion data annotation.
+	return autoConvert_v1alpha4_KubeadmConfigSpec_To_v1alpha3_KubeadmConfigSpec(in, out, s)
+}
+
+// Convert_v1alpha3_FileSource_To_v1alpha4_FileSource converts from the v1alpha3 FileSource, where Secret is the only source.
+func Convert_v1alpha3_FileSource_To_v1alpha4_FileSource(in *FileSource, out *kubeadmbootstrapv1alpha4.FileSource, s apiconversion.Scope) error {
+	if err := autoConvert_v1alpha3_FileSource_To_v1alpha4_FileSource(in, out, s); err != nil {
+		return err
+	}
+	out.Secret = &kubeadmbootstrapv1alpha4.SecretFileSource{}
+	return Convert_v1alpha3_SecretFileSource_To_v1alpha4_SecretFileSource(&in.Secret, out.Secret, s)
+}
+
+// Convert_v1alpha4_FileSource_To_v1alpha3_FileSource converts to the v1alpha3 FileSource.
+func Convert_v1alpha4_FileSource_To_v1alpha3_FileSource(in *kubeadmbootstrapv1alpha4.FileSource, out *FileSource, s apiconversion.Scope) error {
+	// FileSource.ConfigMap does not exist in v1alpha3, it is preserved in the conversion data annotation.
+	if err := autoConvert_v1alpha4_FileSource_To_v1alpha3_FileSource(in, out, s); err != nil {
+		return err
+	}
+	if in.Secret != nil {
+		return Convert_v1alpha4_SecretFileSource_To_v1alpha3_SecretFileSource(in.Secret, &out.Secret, s)
+	}
+	return nil
+}
+
+// restoreFileSources restores the file sources which can't be represented in v1alpha3, as long as the
+// files have not been changed since the conversion data annotation was written.
+func restoreFileSources(dst, restored []kubeadmbootstrapv1alpha4.File) {
+	if len(dst) != len(restored) {
+		return
+	}
+	for i := range dst {
+		if dst[i].Path != restored[i].Path || dst[i].ContentFrom == nil || restored[i].ContentFrom == nil {
+			continue
+		}
+		if restored[i].ContentFrom.Secret == nil {
+			dst[i].ContentFrom.Secret = nil
+		}
+		dst[i].ContentFrom.ConfigMap = restored[i].ContentFrom.ConfigMap
+	}
+}
+
+// restoreIgnorePreflightErrors restores the NodeRegistrationOptions.IgnorePreflightErrors fields, which can't be represented
+// in v1alpha3 because it uses the kubeadm v1beta1 types.
+func restoreIgnorePreflightErrors(dst, restored *kubeadmbootstrapv1alpha4.KubeadmConfigSpec) {
+	if dst.InitConfiguration != nil && restored.InitConfiguration != nil {
+		dst.InitConfiguration.NodeRegistration.IgnorePreflightErrors = restored.InitConfiguration.NodeRegistration.IgnorePreflightErrors
+	}
+	if dst.JoinConfiguration != nil && restored.JoinConfiguration != nil {
+		dst.JoinConfiguration.NodeRegistration.IgnorePreflightErrors = restored.JoinConfiguration.NodeRegistration.IgnorePreflightErrors
+	}
+}
@@ -59,3 +59,21 @@ const (
 	// an error while while retrieving certificates for a joining node.
 	CertificatesCorruptedReason = "CertificatesCorrupted"
 )
+
+const (
+	// CertificatesNotExpiringCondition documents that the cluster CA certificates and the machine certificates
+	// are not going to expire within the renewal threshold.
+	//
+	// NOTE: This condition is set only if the KubeadmConfig controller is configured with a certificates renewal threshold.
+	// Machine certificates are checked only for control plane Machines, reading the expiry of the serving certificate
+	// of the API server running on the Machine; the kubelet rotates its own certificates on the other Machines.
+	CertificatesNotExpiringCondition clusterv1.ConditionType = "CertificatesNotExpiring"
+
+	// CertificatesExpiringReason (Severity=Warning) documents a KubeadmConfig controller detecting certificates
+	// going to expire within the renewal threshold; the condition message reports the expiry time of each certificate.
+	CertificatesExpiringReason = "CertificatesExpiring"
+
+	// CertificatesExpiryCheckFailedReason (Severity=Warning) documents a KubeadmConfig controller detecting
+	// an error while checking certificates expiry.
+	CertificatesExpiryCheckFailedReason = "CertificatesExpiryCheckFailed"
+)
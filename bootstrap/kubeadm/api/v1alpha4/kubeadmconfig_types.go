@@ -22,12 +22,16 @@ import (
 )
 
 // Format specifies the output format of the bootstrap data
-// +kubebuilder:validation:Enum=cloud-config
+// +kubebuilder:validation:Enum=cloud-config;cloudbase-init
 type Format string
 
 const (
 	// CloudConfig make the bootstrap data to be of cloud-config format.
 	CloudConfig Format = "cloud-config"
+
+	// CloudbaseInit make the bootstrap data to be of cloud-config format as supported by cloudbase-init,
+	// for joining Windows worker nodes; commands are executed by cloudbase-init, so they can invoke PowerShell.
+	CloudbaseInit Format = "cloudbase-init"
 )
 
 // KubeadmConfigSpec defines the desired state of KubeadmConfig.
@@ -73,7 +77,29 @@ type KubeadmConfigSpec struct {
 	// +optional
 	NTP *NTP `json:"ntp,omitempty"`
 
-	// Format specifies the output format of the bootstrap data
+	// ContainerRuntime specifies the configuration of the container runtime.
+	// +optional
+	ContainerRuntime *ContainerRuntime `json:"containerRuntime,omitempty"`
+
+	// Proxy specifies the HTTP proxy configuration for the kubelet and the container runtime.
+	// +optional
+	Proxy *Proxy `json:"proxy,omitempty"`
+
+	// AdditionalCACertificates specifies PEM encoded CA certificate bundles to be added to the trusted CAs of the machine,
+	// e.g. the CA of a corporate proxy or of a private registry.
+	// +optional
+	AdditionalCACertificates []string `json:"additionalCACertificates,omitempty"`
+
+	// Patches specifies kubeadm patches for the static Pod manifests generated by kubeadm, e.g. to change the flags
+	// or the resources of the API server. The patches are written to the machine and applied by kubeadm init and join
+	// with the --patches flag, which requires Kubernetes v1.19 or newer.
+	// +optional
+	Patches []Patch `json:"patches,omitempty"`
+
+	// Format specifies the output format of the bootstrap data.
+	// The cloudbase-init format can be used only for Windows worker nodes, and it does not support
+	// diskSetup, mounts, ntp, users, containerRuntime, proxy, additionalCACertificates, patches
+	// and useExperimentalRetryJoin.
 	// +optional
 	Format Format `json:"format,omitempty"`
 
@@ -105,6 +131,11 @@ type KubeadmConfigStatus struct {
 	// +optional
 	DataSecretName *string `json:"dataSecretName,omitempty"`
 
+	// DataSecretHash is the hash of the KubeadmConfig spec used to generate the bootstrap data; it is used to detect
+	// changes to the spec happening after the bootstrap data has been generated.
+	// +optional
+	DataSecretHash string `json:"dataSecretHash,omitempty"`
+
 	// FailureReason will be set on non-retryable errors
 	// +optional
 	FailureReason string `json:"failureReason,omitempty"`
@@ -201,7 +232,12 @@ type File struct {
 // sources of data for target systems should add them here.
 type FileSource struct {
 	// Secret represents a secret that should populate this file.
-	Secret SecretFileSource `json:"secret"`
+	// +optional
+	Secret *SecretFileSource `json:"secret,omitempty"`
+
+	// ConfigMap represents a config map that should populate this file.
+	// +optional
+	ConfigMap *ConfigMapFileSource `json:"configMap,omitempty"`
 }
 
 // Adapts a Secret into a FileSource.
@@ -216,6 +252,18 @@ type SecretFileSource struct {
 	Key string `json:"key"`
 }
 
+// Adapts a ConfigMap into a FileSource.
+//
+// The contents of the target ConfigMap's Data field will be presented
+// as files using the keys in the Data field as the file names.
+type ConfigMapFileSource struct {
+	// Name of the config map in the KubeadmBootstrapConfig's namespace to use.
+	Name string `json:"name"`
+
+	// Key is the key in the config map's data map for this value.
+	Key string `json:"key"`
+}
+
 // User defines the input for a generated user in cloud-init.
 type User struct {
 	// Name specifies the user name
@@ -273,6 +321,126 @@ type NTP struct {
 	Enabled *bool `json:"enabled,omitempty"`
 }
 
+// DefaultContainerdConfigPath is the default path of the containerd configuration file.
+const DefaultContainerdConfigPath = "/etc/containerd/config.toml"
+
+// ContainerRuntime defines input for the generated container runtime configuration in cloud-init.
+type ContainerRuntime struct {
+	// Containerd specifies the containerd configuration.
+	// +optional
+	Containerd *Containerd `json:"containerd,omitempty"`
+}
+
+// Containerd defines input for the generated containerd configuration file in cloud-init.
+// The configuration file replaces the one existing on the machine image, and containerd is restarted
+// after PreKubeadmCommands and before kubeadm runs, so the configuration is in effect when the kubelet starts.
+type Containerd struct {
+	// ConfigPath specifies the path of the containerd configuration file.
+	// Defaults to /etc/containerd/config.toml.
+	// +optional
+	ConfigPath string `json:"configPath,omitempty"`
+
+	// SandboxImage specifies the image to use for the pod sandbox (pause) containers, e.g. k8s.gcr.io/pause:3.2.
+	// +optional
+	SandboxImage string `json:"sandboxImage,omitempty"`
+
+	// RegistryMirrors specifies the mirrors to use when pulling images from a registry.
+	// +optional
+	RegistryMirrors []RegistryMirror `json:"registryMirrors,omitempty"`
+
+	// AdditionalConfig specifies a TOML snippet to append to the generated containerd configuration file,
+	// using the version 2 configuration format. The snippet must not redefine the tables generated for
+	// the other fields, i.e. [plugins."io.containerd.grpc.v1.cri"] and its registry mirror tables.
+	// +optional
+	AdditionalConfig string `json:"additionalConfig,omitempty"`
+}
+
+// RegistryMirror defines the mirrors of a registry.
+type RegistryMirror struct {
+	// Registry specifies the name of the registry, e.g. docker.io.
+	Registry string `json:"registry"`
+
+	// Endpoints specifies the endpoints of the mirrors, e.g. https://mirror.example.com, in order of preference.
+	// +kubebuilder:validation:MinItems=1
+	Endpoints []string `json:"endpoints"`
+}
+
+const (
+	// KubeletProxyDropInPath is the path of the systemd drop-in with the HTTP proxy configuration for the kubelet.
+	KubeletProxyDropInPath = "/etc/systemd/system/kubelet.service.d/http-proxy.conf"
+
+	// ContainerdProxyDropInPath is the path of the systemd drop-in with the HTTP proxy configuration for containerd.
+	ContainerdProxyDropInPath = "/etc/systemd/system/containerd.service.d/http-proxy.conf"
+)
+
+// Proxy defines input for the generated HTTP proxy configuration in cloud-init.
+// The configuration is written as systemd drop-ins for the kubelet and containerd services, and containerd is restarted
+// after PreKubeadmCommands and before kubeadm runs, so the configuration is in effect when the kubelet starts.
+type Proxy struct {
+	// HTTPProxy specifies the proxy to use for HTTP requests, e.g. http://proxy.example.com:3128.
+	// +optional
+	HTTPProxy string `json:"httpProxy,omitempty"`
+
+	// HTTPSProxy specifies the proxy to use for HTTPS requests, e.g. http://proxy.example.com:3128.
+	// +optional
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+
+	// NoProxy specifies the hosts, domains and CIDRs which should be excluded from proxying,
+	// e.g. the service and pod CIDRs and the control plane endpoint.
+	// +optional
+	NoProxy []string `json:"noProxy,omitempty"`
+}
+
+// KubeadmPatchesDirectory is the directory where the kubeadm patches are written to.
+const KubeadmPatchesDirectory = "/run/kubeadm/patches"
+
+// PatchTarget is the component a kubeadm patch applies to.
+type PatchTarget string
+
+const (
+	// PatchTargetKubeAPIServer targets the kube-apiserver static Pod manifest.
+	PatchTargetKubeAPIServer = PatchTarget("kube-apiserver")
+
+	// PatchTargetKubeControllerManager targets the kube-controller-manager static Pod manifest.
+	PatchTargetKubeControllerManager = PatchTarget("kube-controller-manager")
+
+	// PatchTargetKubeScheduler targets the kube-scheduler static Pod manifest.
+	PatchTargetKubeScheduler = PatchTarget("kube-scheduler")
+
+	// PatchTargetEtcd targets the etcd static Pod manifest.
+	PatchTargetEtcd = PatchTarget("etcd")
+)
+
+// PatchType is the type of a kubeadm patch.
+type PatchType string
+
+const (
+	// PatchTypeStrategic is a strategic merge patch.
+	PatchTypeStrategic = PatchType("strategic")
+
+	// PatchTypeMerge is a JSON merge patch.
+	PatchTypeMerge = PatchType("merge")
+
+	// PatchTypeJSON is a JSON patch.
+	PatchTypeJSON = PatchType("json")
+)
+
+// Patch defines a kubeadm patch for a static Pod manifest.
+// Patches are applied in the order they are defined.
+type Patch struct {
+	// Target is the component the patch applies to.
+	// +kubebuilder:validation:Enum=kube-apiserver;kube-controller-manager;kube-scheduler;etcd
+	Target PatchTarget `json:"target"`
+
+	// Type is the type of the patch. Defaults to strategic.
+	// +kubebuilder:validation:Enum=strategic;merge;json
+	// +optional
+	Type PatchType `json:"type,omitempty"`
+
+	// Content is the patch, in YAML or JSON.
+	Content string `json:"content"`
+}
+
 // DiskSetup defines input for generated disk_setup and fs_setup in cloud-init.
 type DiskSetup struct {
 	// Partitions specifies the list of the partitions to setup.
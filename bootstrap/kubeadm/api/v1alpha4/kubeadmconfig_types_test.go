@@ -27,6 +27,18 @@ import (
 // These tests are written in BDD-style using Ginkgo framework. Refer to
 // http://onsi.github.io/ginkgo to learn more.
 
+const testCACertificate = `-----BEGIN CERTIFICATE-----
+MIIBezCCASGgAwIBAgIUSC6srXSkttSVbQYFBRgOjq2lrT0wCgYIKoZIzj0EAwIw
+EjEQMA4GA1UEAwwHdGVzdC1jYTAgFw0yNjEwMTYyMzI1MzVaGA8yMTI2MDkyMjIz
+MjUzNVowEjEQMA4GA1UEAwwHdGVzdC1jYTBZMBMGByqGSM49AgEGCCqGSM49AwEH
+A0IABFQhRgD6o0WRnNndGAti/C4uBmCvr7Kn4BCmgss9HQIOSPYDDiBtClLB31+A
+ZYXaQKnY89R6iTWN/ZURhENhxQ+jUzBRMB0GA1UdDgQWBBQ+IMLI17VNaeJRtbCJ
+N18VmRWaTTAfBgNVHSMEGDAWgBQ+IMLI17VNaeJRtbCJN18VmRWaTTAPBgNVHRMB
+Af8EBTADAQH/MAoGCCqGSM49BAMCA0gAMEUCIQDlMN5bEe/UBuNipIjiFW5Ax6XC
+B9rhWajUcs+vNsyNQQIgG8xqHccBy75QgusPi4c8EUj8rPQ8Jcz9PpeB32oAm+Y=
+-----END CERTIFICATE-----
+`
+
 func TestClusterValidate(t *testing.T) {
 	cases := map[string]struct {
 		in        *KubeadmConfig
@@ -57,7 +69,7 @@ func TestClusterValidate(t *testing.T) {
 					Files: []File{
 						{
 							ContentFrom: &FileSource{
-								Secret: SecretFileSource{
+								Secret: &SecretFileSource{
 									Name: "foo",
 									Key:  "bar",
 								},
@@ -94,7 +106,7 @@ func TestClusterValidate(t *testing.T) {
 					Files: []File{
 						{
 							ContentFrom: &FileSource{
-								Secret: SecretFileSource{
+								Secret: &SecretFileSource{
 									Key: "bar",
 								},
 							},
@@ -115,7 +127,7 @@ func TestClusterValidate(t *testing.T) {
 					Files: []File{
 						{
 							ContentFrom: &FileSource{
-								Secret: SecretFileSource{
+								Secret: &SecretFileSource{
 									Name: "foo",
 								},
 							},
@@ -126,6 +138,107 @@ func TestClusterValidate(t *testing.T) {
 			},
 			expectErr: true,
 		},
+		"valid contentFrom config map": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					Files: []File{
+						{
+							ContentFrom: &FileSource{
+								ConfigMap: &ConfigMapFileSource{
+									Name: "foo",
+									Key:  "bar",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"invalid contentFrom without source": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					Files: []File{
+						{
+							ContentFrom: &FileSource{},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"invalid contentFrom with secret and config map": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					Files: []File{
+						{
+							ContentFrom: &FileSource{
+								Secret: &SecretFileSource{
+									Name: "foo",
+									Key:  "bar",
+								},
+								ConfigMap: &ConfigMapFileSource{
+									Name: "foo",
+									Key:  "bar",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"invalid contentFrom config map without name": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					Files: []File{
+						{
+							ContentFrom: &FileSource{
+								ConfigMap: &ConfigMapFileSource{
+									Key: "bar",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"invalid contentFrom config map without key": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					Files: []File{
+						{
+							ContentFrom: &FileSource{
+								ConfigMap: &ConfigMapFileSource{
+									Name: "foo",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
 		"invalid with duplicate file path": {
 			in: &KubeadmConfig{
 				ObjectMeta: metav1.ObjectMeta{
@@ -145,6 +258,259 @@ func TestClusterValidate(t *testing.T) {
 			},
 			expectErr: true,
 		},
+		"valid containerd config": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					ContainerRuntime: &ContainerRuntime{
+						Containerd: &Containerd{
+							SandboxImage: "k8s.gcr.io/pause:3.2",
+							RegistryMirrors: []RegistryMirror{
+								{Registry: "docker.io", Endpoints: []string{"https://mirror.example.com"}},
+								{Registry: "quay.io", Endpoints: []string{"https://quay-mirror.example.com"}},
+							},
+						},
+					},
+				},
+			},
+		},
+		"invalid with containerd config path conflicting with a file path": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					Files: []File{
+						{
+							Path:    DefaultContainerdConfigPath,
+							Content: "foo",
+						},
+					},
+					ContainerRuntime: &ContainerRuntime{
+						Containerd: &Containerd{},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"invalid with duplicate registry mirrors": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					ContainerRuntime: &ContainerRuntime{
+						Containerd: &Containerd{
+							RegistryMirrors: []RegistryMirror{
+								{Registry: "docker.io", Endpoints: []string{"https://mirror.example.com"}},
+								{Registry: "docker.io", Endpoints: []string{"https://other-mirror.example.com"}},
+							},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"valid proxy and CA certificates": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					Proxy: &Proxy{
+						HTTPProxy:  "http://proxy.example.com:3128",
+						HTTPSProxy: "https://proxy.example.com:3129",
+						NoProxy:    []string{"localhost", "10.96.0.0/12", ".example.com"},
+					},
+					AdditionalCACertificates: []string{testCACertificate, testCACertificate + testCACertificate},
+				},
+			},
+		},
+		"invalid with proxy URL without scheme": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					Proxy: &Proxy{
+						HTTPProxy: "proxy.example.com:3128",
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"invalid with empty noProxy entry": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					Proxy: &Proxy{
+						NoProxy: []string{"localhost", " "},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"invalid with proxy drop-in path conflicting with a file path": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					Files: []File{
+						{
+							Path:    KubeletProxyDropInPath,
+							Content: "foo",
+						},
+					},
+					Proxy: &Proxy{
+						HTTPProxy: "http://proxy.example.com:3128",
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"invalid with CA certificate not PEM encoded": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					AdditionalCACertificates: []string{"foo"},
+				},
+			},
+			expectErr: true,
+		},
+		"valid patches": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					Patches: []Patch{
+						{
+							Target:  PatchTargetKubeAPIServer,
+							Content: "spec:\n  containers:\n  - name: kube-apiserver\n    resources:\n      requests:\n        cpu: 500m\n",
+						},
+						{
+							Target:  PatchTargetEtcd,
+							Type:    PatchTypeJSON,
+							Content: `[{"op": "add", "path": "/metadata/labels/foo", "value": "bar"}]`,
+						},
+					},
+				},
+			},
+			expectErr: false,
+		},
+		"invalid with empty patch": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					Patches: []Patch{
+						{
+							Target: PatchTargetKubeScheduler,
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"invalid with json patch which is not a list of operations": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					Patches: []Patch{
+						{
+							Target:  PatchTargetKubeControllerManager,
+							Type:    PatchTypeJSON,
+							Content: "metadata:\n  labels:\n    foo: bar\n",
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"valid Windows worker": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					Format:             CloudbaseInit,
+					JoinConfiguration:  &JoinConfiguration{},
+					PreKubeadmCommands: []string{"powershell C:/k/prepare.ps1"},
+					Files: []File{
+						{
+							Path:    "C:/k/prepare.ps1",
+							Content: "foo",
+						},
+					},
+				},
+			},
+			expectErr: false,
+		},
+		"invalid Windows control plane": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					Format:               CloudbaseInit,
+					ClusterConfiguration: &ClusterConfiguration{},
+					InitConfiguration:    &InitConfiguration{},
+				},
+			},
+			expectErr: true,
+		},
+		"invalid Windows control plane join": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					Format: CloudbaseInit,
+					JoinConfiguration: &JoinConfiguration{
+						ControlPlane: &JoinControlPlane{},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"invalid Windows worker with unsupported fields": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					Format:            CloudbaseInit,
+					JoinConfiguration: &JoinConfiguration{},
+					NTP:               &NTP{Servers: []string{"time.example.com"}},
+				},
+			},
+			expectErr: true,
+		},
 	}
 
 	for name, tt := range cases {
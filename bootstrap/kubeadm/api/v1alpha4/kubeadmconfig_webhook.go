@@ -17,21 +17,36 @@ limitations under the License.
 package v1alpha4
 
 import (
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
+	"net/url"
+	"strings"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/yaml"
 )
 
 var (
-	ConflictingFileSourceMsg = "only one of content of contentFrom may be specified for a single file"
-	MissingFileSourceMsg     = "source for file content must be specified if contenFrom is non-nil"
-	MissingSecretNameMsg     = "secret file source must specify non-empty secret name"
-	MissingSecretKeyMsg      = "secret file source must specify non-empty secret key"
-	PathConflictMsg          = "path property must be unique among all files"
+	ConflictingFileSourceMsg        = "only one of content of contentFrom may be specified for a single file"
+	ConflictingContentFromSourceMsg = "only one of secret or configMap may be specified as contentFrom source"
+	MissingFileSourceMsg            = "source for file content must be specified if contenFrom is non-nil"
+	MissingSecretNameMsg            = "secret file source must specify non-empty secret name"
+	MissingSecretKeyMsg             = "secret file source must specify non-empty secret key"
+	MissingConfigMapNameMsg         = "config map file source must specify non-empty config map name"
+	MissingConfigMapKeyMsg          = "config map file source must specify non-empty config map key"
+	PathConflictMsg                 = "path property must be unique among all files"
+	RegistryConflictMsg             = "registry property must be unique among all registry mirrors"
+	InvalidProxyURLMsg              = "proxy must be a valid URL with the http or https scheme"
+	EmptyNoProxyMsg                 = "noProxy entries must not be empty"
+	InvalidCACertificateMsg         = "CA certificate must contain at least one PEM encoded certificate"
+	InvalidPatchMsg                 = "patch content must be a YAML or JSON object, or a list of operations for json patches"
+	WindowsControlPlaneMsg          = "the cloudbase-init format can be used only for Windows worker nodes"
+	WindowsUnsupportedMsg           = "not supported by the cloudbase-init format"
 )
 
 func (c *KubeadmConfig) SetupWebhookWithManager(mgr ctrl.Manager) error {
@@ -76,43 +91,132 @@ func (c *KubeadmConfigSpec) validate(name string) error {
 				),
 			)
 		}
-		// n.b.: if we ever add types besides Secret as a ContentFrom
-		// Source, we must add webhook validation here for one of the
-		// sources being non-nil.
 		if file.ContentFrom != nil {
-			if file.ContentFrom.Secret.Name == "" {
+			allErrs = append(allErrs, file.ContentFrom.validate(field.NewPath("spec", "files", fmt.Sprintf("%d", i), "contentFrom"), file)...)
+		}
+		_, conflict := knownPaths[file.Path]
+		if conflict {
+			allErrs = append(
+				allErrs,
+				field.Invalid(
+					field.NewPath("spec", "files", fmt.Sprintf("%d", i), "path"),
+					file,
+					PathConflictMsg,
+				),
+			)
+		}
+		knownPaths[file.Path] = struct{}{}
+	}
+
+	if c.ContainerRuntime != nil && c.ContainerRuntime.Containerd != nil {
+		containerd := c.ContainerRuntime.Containerd
+		configPath := containerd.ConfigPath
+		if configPath == "" {
+			configPath = DefaultContainerdConfigPath
+		}
+		if _, conflict := knownPaths[configPath]; conflict {
+			allErrs = append(
+				allErrs,
+				field.Invalid(
+					field.NewPath("spec", "containerRuntime", "containerd", "configPath"),
+					configPath,
+					PathConflictMsg,
+				),
+			)
+		}
+
+		knownRegistries := map[string]struct{}{}
+		for i, mirror := range containerd.RegistryMirrors {
+			if _, conflict := knownRegistries[mirror.Registry]; conflict {
 				allErrs = append(
 					allErrs,
 					field.Invalid(
-						field.NewPath("spec", "files", fmt.Sprintf("%d", i), "contentFrom", "secret", "name"),
-						file,
-						MissingSecretNameMsg,
+						field.NewPath("spec", "containerRuntime", "containerd", "registryMirrors", fmt.Sprintf("%d", i), "registry"),
+						mirror.Registry,
+						RegistryConflictMsg,
 					),
 				)
 			}
-			if file.ContentFrom.Secret.Key == "" {
+			knownRegistries[mirror.Registry] = struct{}{}
+		}
+	}
+
+	if c.Proxy != nil {
+		for _, path := range []string{KubeletProxyDropInPath, ContainerdProxyDropInPath} {
+			if _, conflict := knownPaths[path]; conflict {
 				allErrs = append(
 					allErrs,
 					field.Invalid(
-						field.NewPath("spec", "files", fmt.Sprintf("%d", i), "contentFrom", "secret", "key"),
-						file,
-						MissingSecretKeyMsg,
+						field.NewPath("spec", "proxy"),
+						path,
+						PathConflictMsg,
 					),
 				)
 			}
 		}
-		_, conflict := knownPaths[file.Path]
-		if conflict {
+
+		proxies := []struct {
+			name  string
+			value string
+		}{
+			{name: "httpProxy", value: c.Proxy.HTTPProxy},
+			{name: "httpsProxy", value: c.Proxy.HTTPSProxy},
+		}
+		for _, proxy := range proxies {
+			if proxy.value != "" && !isValidProxyURL(proxy.value) {
+				allErrs = append(
+					allErrs,
+					field.Invalid(
+						field.NewPath("spec", "proxy", proxy.name),
+						proxy.value,
+						InvalidProxyURLMsg,
+					),
+				)
+			}
+		}
+
+		for i, noProxy := range c.Proxy.NoProxy {
+			if strings.TrimSpace(noProxy) == "" {
+				allErrs = append(
+					allErrs,
+					field.Invalid(
+						field.NewPath("spec", "proxy", "noProxy", fmt.Sprintf("%d", i)),
+						noProxy,
+						EmptyNoProxyMsg,
+					),
+				)
+			}
+		}
+	}
+
+	for i, caCertificate := range c.AdditionalCACertificates {
+		if !containsPEMCertificate(caCertificate) {
 			allErrs = append(
 				allErrs,
 				field.Invalid(
-					field.NewPath("spec", "files", fmt.Sprintf("%d", i), "path"),
-					file,
-					PathConflictMsg,
+					field.NewPath("spec", "additionalCACertificates", fmt.Sprintf("%d", i)),
+					caCertificate,
+					InvalidCACertificateMsg,
+				),
+			)
+		}
+	}
+
+	for i, patch := range c.Patches {
+		if !isValidPatch(patch) {
+			allErrs = append(
+				allErrs,
+				field.Invalid(
+					field.NewPath("spec", "patches", fmt.Sprintf("%d", i), "content"),
+					patch.Content,
+					InvalidPatchMsg,
 				),
 			)
 		}
-		knownPaths[file.Path] = struct{}{}
+	}
+
+	if c.Format == CloudbaseInit {
+		allErrs = append(allErrs, c.validateWindows()...)
 	}
 
 	if len(allErrs) == 0 {
@@ -120,3 +224,110 @@ func (c *KubeadmConfigSpec) validate(name string) error {
 	}
 	return apierrors.NewInvalid(GroupVersion.WithKind("KubeadmConfig").GroupKind(), name, allErrs)
 }
+
+// validateWindows checks that a KubeadmConfigSpec using the cloudbase-init format is for a worker node,
+// and that it does not use fields which are not supported on Windows.
+func (c *KubeadmConfigSpec) validateWindows() field.ErrorList {
+	var allErrs field.ErrorList
+
+	if c.ClusterConfiguration != nil {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "clusterConfiguration"), WindowsControlPlaneMsg))
+	}
+	if c.InitConfiguration != nil {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "initConfiguration"), WindowsControlPlaneMsg))
+	}
+	if c.JoinConfiguration != nil && c.JoinConfiguration.ControlPlane != nil {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "joinConfiguration", "controlPlane"), WindowsControlPlaneMsg))
+	}
+
+	unsupported := []struct {
+		name string
+		set  bool
+	}{
+		{name: "diskSetup", set: c.DiskSetup != nil},
+		{name: "mounts", set: len(c.Mounts) > 0},
+		{name: "ntp", set: c.NTP != nil},
+		{name: "users", set: len(c.Users) > 0},
+		{name: "containerRuntime", set: c.ContainerRuntime != nil},
+		{name: "proxy", set: c.Proxy != nil},
+		{name: "additionalCACertificates", set: len(c.AdditionalCACertificates) > 0},
+		{name: "patches", set: len(c.Patches) > 0},
+		{name: "useExperimentalRetryJoin", set: c.UseExperimentalRetryJoin},
+	}
+	for _, f := range unsupported {
+		if f.set {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", f.name), WindowsUnsupportedMsg))
+		}
+	}
+	return allErrs
+}
+
+// validate checks that exactly one source is specified, and that it references a key in a named object.
+func (s *FileSource) validate(path *field.Path, file File) field.ErrorList {
+	var allErrs field.ErrorList
+
+	switch {
+	case s.Secret == nil && s.ConfigMap == nil:
+		allErrs = append(allErrs, field.Invalid(path, file, MissingFileSourceMsg))
+	case s.Secret != nil && s.ConfigMap != nil:
+		allErrs = append(allErrs, field.Invalid(path, file, ConflictingContentFromSourceMsg))
+	case s.Secret != nil:
+		if s.Secret.Name == "" {
+			allErrs = append(allErrs, field.Invalid(path.Child("secret", "name"), file, MissingSecretNameMsg))
+		}
+		if s.Secret.Key == "" {
+			allErrs = append(allErrs, field.Invalid(path.Child("secret", "key"), file, MissingSecretKeyMsg))
+		}
+	case s.ConfigMap != nil:
+		if s.ConfigMap.Name == "" {
+			allErrs = append(allErrs, field.Invalid(path.Child("configMap", "name"), file, MissingConfigMapNameMsg))
+		}
+		if s.ConfigMap.Key == "" {
+			allErrs = append(allErrs, field.Invalid(path.Child("configMap", "key"), file, MissingConfigMapKeyMsg))
+		}
+	}
+
+	return allErrs
+}
+
+// isValidProxyURL returns true if the value is an absolute URL with the http or https scheme.
+func isValidProxyURL(value string) bool {
+	u, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// containsPEMCertificate returns true if the value contains at least one PEM encoded certificate,
+// and all the PEM blocks are certificates.
+func containsPEMCertificate(value string) bool {
+	found := false
+	rest := []byte(value)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return false
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// isValidPatch returns true if the patch content can be parsed as required by its type:
+// a list of operations for json patches, an object otherwise.
+func isValidPatch(patch Patch) bool {
+	if patch.Type == PatchTypeJSON {
+		var operations []map[string]interface{}
+		return yaml.Unmarshal([]byte(patch.Content), &operations) == nil && len(operations) > 0
+	}
+	var object map[string]interface{}
+	return yaml.Unmarshal([]byte(patch.Content), &object) == nil && len(object) > 0
+}
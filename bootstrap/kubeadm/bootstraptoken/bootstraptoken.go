@@ -0,0 +1,253 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package bootstraptoken implements helpers for managing the kubeadm bootstrap tokens of a workload cluster.
+package bootstraptoken
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	bootstrapapi "k8s.io/cluster-bootstrap/token/api"
+	bootstraputil "k8s.io/cluster-bootstrap/token/util"
+	"sigs.k8s.io/cluster-api/controllers/remote"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultTTL is the default amount of time a bootstrap token is valid.
+	DefaultTTL = 15 * time.Minute
+
+	// DefaultDescription is the description of the bootstrap tokens generated by Cluster API.
+	DefaultDescription = "token generated by cluster-api-bootstrap-provider-kubeadm"
+
+	// DefaultExtraGroups are the groups the bootstrap tokens generated by Cluster API authenticate as.
+	DefaultExtraGroups = "system:bootstrappers:kubeadm:default-node-token"
+)
+
+// Token describes a bootstrap token stored in a workload cluster.
+type Token struct {
+	// ID is the public part of the token.
+	ID string
+
+	// Expiration is the time after which the token is no longer valid.
+	// It is nil for tokens without an expiration.
+	Expiration *time.Time
+
+	// Description is the human readable description of the token.
+	Description string
+}
+
+// Expired returns true if the token expiration is in the past.
+func (t *Token) Expired() bool {
+	return t.Expiration != nil && t.Expiration.Before(time.Now().UTC())
+}
+
+// Manager creates, lists, refreshes and revokes the bootstrap tokens of a workload cluster.
+type Manager struct {
+	// Client is a client for the workload cluster.
+	Client client.Client
+
+	// TTL is the amount of time created or refreshed tokens are valid; if zero, DefaultTTL is used.
+	TTL time.Duration
+}
+
+// NewManagerForCluster returns a Manager for the workload cluster, connecting to it via its kubeconfig secret.
+func NewManagerForCluster(ctx context.Context, sourceName string, c client.Client, cluster client.ObjectKey, ttl time.Duration) (*Manager, error) {
+	remoteClient, err := remote.NewClusterClient(ctx, sourceName, c, cluster)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create a client for Cluster %q", cluster.String())
+	}
+	return &Manager{Client: remoteClient, TTL: ttl}, nil
+}
+
+func (m *Manager) ttl() time.Duration {
+	if m.TTL == 0 {
+		return DefaultTTL
+	}
+	return m.TTL
+}
+
+// Create generates a new bootstrap token, stores it in the workload cluster and returns it.
+func (m *Manager) Create(ctx context.Context) (string, error) {
+	token, err := bootstraputil.GenerateBootstrapToken()
+	if err != nil {
+		return "", errors.Wrap(err, "unable to generate bootstrap token")
+	}
+
+	tokenID, tokenSecret, err := parse(token)
+	if err != nil {
+		return "", err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      bootstraputil.BootstrapTokenSecretName(tokenID),
+			Namespace: metav1.NamespaceSystem,
+		},
+		Type: bootstrapapi.SecretTypeBootstrapToken,
+		Data: map[string][]byte{
+			bootstrapapi.BootstrapTokenIDKey:               []byte(tokenID),
+			bootstrapapi.BootstrapTokenSecretKey:           []byte(tokenSecret),
+			bootstrapapi.BootstrapTokenExpirationKey:       []byte(m.expiration()),
+			bootstrapapi.BootstrapTokenUsageSigningKey:     []byte("true"),
+			bootstrapapi.BootstrapTokenUsageAuthentication: []byte("true"),
+			bootstrapapi.BootstrapTokenExtraGroupsKey:      []byte(DefaultExtraGroups),
+			bootstrapapi.BootstrapTokenDescriptionKey:      []byte(DefaultDescription),
+		},
+	}
+
+	if err := m.Client.Create(ctx, secret); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Get fetches the Secret storing the token and returns an error if it is invalid.
+func (m *Manager) Get(ctx context.Context, token string) (*corev1.Secret, error) {
+	tokenID, _, err := parse(token)
+	if err != nil {
+		return nil, err
+	}
+
+	secretName := bootstraputil.BootstrapTokenSecretName(tokenID)
+	secret := &corev1.Secret{}
+	if err := m.Client.Get(ctx, client.ObjectKey{Name: secretName, Namespace: metav1.NamespaceSystem}, secret); err != nil {
+		return secret, err
+	}
+
+	if secret.Data == nil {
+		return nil, errors.Errorf("Invalid bootstrap secret %q, remove the token from the kubadm config to re-create", secretName)
+	}
+	return secret, nil
+}
+
+// List returns the bootstrap tokens stored in the workload cluster.
+// If all is false, only the tokens generated by Cluster API are returned.
+func (m *Manager) List(ctx context.Context, all bool) ([]Token, error) {
+	secrets := &corev1.SecretList{}
+	if err := m.Client.List(ctx, secrets, client.InNamespace(metav1.NamespaceSystem)); err != nil {
+		return nil, errors.Wrap(err, "failed to list bootstrap token secrets")
+	}
+
+	tokens := []Token{}
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		if secret.Type != bootstrapapi.SecretTypeBootstrapToken {
+			continue
+		}
+
+		token := Token{
+			ID:          string(secret.Data[bootstrapapi.BootstrapTokenIDKey]),
+			Description: string(secret.Data[bootstrapapi.BootstrapTokenDescriptionKey]),
+		}
+		if !all && token.Description != DefaultDescription {
+			continue
+		}
+
+		expiration, err := Expiration(secret)
+		if err != nil {
+			return nil, err
+		}
+		token.Expiration = expiration
+		tokens = append(tokens, token)
+	}
+	return tokens, nil
+}
+
+// Refresh extends the TTL for an existing token.
+func (m *Manager) Refresh(ctx context.Context, token string) error {
+	secret, err := m.Get(ctx, token)
+	if err != nil {
+		return err
+	}
+	secret.Data[bootstrapapi.BootstrapTokenExpirationKey] = []byte(m.expiration())
+
+	return m.Client.Update(ctx, secret)
+}
+
+// Revoke deletes the token from the workload cluster, so it can no longer be used to join nodes.
+// Revoking a token which does not exist is not an error.
+func (m *Manager) Revoke(ctx context.Context, token string) error {
+	tokenID, _, err := parse(token)
+	if err != nil {
+		return err
+	}
+	return m.RevokeByID(ctx, tokenID)
+}
+
+// RevokeByID deletes the token with the given ID from the workload cluster.
+// Revoking a token which does not exist is not an error.
+func (m *Manager) RevokeByID(ctx context.Context, tokenID string) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      bootstraputil.BootstrapTokenSecretName(tokenID),
+			Namespace: metav1.NamespaceSystem,
+		},
+	}
+	if err := m.Client.Delete(ctx, secret); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to revoke bootstrap token %q", tokenID)
+	}
+	return nil
+}
+
+// ShouldRotate returns true if an existing token is past half of its TTL and should to be rotated.
+func (m *Manager) ShouldRotate(ctx context.Context, token string) (bool, error) {
+	secret, err := m.Get(ctx, token)
+	if err != nil {
+		return false, err
+	}
+
+	expiration, err := Expiration(secret)
+	if err != nil {
+		return false, err
+	}
+	if expiration == nil {
+		return false, nil
+	}
+	return expiration.Before(time.Now().UTC().Add(m.ttl() / 2)), nil
+}
+
+func (m *Manager) expiration() string {
+	return time.Now().UTC().Add(m.ttl()).Format(time.RFC3339)
+}
+
+// Expiration returns the expiration of the token stored in the Secret, or nil if the token does not expire.
+func Expiration(secret *corev1.Secret) (*time.Time, error) {
+	value, ok := secret.Data[bootstrapapi.BootstrapTokenExpirationKey]
+	if !ok || len(value) == 0 {
+		return nil, nil
+	}
+
+	expiration, err := time.Parse(time.RFC3339, string(value))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid expiration for bootstrap secret %q", secret.Name)
+	}
+	return &expiration, nil
+}
+
+// parse splits a token in its ID and secret.
+func parse(token string) (string, string, error) {
+	substrs := bootstraputil.BootstrapTokenRegexp.FindStringSubmatch(token)
+	if len(substrs) != 3 {
+		return "", "", errors.Errorf("the bootstrap token %q was not of the form %q", token, bootstrapapi.BootstrapTokenPattern)
+	}
+	return substrs[1], substrs[2], nil
+}
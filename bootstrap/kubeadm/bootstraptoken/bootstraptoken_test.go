@@ -0,0 +1,137 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstraptoken
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	bootstrapapi "k8s.io/cluster-bootstrap/token/api"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("creates a token with the given TTL", func(t *testing.T) {
+		g := NewWithT(t)
+
+		m := &Manager{Client: fake.NewClientBuilder().Build(), TTL: time.Hour}
+		token, err := m.Create(ctx)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		secret, err := m.Get(ctx, token)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(secret.Type).To(Equal(bootstrapapi.SecretTypeBootstrapToken))
+
+		expiration, err := Expiration(secret)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(*expiration).To(BeTemporally("~", time.Now().Add(time.Hour), time.Minute))
+	})
+
+	t.Run("lists only the tokens generated by Cluster API unless all are requested", func(t *testing.T) {
+		g := NewWithT(t)
+
+		external := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "bootstrap-token-abcdef",
+				Namespace: metav1.NamespaceSystem,
+			},
+			Type: bootstrapapi.SecretTypeBootstrapToken,
+			Data: map[string][]byte{
+				bootstrapapi.BootstrapTokenIDKey:     []byte("abcdef"),
+				bootstrapapi.BootstrapTokenSecretKey: []byte("0123456789abcdef"),
+			},
+		}
+		other := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "other",
+				Namespace: metav1.NamespaceSystem,
+			},
+		}
+
+		m := &Manager{Client: fake.NewClientBuilder().WithObjects(external, other).Build()}
+		_, err := m.Create(ctx)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		tokens, err := m.List(ctx, false)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(tokens).To(HaveLen(1))
+		g.Expect(tokens[0].Description).To(Equal(DefaultDescription))
+		g.Expect(tokens[0].Expired()).To(BeFalse())
+
+		tokens, err = m.List(ctx, true)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(tokens).To(HaveLen(2))
+	})
+
+	t.Run("refreshes and rotates a token", func(t *testing.T) {
+		g := NewWithT(t)
+
+		m := &Manager{Client: fake.NewClientBuilder().Build(), TTL: time.Hour}
+		token, err := m.Create(ctx)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		shouldRotate, err := m.ShouldRotate(ctx, token)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(shouldRotate).To(BeFalse())
+
+		// Move the token close to its expiration.
+		secret, err := m.Get(ctx, token)
+		g.Expect(err).NotTo(HaveOccurred())
+		secret.Data[bootstrapapi.BootstrapTokenExpirationKey] = []byte(time.Now().UTC().Add(time.Minute).Format(time.RFC3339))
+		g.Expect(m.Client.Update(ctx, secret)).To(Succeed())
+
+		shouldRotate, err = m.ShouldRotate(ctx, token)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(shouldRotate).To(BeTrue())
+
+		g.Expect(m.Refresh(ctx, token)).To(Succeed())
+		shouldRotate, err = m.ShouldRotate(ctx, token)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(shouldRotate).To(BeFalse())
+	})
+
+	t.Run("revokes a token", func(t *testing.T) {
+		g := NewWithT(t)
+
+		m := &Manager{Client: fake.NewClientBuilder().Build()}
+		token, err := m.Create(ctx)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		g.Expect(m.Revoke(ctx, token)).To(Succeed())
+		_, err = m.Get(ctx, token)
+		g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		// Revoking a token twice is not an error.
+		g.Expect(m.Revoke(ctx, token)).To(Succeed())
+	})
+
+	t.Run("returns error for malformed tokens", func(t *testing.T) {
+		g := NewWithT(t)
+
+		m := &Manager{Client: fake.NewClientBuilder().Build()}
+		g.Expect(m.Revoke(ctx, "not-a-token")).NotTo(Succeed())
+		g.Expect(m.Refresh(ctx, "not-a-token")).NotTo(Succeed())
+	})
+}
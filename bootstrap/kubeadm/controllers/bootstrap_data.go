@@ -0,0 +1,65 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/pkg/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util/conditions"
+)
+
+// computeSpecHash returns a 32-bit FNV-1a hash of the json representation of a KubeadmConfig spec.
+// NOTE: The json representation is used so the hash does not change when the spec is read back from the API server,
+// e.g. when empty slices are returned as nil.
+func computeSpecHash(spec *bootstrapv1.KubeadmConfigSpec) (string, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal KubeadmConfig spec")
+	}
+	hasher := fnv.New32a()
+	if _, err := hasher.Write(data); err != nil {
+		return "", errors.Wrap(err, "failed to compute KubeadmConfig spec hash")
+	}
+	return fmt.Sprintf("%d", hasher.Sum32()), nil
+}
+
+// reconcileBootstrapDataUpToDate checks if the KubeadmConfig spec changed after the bootstrap data has been generated,
+// surfacing stale bootstrap data in the BootstrapDataUpToDate condition, so the Machine owner can replace the Machine.
+func (r *KubeadmConfigReconciler) reconcileBootstrapDataUpToDate(scope *Scope) error {
+	hash, err := computeSpecHash(&scope.Config.Spec)
+	if err != nil {
+		return err
+	}
+	switch scope.Config.Status.DataSecretHash {
+	case "":
+		// The bootstrap data has been generated before the hash was tracked, or the status has been lost
+		// e.g. during a move; assume the bootstrap data is up to date.
+		scope.Config.Status.DataSecretHash = hash
+		conditions.MarkTrue(scope.Config, clusterv1.BootstrapDataUpToDateCondition)
+	case hash:
+		conditions.MarkTrue(scope.Config, clusterv1.BootstrapDataUpToDateCondition)
+	default:
+		conditions.MarkFalse(scope.Config, clusterv1.BootstrapDataUpToDateCondition, clusterv1.BootstrapDataStaleReason, clusterv1.ConditionSeverityWarning,
+			"The KubeadmConfig spec changed after the bootstrap data has been generated; to roll out the change, update the KubeadmConfigTemplate used by the MachineDeployment instead")
+	}
+	return nil
+}
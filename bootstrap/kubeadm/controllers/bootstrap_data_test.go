@@ -0,0 +1,101 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/test/helpers"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestKubeadmConfigReconciler_Reconcile_BootstrapDataUpToDate(t *testing.T) {
+	tests := []struct {
+		name         string
+		hashSet      bool
+		specChanged  bool
+		wantUpToDate bool
+	}{
+		{
+			name:         "records the hash and reports the bootstrap data up to date if the hash is not set",
+			hashSet:      false,
+			wantUpToDate: true,
+		},
+		{
+			name:         "reports the bootstrap data up to date if the spec did not change",
+			hashSet:      true,
+			wantUpToDate: true,
+		},
+		{
+			name:         "reports stale bootstrap data if the spec changed",
+			hashSet:      true,
+			specChanged:  true,
+			wantUpToDate: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			cluster := newCluster("cluster")
+			cluster.Status.InfrastructureReady = true
+			conditions.MarkTrue(cluster, clusterv1.ControlPlaneInitializedCondition)
+
+			machine := newWorkerMachine(cluster)
+			machine.Spec.Bootstrap.DataSecretName = pointer.StringPtr("secret")
+
+			config := newWorkerJoinKubeadmConfig(machine)
+			config.Status.Ready = true
+			config.Status.DataSecretName = pointer.StringPtr("secret")
+
+			objects := []client.Object{cluster, machine, config}
+			objects = append(objects, createSecrets(t, cluster, config)...)
+			if tt.hashSet {
+				hash, err := computeSpecHash(&config.Spec)
+				g.Expect(err).NotTo(HaveOccurred())
+				config.Status.DataSecretHash = hash
+			}
+			if tt.specChanged {
+				config.Spec.PreKubeadmCommands = []string{"echo changed"}
+			}
+			myclient := helpers.NewFakeClientWithScheme(setupScheme(), objects...)
+
+			k := &KubeadmConfigReconciler{
+				Client: myclient,
+			}
+
+			_, err := k.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(config)})
+			g.Expect(err).NotTo(HaveOccurred())
+
+			gotConfig := &bootstrapv1.KubeadmConfig{}
+			g.Expect(myclient.Get(ctx, client.ObjectKeyFromObject(config), gotConfig)).To(Succeed())
+			g.Expect(conditions.IsTrue(gotConfig, clusterv1.BootstrapDataUpToDateCondition)).To(Equal(tt.wantUpToDate))
+			hash, err := computeSpecHash(&gotConfig.Spec)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(gotConfig.Status.DataSecretHash == hash).To(Equal(tt.wantUpToDate))
+			if !tt.wantUpToDate {
+				g.Expect(conditions.GetReason(gotConfig, clusterv1.BootstrapDataUpToDateCondition)).To(Equal(clusterv1.BootstrapDataStaleReason))
+			}
+		})
+	}
+}
@@ -0,0 +1,309 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/controllers/remote"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/secret"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// defaultAPIServerBindPort is the port the API server binds to when the kubeadm configuration does not set it.
+	defaultAPIServerBindPort = 6443
+
+	// apiServerServerName is the server name used when verifying the API server serving certificate;
+	// kubeadm always includes it in the certificate SANs.
+	apiServerServerName = "kubernetes"
+
+	// apiServerDialTimeout is the timeout for connecting to the API server running on a machine.
+	apiServerDialTimeout = 10 * time.Second
+
+	// certificatesExpiryCheckInterval is the maximum interval between two checks of the certificates expiry.
+	certificatesExpiryCheckInterval = 24 * time.Hour
+
+	// certificatesRotationRequeueAfter is the time to wait before checking again if a machine can be rotated
+	// when another machine in the cluster is already waiting for remediation.
+	certificatesRotationRequeueAfter = time.Minute
+
+	// machineCertificates is the name used for the kubeadm issued machine certificates in condition messages.
+	machineCertificates = "machine"
+)
+
+// apiServerCertificateExpiryGetter returns the expiry time of the serving certificate of the API server running on
+// a node of a workload cluster, verifying the certificate with the given cluster CA certificates.
+type apiServerCertificateExpiryGetter func(ctx context.Context, c client.Client, cluster *clusterv1.Cluster, nodeName string, port int32, caData []byte) (time.Time, error)
+
+// reconcileCertificatesExpiry checks the expiry of the cluster CA certificates and of the machine certificates,
+// surfacing the certificates going to expire within the renewal threshold in the CertificatesNotExpiring condition.
+// If certificates rotation is enabled, machines with expiring certificates are marked for remediation, one machine
+// per cluster at time, so they get replaced by their owner.
+func (r *KubeadmConfigReconciler) reconcileCertificatesExpiry(ctx context.Context, scope *Scope) (ctrl.Result, error) {
+	if r.CertificatesRenewalThreshold <= 0 {
+		return ctrl.Result{}, nil
+	}
+	log := ctrl.LoggerFrom(ctx)
+
+	expiries, err := r.getCertificatesExpiry(ctx, scope)
+	if err != nil {
+		conditions.MarkFalse(scope.Config, bootstrapv1.CertificatesNotExpiringCondition, bootstrapv1.CertificatesExpiryCheckFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return ctrl.Result{}, err
+	}
+
+	now := time.Now()
+	renewBefore := now.Add(r.CertificatesRenewalThreshold)
+	requeueAfter := certificatesExpiryCheckInterval
+	expiring := []string{}
+	for _, name := range sortedKeys(expiries) {
+		expiry := expiries[name]
+		if expiry.Before(renewBefore) {
+			expiring = append(expiring, fmt.Sprintf("%s certificates expire at %s", name, expiry.UTC().Format(time.RFC3339)))
+			continue
+		}
+		if d := expiry.Sub(renewBefore); d < requeueAfter {
+			requeueAfter = d
+		}
+	}
+
+	if len(expiring) == 0 {
+		conditions.MarkTrue(scope.Config, bootstrapv1.CertificatesNotExpiringCondition)
+		return ctrl.Result{RequeueAfter: requeueAfter}, nil
+	}
+	conditions.MarkFalse(scope.Config, bootstrapv1.CertificatesNotExpiringCondition, bootstrapv1.CertificatesExpiringReason, clusterv1.ConditionSeverityWarning, strings.Join(expiring, "; "))
+
+	machineExpiry, ok := expiries[machineCertificates]
+	if !r.RotateMachineCertificates || !ok || !machineExpiry.Before(renewBefore) {
+		return ctrl.Result{RequeueAfter: requeueAfter}, nil
+	}
+
+	rotated, err := r.rotateMachineCertificates(ctx, scope, machineExpiry)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !rotated {
+		log.Info("Waiting for other machines to complete remediation before rotating machine certificates")
+		return ctrl.Result{RequeueAfter: certificatesRotationRequeueAfter}, nil
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// getCertificatesExpiry returns the expiry time of the cluster CA certificates stored in secrets and, if the config
+// owner is a control plane Machine with a Node, of the machine certificates.
+// The certificates issued by kubeadm on a control plane machine are renewed only when the machine is replaced, and
+// their expiry is read from the serving certificate of the API server running on the machine; worker machines only
+// have the kubelet client certificate, which is rotated by the kubelet.
+func (r *KubeadmConfigReconciler) getCertificatesExpiry(ctx context.Context, scope *Scope) (map[string]time.Time, error) {
+	expiries := map[string]time.Time{}
+
+	certificates := secret.Certificates{
+		&secret.Certificate{Purpose: secret.ClusterCA},
+		&secret.Certificate{Purpose: secret.EtcdCA},
+		&secret.Certificate{Purpose: secret.FrontProxyCA},
+	}
+	if err := certificates.Lookup(ctx, r.Client, util.ObjectKey(scope.Cluster)); err != nil {
+		return nil, errors.Wrap(err, "failed to lookup cluster certificates")
+	}
+	for _, certificate := range certificates {
+		// Skip certificates not stored in the management cluster, e.g. the etcd CA when using external etcd.
+		if certificate.KeyPair == nil {
+			continue
+		}
+		expiry, err := certificate.ExpiryTime()
+		if err != nil {
+			return nil, err
+		}
+		expiries[string(certificate.Purpose)] = expiry
+	}
+
+	if !scope.ConfigOwner.IsControlPlaneMachine() {
+		return expiries, nil
+	}
+	nodeName, _, err := unstructured.NestedString(scope.ConfigOwner.Object, "status", "nodeRef", "name")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the node of Machine %s/%s", scope.ConfigOwner.GetNamespace(), scope.ConfigOwner.GetName())
+	}
+	clusterCA := certificates.GetByPurpose(secret.ClusterCA)
+	if nodeName == "" || clusterCA == nil || clusterCA.KeyPair == nil {
+		return expiries, nil
+	}
+
+	expiry, err := r.apiServerCertificateExpiryGetter(ctx, r.Client, scope.Cluster, nodeName, apiServerBindPort(scope.Config), clusterCA.KeyPair.Cert)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the expiry of the certificates of Machine %s/%s", scope.ConfigOwner.GetNamespace(), scope.ConfigOwner.GetName())
+	}
+	expiries[machineCertificates] = expiry
+	return expiries, nil
+}
+
+// apiServerBindPort returns the port the API server of a control plane machine binds to.
+func apiServerBindPort(config *bootstrapv1.KubeadmConfig) int32 {
+	var port int32
+	switch {
+	case config.Spec.JoinConfiguration != nil && config.Spec.JoinConfiguration.ControlPlane != nil:
+		port = config.Spec.JoinConfiguration.ControlPlane.LocalAPIEndpoint.BindPort
+	case config.Spec.InitConfiguration != nil:
+		port = config.Spec.InitConfiguration.LocalAPIEndpoint.BindPort
+	}
+	if port == 0 {
+		return defaultAPIServerBindPort
+	}
+	return port
+}
+
+// getAPIServerCertificateExpiry returns the expiry time of the serving certificate of the API server running on a
+// node of a workload cluster. The API server is reached by port-forwarding to its static pod through the workload
+// cluster API server, given that the nodes are not necessarily reachable from the management cluster.
+func getAPIServerCertificateExpiry(ctx context.Context, c client.Client, cluster *clusterv1.Cluster, nodeName string, port int32, caData []byte) (time.Time, error) {
+	restConfig, err := remote.RESTConfig(ctx, KubeadmConfigControllerName, c, util.ObjectKey(cluster))
+	if err != nil {
+		return time.Time{}, err
+	}
+	restConfig.Timeout = apiServerDialTimeout
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return time.Time{}, err
+	}
+	transport, upgrader, err := spdy.RoundTripperFor(restConfig)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// Static pods are mirrored in the API server with the node name as a suffix.
+	podName := fmt.Sprintf("kube-apiserver-%s", nodeName)
+	req := clientset.CoreV1().RESTClient().
+		Post().
+		Resource("pods").
+		Namespace("kube-system").
+		Name(podName).
+		SubResource("portforward")
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, req.URL())
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	readyCh := make(chan struct{})
+	forwarder, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"}, []string{fmt.Sprintf("0:%d", port)}, stopCh, readyCh, ioutil.Discard, ioutil.Discard)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to port-forward to pod %s", podName)
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- forwarder.ForwardPorts()
+	}()
+	select {
+	case <-readyCh:
+	case err := <-errCh:
+		return time.Time{}, errors.Wrapf(err, "failed to port-forward to pod %s", podName)
+	case <-ctx.Done():
+		return time.Time{}, ctx.Err()
+	}
+
+	ports, err := forwarder.GetPorts()
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to port-forward to pod %s", podName)
+	}
+	return tlsCertificateExpiry(fmt.Sprintf("127.0.0.1:%d", ports[0].Local), apiServerServerName, caData)
+}
+
+// tlsCertificateExpiry connects to a TLS server and returns the expiry time of its serving certificate, after
+// verifying it with the given PEM encoded CA certificates.
+func tlsCertificateExpiry(addr, serverName string, caData []byte) (time.Time, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caData) {
+		return time.Time{}, errors.New("failed to parse the CA certificates")
+	}
+
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: apiServerDialTimeout}, "tcp", addr, &tls.Config{
+		RootCAs:    pool,
+		ServerName: serverName,
+		MinVersion: tls.VersionTLS12,
+	})
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to connect to %s", addr)
+	}
+	defer conn.Close()
+
+	// The serving certificate is the first one, followed by the intermediate ones.
+	return conn.ConnectionState().PeerCertificates[0].NotAfter, nil
+}
+
+// rotateMachineCertificates marks the Machine owning the config for remediation, so it gets replaced by its owner
+// with a new Machine with new certificates. In order to keep the rollout under control, the Machine is marked only
+// if there are no other Machines in the cluster waiting for remediation.
+func (r *KubeadmConfigReconciler) rotateMachineCertificates(ctx context.Context, scope *Scope, expiry time.Time) (bool, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	machine := &clusterv1.Machine{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Namespace: scope.ConfigOwner.GetNamespace(), Name: scope.ConfigOwner.GetName()}, machine); err != nil {
+		return false, errors.Wrapf(err, "failed to get Machine %s/%s", scope.ConfigOwner.GetNamespace(), scope.ConfigOwner.GetName())
+	}
+	if !machine.DeletionTimestamp.IsZero() || conditions.IsFalse(machine, clusterv1.MachineOwnerRemediatedCondition) {
+		return true, nil
+	}
+
+	machines := &clusterv1.MachineList{}
+	if err := r.Client.List(ctx, machines, client.InNamespace(scope.Cluster.Namespace), client.MatchingLabels{clusterv1.ClusterLabelName: scope.Cluster.Name}); err != nil {
+		return false, errors.Wrapf(err, "failed to list Machines for Cluster %s/%s", scope.Cluster.Namespace, scope.Cluster.Name)
+	}
+	for i := range machines.Items {
+		if conditions.IsFalse(&machines.Items[i], clusterv1.MachineOwnerRemediatedCondition) {
+			return false, nil
+		}
+	}
+
+	patchHelper, err := patch.NewHelper(machine, r.Client)
+	if err != nil {
+		return false, err
+	}
+	conditions.MarkFalse(machine, clusterv1.MachineOwnerRemediatedCondition, clusterv1.WaitingForRemediationReason, clusterv1.ConditionSeverityWarning,
+		"Machine certificates expire at %s", expiry.UTC().Format(time.RFC3339))
+	if err := patchHelper.Patch(ctx, machine, patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{clusterv1.MachineOwnerRemediatedCondition}}); err != nil {
+		return false, errors.Wrapf(err, "failed to mark Machine %s/%s for remediation", machine.Namespace, machine.Name)
+	}
+	log.Info("Marked Machine for remediation to rotate expiring certificates", "machine", machine.Name)
+	return true, nil
+}
+
+func sortedKeys(m map[string]time.Time) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,224 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/test/helpers"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/secret"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestKubeadmConfigReconciler_Reconcile_CertificatesExpiry(t *testing.T) {
+	tests := []struct {
+		name                      string
+		renewalThreshold          time.Duration
+		rotateMachineCertificates bool
+		workerMachine             bool
+		withoutNode               bool
+		machineCertificatesExpiry time.Duration
+		otherMachineRemediating   bool
+		wantCondition             bool
+		wantNotExpiring           bool
+		wantMachineRemediated     bool
+		wantRequeueAfter          time.Duration
+	}{
+		{
+			name:                      "does not check certificates expiry if the renewal threshold is not set",
+			renewalThreshold:          0,
+			machineCertificatesExpiry: 24 * time.Hour,
+			wantCondition:             false,
+			wantRequeueAfter:          0,
+		},
+		{
+			name:                      "reports certificates not expiring",
+			renewalThreshold:          30 * 24 * time.Hour,
+			machineCertificatesExpiry: 365 * 24 * time.Hour,
+			wantCondition:             true,
+			wantNotExpiring:           true,
+			wantRequeueAfter:          certificatesExpiryCheckInterval,
+		},
+		{
+			name:                      "does not check the machine certificates of worker machines",
+			renewalThreshold:          30 * 24 * time.Hour,
+			rotateMachineCertificates: true,
+			workerMachine:             true,
+			machineCertificatesExpiry: 24 * time.Hour,
+			wantCondition:             true,
+			wantNotExpiring:           true,
+			wantRequeueAfter:          certificatesExpiryCheckInterval,
+		},
+		{
+			name:                      "does not check the machine certificates of control plane machines without a node",
+			renewalThreshold:          30 * 24 * time.Hour,
+			rotateMachineCertificates: true,
+			withoutNode:               true,
+			machineCertificatesExpiry: 24 * time.Hour,
+			wantCondition:             true,
+			wantNotExpiring:           true,
+			wantRequeueAfter:          certificatesExpiryCheckInterval,
+		},
+		{
+			name:                      "reports machine certificates expiring without rotating them",
+			renewalThreshold:          30 * 24 * time.Hour,
+			machineCertificatesExpiry: 25 * 24 * time.Hour,
+			wantCondition:             true,
+			wantNotExpiring:           false,
+			wantRequeueAfter:          certificatesExpiryCheckInterval,
+		},
+		{
+			name:                      "marks the machine for remediation if machine certificates are expiring",
+			renewalThreshold:          30 * 24 * time.Hour,
+			rotateMachineCertificates: true,
+			machineCertificatesExpiry: 25 * 24 * time.Hour,
+			wantCondition:             true,
+			wantNotExpiring:           false,
+			wantMachineRemediated:     true,
+			wantRequeueAfter:          certificatesExpiryCheckInterval,
+		},
+		{
+			name:                      "waits for other machines to be remediated before marking the machine for remediation",
+			renewalThreshold:          30 * 24 * time.Hour,
+			rotateMachineCertificates: true,
+			machineCertificatesExpiry: 25 * 24 * time.Hour,
+			otherMachineRemediating:   true,
+			wantCondition:             true,
+			wantNotExpiring:           false,
+			wantMachineRemediated:     false,
+			wantRequeueAfter:          certificatesRotationRequeueAfter,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			cluster := newCluster("cluster")
+			cluster.Status.InfrastructureReady = true
+			conditions.MarkTrue(cluster, clusterv1.ControlPlaneInitializedCondition)
+
+			var machine *clusterv1.Machine
+			var config *bootstrapv1.KubeadmConfig
+			if tt.workerMachine {
+				machine = newWorkerMachine(cluster)
+				config = newWorkerJoinKubeadmConfig(machine)
+			} else {
+				machine = newControlPlaneMachine(cluster, "control-plane-machine")
+				config = newControlPlaneJoinKubeadmConfig(machine, "control-plane-join-cfg")
+			}
+			machine.Spec.Bootstrap.DataSecretName = pointer.StringPtr("secret")
+			if !tt.withoutNode {
+				machine.Status.NodeRef = &corev1.ObjectReference{Kind: "Node", Name: "node"}
+			}
+			config.Status.Ready = true
+			config.Status.DataSecretName = pointer.StringPtr("secret")
+
+			otherMachine := newMachine(cluster, "other-machine")
+			if tt.otherMachineRemediating {
+				conditions.MarkFalse(otherMachine, clusterv1.MachineOwnerRemediatedCondition, clusterv1.WaitingForRemediationReason, clusterv1.ConditionSeverityWarning, "")
+			}
+
+			objects := []client.Object{cluster, machine, otherMachine, config}
+			objects = append(objects, createSecrets(t, cluster, config)...)
+			myclient := helpers.NewFakeClientWithScheme(setupScheme(), objects...)
+
+			k := &KubeadmConfigReconciler{
+				Client:                       myclient,
+				CertificatesRenewalThreshold: tt.renewalThreshold,
+				RotateMachineCertificates:    tt.rotateMachineCertificates,
+				apiServerCertificateExpiryGetter: func(_ context.Context, _ client.Client, _ *clusterv1.Cluster, nodeName string, port int32, caData []byte) (time.Time, error) {
+					g.Expect(tt.workerMachine || tt.withoutNode).To(BeFalse(), "the machine certificates should not be checked")
+					g.Expect(nodeName).To(Equal("node"))
+					g.Expect(port).To(BeEquivalentTo(defaultAPIServerBindPort))
+					g.Expect(caData).NotTo(BeEmpty())
+					return time.Now().Add(tt.machineCertificatesExpiry), nil
+				},
+			}
+
+			result, err := k.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(config)})
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(result.RequeueAfter).To(BeNumerically("<=", tt.wantRequeueAfter))
+			g.Expect(result.RequeueAfter).To(BeNumerically(">", tt.wantRequeueAfter-time.Minute))
+
+			gotConfig := &bootstrapv1.KubeadmConfig{}
+			g.Expect(myclient.Get(ctx, client.ObjectKeyFromObject(config), gotConfig)).To(Succeed())
+			g.Expect(conditions.Has(gotConfig, bootstrapv1.CertificatesNotExpiringCondition)).To(Equal(tt.wantCondition))
+			if tt.wantCondition {
+				g.Expect(conditions.IsTrue(gotConfig, bootstrapv1.CertificatesNotExpiringCondition)).To(Equal(tt.wantNotExpiring))
+			}
+			if tt.wantCondition && !tt.wantNotExpiring {
+				g.Expect(conditions.GetReason(gotConfig, bootstrapv1.CertificatesNotExpiringCondition)).To(Equal(bootstrapv1.CertificatesExpiringReason))
+				g.Expect(conditions.GetMessage(gotConfig, bootstrapv1.CertificatesNotExpiringCondition)).To(ContainSubstring("machine certificates expire at"))
+			}
+
+			gotMachine := &clusterv1.Machine{}
+			g.Expect(myclient.Get(ctx, client.ObjectKeyFromObject(machine), gotMachine)).To(Succeed())
+			g.Expect(conditions.IsFalse(gotMachine, clusterv1.MachineOwnerRemediatedCondition)).To(Equal(tt.wantMachineRemediated))
+		})
+	}
+}
+
+func TestAPIServerBindPort(t *testing.T) {
+	g := NewWithT(t)
+
+	config := &bootstrapv1.KubeadmConfig{}
+	g.Expect(apiServerBindPort(config)).To(BeEquivalentTo(defaultAPIServerBindPort))
+
+	config.Spec.InitConfiguration = &bootstrapv1.InitConfiguration{LocalAPIEndpoint: bootstrapv1.APIEndpoint{BindPort: 8443}}
+	g.Expect(apiServerBindPort(config)).To(BeEquivalentTo(8443))
+
+	config.Spec.JoinConfiguration = &bootstrapv1.JoinConfiguration{ControlPlane: &bootstrapv1.JoinControlPlane{LocalAPIEndpoint: bootstrapv1.APIEndpoint{BindPort: 9443}}}
+	g.Expect(apiServerBindPort(config)).To(BeEquivalentTo(9443))
+}
+
+func TestTLSCertificateExpiry(t *testing.T) {
+	g := NewWithT(t)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer server.Close()
+	serverCertificate := server.Certificate()
+	caData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: serverCertificate.Raw})
+	addr := server.Listener.Addr().String()
+
+	expiry, err := tlsCertificateExpiry(addr, "example.com", caData)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(expiry).To(BeTemporally("==", serverCertificate.NotAfter))
+
+	// The serving certificate is verified with the CA certificates and the server name.
+	certificates := secret.NewCertificatesForInitialControlPlane(&bootstrapv1.ClusterConfiguration{})
+	g.Expect(certificates.Generate()).To(Succeed())
+	_, err = tlsCertificateExpiry(addr, "example.com", certificates.GetByPurpose(secret.ClusterCA).KeyPair.Cert)
+	g.Expect(err).To(HaveOccurred())
+
+	_, err = tlsCertificateExpiry(addr, "kubernetes", caData)
+	g.Expect(err).To(HaveOccurred())
+
+	_, err = tlsCertificateExpiry(addr, "example.com", []byte("not a certificate"))
+	g.Expect(err).To(HaveOccurred())
+}
@@ -30,6 +30,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/version"
 	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha4"
@@ -37,6 +38,7 @@ import (
 	"sigs.k8s.io/cluster-api/bootstrap/kubeadm/internal/locking"
 	kubeadmtypes "sigs.k8s.io/cluster-api/bootstrap/kubeadm/types"
 	bsutil "sigs.k8s.io/cluster-api/bootstrap/util"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
 	"sigs.k8s.io/cluster-api/controllers/remote"
 	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/feature"
@@ -56,6 +58,11 @@ import (
 const (
 	// KubeadmConfigControllerName defines the controller used when creating clients.
 	KubeadmConfigControllerName = "kubeadmconfig-controller"
+
+	// waitingForClusterInfrastructureMinRequeue and waitingForClusterInfrastructureMaxRequeue bound the delay before
+	// checking again a Cluster infrastructure which is not ready yet.
+	waitingForClusterInfrastructureMinRequeue = 5 * time.Second
+	waitingForClusterInfrastructureMaxRequeue = 5 * time.Minute
 )
 
 // InitLocker is a lock that is used around kubeadm init.
@@ -65,7 +72,8 @@ type InitLocker interface {
 }
 
 // +kubebuilder:rbac:groups=bootstrap.cluster.x-k8s.io,resources=kubeadmconfigs;kubeadmconfigs/status,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status;machines;machines/status,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines;machines/status,verbs=get;list;watch;patch
 // +kubebuilder:rbac:groups=exp.cluster.x-k8s.io,resources=machinepools;machinepools/status,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=secrets;events;configmaps,verbs=get;list;watch;create;update;patch;delete
 
@@ -74,7 +82,16 @@ type KubeadmConfigReconciler struct {
 	Client          client.Client
 	KubeadmInitLock InitLocker
 
-	remoteClientGetter remote.ClusterClientGetter
+	// CertificatesRenewalThreshold is the time before expiry when certificates are reported as expiring;
+	// if zero, certificates expiry is not checked.
+	CertificatesRenewalThreshold time.Duration
+
+	// RotateMachineCertificates enables the remediation of control plane Machines with certificates going to expire
+	// within CertificatesRenewalThreshold.
+	RotateMachineCertificates bool
+
+	remoteClientGetter               remote.ClusterClientGetter
+	apiServerCertificateExpiryGetter apiServerCertificateExpiryGetter
 }
 
 type Scope struct {
@@ -92,6 +109,9 @@ func (r *KubeadmConfigReconciler) SetupWithManager(ctx context.Context, mgr ctrl
 	if r.remoteClientGetter == nil {
 		r.remoteClientGetter = remote.NewClusterClient
 	}
+	if r.apiServerCertificateExpiryGetter == nil {
+		r.apiServerCertificateExpiryGetter = getAPIServerCertificateExpiry
+	}
 
 	b := ctrl.NewControllerManagedBy(mgr).
 		For(&bootstrapv1.KubeadmConfig{}).
@@ -109,7 +129,7 @@ func (r *KubeadmConfigReconciler) SetupWithManager(ctx context.Context, mgr ctrl
 		)
 	}
 
-	c, err := b.Build(r)
+	c, err := b.Build(metrics.NewInstrumentedReconciler("kubeadmconfig", r))
 	if err != nil {
 		return errors.Wrap(err, "failed setting up with a controller manager")
 	}
@@ -214,9 +234,7 @@ func (r *KubeadmConfigReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	switch {
 	// Wait for the infrastructure to be ready.
 	case !cluster.Status.InfrastructureReady:
-		log.Info("Cluster infrastructure is not ready, waiting")
-		conditions.MarkFalse(config, bootstrapv1.DataSecretAvailableCondition, bootstrapv1.WaitingForClusterInfrastructureReason, clusterv1.ConditionSeverityInfo, "")
-		return ctrl.Result{}, nil
+		return r.waitForClusterInfrastructure(scope), nil
 	// Reconcile status for machines that already have a secret reference, but our status isn't up to date.
 	// This case solves the pivoting scenario (or a backup restore) which doesn't preserve the status subresource on objects.
 	case configOwner.DataSecretName() != nil && (!config.Status.Ready || config.Status.DataSecretName == nil):
@@ -226,6 +244,11 @@ func (r *KubeadmConfigReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		return ctrl.Result{}, nil
 	// Status is ready means a config has been generated.
 	case config.Status.Ready:
+		// Check if the spec changed after the bootstrap data has been generated.
+		if err := r.reconcileBootstrapDataUpToDate(scope); err != nil {
+			return ctrl.Result{}, err
+		}
+
 		if config.Spec.JoinConfiguration != nil && config.Spec.JoinConfiguration.Discovery.BootstrapToken != nil {
 			if !configOwner.IsInfrastructureReady() {
 				// If the BootstrapToken has been generated for a join and the infrastructure is not ready.
@@ -238,8 +261,8 @@ func (r *KubeadmConfigReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 				return r.rotateMachinePoolBootstrapToken(ctx, config, cluster, scope)
 			}
 		}
-		// In any other case just return as the config is already generated and need not be generated again.
-		return ctrl.Result{}, nil
+		// In any other case the config is already generated and need not be generated again; just check certificates expiry.
+		return r.reconcileCertificatesExpiry(ctx, scope)
 	}
 
 	// Note: can't use IsFalse here because we need to handle the absence of the condition as well as false.
@@ -268,18 +291,53 @@ func (r *KubeadmConfigReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	return r.joinWorker(ctx, scope)
 }
 
+// waitForClusterInfrastructure reports that the generation of the bootstrap data is waiting for the Cluster infrastructure to be
+// ready, and requeues the KubeadmConfig with a delay growing exponentially with the time it has been waiting, within bounds;
+// this is a safety net, given that KubeadmConfigs are also reconciled when the Cluster infrastructure becomes ready.
+func (r *KubeadmConfigReconciler) waitForClusterInfrastructure(scope *Scope) ctrl.Result {
+	message := "Waiting for the Cluster infrastructure to be ready"
+	if ref := scope.Cluster.Spec.InfrastructureRef; ref != nil {
+		message = fmt.Sprintf("Waiting for %s %s to be ready", ref.Kind, ref.Name)
+	}
+	conditions.MarkFalse(scope.Config, bootstrapv1.DataSecretAvailableCondition, bootstrapv1.WaitingForClusterInfrastructureReason, clusterv1.ConditionSeverityInfo, message)
+	metrics.RecordBootstrapDataWait("kubeadmconfig", bootstrapv1.WaitingForClusterInfrastructureReason)
+
+	requeueAfter := waitingForClusterInfrastructureRequeueAfter(scope.Config)
+	scope.Info("Cluster infrastructure is not ready, waiting", "requeueAfter", requeueAfter)
+	return ctrl.Result{RequeueAfter: requeueAfter}
+}
+
+// waitingForClusterInfrastructureRequeueAfter returns the time the KubeadmConfig has been waiting for the Cluster infrastructure,
+// as reported by the DataSecretAvailable condition, bounded by the minimum and maximum requeue delays; requeueing after this
+// delay doubles the time waited at every check.
+func waitingForClusterInfrastructureRequeueAfter(config *bootstrapv1.KubeadmConfig) time.Duration {
+	var waiting time.Duration
+	if c := conditions.Get(config, bootstrapv1.DataSecretAvailableCondition); c != nil && c.Reason == bootstrapv1.WaitingForClusterInfrastructureReason {
+		waiting = time.Since(c.LastTransitionTime.Time)
+	}
+
+	switch {
+	case waiting < waitingForClusterInfrastructureMinRequeue:
+		return waitingForClusterInfrastructureMinRequeue
+	case waiting > waitingForClusterInfrastructureMaxRequeue:
+		return waitingForClusterInfrastructureMaxRequeue
+	default:
+		return waiting.Round(time.Second)
+	}
+}
+
 func (r *KubeadmConfigReconciler) refreshBootstrapToken(ctx context.Context, config *bootstrapv1.KubeadmConfig, cluster *clusterv1.Cluster) (ctrl.Result, error) {
 	log := ctrl.LoggerFrom(ctx)
 	token := config.Spec.JoinConfiguration.Discovery.BootstrapToken.Token
 
-	remoteClient, err := r.remoteClientGetter(ctx, KubeadmConfigControllerName, r.Client, util.ObjectKey(cluster))
+	tokens, err := r.bootstrapTokens(ctx, cluster)
 	if err != nil {
 		log.Error(err, "Error creating remote cluster client")
 		return ctrl.Result{}, err
 	}
 
 	log.Info("Refreshing token until the infrastructure has a chance to consume it")
-	if err := refreshToken(ctx, remoteClient, token); err != nil {
+	if err := tokens.Refresh(ctx, token); err != nil {
 		return ctrl.Result{}, errors.Wrapf(err, "failed to refresh bootstrap token")
 	}
 	return ctrl.Result{
@@ -290,19 +348,19 @@ func (r *KubeadmConfigReconciler) refreshBootstrapToken(ctx context.Context, con
 func (r *KubeadmConfigReconciler) rotateMachinePoolBootstrapToken(ctx context.Context, config *bootstrapv1.KubeadmConfig, cluster *clusterv1.Cluster, scope *Scope) (ctrl.Result, error) {
 	log := ctrl.LoggerFrom(ctx)
 	log.V(2).Info("Config is owned by a MachinePool, checking if token should be rotated")
-	remoteClient, err := r.remoteClientGetter(ctx, KubeadmConfigControllerName, r.Client, util.ObjectKey(cluster))
+	tokens, err := r.bootstrapTokens(ctx, cluster)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
 	token := config.Spec.JoinConfiguration.Discovery.BootstrapToken.Token
-	shouldRotate, err := shouldRotate(ctx, remoteClient, token)
+	shouldRotate, err := tokens.ShouldRotate(ctx, token)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 	if shouldRotate {
 		log.V(2).Info("Creating new bootstrap token")
-		token, err := createToken(ctx, remoteClient)
+		token, err := tokens.Create(ctx)
 		if err != nil {
 			return ctrl.Result{}, errors.Wrapf(err, "failed to create new bootstrap token")
 		}
@@ -331,6 +389,10 @@ func (r *KubeadmConfigReconciler) handleClusterNotInitialized(ctx context.Contex
 		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
 	}
 
+	if scope.Config.Spec.Format == bootstrapv1.CloudbaseInit {
+		return ctrl.Result{}, errors.Errorf("Machine is a control plane, but the %s format can be used only for Windows worker nodes", bootstrapv1.CloudbaseInit)
+	}
+
 	// if the machine has not ClusterConfiguration and InitConfiguration, requeue
 	if scope.Config.Spec.InitConfiguration == nil && scope.Config.Spec.ClusterConfiguration == nil {
 		scope.Info("Control plane is not ready, requeing joining control planes until ready.")
@@ -418,6 +480,12 @@ func (r *KubeadmConfigReconciler) handleClusterNotInitialized(ctx context.Contex
 		verbosityFlag = fmt.Sprintf("--v %s", strconv.Itoa(int(*scope.Config.Spec.Verbosity)))
 	}
 
+	patchesFlag, err := kubeadmPatchesFlag(scope.Config.Spec.Patches, scope.ConfigOwner.KubernetesVersion())
+	if err != nil {
+		scope.Error(err, "Failed to generate kubeadm patches flag")
+		return ctrl.Result{}, err
+	}
+
 	files, err := r.resolveFiles(ctx, scope.Config)
 	if err != nil {
 		conditions.MarkFalse(scope.Config, bootstrapv1.DataSecretAvailableCondition, bootstrapv1.DataSecretGenerationFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
@@ -426,14 +494,19 @@ func (r *KubeadmConfigReconciler) handleClusterNotInitialized(ctx context.Contex
 
 	cloudInitData, err := cloudinit.NewInitControlPlane(&cloudinit.ControlPlaneInput{
 		BaseUserData: cloudinit.BaseUserData{
-			AdditionalFiles:     files,
-			NTP:                 scope.Config.Spec.NTP,
-			PreKubeadmCommands:  scope.Config.Spec.PreKubeadmCommands,
-			PostKubeadmCommands: scope.Config.Spec.PostKubeadmCommands,
-			Users:               scope.Config.Spec.Users,
-			Mounts:              scope.Config.Spec.Mounts,
-			DiskSetup:           scope.Config.Spec.DiskSetup,
-			KubeadmVerbosity:    verbosityFlag,
+			AdditionalFiles:          files,
+			NTP:                      scope.Config.Spec.NTP,
+			ContainerRuntime:         scope.Config.Spec.ContainerRuntime,
+			Proxy:                    scope.Config.Spec.Proxy,
+			AdditionalCACertificates: scope.Config.Spec.AdditionalCACertificates,
+			Patches:                  scope.Config.Spec.Patches,
+			PreKubeadmCommands:       scope.Config.Spec.PreKubeadmCommands,
+			PostKubeadmCommands:      scope.Config.Spec.PostKubeadmCommands,
+			Users:                    scope.Config.Spec.Users,
+			Mounts:                   scope.Config.Spec.Mounts,
+			DiskSetup:                scope.Config.Spec.DiskSetup,
+			KubeadmVerbosity:         verbosityFlag,
+			KubeadmPatchesFlag:       patchesFlag,
 		},
 		InitConfiguration:    initdata,
 		ClusterConfiguration: clusterdata,
@@ -493,26 +566,45 @@ func (r *KubeadmConfigReconciler) joinWorker(ctx context.Context, scope *Scope)
 		verbosityFlag = fmt.Sprintf("--v %s", strconv.Itoa(int(*scope.Config.Spec.Verbosity)))
 	}
 
+	patchesFlag, err := kubeadmPatchesFlag(scope.Config.Spec.Patches, scope.ConfigOwner.KubernetesVersion())
+	if err != nil {
+		scope.Error(err, "Failed to generate kubeadm patches flag")
+		return ctrl.Result{}, err
+	}
+
 	files, err := r.resolveFiles(ctx, scope.Config)
 	if err != nil {
 		conditions.MarkFalse(scope.Config, bootstrapv1.DataSecretAvailableCondition, bootstrapv1.DataSecretGenerationFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
 		return ctrl.Result{}, err
 	}
 
-	cloudJoinData, err := cloudinit.NewNode(&cloudinit.NodeInput{
+	nodeInput := &cloudinit.NodeInput{
 		BaseUserData: cloudinit.BaseUserData{
-			AdditionalFiles:      files,
-			NTP:                  scope.Config.Spec.NTP,
-			PreKubeadmCommands:   scope.Config.Spec.PreKubeadmCommands,
-			PostKubeadmCommands:  scope.Config.Spec.PostKubeadmCommands,
-			Users:                scope.Config.Spec.Users,
-			Mounts:               scope.Config.Spec.Mounts,
-			DiskSetup:            scope.Config.Spec.DiskSetup,
-			KubeadmVerbosity:     verbosityFlag,
-			UseExperimentalRetry: scope.Config.Spec.UseExperimentalRetryJoin,
+			AdditionalFiles:          files,
+			NTP:                      scope.Config.Spec.NTP,
+			ContainerRuntime:         scope.Config.Spec.ContainerRuntime,
+			Proxy:                    scope.Config.Spec.Proxy,
+			AdditionalCACertificates: scope.Config.Spec.AdditionalCACertificates,
+			Patches:                  scope.Config.Spec.Patches,
+			PreKubeadmCommands:       scope.Config.Spec.PreKubeadmCommands,
+			PostKubeadmCommands:      scope.Config.Spec.PostKubeadmCommands,
+			Users:                    scope.Config.Spec.Users,
+			Mounts:                   scope.Config.Spec.Mounts,
+			DiskSetup:                scope.Config.Spec.DiskSetup,
+			KubeadmVerbosity:         verbosityFlag,
+			KubeadmPatchesFlag:       patchesFlag,
+			UseExperimentalRetry:     scope.Config.Spec.UseExperimentalRetryJoin,
 		},
 		JoinConfiguration: joinData,
-	})
+	}
+
+	var cloudJoinData []byte
+	if scope.Config.Spec.Format == bootstrapv1.CloudbaseInit {
+		scope.Info("Using the cloudbase-init format for a Windows worker node")
+		cloudJoinData, err = cloudinit.NewWindowsNode(nodeInput)
+	} else {
+		cloudJoinData, err = cloudinit.NewNode(nodeInput)
+	}
 	if err != nil {
 		scope.Error(err, "Failed to create a worker join configuration")
 		return ctrl.Result{}, err
@@ -530,6 +622,10 @@ func (r *KubeadmConfigReconciler) joinControlplane(ctx context.Context, scope *S
 		return ctrl.Result{}, fmt.Errorf("%s is not a valid control plane kind, only Machine is supported", scope.ConfigOwner.GetKind())
 	}
 
+	if scope.Config.Spec.Format == bootstrapv1.CloudbaseInit {
+		return ctrl.Result{}, errors.Errorf("Machine is a control plane, but the %s format can be used only for Windows worker nodes", bootstrapv1.CloudbaseInit)
+	}
+
 	if scope.Config.Spec.JoinConfiguration.ControlPlane == nil {
 		scope.Config.Spec.JoinConfiguration.ControlPlane = &bootstrapv1.JoinControlPlane{}
 	}
@@ -570,6 +666,12 @@ func (r *KubeadmConfigReconciler) joinControlplane(ctx context.Context, scope *S
 		verbosityFlag = fmt.Sprintf("--v %s", strconv.Itoa(int(*scope.Config.Spec.Verbosity)))
 	}
 
+	patchesFlag, err := kubeadmPatchesFlag(scope.Config.Spec.Patches, scope.ConfigOwner.KubernetesVersion())
+	if err != nil {
+		scope.Error(err, "Failed to generate kubeadm patches flag")
+		return ctrl.Result{}, err
+	}
+
 	files, err := r.resolveFiles(ctx, scope.Config)
 	if err != nil {
 		conditions.MarkFalse(scope.Config, bootstrapv1.DataSecretAvailableCondition, bootstrapv1.DataSecretGenerationFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
@@ -580,15 +682,20 @@ func (r *KubeadmConfigReconciler) joinControlplane(ctx context.Context, scope *S
 		JoinConfiguration: joinData,
 		Certificates:      certificates,
 		BaseUserData: cloudinit.BaseUserData{
-			AdditionalFiles:      files,
-			NTP:                  scope.Config.Spec.NTP,
-			PreKubeadmCommands:   scope.Config.Spec.PreKubeadmCommands,
-			PostKubeadmCommands:  scope.Config.Spec.PostKubeadmCommands,
-			Users:                scope.Config.Spec.Users,
-			Mounts:               scope.Config.Spec.Mounts,
-			DiskSetup:            scope.Config.Spec.DiskSetup,
-			KubeadmVerbosity:     verbosityFlag,
-			UseExperimentalRetry: scope.Config.Spec.UseExperimentalRetryJoin,
+			AdditionalFiles:          files,
+			NTP:                      scope.Config.Spec.NTP,
+			ContainerRuntime:         scope.Config.Spec.ContainerRuntime,
+			Proxy:                    scope.Config.Spec.Proxy,
+			AdditionalCACertificates: scope.Config.Spec.AdditionalCACertificates,
+			Patches:                  scope.Config.Spec.Patches,
+			PreKubeadmCommands:       scope.Config.Spec.PreKubeadmCommands,
+			PostKubeadmCommands:      scope.Config.Spec.PostKubeadmCommands,
+			Users:                    scope.Config.Spec.Users,
+			Mounts:                   scope.Config.Spec.Mounts,
+			DiskSetup:                scope.Config.Spec.DiskSetup,
+			KubeadmVerbosity:         verbosityFlag,
+			KubeadmPatchesFlag:       patchesFlag,
+			UseExperimentalRetry:     scope.Config.Spec.UseExperimentalRetryJoin,
 		},
 	})
 	if err != nil {
@@ -612,7 +719,13 @@ func (r *KubeadmConfigReconciler) resolveFiles(ctx context.Context, cfg *bootstr
 	for i := range cfg.Spec.Files {
 		in := cfg.Spec.Files[i]
 		if in.ContentFrom != nil {
-			data, err := r.resolveSecretFileContent(ctx, cfg.Namespace, in)
+			var data []byte
+			var err error
+			if in.ContentFrom.ConfigMap != nil {
+				data, err = r.resolveConfigMapFileContent(ctx, cfg.Namespace, in)
+			} else {
+				data, err = r.resolveSecretFileContent(ctx, cfg.Namespace, in)
+			}
 			if err != nil {
 				return nil, errors.Wrapf(err, "failed to resolve file source")
 			}
@@ -642,6 +755,25 @@ func (r *KubeadmConfigReconciler) resolveSecretFileContent(ctx context.Context,
 	return data, nil
 }
 
+// resolveConfigMapFileContent returns file content fetched from a referenced config map object.
+func (r *KubeadmConfigReconciler) resolveConfigMapFileContent(ctx context.Context, ns string, source bootstrapv1.File) ([]byte, error) {
+	configMap := &corev1.ConfigMap{}
+	key := types.NamespacedName{Namespace: ns, Name: source.ContentFrom.ConfigMap.Name}
+	if err := r.Client.Get(ctx, key, configMap); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, errors.Wrapf(err, "config map not found: %s", key)
+		}
+		return nil, errors.Wrapf(err, "failed to retrieve ConfigMap %q", key)
+	}
+	if data, ok := configMap.Data[source.ContentFrom.ConfigMap.Key]; ok {
+		return []byte(data), nil
+	}
+	if data, ok := configMap.BinaryData[source.ContentFrom.ConfigMap.Key]; ok {
+		return data, nil
+	}
+	return nil, errors.Errorf("config map references non-existent config map key: %q", source.ContentFrom.ConfigMap.Key)
+}
+
 // ClusterToKubeadmConfigs is a handler.ToRequestsFunc to be used to enqeue
 // requests for reconciliation of KubeadmConfigs.
 func (r *KubeadmConfigReconciler) ClusterToKubeadmConfigs(o client.Object) []ctrl.Request {
@@ -765,12 +897,12 @@ func (r *KubeadmConfigReconciler) reconcileDiscovery(ctx context.Context, cluste
 
 	// if BootstrapToken already contains a token, respect it; otherwise create a new bootstrap token for the node to join
 	if config.Spec.JoinConfiguration.Discovery.BootstrapToken.Token == "" {
-		remoteClient, err := r.remoteClientGetter(ctx, KubeadmConfigControllerName, r.Client, util.ObjectKey(cluster))
+		tokens, err := r.bootstrapTokens(ctx, cluster)
 		if err != nil {
 			return ctrl.Result{}, err
 		}
 
-		token, err := createToken(ctx, remoteClient)
+		token, err := tokens.Create(ctx)
 		if err != nil {
 			return ctrl.Result{}, errors.Wrapf(err, "failed to create new bootstrap token")
 		}
@@ -857,7 +989,8 @@ func (r *KubeadmConfigReconciler) storeBootstrapData(ctx context.Context, scope
 			},
 		},
 		Data: map[string][]byte{
-			"value": data,
+			"value":  data,
+			"format": []byte(bootstrapDataFormat(scope.Config)),
 		},
 		Type: clusterv1.ClusterSecretType,
 	}
@@ -873,8 +1006,44 @@ func (r *KubeadmConfigReconciler) storeBootstrapData(ctx context.Context, scope
 			return errors.Wrapf(err, "failed to update bootstrap data secret for KubeadmConfig %s/%s", scope.Config.Namespace, scope.Config.Name)
 		}
 	}
+	hash, err := computeSpecHash(&scope.Config.Spec)
+	if err != nil {
+		return err
+	}
 	scope.Config.Status.DataSecretName = pointer.StringPtr(secret.Name)
+	scope.Config.Status.DataSecretHash = hash
 	scope.Config.Status.Ready = true
 	conditions.MarkTrue(scope.Config, bootstrapv1.DataSecretAvailableCondition)
+	conditions.MarkTrue(scope.Config, clusterv1.BootstrapDataUpToDateCondition)
 	return nil
 }
+
+// bootstrapDataFormat returns the format of the bootstrap data generated for a KubeadmConfig.
+func bootstrapDataFormat(config *bootstrapv1.KubeadmConfig) bootstrapv1.Format {
+	if config.Spec.Format == "" {
+		return bootstrapv1.CloudConfig
+	}
+	return config.Spec.Format
+}
+
+// kubeadmPatchesFlag returns the kubeadm flag for applying the patches written in bootstrapv1.KubeadmPatchesDirectory,
+// or an empty string if there are no patches.
+// The flag was introduced as --experimental-patches in kubeadm v1.19, and renamed to --patches in v1.22.
+func kubeadmPatchesFlag(patches []bootstrapv1.Patch, kubernetesVersion string) (string, error) {
+	if len(patches) == 0 {
+		return "", nil
+	}
+
+	v, err := version.ParseGeneric(kubernetesVersion)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse Kubernetes version %q", kubernetesVersion)
+	}
+	switch {
+	case v.LessThan(version.MustParseGeneric("v1.19.0")):
+		return "", errors.Errorf("kubeadm patches require Kubernetes v1.19 or newer, got %s", kubernetesVersion)
+	case v.LessThan(version.MustParseGeneric("v1.22.0")):
+		return fmt.Sprintf("--experimental-patches %s", bootstrapv1.KubeadmPatchesDirectory), nil
+	default:
+		return fmt.Sprintf("--patches %s", bootstrapv1.KubeadmPatchesDirectory), nil
+	}
+}
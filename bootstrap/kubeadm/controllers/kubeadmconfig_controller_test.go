@@ -213,13 +213,59 @@ func TestKubeadmConfigReconciler_ReturnEarlyIfClusterInfraNotReady(t *testing.T)
 		},
 	}
 
-	expectedResult := reconcile.Result{}
+	expectedResult := reconcile.Result{RequeueAfter: waitingForClusterInfrastructureMinRequeue}
 	actualResult, actualError := k.Reconcile(ctx, request)
 	g.Expect(actualResult).To(Equal(expectedResult))
 	g.Expect(actualError).NotTo(HaveOccurred())
 	assertHasFalseCondition(g, myclient, request, bootstrapv1.DataSecretAvailableCondition, clusterv1.ConditionSeverityInfo, bootstrapv1.WaitingForClusterInfrastructureReason)
 }
 
+func TestKubeadmConfigReconciler_WaitingForClusterInfrastructureRequeueAfter(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition *clusterv1.Condition
+		want      time.Duration
+	}{
+		{
+			name:      "starts waiting",
+			condition: nil,
+			want:      waitingForClusterInfrastructureMinRequeue,
+		},
+		{
+			name:      "waiting for another reason",
+			condition: conditions.FalseCondition(bootstrapv1.DataSecretAvailableCondition, bootstrapv1.DataSecretGenerationFailedReason, clusterv1.ConditionSeverityWarning, ""),
+			want:      waitingForClusterInfrastructureMinRequeue,
+		},
+		{
+			name:      "doubles the time waited so far",
+			condition: waitingForClusterInfrastructureSince(time.Minute),
+			want:      time.Minute,
+		},
+		{
+			name:      "is capped to the max requeue",
+			condition: waitingForClusterInfrastructureSince(time.Hour),
+			want:      waitingForClusterInfrastructureMaxRequeue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			config := &bootstrapv1.KubeadmConfig{}
+			if tt.condition != nil {
+				config.Status.Conditions = clusterv1.Conditions{*tt.condition}
+			}
+			g.Expect(waitingForClusterInfrastructureRequeueAfter(config)).To(Equal(tt.want))
+		})
+	}
+}
+
+func waitingForClusterInfrastructureSince(d time.Duration) *clusterv1.Condition {
+	c := conditions.FalseCondition(bootstrapv1.DataSecretAvailableCondition, bootstrapv1.WaitingForClusterInfrastructureReason, clusterv1.ConditionSeverityInfo, "")
+	c.LastTransitionTime = metav1.NewTime(time.Now().Add(-d))
+	return c
+}
+
 // Return early If the owning machine does not have an associated cluster.
 func TestKubeadmConfigReconciler_Reconcile_ReturnEarlyIfMachineHasNoCluster(t *testing.T) {
 	g := NewWithT(t)
@@ -520,6 +566,16 @@ func TestReconcileIfJoinNodesAndControlPlaneIsReady(t *testing.T) {
 			configName:    "worker-join-cfg",
 			configBuilder: newKubeadmConfig,
 		},
+		{
+			name:       "Join a Windows worker node using the cloudbase-init format",
+			machine:    newWorkerMachine(cluster),
+			configName: "worker-join-cfg",
+			configBuilder: func(machine *clusterv1.Machine, name string) *bootstrapv1.KubeadmConfig {
+				c := newWorkerJoinKubeadmConfig(machine)
+				c.Spec.Format = bootstrapv1.CloudbaseInit
+				return c
+			},
+		},
 		{
 			name:          "Join a control plane node with a fully compiled kubeadm config object",
 			machine:       newControlPlaneMachine(cluster, "control-plane-join-machine"),
@@ -1561,6 +1617,14 @@ func TestKubeadmConfigReconciler_ResolveFiles(t *testing.T) {
 			"key": []byte("foo"),
 		},
 	}
+	testConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "source",
+		},
+		Data: map[string]string{
+			"key": "baz",
+		},
+	}
 
 	cases := map[string]struct {
 		cfg     *bootstrapv1.KubeadmConfig
@@ -1595,7 +1659,7 @@ func TestKubeadmConfigReconciler_ResolveFiles(t *testing.T) {
 					Files: []bootstrapv1.File{
 						{
 							ContentFrom: &bootstrapv1.FileSource{
-								Secret: bootstrapv1.SecretFileSource{
+								Secret: &bootstrapv1.SecretFileSource{
 									Name: "source",
 									Key:  "key",
 								},
@@ -1617,6 +1681,34 @@ func TestKubeadmConfigReconciler_ResolveFiles(t *testing.T) {
 			},
 			objects: []client.Object{testSecret},
 		},
+		"contentFrom config map should convert correctly": {
+			cfg: &bootstrapv1.KubeadmConfig{
+				Spec: bootstrapv1.KubeadmConfigSpec{
+					Files: []bootstrapv1.File{
+						{
+							ContentFrom: &bootstrapv1.FileSource{
+								ConfigMap: &bootstrapv1.ConfigMapFileSource{
+									Name: "source",
+									Key:  "key",
+								},
+							},
+							Path:        "/path",
+							Owner:       "root:root",
+							Permissions: "0600",
+						},
+					},
+				},
+			},
+			expect: []bootstrapv1.File{
+				{
+					Content:     "baz",
+					Path:        "/path",
+					Owner:       "root:root",
+					Permissions: "0600",
+				},
+			},
+			objects: []client.Object{testConfigMap},
+		},
 		"multiple files should work correctly": {
 			cfg: &bootstrapv1.KubeadmConfig{
 				Spec: bootstrapv1.KubeadmConfigSpec{
@@ -1629,7 +1721,7 @@ func TestKubeadmConfigReconciler_ResolveFiles(t *testing.T) {
 						},
 						{
 							ContentFrom: &bootstrapv1.FileSource{
-								Secret: bootstrapv1.SecretFileSource{
+								Secret: &bootstrapv1.SecretFileSource{
 									Name: "source",
 									Key:  "key",
 								},
@@ -1938,3 +2030,59 @@ func assertHasTrueCondition(g *WithT, myclient client.Client, req ctrl.Request,
 	g.Expect(c).ToNot(BeNil())
 	g.Expect(c.Status).To(Equal(corev1.ConditionTrue))
 }
+
+func TestKubeadmPatchesFlag(t *testing.T) {
+	patches := []bootstrapv1.Patch{{Target: bootstrapv1.PatchTargetKubeAPIServer, Content: "metadata: {}"}}
+
+	tests := []struct {
+		name              string
+		patches           []bootstrapv1.Patch
+		kubernetesVersion string
+		want              string
+		wantErr           bool
+	}{
+		{
+			name:              "no flag without patches",
+			kubernetesVersion: "v1.21.1",
+			want:              "",
+		},
+		{
+			name:              "experimental flag before v1.22",
+			patches:           patches,
+			kubernetesVersion: "v1.21.1",
+			want:              "--experimental-patches /run/kubeadm/patches",
+		},
+		{
+			name:              "flag since v1.22",
+			patches:           patches,
+			kubernetesVersion: "v1.22.0",
+			want:              "--patches /run/kubeadm/patches",
+		},
+		{
+			name:              "error before v1.19",
+			patches:           patches,
+			kubernetesVersion: "v1.18.5",
+			wantErr:           true,
+		},
+		{
+			name:              "error with invalid version",
+			patches:           patches,
+			kubernetesVersion: "foo",
+			wantErr:           true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := kubeadmPatchesFlag(tt.patches, tt.kubernetesVersion)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
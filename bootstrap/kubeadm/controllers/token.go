@@ -18,100 +18,22 @@ package controllers
 
 import (
 	"context"
-	"time"
 
-	"github.com/pkg/errors"
-	v1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	bootstrapapi "k8s.io/cluster-bootstrap/token/api"
-	bootstraputil "k8s.io/cluster-bootstrap/token/util"
-	"sigs.k8s.io/controller-runtime/pkg/client"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/bootstrap/kubeadm/bootstraptoken"
+	"sigs.k8s.io/cluster-api/util"
 )
 
 var (
 	// DefaultTokenTTL is the amount of time a bootstrap token (and therefore a KubeadmConfig) will be valid.
-	DefaultTokenTTL = 15 * time.Minute
+	DefaultTokenTTL = bootstraptoken.DefaultTTL
 )
 
-// createToken attempts to create a token with the given ID.
-func createToken(ctx context.Context, c client.Client) (string, error) {
-	token, err := bootstraputil.GenerateBootstrapToken()
+// bootstrapTokens returns a manager for the bootstrap tokens of the workload cluster.
+func (r *KubeadmConfigReconciler) bootstrapTokens(ctx context.Context, cluster *clusterv1.Cluster) (*bootstraptoken.Manager, error) {
+	remoteClient, err := r.remoteClientGetter(ctx, KubeadmConfigControllerName, r.Client, util.ObjectKey(cluster))
 	if err != nil {
-		return "", errors.Wrap(err, "unable to generate bootstrap token")
+		return nil, err
 	}
-
-	substrs := bootstraputil.BootstrapTokenRegexp.FindStringSubmatch(token)
-	if len(substrs) != 3 {
-		return "", errors.Errorf("the bootstrap token %q was not of the form %q", token, bootstrapapi.BootstrapTokenPattern)
-	}
-	tokenID := substrs[1]
-	tokenSecret := substrs[2]
-
-	secretName := bootstraputil.BootstrapTokenSecretName(tokenID)
-	secretToken := &v1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      secretName,
-			Namespace: metav1.NamespaceSystem,
-		},
-		Type: bootstrapapi.SecretTypeBootstrapToken,
-		Data: map[string][]byte{
-			bootstrapapi.BootstrapTokenIDKey:               []byte(tokenID),
-			bootstrapapi.BootstrapTokenSecretKey:           []byte(tokenSecret),
-			bootstrapapi.BootstrapTokenExpirationKey:       []byte(time.Now().UTC().Add(DefaultTokenTTL).Format(time.RFC3339)),
-			bootstrapapi.BootstrapTokenUsageSigningKey:     []byte("true"),
-			bootstrapapi.BootstrapTokenUsageAuthentication: []byte("true"),
-			bootstrapapi.BootstrapTokenExtraGroupsKey:      []byte("system:bootstrappers:kubeadm:default-node-token"),
-			bootstrapapi.BootstrapTokenDescriptionKey:      []byte("token generated by cluster-api-bootstrap-provider-kubeadm"),
-		},
-	}
-
-	if err = c.Create(ctx, secretToken); err != nil {
-		return "", err
-	}
-	return token, nil
-}
-
-// getToken fetches the token Secret and returns an error if it is invalid.
-func getToken(ctx context.Context, c client.Client, token string) (*v1.Secret, error) {
-	substrs := bootstraputil.BootstrapTokenRegexp.FindStringSubmatch(token)
-	if len(substrs) != 3 {
-		return nil, errors.Errorf("the bootstrap token %q was not of the form %q", token, bootstrapapi.BootstrapTokenPattern)
-	}
-	tokenID := substrs[1]
-
-	secretName := bootstraputil.BootstrapTokenSecretName(tokenID)
-	secret := &v1.Secret{}
-	if err := c.Get(ctx, client.ObjectKey{Name: secretName, Namespace: metav1.NamespaceSystem}, secret); err != nil {
-		return secret, err
-	}
-
-	if secret.Data == nil {
-		return nil, errors.Errorf("Invalid bootstrap secret %q, remove the token from the kubadm config to re-create", secretName)
-	}
-	return secret, nil
-}
-
-// refreshToken extends the TTL for an existing token.
-func refreshToken(ctx context.Context, c client.Client, token string) error {
-	secret, err := getToken(ctx, c, token)
-	if err != nil {
-		return err
-	}
-	secret.Data[bootstrapapi.BootstrapTokenExpirationKey] = []byte(time.Now().UTC().Add(DefaultTokenTTL).Format(time.RFC3339))
-
-	return c.Update(ctx, secret)
-}
-
-// shouldRotate returns true if an existing token is past half of its TTL and should to be rotated.
-func shouldRotate(ctx context.Context, c client.Client, token string) (bool, error) {
-	secret, err := getToken(ctx, c, token)
-	if err != nil {
-		return false, err
-	}
-
-	expiration, err := time.Parse(time.RFC3339, string(secret.Data[bootstrapapi.BootstrapTokenExpirationKey]))
-	if err != nil {
-		return false, err
-	}
-	return expiration.Before(time.Now().UTC().Add(DefaultTokenTTL / 2)), nil
+	return &bootstraptoken.Manager{Client: remoteClient, TTL: DefaultTokenTTL}, nil
 }
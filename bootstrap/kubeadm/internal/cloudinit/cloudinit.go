@@ -20,6 +20,7 @@ import (
 	"bytes"
 	_ "embed"
 	"fmt"
+	"strings"
 	"text/template"
 
 	"github.com/pkg/errors"
@@ -41,26 +42,32 @@ const (
 
 // BaseUserData is shared across all the various types of files written to disk.
 type BaseUserData struct {
-	Header               string
-	PreKubeadmCommands   []string
-	PostKubeadmCommands  []string
-	AdditionalFiles      []bootstrapv1.File
-	WriteFiles           []bootstrapv1.File
-	Users                []bootstrapv1.User
-	NTP                  *bootstrapv1.NTP
-	DiskSetup            *bootstrapv1.DiskSetup
-	Mounts               []bootstrapv1.MountPoints
-	ControlPlane         bool
-	UseExperimentalRetry bool
-	KubeadmCommand       string
-	KubeadmVerbosity     string
-	SentinelFileCommand  string
+	Header                   string
+	PreKubeadmCommands       []string
+	PostKubeadmCommands      []string
+	ContainerRuntimeCommands []string
+	AdditionalFiles          []bootstrapv1.File
+	WriteFiles               []bootstrapv1.File
+	Users                    []bootstrapv1.User
+	NTP                      *bootstrapv1.NTP
+	ContainerRuntime         *bootstrapv1.ContainerRuntime
+	Proxy                    *bootstrapv1.Proxy
+	AdditionalCACertificates []string
+	Patches                  []bootstrapv1.Patch
+	DiskSetup                *bootstrapv1.DiskSetup
+	Mounts                   []bootstrapv1.MountPoints
+	ControlPlane             bool
+	UseExperimentalRetry     bool
+	KubeadmCommand           string
+	KubeadmVerbosity         string
+	KubeadmPatchesFlag       string
+	SentinelFileCommand      string
 }
 
 func (input *BaseUserData) prepare() error {
 	input.Header = cloudConfigHeader
 	input.WriteFiles = append(input.WriteFiles, input.AdditionalFiles...)
-	input.KubeadmCommand = fmt.Sprintf(standardJoinCommand, input.KubeadmVerbosity)
+	input.KubeadmCommand = fmt.Sprintf(standardJoinCommand, strings.TrimSpace(input.KubeadmVerbosity+" "+input.KubeadmPatchesFlag))
 	if input.UseExperimentalRetry {
 		input.KubeadmCommand = retriableJoinScriptName
 		joinScriptFile, err := generateBootstrapScript(input)
@@ -69,6 +76,13 @@ func (input *BaseUserData) prepare() error {
 		}
 		input.WriteFiles = append(input.WriteFiles, *joinScriptFile)
 	}
+	if err := input.prepareContainerRuntime(); err != nil {
+		return errors.Wrap(err, "failed to generate container runtime configuration")
+	}
+	if err := input.prepareProxy(); err != nil {
+		return errors.Wrap(err, "failed to generate proxy configuration")
+	}
+	input.preparePatches()
 	input.SentinelFileCommand = sentinelFileCommand
 	return nil
 }
@@ -87,6 +101,10 @@ func generate(kind string, tpl string, data interface{}) ([]byte, error) {
 		return nil, errors.Wrap(err, "failed to parse ntp template")
 	}
 
+	if _, err := tm.Parse(caCertsTemplate); err != nil {
+		return nil, errors.Wrap(err, "failed to parse ca certs template")
+	}
+
 	if _, err := tm.Parse(usersTemplate); err != nil {
 		return nil, errors.Wrap(err, "failed to parse users template")
 	}
@@ -179,3 +179,257 @@ func TestNewInitControlPlaneDiskMounts(t *testing.T) {
 	g.Expect(out).To(ContainSubstring(expectedFSSetup))
 	g.Expect(out).To(ContainSubstring(expectedMounts))
 }
+
+func TestNewInitControlPlaneContainerRuntime(t *testing.T) {
+	g := NewWithT(t)
+
+	cpinput := &ControlPlaneInput{
+		BaseUserData: BaseUserData{
+			Header:             "test",
+			PreKubeadmCommands: []string{"install-containerd"},
+			ContainerRuntime: &bootstrapv1.ContainerRuntime{
+				Containerd: &bootstrapv1.Containerd{
+					SandboxImage: "k8s.gcr.io/pause:3.2",
+					RegistryMirrors: []bootstrapv1.RegistryMirror{
+						{
+							Registry:  "docker.io",
+							Endpoints: []string{"https://mirror.example.com", "https://registry-1.docker.io"},
+						},
+					},
+					AdditionalConfig: `[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc.options]
+  SystemdCgroup = true`,
+				},
+			},
+		},
+		Certificates:         secret.Certificates{},
+		ClusterConfiguration: "my-cluster-config",
+		InitConfiguration:    "my-init-config",
+	}
+
+	for _, certificate := range cpinput.Certificates {
+		certificate.KeyPair = &certs.KeyPair{
+			Cert: []byte("some certificate"),
+			Key:  []byte("some key"),
+		}
+	}
+
+	out, err := NewInitControlPlane(cpinput)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	expectedFile := []string{
+		`-   path: /etc/containerd/config.toml
+    owner: root:root
+    permissions: '0644'
+    content: |
+      version = 2`,
+		`      [plugins."io.containerd.grpc.v1.cri"]
+        sandbox_image = "k8s.gcr.io/pause:3.2"`,
+		`      [plugins."io.containerd.grpc.v1.cri".registry.mirrors."docker.io"]
+        endpoint = ["https://mirror.example.com", "https://registry-1.docker.io"]`,
+		`      [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc.options]
+        SystemdCgroup = true`,
+	}
+	for _, f := range expectedFile {
+		g.Expect(out).To(ContainSubstring(f))
+	}
+
+	// containerd must be restarted after PreKubeadmCommands and before kubeadm runs.
+	expectedCommands := `runcmd:
+  - "install-containerd"
+  - "systemctl daemon-reload && systemctl restart containerd"
+  - 'kubeadm init`
+	g.Expect(out).To(ContainSubstring(expectedCommands))
+}
+
+func TestNewNodeProxyAndCACertificates(t *testing.T) {
+	g := NewWithT(t)
+
+	input := &NodeInput{
+		BaseUserData: BaseUserData{
+			Header: "test",
+			ContainerRuntime: &bootstrapv1.ContainerRuntime{
+				Containerd: &bootstrapv1.Containerd{},
+			},
+			Proxy: &bootstrapv1.Proxy{
+				HTTPProxy:  "http://proxy.example.com:3128",
+				HTTPSProxy: "http://proxy.example.com:3128",
+				NoProxy:    []string{"localhost", "10.96.0.0/12"},
+			},
+			AdditionalCACertificates: []string{"-----BEGIN CERTIFICATE-----\nfoo\n-----END CERTIFICATE-----"},
+		},
+		JoinConfiguration: "my-join-config",
+	}
+
+	out, err := NewNode(input)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	for _, path := range []string{bootstrapv1.KubeletProxyDropInPath, bootstrapv1.ContainerdProxyDropInPath} {
+		g.Expect(out).To(ContainSubstring(`-   path: ` + path + `
+    owner: root:root
+    permissions: '0644'
+    content: |
+      [Service]
+      Environment="HTTP_PROXY=http://proxy.example.com:3128"
+      Environment="HTTPS_PROXY=http://proxy.example.com:3128"
+      Environment="NO_PROXY=localhost,10.96.0.0/12"`))
+	}
+
+	// containerd must be restarted only once, before kubeadm runs.
+	g.Expect(out).To(ContainSubstring(`runcmd:
+  - "systemctl daemon-reload && systemctl restart containerd"
+  - kubeadm join`))
+
+	g.Expect(out).To(ContainSubstring(`ca_certs:
+  trusted:
+    - |
+      -----BEGIN CERTIFICATE-----
+      foo
+      -----END CERTIFICATE-----`))
+}
+
+func TestNewInitControlPlanePatches(t *testing.T) {
+	g := NewWithT(t)
+
+	cpinput := &ControlPlaneInput{
+		BaseUserData: BaseUserData{
+			Header: "test",
+			Patches: []bootstrapv1.Patch{
+				{
+					Target:  bootstrapv1.PatchTargetKubeAPIServer,
+					Content: "metadata:\n  labels:\n    foo: bar\n",
+				},
+				{
+					Target:  bootstrapv1.PatchTargetEtcd,
+					Type:    bootstrapv1.PatchTypeJSON,
+					Content: `[{"op": "add", "path": "/metadata/labels/foo", "value": "bar"}]`,
+				},
+			},
+			KubeadmPatchesFlag: "--patches " + bootstrapv1.KubeadmPatchesDirectory,
+		},
+		Certificates:         secret.Certificates{},
+		ClusterConfiguration: "my-cluster-config",
+		InitConfiguration:    "my-init-config",
+	}
+
+	out, err := NewInitControlPlane(cpinput)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(out).To(ContainSubstring(`-   path: /run/kubeadm/patches/kube-apiserver000+strategic.yaml
+    owner: root:root
+    permissions: '0640'
+    content: |
+      metadata:
+        labels:
+          foo: bar`))
+	g.Expect(out).To(ContainSubstring(`-   path: /run/kubeadm/patches/etcd001+json.yaml`))
+	g.Expect(out).To(ContainSubstring(`kubeadm init --config /run/kubeadm/kubeadm.yaml  --patches /run/kubeadm/patches &&`))
+}
+
+func TestNewNodePatchesFlag(t *testing.T) {
+	g := NewWithT(t)
+
+	input := &NodeInput{
+		BaseUserData: BaseUserData{
+			Header:             "test",
+			KubeadmVerbosity:   "--v 5",
+			KubeadmPatchesFlag: "--experimental-patches " + bootstrapv1.KubeadmPatchesDirectory,
+		},
+		JoinConfiguration: "my-join-config",
+	}
+
+	out, err := NewNode(input)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(ContainSubstring(`kubeadm join --config /run/kubeadm/kubeadm-join-config.yaml --v 5 --experimental-patches /run/kubeadm/patches &&`))
+}
+
+func TestNewWindowsNode(t *testing.T) {
+	g := NewWithT(t)
+
+	input := &NodeInput{
+		BaseUserData: BaseUserData{
+			PreKubeadmCommands:  []string{"powershell C:/k/prepare.ps1"},
+			PostKubeadmCommands: []string{"powershell C:/k/post.ps1"},
+			AdditionalFiles: []bootstrapv1.File{
+				{
+					Path:    "C:/k/prepare.ps1",
+					Content: "Write-Output hi",
+				},
+			},
+			KubeadmVerbosity: "--v 5",
+		},
+		JoinConfiguration: "my-join-config",
+	}
+
+	out, err := NewWindowsNode(input)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(string(out)).To(HavePrefix("#cloud-config\n"))
+	g.Expect(out).NotTo(ContainSubstring("jinja"))
+	g.Expect(out).NotTo(ContainSubstring("owner:"))
+	g.Expect(out).To(ContainSubstring(`-   path: C:/k/prepare.ps1
+    content: |
+      Write-Output hi`))
+	g.Expect(out).To(ContainSubstring(`-   path: C:/run/kubeadm/kubeadm-join-config.yaml
+    content: |
+      ---
+      my-join-config`))
+	g.Expect(out).To(ContainSubstring(`runcmd:
+  - "powershell C:/k/prepare.ps1"
+  - kubeadm join --config C:/run/kubeadm/kubeadm-join-config.yaml --v 5 && echo success > C:\run\cluster-api\bootstrap-success.complete
+  - "powershell C:/k/post.ps1"`))
+}
+
+func TestGenerateProxyDropIn(t *testing.T) {
+	g := NewWithT(t)
+
+	got, err := generateProxyDropIn(&bootstrapv1.Proxy{HTTPSProxy: "http://proxy.example.com:3128"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(Equal(`[Service]
+Environment="HTTPS_PROXY=http://proxy.example.com:3128"
+`))
+}
+
+func TestGenerateContainerdConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		containerd *bootstrapv1.Containerd
+		wantPath   string
+		want       string
+	}{
+		{
+			name:       "empty config",
+			containerd: &bootstrapv1.Containerd{},
+			wantPath:   "/etc/containerd/config.toml",
+			want:       "version = 2\n",
+		},
+		{
+			name: "custom path and registry mirrors only",
+			containerd: &bootstrapv1.Containerd{
+				ConfigPath: "/etc/containerd/custom.toml",
+				RegistryMirrors: []bootstrapv1.RegistryMirror{
+					{Registry: "docker.io", Endpoints: []string{"https://mirror.example.com"}},
+					{Registry: "quay.io", Endpoints: []string{"https://quay-mirror.example.com"}},
+				},
+			},
+			wantPath: "/etc/containerd/custom.toml",
+			want: `version = 2
+
+[plugins."io.containerd.grpc.v1.cri".registry.mirrors."docker.io"]
+  endpoint = ["https://mirror.example.com"]
+
+[plugins."io.containerd.grpc.v1.cri".registry.mirrors."quay.io"]
+  endpoint = ["https://quay-mirror.example.com"]
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := generateContainerdConfig(tt.containerd)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got.Path).To(Equal(tt.wantPath))
+			g.Expect(got.Content).To(Equal(tt.want))
+		})
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"bytes"
+	"text/template"
+
+	"github.com/pkg/errors"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha4"
+)
+
+const (
+	containerdConfigOwner       = "root:root"
+	containerdConfigPermissions = "0644"
+	// containerdRestartCommand restarts containerd so the generated configuration file is in effect before kubeadm runs.
+	containerdRestartCommand = "systemctl daemon-reload && systemctl restart containerd"
+
+	containerdConfigTemplate = `version = 2
+{{- if .SandboxImage }}
+
+[plugins."io.containerd.grpc.v1.cri"]
+  sandbox_image = {{ printf "%q" .SandboxImage }}
+{{- end }}
+{{- range .RegistryMirrors }}
+
+[plugins."io.containerd.grpc.v1.cri".registry.mirrors.{{ printf "%q" .Registry }}]
+  endpoint = [{{ range $i, $e := .Endpoints }}{{ if $i }}, {{ end }}{{ printf "%q" $e }}{{ end }}]
+{{- end }}
+{{- if .AdditionalConfig }}
+
+{{ .AdditionalConfig }}
+{{- end }}
+`
+)
+
+// prepareContainerRuntime adds the container runtime configuration files to WriteFiles and
+// the commands required for the configuration to be in effect to ContainerRuntimeCommands.
+// ContainerRuntimeCommands run after PreKubeadmCommands, so the configuration also applies to
+// container runtimes installed or modified by PreKubeadmCommands.
+func (input *BaseUserData) prepareContainerRuntime() error {
+	if input.ContainerRuntime == nil || input.ContainerRuntime.Containerd == nil {
+		return nil
+	}
+
+	configFile, err := generateContainerdConfig(input.ContainerRuntime.Containerd)
+	if err != nil {
+		return err
+	}
+	input.WriteFiles = append(input.WriteFiles, *configFile)
+	input.ContainerRuntimeCommands = append(input.ContainerRuntimeCommands, containerdRestartCommand)
+	return nil
+}
+
+// generateContainerdConfig generates the containerd configuration file.
+func generateContainerdConfig(containerd *bootstrapv1.Containerd) (*bootstrapv1.File, error) {
+	t, err := template.New("containerd").Parse(containerdConfigTemplate)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse containerd config template")
+	}
+
+	var out bytes.Buffer
+	if err := t.Execute(&out, containerd); err != nil {
+		return nil, errors.Wrap(err, "failed to generate containerd config")
+	}
+
+	path := containerd.ConfigPath
+	if path == "" {
+		path = bootstrapv1.DefaultContainerdConfigPath
+	}
+	return &bootstrapv1.File{
+		Path:        path,
+		Owner:       containerdConfigOwner,
+		Permissions: containerdConfigPermissions,
+		Content:     out.String(),
+	}, nil
+}
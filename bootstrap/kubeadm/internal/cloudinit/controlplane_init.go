@@ -17,6 +17,7 @@ limitations under the License.
 package cloudinit
 
 import (
+	"github.com/pkg/errors"
 	"sigs.k8s.io/cluster-api/util/secret"
 )
 
@@ -37,9 +38,11 @@ const (
     content: "This placeholder file is used to create the /run/cluster-api sub directory in a way that is compatible with both Linux and Windows (mkdir -p /run/cluster-api does not work with Windows)"
 runcmd:
 {{- template "commands" .PreKubeadmCommands }}
-  - 'kubeadm init --config /run/kubeadm/kubeadm.yaml {{.KubeadmVerbosity}} && {{ .SentinelFileCommand }}'
+{{- template "commands" .ContainerRuntimeCommands }}
+  - 'kubeadm init --config /run/kubeadm/kubeadm.yaml {{.KubeadmVerbosity}}{{if .KubeadmPatchesFlag}} {{.KubeadmPatchesFlag}}{{end}} && {{ .SentinelFileCommand }}'
 {{- template "commands" .PostKubeadmCommands }}
 {{- template "ntp" .NTP }}
+{{- template "ca_certs" .AdditionalCACertificates }}
 {{- template "users" .Users }}
 {{- template "disk_setup" .DiskSetup}}
 {{- template "fs_setup" .DiskSetup}}
@@ -61,6 +64,13 @@ func NewInitControlPlane(input *ControlPlaneInput) ([]byte, error) {
 	input.Header = cloudConfigHeader
 	input.WriteFiles = input.Certificates.AsFiles()
 	input.WriteFiles = append(input.WriteFiles, input.AdditionalFiles...)
+	if err := input.prepareContainerRuntime(); err != nil {
+		return nil, errors.Wrap(err, "failed to generate container runtime configuration")
+	}
+	if err := input.prepareProxy(); err != nil {
+		return nil, errors.Wrap(err, "failed to generate proxy configuration")
+	}
+	input.preparePatches()
 	input.SentinelFileCommand = sentinelFileCommand
 	userData, err := generate("InitControlplane", controlPlaneCloudInit, input)
 	if err != nil {
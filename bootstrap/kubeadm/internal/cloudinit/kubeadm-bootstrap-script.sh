@@ -125,11 +125,11 @@ retry-command kubeadm join phase preflight --ignore-preflight-errors=DirAvailabl
 retry-command kubeadm join phase control-plane-prepare download-certs
 retry-command kubeadm join phase control-plane-prepare certs
 retry-command kubeadm join phase control-plane-prepare kubeconfig
-retry-command kubeadm join phase control-plane-prepare control-plane
+retry-command kubeadm join phase control-plane-prepare control-plane{{if .KubeadmPatchesFlag}} {{.KubeadmPatchesFlag}}{{end}}
 # {{ end }}
 retry-command kubeadm join phase kubelet-start
 # {{ if .ControlPlane }}
-try-or-die-command kubeadm join phase control-plane-join etcd
+try-or-die-command kubeadm join phase control-plane-join etcd{{if .KubeadmPatchesFlag}} {{.KubeadmPatchesFlag}}{{end}}
 retry-command kubeadm join phase control-plane-join update-status
 retry-command kubeadm join phase control-plane-join mark-control-plane
 # {{ end }}
@@ -0,0 +1,52 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"fmt"
+	"path"
+
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha4"
+)
+
+const (
+	patchOwner       = "root:root"
+	patchPermissions = "0640"
+)
+
+// preparePatches adds the kubeadm patches to WriteFiles.
+// The patches are applied by kubeadm only if the patches flag is passed to the kubeadm commands, see KubeadmPatchesFlag.
+func (input *BaseUserData) preparePatches() {
+	for i, patch := range input.Patches {
+		input.WriteFiles = append(input.WriteFiles, bootstrapv1.File{
+			Path:        patchPath(i, patch),
+			Owner:       patchOwner,
+			Permissions: patchPermissions,
+			Content:     patch.Content,
+		})
+	}
+}
+
+// patchPath returns the path of the file for a kubeadm patch, in the target[suffix][+patchtype].extension format expected by kubeadm.
+// The index of the patch is used as a suffix, so patches are applied in the order they are defined.
+func patchPath(index int, patch bootstrapv1.Patch) string {
+	patchType := patch.Type
+	if patchType == "" {
+		patchType = bootstrapv1.PatchTypeStrategic
+	}
+	return path.Join(bootstrapv1.KubeadmPatchesDirectory, fmt.Sprintf("%s%03d+%s.yaml", patch.Target, index, patchType))
+}
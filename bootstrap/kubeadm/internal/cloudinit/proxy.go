@@ -0,0 +1,88 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha4"
+)
+
+const (
+	proxyDropInOwner       = "root:root"
+	proxyDropInPermissions = "0644"
+
+	proxyDropInTemplate = `[Service]
+{{- if .HTTPProxy }}
+Environment="HTTP_PROXY={{ .HTTPProxy }}"
+{{- end }}
+{{- if .HTTPSProxy }}
+Environment="HTTPS_PROXY={{ .HTTPSProxy }}"
+{{- end }}
+{{- if .NoProxy }}
+Environment="NO_PROXY={{ join .NoProxy "," }}"
+{{- end }}
+`
+)
+
+// prepareProxy adds the systemd drop-ins with the HTTP proxy configuration for the kubelet and containerd to WriteFiles,
+// and the commands required for the configuration to be in effect to ContainerRuntimeCommands.
+// The kubelet is (re)started by kubeadm, so it is not required to restart it.
+func (input *BaseUserData) prepareProxy() error {
+	if input.Proxy == nil {
+		return nil
+	}
+
+	content, err := generateProxyDropIn(input.Proxy)
+	if err != nil {
+		return err
+	}
+	for _, path := range []string{bootstrapv1.KubeletProxyDropInPath, bootstrapv1.ContainerdProxyDropInPath} {
+		input.WriteFiles = append(input.WriteFiles, bootstrapv1.File{
+			Path:        path,
+			Owner:       proxyDropInOwner,
+			Permissions: proxyDropInPermissions,
+			Content:     content,
+		})
+	}
+
+	// Restart containerd only once, if not already required by the container runtime configuration.
+	for _, command := range input.ContainerRuntimeCommands {
+		if command == containerdRestartCommand {
+			return nil
+		}
+	}
+	input.ContainerRuntimeCommands = append(input.ContainerRuntimeCommands, containerdRestartCommand)
+	return nil
+}
+
+// generateProxyDropIn generates the content of the systemd drop-in with the HTTP proxy configuration.
+func generateProxyDropIn(proxy *bootstrapv1.Proxy) (string, error) {
+	t, err := template.New("proxy").Funcs(template.FuncMap{"join": strings.Join}).Parse(proxyDropInTemplate)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse proxy drop-in template")
+	}
+
+	var out bytes.Buffer
+	if err := t.Execute(&out, proxy); err != nil {
+		return "", errors.Wrap(err, "failed to generate proxy drop-in")
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	windowsJoinCommand = "kubeadm join --config C:/run/kubeadm/kubeadm-join-config.yaml %s"
+	// windowsSentinelFileCommand writes the same file written by sentinelFileCommand on Linux, using a path
+	// cmd.exe can redirect to.
+	windowsSentinelFileCommand = `echo success > C:\run\cluster-api\bootstrap-success.complete`
+	// cloudbase-init does not support jinja templates, so the header must not include the jinja marker.
+	cloudbaseInitHeader = `#cloud-config
+`
+
+	windowsNodeCloudInit = `{{.Header}}
+{{template "files" .WriteFiles}}
+-   path: C:/run/kubeadm/kubeadm-join-config.yaml
+    content: |
+      ---
+{{.JoinConfiguration | Indent 6}}
+-   path: C:/run/cluster-api/placeholder
+    content: "This placeholder file is used to create the C:/run/cluster-api sub directory"
+runcmd:
+{{- template "commands" .PreKubeadmCommands }}
+  - {{ .KubeadmCommand }} && {{ .SentinelFileCommand }}
+{{- template "commands" .PostKubeadmCommands }}
+`
+)
+
+// NewWindowsNode returns the user data string to be used on a Windows node instance, in the cloud-config
+// format supported by cloudbase-init.
+// Commands are executed by cloudbase-init, so PowerShell scripts can be run e.g. with "powershell C:/k/script.ps1";
+// only files, pre and post kubeadm commands and the kubeadm verbosity are used from the input.
+func NewWindowsNode(input *NodeInput) ([]byte, error) {
+	input.Header = cloudbaseInitHeader
+	input.WriteFiles = append(input.WriteFiles, input.AdditionalFiles...)
+	input.KubeadmCommand = strings.TrimSpace(fmt.Sprintf(windowsJoinCommand, input.KubeadmVerbosity))
+	input.SentinelFileCommand = windowsSentinelFileCommand
+	return generate("WindowsNode", windowsNodeCloudInit, input)
+}
@@ -61,18 +61,20 @@ func init() {
 }
 
 var (
-	metricsBindAddr             string
-	enableLeaderElection        bool
-	leaderElectionLeaseDuration time.Duration
-	leaderElectionRenewDeadline time.Duration
-	leaderElectionRetryPeriod   time.Duration
-	watchNamespace              string
-	profilerAddress             string
-	kubeadmConfigConcurrency    int
-	syncPeriod                  time.Duration
-	webhookPort                 int
-	webhookCertDir              string
-	healthAddr                  string
+	metricsBindAddr              string
+	enableLeaderElection         bool
+	leaderElectionLeaseDuration  time.Duration
+	leaderElectionRenewDeadline  time.Duration
+	leaderElectionRetryPeriod    time.Duration
+	watchNamespace               string
+	profilerAddress              string
+	kubeadmConfigConcurrency     int
+	syncPeriod                   time.Duration
+	webhookPort                  int
+	webhookCertDir               string
+	healthAddr                   string
+	certificatesRenewalThreshold time.Duration
+	rotateMachineCertificates    bool
 )
 
 func InitFlags(fs *pflag.FlagSet) {
@@ -106,6 +108,12 @@ func InitFlags(fs *pflag.FlagSet) {
 	fs.DurationVar(&kubeadmbootstrapcontrollers.DefaultTokenTTL, "bootstrap-token-ttl", 15*time.Minute,
 		"The amount of time the bootstrap token will be valid")
 
+	fs.DurationVar(&certificatesRenewalThreshold, "certificates-renewal-threshold", 0,
+		"The amount of time before expiry when certificates are reported as expiring (e.g. 720h). If zero, certificates expiry is not checked.")
+
+	fs.BoolVar(&rotateMachineCertificates, "rotate-machine-certificates", false,
+		"Enable the remediation of control plane machines with certificates expiring within the certificates renewal threshold.")
+
 	fs.IntVar(&webhookPort, "webhook-port", 9443,
 		"Webhook Server port")
 
@@ -187,7 +195,9 @@ func setupChecks(mgr ctrl.Manager) {
 
 func setupReconcilers(ctx context.Context, mgr ctrl.Manager) {
 	if err := (&kubeadmbootstrapcontrollers.KubeadmConfigReconciler{
-		Client: mgr.GetClient(),
+		Client:                       mgr.GetClient(),
+		CertificatesRenewalThreshold: certificatesRenewalThreshold,
+		RotateMachineCertificates:    rotateMachineCertificates,
 	}).SetupWithManager(ctx, mgr, concurrency(kubeadmConfigConcurrency)); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "KubeadmConfig")
 		os.Exit(1)
@@ -17,6 +17,7 @@ limitations under the License.
 package v1beta1
 
 import (
+	apimachineryconversion "k8s.io/apimachinery/pkg/conversion"
 	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha4"
 	"sigs.k8s.io/controller-runtime/pkg/conversion"
 )
@@ -68,3 +69,8 @@ func (dst *JoinConfiguration) ConvertFrom(srcRaw conversion.Hub) error {
 	src := srcRaw.(*bootstrapv1.JoinConfiguration)
 	return Convert_v1alpha4_JoinConfiguration_To_v1beta1_JoinConfiguration(src, dst, nil)
 }
+
+func Convert_v1alpha4_NodeRegistrationOptions_To_v1beta1_NodeRegistrationOptions(in *bootstrapv1.NodeRegistrationOptions, out *NodeRegistrationOptions, s apimachineryconversion.Scope) error {
+	// NodeRegistrationOptions.IgnorePreflightErrors exists in bootstrapv1.NodeRegistrationOptions but not in v1beta1 types (it was added in kubeadm v1beta2). Ignoring when converting.
+	return autoConvert_v1alpha4_NodeRegistrationOptions_To_v1beta1_NodeRegistrationOptions(in, out, s)
+}
@@ -22,6 +22,17 @@ const (
 	// ClusterctlLabelName is applied to all components managed by clusterctl.
 	ClusterctlLabelName = "clusterctl.cluster.x-k8s.io"
 
+	// ClusterctlManagedByLabelName is the Kubernetes recommended label applied to all the objects created or updated by clusterctl,
+	// with value ClusterctlManagedByLabelValue.
+	ClusterctlManagedByLabelName = "app.kubernetes.io/managed-by"
+
+	// ClusterctlManagedByLabelValue is the value of the ClusterctlManagedByLabelName label for the objects managed by clusterctl.
+	ClusterctlManagedByLabelValue = "clusterctl"
+
+	// ClusterctlVersionLabelName is the Kubernetes recommended label applied to all the provider components created or
+	// updated by clusterctl; the value is the version of the provider the components belong to.
+	ClusterctlVersionLabelName = "app.kubernetes.io/version"
+
 	// ClusterctlCoreLabelName is applied to all the core objects managed by clusterctl.
 	ClusterctlCoreLabelName = "clusterctl.cluster.x-k8s.io/core"
 
@@ -33,6 +44,39 @@ const (
 
 	// ClusterctlMoveLabelName can be set on CRDs that providers wish to move that are not part of a cluster.
 	ClusterctlMoveLabelName = "clusterctl.cluster.x-k8s.io/move"
+
+	// ClusterctlNamespaceCreatedAnnotation is applied to namespaces created by clusterctl.
+	ClusterctlNamespaceCreatedAnnotation = "clusterctl.cluster.x-k8s.io/namespace-created"
+
+	// ClusterctlNamespaceUserOwnedAnnotation is applied to namespaces which already existed, and were not managed by
+	// clusterctl, when installing a provider, e.g. shared namespaces created by users; those namespaces are never deleted
+	// by clusterctl.
+	ClusterctlNamespaceUserOwnedAnnotation = "clusterctl.cluster.x-k8s.io/namespace-user-owned"
+
+	// ClusterctlPausedAnnotation is applied by clusterctl to the Cluster and to the descendant objects it pauses, i.e.
+	// the objects which were not already paused; when resuming the Cluster, clusterctl resumes only these objects.
+	ClusterctlPausedAnnotation = "clusterctl.cluster.x-k8s.io/paused"
+
+	// ClusterctlTemplateLabelName is applied to ConfigMaps holding an overlay for a workload cluster template stored
+	// in the management cluster; the value is the name of the ConfigMap holding the template.
+	ClusterctlTemplateLabelName = "clusterctl.cluster.x-k8s.io/template"
+
+	// ClusterctlTemplateEnvironmentLabelName is applied to ConfigMaps holding an overlay for a workload cluster template
+	// stored in the management cluster; the value is the environment the overlay applies to, e.g. dev, staging or prod.
+	ClusterctlTemplateEnvironmentLabelName = "clusterctl.cluster.x-k8s.io/template-environment"
+
+	// ClusterctlAuditLabelName is applied to ConfigMaps holding an entry of the audit log of the operations
+	// performed by clusterctl on the management cluster; the value is the name of the operation, e.g. init or move.
+	ClusterctlAuditLabelName = "clusterctl.cluster.x-k8s.io/audit"
+
+	// ClusterctlInfrastructureTemplateHashAnnotation is applied to MachineDeployments and control planes by clusterctl alpha rotate-templates;
+	// the value is the name of the referenced infrastructure machine template and the hash of its spec, separated by a slash,
+	// and it is used for detecting changes to the template which require a rollout.
+	ClusterctlInfrastructureTemplateHashAnnotation = "clusterctl.cluster.x-k8s.io/infrastructure-template-hash"
+
+	// ClusterctlClonedFromAnnotation is applied to the infrastructure machine templates cloned by clusterctl alpha rotate-templates;
+	// the value is the name of the template the clone derives from.
+	ClusterctlClonedFromAnnotation = "clusterctl.cluster.x-k8s.io/cloned-from"
 )
 
 // ResourceLifecycle configures the lifecycle of a resource.
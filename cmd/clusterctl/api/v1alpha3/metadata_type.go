@@ -44,6 +44,23 @@ type ReleaseSeries struct {
 	//
 	// The value is an API Version, e.g. `v1alpha3`.
 	Contract string `json:"contract,omitempty"`
+
+	// Compatibility defines the versions of the core provider and of Kubernetes this release series is compatible with.
+	// If not set, compatibility is checked only against the Cluster API contract.
+	// +optional
+	Compatibility *ReleaseSeriesCompatibility `json:"compatibility,omitempty"`
+}
+
+// ReleaseSeriesCompatibility defines the versions of the core provider and of Kubernetes a release series is compatible with.
+// Versions are expressed as semantic version ranges, e.g. `>=0.4.0 <0.5.0`.
+type ReleaseSeriesCompatibility struct {
+	// CoreProvider defines the range of versions of the core provider (Cluster API) compatible with the release series.
+	// +optional
+	CoreProvider string `json:"coreProvider,omitempty"`
+
+	// Kubernetes defines the range of Kubernetes versions of the management cluster compatible with the release series.
+	// +optional
+	Kubernetes string `json:"kubernetes,omitempty"`
 }
 
 func init() {
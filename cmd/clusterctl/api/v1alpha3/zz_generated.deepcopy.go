@@ -1,3 +1,4 @@
+//go:build !ignore_autogenerated
 // +build !ignore_autogenerated
 
 /*
@@ -32,7 +33,9 @@ func (in *Metadata) DeepCopyInto(out *Metadata) {
 	if in.ReleaseSeries != nil {
 		in, out := &in.ReleaseSeries, &out.ReleaseSeries
 		*out = make([]ReleaseSeries, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
@@ -114,6 +117,11 @@ func (in *ProviderList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReleaseSeries) DeepCopyInto(out *ReleaseSeries) {
 	*out = *in
+	if in.Compatibility != nil {
+		in, out := &in.Compatibility, &out.Compatibility
+		*out = new(ReleaseSeriesCompatibility)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseSeries.
@@ -125,3 +133,18 @@ func (in *ReleaseSeries) DeepCopy() *ReleaseSeries {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseSeriesCompatibility) DeepCopyInto(out *ReleaseSeriesCompatibility) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseSeriesCompatibility.
+func (in *ReleaseSeriesCompatibility) DeepCopy() *ReleaseSeriesCompatibility {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseSeriesCompatibility)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -38,6 +38,9 @@ type ComponentsOptions repository.ComponentsOptions
 // Template wraps a YAML file that defines the cluster objects (Cluster, Machines etc.).
 type Template repository.Template
 
+// TemplateVariable describes a variable required by a cluster template.
+type TemplateVariable yaml.Variable
+
 // UpgradePlan defines a list of possible upgrade targets for a management group.
 type UpgradePlan cluster.UpgradePlan
 
@@ -51,3 +54,21 @@ type Kubeconfig cluster.Kubeconfig
 // Processor defines the methods necessary for creating a specific yaml
 // processor.
 type Processor yaml.Processor
+
+// ObjectDiff defines the differences between an object and the corresponding object in the management cluster.
+type ObjectDiff cluster.ObjectDiff
+
+// ClusterPauseStatus defines the paused state of a Cluster and of all its descendant objects.
+type ClusterPauseStatus cluster.PauseStatus
+
+// ImageResolver maps a Kubernetes version, operating system and region to a provider specific image ID.
+type ImageResolver cluster.ImageResolver
+
+// MachineLogs defines the logs of a Machine, read from its console or from its Node.
+type MachineLogs cluster.MachineLogs
+
+// OperationHistoryEntry defines an operation performed by clusterctl, as recorded in the audit log of the management cluster.
+type OperationHistoryEntry cluster.AuditEntry
+
+// MachineTemplateRotation defines the action performed for an object referencing an infrastructure machine template.
+type MachineTemplateRotation cluster.TemplateRotation
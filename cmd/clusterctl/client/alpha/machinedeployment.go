@@ -23,7 +23,6 @@ import (
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
 	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
-	"sigs.k8s.io/cluster-api/controllers/mdutil"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -66,44 +65,6 @@ func patchMachineDeployemt(proxy cluster.Proxy, name, namespace string, patch cl
 	return nil
 }
 
-// findMachineDeploymentRevision finds the specific revision in the machine sets.
-func findMachineDeploymentRevision(toRevision int64, allMSs []*clusterv1.MachineSet) (*clusterv1.MachineSet, error) {
-	var (
-		latestMachineSet   *clusterv1.MachineSet
-		latestRevision     = int64(-1)
-		previousMachineSet *clusterv1.MachineSet
-		previousRevision   = int64(-1)
-	)
-	for _, ms := range allMSs {
-		if v, err := mdutil.Revision(ms); err == nil {
-			if toRevision == 0 {
-				if latestRevision < v {
-					// newest one we've seen so far
-					previousRevision = latestRevision
-					previousMachineSet = latestMachineSet
-					latestRevision = v
-					latestMachineSet = ms
-				} else if previousRevision < v {
-					// second newest one we've seen so far
-					previousRevision = v
-					previousMachineSet = ms
-				}
-			} else if toRevision == v {
-				return ms, nil
-			}
-		}
-	}
-
-	if toRevision > 0 {
-		return nil, errors.Errorf("unable to find specified revision: %v", toRevision)
-	}
-
-	if previousMachineSet == nil {
-		return nil, errors.Errorf("no rollout history found")
-	}
-	return previousMachineSet, nil
-}
-
 // getMachineSetsForDeployment returns a list of MachineSets associated with a MachineDeployment.
 func getMachineSetsForDeployment(proxy cluster.Proxy, d *clusterv1.MachineDeployment) ([]*clusterv1.MachineSet, error) {
 	log := logf.Log
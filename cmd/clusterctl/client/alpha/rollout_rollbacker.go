@@ -62,7 +62,7 @@ func rollbackMachineDeployment(proxy cluster.Proxy, d *clusterv1.MachineDeployme
 		return err
 	}
 	log.V(7).Info("Found MachineSets", "count", len(msList))
-	msForRevision, err := findMachineDeploymentRevision(toRevision, msList)
+	msForRevision, err := mdutil.FindMachineSetForRevision(toRevision, msList)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,92 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alpha
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/rbac"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+)
+
+func Test_Rollout_RBACRules(t *testing.T) {
+	g := NewWithT(t)
+
+	deployment := &clusterv1.MachineDeployment{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "MachineDeployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "md-1",
+			Namespace: "default",
+			Annotations: map[string]string{
+				clusterv1.RevisionAnnotation: "2",
+			},
+		},
+		Spec: clusterv1.MachineDeploymentSpec{
+			ClusterName: "test",
+			Selector: metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					clusterv1.ClusterLabelName: "test",
+				},
+			},
+		},
+	}
+	machineSet := func(name, revision string) *clusterv1.MachineSet {
+		return &clusterv1.MachineSet{
+			TypeMeta: metav1.TypeMeta{
+				Kind: "MachineSet",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				OwnerReferences: []metav1.OwnerReference{
+					*metav1.NewControllerRef(deployment, clusterv1.GroupVersion.WithKind("MachineDeployment")),
+				},
+				Labels: map[string]string{
+					clusterv1.ClusterLabelName: "test",
+				},
+				Annotations: map[string]string{
+					clusterv1.RevisionAnnotation: revision,
+				},
+			},
+		}
+	}
+
+	recorder := &test.CallRecorder{}
+	proxy := test.NewFakeProxy().WithCallRecorder(recorder).WithObjs(deployment, machineSet("ms-rev-1", "1"), machineSet("ms-rev-2", "2"))
+	ref := corev1.ObjectReference{
+		Kind:      MachineDeployment,
+		Name:      "md-1",
+		Namespace: "default",
+	}
+
+	r := newRolloutClient()
+	g.Expect(r.ObjectRestarter(proxy, ref)).To(Succeed())
+	g.Expect(r.ObjectPauser(proxy, ref)).To(Succeed())
+	g.Expect(r.ObjectResumer(proxy, ref)).To(Succeed())
+	g.Expect(r.ObjectRollbacker(proxy, ref, 1)).To(Succeed())
+	g.Expect(recorder.Calls()).ToNot(BeEmpty())
+
+	roles, err := rbac.Generate(rbac.Options{Operations: []rbac.Operation{rbac.OperationRollout}})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(recorder.NotAllowed(roles)).To(BeEmpty())
+}
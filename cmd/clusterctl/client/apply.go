@@ -0,0 +1,148 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/components"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+)
+
+// ApplyOptions carries the options supported by Apply.
+type ApplyOptions struct {
+	// Kubeconfig defines the kubeconfig to use for accessing the management cluster. If empty,
+	// default rules for kubeconfig discovery will be used.
+	Kubeconfig Kubeconfig
+
+	// Namespace where the objects without a namespace should be applied. If unspecified, the current namespace will be used.
+	Namespace string
+
+	// Objects to apply to the management cluster, e.g. the objects exported from another management cluster.
+	Objects []unstructured.Unstructured
+
+	// SourceNamespace and TargetNamespace, if set, re-target the objects in the source namespace to the target namespace,
+	// including the references to the source namespace, e.g. when restoring provider components into a management cluster
+	// with a different namespace layout.
+	SourceNamespace string
+	TargetNamespace string
+}
+
+// Apply creates or updates objects in the management cluster using server side apply, so the fields set by controllers
+// or other clients and not included in the objects are preserved.
+func (c *clusterctlClient) Apply(options ApplyOptions) error {
+	log := logf.Log
+
+	// gets access to the management cluster
+	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{Kubeconfig: options.Kubeconfig})
+	if err != nil {
+		return err
+	}
+
+	// Ensure this command only runs against management clusters with the current Cluster API contract.
+	if err := clusterClient.ProviderInventory().CheckCAPIContract(); err != nil {
+		return err
+	}
+
+	// If the option specifying the Namespace is empty, try to detect it.
+	if options.Namespace == "" {
+		currentNamespace, err := clusterClient.Proxy().CurrentNamespace()
+		if err != nil {
+			return err
+		}
+		options.Namespace = currentNamespace
+	}
+
+	proxyClient, err := clusterClient.Proxy().NewClient()
+	if err != nil {
+		return err
+	}
+	objs, requiresCertManager, err := applyObjects(options, func(gvk schema.GroupVersionKind) bool {
+		// Kinds not yet served by the management cluster, e.g. kinds defined by the CRDs being applied, are assumed to be namespaced.
+		mapping, err := proxyClient.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+		return err != nil || mapping.Scope.Name() != meta.RESTScopeNameRoot
+	})
+	if err != nil {
+		return err
+	}
+
+	// If the objects depend on cert-manager, e.g. provider components, ensure cert-manager is in place before applying them.
+	if requiresCertManager {
+		certManager, err := clusterClient.CertManager()
+		if err != nil {
+			return err
+		}
+		if err := certManager.EnsureInstalled(); err != nil {
+			return err
+		}
+	}
+
+	for i := range objs {
+		obj := &objs[i]
+		if err := clusterClient.Objects().ApplyUnstructured(obj); err != nil {
+			return err
+		}
+		log.Info("Applied", obj.GetKind(), obj.GetName(), "Namespace", obj.GetNamespace())
+	}
+	return nil
+}
+
+// applyObjects returns the objects to be applied, with the defaults for the namespace of namespaced objects and the
+// namespace rewrite applied, and whether they depend on cert-manager.
+func applyObjects(options ApplyOptions, isNamespaced func(schema.GroupVersionKind) bool) ([]unstructured.Unstructured, bool, error) {
+	objs := make([]unstructured.Unstructured, 0, len(options.Objects))
+	for i := range options.Objects {
+		obj := options.Objects[i].DeepCopy()
+		if obj.GetNamespace() == "" && isNamespaced(obj.GroupVersionKind()) {
+			obj.SetNamespace(options.Namespace)
+		}
+		objs = append(objs, *obj)
+	}
+
+	model, err := components.FromUnstructured(objs)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if options.SourceNamespace == "" && options.TargetNamespace == "" {
+		return objs, model.RequiresCertManager(), nil
+	}
+	if options.SourceNamespace == "" || options.TargetNamespace == "" {
+		return nil, false, errors.New("both the source and the target namespace are required for re-targeting objects")
+	}
+
+	namespaces, err := model.TargetNamespaces()
+	if err != nil {
+		return nil, false, err
+	}
+	if !sets.NewString(namespaces...).Has(options.SourceNamespace) {
+		return nil, false, errors.Errorf("there are no objects in the %s namespace", options.SourceNamespace)
+	}
+	if err := model.RewriteNamespace(options.SourceNamespace, options.TargetNamespace); err != nil {
+		return nil, false, err
+	}
+
+	// Nb. the model sorts the objects so they can be applied in sequence, e.g. Namespaces and CRDs first.
+	objs, err = model.ToUnstructured()
+	if err != nil {
+		return nil, false, err
+	}
+	return objs, model.RequiresCertManager(), nil
+}
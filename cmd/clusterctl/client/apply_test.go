@@ -0,0 +1,126 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func Test_applyObjects(t *testing.T) {
+	obj := func(apiVersion, kind, namespace, name string) unstructured.Unstructured {
+		u := unstructured.Unstructured{}
+		u.SetAPIVersion(apiVersion)
+		u.SetKind(kind)
+		u.SetNamespace(namespace)
+		u.SetName(name)
+		return u
+	}
+	certificate := obj("cert-manager.io/v1", "Certificate", "capi-system", "serving-cert")
+	deployment := obj("apps/v1", "Deployment", "capi-system", "capi-controller-manager")
+	namespace := obj("v1", "Namespace", "", "capi-system")
+
+	type namespacedName struct {
+		kind      string
+		namespace string
+		name      string
+	}
+	tests := []struct {
+		name                    string
+		options                 ApplyOptions
+		want                    []namespacedName
+		wantRequiresCertManager bool
+		wantErr                 bool
+	}{
+		{
+			name: "objects without a namespace get the default namespace",
+			options: ApplyOptions{
+				Namespace: "default",
+				Objects:   []unstructured.Unstructured{obj("cluster.x-k8s.io/v1alpha4", "Cluster", "", "cluster1")},
+			},
+			want: []namespacedName{{kind: "Cluster", namespace: "default", name: "cluster1"}},
+		},
+		{
+			name: "objects depending on cert-manager are detected",
+			options: ApplyOptions{
+				Namespace: "default",
+				Objects:   []unstructured.Unstructured{deployment, certificate},
+			},
+			want: []namespacedName{
+				{kind: "Deployment", namespace: "capi-system", name: "capi-controller-manager"},
+				{kind: "Certificate", namespace: "capi-system", name: "serving-cert"},
+			},
+			wantRequiresCertManager: true,
+		},
+		{
+			name: "objects are re-targeted to another namespace and sorted",
+			options: ApplyOptions{
+				Namespace:       "default",
+				Objects:         []unstructured.Unstructured{deployment, namespace},
+				SourceNamespace: "capi-system",
+				TargetNamespace: "capi-providers",
+			},
+			want: []namespacedName{
+				{kind: "Namespace", name: "capi-providers"},
+				{kind: "Deployment", namespace: "capi-providers", name: "capi-controller-manager"},
+			},
+		},
+		{
+			name: "fails if there are no objects in the source namespace",
+			options: ApplyOptions{
+				Namespace:       "default",
+				Objects:         []unstructured.Unstructured{deployment},
+				SourceNamespace: "foo",
+				TargetNamespace: "capi-providers",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails if the target namespace is missing",
+			options: ApplyOptions{
+				Namespace:       "default",
+				Objects:         []unstructured.Unstructured{deployment},
+				SourceNamespace: "capi-system",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			objs, requiresCertManager, err := applyObjects(tt.options, func(gvk schema.GroupVersionKind) bool {
+				return gvk.Kind != "Namespace"
+			})
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(requiresCertManager).To(Equal(tt.wantRequiresCertManager))
+
+			got := []namespacedName{}
+			for _, o := range objs {
+				got = append(got, namespacedName{kind: o.GetKind(), namespace: o.GetNamespace(), name: o.GetName()})
+			}
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
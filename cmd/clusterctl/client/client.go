@@ -17,12 +17,18 @@ limitations under the License.
 package client
 
 import (
+	"strconv"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/alpha"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/tree"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
 )
 
 // Client is exposes the clusterctl high-level client library.
@@ -85,6 +91,58 @@ type AlphaClient interface {
 	RolloutResume(options RolloutOptions) error
 	// RolloutUndo provides rollout rollback of cluster-api resources
 	RolloutUndo(options RolloutOptions) error
+
+	// GetMachineAddresses returns the addresses of a Machine, and eventually a jump host to reach it.
+	GetMachineAddresses(options GetMachineAddressesOptions) (*MachineAddresses, error)
+
+	// GetMachineLogs returns the console logs of a Machine, or the logs of its Node if the provider does not expose console logs.
+	GetMachineLogs(options GetMachineLogsOptions) (*MachineLogs, error)
+
+	// CordonAndDrainMachine cordons the Node of a Machine and evicts, or deletes, the Pods running on it.
+	CordonAndDrainMachine(options CordonAndDrainMachineOptions) error
+
+	// UpgradeCluster upgrades the Kubernetes version of the control plane of a Cluster, and then of each of its
+	// MachineDeployments, aborting the upgrade if the number of unhealthy Machines exceeds the error budget.
+	UpgradeCluster(options UpgradeClusterOptions) error
+
+	// Wait waits for an expression of conditions on Cluster API objects to be satisfied.
+	Wait(options WaitOptions) error
+
+	// WatchCluster watches the provisioning status of a Cluster, until it becomes ready or reports a terminal failure.
+	WatchCluster(options WatchClusterOptions) error
+
+	// Diff compares objects with the corresponding objects in the management cluster using server-side dry-run.
+	Diff(options DiffOptions) ([]ObjectDiff, error)
+
+	// Apply creates or updates objects in the management cluster using server side apply.
+	Apply(options ApplyOptions) error
+
+	// DeleteObjects deletes objects from the management cluster, optionally waiting for them to be removed.
+	DeleteObjects(options DeleteObjectsOptions) error
+
+	// PauseCluster pauses the reconciliation of a Cluster and of all its descendant objects.
+	PauseCluster(options PauseClusterOptions) error
+
+	// ResumeCluster resumes the reconciliation of a Cluster and of all its descendant objects.
+	ResumeCluster(options PauseClusterOptions) error
+
+	// GetClusterPauseStatus returns the paused state of a Cluster and of all its descendant objects.
+	GetClusterPauseStatus(options PauseClusterOptions) (*ClusterPauseStatus, error)
+
+	// CollectDiagnostics collects diagnostics from the management cluster into a gzipped tarball for bug reports.
+	CollectDiagnostics(options CollectDiagnosticsOptions) error
+
+	// GetOperationHistory returns the operations performed by clusterctl on the management cluster, as recorded in its audit log.
+	GetOperationHistory(options GetOperationHistoryOptions) ([]OperationHistoryEntry, error)
+
+	// ExportCluster exports the definition of a Cluster as a bundle of objects that can be applied to any management cluster.
+	ExportCluster(options ExportClusterOptions) ([]unstructured.Unstructured, error)
+
+	// RotateMachineTemplates rolls out the Machines of a Cluster created from infrastructure machine templates which changed since the last check.
+	RotateMachineTemplates(options RotateMachineTemplatesOptions) ([]MachineTemplateRotation, error)
+
+	// WaitForProviderComponentsReady waits for the Deployments and the webhooks of the providers installed in a namespace to be serving.
+	WaitForProviderComponentsReady(options WaitForProviderComponentsReadyOptions) error
 }
 
 // YamlPrinter exposes methods that prints the processed template and
@@ -103,6 +161,8 @@ type clusterctlClient struct {
 	repositoryClientFactory RepositoryClientFactory
 	clusterClientFactory    ClusterClientFactory
 	alphaClient             alpha.Client
+	proxyOptions            []cluster.ProxyOption
+	logger                  logr.Logger
 }
 
 // RepositoryClientFactoryInput represents the inputs required by the
@@ -150,6 +210,58 @@ func InjectClusterClientFactory(factory ClusterClientFactory) Option {
 	}
 }
 
+// InjectImpersonation makes all the requests to the management cluster impersonate the given user and groups,
+// so clusterctl operations can be executed on behalf of users with reduced privileges.
+// The option is ignored when a ClusterClientFactory is injected with InjectClusterClientFactory.
+func InjectImpersonation(user string, groups ...string) Option {
+	return func(c *clusterctlClient) {
+		c.proxyOptions = append(c.proxyOptions, cluster.InjectImpersonation(user, groups...))
+	}
+}
+
+// InjectNamespace scopes all the operations to the given namespace, i.e. it is used as a default by all the
+// operations accepting a namespace, in place of the namespace from the kubeconfig file.
+// The option is ignored when a ClusterClientFactory is injected with InjectClusterClientFactory.
+func InjectNamespace(namespace string) Option {
+	return func(c *clusterctlClient) {
+		c.proxyOptions = append(c.proxyOptions, cluster.InjectNamespace(namespace))
+	}
+}
+
+// InjectProxyURL makes all the requests to the management cluster go through the given HTTP, HTTPS or SOCKS5 proxy.
+// The option is ignored when a ClusterClientFactory is injected with InjectClusterClientFactory.
+func InjectProxyURL(proxyURL string) Option {
+	return func(c *clusterctlClient) {
+		c.proxyOptions = append(c.proxyOptions, cluster.InjectProxyURL(proxyURL))
+	}
+}
+
+// InjectCAData adds PEM encoded certificate authorities to the ones trusted when connecting to the management cluster.
+// The option is ignored when a ClusterClientFactory is injected with InjectClusterClientFactory.
+func InjectCAData(caData []byte) Option {
+	return func(c *clusterctlClient) {
+		c.proxyOptions = append(c.proxyOptions, cluster.InjectCAData(caData))
+	}
+}
+
+// InjectInsecureSkipTLSVerify disables the verification of the management cluster's certificate.
+// The option is ignored when a ClusterClientFactory is injected with InjectClusterClientFactory.
+func InjectInsecureSkipTLSVerify(insecure bool) Option {
+	return func(c *clusterctlClient) {
+		c.proxyOptions = append(c.proxyOptions, cluster.InjectInsecureSkipTLSVerify(insecure))
+	}
+}
+
+// InjectLogger sets the logr.Logger the client logs through, so consumers embedding the library can route its structured
+// logs, e.g. the operations performed on the management cluster with their parameters and duration, into their own logging
+// stack and control their verbosity. The logger is used only by this client; if not injected, the global logger is used.
+// NOTE: The lower level clusterctl libraries log through the global logger, which can be set with log.SetLogger.
+func InjectLogger(logger logr.Logger) Option {
+	return func(c *clusterctlClient) {
+		c.logger = logger
+	}
+}
+
 // New returns a configClient.
 func New(path string, options ...Option) (Client, error) {
 	return newClusterctlClient(path, options...)
@@ -171,6 +283,11 @@ func newClusterctlClient(path string, options ...Option) (*clusterctlClient, err
 		client.configClient = c
 	}
 
+	// if there is an injected logger, use it, otherwise use the global one.
+	if client.logger == nil {
+		client.logger = logf.Log
+	}
+
 	// if there is an injected RepositoryFactory, use it, otherwise use a default one.
 	if client.repositoryClientFactory == nil {
 		client.repositoryClientFactory = defaultRepositoryFactory(client.configClient)
@@ -178,7 +295,7 @@ func newClusterctlClient(path string, options ...Option) (*clusterctlClient, err
 
 	// if there is an injected ClusterFactory, use it, otherwise use a default one.
 	if client.clusterClientFactory == nil {
-		client.clusterClientFactory = defaultClusterFactory(client.configClient)
+		client.clusterClientFactory = defaultClusterFactory(client.configClient, client.proxyOptions...)
 	}
 
 	// if there is an injected alphaClient, use it, otherwise use a default one.
@@ -202,13 +319,52 @@ func defaultRepositoryFactory(configClient config.Client) RepositoryClientFactor
 }
 
 // defaultClusterFactory is a ClusterClientFactory func the uses the default client provided by the cluster low level library.
-func defaultClusterFactory(configClient config.Client) ClusterClientFactory {
+func defaultClusterFactory(configClient config.Client, proxyOptions ...cluster.ProxyOption) ClusterClientFactory {
 	return func(input ClusterClientFactoryInput) (cluster.Client, error) {
+		// The connection settings from the clusterctl configuration are applied first, so injected options take precedence.
+		connectionOptions, err := connectionOptionsFromConfig(configClient)
+		if err != nil {
+			return nil, err
+		}
 		return cluster.New(
 			// Kubeconfig is a type alias to cluster.Kubeconfig
 			cluster.Kubeconfig(input.Kubeconfig),
 			configClient,
 			cluster.InjectYamlProcessor(input.Processor),
+			cluster.InjectProxyOptions(connectionOptions...),
+			cluster.InjectProxyOptions(proxyOptions...),
 		), nil
 	}
 }
+
+const (
+	// ManagementClusterProxyURLVariable is the variable defining the HTTP, HTTPS or SOCKS5 proxy used for connecting to the management cluster.
+	ManagementClusterProxyURLVariable = "CLUSTERCTL_MANAGEMENT_CLUSTER_PROXY_URL"
+
+	// ManagementClusterCAFileVariable is the variable defining a file with additional certificate authorities trusted
+	// when connecting to the management cluster.
+	ManagementClusterCAFileVariable = "CLUSTERCTL_MANAGEMENT_CLUSTER_CA_FILE"
+
+	// ManagementClusterInsecureSkipTLSVerifyVariable is the variable disabling the verification of the management cluster's certificate.
+	ManagementClusterInsecureSkipTLSVerifyVariable = "CLUSTERCTL_MANAGEMENT_CLUSTER_INSECURE_SKIP_TLS_VERIFY"
+)
+
+// connectionOptionsFromConfig returns the proxy options for the management cluster connection settings defined
+// in the clusterctl configuration file or in environment variables.
+func connectionOptionsFromConfig(configClient config.Client) ([]cluster.ProxyOption, error) {
+	var options []cluster.ProxyOption
+	if proxyURL, err := configClient.Variables().Get(ManagementClusterProxyURLVariable); err == nil && proxyURL != "" {
+		options = append(options, cluster.InjectProxyURL(proxyURL))
+	}
+	if caFile, err := configClient.Variables().Get(ManagementClusterCAFileVariable); err == nil && caFile != "" {
+		options = append(options, cluster.InjectCAFile(caFile))
+	}
+	if insecure, err := configClient.Variables().Get(ManagementClusterInsecureSkipTLSVerifyVariable); err == nil && insecure != "" {
+		v, err := strconv.ParseBool(insecure)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid value for %s", ManagementClusterInsecureSkipTLSVerifyVariable)
+		}
+		options = append(options, cluster.InjectInsecureSkipTLSVerify(v))
+	}
+	return options, nil
+}
@@ -21,7 +21,9 @@ import (
 	"testing"
 	"time"
 
+	. "github.com/onsi/gomega"
 	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -99,6 +101,74 @@ func (f fakeClient) Delete(options DeleteOptions) error {
 	return f.internalClient.Delete(options)
 }
 
+func (f fakeClient) GetMachineAddresses(options GetMachineAddressesOptions) (*MachineAddresses, error) {
+	return f.internalClient.GetMachineAddresses(options)
+}
+
+func (f fakeClient) Wait(options WaitOptions) error {
+	return f.internalClient.Wait(options)
+}
+
+func (f fakeClient) WatchCluster(options WatchClusterOptions) error {
+	return f.internalClient.WatchCluster(options)
+}
+
+func (f fakeClient) Diff(options DiffOptions) ([]ObjectDiff, error) {
+	return f.internalClient.Diff(options)
+}
+
+func (f fakeClient) Apply(options ApplyOptions) error {
+	return f.internalClient.Apply(options)
+}
+
+func (f fakeClient) DeleteObjects(options DeleteObjectsOptions) error {
+	return f.internalClient.DeleteObjects(options)
+}
+
+func (f fakeClient) PauseCluster(options PauseClusterOptions) error {
+	return f.internalClient.PauseCluster(options)
+}
+
+func (f fakeClient) ResumeCluster(options PauseClusterOptions) error {
+	return f.internalClient.ResumeCluster(options)
+}
+
+func (f fakeClient) GetClusterPauseStatus(options PauseClusterOptions) (*ClusterPauseStatus, error) {
+	return f.internalClient.GetClusterPauseStatus(options)
+}
+
+func (f fakeClient) CollectDiagnostics(options CollectDiagnosticsOptions) error {
+	return f.internalClient.CollectDiagnostics(options)
+}
+
+func (f fakeClient) GetOperationHistory(options GetOperationHistoryOptions) ([]OperationHistoryEntry, error) {
+	return f.internalClient.GetOperationHistory(options)
+}
+
+func (f fakeClient) ExportCluster(options ExportClusterOptions) ([]unstructured.Unstructured, error) {
+	return f.internalClient.ExportCluster(options)
+}
+
+func (f fakeClient) RotateMachineTemplates(options RotateMachineTemplatesOptions) ([]MachineTemplateRotation, error) {
+	return f.internalClient.RotateMachineTemplates(options)
+}
+
+func (f fakeClient) WaitForProviderComponentsReady(options WaitForProviderComponentsReadyOptions) error {
+	return f.internalClient.WaitForProviderComponentsReady(options)
+}
+
+func (f fakeClient) GetMachineLogs(options GetMachineLogsOptions) (*MachineLogs, error) {
+	return f.internalClient.GetMachineLogs(options)
+}
+
+func (f fakeClient) CordonAndDrainMachine(options CordonAndDrainMachineOptions) error {
+	return f.internalClient.CordonAndDrainMachine(options)
+}
+
+func (f fakeClient) UpgradeCluster(options UpgradeClusterOptions) error {
+	return f.internalClient.UpgradeCluster(options)
+}
+
 func (f fakeClient) Move(options MoveOptions) error {
 	return f.internalClient.Move(options)
 }
@@ -309,6 +379,46 @@ func (f *fakeClusterClient) WorkloadCluster() cluster.WorkloadCluster {
 	return f.internalclient.WorkloadCluster()
 }
 
+func (f *fakeClusterClient) Objects() cluster.ObjectsClient {
+	return f.internalclient.Objects()
+}
+
+func (f *fakeClusterClient) ClusterPauser() cluster.ClusterPauser {
+	return f.internalclient.ClusterPauser()
+}
+
+func (f *fakeClusterClient) Diagnostics() cluster.DiagnosticsCollector {
+	return f.internalclient.Diagnostics()
+}
+
+func (f *fakeClusterClient) MachineLogs() cluster.MachineLogsClient {
+	return f.internalclient.MachineLogs()
+}
+
+func (f *fakeClusterClient) MachineDrain() cluster.MachineDrainClient {
+	return f.internalclient.MachineDrain()
+}
+
+func (f *fakeClusterClient) Audit() cluster.AuditClient {
+	return f.internalclient.Audit()
+}
+
+func (f *fakeClusterClient) ClusterExporter() cluster.ClusterExporter {
+	return f.internalclient.ClusterExporter()
+}
+
+func (f *fakeClusterClient) TemplateRotator() cluster.TemplateRotator {
+	return f.internalclient.TemplateRotator()
+}
+
+func (f *fakeClusterClient) ProviderHealth() cluster.ProviderHealthClient {
+	return f.internalclient.ProviderHealth()
+}
+
+func (f *fakeClusterClient) EventRecorder() cluster.EventRecorder {
+	return f.internalclient.EventRecorder()
+}
+
 func (f *fakeClusterClient) WithObjs(objs ...client.Object) *fakeClusterClient {
 	f.fakeProxy.WithObjs(objs...)
 	return f
@@ -543,3 +653,52 @@ func (f *fakeComponentClient) Get(options repository.ComponentsOptions) (reposit
 		},
 	)
 }
+
+func Test_connectionOptionsFromConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		configVars  map[string]string
+		wantOptions int
+		wantErr     bool
+	}{
+		{
+			name:        "no connection settings",
+			configVars:  map[string]string{},
+			wantOptions: 0,
+		},
+		{
+			name: "all the connection settings",
+			configVars: map[string]string{
+				ManagementClusterProxyURLVariable:              "http://proxy:3128",
+				ManagementClusterCAFileVariable:                "/tmp/ca.pem",
+				ManagementClusterInsecureSkipTLSVerifyVariable: "false",
+			},
+			wantOptions: 3,
+		},
+		{
+			name: "invalid insecure skip TLS verify",
+			configVars: map[string]string{
+				ManagementClusterInsecureSkipTLSVerifyVariable: "maybe",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			configClient := newFakeConfig()
+			for k, v := range tt.configVars {
+				configClient.WithVar(k, v)
+			}
+
+			options, err := connectionOptionsFromConfig(configClient)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(options).To(HaveLen(tt.wantOptions))
+		})
+	}
+}
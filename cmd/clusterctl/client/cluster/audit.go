@@ -0,0 +1,269 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/user"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// AuditNamespace is the namespace where the entries of the audit log are stored.
+	AuditNamespace = "clusterctl-system"
+
+	// auditNamePrefix is the prefix of the name of the ConfigMaps holding the entries of the audit log.
+	auditNamePrefix = "clusterctl-audit"
+
+	auditOperationKey  = "operation"
+	auditUserKey       = "user"
+	auditTimestampKey  = "timestamp"
+	auditOutcomeKey    = "outcome"
+	auditMessageKey    = "message"
+	auditParametersKey = "parameters"
+)
+
+// Names of the operations recorded in the audit log.
+const (
+	AuditOperationInit            = "init"
+	AuditOperationDelete          = "delete"
+	AuditOperationMove            = "move"
+	AuditOperationUpgradeApply    = "upgrade-apply"
+	AuditOperationUpgradeCluster  = "upgrade-cluster"
+	AuditOperationDrain           = "drain"
+	AuditOperationForceDelete     = "force-delete"
+	AuditOperationRotateTemplates = "rotate-templates"
+)
+
+// AuditOutcome is the outcome of an operation recorded in the audit log.
+type AuditOutcome string
+
+const (
+	// AuditOutcomeSucceeded is the outcome of an operation completed successfully.
+	AuditOutcomeSucceeded = AuditOutcome("Succeeded")
+
+	// AuditOutcomeFailed is the outcome of an operation which returned an error.
+	AuditOutcomeFailed = AuditOutcome("Failed")
+)
+
+// AuditEntry is an entry of the audit log, describing an operation performed by clusterctl on the management cluster.
+type AuditEntry struct {
+	// Operation is the name of the operation, e.g. init or move.
+	Operation string
+
+	// User is the user who performed the operation.
+	User string
+
+	// Parameters are the parameters of the operation, e.g. the name of the cluster being moved.
+	Parameters map[string]string
+
+	// Timestamp is the time when the operation completed.
+	Timestamp time.Time
+
+	// Outcome is the outcome of the operation.
+	Outcome AuditOutcome
+
+	// Message is the error returned by a failed operation; it is empty for operations completed successfully.
+	Message string
+}
+
+// AuditListOptions carries the options supported by AuditClient.List.
+type AuditListOptions struct {
+	// Operation filters the entries by operation; if empty, all the entries are returned.
+	Operation string
+
+	// Limit is the maximum number of entries to return, starting from the most recent; zero means no limit.
+	Limit int
+}
+
+// AuditClient records the operations performed by clusterctl in an audit log stored in the management cluster,
+// so operators can reconstruct who changed the management cluster out-of-band with respect to the controllers.
+type AuditClient interface {
+	// Record adds an entry to the audit log; if the user is not set, it is inferred from the environment.
+	// NOTE: Recording is best effort, and failures are logged but not returned, so they never block the
+	// operation being recorded.
+	Record(entry AuditEntry)
+
+	// List returns the entries of the audit log, sorted from the most recent to the oldest.
+	List(options AuditListOptions) ([]AuditEntry, error)
+}
+
+// auditClient implements AuditClient storing the entries of the audit log as ConfigMaps.
+type auditClient struct {
+	proxy Proxy
+}
+
+// ensure auditClient implements the AuditClient interface.
+var _ AuditClient = &auditClient{}
+
+// newAuditClient returns an auditClient.
+func newAuditClient(proxy Proxy) *auditClient {
+	return &auditClient{
+		proxy: proxy,
+	}
+}
+
+func (a *auditClient) Record(entry AuditEntry) {
+	log := logf.Log
+
+	if entry.User == "" {
+		entry.User = a.currentUser()
+	}
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+
+	if err := a.record(entry); err != nil {
+		log.V(5).Info("Failed to record the operation in the audit log", "Operation", entry.Operation, "Error", err.Error())
+	}
+}
+
+func (a *auditClient) record(entry AuditEntry) error {
+	cm, err := auditEntryToConfigMap(entry)
+	if err != nil {
+		return err
+	}
+
+	c, err := a.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	// Ensures the namespace hosting the audit log exists.
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: AuditNamespace}}
+	if err := c.Create(ctx, ns); err != nil && !apierrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "failed to create namespace %q", AuditNamespace)
+	}
+
+	if err := c.Create(ctx, cm); err != nil {
+		return errors.Wrapf(err, "failed to create ConfigMap %s/%s", cm.Namespace, cm.Name)
+	}
+	return nil
+}
+
+func (a *auditClient) List(options AuditListOptions) ([]AuditEntry, error) {
+	c, err := a.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var selector client.ListOption = client.HasLabels{clusterctlv1.ClusterctlAuditLabelName}
+	if options.Operation != "" {
+		selector = client.MatchingLabels{clusterctlv1.ClusterctlAuditLabelName: options.Operation}
+	}
+
+	cmList := &corev1.ConfigMapList{}
+	if err := c.List(ctx, cmList, client.InNamespace(AuditNamespace), selector); err != nil {
+		return nil, errors.Wrap(err, "failed to list the entries of the audit log")
+	}
+
+	entries := make([]AuditEntry, 0, len(cmList.Items))
+	for i := range cmList.Items {
+		entry, err := configMapToAuditEntry(&cmList.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.After(entries[j].Timestamp)
+	})
+
+	if options.Limit > 0 && len(entries) > options.Limit {
+		entries = entries[:options.Limit]
+	}
+	return entries, nil
+}
+
+// currentUser returns the user performing an operation, that is the local user running clusterctl and,
+// if set, the user impersonated when accessing the management cluster.
+func (a *auditClient) currentUser() string {
+	name := "unknown"
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		name = u.Username
+	}
+
+	if config, err := a.proxy.GetConfig(); err == nil && config != nil && config.Impersonate.UserName != "" {
+		name = fmt.Sprintf("%s (as %s)", name, config.Impersonate.UserName)
+	}
+	return name
+}
+
+// auditEntryToConfigMap returns the ConfigMap holding an entry of the audit log.
+func auditEntryToConfigMap(entry AuditEntry) (*corev1.ConfigMap, error) {
+	if entry.Operation == "" {
+		return nil, errors.New("invalid audit entry: operation must be set")
+	}
+
+	parameters, err := json.Marshal(entry.Parameters)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal the parameters of the audit entry")
+	}
+
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s-%x", auditNamePrefix, entry.Operation, entry.Timestamp.UnixNano()),
+			Namespace: AuditNamespace,
+			Labels: map[string]string{
+				clusterctlv1.ClusterctlLabelName:      "",
+				clusterctlv1.ClusterctlAuditLabelName: entry.Operation,
+			},
+		},
+		Data: map[string]string{
+			auditOperationKey:  entry.Operation,
+			auditUserKey:       entry.User,
+			auditTimestampKey:  entry.Timestamp.UTC().Format(time.RFC3339Nano),
+			auditOutcomeKey:    string(entry.Outcome),
+			auditMessageKey:    entry.Message,
+			auditParametersKey: string(parameters),
+		},
+	}, nil
+}
+
+// configMapToAuditEntry returns the entry of the audit log stored in a ConfigMap.
+func configMapToAuditEntry(cm *corev1.ConfigMap) (AuditEntry, error) {
+	entry := AuditEntry{
+		Operation: cm.Data[auditOperationKey],
+		User:      cm.Data[auditUserKey],
+		Outcome:   AuditOutcome(cm.Data[auditOutcomeKey]),
+		Message:   cm.Data[auditMessageKey],
+	}
+
+	timestamp, err := time.Parse(time.RFC3339Nano, cm.Data[auditTimestampKey])
+	if err != nil {
+		return AuditEntry{}, errors.Wrapf(err, "invalid timestamp in audit entry %s/%s", cm.Namespace, cm.Name)
+	}
+	entry.Timestamp = timestamp
+
+	if parameters := cm.Data[auditParametersKey]; parameters != "" {
+		if err := json.Unmarshal([]byte(parameters), &entry.Parameters); err != nil {
+			return AuditEntry{}, errors.Wrapf(err, "invalid parameters in audit entry %s/%s", cm.Namespace, cm.Name)
+		}
+	}
+	return entry, nil
+}
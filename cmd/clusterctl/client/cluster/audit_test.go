@@ -0,0 +1,132 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+)
+
+func Test_auditClient_Record(t *testing.T) {
+	g := NewWithT(t)
+
+	proxy := test.NewFakeProxy()
+	a := newAuditClient(proxy)
+
+	timestamp := time.Date(2021, 4, 12, 10, 22, 31, 0, time.UTC)
+	a.Record(AuditEntry{
+		Operation:  AuditOperationMove,
+		User:       "alice",
+		Parameters: map[string]string{"namespace": "ns1", "clusterName": "cluster1"},
+		Timestamp:  timestamp,
+		Outcome:    AuditOutcomeFailed,
+		Message:    "something went wrong",
+	})
+
+	c, err := proxy.NewClient()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	cms := &corev1.ConfigMapList{}
+	g.Expect(c.List(ctx, cms)).To(Succeed())
+	g.Expect(cms.Items).To(HaveLen(1))
+
+	cm := cms.Items[0]
+	g.Expect(cm.Namespace).To(Equal(AuditNamespace))
+	g.Expect(cm.Labels).To(HaveKeyWithValue(clusterctlv1.ClusterctlAuditLabelName, AuditOperationMove))
+
+	entries, err := a.List(AuditListOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(entries).To(Equal([]AuditEntry{
+		{
+			Operation:  AuditOperationMove,
+			User:       "alice",
+			Parameters: map[string]string{"namespace": "ns1", "clusterName": "cluster1"},
+			Timestamp:  timestamp,
+			Outcome:    AuditOutcomeFailed,
+			Message:    "something went wrong",
+		},
+	}))
+}
+
+func Test_auditClient_Record_defaults(t *testing.T) {
+	g := NewWithT(t)
+
+	a := newAuditClient(test.NewFakeProxy())
+	a.Record(AuditEntry{Operation: AuditOperationInit, Outcome: AuditOutcomeSucceeded})
+
+	entries, err := a.List(AuditListOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(entries).To(HaveLen(1))
+	g.Expect(entries[0].User).NotTo(BeEmpty())
+	g.Expect(entries[0].Timestamp.IsZero()).To(BeFalse())
+}
+
+func Test_auditClient_List(t *testing.T) {
+	base := time.Date(2021, 4, 12, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		options        AuditListOptions
+		wantOperations []string
+	}{
+		{
+			name:           "all the entries, most recent first",
+			options:        AuditListOptions{},
+			wantOperations: []string{AuditOperationUpgradeApply, AuditOperationMove, AuditOperationMove, AuditOperationInit},
+		},
+		{
+			name:           "filter by operation",
+			options:        AuditListOptions{Operation: AuditOperationMove},
+			wantOperations: []string{AuditOperationMove, AuditOperationMove},
+		},
+		{
+			name:           "limit",
+			options:        AuditListOptions{Limit: 2},
+			wantOperations: []string{AuditOperationUpgradeApply, AuditOperationMove},
+		},
+		{
+			name:           "no entries for the operation",
+			options:        AuditListOptions{Operation: AuditOperationDelete},
+			wantOperations: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			a := newAuditClient(test.NewFakeProxy())
+			a.Record(AuditEntry{Operation: AuditOperationMove, User: "alice", Timestamp: base.Add(2 * time.Minute), Outcome: AuditOutcomeSucceeded})
+			a.Record(AuditEntry{Operation: AuditOperationInit, User: "alice", Timestamp: base, Outcome: AuditOutcomeSucceeded})
+			a.Record(AuditEntry{Operation: AuditOperationUpgradeApply, User: "bob", Timestamp: base.Add(3 * time.Minute), Outcome: AuditOutcomeSucceeded})
+			a.Record(AuditEntry{Operation: AuditOperationMove, User: "bob", Timestamp: base.Add(time.Minute), Outcome: AuditOutcomeFailed})
+
+			entries, err := a.List(tt.options)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			operations := []string{}
+			for _, e := range entries {
+				operations = append(operations, e.Operation)
+			}
+			g.Expect(operations).To(Equal(tt.wantOperations))
+		})
+	}
+}
@@ -407,33 +407,36 @@ func (cm *certManagerClient) createObj(obj unstructured.Unstructured) error {
 	// check if the component already exists, and eventually update it; otherwise create it
 	// NOTE: This is required because this func is used also for upgrading cert-manager and during upgrades
 	// some objects of the previous release are preserved in order to avoid to delete user data (e.g. CRDs).
-	currentR := &unstructured.Unstructured{}
-	currentR.SetGroupVersionKind(obj.GroupVersionKind())
+	// NOTE: The object is read again on conflicts, so the update uses the latest resource version.
+	return retryOnConflict(newConflictBackoff(cm.configClient), func() error {
+		currentR := &unstructured.Unstructured{}
+		currentR.SetGroupVersionKind(obj.GroupVersionKind())
+
+		key := client.ObjectKey{
+			Namespace: obj.GetNamespace(),
+			Name:      obj.GetName(),
+		}
+		if err := c.Get(ctx, key, currentR); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return errors.Wrapf(err, "failed to get cert-manager object %s, %s/%s", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+			}
 
-	key := client.ObjectKey{
-		Namespace: obj.GetNamespace(),
-		Name:      obj.GetName(),
-	}
-	if err := c.Get(ctx, key, currentR); err != nil {
-		if !apierrors.IsNotFound(err) {
-			return errors.Wrapf(err, "failed to get cert-manager object %s, %s/%s", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+			// if it does not exists, create the component
+			log.V(5).Info("Creating", logf.UnstructuredToValues(obj)...)
+			if err := c.Create(ctx, &obj); err != nil {
+				return errors.Wrapf(err, "failed to create cert-manager component %s, %s/%s", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+			}
+			return nil
 		}
 
-		// if it does not exists, create the component
-		log.V(5).Info("Creating", logf.UnstructuredToValues(obj)...)
-		if err := c.Create(ctx, &obj); err != nil {
-			return errors.Wrapf(err, "failed to create cert-manager component %s, %s/%s", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+		// otherwise update the component
+		log.V(5).Info("Updating", logf.UnstructuredToValues(obj)...)
+		obj.SetResourceVersion(currentR.GetResourceVersion())
+		if err := c.Update(ctx, &obj); err != nil {
+			return errors.Wrapf(err, "failed to update cert-manager component %s, %s/%s", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
 		}
 		return nil
-	}
-
-	// otherwise update the component
-	log.V(5).Info("Updating", logf.UnstructuredToValues(obj)...)
-	obj.SetResourceVersion(currentR.GetResourceVersion())
-	if err := c.Update(ctx, &obj); err != nil {
-		return errors.Wrapf(err, "failed to update cert-manager component %s, %s/%s", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
-	}
-	return nil
+	})
 }
 
 func (cm *certManagerClient) deleteObj(obj unstructured.Unstructured) error {
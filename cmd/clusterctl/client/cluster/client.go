@@ -18,12 +18,15 @@ package cluster
 
 import (
 	"context"
+	"strconv"
 	"time"
 
 	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
 	yaml "sigs.k8s.io/cluster-api/cmd/clusterctl/client/yamlprocessor"
@@ -33,6 +36,11 @@ import (
 
 const (
 	minimumKubernetesVersion = "v1.19.1"
+
+	// conflictRetryAttemptsConfigKey is the clusterctl configuration variable defining the number of attempts for
+	// read-modify-write operations failed because of conflicts.
+	conflictRetryAttemptsConfigKey = "conflict-retry-attempts"
+	defaultConflictRetryAttempts   = 7
 )
 
 var (
@@ -89,6 +97,40 @@ type Client interface {
 
 	// WorkloadCluster has methods for fetching kubeconfig of workload cluster from management cluster.
 	WorkloadCluster() WorkloadCluster
+
+	// Objects has methods to work with generic Kubernetes objects stored in the management cluster.
+	Objects() ObjectsClient
+
+	// ClusterPauser returns a ClusterPauser that supports pausing and resuming the reconciliation of a Cluster
+	// and of all its descendant objects.
+	ClusterPauser() ClusterPauser
+
+	// EventRecorder returns an EventRecorder that records Kubernetes Events on the objects changed by clusterctl.
+	EventRecorder() EventRecorder
+
+	// Diagnostics returns a DiagnosticsCollector that collects diagnostics from the management cluster for bug reports.
+	Diagnostics() DiagnosticsCollector
+
+	// MachineLogs returns a MachineLogsClient that reads the console logs of Machines, or the logs of their Nodes.
+	MachineLogs() MachineLogsClient
+
+	// MachineDrain returns a MachineDrainClient that cordons and drains the Nodes of Machines.
+	MachineDrain() MachineDrainClient
+
+	// Audit returns an AuditClient that records the operations performed by clusterctl in an audit log
+	// stored in the management cluster.
+	Audit() AuditClient
+
+	// ClusterExporter returns a ClusterExporter that exports the definition of a Cluster as a bundle of objects
+	// that can be applied to any management cluster.
+	ClusterExporter() ClusterExporter
+
+	// TemplateRotator returns a TemplateRotator that rolls out Machines when the infrastructure machine templates
+	// they are created from change.
+	TemplateRotator() TemplateRotator
+
+	// ProviderHealth returns a ProviderHealthClient that checks the components of a provider are ready to serve requests.
+	ProviderHealth() ProviderHealthClient
 }
 
 // PollImmediateWaiter tries a condition func until it returns true, an error, or the timeout is reached.
@@ -102,6 +144,7 @@ type clusterClient struct {
 	repositoryClientFactory RepositoryClientFactory
 	pollImmediateWaiter     PollImmediateWaiter
 	processor               yaml.Processor
+	proxyOptions            []ProxyOption
 }
 
 type RepositoryClientFactory func(provider config.Provider, configClient config.Client, options ...repository.Option) (repository.Client, error)
@@ -130,15 +173,15 @@ func (c *clusterClient) ProviderInventory() InventoryClient {
 }
 
 func (c *clusterClient) ProviderInstaller() ProviderInstaller {
-	return newProviderInstaller(c.configClient, c.repositoryClientFactory, c.proxy, c.ProviderInventory(), c.ProviderComponents())
+	return newProviderInstaller(c.configClient, c.repositoryClientFactory, c.proxy, c.ProviderInventory(), c.ProviderComponents(), c.pollImmediateWaiter)
 }
 
 func (c *clusterClient) ObjectMover() ObjectMover {
-	return newObjectMover(c.proxy, c.ProviderInventory())
+	return newObjectMover(c.configClient, c.proxy, c.ProviderInventory())
 }
 
 func (c *clusterClient) ProviderUpgrader() ProviderUpgrader {
-	return newProviderUpgrader(c.configClient, c.repositoryClientFactory, c.ProviderInventory(), c.ProviderComponents())
+	return newProviderUpgrader(c.configClient, c.repositoryClientFactory, c.proxy, c.ProviderInventory(), c.ProviderComponents())
 }
 
 func (c *clusterClient) Template() TemplateClient {
@@ -149,6 +192,46 @@ func (c *clusterClient) WorkloadCluster() WorkloadCluster {
 	return newWorkloadCluster(c.proxy)
 }
 
+func (c *clusterClient) Objects() ObjectsClient {
+	return newObjectsClient(c.configClient, c.proxy, c.pollImmediateWaiter)
+}
+
+func (c *clusterClient) ClusterPauser() ClusterPauser {
+	return newClusterPauser(c.proxy)
+}
+
+func (c *clusterClient) EventRecorder() EventRecorder {
+	return newEventRecorder(c.proxy)
+}
+
+func (c *clusterClient) Diagnostics() DiagnosticsCollector {
+	return newDiagnosticsCollector(c.proxy, c.ProviderInventory())
+}
+
+func (c *clusterClient) MachineLogs() MachineLogsClient {
+	return newMachineLogsClient(c.proxy)
+}
+
+func (c *clusterClient) MachineDrain() MachineDrainClient {
+	return newMachineDrainClient(c.proxy)
+}
+
+func (c *clusterClient) Audit() AuditClient {
+	return newAuditClient(c.proxy)
+}
+
+func (c *clusterClient) ClusterExporter() ClusterExporter {
+	return newClusterExporter(c.proxy)
+}
+
+func (c *clusterClient) TemplateRotator() TemplateRotator {
+	return newTemplateRotator(c.proxy)
+}
+
+func (c *clusterClient) ProviderHealth() ProviderHealthClient {
+	return newProviderHealthClient(c.proxy, c.pollImmediateWaiter)
+}
+
 // Option is a configuration option supplied to New.
 type Option func(*clusterClient)
 
@@ -159,6 +242,14 @@ func InjectProxy(proxy Proxy) Option {
 	}
 }
 
+// InjectProxyOptions allows to customize the default proxy, e.g. with InjectImpersonation or InjectNamespace.
+// The options are ignored when a proxy is injected with InjectProxy.
+func InjectProxyOptions(options ...ProxyOption) Option {
+	return func(c *clusterClient) {
+		c.proxyOptions = append(c.proxyOptions, options...)
+	}
+}
+
 // InjectRepositoryFactory allows to override the default factory used for creating
 // RepositoryClient objects.
 func InjectRepositoryFactory(factory RepositoryClientFactory) Option {
@@ -202,7 +293,7 @@ func newClusterClient(kubeconfig Kubeconfig, configClient config.Client, options
 
 	// if there is an injected proxy, use it, otherwise use a default one
 	if client.proxy == nil {
-		client.proxy = newProxy(client.kubeconfig)
+		client.proxy = newProxy(client.kubeconfig, client.proxyOptions...)
 	}
 
 	// if there is an injected repositoryClientFactory, use it, otherwise use the default one
@@ -228,6 +319,9 @@ type Proxy interface {
 	// ValidateKubernetesVersion returns an error if management cluster version less than minimumKubernetesVersion
 	ValidateKubernetesVersion() error
 
+	// GetServerVersion returns the Kubernetes version of the management cluster.
+	GetServerVersion() (string, error)
+
 	// NewClient returns a new controller runtime Client object for working on the management cluster
 	NewClient() (client.Client, error)
 
@@ -257,6 +351,27 @@ func retryWithExponentialBackoff(opts wait.Backoff, operation func() error) erro
 	return nil
 }
 
+// retryOnConflict repeats a read-modify-write operation until it does not fail because of conflicts or the backoff times out.
+// The operation is expected to read the object before changing it, so each attempt works on a fresh copy of the object.
+// NOTE: errors are returned as is, so callers can check them e.g. with apierrors.IsNotFound.
+func retryOnConflict(opts wait.Backoff, operation func() error) error {
+	log := logf.Log
+
+	conflicts := 0
+	err := retry.RetryOnConflict(opts, func() error {
+		err := operation()
+		if apierrors.IsConflict(err) {
+			conflicts++
+			log.V(5).Info("Operation failed because of a conflict, retrying with a fresh copy of the object", "Conflicts", conflicts, "Cause", err.Error())
+		}
+		return err
+	})
+	if conflicts > 0 && err == nil {
+		log.V(5).Info("Operation succeeded after conflicts", "Conflicts", conflicts)
+	}
+	return err
+}
+
 // newWriteBackoff creates a new API Machinery backoff parameter set suitable for use with clusterctl write operations.
 func newWriteBackoff() wait.Backoff {
 	// Return a exponential backoff configuration which returns durations for a total time of ~40s.
@@ -270,6 +385,41 @@ func newWriteBackoff() wait.Backoff {
 	}
 }
 
+// newConflictBackoff creates a new API Machinery backoff parameter set suitable for use when clusterctl retries
+// read-modify-write operations failed because of conflicts.
+// The number of attempts can be changed with the conflict-retry-attempts variable in the clusterctl configuration;
+// the default is used if the configClient is nil or the variable is not set to a positive integer.
+func newConflictBackoff(configClient config.Client) wait.Backoff {
+	// Return a exponential backoff configuration which returns durations for a total time of ~6s.
+	// Example: 0, .1s, .2s, .4s, .8s, 1.6s, 3.2s
+	// Jitter is added as a random fraction of the duration multiplied by the jitter factor.
+	return wait.Backoff{
+		Duration: 100 * time.Millisecond,
+		Factor:   2,
+		Steps:    conflictRetryAttempts(configClient),
+		Jitter:   0.1,
+	}
+}
+
+// conflictRetryAttempts returns the number of attempts for read-modify-write operations failed because of conflicts.
+func conflictRetryAttempts(configClient config.Client) int {
+	log := logf.Log
+
+	if configClient == nil {
+		return defaultConflictRetryAttempts
+	}
+	value, err := configClient.Variables().Get(conflictRetryAttemptsConfigKey)
+	if err != nil {
+		return defaultConflictRetryAttempts
+	}
+	attempts, err := strconv.Atoi(value)
+	if err != nil || attempts < 1 {
+		log.Info("Invalid value set for ", conflictRetryAttemptsConfigKey, value)
+		return defaultConflictRetryAttempts
+	}
+	return attempts
+}
+
 // newConnectBackoff creates a new API Machinery backoff parameter set suitable for use when clusterctl connect to a cluster.
 func newConnectBackoff() wait.Backoff {
 	// Return a exponential backoff configuration which returns durations for a total time of ~15s.
@@ -18,8 +18,13 @@ package cluster
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
 	yaml "sigs.k8s.io/cluster-api/cmd/clusterctl/client/yamlprocessor"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
 )
@@ -68,3 +73,104 @@ func Test_newClusterClient_YamlProcessor(t *testing.T) {
 		})
 	}
 }
+
+func Test_retryOnConflict(t *testing.T) {
+	conflict := apierrors.NewConflict(schema.GroupResource{Resource: "foo"}, "bar", errors.New("conflict"))
+	backoff := wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 3}
+
+	tests := []struct {
+		name         string
+		errs         []error
+		wantErr      bool
+		wantAttempts int
+	}{
+		{
+			name:         "succeeds at the first attempt",
+			errs:         []error{nil},
+			wantAttempts: 1,
+		},
+		{
+			name:         "retries on conflicts",
+			errs:         []error{conflict, conflict, nil},
+			wantAttempts: 3,
+		},
+		{
+			name:         "fails if conflicts persist after all the attempts",
+			errs:         []error{conflict, conflict, conflict},
+			wantErr:      true,
+			wantAttempts: 3,
+		},
+		{
+			name:         "does not retry other errors",
+			errs:         []error{errors.New("foo"), nil},
+			wantErr:      true,
+			wantAttempts: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			attempts := 0
+			err := retryOnConflict(backoff, func() error {
+				err := tt.errs[attempts]
+				attempts++
+				return err
+			})
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			g.Expect(attempts).To(Equal(tt.wantAttempts))
+		})
+	}
+}
+
+func Test_conflictRetryAttempts(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts string
+		want     int
+	}{
+		{
+			name:     "default if the variable is not set",
+			attempts: "",
+			want:     defaultConflictRetryAttempts,
+		},
+		{
+			name:     "the variable value if set",
+			attempts: "3",
+			want:     3,
+		},
+		{
+			name:     "default if the variable is not a number",
+			attempts: "foo",
+			want:     defaultConflictRetryAttempts,
+		},
+		{
+			name:     "default if the variable is not a positive number",
+			attempts: "0",
+			want:     defaultConflictRetryAttempts,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			configClient := newFakeConfig("")
+			if tt.attempts != "" {
+				configClient.WithVar(conflictRetryAttemptsConfigKey, tt.attempts)
+			}
+
+			g.Expect(conflictRetryAttempts(configClient)).To(Equal(tt.want))
+			g.Expect(newConflictBackoff(configClient).Steps).To(Equal(tt.want))
+		})
+	}
+
+	t.Run("default if there is no config client", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(conflictRetryAttempts(nil)).To(Equal(defaultConflictRetryAttempts))
+	})
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+
+	"github.com/blang/semver"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+)
+
+const (
+	// CoreProviderCompatibility identifies the compatibility with the version of the core provider.
+	CoreProviderCompatibility = "core provider"
+
+	// KubernetesCompatibility identifies the compatibility with the Kubernetes version of the management cluster.
+	KubernetesCompatibility = "Kubernetes"
+)
+
+// CompatibilityError is returned when a provider version is not compatible with the version of the core provider
+// or with the Kubernetes version of the management cluster, according to the compatibility defined in the provider metadata.
+type CompatibilityError struct {
+	// Provider is the provider, e.g. infrastructure-aws.
+	Provider string
+
+	// Version is the provider version.
+	Version string
+
+	// Dependency is what the provider is not compatible with, either CoreProviderCompatibility or KubernetesCompatibility.
+	Dependency string
+
+	// DependencyVersion is the version of the dependency.
+	DependencyVersion string
+
+	// SupportedVersions is the range of versions of the dependency supported by the provider version.
+	SupportedVersions string
+}
+
+func (e *CompatibilityError) Error() string {
+	return fmt.Sprintf("version %s of the %s provider is not compatible with %s %s, supported versions are %q", e.Version, e.Provider, e.Dependency, e.DependencyVersion, e.SupportedVersions)
+}
+
+// IsCompatibilityError returns true if the error, or any of the errors in an aggregate, is a CompatibilityError.
+func IsCompatibilityError(err error) bool {
+	if _, ok := errors.Cause(err).(*CompatibilityError); ok {
+		return true
+	}
+	if agg, ok := errors.Cause(err).(kerrors.Aggregate); ok {
+		for _, e := range agg.Errors() {
+			if IsCompatibilityError(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkCompatibility checks if a provider version is compatible with the version of the core provider and
+// with the Kubernetes version of the management cluster, according to the compatibility defined in its release series.
+func checkCompatibility(provider clusterctlv1.Provider, providerVersion string, releaseSeries *clusterctlv1.ReleaseSeries, coreProviderVersion, kubernetesVersion string) error {
+	if releaseSeries == nil || releaseSeries.Compatibility == nil {
+		return nil
+	}
+
+	var errList []error
+	for _, c := range []struct {
+		dependency        string
+		dependencyVersion string
+		supportedVersions string
+	}{
+		{dependency: CoreProviderCompatibility, dependencyVersion: coreProviderVersion, supportedVersions: releaseSeries.Compatibility.CoreProvider},
+		{dependency: KubernetesCompatibility, dependencyVersion: kubernetesVersion, supportedVersions: releaseSeries.Compatibility.Kubernetes},
+	} {
+		if c.supportedVersions == "" {
+			continue
+		}
+
+		supported, err := semver.ParseRange(c.supportedVersions)
+		if err != nil {
+			return errors.Wrapf(err, "invalid provider metadata: failed to parse the %s compatibility for version %s of the %s provider", c.dependency, providerVersion, provider.ManifestLabel())
+		}
+
+		v, err := parseCompatibilityVersion(c.dependencyVersion)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse %s version %q", c.dependency, c.dependencyVersion)
+		}
+
+		if !supported(v) {
+			errList = append(errList, &CompatibilityError{
+				Provider:          provider.ManifestLabel(),
+				Version:           providerVersion,
+				Dependency:        c.dependency,
+				DependencyVersion: c.dependencyVersion,
+				SupportedVersions: c.supportedVersions,
+			})
+		}
+	}
+	return kerrors.NewAggregate(errList)
+}
+
+// parseCompatibilityVersion parses a version ignoring pre-release and build metadata, so e.g. Kubernetes
+// versions like v1.19.1-gke.100 or v1.19.1+k3s1 match ranges defined on release versions.
+func parseCompatibilityVersion(s string) (semver.Version, error) {
+	v, err := semver.ParseTolerant(s)
+	if err != nil {
+		return semver.Version{}, err
+	}
+	v.Pre = nil
+	v.Build = nil
+	return v, nil
+}
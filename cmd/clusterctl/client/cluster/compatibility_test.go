@@ -0,0 +1,125 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+)
+
+func Test_checkCompatibility(t *testing.T) {
+	provider := fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v1.0.0", "infra-system", "")
+
+	type args struct {
+		releaseSeries       *clusterctlv1.ReleaseSeries
+		coreProviderVersion string
+		kubernetesVersion   string
+	}
+	tests := []struct {
+		name                   string
+		args                   args
+		wantErr                bool
+		wantCompatibilityError bool
+	}{
+		{
+			name: "pass if the release series does not define compatibility",
+			args: args{
+				releaseSeries:       &clusterctlv1.ReleaseSeries{Major: 1, Minor: 0, Contract: "v1alpha4"},
+				coreProviderVersion: "v0.4.0",
+				kubernetesVersion:   "v1.19.1",
+			},
+		},
+		{
+			name: "pass if versions are in the supported ranges, ignoring pre-release and build metadata",
+			args: args{
+				releaseSeries: &clusterctlv1.ReleaseSeries{Major: 1, Minor: 0, Contract: "v1alpha4", Compatibility: &clusterctlv1.ReleaseSeriesCompatibility{
+					CoreProvider: ">=0.4.0 <0.5.0",
+					Kubernetes:   ">=1.19.1",
+				}},
+				coreProviderVersion: "v0.4.2",
+				kubernetesVersion:   "v1.19.1-gke.100",
+			},
+		},
+		{
+			name: "fail if the core provider version is not in the supported range",
+			args: args{
+				releaseSeries: &clusterctlv1.ReleaseSeries{Major: 1, Minor: 0, Contract: "v1alpha4", Compatibility: &clusterctlv1.ReleaseSeriesCompatibility{
+					CoreProvider: ">=0.4.0 <0.5.0",
+				}},
+				coreProviderVersion: "v0.5.0",
+				kubernetesVersion:   "v1.19.1",
+			},
+			wantErr:                true,
+			wantCompatibilityError: true,
+		},
+		{
+			name: "fail if the Kubernetes version is not in the supported range",
+			args: args{
+				releaseSeries: &clusterctlv1.ReleaseSeries{Major: 1, Minor: 0, Contract: "v1alpha4", Compatibility: &clusterctlv1.ReleaseSeriesCompatibility{
+					Kubernetes: ">=1.20.0",
+				}},
+				coreProviderVersion: "v0.4.0",
+				kubernetesVersion:   "v1.19.1",
+			},
+			wantErr:                true,
+			wantCompatibilityError: true,
+		},
+		{
+			name: "fail if the supported range is not valid",
+			args: args{
+				releaseSeries: &clusterctlv1.ReleaseSeries{Major: 1, Minor: 0, Contract: "v1alpha4", Compatibility: &clusterctlv1.ReleaseSeriesCompatibility{
+					CoreProvider: "not a range",
+				}},
+				coreProviderVersion: "v0.4.0",
+				kubernetesVersion:   "v1.19.1",
+			},
+			wantErr:                true,
+			wantCompatibilityError: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			err := checkCompatibility(provider, provider.Version, tt.args.releaseSeries, tt.args.coreProviderVersion, tt.args.kubernetesVersion)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(IsCompatibilityError(err)).To(Equal(tt.wantCompatibilityError))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+		})
+	}
+}
+
+func Test_IsCompatibilityError(t *testing.T) {
+	g := NewWithT(t)
+
+	err := &CompatibilityError{
+		Provider:          "infrastructure-infra",
+		Version:           "v1.0.0",
+		Dependency:        KubernetesCompatibility,
+		DependencyVersion: "v1.19.1",
+		SupportedVersions: ">=1.20.0",
+	}
+	g.Expect(err.Error()).To(Equal(`version v1.0.0 of the infrastructure-infra provider is not compatible with Kubernetes v1.19.1, supported versions are ">=1.20.0"`))
+	g.Expect(IsCompatibilityError(errors.Wrap(err, "wrapped"))).To(BeTrue())
+	g.Expect(IsCompatibilityError(errors.New("another error"))).To(BeFalse())
+}
@@ -18,33 +18,53 @@ package cluster
 
 import (
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/util"
 	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	utilyaml "sigs.k8s.io/cluster-api/util/yaml"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type DeleteOptions struct {
 	Provider         clusterctlv1.Provider
 	IncludeNamespace bool
-	IncludeCRDs      bool
+
+	// IncludeCRDs forces the deletion of the provider's CRDs; CRDs with existing instances are deleted,
+	// together with all their instances, only if also IncludeNamespace is set.
+	IncludeCRDs bool
 }
 
 // ComponentsClient has methods to work with provider components in the cluster.
 type ComponentsClient interface {
-	// Create creates the provider components in the management cluster.
-	Create(objs []unstructured.Unstructured) error
+	// Create creates or updates the provider components in the management cluster, in order, returning the
+	// result of the operation for each object processed; it stops at the first object that fails, given that the
+	// following objects usually depend on it, e.g. the objects in a namespace that could not be created.
+	Create(objs []unstructured.Unstructured) (ApplyResults, error)
+
+	// CreateFromReader creates or updates the objects read from a stream of YAML documents, e.g. a provider components
+	// bundle, processing one document at a time so the whole stream is never buffered in memory.
+	// If not nil, progress is called with the result of the operation for each object as soon as it is processed.
+	CreateFromReader(r io.Reader, progress func(ApplyResult)) (ApplyResults, error)
+
+	// DeleteFromReader deletes the objects read from a stream of YAML documents, processing one document at a time;
+	// objects not existing in the management cluster are ignored.
+	// If not nil, progress is called with the result of the operation for each object as soon as it is processed.
+	DeleteFromReader(r io.Reader, progress func(ApplyResult)) (ApplyResults, error)
 
 	// Delete deletes the provider components from the management cluster.
 	// The operation is designed to prevent accidental deletion of user created objects, so
@@ -57,33 +77,149 @@ type ComponentsClient interface {
 	DeleteWebhookNamespace() error
 }
 
+// ApplyOperation defines the operation applied to an object when creating provider components.
+type ApplyOperation string
+
+const (
+	// ApplyCreated is used for objects not existing in the management cluster, which have been created.
+	ApplyCreated ApplyOperation = "created"
+
+	// ApplyConfigured is used for objects already existing in the management cluster, which have been updated.
+	ApplyConfigured ApplyOperation = "configured"
+
+	// ApplyUnchanged is used for objects already existing in the management cluster and already up to date.
+	ApplyUnchanged ApplyOperation = "unchanged"
+
+	// ApplyDeleted is used for objects which have been deleted, or which were already not existing in the management cluster.
+	ApplyDeleted ApplyOperation = "deleted"
+
+	// ApplyFailed is used for objects that could not be created, updated or deleted.
+	ApplyFailed ApplyOperation = "failed"
+)
+
+// ApplyResult defines the result of creating or updating an object.
+type ApplyResult struct {
+	// Object is the object being created or updated.
+	Object corev1.ObjectReference
+
+	// Operation is the operation applied to the object.
+	Operation ApplyOperation
+
+	// Error is the error that occurred when creating or updating the object, if any.
+	Error error
+}
+
+func (r ApplyResult) String() string {
+	name := r.Object.Name
+	if r.Object.Namespace != "" {
+		name = r.Object.Namespace + "/" + name
+	}
+	return fmt.Sprintf("%s %s %s", r.Object.Kind, name, r.Operation)
+}
+
+// ApplyResults defines the results of creating or updating a list of objects.
+type ApplyResults []ApplyResult
+
+// Failed returns the results for the objects that could not be created or updated.
+func (r ApplyResults) Failed() ApplyResults {
+	ret := ApplyResults{}
+	for _, result := range r {
+		if result.Operation == ApplyFailed {
+			ret = append(ret, result)
+		}
+	}
+	return ret
+}
+
 // providerComponents implements ComponentsClient.
 type providerComponents struct {
 	proxy Proxy
 }
 
-func (p *providerComponents) Create(objs []unstructured.Unstructured) error {
-	createComponentObjectBackoff := newWriteBackoff()
+func (p *providerComponents) Create(objs []unstructured.Unstructured) (ApplyResults, error) {
+	return p.create(newWriteBackoff(), objs)
+}
+
+// create creates or updates objects in order, stopping at the first failure.
+func (p *providerComponents) create(backoff wait.Backoff, objs []unstructured.Unstructured) (ApplyResults, error) {
+	results := make(ApplyResults, 0, len(objs))
 	for i := range objs {
-		obj := objs[i]
+		result := p.applyObj(backoff, objs[i], p.createObj)
+		results = append(results, result)
+		if result.Error != nil {
+			return results, result.Error
+		}
+	}
+	return results, nil
+}
 
-		// Create the Kubernetes object.
-		// Nb. The operation is wrapped in a retry loop to make Create more resilient to unexpected conditions.
-		if err := retryWithExponentialBackoff(createComponentObjectBackoff, func() error {
-			return p.createObj(obj)
-		}); err != nil {
-			return err
+func (p *providerComponents) CreateFromReader(r io.Reader, progress func(ApplyResult)) (ApplyResults, error) {
+	return p.applyFromReader(r, progress, p.createObj)
+}
+
+func (p *providerComponents) DeleteFromReader(r io.Reader, progress func(ApplyResult)) (ApplyResults, error) {
+	return p.applyFromReader(r, progress, p.deleteObj)
+}
+
+// applyFromReader applies an operation to the objects read from a stream of YAML documents, one document at a time;
+// if the operation fails for some objects, the remaining objects are processed anyway and the returned error
+// aggregates all the failures, while errors reading the stream stop the processing.
+func (p *providerComponents) applyFromReader(r io.Reader, progress func(ApplyResult), apply func(unstructured.Unstructured) (ApplyOperation, error)) (ApplyResults, error) {
+	backoff := newWriteBackoff()
+	results := ApplyResults{}
+	errList := []error{}
+	err := utilyaml.DecodeUnstructured(r, func(obj unstructured.Unstructured) error {
+		result := p.applyObj(backoff, obj, apply)
+		if result.Error != nil {
+			errList = append(errList, result.Error)
+		}
+		results = append(results, result)
+		if progress != nil {
+			progress(result)
 		}
+		return nil
+	})
+	if err != nil {
+		errList = append(errList, errors.Wrap(err, "failed to read objects"))
 	}
 
-	return nil
+	return results, kerrors.NewAggregate(errList)
+}
+
+// applyObj applies an operation to an object, returning the result of the operation.
+func (p *providerComponents) applyObj(backoff wait.Backoff, obj unstructured.Unstructured, apply func(unstructured.Unstructured) (ApplyOperation, error)) ApplyResult {
+	log := logf.Log
+
+	// Nb. The operation is wrapped in a retry loop to make it more resilient to unexpected conditions.
+	var operation ApplyOperation
+	err := retryWithExponentialBackoff(backoff, func() error {
+		var err error
+		operation, err = apply(obj)
+		return err
+	})
+
+	result := ApplyResult{
+		Object: corev1.ObjectReference{
+			APIVersion: obj.GetAPIVersion(),
+			Kind:       obj.GetKind(),
+			Namespace:  obj.GetNamespace(),
+			Name:       obj.GetName(),
+		},
+		Operation: operation,
+	}
+	if err != nil {
+		result.Operation = ApplyFailed
+		result.Error = err
+	}
+	log.V(5).Info(result.String())
+	return result
 }
 
-func (p *providerComponents) createObj(obj unstructured.Unstructured) error {
+func (p *providerComponents) createObj(obj unstructured.Unstructured) (ApplyOperation, error) {
 	log := logf.Log
 	c, err := p.proxy.NewClient()
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	// check if the component already exists, and eventually update it
@@ -96,25 +232,66 @@ func (p *providerComponents) createObj(obj unstructured.Unstructured) error {
 	}
 	if err := c.Get(ctx, key, currentR); err != nil {
 		if !apierrors.IsNotFound(err) {
-			return errors.Wrapf(err, "failed to get current provider object")
+			return "", errors.Wrapf(err, "failed to get current provider object %s, %s/%s", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
 		}
 
 		//if it does not exists, create the component
+		// NB. Namespaces created by clusterctl are annotated, so they can be distinguished from namespaces created by users.
+		if obj.GetKind() == "Namespace" {
+			annotations := obj.GetAnnotations()
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+			annotations[clusterctlv1.ClusterctlNamespaceCreatedAnnotation] = ""
+			obj.SetAnnotations(annotations)
+		}
 		log.V(5).Info("Creating", logf.UnstructuredToValues(obj)...)
 		if err := c.Create(ctx, &obj); err != nil {
-			return errors.Wrapf(err, "failed to create provider object %s, %s/%s", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+			return "", errors.Wrapf(err, "failed to create provider object %s, %s/%s", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
 		}
-		return nil
+		return ApplyCreated, nil
 	}
 
 	// otherwise update the component
+	// NB. Namespaces existing before installing the provider, and not managed by clusterctl, are annotated as owned by
+	// the user, so they are not deleted together with the provider; namespaces created by previous versions of clusterctl
+	// are already labeled as managed by clusterctl.
+	if obj.GetKind() == "Namespace" {
+		if _, ok := currentR.GetLabels()[clusterctlv1.ClusterctlLabelName]; !ok {
+			annotations := obj.GetAnnotations()
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+			annotations[clusterctlv1.ClusterctlNamespaceUserOwnedAnnotation] = ""
+			obj.SetAnnotations(annotations)
+		}
+	}
 	// NB. we are using client.Merge PatchOption so the new objects gets compared with the current one server side
 	log.V(5).Info("Patching", logf.UnstructuredToValues(obj)...)
 	obj.SetResourceVersion(currentR.GetResourceVersion())
 	if err := c.Patch(ctx, &obj, client.Merge); err != nil {
-		return errors.Wrapf(err, "failed to patch provider object")
+		return "", errors.Wrapf(err, "failed to patch provider object %s, %s/%s", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
 	}
-	return nil
+
+	// The API server does not change the resource version if the patch is a no-op.
+	if obj.GetResourceVersion() == currentR.GetResourceVersion() {
+		return ApplyUnchanged, nil
+	}
+	return ApplyConfigured, nil
+}
+
+func (p *providerComponents) deleteObj(obj unstructured.Unstructured) (ApplyOperation, error) {
+	log := logf.Log
+	c, err := p.proxy.NewClient()
+	if err != nil {
+		return "", err
+	}
+
+	log.V(5).Info("Deleting", logf.UnstructuredToValues(obj)...)
+	if err := c.Delete(ctx, &obj); err != nil && !apierrors.IsNotFound(err) {
+		return "", errors.Wrapf(err, "failed to delete object %s, %s/%s", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+	}
+	return ApplyDeleted, nil
 }
 
 func (p *providerComponents) Delete(options DeleteOptions) error {
@@ -167,6 +344,12 @@ func (p *providerComponents) Delete(options DeleteOptions) error {
 			if !options.IncludeNamespace {
 				continue
 			}
+			// If the Namespace is owned by the user, e.g. it is a shared namespace created by the user before installing
+			// the provider, skip it; the provider components hosted in the namespace are deleted one by one.
+			if _, ok := obj.GetAnnotations()[clusterctlv1.ClusterctlNamespaceUserOwnedAnnotation]; ok {
+				log.Info("Skipping deletion of a namespace owned by the user", "Namespace", obj.GetName())
+				continue
+			}
 			namespacesToDelete.Insert(obj.GetName())
 		}
 
@@ -192,6 +375,20 @@ func (p *providerComponents) Delete(options DeleteOptions) error {
 		return err
 	}
 
+	// Deleting a CRD deletes all its instances, so refuse to delete CRDs with existing instances unless the user
+	// explicitly asked to delete both the CRDs and the provider namespace.
+	if options.IncludeCRDs && !options.IncludeNamespace {
+		crdsWithInstances, err := getCRDsWithInstances(cs, resourcesToDelete)
+		if err != nil {
+			return err
+		}
+		if len(crdsWithInstances) > 0 {
+			return errors.Errorf("refusing to delete the CRDs of the %q provider because instances of the following CRDs exist: %s. "+
+				"Delete the instances first, or delete the provider including both the CRDs and the namespace to delete the instances as well",
+				options.Provider.ManifestLabel(), strings.Join(crdsWithInstances, ", "))
+		}
+	}
+
 	errList := []error{}
 	for i := range resourcesToDelete {
 		obj := resourcesToDelete[i]
@@ -217,6 +414,53 @@ func (p *providerComponents) Delete(options DeleteOptions) error {
 	return kerrors.NewAggregate(errList)
 }
 
+// getCRDsWithInstances returns the names of the CRDs in a list of objects for which instances exist in the cluster.
+func getCRDsWithInstances(c client.Client, objs []unstructured.Unstructured) ([]string, error) {
+	crdsWithInstances := []string{}
+	for _, obj := range objs {
+		if obj.GetKind() != "CustomResourceDefinition" {
+			continue
+		}
+
+		group, _, _ := unstructured.NestedString(obj.Object, "spec", "group")
+		kind, _, _ := unstructured.NestedString(obj.Object, "spec", "names", "kind")
+		version := getCRDStorageVersion(obj)
+		if group == "" || kind == "" || version == "" {
+			continue
+		}
+
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(schema.GroupVersionKind{Group: group, Version: version, Kind: kind + "List"})
+		if err := c.List(ctx, list, client.Limit(1)); err != nil {
+			if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				continue
+			}
+			return nil, errors.Wrapf(err, "failed to list instances of CRD %s", obj.GetName())
+		}
+		if len(list.Items) > 0 {
+			crdsWithInstances = append(crdsWithInstances, obj.GetName())
+		}
+	}
+	return crdsWithInstances, nil
+}
+
+// getCRDStorageVersion returns the storage version of a CRD, supporting both apiextensions v1 and v1beta1 CRDs.
+func getCRDStorageVersion(crd unstructured.Unstructured) string {
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	for _, v := range versions {
+		version, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if storage, _, _ := unstructured.NestedBool(version, "storage"); storage {
+			name, _, _ := unstructured.NestedString(version, "name")
+			return name
+		}
+	}
+	version, _, _ := unstructured.NestedString(crd.Object, "spec", "version")
+	return version
+}
+
 func (p *providerComponents) DeleteWebhookNamespace() error {
 	log := logf.Log
 	log.V(5).Info("Deleting %s namespace", repository.WebhookNamespaceName)
@@ -17,15 +17,19 @@ limitations under the License.
 package cluster
 
 import (
+	"strings"
 	"testing"
 
 	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
 
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/scheme"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
@@ -33,6 +37,194 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+func Test_providerComponents_Create(t *testing.T) {
+	g := NewWithT(t)
+
+	configMap := func(name string, data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "ConfigMap",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns1",
+				Name:      name,
+			},
+			Data: data,
+		}
+	}
+	toUnstructured := func(obj *corev1.ConfigMap) unstructured.Unstructured {
+		u := unstructured.Unstructured{}
+		g.Expect(scheme.Scheme.Convert(obj, &u, nil)).To(Succeed())
+		return u
+	}
+
+	userNamespace := &corev1.Namespace{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Namespace",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "ns3",
+		},
+	}
+	proxy := test.NewFakeProxy().WithObjs(configMap("existing", map[string]string{"foo": "bar"}), userNamespace)
+	c := newComponentsClient(proxy)
+
+	namespace := func(name string) unstructured.Unstructured {
+		u := unstructured.Unstructured{}
+		u.SetAPIVersion("v1")
+		u.SetKind("Namespace")
+		u.SetName(name)
+		u.SetLabels(map[string]string{clusterctlv1.ClusterctlLabelName: ""})
+		return u
+	}
+
+	results, err := c.Create([]unstructured.Unstructured{
+		toUnstructured(configMap("new", map[string]string{"foo": "bar"})),
+		toUnstructured(configMap("existing", map[string]string{"foo": "baz"})),
+		namespace("ns2"),
+		namespace("ns3"),
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(results).To(HaveLen(4))
+	g.Expect(results[0].Object).To(Equal(corev1.ObjectReference{APIVersion: "v1", Kind: "ConfigMap", Namespace: "ns1", Name: "new"}))
+	g.Expect(results[0].Operation).To(Equal(ApplyCreated))
+	g.Expect(results[1].Object).To(Equal(corev1.ObjectReference{APIVersion: "v1", Kind: "ConfigMap", Namespace: "ns1", Name: "existing"}))
+	g.Expect(results[1].Operation).To(Equal(ApplyConfigured))
+	g.Expect(results.Failed()).To(BeEmpty())
+
+	cs, err := proxy.NewClient()
+	g.Expect(err).NotTo(HaveOccurred())
+	got := &corev1.ConfigMap{}
+	g.Expect(cs.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: "existing"}, got)).To(Succeed())
+	g.Expect(got.Data).To(Equal(map[string]string{"foo": "baz"}))
+
+	// Namespaces created by clusterctl are annotated.
+	ns := &corev1.Namespace{}
+	g.Expect(cs.Get(ctx, client.ObjectKey{Name: "ns2"}, ns)).To(Succeed())
+	g.Expect(ns.Annotations).To(HaveKey(clusterctlv1.ClusterctlNamespaceCreatedAnnotation))
+	g.Expect(ns.Annotations).ToNot(HaveKey(clusterctlv1.ClusterctlNamespaceUserOwnedAnnotation))
+
+	// Namespaces existing before and not managed by clusterctl are annotated as owned by the user.
+	ns = &corev1.Namespace{}
+	g.Expect(cs.Get(ctx, client.ObjectKey{Name: "ns3"}, ns)).To(Succeed())
+	g.Expect(ns.Annotations).To(HaveKey(clusterctlv1.ClusterctlNamespaceUserOwnedAnnotation))
+	g.Expect(ns.Annotations).ToNot(HaveKey(clusterctlv1.ClusterctlNamespaceCreatedAnnotation))
+}
+
+func Test_providerComponents_CreateStopsAtFirstFailure(t *testing.T) {
+	g := NewWithT(t)
+
+	configMap := func(name string) unstructured.Unstructured {
+		u := unstructured.Unstructured{}
+		u.SetAPIVersion("v1")
+		u.SetKind("ConfigMap")
+		u.SetNamespace("ns1")
+		u.SetName(name)
+		return u
+	}
+
+	proxy := test.NewFakeProxy()
+	c := &providerComponents{proxy: proxy}
+
+	// The second object fails because it has no name, so the third one is not created.
+	results, err := c.create(wait.Backoff{Steps: 1}, []unstructured.Unstructured{
+		configMap("first"),
+		configMap(""),
+		configMap("third"),
+	})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(results).To(HaveLen(2))
+	g.Expect(results[0].Operation).To(Equal(ApplyCreated))
+	g.Expect(results[1].Operation).To(Equal(ApplyFailed))
+	g.Expect(results.Failed()).To(HaveLen(1))
+
+	cs, err := proxy.NewClient()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(apierrors.IsNotFound(cs.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: "third"}, &corev1.ConfigMap{}))).To(BeTrue())
+}
+
+func Test_providerComponents_CreateAndDeleteFromReader(t *testing.T) {
+	g := NewWithT(t)
+
+	proxy := test.NewFakeProxy().WithObjs(&corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns1",
+			Name:      "existing",
+		},
+		Data: map[string]string{"foo": "bar"},
+	})
+	c := newComponentsClient(proxy)
+
+	manifest := `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: new
+  namespace: ns1
+data:
+  foo: bar
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: existing
+  namespace: ns1
+data:
+  foo: baz
+`
+
+	// Objects are created or updated one at a time, reporting progress for each of them.
+	var progress []string
+	results, err := c.CreateFromReader(strings.NewReader(manifest), func(r ApplyResult) {
+		progress = append(progress, r.String())
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(results).To(HaveLen(2))
+	g.Expect(results.Failed()).To(BeEmpty())
+	g.Expect(progress).To(Equal([]string{"ConfigMap ns1/new created", "ConfigMap ns1/existing configured"}))
+
+	cs, err := proxy.NewClient()
+	g.Expect(err).NotTo(HaveOccurred())
+	got := &corev1.ConfigMap{}
+	g.Expect(cs.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: "existing"}, got)).To(Succeed())
+	g.Expect(got.Data).To(Equal(map[string]string{"foo": "baz"}))
+
+	// Objects are deleted one at a time, ignoring objects not existing anymore.
+	g.Expect(cs.Delete(ctx, got)).To(Succeed())
+	progress = nil
+	results, err = c.DeleteFromReader(strings.NewReader(manifest), func(r ApplyResult) {
+		progress = append(progress, r.String())
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(results.Failed()).To(BeEmpty())
+	g.Expect(progress).To(Equal([]string{"ConfigMap ns1/new deleted", "ConfigMap ns1/existing deleted"}))
+	g.Expect(apierrors.IsNotFound(cs.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: "new"}, &corev1.ConfigMap{}))).To(BeTrue())
+
+	// Errors reading the stream are reported.
+	_, err = c.CreateFromReader(strings.NewReader("apiVersion: v1\nfoobar\n"), nil)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func Test_ApplyResults_Failed(t *testing.T) {
+	g := NewWithT(t)
+
+	failed := ApplyResult{Object: corev1.ObjectReference{Kind: "Deployment", Namespace: "ns1", Name: "foo"}, Operation: ApplyFailed, Error: errors.New("failed")}
+	results := ApplyResults{
+		{Object: corev1.ObjectReference{Kind: "Namespace", Name: "ns1"}, Operation: ApplyUnchanged},
+		failed,
+		{Object: corev1.ObjectReference{Kind: "Service", Namespace: "ns1", Name: "foo"}, Operation: ApplyCreated},
+	}
+	g.Expect(results.Failed()).To(Equal(ApplyResults{failed}))
+	g.Expect(failed.String()).To(Equal("Deployment ns1/foo failed"))
+	g.Expect(results[0].String()).To(Equal("Namespace ns1 unchanged"))
+}
+
 func Test_providerComponents_Delete(t *testing.T) {
 	labels := map[string]string{
 		clusterv1.ProviderLabelName: "infrastructure-infra",
@@ -61,8 +253,9 @@ func Test_providerComponents_Delete(t *testing.T) {
 				Kind: "Namespace",
 			},
 			ObjectMeta: metav1.ObjectMeta{
-				Name:   "ns1",
-				Labels: labels,
+				Name:        "ns1",
+				Labels:      labels,
+				Annotations: map[string]string{clusterctlv1.ClusterctlNamespaceCreatedAnnotation: ""},
 			},
 		},
 		// A namespaced provider component (should always be deleted)
@@ -296,6 +489,166 @@ func Test_providerComponents_Delete(t *testing.T) {
 	}
 }
 
+func Test_providerComponents_DeleteCRDsWithInstances(t *testing.T) {
+	sharedLabels := map[string]string{
+		clusterv1.ProviderLabelName:                      "cluster-api",
+		clusterctlv1.ClusterctlResourceLifecyleLabelName: string(clusterctlv1.ResourceLifecycleShared),
+	}
+
+	crd := unstructured.Unstructured{}
+	crd.SetAPIVersion("apiextensions.k8s.io/v1")
+	crd.SetKind("CustomResourceDefinition")
+	crd.SetName("clusters.cluster.x-k8s.io")
+	crd.SetLabels(sharedLabels)
+	crd.Object["spec"] = map[string]interface{}{
+		"group": "cluster.x-k8s.io",
+		"names": map[string]interface{}{
+			"kind": "Cluster",
+		},
+		"versions": []interface{}{
+			map[string]interface{}{"name": "v1alpha3", "storage": false},
+			map[string]interface{}{"name": "v1alpha4", "storage": true},
+		},
+	}
+
+	cluster := &clusterv1.Cluster{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: clusterv1.GroupVersion.String(),
+			Kind:       "Cluster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "cluster1",
+		},
+	}
+
+	provider := clusterctlv1.Provider{ObjectMeta: metav1.ObjectMeta{Name: "cluster-api", Namespace: "capi-system"}, ProviderName: "cluster-api", Type: string(clusterctlv1.CoreProviderType)}
+
+	tests := []struct {
+		name             string
+		initObjs         []client.Object
+		includeNamespace bool
+		wantErr          bool
+		wantCRDDeleted   bool
+	}{
+		{
+			name:           "CRDs without instances are deleted",
+			initObjs:       []client.Object{crd.DeepCopy()},
+			wantErr:        false,
+			wantCRDDeleted: true,
+		},
+		{
+			name:           "CRDs with instances are not deleted",
+			initObjs:       []client.Object{crd.DeepCopy(), cluster.DeepCopy()},
+			wantErr:        true,
+			wantCRDDeleted: false,
+		},
+		{
+			name:             "CRDs with instances are deleted if also the namespace is deleted",
+			initObjs:         []client.Object{crd.DeepCopy(), cluster.DeepCopy()},
+			includeNamespace: true,
+			wantErr:          false,
+			wantCRDDeleted:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			proxy := test.NewFakeProxy().WithObjs(tt.initObjs...)
+			c := newComponentsClient(proxy)
+			err := c.Delete(DeleteOptions{
+				Provider:         provider,
+				IncludeNamespace: tt.includeNamespace,
+				IncludeCRDs:      true,
+			})
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+
+			cs, err := proxy.NewClient()
+			g.Expect(err).NotTo(HaveOccurred())
+
+			obj := &unstructured.Unstructured{}
+			obj.SetAPIVersion(crd.GetAPIVersion())
+			obj.SetKind(crd.GetKind())
+			err = cs.Get(ctx, client.ObjectKey{Name: crd.GetName()}, obj)
+			g.Expect(apierrors.IsNotFound(err)).To(Equal(tt.wantCRDDeleted))
+		})
+	}
+}
+
+func Test_providerComponents_DeleteNamespaceOwnedByTheUser(t *testing.T) {
+	tests := []struct {
+		name                 string
+		annotations          map[string]string
+		wantNamespaceDeleted bool
+	}{
+		{
+			name:                 "a namespace created by the user before installing the provider should never be deleted",
+			annotations:          map[string]string{clusterctlv1.ClusterctlNamespaceUserOwnedAnnotation: ""},
+			wantNamespaceDeleted: false,
+		},
+		{
+			name:                 "a namespace created by a previous version of clusterctl should be deleted",
+			annotations:          nil,
+			wantNamespaceDeleted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			labels := map[string]string{
+				clusterv1.ProviderLabelName: "infrastructure-infra",
+			}
+			initObjs := []client.Object{
+				&corev1.Namespace{
+					TypeMeta: metav1.TypeMeta{
+						Kind: "Namespace",
+					},
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "ns1",
+						Labels:      labels,
+						Annotations: tt.annotations,
+					},
+				},
+				// A namespaced provider component (should always be deleted)
+				&corev1.Pod{
+					TypeMeta: metav1.TypeMeta{
+						Kind: "Pod",
+					},
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "ns1",
+						Name:      "pod1",
+						Labels:    labels,
+					},
+				},
+			}
+
+			proxy := test.NewFakeProxy().WithObjs(initObjs...)
+			c := newComponentsClient(proxy)
+			err := c.Delete(DeleteOptions{
+				Provider:         clusterctlv1.Provider{ObjectMeta: metav1.ObjectMeta{Name: "infrastructure-infra", Namespace: "ns1"}, ProviderName: "infra", Type: string(clusterctlv1.InfrastructureProviderType)},
+				IncludeNamespace: true,
+			})
+			g.Expect(err).NotTo(HaveOccurred())
+
+			cs, err := proxy.NewClient()
+			g.Expect(err).NotTo(HaveOccurred())
+			err = cs.Get(ctx, client.ObjectKey{Name: "ns1"}, &corev1.Namespace{})
+			g.Expect(apierrors.IsNotFound(err)).To(Equal(tt.wantNamespaceDeleted))
+			if !tt.wantNamespaceDeleted {
+				// The provider components in a preserved namespace are deleted one by one.
+				err = cs.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: "pod1"}, &corev1.Pod{})
+				g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			}
+		})
+	}
+}
+
 func Test_providerComponents_DeleteCoreProviderWebhookNamespace(t *testing.T) {
 	t.Run("deletes capi-webhook-system namespace", func(t *testing.T) {
 		g := NewWithT(t)
@@ -0,0 +1,480 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// redactedValue replaces sensitive values in the diagnostics bundle.
+	redactedValue = "REDACTED"
+
+	// lastAppliedConfigAnnotation is the annotation kubectl uses for storing the last applied configuration,
+	// which might contain sensitive values.
+	lastAppliedConfigAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+)
+
+// DefaultNodeLogFiles is the list of files collected by default from /var/log on the workload cluster nodes;
+// cloud-init-output.log contains the output of kubeadm on nodes bootstrapped with cloud-init.
+var DefaultNodeLogFiles = []string{"cloud-init-output.log", "kubelet.log"}
+
+// DiagnosticsOptions defines the options supported by DiagnosticsCollector.
+type DiagnosticsOptions struct {
+	// Namespace where the Cluster API objects and the events are collected from. If empty, all the namespaces are collected.
+	Namespace string
+
+	// LogTailLines is the number of lines collected from the end of the controllers logs. If zero, the whole logs are collected.
+	LogTailLines int64
+
+	// WorkloadClusterNodeLogs enables collecting logs from the nodes of the workload clusters.
+	WorkloadClusterNodeLogs bool
+
+	// NodeLogFiles is the list of files collected from /var/log on the workload cluster nodes, e.g. kubelet.log.
+	// Files not existing on a node are ignored. If empty, DefaultNodeLogFiles are collected.
+	NodeLogFiles []string
+}
+
+// DiagnosticsCollector defines methods for collecting diagnostics from a management cluster for bug reports.
+type DiagnosticsCollector interface {
+	// Collect writes a gzipped tarball with the Cluster API objects, the controllers logs, the events, the CRD versions
+	// and the webhook configurations of the management cluster, and optionally the logs of the workload cluster nodes.
+	// Secret values and the last applied configurations are redacted.
+	// NOTE: Collecting diagnostics is best effort; errors collecting single items are recorded in the errors.txt file of the tarball.
+	Collect(options DiagnosticsOptions, w io.Writer) error
+}
+
+// podLogReader defines methods for reading the logs of containers.
+type podLogReader interface {
+	// ReadLogs returns the logs of a container, eventually limited to the last tailLines.
+	ReadLogs(namespace, pod, container string, tailLines int64) ([]byte, error)
+}
+
+// proxyPodLogReader implements podLogReader reading logs from the cluster the proxy points to.
+type proxyPodLogReader struct {
+	proxy Proxy
+}
+
+func (r *proxyPodLogReader) ReadLogs(namespace, pod, container string, tailLines int64) ([]byte, error) {
+	config, err := r.proxy.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	cs, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the client-go client")
+	}
+
+	logOptions := &corev1.PodLogOptions{Container: container}
+	if tailLines > 0 {
+		logOptions.TailLines = &tailLines
+	}
+	return cs.CoreV1().Pods(namespace).GetLogs(pod, logOptions).DoRaw(ctx)
+}
+
+// diagnosticsCollector implements DiagnosticsCollector.
+type diagnosticsCollector struct {
+	proxy             Proxy
+	providerInventory InventoryClient
+	workloadCluster   WorkloadCluster
+	logReader         podLogReader
+}
+
+// ensure diagnosticsCollector implements the DiagnosticsCollector interface.
+var _ DiagnosticsCollector = &diagnosticsCollector{}
+
+// diagnosticsStep defines a step of the diagnostics collection.
+type diagnosticsStep struct {
+	description string
+	collect     func(*diagnosticsBundle, DiagnosticsOptions) error
+}
+
+func (d *diagnosticsCollector) Collect(options DiagnosticsOptions, w io.Writer) error {
+	log := logf.Log
+
+	gw := gzip.NewWriter(w)
+	b := &diagnosticsBundle{tw: tar.NewWriter(gw), now: time.Now()}
+
+	steps := []diagnosticsStep{
+		{"Cluster API objects", d.collectObjects},
+		{"events", d.collectEvents},
+		{"CRD versions", d.collectCRDs},
+		{"webhook configurations", d.collectWebhooks},
+		{"controller logs", d.collectControllerLogs},
+	}
+	if options.WorkloadClusterNodeLogs {
+		steps = append(steps, diagnosticsStep{"workload cluster node logs", d.collectNodeLogs})
+	}
+
+	for _, s := range steps {
+		log.Info("Collecting " + s.description)
+		if err := s.collect(b, options); err != nil {
+			b.addError(errors.Wrapf(err, "failed to collect %s", s.description))
+		}
+	}
+
+	if err := b.close(); err != nil {
+		return err
+	}
+	return errors.Wrap(gw.Close(), "failed to write the diagnostics bundle")
+}
+
+// collectObjects collects the objects of all the types considered by move, i.e. the Cluster API objects and the
+// related Secrets and ConfigMaps.
+func (d *diagnosticsCollector) collectObjects(b *diagnosticsBundle, options DiagnosticsOptions) error {
+	graph := newObjectGraph(d.proxy)
+	if err := graph.getDiscoveryTypes(); err != nil {
+		return err
+	}
+
+	selectors := []client.ListOption{}
+	if options.Namespace != "" {
+		selectors = append(selectors, client.InNamespace(options.Namespace))
+	}
+
+	for _, discoveryType := range graph.types {
+		typeMeta := discoveryType.typeMeta
+		// Nb. the List suffix is required when listing unstructured objects.
+		typeMeta.Kind = strings.TrimSuffix(typeMeta.Kind, "List") + "List"
+		objList := &unstructured.UnstructuredList{}
+		if err := getObjList(d.proxy, typeMeta, selectors, objList); err != nil {
+			b.addError(err)
+			continue
+		}
+		for i := range objList.Items {
+			obj := &objList.Items[i]
+			redactObject(obj)
+
+			namespace := obj.GetNamespace()
+			if namespace == "" {
+				namespace = "cluster-scoped"
+			}
+			if err := b.addYAML(path.Join("management-cluster", "resources", namespace, obj.GetKind(), obj.GetName()+".yaml"), obj.Object); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// collectEvents collects the events, grouped by namespace.
+func (d *diagnosticsCollector) collectEvents(b *diagnosticsBundle, options DiagnosticsOptions) error {
+	c, err := d.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	events := &corev1.EventList{}
+	if err := c.List(ctx, events, client.InNamespace(options.Namespace)); err != nil {
+		return errors.Wrap(err, "failed to list events")
+	}
+
+	eventsByNamespace := map[string][]corev1.Event{}
+	for _, e := range events.Items {
+		e.ManagedFields = nil
+		eventsByNamespace[e.Namespace] = append(eventsByNamespace[e.Namespace], e)
+	}
+	for namespace, events := range eventsByNamespace {
+		sort.Slice(events, func(i, j int) bool {
+			return events[i].LastTimestamp.Before(&events[j].LastTimestamp)
+		})
+		if err := b.addYAML(path.Join("management-cluster", "events", namespace+".yaml"), events); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// crdVersions defines the versions of a CRD, as reported in the diagnostics bundle.
+type crdVersions struct {
+	Name           string   `json:"name"`
+	Provider       string   `json:"provider,omitempty"`
+	ServedVersions []string `json:"servedVersions"`
+	StorageVersion string   `json:"storageVersion"`
+	StoredVersions []string `json:"storedVersions"`
+}
+
+// collectCRDs collects the versions of the CRDs installed by clusterctl.
+func (d *diagnosticsCollector) collectCRDs(b *diagnosticsBundle, _ DiagnosticsOptions) error {
+	c, err := d.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	crds := &apiextensionsv1.CustomResourceDefinitionList{}
+	if err := c.List(ctx, crds, client.HasLabels{clusterctlv1.ClusterctlLabelName}); err != nil {
+		return errors.Wrap(err, "failed to list CRDs")
+	}
+
+	versions := []crdVersions{}
+	for _, crd := range crds.Items {
+		v := crdVersions{
+			Name:           crd.Name,
+			Provider:       crd.Labels[clusterv1.ProviderLabelName],
+			ServedVersions: []string{},
+			StoredVersions: crd.Status.StoredVersions,
+		}
+		for _, version := range crd.Spec.Versions {
+			if version.Served {
+				v.ServedVersions = append(v.ServedVersions, version.Name)
+			}
+			if version.Storage {
+				v.StorageVersion = version.Name
+			}
+		}
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Name < versions[j].Name
+	})
+	return b.addYAML(path.Join("management-cluster", "crds.yaml"), versions)
+}
+
+// collectWebhooks collects the webhook configurations installed by clusterctl.
+func (d *diagnosticsCollector) collectWebhooks(b *diagnosticsBundle, _ DiagnosticsOptions) error {
+	for _, kind := range []string{"ValidatingWebhookConfiguration", "MutatingWebhookConfiguration"} {
+		objList := &unstructured.UnstructuredList{}
+		typeMeta := metav1.TypeMeta{APIVersion: "admissionregistration.k8s.io/v1", Kind: kind + "List"}
+		if err := getObjList(d.proxy, typeMeta, []client.ListOption{client.HasLabels{clusterctlv1.ClusterctlLabelName}}, objList); err != nil {
+			return err
+		}
+		for i := range objList.Items {
+			obj := &objList.Items[i]
+			redactObject(obj)
+			if err := b.addYAML(path.Join("management-cluster", "webhooks", kind, obj.GetName()+".yaml"), obj.Object); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// collectControllerLogs collects the logs of all the containers of the pods in the namespaces of the providers.
+func (d *diagnosticsCollector) collectControllerLogs(b *diagnosticsBundle, options DiagnosticsOptions) error {
+	providers, err := d.providerInventory.List()
+	if err != nil {
+		return err
+	}
+
+	c, err := d.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	namespaces := map[string]bool{}
+	for _, provider := range providers.Items {
+		if namespaces[provider.Namespace] {
+			continue
+		}
+		namespaces[provider.Namespace] = true
+
+		pods := &corev1.PodList{}
+		if err := c.List(ctx, pods, client.InNamespace(provider.Namespace)); err != nil {
+			b.addError(errors.Wrapf(err, "failed to list pods in namespace %s", provider.Namespace))
+			continue
+		}
+		for _, pod := range pods.Items {
+			for _, container := range pod.Spec.Containers {
+				logs, err := d.logReader.ReadLogs(pod.Namespace, pod.Name, container.Name, options.LogTailLines)
+				if err != nil {
+					b.addError(errors.Wrapf(err, "failed to read the logs of container %s of pod %s/%s", container.Name, pod.Namespace, pod.Name))
+					continue
+				}
+				if err := b.add(path.Join("management-cluster", "logs", pod.Namespace, pod.Name, container.Name+".log"), logs); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// collectNodeLogs collects log files from the nodes of the workload clusters, reading them through the node proxy
+// of the API server of each workload cluster.
+func (d *diagnosticsCollector) collectNodeLogs(b *diagnosticsBundle, options DiagnosticsOptions) error {
+	c, err := d.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	clusters := &clusterv1.ClusterList{}
+	if err := c.List(ctx, clusters, client.InNamespace(options.Namespace)); err != nil {
+		return errors.Wrap(err, "failed to list Clusters")
+	}
+
+	files := options.NodeLogFiles
+	if len(files) == 0 {
+		files = DefaultNodeLogFiles
+	}
+
+	for _, cluster := range clusters.Items {
+		cs, err := newWorkloadClusterClientset(d.workloadCluster, cluster.Namespace, cluster.Name)
+		if err != nil {
+			b.addError(err)
+			continue
+		}
+
+		nodes, err := cs.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			b.addError(errors.Wrapf(err, "failed to list the nodes of Cluster %s/%s", cluster.Namespace, cluster.Name))
+			continue
+		}
+		for _, node := range nodes.Items {
+			for _, file := range files {
+				logs, err := readNodeLogFile(cs, node.Name, file)
+				if err != nil {
+					if apierrors.IsNotFound(err) {
+						continue
+					}
+					b.addError(errors.Wrapf(err, "failed to read %s from node %s of Cluster %s/%s", file, node.Name, cluster.Namespace, cluster.Name))
+					continue
+				}
+				if err := b.add(path.Join("workload-clusters", cluster.Namespace, cluster.Name, "nodes", node.Name, file), logs); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// newWorkloadClusterClientset returns a client-go client for the API server of a workload cluster.
+func newWorkloadClusterClientset(workloadCluster WorkloadCluster, clusterNamespace, clusterName string) (kubernetes.Interface, error) {
+	kubeconfig, err := workloadCluster.GetKubeconfig(clusterName, clusterNamespace)
+	if err != nil {
+		return nil, err
+	}
+	config, err := clientcmd.RESTConfigFromKubeConfig([]byte(kubeconfig))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the kubeconfig of Cluster %s/%s", clusterNamespace, clusterName)
+	}
+	cs, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create the client-go client for Cluster %s/%s", clusterNamespace, clusterName)
+	}
+	return cs, nil
+}
+
+// readNodeLogFile returns the content of a file in /var/log on a node, read through the node proxy of the API server of
+// the workload cluster.
+func readNodeLogFile(cs kubernetes.Interface, nodeName, file string) ([]byte, error) {
+	return cs.CoreV1().RESTClient().Get().Resource("nodes").Name(nodeName).SubResource("proxy").Suffix("logs", file).DoRaw(ctx)
+}
+
+// redactObject removes sensitive values from an object, i.e. the data of Secrets and the last applied configuration;
+// managed fields are removed as well, given that they are not relevant for diagnostics.
+func redactObject(obj *unstructured.Unstructured) {
+	obj.SetManagedFields(nil)
+
+	if annotations := obj.GetAnnotations(); annotations != nil {
+		if _, ok := annotations[lastAppliedConfigAnnotation]; ok {
+			annotations[lastAppliedConfigAnnotation] = redactedValue
+			obj.SetAnnotations(annotations)
+		}
+	}
+
+	if obj.GetAPIVersion() != "v1" || obj.GetKind() != "Secret" {
+		return
+	}
+	for _, field := range []string{"data", "stringData"} {
+		data, ok := obj.Object[field].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for k := range data {
+			data[k] = redactedValue
+		}
+	}
+}
+
+// diagnosticsBundle writes files into a tarball, keeping track of the errors occurred while collecting them.
+type diagnosticsBundle struct {
+	tw     *tar.Writer
+	now    time.Time
+	errors []string
+}
+
+// add adds a file to the bundle.
+func (b *diagnosticsBundle) add(name string, data []byte) error {
+	if err := b.tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: b.now,
+	}); err != nil {
+		return errors.Wrapf(err, "failed to write %s to the diagnostics bundle", name)
+	}
+	if _, err := b.tw.Write(data); err != nil {
+		return errors.Wrapf(err, "failed to write %s to the diagnostics bundle", name)
+	}
+	return nil
+}
+
+// addYAML adds a file with the YAML representation of an object to the bundle.
+func (b *diagnosticsBundle) addYAML(name string, obj interface{}) error {
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %s", name)
+	}
+	return b.add(name, data)
+}
+
+// addError records an error occurred while collecting diagnostics.
+func (b *diagnosticsBundle) addError(err error) {
+	logf.Log.V(1).Info("Failed to collect diagnostics", "Error", err.Error())
+	b.errors = append(b.errors, err.Error())
+}
+
+// close adds the errors occurred while collecting diagnostics to the bundle and closes it.
+func (b *diagnosticsBundle) close() error {
+	if len(b.errors) > 0 {
+		if err := b.add("errors.txt", []byte(fmt.Sprintln(strings.Join(b.errors, "\n")))); err != nil {
+			return err
+		}
+	}
+	return errors.Wrap(b.tw.Close(), "failed to write the diagnostics bundle")
+}
+
+func newDiagnosticsCollector(proxy Proxy, providerInventory InventoryClient) *diagnosticsCollector {
+	return &diagnosticsCollector{
+		proxy:             proxy,
+		providerInventory: providerInventory,
+		workloadCluster:   newWorkloadCluster(proxy),
+		logReader:         &proxyPodLogReader{proxy: proxy},
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+)
+
+type fakePodLogReader struct{}
+
+func (r *fakePodLogReader) ReadLogs(namespace, pod, container string, tailLines int64) ([]byte, error) {
+	return []byte(fmt.Sprintf("logs of %s/%s/%s, tail %d", namespace, pod, container, tailLines)), nil
+}
+
+func Test_diagnosticsCollector_Collect(t *testing.T) {
+	g := NewWithT(t)
+
+	pod := &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Pod",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "capi-system",
+			Name:      "capi-controller-manager",
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "manager"}},
+		},
+	}
+	event := &corev1.Event{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Event",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns1",
+			Name:      "foo.1",
+		},
+		Reason: "Moved",
+	}
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns1",
+			Name:      "foo-credentials",
+		},
+		Data: map[string][]byte{
+			"password": []byte("secret"),
+		},
+	}
+
+	proxy := getFakeProxyWithCRDs().
+		WithProviderInventory("cluster-api", clusterctlv1.CoreProviderType, "v1.0.0", "capi-system", "").
+		WithObjs(pod, event, secret)
+	for _, o := range test.NewFakeCluster("ns1", "foo").Objs() {
+		proxy.WithObjs(o)
+	}
+
+	d := &diagnosticsCollector{
+		proxy:             proxy,
+		providerInventory: newInventoryClient(proxy, nil),
+		workloadCluster:   newWorkloadCluster(proxy),
+		logReader:         &fakePodLogReader{},
+	}
+
+	var buf bytes.Buffer
+	g.Expect(d.Collect(DiagnosticsOptions{Namespace: "ns1", LogTailLines: 10}, &buf)).To(Succeed())
+
+	files := readTarball(g, &buf)
+	g.Expect(files).To(HaveKey("management-cluster/resources/ns1/Cluster/foo.yaml"))
+	g.Expect(files).To(HaveKey("management-cluster/events/ns1.yaml"))
+	g.Expect(files["management-cluster/events/ns1.yaml"]).To(ContainSubstring("reason: Moved"))
+	g.Expect(files).To(HaveKey("management-cluster/crds.yaml"))
+	g.Expect(files["management-cluster/crds.yaml"]).To(ContainSubstring("storageVersion: v1alpha4"))
+	g.Expect(files["management-cluster/logs/capi-system/capi-controller-manager/manager.log"]).To(Equal("logs of capi-system/capi-controller-manager/manager, tail 10"))
+
+	// Secret values are redacted.
+	g.Expect(files).To(HaveKey("management-cluster/resources/ns1/Secret/foo-credentials.yaml"))
+	g.Expect(files["management-cluster/resources/ns1/Secret/foo-credentials.yaml"]).To(ContainSubstring("password: " + redactedValue))
+}
+
+func Test_redactObject(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata": map[string]interface{}{
+				"name":      "foo",
+				"namespace": "ns1",
+				"annotations": map[string]interface{}{
+					lastAppliedConfigAnnotation: `{"data":{"value":"c2VjcmV0"}}`,
+					"foo":                       "bar",
+				},
+				"managedFields": []interface{}{
+					map[string]interface{}{"manager": "kubectl"},
+				},
+			},
+			"data": map[string]interface{}{
+				"value": "c2VjcmV0",
+			},
+			"stringData": map[string]interface{}{
+				"other": "secret",
+			},
+		},
+	}
+
+	redactObject(obj)
+
+	g.Expect(obj.GetManagedFields()).To(BeEmpty())
+	g.Expect(obj.GetAnnotations()).To(Equal(map[string]string{
+		lastAppliedConfigAnnotation: redactedValue,
+		"foo":                       "bar",
+	}))
+	g.Expect(obj.Object["data"]).To(Equal(map[string]interface{}{"value": redactedValue}))
+	g.Expect(obj.Object["stringData"]).To(Equal(map[string]interface{}{"other": redactedValue}))
+}
+
+// readTarball returns the content of the files in a gzipped tarball.
+func readTarball(g *WithT, r io.Reader) map[string]string {
+	gr, err := gzip.NewReader(r)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	files := map[string]string{}
+	tr := tar.NewReader(gr)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		g.Expect(err).NotTo(HaveOccurred())
+
+		data, err := ioutil.ReadAll(tr)
+		g.Expect(err).NotTo(HaveOccurred())
+		files[h.Name] = string(data)
+	}
+	return files
+}
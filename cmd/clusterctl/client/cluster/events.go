@@ -0,0 +1,141 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/reference"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// EventSourceComponent is the component reported as a source of the events recorded by clusterctl.
+	EventSourceComponent = "clusterctl"
+
+	// MovedEventReason is the reason of the event recorded on a Cluster moved to another management cluster.
+	MovedEventReason = "Moved"
+
+	// ForceDeletedEventReason is the reason of the event recorded on an object deleted without running its finalizers.
+	ForceDeletedEventReason = "ForceDeleted"
+
+	// PausedEventReason is the reason of the event recorded on a Cluster paused by clusterctl.
+	PausedEventReason = "Paused"
+
+	// ResumedEventReason is the reason of the event recorded on a Cluster resumed by clusterctl.
+	ResumedEventReason = "Resumed"
+)
+
+// EventRecorder records Kubernetes Events on the objects changed by clusterctl, so operators have an audit
+// trail of the changes applied out-of-band with respect to the controllers, visible e.g. in kubectl describe.
+type EventRecorder interface {
+	// Eventf records an event on an object; eventType is either corev1.EventTypeNormal or corev1.EventTypeWarning.
+	// NOTE: Recording events is best effort, and failures are logged but not returned, so they never block the
+	// operation being recorded.
+	Eventf(obj client.Object, eventType, reason, messageFmt string, args ...interface{})
+}
+
+// eventRecorder implements EventRecorder creating Event objects in the cluster the proxy points to.
+type eventRecorder struct {
+	proxy Proxy
+}
+
+// ensure eventRecorder implements the EventRecorder interface.
+var _ EventRecorder = &eventRecorder{}
+
+// newEventRecorder returns an eventRecorder.
+func newEventRecorder(proxy Proxy) *eventRecorder {
+	return &eventRecorder{
+		proxy: proxy,
+	}
+}
+
+func (r *eventRecorder) Eventf(obj client.Object, eventType, reason, messageFmt string, args ...interface{}) {
+	log := logf.Log
+
+	event, err := newEvent(obj, eventType, reason, fmt.Sprintf(messageFmt, args...))
+	if err != nil {
+		log.V(5).Info("Failed to record event", "Reason", reason, "Error", err.Error())
+		return
+	}
+
+	c, err := r.proxy.NewClient()
+	if err != nil {
+		log.V(5).Info("Failed to record event", "Reason", reason, "Error", err.Error())
+		return
+	}
+	if err := c.Create(ctx, event); err != nil {
+		log.V(5).Info("Failed to record event", "Reason", reason, "Error", err.Error())
+	}
+}
+
+// newEvent returns an Event for an object.
+func newEvent(obj client.Object, eventType, reason, message string) (*corev1.Event, error) {
+	ref, err := reference.GetReference(Scheme, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	// Events for cluster-scoped objects are stored in the default namespace, like it happens in client-go.
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+
+	now := time.Now()
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%v.%x", ref.Name, now.UnixNano()),
+			Namespace: namespace,
+		},
+		InvolvedObject:      *ref,
+		Reason:              reason,
+		Message:             message,
+		Type:                eventType,
+		Source:              corev1.EventSource{Component: EventSourceComponent},
+		ReportingController: EventSourceComponent,
+		FirstTimestamp:      metav1.NewTime(now),
+		LastTimestamp:       metav1.NewTime(now),
+		Count:               1,
+	}, nil
+}
+
+// recordNodeEvent records an event on the object corresponding to a node of the object graph, reading it
+// from the cluster the proxy points to, so the event refers to the UID of the object in that cluster.
+func recordNodeEvent(proxy Proxy, recorder EventRecorder, n *node, eventType, reason, messageFmt string, args ...interface{}) {
+	log := logf.Log
+
+	c, err := proxy.NewClient()
+	if err != nil {
+		log.V(5).Info("Failed to record event", "Reason", reason, "Error", err.Error())
+		return
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(n.identity.APIVersion)
+	obj.SetKind(n.identity.Kind)
+	if err := c.Get(ctx, client.ObjectKey{Namespace: n.identity.Namespace, Name: n.identity.Name}, obj); err != nil {
+		log.V(5).Info("Failed to record event", "Reason", reason, "Error", err.Error())
+		return
+	}
+	recorder.Eventf(obj, eventType, reason, messageFmt, args...)
+}
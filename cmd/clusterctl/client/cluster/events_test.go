@@ -0,0 +1,79 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_eventRecorder_Eventf(t *testing.T) {
+	tests := []struct {
+		name          string
+		obj           client.Object
+		wantNamespace string
+	}{
+		{
+			name: "namespaced object",
+			obj: &clusterv1.Cluster{
+				TypeMeta:   metav1.TypeMeta{APIVersion: clusterv1.GroupVersion.String(), Kind: "Cluster"},
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "cluster1", UID: "uid1"},
+			},
+			wantNamespace: "ns1",
+		},
+		{
+			name: "cluster-scoped object",
+			obj: &corev1.Namespace{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+				ObjectMeta: metav1.ObjectMeta{Name: "ns1", UID: "uid1"},
+			},
+			wantNamespace: metav1.NamespaceDefault,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			proxy := test.NewFakeProxy()
+			r := newEventRecorder(proxy)
+			r.Eventf(tt.obj, corev1.EventTypeNormal, MovedEventReason, "Moved %s", tt.obj.GetName())
+
+			c, err := proxy.NewClient()
+			g.Expect(err).NotTo(HaveOccurred())
+
+			events := &corev1.EventList{}
+			g.Expect(c.List(ctx, events)).To(Succeed())
+			g.Expect(events.Items).To(HaveLen(1))
+
+			event := events.Items[0]
+			g.Expect(event.Namespace).To(Equal(tt.wantNamespace))
+			g.Expect(event.InvolvedObject.Kind).To(Equal(tt.obj.GetObjectKind().GroupVersionKind().Kind))
+			g.Expect(event.InvolvedObject.Name).To(Equal(tt.obj.GetName()))
+			g.Expect(event.InvolvedObject.UID).To(Equal(tt.obj.GetUID()))
+			g.Expect(event.Type).To(Equal(corev1.EventTypeNormal))
+			g.Expect(event.Reason).To(Equal(MovedEventReason))
+			g.Expect(event.Message).To(Equal("Moved " + tt.obj.GetName()))
+			g.Expect(event.Source.Component).To(Equal(EventSourceComponent))
+		})
+	}
+}
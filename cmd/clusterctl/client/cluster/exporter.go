@@ -0,0 +1,296 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// exportStrippedMetadataFields are the metadata fields set by the API server or by controllers, which are removed
+// from the exported objects so they can be applied to another management cluster.
+var exportStrippedMetadataFields = []string{
+	"uid",
+	"resourceVersion",
+	"generation",
+	"creationTimestamp",
+	"deletionTimestamp",
+	"deletionGracePeriodSeconds",
+	"selfLink",
+	"managedFields",
+	"ownerReferences",
+	"finalizers",
+}
+
+// ClusterExportOptions carries the options supported by ClusterExporter.Export.
+type ClusterExportOptions struct {
+	// Namespace where the Cluster is located.
+	Namespace string
+
+	// ClusterName is the name of the Cluster to export.
+	ClusterName string
+
+	// TargetNamespace is the namespace the exported objects, and the references between them, are re-targeted to.
+	// If empty, the namespace of the Cluster is preserved.
+	TargetNamespace string
+}
+
+// ClusterExporter defines methods for exporting the definition of a Cluster as a bundle of objects
+// that can be applied to any management cluster.
+type ClusterExporter interface {
+	// Export returns the objects defining a Cluster, sorted so the core Kubernetes objects come first, followed by the Cluster and
+	// by the other objects sorted by kind and name.
+	// The exported objects are the Cluster, the objects belonging to the Cluster which are not generated by a controller, e.g. the
+	// control plane, the MachineDeployments and their templates, the Secrets owned by any of those objects, and all the objects
+	// they reference. Status, OwnerReferences, finalizers and the other metadata fields set by the API server are removed.
+	Export(options ClusterExportOptions) ([]unstructured.Unstructured, error)
+}
+
+// clusterExporter implements ClusterExporter.
+type clusterExporter struct {
+	proxy Proxy
+}
+
+// ensure clusterExporter implements the ClusterExporter interface.
+var _ ClusterExporter = &clusterExporter{}
+
+// newClusterExporter returns a clusterExporter.
+func newClusterExporter(proxy Proxy) *clusterExporter {
+	return &clusterExporter{
+		proxy: proxy,
+	}
+}
+
+func (e *clusterExporter) Export(options ClusterExportOptions) ([]unstructured.Unstructured, error) {
+	log := logf.Log
+
+	graph, cluster, err := discoverCluster(e.proxy, options.Namespace, options.ClusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := getExportNodes(graph, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := e.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	objs := make([]unstructured.Unstructured, 0, len(nodes))
+	for _, n := range nodes {
+		log.V(1).Info("Exporting", n.identity.Kind, n.identity.Name, "Namespace", n.identity.Namespace)
+
+		obj := unstructured.Unstructured{}
+		obj.SetAPIVersion(n.identity.APIVersion)
+		obj.SetKind(n.identity.Kind)
+		objKey := client.ObjectKey{
+			Namespace: n.identity.Namespace,
+			Name:      n.identity.Name,
+		}
+		if err := c.Get(ctx, objKey, &obj); err != nil {
+			return nil, errors.Wrapf(err, "error reading %q %s/%s", obj.GroupVersionKind(), objKey.Namespace, objKey.Name)
+		}
+
+		normalizeExportedObject(&obj, options.TargetNamespace)
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+// getExportNodes returns the nodes defining a Cluster, i.e. the nodes belonging to the Cluster which are not generated by a
+// controller and all the nodes they reference, sorted so the core Kubernetes objects come first, followed by the Cluster and
+// by the other nodes sorted by kind and name.
+// An error is returned if any of the exported nodes references objects in another namespace, because the export would not
+// be self-contained.
+func getExportNodes(graph *objectGraph, cluster *node) ([]*node, error) {
+	exported := map[*node]bool{}
+	results := map[*node]bool{}
+	for _, n := range graph.getNodes() {
+		if isClusterDefinition(n, cluster, results) {
+			exported[n] = true
+		}
+	}
+
+	// Adds the objects referenced by the exported nodes, e.g. templates shared with other Clusters, so the export is self-contained.
+	queue := make([]*node, 0, len(exported))
+	for n := range exported {
+		queue = append(queue, n)
+	}
+	dangling := []string{}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+
+		for _, ref := range n.references {
+			if ref.Namespace == "" {
+				ref.Namespace = n.identity.Namespace
+			}
+			if ref.Namespace != n.identity.Namespace {
+				dangling = append(dangling, fmt.Sprintf("%s references %s, which is in another namespace", describeRef(n.identity), describeRef(ref)))
+				continue
+			}
+			if target := graph.getReferencedNode(ref); target != nil && !target.virtual && !exported[target] {
+				exported[target] = true
+				queue = append(queue, target)
+			}
+		}
+	}
+	if len(dangling) > 0 {
+		sort.Strings(dangling)
+		return nil, errors.Errorf("cannot export Cluster %s/%s because of references to objects in other namespaces:\n%s",
+			cluster.identity.Namespace, cluster.identity.Name, strings.Join(dangling, "\n"))
+	}
+
+	nodes := make([]*node, 0, len(exported))
+	for n := range exported {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if oi, oj := exportOrder(nodes[i], cluster), exportOrder(nodes[j], cluster); oi != oj {
+			return oi < oj
+		}
+		if nodes[i].identity.Kind != nodes[j].identity.Kind {
+			return nodes[i].identity.Kind < nodes[j].identity.Kind
+		}
+		return nodes[i].identity.Name < nodes[j].identity.Name
+	})
+	return nodes, nil
+}
+
+// isClusterDefinition returns true if a node is part of the definition of a Cluster, that is if it belongs to the Cluster,
+// and it is not generated by a controller, e.g. a MachineSet controlled by a MachineDeployment, or owned by an object generated
+// by a controller, e.g. the InfrastructureMachine of a Machine controlled by a MachineSet.
+// Secrets and ConfigMaps are considered part of the definition also when controlled by an object which is part of the
+// definition, so e.g. the certificates of a Cluster are exported together with its control plane.
+// NB. results caches the outcome for the nodes already processed.
+func isClusterDefinition(n, cluster *node, results map[*node]bool) bool {
+	if result, ok := results[n]; ok {
+		return result
+	}
+
+	// Guards against cycles in the OwnerReference chain, by considering the nodes in a cycle as generated.
+	results[n] = false
+
+	result := isClusterDefinitionUncached(n, cluster, results)
+	results[n] = result
+	return result
+}
+
+func isClusterDefinitionUncached(n, cluster *node, results map[*node]bool) bool {
+	if n.virtual {
+		return false
+	}
+	if _, ok := n.tenantClusters[cluster]; !ok {
+		return false
+	}
+
+	for owner, attributes := range n.owners {
+		// Owners which are not read during discovery or which do not belong to the Cluster, e.g. a ClusterResourceSet, do not
+		// make a node generated.
+		if owner.virtual {
+			continue
+		}
+		if _, ok := owner.tenantClusters[cluster]; !ok {
+			continue
+		}
+
+		if attributes.Controller != nil && *attributes.Controller && n.identity.APIVersion != "v1" {
+			return false
+		}
+		if !isClusterDefinition(owner, cluster, results) {
+			return false
+		}
+	}
+	return true
+}
+
+// exportOrder returns the position of a node in the export, so core Kubernetes objects like Secrets come first, because they
+// may be required by the other objects, followed by the Cluster.
+func exportOrder(n, cluster *node) int {
+	switch {
+	case n.identity.APIVersion == "v1":
+		return 0
+	case n == cluster:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// normalizeExportedObject removes from an object the status and the metadata fields set by the API server or by controllers,
+// and re-targets the object and the references to other objects in the same namespace to the target namespace, if any.
+// NB. OwnerReferences are dropped, given that they require the UID of the owners; they are restored by the controllers once the
+// objects are applied to a management cluster.
+func normalizeExportedObject(obj *unstructured.Unstructured, targetNamespace string) {
+	sourceNamespace := obj.GetNamespace()
+
+	for _, field := range exportStrippedMetadataFields {
+		unstructured.RemoveNestedField(obj.Object, "metadata", field)
+	}
+	unstructured.RemoveNestedField(obj.Object, "status")
+
+	if annotations := obj.GetAnnotations(); annotations != nil {
+		delete(annotations, corev1.LastAppliedConfigAnnotation)
+		if len(annotations) == 0 {
+			annotations = nil
+		}
+		obj.SetAnnotations(annotations)
+	}
+
+	if targetNamespace != "" {
+		obj.SetNamespace(targetNamespace)
+	}
+
+	if spec, ok := obj.Object["spec"]; ok {
+		normalizeObjectReferences(spec, sourceNamespace, targetNamespace)
+	}
+}
+
+// normalizeObjectReferences removes the UID and the resource version from all the object references, i.e. the nested
+// objects having both a kind and a name field, found in the given value, and re-targets the references to objects in the
+// source namespace to the target namespace, if any.
+func normalizeObjectReferences(value interface{}, sourceNamespace, targetNamespace string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		kind, kindOk := v["kind"].(string)
+		name, nameOk := v["name"].(string)
+		if kindOk && nameOk && kind != "" && name != "" {
+			delete(v, "uid")
+			delete(v, "resourceVersion")
+			if namespace, ok := v["namespace"].(string); ok && namespace == sourceNamespace && targetNamespace != "" {
+				v["namespace"] = targetNamespace
+			}
+		}
+		for _, nested := range v {
+			normalizeObjectReferences(nested, sourceNamespace, targetNamespace)
+		}
+	case []interface{}:
+		for _, nested := range v {
+			normalizeObjectReferences(nested, sourceNamespace, targetNamespace)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_getExportNodes(t *testing.T) {
+	g := NewWithT(t)
+
+	// Create an objectGraph bound to a source cluster with all the CRDs for the types involved in the test.
+	graph := getObjectGraphWithObjs(getExporterTestObjs())
+	g.Expect(getFakeDiscoveryTypes(graph)).To(Succeed())
+	g.Expect(graph.Discovery("ns1")).To(Succeed())
+
+	cluster := getClusterNode(graph, "ns1", "cluster1")
+	g.Expect(cluster).ToNot(BeNil())
+
+	nodes, err := getExportNodes(graph, cluster)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	exported := []string{}
+	for _, n := range nodes {
+		exported = append(exported, n.identity.Kind+"/"+n.identity.Name)
+	}
+	// NB. The MachineSet, the Machine it controls and the Machine's infrastructure machine, bootstrap config and bootstrap data
+	// Secret are generated by controllers, so they are not exported; the standalone Machine is exported with all its objects.
+	g.Expect(exported).To(Equal([]string{
+		"Secret/cluster1-ca",
+		"Secret/cluster1-kubeconfig",
+		"Secret/cluster1-sa",
+		"Secret/m2",
+		"Cluster/cluster1",
+		"GenericBootstrapConfig/m2",
+		"GenericBootstrapConfigTemplate/md1",
+		"GenericInfrastructureCluster/cluster1",
+		"GenericInfrastructureMachine/m2",
+		"GenericInfrastructureMachineTemplate/md1",
+		"Machine/m2",
+		"MachineDeployment/md1",
+	}))
+}
+
+func Test_getExportNodes_ReferencesToOtherNamespaces(t *testing.T) {
+	g := NewWithT(t)
+
+	objs := getExporterTestObjs()
+	for _, o := range objs {
+		if c, ok := o.(*clusterv1.Cluster); ok && c.Name == "cluster1" {
+			c.Spec.InfrastructureRef.Namespace = "ns2"
+		}
+	}
+
+	graph := getObjectGraphWithObjs(objs)
+	g.Expect(getFakeDiscoveryTypes(graph)).To(Succeed())
+	g.Expect(graph.Discovery("ns1")).To(Succeed())
+
+	_, err := getExportNodes(graph, getClusterNode(graph, "ns1", "cluster1"))
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("Cluster ns1/cluster1 references GenericInfrastructureCluster ns2/cluster1, which is in another namespace"))
+}
+
+func Test_normalizeExportedObject(t *testing.T) {
+	tests := []struct {
+		name            string
+		targetNamespace string
+		wantNamespace   string
+	}{
+		{
+			name:            "preserves the namespace",
+			targetNamespace: "",
+			wantNamespace:   "ns1",
+		},
+		{
+			name:            "re-targets the object and the references to another namespace",
+			targetNamespace: "ns2",
+			wantNamespace:   "ns2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": clusterv1.GroupVersion.String(),
+				"kind":       "Cluster",
+				"metadata": map[string]interface{}{
+					"name":              "cluster1",
+					"namespace":         "ns1",
+					"uid":               "a-uid",
+					"resourceVersion":   "1",
+					"generation":        int64(2),
+					"creationTimestamp": "2021-01-01T00:00:00Z",
+					"finalizers":        []interface{}{clusterv1.ClusterFinalizer},
+					"ownerReferences":   []interface{}{map[string]interface{}{"kind": "Foo", "name": "foo", "uid": "foo-uid"}},
+					"labels":            map[string]interface{}{"foo": "bar"},
+					"annotations": map[string]interface{}{
+						"kubectl.kubernetes.io/last-applied-configuration": "{}",
+					},
+				},
+				"spec": map[string]interface{}{
+					"infrastructureRef": map[string]interface{}{
+						"kind":            "GenericInfrastructureCluster",
+						"name":            "cluster1",
+						"namespace":       "ns1",
+						"uid":             "infra-uid",
+						"resourceVersion": "3",
+					},
+					"controlPlaneRef": map[string]interface{}{
+						"kind":      "GenericControlPlane",
+						"name":      "cp1",
+						"namespace": "other",
+					},
+				},
+				"status": map[string]interface{}{
+					"phase": "Provisioned",
+				},
+			}}
+
+			normalizeExportedObject(obj, tt.targetNamespace)
+
+			g.Expect(obj.GetName()).To(Equal("cluster1"))
+			g.Expect(obj.GetNamespace()).To(Equal(tt.wantNamespace))
+			g.Expect(obj.GetLabels()).To(Equal(map[string]string{"foo": "bar"}))
+			g.Expect(obj.GetAnnotations()).To(BeNil())
+			metadata := obj.Object["metadata"].(map[string]interface{})
+			for _, field := range exportStrippedMetadataFields {
+				g.Expect(metadata).ToNot(HaveKey(field))
+			}
+			g.Expect(obj.Object).ToNot(HaveKey("status"))
+
+			infrastructureRef, _, _ := unstructured.NestedMap(obj.Object, "spec", "infrastructureRef")
+			g.Expect(infrastructureRef).To(Equal(map[string]interface{}{
+				"kind":      "GenericInfrastructureCluster",
+				"name":      "cluster1",
+				"namespace": tt.wantNamespace,
+			}))
+
+			// References to other namespaces are never re-targeted.
+			controlPlaneNamespace, _, _ := unstructured.NestedString(obj.Object, "spec", "controlPlaneRef", "namespace")
+			g.Expect(controlPlaneNamespace).To(Equal("other"))
+		})
+	}
+}
+
+// getExporterTestObjs returns the objects of a Cluster with a MachineDeployment and a standalone Machine, and of another
+// Cluster in the same namespace.
+func getExporterTestObjs() []client.Object {
+	objs := test.NewFakeCluster("ns1", "cluster1").
+		WithMachines(test.NewFakeMachine("m2")).
+		WithMachineDeployments(
+			test.NewFakeMachineDeployment("md1").
+				WithMachineSets(
+					test.NewFakeMachineSet("ms1").
+						WithMachines(test.NewFakeMachine("m1")),
+				),
+		).Objs()
+	return append(objs, test.NewFakeCluster("ns1", "cluster2").Objs()...)
+}
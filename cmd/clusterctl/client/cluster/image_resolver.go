@@ -0,0 +1,132 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultImageLookupConfigMapName is the name of the ConfigMap hosting the default image lookup table; the ConfigMap
+// is read from the namespace where the core provider is installed.
+const DefaultImageLookupConfigMapName = "clusterctl-image-lookup"
+
+// ImageLookup defines the inputs for resolving a machine image.
+type ImageLookup struct {
+	// Provider is the name of the infrastructure provider, e.g. aws.
+	Provider string
+
+	// KubernetesVersion is the Kubernetes version the image should provide, e.g. v1.20.4.
+	KubernetesVersion string
+
+	// OS is the operating system of the image, e.g. ubuntu-20.04. Optional.
+	OS string
+
+	// Region is the provider specific region or location the image should be available in, e.g. us-east-1. Optional.
+	Region string
+}
+
+// ImageResolver maps a Kubernetes version, operating system and region to a provider specific image ID,
+// so cluster templates do not have to hard-code image IDs.
+type ImageResolver interface {
+	// Resolve returns the image ID for the given lookup; if no image matches, an empty string is returned.
+	Resolve(lookup ImageLookup) (string, error)
+}
+
+// ImageLookupEntry is an entry of an image lookup table.
+// Empty OS and Region match any value, so more specific entries should be listed first.
+type ImageLookupEntry struct {
+	KubernetesVersion string `json:"kubernetesVersion"`
+	OS                string `json:"os,omitempty"`
+	Region            string `json:"region,omitempty"`
+	Image             string `json:"image"`
+}
+
+// matches returns true if the entry matches the given lookup.
+func (e ImageLookupEntry) matches(lookup ImageLookup) bool {
+	if e.KubernetesVersion != lookup.KubernetesVersion {
+		return false
+	}
+	if e.OS != "" && e.OS != lookup.OS {
+		return false
+	}
+	if e.Region != "" && e.Region != lookup.Region {
+		return false
+	}
+	return true
+}
+
+// configMapImageResolver implements ImageResolver reading a lookup table from a ConfigMap.
+// The ConfigMap hosts a lookup table for each provider, with the provider name as a key and
+// a YAML list of ImageLookupEntry as a value.
+type configMapImageResolver struct {
+	proxy     Proxy
+	namespace string
+	name      string
+}
+
+// ensure configMapImageResolver implements the ImageResolver interface.
+var _ ImageResolver = &configMapImageResolver{}
+
+// NewConfigMapImageResolver returns an ImageResolver reading the lookup table from the given ConfigMap.
+// If the ConfigMap does not exist, no images are resolved.
+func NewConfigMapImageResolver(proxy Proxy, namespace, name string) ImageResolver {
+	return &configMapImageResolver{
+		proxy:     proxy,
+		namespace: namespace,
+		name:      name,
+	}
+}
+
+func (r *configMapImageResolver) Resolve(lookup ImageLookup) (string, error) {
+	if lookup.Provider == "" || lookup.KubernetesVersion == "" {
+		return "", nil
+	}
+
+	c, err := r.proxy.NewClient()
+	if err != nil {
+		return "", err
+	}
+
+	configMap := &corev1.ConfigMap{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: r.namespace, Name: r.name}, configMap); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", errors.Wrapf(err, "failed to read the image lookup table from ConfigMap %s/%s", r.namespace, r.name)
+	}
+
+	data, ok := configMap.Data[lookup.Provider]
+	if !ok {
+		return "", nil
+	}
+
+	entries := []ImageLookupEntry{}
+	if err := yaml.UnmarshalStrict([]byte(data), &entries); err != nil {
+		return "", errors.Wrapf(err, "failed to parse the image lookup table for provider %q in ConfigMap %s/%s", lookup.Provider, r.namespace, r.name)
+	}
+
+	for _, e := range entries {
+		if e.matches(lookup) {
+			return e.Image, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_configMapImageResolver_Resolve(t *testing.T) {
+	lookupTable := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "capi-system",
+			Name:      DefaultImageLookupConfigMapName,
+		},
+		Data: map[string]string{
+			"aws": `
+- kubernetesVersion: v1.20.4
+  os: ubuntu-20.04
+  region: eu-west-1
+  image: ami-ubuntu-eu
+- kubernetesVersion: v1.20.4
+  os: ubuntu-20.04
+  image: ami-ubuntu
+- kubernetesVersion: v1.20.4
+  image: ami-default
+`,
+			"invalid": "- kubernetesVersion: v1.20.4\n  foo: bar",
+		},
+	}
+
+	tests := []struct {
+		name    string
+		objs    []client.Object
+		lookup  ImageLookup
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "returns the most specific entry listed first",
+			objs:   []client.Object{lookupTable},
+			lookup: ImageLookup{Provider: "aws", KubernetesVersion: "v1.20.4", OS: "ubuntu-20.04", Region: "eu-west-1"},
+			want:   "ami-ubuntu-eu",
+		},
+		{
+			name:   "entries without region match any region",
+			objs:   []client.Object{lookupTable},
+			lookup: ImageLookup{Provider: "aws", KubernetesVersion: "v1.20.4", OS: "ubuntu-20.04", Region: "us-east-1"},
+			want:   "ami-ubuntu",
+		},
+		{
+			name:   "entries without os and region match any os and region",
+			objs:   []client.Object{lookupTable},
+			lookup: ImageLookup{Provider: "aws", KubernetesVersion: "v1.20.4", OS: "flatcar"},
+			want:   "ami-default",
+		},
+		{
+			name:   "returns no image if the Kubernetes version does not match",
+			objs:   []client.Object{lookupTable},
+			lookup: ImageLookup{Provider: "aws", KubernetesVersion: "v1.19.1"},
+			want:   "",
+		},
+		{
+			name:   "returns no image if there is no lookup table for the provider",
+			objs:   []client.Object{lookupTable},
+			lookup: ImageLookup{Provider: "azure", KubernetesVersion: "v1.20.4"},
+			want:   "",
+		},
+		{
+			name:   "returns no image if the ConfigMap does not exist",
+			objs:   []client.Object{},
+			lookup: ImageLookup{Provider: "aws", KubernetesVersion: "v1.20.4"},
+			want:   "",
+		},
+		{
+			name:    "fails if the lookup table is invalid",
+			objs:    []client.Object{lookupTable},
+			lookup:  ImageLookup{Provider: "invalid", KubernetesVersion: "v1.20.4"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := NewConfigMapImageResolver(test.NewFakeProxy().WithObjs(tt.objs...), "capi-system", DefaultImageLookupConfigMapName)
+			got, err := r.Resolve(tt.lookup)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
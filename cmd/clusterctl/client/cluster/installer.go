@@ -17,7 +17,10 @@ limitations under the License.
 package cluster
 
 import (
+	"time"
+
 	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/version"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
@@ -25,6 +28,11 @@ import (
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
 	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	waitProviderInterval = 5 * time.Second
 )
 
 // ProviderInstaller defines methods for enforcing consistency rules for provider installation.
@@ -34,8 +42,9 @@ type ProviderInstaller interface {
 	// before actually starting the installation of new providers.
 	Add(repository.Components)
 
-	// Install performs the installation of the providers ready in the install queue.
-	Install() ([]repository.Components, error)
+	// Install performs the installation of the providers ready in the install queue, in the order defined by the
+	// dependencies between provider types.
+	Install(InstallOptions) ([]repository.Components, error)
 
 	// Validate performs steps to validate a management cluster by looking at the current state and the providers in the queue.
 	// The following checks are performed in order to ensure a fully operational cluster:
@@ -44,12 +53,31 @@ type ProviderInstaller interface {
 	// - Providers must combine in valid management groups
 	//   - All the providers must belong to one/only one management groups
 	//   - All the providers in a management group must support the same API Version of Cluster API (contract)
-	Validate() error
+	// - Providers must be compatible with the version of the core provider and with the Kubernetes version of the management cluster,
+	//   if a compatibility is defined in the provider metadata
+	Validate(ValidateOptions) error
 
 	// Images returns the list of images required for installing the providers ready in the install queue.
 	Images() []string
 }
 
+// InstallOptions defines the options used to configure installation.
+type InstallOptions struct {
+	// WaitProviders instructs the installer to wait for the providers each provider depends on to be ready before installing it,
+	// and for all the providers to be ready before returning.
+	WaitProviders bool
+
+	// WaitProviderTimeout sets the timeout for waiting for a provider to be ready.
+	WaitProviderTimeout time.Duration
+}
+
+// ValidateOptions defines the options used to configure validation.
+type ValidateOptions struct {
+	// SkipCompatibilityCheck instructs the installer to skip the check of the compatibility of the providers with
+	// the version of the core provider and with the Kubernetes version of the management cluster.
+	SkipCompatibilityCheck bool
+}
+
 // providerInstaller implements ProviderInstaller.
 type providerInstaller struct {
 	configClient            config.Client
@@ -57,6 +85,7 @@ type providerInstaller struct {
 	proxy                   Proxy
 	providerComponents      ComponentsClient
 	providerInventory       InventoryClient
+	pollImmediateWaiter     PollImmediateWaiter
 	installQueue            []repository.Components
 }
 
@@ -66,18 +95,140 @@ func (i *providerInstaller) Add(components repository.Components) {
 	i.installQueue = append(i.installQueue, components)
 }
 
-func (i *providerInstaller) Install() ([]repository.Components, error) {
-	ret := make([]repository.Components, 0, len(i.installQueue))
-	for _, components := range i.installQueue {
+func (i *providerInstaller) Install(opts InstallOptions) ([]repository.Components, error) {
+	queue := installOrder(i.installQueue)
+
+	ret := make([]repository.Components, 0, len(queue))
+	ready := sets.NewInt()
+	for idx, components := range queue {
+		// If requested, wait for the providers this provider depends on to be ready before installing it, so it is installed
+		// on a management cluster already serving the APIs and webhooks it depends on (e.g. the ones of the core provider).
+		if opts.WaitProviders {
+			for _, dep := range queueDependencies(queue, idx) {
+				if ready.Has(dep) {
+					continue
+				}
+				if err := i.waitForProviderReady(queue[dep], opts.WaitProviderTimeout); err != nil {
+					return nil, err
+				}
+				ready.Insert(dep)
+			}
+		}
+
 		if err := installComponentsAndUpdateInventory(components, i.providerComponents, i.providerInventory); err != nil {
 			return nil, err
 		}
 
 		ret = append(ret, components)
 	}
+
+	// If requested, wait for the providers no other provider depends on too, so all the providers are ready on return.
+	if opts.WaitProviders {
+		for idx := range queue {
+			if ready.Has(idx) {
+				continue
+			}
+			if err := i.waitForProviderReady(queue[idx], opts.WaitProviderTimeout); err != nil {
+				return nil, err
+			}
+		}
+	}
 	return ret, nil
 }
 
+// providerDependencies defines the dependency graph between provider types: a provider of a given type can be installed only
+// after the providers of the types it depends on. All the providers depend on the core provider; control plane providers
+// depend on bootstrap providers too, because they create bootstrap configurations for the control plane machines.
+var providerDependencies = map[clusterctlv1.ProviderType][]clusterctlv1.ProviderType{
+	clusterctlv1.CoreProviderType:           {},
+	clusterctlv1.BootstrapProviderType:      {clusterctlv1.CoreProviderType},
+	clusterctlv1.ControlPlaneProviderType:   {clusterctlv1.CoreProviderType, clusterctlv1.BootstrapProviderType},
+	clusterctlv1.InfrastructureProviderType: {clusterctlv1.CoreProviderType},
+}
+
+// installOrder returns the components in the install queue sorted so each provider comes after the providers it depends on;
+// providers without dependencies between them are kept in the queue order.
+func installOrder(installQueue []repository.Components) []repository.Components {
+	ret := make([]repository.Components, 0, len(installQueue))
+	added := make([]bool, len(installQueue))
+	for len(ret) < len(installQueue) {
+		for idx, components := range installQueue {
+			if added[idx] || !dependenciesAdded(installQueue, added, components.Type()) {
+				continue
+			}
+			ret = append(ret, components)
+			added[idx] = true
+			// Restart from the beginning of the queue, so the queue order is preserved as much as possible.
+			break
+		}
+	}
+	return ret
+}
+
+// dependenciesAdded returns true if all the providers in the install queue a provider of the given type depends on have been added.
+func dependenciesAdded(installQueue []repository.Components, added []bool, providerType clusterctlv1.ProviderType) bool {
+	for _, dependencyType := range providerDependencies[providerType] {
+		for idx, components := range installQueue {
+			if !added[idx] && components.Type() == dependencyType {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// queueDependencies returns the indexes of the providers in the queue the provider at the given index depends on.
+func queueDependencies(queue []repository.Components, idx int) []int {
+	ret := []int{}
+	for _, dependencyType := range providerDependencies[queue[idx].Type()] {
+		for dep, components := range queue[:idx] {
+			if components.Type() == dependencyType {
+				ret = append(ret, dep)
+			}
+		}
+	}
+	return ret
+}
+
+// waitForProviderReady waits for all the deployments of a provider to be available, and for the webhooks of the provider to be serving.
+func (i *providerInstaller) waitForProviderReady(components repository.Components, timeout time.Duration) error {
+	log := logf.Log
+	log.Info("Waiting for provider to be available", "Provider", components.ManifestLabel(), "TargetNamespace", components.TargetNamespace())
+
+	c, err := i.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	for _, obj := range components.InstanceObjs() {
+		if obj.GetKind() != "Deployment" {
+			continue
+		}
+		key := client.ObjectKey{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+		if err := i.pollImmediateWaiter(waitProviderInterval, timeout, func() (bool, error) {
+			return isDeploymentAvailable(c, key), nil
+		}); err != nil {
+			return errors.Wrapf(err, "error waiting for Deployment %s of provider %s to be available", key.String(), components.ManifestLabel())
+		}
+	}
+
+	// Checks the webhooks are serving too, because the Deployments could be available before the API server trusts the webhook
+	// certificates, and in the meantime the creation of any object handled by the webhooks would fail.
+	probes, err := getWebhookProbes(c, components.TargetNamespace())
+	if err != nil {
+		return err
+	}
+	for _, probe := range probes {
+		probe := probe
+		if err := i.pollImmediateWaiter(waitProviderInterval, timeout, func() (bool, error) {
+			return probe.check(c) == nil, nil
+		}); err != nil {
+			return errors.Wrapf(err, "error waiting for webhook %s of provider %s to be serving", probe.webhook, components.ManifestLabel())
+		}
+	}
+	return nil
+}
+
 func installComponentsAndUpdateInventory(components repository.Components, providerComponents ComponentsClient, providerInventory InventoryClient) error {
 	log := logf.Log
 	log.Info("Installing", "Provider", components.ManifestLabel(), "Version", components.Version(), "TargetNamespace", components.TargetNamespace())
@@ -102,7 +253,7 @@ func installComponentsAndUpdateInventory(components repository.Components, provi
 		log.V(1).Info("Creating shared objects", "Provider", components.ManifestLabel(), "Version", components.Version())
 		// TODO: currently shared components overrides existing shared components. As a future improvement we should
 		//  consider if to delete (preserving CRDs) before installing so there will be no left-overs in case the list of resources changes
-		if err := providerComponents.Create(components.SharedObjs()); err != nil {
+		if _, err := providerComponents.Create(components.SharedObjs()); err != nil {
 			return err
 		}
 	} else {
@@ -112,7 +263,7 @@ func installComponentsAndUpdateInventory(components repository.Components, provi
 	// Then always install the instance specific objects and the then inventory item for the provider
 
 	log.V(1).Info("Creating instance objects", "Provider", components.ManifestLabel(), "Version", components.Version(), "TargetNamespace", components.TargetNamespace())
-	if err := providerComponents.Create(components.InstanceObjs()); err != nil {
+	if _, err := providerComponents.Create(components.InstanceObjs()); err != nil {
 		return err
 	}
 
@@ -158,7 +309,7 @@ func shouldInstallSharedComponents(providerList *clusterctlv1.ProviderList, prov
 	return true, nil
 }
 
-func (i *providerInstaller) Validate() error {
+func (i *providerInstaller) Validate(opts ValidateOptions) error {
 	// Get the list of providers currently in the cluster.
 	providerList, err := i.providerInventory.List()
 	if err != nil {
@@ -206,6 +357,33 @@ func (i *providerInstaller) Validate() error {
 			return errors.Errorf("installing provider %q can lead to a non functioning management cluster: the target version for the provider supports the %s API Version of Cluster API (contract), while the management group is using %s", components.ManifestLabel(), providerContract, managementGroupContract)
 		}
 	}
+
+	if opts.SkipCompatibilityCheck {
+		return nil
+	}
+
+	// Checks if all the providers are compatible with the version of the core provider of the corresponding management group
+	// and with the Kubernetes version of the management cluster.
+	kubernetesVersion, err := i.proxy.GetServerVersion()
+	if err != nil {
+		return err
+	}
+	var errList []error
+	for _, components := range i.installQueue {
+		provider := components.InventoryObject()
+		managementGroup := managementGroups.FindManagementGroupByProviderInstanceName(provider.InstanceName())
+
+		releaseSeries, err := i.getProviderReleaseSeries(provider)
+		if err != nil {
+			return err
+		}
+		if err := checkCompatibility(provider, provider.Version, releaseSeries, managementGroup.CoreProvider.Version, kubernetesVersion); err != nil {
+			errList = append(errList, err)
+		}
+	}
+	if len(errList) > 0 {
+		return errors.Wrap(kerrors.NewAggregate(errList), "installing the providers can lead to a non functioning management cluster")
+	}
 	return nil
 }
 
@@ -217,40 +395,48 @@ func (i *providerInstaller) getProviderContract(providerInstanceContracts map[st
 	}
 
 	// Otherwise get the contract for the providers instance.
+	releaseSeries, err := i.getProviderReleaseSeries(provider)
+	if err != nil {
+		return "", err
+	}
+
+	if releaseSeries.Contract != clusterv1.GroupVersion.Version {
+		return "", errors.Errorf("current version of clusterctl could install only %s providers, detected %s for provider %s", clusterv1.GroupVersion.Version, releaseSeries.Contract, provider.ManifestLabel())
+	}
+
+	providerInstanceContracts[provider.InstanceName()] = releaseSeries.Contract
+	return releaseSeries.Contract, nil
+}
 
+// getProviderReleaseSeries returns the release series for a provider instance, as defined in the provider metadata.
+func (i *providerInstaller) getProviderReleaseSeries(provider clusterctlv1.Provider) (*clusterctlv1.ReleaseSeries, error) {
 	// Gets the providers metadata.
 	configRepository, err := i.configClient.Providers().Get(provider.ProviderName, provider.GetProviderType())
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	providerRepository, err := i.repositoryClientFactory(configRepository, i.configClient)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	latestMetadata, err := providerRepository.Metadata(provider.Version).Get()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	// Gets the contract for the current release.
+	// Gets the release series for the current release.
 	currentVersion, err := version.ParseSemantic(provider.Version)
 	if err != nil {
-		return "", errors.Wrapf(err, "failed to parse current version for the %s provider", provider.InstanceName())
+		return nil, errors.Wrapf(err, "failed to parse current version for the %s provider", provider.InstanceName())
 	}
 
 	releaseSeries := latestMetadata.GetReleaseSeriesForVersion(currentVersion)
 	if releaseSeries == nil {
-		return "", errors.Errorf("invalid provider metadata: version %s for the provider %s does not match any release series", provider.Version, provider.InstanceName())
-	}
-
-	if releaseSeries.Contract != clusterv1.GroupVersion.Version {
-		return "", errors.Errorf("current version of clusterctl could install only %s providers, detected %s for provider %s", clusterv1.GroupVersion.Version, releaseSeries.Contract, provider.ManifestLabel())
+		return nil, errors.Errorf("invalid provider metadata: version %s for the provider %s does not match any release series", provider.Version, provider.InstanceName())
 	}
-
-	providerInstanceContracts[provider.InstanceName()] = releaseSeries.Contract
-	return releaseSeries.Contract, nil
+	return releaseSeries, nil
 }
 
 // simulateInstall adds a provider to the list of providers in a cluster (without installing it).
@@ -289,12 +475,13 @@ func (i *providerInstaller) Images() []string {
 	return ret.List()
 }
 
-func newProviderInstaller(configClient config.Client, repositoryClientFactory RepositoryClientFactory, proxy Proxy, providerMetadata InventoryClient, providerComponents ComponentsClient) *providerInstaller {
+func newProviderInstaller(configClient config.Client, repositoryClientFactory RepositoryClientFactory, proxy Proxy, providerMetadata InventoryClient, providerComponents ComponentsClient, pollImmediateWaiter PollImmediateWaiter) *providerInstaller {
 	return &providerInstaller{
 		configClient:            configClient,
 		repositoryClientFactory: repositoryClientFactory,
 		proxy:                   proxy,
 		providerComponents:      providerComponents,
 		providerInventory:       providerMetadata,
+		pollImmediateWaiter:     pollImmediateWaiter,
 	}
 }
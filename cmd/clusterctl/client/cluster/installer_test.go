@@ -18,10 +18,15 @@ package cluster
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
@@ -271,7 +276,7 @@ func Test_providerInstaller_Validate(t *testing.T) {
 				installQueue: tt.fields.installQueue,
 			}
 
-			err := i.Validate()
+			err := i.Validate(ValidateOptions{})
 			if tt.wantErr {
 				g.Expect(err).To(HaveOccurred())
 			} else {
@@ -281,13 +286,129 @@ func Test_providerInstaller_Validate(t *testing.T) {
 	}
 }
 
+func Test_providerInstaller_waitForProviderReady(t *testing.T) {
+	deployment := func(available corev1.ConditionStatus) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: appsv1.SchemeGroupVersion.String(),
+				Kind:       "Deployment",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "infra1-system",
+				Name:      "infra1-controller-manager",
+			},
+			Status: appsv1.DeploymentStatus{
+				Conditions: []appsv1.DeploymentCondition{
+					{Type: appsv1.DeploymentAvailable, Status: available},
+				},
+			},
+		}
+	}
+	deploymentObj := unstructured.Unstructured{}
+	deploymentObj.SetAPIVersion(appsv1.SchemeGroupVersion.String())
+	deploymentObj.SetKind("Deployment")
+	deploymentObj.SetNamespace("infra1-system")
+	deploymentObj.SetName("infra1-controller-manager")
+
+	tests := []struct {
+		name    string
+		proxy   Proxy
+		wantErr bool
+	}{
+		{
+			name:    "provider deployments are available",
+			proxy:   test.NewFakeProxy().WithObjs(deployment(corev1.ConditionTrue)),
+			wantErr: false,
+		},
+		{
+			name:    "provider deployments are not available",
+			proxy:   test.NewFakeProxy().WithObjs(deployment(corev1.ConditionFalse)),
+			wantErr: true,
+		},
+		{
+			name:    "provider deployments do not exist",
+			proxy:   test.NewFakeProxy(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			components := newFakeComponents("infra1", clusterctlv1.InfrastructureProviderType, "v1.0.0", "infra1-system", "")
+			components.(*fakeComponents).instanceObjs = []unstructured.Unstructured{deploymentObj}
+
+			i := &providerInstaller{
+				proxy: tt.proxy,
+				pollImmediateWaiter: func(_, _ time.Duration, condition wait.ConditionFunc) error {
+					return wait.PollImmediate(time.Millisecond, 10*time.Millisecond, condition)
+				},
+			}
+
+			err := i.waitForProviderReady(components, time.Minute)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+		})
+	}
+}
+
+func Test_installOrder(t *testing.T) {
+	core := newFakeComponents("cluster-api", clusterctlv1.CoreProviderType, "v1.0.0", "cluster-api-system", "")
+	bootstrap := newFakeComponents("kubeadm", clusterctlv1.BootstrapProviderType, "v1.0.0", "kubeadm-bootstrap-system", "")
+	controlPlane := newFakeComponents("kubeadm", clusterctlv1.ControlPlaneProviderType, "v1.0.0", "kubeadm-control-plane-system", "")
+	infra1 := newFakeComponents("infra1", clusterctlv1.InfrastructureProviderType, "v1.0.0", "infra1-system", "")
+	infra2 := newFakeComponents("infra2", clusterctlv1.InfrastructureProviderType, "v1.0.0", "infra2-system", "")
+
+	tests := []struct {
+		name             string
+		installQueue     []repository.Components
+		wantOrder        []repository.Components
+		wantDependencies [][]int
+	}{
+		{
+			name:             "providers already in dependency order",
+			installQueue:     []repository.Components{core, bootstrap, controlPlane, infra1},
+			wantOrder:        []repository.Components{core, bootstrap, controlPlane, infra1},
+			wantDependencies: [][]int{{}, {0}, {0, 1}, {0}},
+		},
+		{
+			name:             "providers are sorted by dependencies",
+			installQueue:     []repository.Components{infra1, controlPlane, infra2, bootstrap, core},
+			wantOrder:        []repository.Components{core, infra1, infra2, bootstrap, controlPlane},
+			wantDependencies: [][]int{{}, {0}, {0}, {0}, {0, 3}},
+		},
+		{
+			name:             "dependencies not in the queue are ignored",
+			installQueue:     []repository.Components{controlPlane, infra1},
+			wantOrder:        []repository.Components{controlPlane, infra1},
+			wantDependencies: [][]int{{}, {}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got := installOrder(tt.installQueue)
+			g.Expect(got).To(Equal(tt.wantOrder))
+			for idx := range got {
+				g.Expect(queueDependencies(got, idx)).To(Equal(tt.wantDependencies[idx]))
+			}
+		})
+	}
+}
+
 type fakeComponents struct {
 	config.Provider
 	inventoryObject clusterctlv1.Provider
+	instanceObjs    []unstructured.Unstructured
+	sharedObjs      []unstructured.Unstructured
 }
 
 func (c *fakeComponents) Version() string {
-	panic("not implemented")
+	return c.inventoryObject.Version
 }
 
 func (c *fakeComponents) Variables() []string {
@@ -299,7 +420,7 @@ func (c *fakeComponents) Images() []string {
 }
 
 func (c *fakeComponents) TargetNamespace() string {
-	panic("not implemented")
+	return c.inventoryObject.Namespace
 }
 
 func (c *fakeComponents) WatchingNamespace() string {
@@ -311,11 +432,11 @@ func (c *fakeComponents) InventoryObject() clusterctlv1.Provider {
 }
 
 func (c *fakeComponents) InstanceObjs() []unstructured.Unstructured {
-	panic("not implemented")
+	return c.instanceObjs
 }
 
 func (c *fakeComponents) SharedObjs() []unstructured.Unstructured {
-	panic("not implemented")
+	return c.sharedObjs
 }
 
 func (c *fakeComponents) Yaml() ([]byte, error) {
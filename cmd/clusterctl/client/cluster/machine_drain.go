@@ -0,0 +1,174 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	kubedrain "sigs.k8s.io/cluster-api/third_party/kubernetes-drain"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// MachineDrainOptions defines the options supported by MachineDrainClient.
+type MachineDrainOptions struct {
+	// Namespace where the Machine is located.
+	Namespace string
+
+	// MachineName is the name of the Machine.
+	MachineName string
+
+	// Timeout is the time to wait for the Pods to be evicted or deleted from the Node; zero means infinite.
+	Timeout time.Duration
+
+	// GracePeriodSeconds is the period of time in seconds given to each Pod to terminate gracefully.
+	// If negative, the default value specified in the Pod will be used.
+	GracePeriodSeconds int
+
+	// DeleteEmptyDirData allows to drain Pods using emptyDir volumes; data in emptyDir volumes is deleted.
+	DeleteEmptyDirData bool
+
+	// DisableEviction forces the drain to delete Pods rather than evicting them, thus bypassing PodDisruptionBudgets.
+	DisableEviction bool
+}
+
+// MachineDrainClient has methods for draining the Nodes of Machines.
+type MachineDrainClient interface {
+	// CordonAndDrain cordons the Node of a Machine, and then evicts, or deletes, the Pods running on it.
+	// Pods whose eviction is blocked by a PodDisruptionBudget are retried until the timeout expires.
+	CordonAndDrain(options MachineDrainOptions) error
+}
+
+// workloadClientsetGetter returns a client-go clientset for accessing the API server of a workload cluster.
+type workloadClientsetGetter func(clusterNamespace, clusterName string) (kubernetes.Interface, error)
+
+// newWorkloadClientsetGetter returns a workloadClientsetGetter reading the kubeconfig of the workload cluster
+// from the management cluster.
+func newWorkloadClientsetGetter(workloadCluster WorkloadCluster) workloadClientsetGetter {
+	return func(clusterNamespace, clusterName string) (kubernetes.Interface, error) {
+		kubeconfig, err := workloadCluster.GetKubeconfig(clusterName, clusterNamespace)
+		if err != nil {
+			return nil, err
+		}
+		config, err := clientcmd.RESTConfigFromKubeConfig([]byte(kubeconfig))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the kubeconfig of Cluster %s/%s", clusterNamespace, clusterName)
+		}
+		cs, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create the client-go client for Cluster %s/%s", clusterNamespace, clusterName)
+		}
+		return cs, nil
+	}
+}
+
+// drainLogWriter implements io.Writer on top of a logger, so the messages printed by the drain helper are
+// reported like the other clusterctl messages.
+type drainLogWriter struct {
+	log logr.Logger
+}
+
+func (w drainLogWriter) Write(p []byte) (int, error) {
+	if msg := strings.TrimSpace(string(p)); msg != "" {
+		w.log.Info(msg)
+	}
+	return len(p), nil
+}
+
+// machineDrainClient implements MachineDrainClient.
+type machineDrainClient struct {
+	proxy             Proxy
+	workloadClientset workloadClientsetGetter
+}
+
+// ensure machineDrainClient implements the MachineDrainClient interface.
+var _ MachineDrainClient = &machineDrainClient{}
+
+func (m *machineDrainClient) CordonAndDrain(options MachineDrainOptions) error {
+	log := logf.Log
+
+	c, err := m.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	machine := &clusterv1.Machine{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: options.Namespace, Name: options.MachineName}, machine); err != nil {
+		return errors.Wrapf(err, "failed to get Machine %s/%s", options.Namespace, options.MachineName)
+	}
+	if machine.Status.NodeRef == nil {
+		return errors.Errorf("Machine %s/%s does not have a Node yet", machine.Namespace, machine.Name)
+	}
+	nodeName := machine.Status.NodeRef.Name
+
+	cs, err := m.workloadClientset(machine.Namespace, machine.Spec.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	node, err := cs.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get Node %s of Machine %s/%s", nodeName, machine.Namespace, machine.Name)
+	}
+
+	log = log.WithValues("Machine", fmt.Sprintf("%s/%s", machine.Namespace, machine.Name), "Node", nodeName)
+	drainer := &kubedrain.Helper{
+		Client:              cs,
+		Force:               true,
+		IgnoreAllDaemonSets: true,
+		DeleteLocalData:     options.DeleteEmptyDirData,
+		GracePeriodSeconds:  options.GracePeriodSeconds,
+		Timeout:             options.Timeout,
+		DisableEviction:     options.DisableEviction,
+		OnPodDeletedOrEvicted: func(pod *corev1.Pod, usingEviction bool) {
+			verbStr := "Deleted"
+			if usingEviction {
+				verbStr = "Evicted"
+			}
+			log.Info(fmt.Sprintf("%s pod", verbStr), "Pod", fmt.Sprintf("%s/%s", pod.Namespace, pod.Name))
+		},
+		Out:    drainLogWriter{log: log.V(5)},
+		ErrOut: drainLogWriter{log: log},
+	}
+
+	log.Info("Cordoning Node")
+	if err := kubedrain.RunCordonOrUncordon(ctx, drainer, node, true); err != nil {
+		return errors.Wrapf(err, "failed to cordon Node %s of Machine %s/%s", nodeName, machine.Namespace, machine.Name)
+	}
+
+	log.Info("Draining Node")
+	if err := kubedrain.RunNodeDrain(ctx, drainer, nodeName); err != nil {
+		return errors.Wrapf(err, "failed to drain Node %s of Machine %s/%s", nodeName, machine.Namespace, machine.Name)
+	}
+	return nil
+}
+
+func newMachineDrainClient(proxy Proxy) *machineDrainClient {
+	return &machineDrainClient{
+		proxy:             proxy,
+		workloadClientset: newWorkloadClientsetGetter(newWorkloadCluster(proxy)),
+	}
+}
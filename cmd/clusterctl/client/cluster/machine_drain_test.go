@@ -0,0 +1,126 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_machineDrainClient_CordonAndDrain(t *testing.T) {
+	machine := func(name string, nodeName string) *clusterv1.Machine {
+		m := &clusterv1.Machine{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Machine",
+				APIVersion: clusterv1.GroupVersion.String(),
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns1",
+				Name:      name,
+			},
+			Spec: clusterv1.MachineSpec{
+				ClusterName: "cluster1",
+			},
+		}
+		if nodeName != "" {
+			m.Status.NodeRef = &corev1.ObjectReference{Kind: "Node", Name: nodeName}
+		}
+		return m
+	}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node1",
+		},
+	}
+	pod := func(name string, nodeName string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      name,
+			},
+			Spec: corev1.PodSpec{
+				NodeName: nodeName,
+			},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		objs         []client.Object
+		workloadObjs []runtime.Object
+		wantErr      bool
+	}{
+		{
+			name:         "cordons the Node and deletes the Pods running on it",
+			objs:         []client.Object{machine("m1", "node1")},
+			workloadObjs: []runtime.Object{node, pod("p1", "node1"), pod("p2", "node1")},
+		},
+		{
+			name:    "fails if the Machine does not have a Node",
+			objs:    []client.Object{machine("m1", "")},
+			wantErr: true,
+		},
+		{
+			name:    "fails if the Node does not exist",
+			objs:    []client.Object{machine("m1", "node1")},
+			wantErr: true,
+		},
+		{
+			name:    "fails if the Machine does not exist",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			cs := fake.NewSimpleClientset(tt.workloadObjs...)
+			m := &machineDrainClient{
+				proxy: test.NewFakeProxy().WithObjs(tt.objs...),
+				workloadClientset: func(clusterNamespace, clusterName string) (kubernetes.Interface, error) {
+					g.Expect(clusterNamespace).To(Equal("ns1"))
+					g.Expect(clusterName).To(Equal("cluster1"))
+					return cs, nil
+				},
+			}
+			err := m.CordonAndDrain(MachineDrainOptions{Namespace: "ns1", MachineName: "m1", GracePeriodSeconds: -1})
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			gotNode, err := cs.CoreV1().Nodes().Get(ctx, "node1", metav1.GetOptions{})
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(gotNode.Spec.Unschedulable).To(BeTrue())
+
+			// NOTE: the fake clientset does not support eviction, so Pods are deleted.
+			pods, err := cs.CoreV1().Pods("default").List(ctx, metav1.ListOptions{})
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(pods.Items).To(BeEmpty())
+		})
+	}
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ConsoleLogsSecretNameField is the optional field of the infrastructure machine status where infrastructure providers
+	// can expose the name of a Secret, in the same namespace, holding the console/serial logs of the machine.
+	ConsoleLogsSecretNameField = "consoleLogsSecretName"
+
+	// ConsoleLogsSecretKey is the key of the Secret referenced by the ConsoleLogsSecretNameField where the logs are stored.
+	ConsoleLogsSecretKey = "value"
+)
+
+// MachineLogsSource defines where the logs of a Machine have been read from.
+type MachineLogsSource string
+
+const (
+	// ConsoleMachineLogsSource defines logs read from the console of the machine, as exposed by the infrastructure provider.
+	ConsoleMachineLogsSource = MachineLogsSource("Console")
+
+	// NodeMachineLogsSource defines logs read from /var/log on the Node, through the API server of the workload cluster.
+	NodeMachineLogsSource = MachineLogsSource("Node")
+)
+
+// MachineLogsOptions defines the options supported by MachineLogsClient.
+type MachineLogsOptions struct {
+	// Namespace where the Machine is located.
+	Namespace string
+
+	// MachineName is the name of the Machine.
+	MachineName string
+
+	// NodeLogFiles is the list of files read from /var/log on the Node when the infrastructure provider does not expose
+	// console logs. Files not existing on the Node are ignored. If empty, DefaultNodeLogFiles are read.
+	NodeLogFiles []string
+}
+
+// MachineLogFile defines a log file of a Machine.
+type MachineLogFile struct {
+	// Name of the log file, e.g. console or kubelet.log.
+	Name string
+
+	// Content of the log file.
+	Content []byte
+}
+
+// MachineLogs defines the logs of a Machine.
+type MachineLogs struct {
+	// Source defines where the logs have been read from.
+	Source MachineLogsSource
+
+	// Files are the log files of the Machine.
+	Files []MachineLogFile
+}
+
+// MachineLogsClient has methods for reading the logs of Machines.
+type MachineLogsClient interface {
+	// Get returns the console logs exposed by the infrastructure provider for a Machine, which are available also when
+	// the machine failed to bootstrap; if the provider does not expose console logs, Get falls back to the log files
+	// read from the Node of the Machine, if any.
+	Get(options MachineLogsOptions) (*MachineLogs, error)
+}
+
+// nodeLogReader defines methods for reading log files from the nodes of a workload cluster.
+type nodeLogReader interface {
+	// ReadNodeLogs returns the content of a file in /var/log on a node of a workload cluster.
+	ReadNodeLogs(clusterNamespace, clusterName, nodeName, file string) ([]byte, error)
+}
+
+// workloadClusterNodeLogReader implements nodeLogReader reading logs through the node proxy of the workload cluster API server,
+// the same way node logs are collected for diagnostics.
+type workloadClusterNodeLogReader struct {
+	workloadCluster WorkloadCluster
+}
+
+func (r *workloadClusterNodeLogReader) ReadNodeLogs(clusterNamespace, clusterName, nodeName, file string) ([]byte, error) {
+	cs, err := newWorkloadClusterClientset(r.workloadCluster, clusterNamespace, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	return readNodeLogFile(cs, nodeName, file)
+}
+
+// machineLogsClient implements MachineLogsClient.
+type machineLogsClient struct {
+	proxy     Proxy
+	logReader nodeLogReader
+}
+
+// ensure machineLogsClient implements the MachineLogsClient interface.
+var _ MachineLogsClient = &machineLogsClient{}
+
+func (m *machineLogsClient) Get(options MachineLogsOptions) (*MachineLogs, error) {
+	c, err := m.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	machine := &clusterv1.Machine{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: options.Namespace, Name: options.MachineName}, machine); err != nil {
+		return nil, errors.Wrapf(err, "failed to get Machine %s/%s", options.Namespace, options.MachineName)
+	}
+
+	logs, err := m.getConsoleLogs(c, machine)
+	if err != nil {
+		return nil, err
+	}
+	if logs != nil {
+		return logs, nil
+	}
+
+	if machine.Status.NodeRef == nil {
+		return nil, errors.Errorf("the infrastructure provider does not expose console logs for Machine %s/%s, and the Machine does not have a Node yet", machine.Namespace, machine.Name)
+	}
+	return m.getNodeLogs(machine, options.NodeLogFiles)
+}
+
+// getConsoleLogs returns the console logs exposed by the infrastructure machine, or nil if the infrastructure provider
+// does not expose them.
+func (m *machineLogsClient) getConsoleLogs(c client.Client, machine *clusterv1.Machine) (*MachineLogs, error) {
+	ref := machine.Spec.InfrastructureRef
+	infraMachine := &unstructured.Unstructured{}
+	infraMachine.SetAPIVersion(ref.APIVersion)
+	infraMachine.SetKind(ref.Kind)
+	if err := c.Get(ctx, client.ObjectKey{Namespace: machine.Namespace, Name: ref.Name}, infraMachine); err != nil {
+		return nil, errors.Wrapf(err, "failed to get %s %s/%s for Machine %s/%s", ref.Kind, machine.Namespace, ref.Name, machine.Namespace, machine.Name)
+	}
+
+	secretName, found, err := unstructured.NestedString(infraMachine.Object, "status", ConsoleLogsSecretNameField)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read status.%s from %s %s/%s", ConsoleLogsSecretNameField, ref.Kind, machine.Namespace, ref.Name)
+	}
+	if !found || secretName == "" {
+		return nil, nil
+	}
+
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: machine.Namespace, Name: secretName}, secret); err != nil {
+		return nil, errors.Wrapf(err, "failed to get the console logs Secret %s/%s for Machine %s/%s", machine.Namespace, secretName, machine.Namespace, machine.Name)
+	}
+	content, ok := secret.Data[ConsoleLogsSecretKey]
+	if !ok {
+		return nil, errors.Errorf("the console logs Secret %s/%s does not have the %q key", machine.Namespace, secretName, ConsoleLogsSecretKey)
+	}
+
+	return &MachineLogs{
+		Source: ConsoleMachineLogsSource,
+		Files:  []MachineLogFile{{Name: "console", Content: content}},
+	}, nil
+}
+
+// getNodeLogs returns the log files read from the Node of the Machine.
+func (m *machineLogsClient) getNodeLogs(machine *clusterv1.Machine, files []string) (*MachineLogs, error) {
+	if len(files) == 0 {
+		files = DefaultNodeLogFiles
+	}
+
+	logs := &MachineLogs{Source: NodeMachineLogsSource}
+	for _, file := range files {
+		content, err := m.logReader.ReadNodeLogs(machine.Namespace, machine.Spec.ClusterName, machine.Status.NodeRef.Name, file)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, errors.Wrapf(err, "failed to read %s from Node %s of Machine %s/%s", file, machine.Status.NodeRef.Name, machine.Namespace, machine.Name)
+		}
+		logs.Files = append(logs.Files, MachineLogFile{Name: file, Content: content})
+	}
+	if len(logs.Files) == 0 {
+		return nil, errors.Errorf("none of the log files %v exists on Node %s of Machine %s/%s", files, machine.Status.NodeRef.Name, machine.Namespace, machine.Name)
+	}
+	return logs, nil
+}
+
+func newMachineLogsClient(proxy Proxy) *machineLogsClient {
+	return &machineLogsClient{
+		proxy:     proxy,
+		logReader: &workloadClusterNodeLogReader{workloadCluster: newWorkloadCluster(proxy)},
+	}
+}
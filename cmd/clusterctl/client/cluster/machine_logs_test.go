@@ -0,0 +1,169 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type fakeNodeLogReader struct{}
+
+func (r *fakeNodeLogReader) ReadNodeLogs(clusterNamespace, clusterName, nodeName, file string) ([]byte, error) {
+	if file != "kubelet.log" {
+		return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "nodes/proxy"}, file)
+	}
+	return []byte(fmt.Sprintf("logs of %s/%s/%s/%s", clusterNamespace, clusterName, nodeName, file)), nil
+}
+
+func Test_machineLogsClient_Get(t *testing.T) {
+	machine := func(name string, nodeName string) *clusterv1.Machine {
+		m := &clusterv1.Machine{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Machine",
+				APIVersion: clusterv1.GroupVersion.String(),
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns1",
+				Name:      name,
+			},
+			Spec: clusterv1.MachineSpec{
+				ClusterName: "cluster1",
+				InfrastructureRef: corev1.ObjectReference{
+					APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha4",
+					Kind:       "TestMachine",
+					Name:       name,
+				},
+			},
+		}
+		if nodeName != "" {
+			m.Status.NodeRef = &corev1.ObjectReference{Kind: "Node", Name: nodeName}
+		}
+		return m
+	}
+	infraMachine := func(name string, consoleLogsSecretName string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha4",
+				"kind":       "TestMachine",
+				"metadata": map[string]interface{}{
+					"namespace": "ns1",
+					"name":      name,
+				},
+				"status": map[string]interface{}{},
+			},
+		}
+		if consoleLogsSecretName != "" {
+			u.Object["status"] = map[string]interface{}{
+				ConsoleLogsSecretNameField: consoleLogsSecretName,
+			}
+		}
+		return u
+	}
+	consoleLogs := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns1",
+			Name:      "console-logs",
+		},
+		Data: map[string][]byte{
+			ConsoleLogsSecretKey: []byte("kubeadm init failed"),
+		},
+	}
+
+	tests := []struct {
+		name        string
+		objs        []client.Object
+		machineName string
+		files       []string
+		want        *MachineLogs
+		wantErr     bool
+	}{
+		{
+			name:        "returns the console logs exposed by the infrastructure provider",
+			objs:        []client.Object{machine("m1", "node1"), infraMachine("m1", "console-logs"), consoleLogs},
+			machineName: "m1",
+			want: &MachineLogs{
+				Source: ConsoleMachineLogsSource,
+				Files:  []MachineLogFile{{Name: "console", Content: []byte("kubeadm init failed")}},
+			},
+		},
+		{
+			name:        "falls back to the Node logs, ignoring missing files",
+			objs:        []client.Object{machine("m1", "node1"), infraMachine("m1", "")},
+			machineName: "m1",
+			want: &MachineLogs{
+				Source: NodeMachineLogsSource,
+				Files:  []MachineLogFile{{Name: "kubelet.log", Content: []byte("logs of ns1/cluster1/node1/kubelet.log")}},
+			},
+		},
+		{
+			name:        "fails if none of the Node log files exists",
+			objs:        []client.Object{machine("m1", "node1"), infraMachine("m1", "")},
+			machineName: "m1",
+			files:       []string{"syslog"},
+			wantErr:     true,
+		},
+		{
+			name:        "fails if there are no console logs and the Machine does not have a Node",
+			objs:        []client.Object{machine("m1", ""), infraMachine("m1", "")},
+			machineName: "m1",
+			wantErr:     true,
+		},
+		{
+			name:        "fails if the console logs Secret does not exist",
+			objs:        []client.Object{machine("m1", ""), infraMachine("m1", "console-logs")},
+			machineName: "m1",
+			wantErr:     true,
+		},
+		{
+			name:        "fails if the Machine does not exist",
+			machineName: "m1",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			m := &machineLogsClient{
+				proxy:     test.NewFakeProxy().WithObjs(tt.objs...),
+				logReader: &fakeNodeLogReader{},
+			}
+			got, err := m.Get(MachineLogsOptions{Namespace: "ns1", MachineName: tt.machineName, NodeLogFiles: tt.files})
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
@@ -29,6 +29,8 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/version"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
 	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -37,11 +39,19 @@ import (
 // ObjectMover defines methods for moving Cluster API objects to another management cluster.
 type ObjectMover interface {
 	// Move moves all the Cluster API objects existing in a namespace (or from all the namespaces if empty) to a target management cluster.
-	Move(namespace string, toCluster Client, dryRun bool) error
+	// If clusterName is not empty, only the objects belonging to the Cluster with the given name are moved.
+	// Before changing any object, Move checks that the moved objects do not reference objects outside the moved set.
+	Move(namespace, clusterName string, toCluster Client, dryRun bool) error
+
+	// GetClusterSecrets returns the Secrets belonging to a Cluster, sorted by name; those are the Secrets owned by the Cluster
+	// or by any of its descendants, the Secrets linked to the Cluster by naming convention and the Secrets with the cluster name label.
+	// All these Secrets are moved together with the Cluster, rebinding their OwnerReferences to the objects in the target management cluster.
+	GetClusterSecrets(namespace, name string) ([]corev1.ObjectReference, error)
 }
 
 // objectMover implements the ObjectMover interface.
 type objectMover struct {
+	configClient          config.Client
 	fromProxy             Proxy
 	fromProviderInventory InventoryClient
 	dryRun                bool
@@ -50,7 +60,20 @@ type objectMover struct {
 // ensure objectMover implements the ObjectMover interface.
 var _ ObjectMover = &objectMover{}
 
-func (o *objectMover) Move(namespace string, toCluster Client, dryRun bool) error {
+func (o *objectMover) GetClusterSecrets(namespace, name string) ([]corev1.ObjectReference, error) {
+	graph, cluster, err := discoverCluster(o.fromProxy, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := []corev1.ObjectReference{}
+	for _, secret := range graph.getClusterSecrets(cluster) {
+		secrets = append(secrets, secret.identity)
+	}
+	return secrets, nil
+}
+
+func (o *objectMover) Move(namespace, clusterName string, toCluster Client, dryRun bool) error {
 	log := logf.Log
 	log.Info("Performing move...")
 	o.dryRun = dryRun
@@ -82,6 +105,24 @@ func (o *objectMover) Move(namespace string, toCluster Client, dryRun bool) erro
 		return err
 	}
 
+	// If required, restrict the move operation to the objects belonging to a single Cluster.
+	if clusterName != "" {
+		if err := objectGraph.filterCluster(namespace, clusterName); err != nil {
+			return err
+		}
+	}
+
+	// Checks that the objects being moved do not reference objects left behind, e.g. objects in other namespaces or belonging to
+	// other Clusters, so the move operation fails before any change to the source or to the target management cluster.
+	if err := objectGraph.checkReferences(); err != nil {
+		return err
+	}
+
+	// Reports the OwnerReferences to objects which are not moved in any case, given that those OwnerReferences are going to be dropped.
+	for _, dropped := range objectGraph.getDroppedOwnerReferences() {
+		log.Info(fmt.Sprintf("Dropping OwnerReference: %s", dropped))
+	}
+
 	// Checks if Cluster API has already completed the provisioning of the infrastructure for the objects involved in the move operation.
 	// This is required because if the infrastructure is provisioned, then we can reasonably assume that the objects we are moving are
 	// not currently waiting for long-running reconciliation loops, and so we can safely rely on the pause field on the Cluster object
@@ -106,8 +147,9 @@ func (o *objectMover) Move(namespace string, toCluster Client, dryRun bool) erro
 	return nil
 }
 
-func newObjectMover(fromProxy Proxy, fromProviderInventory InventoryClient) *objectMover {
+func newObjectMover(configClient config.Client, fromProxy Proxy, fromProviderInventory InventoryClient) *objectMover {
 	return &objectMover{
+		configClient:          configClient,
 		fromProxy:             fromProxy,
 		fromProviderInventory: fromProviderInventory,
 	}
@@ -254,6 +296,14 @@ func (o *objectMover) move(graph *objectGraph, toProxy Proxy) error {
 		return err
 	}
 
+	// Records an event on the Clusters in the target management cluster, so the move is visible to operators.
+	if !o.dryRun {
+		recorder := newEventRecorder(toProxy)
+		for _, cluster := range clusters {
+			recordNodeEvent(toProxy, recorder, cluster, corev1.EventTypeNormal, MovedEventReason, "Moved from another management cluster by clusterctl")
+		}
+	}
+
 	return nil
 }
 
@@ -305,8 +355,12 @@ func getMoveSequence(graph *objectGraph) *moveSequence {
 
 			// Check if all the ownerReferences are already included in the move sequence; if yes, add the node to move group,
 			// otherwise skip it (the node will be re-processed in the next group).
+			// NB. owners not being moved, e.g. owners of Secrets linked to a Cluster by the cluster name label, are ignored.
 			ownersInPlace := true
 			for owner := range n.owners {
+				if !owner.isMoved() {
+					continue
+				}
 				if !moveSequence.hasNode(owner) {
 					ownersInPlace = false
 					break
@@ -460,7 +514,8 @@ func (o *objectMover) ensureNamespace(toProxy Proxy, namespace string) error {
 		return err
 	}
 
-	// If the namespace does not exists, create it.
+	// If the namespace does not exists, create it, preserving labels and annotations from the source namespace
+	// and marking it as created by clusterctl.
 	ns = &corev1.Namespace{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "v1",
@@ -470,6 +525,13 @@ func (o *objectMover) ensureNamespace(toProxy Proxy, namespace string) error {
 			Name: namespace,
 		},
 	}
+	if err := o.copyNamespaceMetadata(ns); err != nil {
+		return err
+	}
+	if ns.Annotations == nil {
+		ns.Annotations = map[string]string{}
+	}
+	ns.Annotations[clusterctlv1.ClusterctlNamespaceCreatedAnnotation] = ""
 	log.V(1).Info("Creating", ns.Kind, ns.Name)
 	if err := cs.Create(ctx, ns); err != nil && !apierrors.IsAlreadyExists(err) {
 		return err
@@ -477,6 +539,26 @@ func (o *objectMover) ensureNamespace(toProxy Proxy, namespace string) error {
 	return nil
 }
 
+// copyNamespaceMetadata copies labels and annotations from the corresponding namespace in the source cluster, if readable.
+func (o *objectMover) copyNamespaceMetadata(ns *corev1.Namespace) error {
+	cFrom, err := o.fromProxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	source := &corev1.Namespace{}
+	if err := cFrom.Get(ctx, client.ObjectKey{Name: ns.Name}, source); err != nil {
+		// Tolerate namespaces that cannot be read, e.g. because of RBAC restrictions.
+		if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get namespace %s from the source cluster", ns.Name)
+	}
+	ns.Labels = source.Labels
+	ns.Annotations = source.Annotations
+	return nil
+}
+
 // createGroup creates all the Kubernetes objects into the target management cluster corresponding to the object graph nodes in a moveGroup.
 func (o *objectMover) createGroup(group moveGroup, toProxy Proxy) error {
 	createTargetObjectBackoff := newWriteBackoff()
@@ -536,9 +618,16 @@ func (o *objectMover) createTargetObject(nodeToCreate *node, toProxy Proxy) erro
 	obj.SetOwnerReferences(nil)
 
 	// Recreate all the OwnerReferences using the newUID of the owner nodes.
+	// NB. OwnerReferences to owners not being moved are dropped, because the owners do not exist in the target management cluster;
+	// those OwnerReferences are reported before starting the move operation.
 	if len(nodeToCreate.owners) > 0 {
 		ownerRefs := []metav1.OwnerReference{}
 		for ownerNode := range nodeToCreate.owners {
+			if !ownerNode.isMoved() {
+				log.V(5).Info(fmt.Sprintf("Dropping OwnerReference: %s is owned by %s, which is not being moved", describeRef(nodeToCreate.identity), describeRef(ownerNode.identity)))
+				continue
+			}
+
 			ownerRef := metav1.OwnerReference{
 				APIVersion: ownerNode.identity.APIVersion,
 				Kind:       ownerNode.identity.Kind,
@@ -554,7 +643,9 @@ func (o *objectMover) createTargetObject(nodeToCreate *node, toProxy Proxy) erro
 
 			ownerRefs = append(ownerRefs, ownerRef)
 		}
-		obj.SetOwnerReferences(ownerRefs)
+		if len(ownerRefs) > 0 {
+			obj.SetOwnerReferences(ownerRefs)
+		}
 	}
 
 	// Creates the targetObj into the target management cluster.
@@ -573,20 +664,25 @@ func (o *objectMover) createTargetObject(nodeToCreate *node, toProxy Proxy) erro
 		// Nb. This should not happen, but it is supported to make move more resilient to unexpected interrupt/restarts of the move process.
 		log.V(5).Info("Object already exists, updating", nodeToCreate.identity.Kind, nodeToCreate.identity.Name, "Namespace", nodeToCreate.identity.Namespace)
 
-		// Retrieve the UID and the resource version for the update.
-		existingTargetObj := &unstructured.Unstructured{}
-		existingTargetObj.SetAPIVersion(obj.GetAPIVersion())
-		existingTargetObj.SetKind(obj.GetKind())
-		if err := cTo.Get(ctx, objKey, existingTargetObj); err != nil {
-			return errors.Wrapf(err, "error reading resource for %q %s/%s",
-				existingTargetObj.GroupVersionKind(), existingTargetObj.GetNamespace(), existingTargetObj.GetName())
-		}
+		// Retrieve the UID and the resource version for the update; the object is read again on conflicts.
+		if err := retryOnConflict(newConflictBackoff(o.configClient), func() error {
+			existingTargetObj := &unstructured.Unstructured{}
+			existingTargetObj.SetAPIVersion(obj.GetAPIVersion())
+			existingTargetObj.SetKind(obj.GetKind())
+			if err := cTo.Get(ctx, objKey, existingTargetObj); err != nil {
+				return errors.Wrapf(err, "error reading resource for %q %s/%s",
+					existingTargetObj.GroupVersionKind(), existingTargetObj.GetNamespace(), existingTargetObj.GetName())
+			}
 
-		obj.SetUID(existingTargetObj.GetUID())
-		obj.SetResourceVersion(existingTargetObj.GetResourceVersion())
-		if err := cTo.Update(ctx, obj); err != nil {
-			return errors.Wrapf(err, "error updating %q %s/%s",
-				obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+			obj.SetUID(existingTargetObj.GetUID())
+			obj.SetResourceVersion(existingTargetObj.GetResourceVersion())
+			if err := cTo.Update(ctx, obj); err != nil {
+				return errors.Wrapf(err, "error updating %q %s/%s",
+					obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+			}
+			return nil
+		}); err != nil {
+			return err
 		}
 	}
 
@@ -622,10 +718,6 @@ func (o *objectMover) deleteGroup(group moveGroup) error {
 	return kerrors.NewAggregate(errList)
 }
 
-var (
-	removeFinalizersPatch = client.RawPatch(types.MergePatchType, []byte("{\"metadata\":{\"finalizers\":[]}}"))
-)
-
 // deleteSourceObject deletes the Kubernetes object corresponding to the node from the source management cluster, taking care of removing all the finalizers so
 // the objects gets immediately deleted (force delete).
 func (o *objectMover) deleteSourceObject(nodeToDelete *node) error {
@@ -641,7 +733,6 @@ func (o *objectMover) deleteSourceObject(nodeToDelete *node) error {
 		return err
 	}
 
-	// Get the source object
 	sourceObj := &unstructured.Unstructured{}
 	sourceObj.SetAPIVersion(nodeToDelete.identity.APIVersion)
 	sourceObj.SetKind(nodeToDelete.identity.Kind)
@@ -650,29 +741,7 @@ func (o *objectMover) deleteSourceObject(nodeToDelete *node) error {
 		Name:      nodeToDelete.identity.Name,
 	}
 
-	if err := cFrom.Get(ctx, sourceObjKey, sourceObj); err != nil {
-		if apierrors.IsNotFound(err) {
-			//If the object is already deleted, move on.
-			log.V(5).Info("Object already deleted, skipping delete for", nodeToDelete.identity.Kind, nodeToDelete.identity.Name, "Namespace", nodeToDelete.identity.Namespace)
-			return nil
-		}
-		return errors.Wrapf(err, "error reading %q %s/%s",
-			sourceObj.GroupVersionKind(), sourceObj.GetNamespace(), sourceObj.GetName())
-	}
-
-	if len(sourceObj.GetFinalizers()) > 0 {
-		if err := cFrom.Patch(ctx, sourceObj, removeFinalizersPatch); err != nil {
-			return errors.Wrapf(err, "error removing finalizers from %q %s/%s",
-				sourceObj.GroupVersionKind(), sourceObj.GetNamespace(), sourceObj.GetName())
-		}
-	}
-
-	if err := cFrom.Delete(ctx, sourceObj); err != nil {
-		return errors.Wrapf(err, "error deleting %q %s/%s",
-			sourceObj.GroupVersionKind(), sourceObj.GetNamespace(), sourceObj.GetName())
-	}
-
-	return nil
+	return forceDelete(cFrom, sourceObj, sourceObjKey, newConflictBackoff(o.configClient))
 }
 
 // checkTargetProviders checks that all the providers installed in the source cluster exists in the target cluster as well (with a version >= of the current version).
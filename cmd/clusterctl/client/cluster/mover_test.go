@@ -621,6 +621,34 @@ func Test_objectMover_move(t *testing.T) {
 	}
 }
 
+func Test_objectMover_move_ClusterLabeledSecret(t *testing.T) {
+	g := NewWithT(t)
+
+	// A secret with the cluster name label, owned by an object which is not moved.
+	objs := append(
+		test.NewFakeCluster("ns1", "foo").Objs(),
+		newClusterLabeledSecret("ns1", "credentials", "foo", &metav1.OwnerReference{APIVersion: "v1", Kind: "ConfigMap", Name: "bar", UID: "/v1, Kind=ConfigMap, ns1/bar"}),
+	)
+
+	graph := getObjectGraphWithObjs(objs)
+	g.Expect(getFakeDiscoveryTypes(graph)).To(Succeed())
+	g.Expect(graph.Discovery("")).To(Succeed())
+
+	toProxy := getFakeProxyWithCRDs()
+	mover := objectMover{
+		fromProxy: graph.proxy,
+	}
+	g.Expect(mover.move(graph, toProxy)).To(Succeed())
+
+	csTo, err := toProxy.NewClient()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// the secret is created in the target cluster, dropping the OwnerReference to the object not being moved.
+	secret := &corev1.Secret{}
+	g.Expect(csTo.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: "credentials"}, secret)).To(Succeed())
+	g.Expect(secret.GetOwnerReferences()).To(BeEmpty())
+}
+
 func Test_objectMover_checkProvisioningCompleted(t *testing.T) {
 	type fields struct {
 		objs []client.Object
@@ -1041,6 +1069,31 @@ func Test_objectMoverService_ensureNamespace(t *testing.T) {
 	}
 }
 
+func Test_objectMoverService_ensureNamespace_copiesMetadata(t *testing.T) {
+	g := NewWithT(t)
+
+	source := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "namespace-1",
+			Labels:      map[string]string{"foo": "bar"},
+			Annotations: map[string]string{"baz": "qux"},
+		},
+	}
+	mover := objectMover{
+		fromProxy: test.NewFakeProxy().WithObjs(source),
+	}
+	toProxy := test.NewFakeProxy()
+
+	g.Expect(mover.ensureNamespace(toProxy, "namespace-1")).To(Succeed())
+
+	csTo, err := toProxy.NewClient()
+	g.Expect(err).ToNot(HaveOccurred())
+	ns := &corev1.Namespace{}
+	g.Expect(csTo.Get(ctx, client.ObjectKey{Name: "namespace-1"}, ns)).To(Succeed())
+	g.Expect(ns.Labels).To(Equal(map[string]string{"foo": "bar"}))
+	g.Expect(ns.Annotations).To(Equal(map[string]string{"baz": "qux", clusterctlv1.ClusterctlNamespaceCreatedAnnotation: ""}))
+}
+
 func Test_objectMoverService_ensureNamespaces(t *testing.T) {
 	type args struct {
 		toProxy Proxy
@@ -18,6 +18,7 @@ package cluster
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -60,6 +61,9 @@ type node struct {
 	// isGlobal gets set to true if this object is a global resource (no namespace).
 	isGlobal bool
 
+	// clusterName is the value of the cluster name label of the object, if any.
+	clusterName string
+
 	// virtual records if this node was discovered indirectly, e.g. by processing an OwnerRef, but not yet observed as a concrete object.
 	virtual bool
 
@@ -73,6 +77,9 @@ type node struct {
 	// tenantCRSs define the list of ClusterResourceSet which are tenant for the node, no matter if the node has a direct OwnerReference to the ClusterResourceSet or if
 	// the node is linked to a ClusterResourceSet indirectly in the OwnerReference chain.
 	tenantCRSs map[*node]empty
+
+	// references contains the object references found in the spec of the object, e.g. Cluster.Spec.InfrastructureRef.
+	references []corev1.ObjectReference
 }
 
 type discoveryTypeInfo struct {
@@ -103,17 +110,38 @@ func (n *node) isSoftOwnedBy(other *node) bool {
 	return ok
 }
 
+// hasDiscoveredOwners returns true if at least one of the owners of the node was read during discovery.
+func (n *node) hasDiscoveredOwners() bool {
+	for owner := range n.owners {
+		if !owner.virtual {
+			return true
+		}
+	}
+	return false
+}
+
+// isMoved returns true if the node belongs at least to one Cluster or to a ClusterResourceSet or to a CRD containing the "move" label,
+// and thus it is moved to the target management cluster.
+func (n *node) isMoved() bool {
+	return len(n.tenantClusters) > 0 || len(n.tenantCRSs) > 0 || n.forceMove
+}
+
 // objectGraph manages the Kubernetes object graph that is generated during the discovery phase for the move operation.
 type objectGraph struct {
 	proxy     Proxy
 	uidToNode map[types.UID]*node
 	types     map[string]*discoveryTypeInfo
+
+	// excludedNodes contains the nodes that would be moved, but are outside the scope of the move operation,
+	// e.g. the objects belonging to other Clusters when moving a single Cluster.
+	excludedNodes map[types.UID]*node
 }
 
 func newObjectGraph(proxy Proxy) *objectGraph {
 	return &objectGraph{
-		proxy:     proxy,
-		uidToNode: map[types.UID]*node{},
+		proxy:         proxy,
+		uidToNode:     map[types.UID]*node{},
+		excludedNodes: map[types.UID]*node{},
 	}
 }
 
@@ -123,6 +151,11 @@ func (o *objectGraph) addObj(obj *unstructured.Unstructured) {
 	// Adds the node to the Graph.
 	newNode := o.objToNode(obj)
 
+	// Records the references to other objects, so it is possible to check they are moved together with the node.
+	if spec, ok := obj.Object["spec"]; ok {
+		newNode.references = getObjectReferences(spec)
+	}
+
 	// Process OwnerReferences; if the owner object doe not exists yet, create a virtual node as a placeholder for it.
 	for _, ownerReference := range obj.GetOwnerReferences() {
 		ownerNode, ok := o.uidToNode[ownerReference.UID]
@@ -178,6 +211,7 @@ func (o *objectGraph) objToNode(obj *unstructured.Unstructured) *node {
 		// it is required to re-compute the forceMove flag when the real node is processed
 		// Without this, there is the risk that, forceMove will report false negatives depending on the discovery order
 		existingNode.forceMove = o.getForceMove(obj.GetKind(), obj.GetAPIVersion(), obj.GetLabels())
+		existingNode.clusterName = obj.GetLabels()[clusterv1.ClusterLabelName]
 		return existingNode
 	}
 
@@ -201,6 +235,7 @@ func (o *objectGraph) objToNode(obj *unstructured.Unstructured) *node {
 		virtual:        false,
 		forceMove:      o.getForceMove(obj.GetKind(), obj.GetAPIVersion(), obj.GetLabels()),
 		isGlobal:       isGlobal,
+		clusterName:    obj.GetLabels()[clusterv1.ClusterLabelName],
 	}
 
 	o.uidToNode[newNode.identity.UID] = newNode
@@ -400,13 +435,27 @@ func (o *objectGraph) getCRSs() []*node {
 func (o *objectGraph) getMoveNodes() []*node {
 	nodes := []*node{}
 	for _, node := range o.uidToNode {
-		if len(node.tenantClusters) > 0 || len(node.tenantCRSs) > 0 || node.forceMove {
+		if node.isMoved() {
 			nodes = append(nodes, node)
 		}
 	}
 	return nodes
 }
 
+// getClusterSecrets returns the list of Secrets existing in the object graph that belong to a Cluster, sorted by name.
+func (o *objectGraph) getClusterSecrets(cluster *node) []*node {
+	secrets := []*node{}
+	for _, secret := range o.getSecrets() {
+		if _, ok := secret.tenantClusters[cluster]; ok {
+			secrets = append(secrets, secret)
+		}
+	}
+	sort.Slice(secrets, func(i, j int) bool {
+		return secrets[i].identity.Name < secrets[j].identity.Name
+	})
+	return secrets
+}
+
 // getMachines returns the list of Machine existing in the object graph.
 func (o *objectGraph) getMachines() []*node {
 	machines := []*node{}
@@ -418,11 +467,24 @@ func (o *objectGraph) getMachines() []*node {
 	return machines
 }
 
-// setSoftOwnership searches for soft ownership relations such as secrets linked to the cluster by a naming convention (without any explicit OwnerReference).
+// setSoftOwnership searches for soft ownership relations such as secrets linked to the cluster by a naming convention
+// or by the cluster name label (without any explicit OwnerReference).
 func (o *objectGraph) setSoftOwnership() {
 	log := logf.Log
 	clusters := o.getClusters()
 	for _, secret := range o.getSecrets() {
+		// If the secret has the cluster name label and it is not owned by any of the discovered objects, then add the cluster
+		// to the list of the secrets's softOwners.
+		// NB. This applies also to secrets with OwnerReferences to objects not being discovered, because such owners are not moved.
+		if secret.clusterName != "" && !secret.hasDiscoveredOwners() {
+			for _, cluster := range clusters {
+				if secret.clusterName == cluster.identity.Name && secret.identity.Namespace == cluster.identity.Namespace {
+					secret.addSoftOwner(cluster)
+				}
+			}
+			continue
+		}
+
 		// If the secret has at least one OwnerReference ignore it.
 		// NB. Cluster API generated secrets have an explicit OwnerReference to the ControlPlane or the KubeadmConfig object while user provided secrets might not have one.
 		if len(secret.owners) > 0 {
@@ -479,6 +541,169 @@ func (o *objectGraph) setCRSTenant(node, tenant *node) {
 	}
 }
 
+// filterCluster restricts the scope of the move operation to the objects belonging to a single Cluster;
+// all the other nodes that would be moved are removed from the graph and tracked as excluded nodes.
+func (o *objectGraph) filterCluster(namespace, name string) error {
+	cluster := getClusterNode(o, namespace, name)
+	if cluster == nil {
+		return errors.Errorf("failed to find Cluster %s/%s", namespace, name)
+	}
+
+	// ClusterResourceSets, and the resources they own, are moved together with the Cluster they are bound to.
+	boundCRSs := map[*node]empty{}
+	for _, node := range o.uidToNode {
+		if _, ok := node.tenantClusters[cluster]; !ok || node.identity.GroupVersionKind().GroupKind() != addonsv1.GroupVersion.WithKind("ClusterResourceSetBinding").GroupKind() {
+			continue
+		}
+		for owner := range node.owners {
+			if owner.identity.GroupVersionKind().GroupKind() == addonsv1.GroupVersion.WithKind("ClusterResourceSet").GroupKind() {
+				boundCRSs[owner] = empty{}
+			}
+		}
+	}
+
+	for uid, node := range o.uidToNode {
+		if !node.isMoved() {
+			continue
+		}
+		if _, ok := node.tenantClusters[cluster]; ok {
+			continue
+		}
+		if len(node.tenantClusters) == 0 && isTenantOfAny(node.tenantCRSs, boundCRSs) {
+			continue
+		}
+		o.excludedNodes[uid] = node
+		delete(o.uidToNode, uid)
+	}
+	return nil
+}
+
+// isTenantOfAny returns true if at least one of the tenants is in the given set of nodes.
+func isTenantOfAny(tenants, nodes map[*node]empty) bool {
+	for tenant := range tenants {
+		if _, ok := nodes[tenant]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkReferences checks that the nodes being moved do not reference objects outside the moved set, and
+// returns an error listing all the dangling references otherwise.
+// NB. OwnerReferences to owners which are not moved in any case, e.g. owners of Secrets linked to a Cluster by the cluster name label,
+// are not considered dangling references, given that they are dropped when creating the objects in the target cluster;
+// see getDroppedOwnerReferences.
+func (o *objectGraph) checkReferences() error {
+	dangling := []string{}
+	for _, node := range o.getMoveNodes() {
+		for owner := range node.owners {
+			if o.isExcluded(owner) {
+				dangling = append(dangling, fmt.Sprintf("%s is owned by %s, which is not being moved", describeRef(node.identity), describeRef(owner.identity)))
+			}
+		}
+		for owner := range node.softOwners {
+			if o.isExcluded(owner) {
+				dangling = append(dangling, fmt.Sprintf("%s belongs to %s, which is not being moved", describeRef(node.identity), describeRef(owner.identity)))
+			}
+		}
+		for _, ref := range node.references {
+			if ref.Namespace == "" {
+				ref.Namespace = node.identity.Namespace
+			}
+			if ref.Namespace != node.identity.Namespace {
+				dangling = append(dangling, fmt.Sprintf("%s references %s, which is in another namespace", describeRef(node.identity), describeRef(ref)))
+				continue
+			}
+			if target := o.getReferencedNode(ref); target != nil && !target.virtual && (o.isExcluded(target) || !target.isMoved()) {
+				dangling = append(dangling, fmt.Sprintf("%s references %s, which is not being moved", describeRef(node.identity), describeRef(target.identity)))
+			}
+		}
+	}
+
+	// Objects outside the scope of the move operation cannot be owned by objects being moved, e.g. the bindings of
+	// a ClusterResourceSet shared with Clusters not being moved.
+	for _, node := range o.excludedNodes {
+		for owner := range node.owners {
+			if !o.isExcluded(owner) && owner.isMoved() {
+				dangling = append(dangling, fmt.Sprintf("%s is owned by %s, which is being moved without it", describeRef(node.identity), describeRef(owner.identity)))
+			}
+		}
+	}
+
+	if len(dangling) == 0 {
+		return nil
+	}
+	sort.Strings(dangling)
+	return errors.Errorf("cannot start the move operation because of references to objects outside the moved set:\n%s", strings.Join(dangling, "\n"))
+}
+
+// getDroppedOwnerReferences returns the description of the OwnerReferences of the nodes being moved to owners which are not moved
+// in any case, e.g. owners of Secrets linked to a Cluster by the cluster name label; those OwnerReferences are dropped when
+// creating the objects in the target cluster.
+func (o *objectGraph) getDroppedOwnerReferences() []string {
+	dropped := []string{}
+	for _, node := range o.getMoveNodes() {
+		for owner := range node.owners {
+			if !o.isExcluded(owner) && !owner.isMoved() {
+				dropped = append(dropped, fmt.Sprintf("%s is owned by %s, which is not being moved", describeRef(node.identity), describeRef(owner.identity)))
+			}
+		}
+	}
+	sort.Strings(dropped)
+	return dropped
+}
+
+// isExcluded returns true if the node is outside the scope of the move operation.
+func (o *objectGraph) isExcluded(n *node) bool {
+	_, ok := o.excludedNodes[n.identity.UID]
+	return ok
+}
+
+// getReferencedNode returns the node, either in scope or excluded, matching an object reference, if any.
+// If the reference does not define an APIVersion, only the Kind is matched.
+func (o *objectGraph) getReferencedNode(ref corev1.ObjectReference) *node {
+	for _, nodes := range []map[types.UID]*node{o.uidToNode, o.excludedNodes} {
+		for _, n := range nodes {
+			if n.identity.Name != ref.Name || n.identity.Namespace != ref.Namespace || n.identity.Kind != ref.Kind {
+				continue
+			}
+			if ref.APIVersion != "" && n.identity.GroupVersionKind().Group != ref.GroupVersionKind().Group {
+				continue
+			}
+			return n
+		}
+	}
+	return nil
+}
+
+// getObjectReferences returns all the object references, i.e. the nested objects having both a kind and a name field, found in the given value.
+func getObjectReferences(value interface{}) []corev1.ObjectReference {
+	refs := []corev1.ObjectReference{}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		kind, kindOk := v["kind"].(string)
+		name, nameOk := v["name"].(string)
+		if kindOk && nameOk && kind != "" && name != "" {
+			ref := corev1.ObjectReference{Kind: kind, Name: name}
+			ref.APIVersion, _ = v["apiVersion"].(string)
+			ref.Namespace, _ = v["namespace"].(string)
+			refs = append(refs, ref)
+		}
+		for _, nested := range v {
+			refs = append(refs, getObjectReferences(nested)...)
+		}
+	case []interface{}:
+		for _, nested := range v {
+			refs = append(refs, getObjectReferences(nested)...)
+		}
+	}
+	return refs
+}
+
+func describeRef(ref corev1.ObjectReference) string {
+	return fmt.Sprintf("%s %s/%s", ref.Kind, ref.Namespace, ref.Name)
+}
+
 // checkVirtualNode logs if nodes are still virtual.
 func (o *objectGraph) checkVirtualNode() {
 	log := logf.Log
@@ -25,9 +25,11 @@ import (
 
 	"github.com/pkg/errors"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -1290,6 +1292,30 @@ func Test_objectGraph_setSoftOwnership(t *testing.T) {
 				"/v1, Kind=Secret, ns1/foo-bar-kubeconfig": {}, // the kubeconfig secret has explicit OwnerRef to the cluster, so it should NOT be identified as a soft ownership
 			},
 		},
+		{
+			name: "A cluster with secrets linked by the cluster name label",
+			fields: fields{
+				objs: append(
+					test.NewFakeCluster("ns1", "foo").Objs(),
+					newClusterLabeledSecret("ns1", "foo-credentials", "foo", nil),
+					newClusterLabeledSecret("ns1", "foo-owned-credentials", "foo", &metav1.OwnerReference{APIVersion: "v1", Kind: "ConfigMap", Name: "bar", UID: "/v1, Kind=ConfigMap, ns1/bar"}),
+					newClusterLabeledSecret("ns1", "other-credentials", "other", nil),
+				),
+			},
+			wantSecrets: map[string][]string{ // wantSecrets is a map[node UID] --> list of soft owner UIDs
+				"/v1, Kind=Secret, ns1/foo-ca": {
+					"cluster.x-k8s.io/v1alpha4, Kind=Cluster, ns1/foo",
+				},
+				"/v1, Kind=Secret, ns1/foo-kubeconfig": {},
+				"/v1, Kind=Secret, ns1/foo-credentials": { // the secret has the cluster name label, so it should be identified as a soft ownership
+					"cluster.x-k8s.io/v1alpha4, Kind=Cluster, ns1/foo",
+				},
+				"/v1, Kind=Secret, ns1/foo-owned-credentials": { // the secret has the cluster name label, so it should be identified as a soft ownership even if it has an OwnerRef
+					"cluster.x-k8s.io/v1alpha4, Kind=Cluster, ns1/foo",
+				},
+				"/v1, Kind=Secret, ns1/other-credentials": {}, // the secret has the cluster name label for a cluster that does not exist
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1318,6 +1344,69 @@ func Test_objectGraph_setSoftOwnership(t *testing.T) {
 	}
 }
 
+func Test_objectGraph_getClusterSecrets(t *testing.T) {
+	g := NewWithT(t)
+
+	objs := append(
+		test.NewFakeCluster("ns1", "foo").Objs(),
+		newClusterLabeledSecret("ns1", "credentials", "foo", &metav1.OwnerReference{APIVersion: "v1", Kind: "ConfigMap", Name: "bar", UID: "/v1, Kind=ConfigMap, ns1/bar"}),
+	)
+	objs = append(objs, test.NewFakeCluster("ns1", "other").Objs()...)
+
+	graph, err := getDetachedObjectGraphWihObjs(objs)
+	g.Expect(err).NotTo(HaveOccurred())
+	graph.setSoftOwnership()
+	graph.setClusterTenants()
+
+	cluster := getClusterNode(graph, "ns1", "foo")
+	g.Expect(cluster).NotTo(BeNil())
+
+	got := []string{}
+	for _, secret := range graph.getClusterSecrets(cluster) {
+		got = append(got, secret.identity.Name)
+	}
+	g.Expect(got).To(Equal([]string{"credentials", "foo-ca", "foo-kubeconfig"}))
+}
+
+func Test_objectGraph_getDroppedOwnerReferences(t *testing.T) {
+	g := NewWithT(t)
+
+	objs := append(
+		test.NewFakeCluster("ns1", "foo").Objs(),
+		newClusterLabeledSecret("ns1", "credentials", "foo", &metav1.OwnerReference{APIVersion: "v1", Kind: "ConfigMap", Name: "bar", UID: "/v1, Kind=ConfigMap, ns1/bar"}),
+	)
+
+	graph, err := getDetachedObjectGraphWihObjs(objs)
+	g.Expect(err).NotTo(HaveOccurred())
+	graph.setSoftOwnership()
+	graph.setClusterTenants()
+
+	g.Expect(graph.checkReferences()).To(Succeed())
+	g.Expect(graph.getDroppedOwnerReferences()).To(Equal([]string{"Secret ns1/credentials is owned by ConfigMap ns1/bar, which is not being moved"}))
+}
+
+// newClusterLabeledSecret returns a Secret with the cluster name label and optionally an OwnerReference.
+func newClusterLabeledSecret(namespace, name, clusterName string, owner *metav1.OwnerReference) *corev1.Secret {
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				clusterv1.ClusterLabelName: clusterName,
+			},
+		},
+	}
+	secret.SetUID(types.UID(fmt.Sprintf("/v1, Kind=Secret, %s/%s", namespace, name)))
+	if owner != nil {
+		secret.SetOwnerReferences([]metav1.OwnerReference{*owner})
+	}
+	return secret
+}
+
 func Test_objectGraph_setClusterTenants(t *testing.T) {
 	type fields struct {
 		objs []client.Object
@@ -1643,3 +1732,176 @@ func Test_objectGraph_setCRSTenants(t *testing.T) {
 		})
 	}
 }
+
+func Test_objectGraph_filterCluster(t *testing.T) {
+	g := NewWithT(t)
+
+	objs := append(
+		test.NewFakeCluster("ns1", "foo").WithMachines(test.NewFakeMachine("m1")).Objs(),
+		test.NewFakeCluster("ns1", "bar").WithMachines(test.NewFakeMachine("m2")).Objs()...,
+	)
+
+	graph := getObjectGraphWithObjs(objs)
+	g.Expect(getFakeDiscoveryTypes(graph)).To(Succeed())
+	g.Expect(graph.Discovery("ns1")).To(Succeed())
+
+	g.Expect(graph.filterCluster("ns1", "baz")).NotTo(Succeed())
+	g.Expect(graph.filterCluster("ns1", "foo")).To(Succeed())
+
+	clusters := graph.getClusters()
+	g.Expect(clusters).To(HaveLen(1))
+	g.Expect(clusters[0].identity.Name).To(Equal("foo"))
+
+	for _, n := range graph.getMoveNodes() {
+		g.Expect(n.tenantClusters).To(HaveKey(clusters[0]))
+	}
+	g.Expect(graph.checkReferences()).To(Succeed())
+}
+
+func Test_objectGraph_filterCluster_WithClusterResourceSet(t *testing.T) {
+	g := NewWithT(t)
+
+	objs := []client.Object{}
+	objs = append(objs, test.NewFakeCluster("ns1", "foo").Objs()...)
+	objs = append(objs, test.NewFakeCluster("ns1", "bar").Objs()...)
+	objs = append(objs, test.NewFakeClusterResourceSet("ns1", "crs1").
+		WithSecret("resource-s1").
+		WithConfigMap("resource-c1").
+		ApplyToCluster(test.SelectClusterObj(objs, "ns1", "foo")).
+		Objs()...)
+	objs = append(objs, test.NewFakeClusterResourceSet("ns1", "crs2").
+		WithSecret("resource-s2").
+		ApplyToCluster(test.SelectClusterObj(objs, "ns1", "bar")).
+		Objs()...)
+
+	graph := getObjectGraphWithObjs(objs)
+	g.Expect(getFakeDiscoveryTypes(graph)).To(Succeed())
+	g.Expect(graph.Discovery("ns1")).To(Succeed())
+	g.Expect(graph.filterCluster("ns1", "foo")).To(Succeed())
+
+	moved := []string{}
+	for _, n := range graph.getMoveNodes() {
+		if n.identity.Kind == "Cluster" || len(n.tenantClusters) == 0 {
+			moved = append(moved, string(n.identity.UID))
+		}
+	}
+	// The ClusterResourceSet bound to the Cluster being moved, its resources and its binding are moved; the ones bound
+	// to the other Cluster are not.
+	g.Expect(moved).To(ConsistOf(
+		"cluster.x-k8s.io/v1alpha4, Kind=Cluster, ns1/foo",
+		"addons.cluster.x-k8s.io/v1alpha4, Kind=ClusterResourceSet, ns1/crs1",
+		"/v1, Kind=Secret, ns1/resource-s1",
+		"/v1, Kind=ConfigMap, ns1/resource-c1",
+	))
+	g.Expect(graph.getMoveNodes()).To(ContainElement(WithTransform(func(n *node) string { return string(n.identity.UID) },
+		Equal("addons.cluster.x-k8s.io/v1alpha4, Kind=ClusterResourceSetBinding, ns1/foo"))))
+	g.Expect(graph.checkReferences()).To(Succeed())
+}
+
+func Test_objectGraph_checkReferences(t *testing.T) {
+	tests := []struct {
+		name        string
+		objs        func() []client.Object
+		clusterName string
+		wantErr     []string
+	}{
+		{
+			name: "all the references are in the moved set",
+			objs: func() []client.Object {
+				return append(
+					test.NewFakeCluster("ns1", "foo").Objs(),
+					test.NewFakeCluster("ns1", "bar").Objs()...,
+				)
+			},
+		},
+		{
+			name: "reference to an object in another namespace",
+			objs: func() []client.Object {
+				objs := test.NewFakeCluster("ns1", "foo").Objs()
+				for _, o := range objs {
+					if cluster, ok := o.(*clusterv1.Cluster); ok {
+						cluster.Spec.InfrastructureRef.Namespace = "ns2"
+					}
+				}
+				return objs
+			},
+			wantErr: []string{"Cluster ns1/foo references GenericInfrastructureCluster ns2/foo, which is in another namespace"},
+		},
+		{
+			name: "reference to an object belonging to a Cluster not being moved",
+			objs: func() []client.Object {
+				objs := test.NewFakeCluster("ns1", "foo").Objs()
+				for _, o := range objs {
+					if cluster, ok := o.(*clusterv1.Cluster); ok {
+						cluster.Spec.InfrastructureRef.Name = "bar"
+					}
+				}
+				return append(objs, test.NewFakeCluster("ns1", "bar").Objs()...)
+			},
+			clusterName: "foo",
+			wantErr:     []string{"Cluster ns1/foo references GenericInfrastructureCluster ns1/bar, which is not being moved"},
+		},
+		{
+			name: "object owned by a Cluster not being moved",
+			objs: func() []client.Object {
+				foo := test.NewFakeCluster("ns1", "foo").Objs()
+				bar := test.NewFakeCluster("ns1", "bar").Objs()
+				for _, o := range foo {
+					if cluster, ok := o.(*clusterv1.Cluster); ok {
+						for _, b := range bar {
+							if b.GetObjectKind().GroupVersionKind().Kind == "GenericInfrastructureCluster" {
+								b.SetOwnerReferences(append(b.GetOwnerReferences(), metav1.OwnerReference{
+									APIVersion: clusterv1.GroupVersion.String(),
+									Kind:       "Cluster",
+									Name:       cluster.Name,
+									UID:        cluster.UID,
+								}))
+							}
+						}
+					}
+				}
+				return append(foo, bar...)
+			},
+			clusterName: "foo",
+			wantErr:     []string{"GenericInfrastructureCluster ns1/bar is owned by Cluster ns1/bar, which is not being moved"},
+		},
+		{
+			name: "ClusterResourceSet shared with a Cluster not being moved",
+			objs: func() []client.Object {
+				objs := []client.Object{}
+				objs = append(objs, test.NewFakeCluster("ns1", "foo").Objs()...)
+				objs = append(objs, test.NewFakeCluster("ns1", "bar").Objs()...)
+				return append(objs, test.NewFakeClusterResourceSet("ns1", "crs1").
+					WithSecret("resource-s1").
+					ApplyToCluster(test.SelectClusterObj(objs, "ns1", "foo")).
+					ApplyToCluster(test.SelectClusterObj(objs, "ns1", "bar")).
+					Objs()...)
+			},
+			clusterName: "foo",
+			wantErr:     []string{"ClusterResourceSetBinding ns1/bar is owned by ClusterResourceSet ns1/crs1, which is being moved without it"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			graph := getObjectGraphWithObjs(tt.objs())
+			g.Expect(getFakeDiscoveryTypes(graph)).To(Succeed())
+			g.Expect(graph.Discovery("ns1")).To(Succeed())
+			if tt.clusterName != "" {
+				g.Expect(graph.filterCluster("ns1", tt.clusterName)).To(Succeed())
+			}
+
+			err := graph.checkReferences()
+			if len(tt.wantErr) == 0 {
+				g.Expect(err).NotTo(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(HaveOccurred())
+			for _, want := range tt.wantErr {
+				g.Expect(err.Error()).To(ContainSubstring(want))
+			}
+		})
+	}
+}
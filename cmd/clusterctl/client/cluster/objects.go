@@ -0,0 +1,144 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// fieldOwner is the field manager used by clusterctl for server side apply.
+const fieldOwner = "clusterctl"
+
+// ObjectsClient has methods to work with generic Kubernetes objects in the management cluster.
+type ObjectsClient interface {
+	// ForceDelete deletes an object after removing all its finalizers, so the object gets immediately deleted.
+	// NOTE: Deleting an object without running finalizers might leave behind external resources.
+	ForceDelete(gvk schema.GroupVersionKind, namespace, name string) error
+
+	// Diff compares objects with the corresponding objects existing in the management cluster, using a server side
+	// apply dry-run so defaulting and validation happening in the API server are taken into account.
+	// NOTE: Objects are not changed in the management cluster.
+	Diff(objs []unstructured.Unstructured) ([]ObjectDiff, error)
+
+	// CreateUnstructured creates an object in the management cluster; it fails if the object already exists.
+	CreateUnstructured(obj *unstructured.Unstructured) error
+
+	// ApplyUnstructured creates an object in the management cluster, or updates it if it already exists, using server side apply;
+	// fields not included in the object, e.g. fields set by controllers, are preserved.
+	ApplyUnstructured(obj *unstructured.Unstructured) error
+
+	// DeleteUnstructured deletes an object in the management cluster; it is a no-op if the object does not exist.
+	DeleteUnstructured(obj *unstructured.Unstructured) error
+
+	// DeleteObjects deletes objects in the management cluster, e.g. the objects of a manifest, in reverse order;
+	// objects which do not exist are ignored. Optionally, DeleteObjects waits for the objects to be removed.
+	// The returned error lists the objects which could not be deleted or which were not removed before the timeout.
+	DeleteObjects(objs []unstructured.Unstructured, options DeleteObjectsOptions) error
+}
+
+// objectsClient implements ObjectsClient.
+type objectsClient struct {
+	configClient        config.Client
+	proxy               Proxy
+	eventRecorder       EventRecorder
+	auditClient         AuditClient
+	pollImmediateWaiter PollImmediateWaiter
+}
+
+// ensure objectsClient implements ObjectsClient.
+var _ ObjectsClient = &objectsClient{}
+
+// newObjectsClient returns an objectsClient.
+func newObjectsClient(configClient config.Client, proxy Proxy, pollImmediateWaiter PollImmediateWaiter) *objectsClient {
+	return &objectsClient{
+		configClient:        configClient,
+		proxy:               proxy,
+		eventRecorder:       newEventRecorder(proxy),
+		auditClient:         newAuditClient(proxy),
+		pollImmediateWaiter: pollImmediateWaiter,
+	}
+}
+
+func (o *objectsClient) ForceDelete(gvk schema.GroupVersionKind, namespace, name string) error {
+	c, err := o.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	if err := forceDelete(c, obj, client.ObjectKey{Namespace: namespace, Name: name}, newConflictBackoff(o.configClient)); err != nil {
+		return err
+	}
+
+	// Records an event only if the object existed, so the event refers to the deleted object.
+	if obj.GetName() != "" {
+		o.eventRecorder.Eventf(obj, corev1.EventTypeWarning, ForceDeletedEventReason, "Deleted by clusterctl without running finalizers")
+		o.auditClient.Record(AuditEntry{
+			Operation: AuditOperationForceDelete,
+			Parameters: map[string]string{
+				"kind":      gvk.Kind,
+				"namespace": namespace,
+				"name":      name,
+			},
+			Outcome: AuditOutcomeSucceeded,
+		})
+	}
+	return nil
+}
+
+// forceDelete removes all the finalizers from an object and then deletes it.
+// If the object does not exists, forceDelete is a no-op.
+func forceDelete(c client.Client, obj *unstructured.Unstructured, key client.ObjectKey, conflictBackoff wait.Backoff) error {
+	log := logf.Log
+
+	// Removes the finalizers using an optimistic lock, so the patch fails with a conflict if a controller changed
+	// the object in the meantime, e.g. adding a finalizer; in this case the object is read again before retrying.
+	if err := retryOnConflict(conflictBackoff, func() error {
+		if err := c.Get(ctx, key, obj); err != nil {
+			return err
+		}
+		if len(obj.GetFinalizers()) == 0 {
+			return nil
+		}
+		patch := client.MergeFromWithOptions(obj.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		obj.SetFinalizers(nil)
+		return c.Patch(ctx, obj, patch)
+	}); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.V(5).Info("Object already deleted, skipping delete for", obj.GetKind(), key.Name, "Namespace", key.Namespace)
+			return nil
+		}
+		return errors.Wrapf(err, "error removing finalizers from %q %s/%s",
+			obj.GroupVersionKind(), key.Namespace, key.Name)
+	}
+
+	if err := c.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "error deleting %q %s/%s",
+			obj.GroupVersionKind(), key.Namespace, key.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func (o *objectsClient) CreateUnstructured(obj *unstructured.Unstructured) error {
+	if err := validateUnstructured(obj); err != nil {
+		return err
+	}
+
+	c, err := o.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	if err := c.Create(ctx, obj); err != nil {
+		return wrapUnstructuredError(err, "failed to create", obj)
+	}
+	return nil
+}
+
+func (o *objectsClient) ApplyUnstructured(obj *unstructured.Unstructured) error {
+	if err := validateUnstructured(obj); err != nil {
+		return err
+	}
+
+	c, err := o.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	if err := applyUnstructured(c, obj); err != nil {
+		return wrapUnstructuredError(err, "failed to apply", obj)
+	}
+	return nil
+}
+
+// applyUnstructured creates or updates an object using server side apply, so the fields set by controllers or
+// other clients and not included in the object are preserved; the object is applied without resourceVersion,
+// so the apply does not fail with conflicts if the object changed in the meantime.
+func applyUnstructured(c client.Client, obj *unstructured.Unstructured) error {
+	log := logf.Log
+
+	log.V(5).Info("Applying", obj.GetKind(), obj.GetName(), "Namespace", obj.GetNamespace())
+	obj.SetResourceVersion("")
+	obj.SetManagedFields(nil)
+	return c.Patch(ctx, obj, client.Apply, client.FieldOwner(fieldOwner), client.ForceOwnership)
+}
+
+func (o *objectsClient) DeleteUnstructured(obj *unstructured.Unstructured) error {
+	if err := validateUnstructured(obj); err != nil {
+		return err
+	}
+
+	c, err := o.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	if err := c.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+		return wrapUnstructuredError(err, "failed to delete", obj)
+	}
+	return nil
+}
+
+// validateUnstructured checks an object has a valid GroupVersionKind and a name, so errors are reported before
+// calling the API server, where an object without apiVersion or kind would fail with a less meaningful error.
+func validateUnstructured(obj *unstructured.Unstructured) error {
+	if obj == nil {
+		return errors.New("invalid object: object is nil")
+	}
+	gv, err := schema.ParseGroupVersion(obj.GetAPIVersion())
+	if err != nil {
+		return errors.Wrapf(err, "invalid object %s/%s: failed to parse apiVersion %q", obj.GetNamespace(), obj.GetName(), obj.GetAPIVersion())
+	}
+	if gv.Version == "" {
+		return errors.Errorf("invalid object %s/%s: apiVersion is required", obj.GetNamespace(), obj.GetName())
+	}
+	if obj.GetKind() == "" {
+		return errors.Errorf("invalid object %s/%s: kind is required", obj.GetNamespace(), obj.GetName())
+	}
+	if obj.GetName() == "" {
+		return errors.Errorf("invalid %s object: name is required", obj.GroupVersionKind())
+	}
+	return nil
+}
+
+// wrapUnstructuredError wraps an error returned by the API server while operating on an object,
+// with a specific message for kinds not served by the API server, e.g. because a provider is not installed.
+func wrapUnstructuredError(err error, action string, obj *unstructured.Unstructured) error {
+	if meta.IsNoMatchError(err) {
+		return errors.Wrapf(err, "%s %s %s/%s: the kind is not served by the management cluster", action, obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+	}
+	return errors.Wrapf(err, "%s %s %s/%s", action, obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func unstructuredMachine(clusterName string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": clusterv1.GroupVersion.String(),
+			"kind":       "Machine",
+			"metadata": map[string]interface{}{
+				"namespace": "ns1",
+				"name":      "machine1",
+			},
+			"spec": map[string]interface{}{
+				"clusterName": clusterName,
+			},
+		},
+	}
+}
+
+func existingMachine() *clusterv1.Machine {
+	return &clusterv1.Machine{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: clusterv1.GroupVersion.String(),
+			Kind:       "Machine",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns1",
+			Name:      "machine1",
+		},
+		Spec: clusterv1.MachineSpec{
+			ClusterName: "old",
+		},
+	}
+}
+
+func Test_objectsClient_CreateUnstructured(t *testing.T) {
+	tests := []struct {
+		name    string
+		objs    []client.Object
+		obj     *unstructured.Unstructured
+		wantErr bool
+	}{
+		{
+			name: "creates an object",
+			obj:  unstructuredMachine("cluster1"),
+		},
+		{
+			name:    "fails if the object already exists",
+			objs:    []client.Object{existingMachine()},
+			obj:     unstructuredMachine("cluster1"),
+			wantErr: true,
+		},
+		{
+			name: "fails if the object does not have a kind",
+			obj: func() *unstructured.Unstructured {
+				u := unstructuredMachine("cluster1")
+				u.SetKind("")
+				return u
+			}(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			proxy := test.NewFakeProxy().WithObjs(tt.objs...)
+			err := newObjectsClient(nil, proxy, wait.PollImmediate).CreateUnstructured(tt.obj)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			c, err := proxy.NewClient()
+			g.Expect(err).NotTo(HaveOccurred())
+
+			machine := &clusterv1.Machine{}
+			g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: "machine1"}, machine)).To(Succeed())
+			g.Expect(machine.Spec.ClusterName).To(Equal("cluster1"))
+		})
+	}
+}
+
+func Test_objectsClient_ApplyUnstructured(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  *unstructured.Unstructured
+	}{
+		{
+			name: "fails if the object has an invalid apiVersion",
+			obj: func() *unstructured.Unstructured {
+				u := unstructuredMachine("cluster1")
+				u.SetAPIVersion("cluster.x-k8s.io/v1alpha4/foo")
+				return u
+			}(),
+		},
+		{
+			name: "fails if the object does not have a name",
+			obj: func() *unstructured.Unstructured {
+				u := unstructuredMachine("cluster1")
+				u.SetName("")
+				return u
+			}(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			err := newObjectsClient(nil, test.NewFakeProxy(), wait.PollImmediate).ApplyUnstructured(tt.obj)
+			g.Expect(err).To(HaveOccurred())
+		})
+	}
+}
+
+func Test_applyUnstructured(t *testing.T) {
+	g := NewWithT(t)
+
+	// The fake client does not support server side apply, so the patch is recorded instead.
+	c := &applyRecordingClient{}
+	obj := unstructuredMachine("cluster1")
+	obj.SetResourceVersion("1")
+	obj.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: "foo"}})
+
+	g.Expect(applyUnstructured(c, obj)).To(Succeed())
+	g.Expect(c.patchType).To(Equal(types.ApplyPatchType))
+	g.Expect(c.patchOptions.FieldManager).To(Equal(fieldOwner))
+	g.Expect(c.patchOptions.Force).NotTo(BeNil())
+	g.Expect(*c.patchOptions.Force).To(BeTrue())
+	g.Expect(c.obj.GetResourceVersion()).To(BeEmpty())
+	g.Expect(c.obj.GetManagedFields()).To(BeEmpty())
+	clusterName, _, _ := unstructured.NestedString(c.obj.Object, "spec", "clusterName")
+	g.Expect(clusterName).To(Equal("cluster1"))
+}
+
+// applyRecordingClient records the patches, because the fake client does not support server side apply.
+type applyRecordingClient struct {
+	client.Client
+	obj          *unstructured.Unstructured
+	patchType    types.PatchType
+	patchOptions client.PatchOptions
+}
+
+func (c *applyRecordingClient) Patch(_ context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	c.obj = obj.(*unstructured.Unstructured).DeepCopy()
+	c.patchType = patch.Type()
+	c.patchOptions.ApplyOptions(opts)
+	return nil
+}
+
+func Test_objectsClient_DeleteUnstructured(t *testing.T) {
+	tests := []struct {
+		name    string
+		objs    []client.Object
+		obj     *unstructured.Unstructured
+		wantErr bool
+	}{
+		{
+			name: "deletes an object",
+			objs: []client.Object{existingMachine()},
+			obj:  unstructuredMachine(""),
+		},
+		{
+			name: "no-op if the object does not exist",
+			obj:  unstructuredMachine(""),
+		},
+		{
+			name:    "fails if the object is nil",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			proxy := test.NewFakeProxy().WithObjs(tt.objs...)
+			err := newObjectsClient(nil, proxy, wait.PollImmediate).DeleteUnstructured(tt.obj)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			c, err := proxy.NewClient()
+			g.Expect(err).NotTo(HaveOccurred())
+
+			err = c.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: "machine1"}, &clusterv1.Machine{})
+			g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+	}
+}
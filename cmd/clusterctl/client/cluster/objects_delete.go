@@ -0,0 +1,137 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	waitObjectsRemovalInterval = 1 * time.Second
+	waitObjectsRemovalTimeout  = 5 * time.Minute
+)
+
+// DeleteObjectsOptions carries the options supported by ObjectsClient.DeleteObjects.
+type DeleteObjectsOptions struct {
+	// PropagationPolicy determines how the dependents of the deleted objects are handled.
+	// If empty, metav1.DeletePropagationForeground is used, so objects are removed only after their dependents.
+	PropagationPolicy metav1.DeletionPropagation
+
+	// WaitForRemoval, if true, waits for the deleted objects to be removed from the management cluster.
+	WaitForRemoval bool
+
+	// Timeout is the maximum time to wait for all the deleted objects to be removed. If unspecified, 5 minutes are used.
+	Timeout time.Duration
+}
+
+func (o *objectsClient) DeleteObjects(objs []unstructured.Unstructured, options DeleteObjectsOptions) error {
+	log := logf.Log
+
+	policy := options.PropagationPolicy
+	if policy == "" {
+		policy = metav1.DeletePropagationForeground
+	}
+	switch policy {
+	case metav1.DeletePropagationForeground, metav1.DeletePropagationBackground, metav1.DeletePropagationOrphan:
+	default:
+		return errors.Errorf("invalid propagation policy %q, accepted values are %q, %q and %q", policy,
+			metav1.DeletePropagationForeground, metav1.DeletePropagationBackground, metav1.DeletePropagationOrphan)
+	}
+
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = waitObjectsRemovalTimeout
+	}
+
+	c, err := o.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	// Objects are deleted in reverse order, so e.g. the objects in a Namespace defined by the same manifest
+	// are deleted before the Namespace itself.
+	errList := []error{}
+	deleted := []unstructured.Unstructured{}
+	for i := len(objs) - 1; i >= 0; i-- {
+		obj := objs[i]
+		if err := validateUnstructured(&obj); err != nil {
+			errList = append(errList, err)
+			continue
+		}
+
+		log.V(5).Info("Deleting", obj.GetKind(), obj.GetName(), "Namespace", obj.GetNamespace(), "PropagationPolicy", policy)
+		if err := c.Delete(ctx, &obj, client.PropagationPolicy(policy)); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			errList = append(errList, wrapUnstructuredError(err, "failed to delete", &obj))
+			continue
+		}
+		deleted = append(deleted, obj)
+	}
+
+	if options.WaitForRemoval && len(deleted) > 0 {
+		if err := o.waitForRemoval(c, deleted, timeout); err != nil {
+			errList = append(errList, err)
+		}
+	}
+	return kerrors.NewAggregate(errList)
+}
+
+// waitForRemoval waits for objects to be removed from the management cluster. The timeout applies to all the objects
+// and, if it expires, the returned error lists the objects which still exist.
+func (o *objectsClient) waitForRemoval(c client.Client, objs []unstructured.Unstructured, timeout time.Duration) error {
+	log := logf.Log
+
+	remaining := objs
+	err := o.pollImmediateWaiter(waitObjectsRemovalInterval, timeout, func() (bool, error) {
+		existing := []unstructured.Unstructured{}
+		for i := range remaining {
+			obj := remaining[i]
+			live := &unstructured.Unstructured{}
+			live.SetGroupVersionKind(obj.GroupVersionKind())
+			if err := c.Get(ctx, client.ObjectKeyFromObject(&obj), live); err != nil {
+				if apierrors.IsNotFound(err) {
+					continue
+				}
+				// Errors reading the object are retried until the timeout expires.
+				log.V(5).Info("Failed to read object while waiting for its removal, retrying", obj.GetKind(), obj.GetName(), "Namespace", obj.GetNamespace(), "Cause", err.Error())
+			}
+			existing = append(existing, obj)
+		}
+		remaining = existing
+		return len(remaining) == 0, nil
+	})
+	if err == nil {
+		return nil
+	}
+
+	errList := []error{}
+	for i := range remaining {
+		obj := remaining[i]
+		errList = append(errList, errors.Errorf("%s %s/%s was not removed", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName()))
+	}
+	return errors.Wrapf(kerrors.NewAggregate(errList), "timed out after %s waiting for objects to be removed", timeout)
+}
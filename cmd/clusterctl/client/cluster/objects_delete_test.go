@@ -0,0 +1,113 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_objectsClient_DeleteObjects(t *testing.T) {
+	// timeoutWaiter simulates objects not being removed, e.g. because of finalizers, without waiting for the timeout.
+	timeoutWaiter := func(interval, timeout time.Duration, condition wait.ConditionFunc) error {
+		return wait.ErrWaitTimeout
+	}
+
+	tests := []struct {
+		name                string
+		objs                []client.Object
+		delete              []unstructured.Unstructured
+		options             DeleteObjectsOptions
+		pollImmediateWaiter PollImmediateWaiter
+		wantErr             string
+		wantNotDeleted      bool
+	}{
+		{
+			name:    "deletes objects and waits for their removal",
+			objs:    []client.Object{existingMachine()},
+			delete:  []unstructured.Unstructured{*unstructuredMachine("")},
+			options: DeleteObjectsOptions{WaitForRemoval: true},
+		},
+		{
+			name:    "deletes objects with the orphan propagation policy",
+			objs:    []client.Object{existingMachine()},
+			delete:  []unstructured.Unstructured{*unstructuredMachine("")},
+			options: DeleteObjectsOptions{PropagationPolicy: metav1.DeletePropagationOrphan},
+		},
+		{
+			name:    "ignores objects which do not exist",
+			delete:  []unstructured.Unstructured{*unstructuredMachine("")},
+			options: DeleteObjectsOptions{WaitForRemoval: true},
+		},
+		{
+			name:                "reports the objects which are not removed before the timeout",
+			objs:                []client.Object{existingMachine()},
+			delete:              []unstructured.Unstructured{*unstructuredMachine("")},
+			options:             DeleteObjectsOptions{WaitForRemoval: true, Timeout: time.Minute},
+			pollImmediateWaiter: timeoutWaiter,
+			wantErr:             "timed out after 1m0s waiting for objects to be removed: cluster.x-k8s.io/v1alpha4, Kind=Machine ns1/machine1 was not removed",
+		},
+		{
+			name:    "reports invalid objects, deleting the other objects",
+			objs:    []client.Object{existingMachine()},
+			delete:  []unstructured.Unstructured{*unstructuredMachine(""), {Object: map[string]interface{}{"apiVersion": "v1"}}},
+			wantErr: "invalid object /: kind is required",
+		},
+		{
+			name:           "fails if the propagation policy is not valid",
+			objs:           []client.Object{existingMachine()},
+			delete:         []unstructured.Unstructured{*unstructuredMachine("")},
+			options:        DeleteObjectsOptions{PropagationPolicy: "Invalid"},
+			wantErr:        "invalid propagation policy \"Invalid\"",
+			wantNotDeleted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			pollImmediateWaiter := tt.pollImmediateWaiter
+			if pollImmediateWaiter == nil {
+				pollImmediateWaiter = wait.PollImmediate
+			}
+
+			proxy := test.NewFakeProxy().WithObjs(tt.objs...)
+			err := newObjectsClient(nil, proxy, pollImmediateWaiter).DeleteObjects(tt.delete, tt.options)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+
+			c, err := proxy.NewClient()
+			g.Expect(err).NotTo(HaveOccurred())
+
+			err = c.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: "machine1"}, &clusterv1.Machine{})
+			g.Expect(apierrors.IsNotFound(err)).To(Equal(!tt.wantNotDeleted))
+		})
+	}
+}
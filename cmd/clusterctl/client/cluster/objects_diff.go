@@ -0,0 +1,167 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DiffOperation defines how an object would change if applied to the management cluster.
+type DiffOperation string
+
+const (
+	// DiffAdded is used for objects not existing in the management cluster.
+	DiffAdded DiffOperation = "Added"
+
+	// DiffChanged is used for objects existing in the management cluster with different fields.
+	DiffChanged DiffOperation = "Changed"
+
+	// DiffUnchanged is used for objects existing in the management cluster without differences.
+	DiffUnchanged DiffOperation = "Unchanged"
+)
+
+// FieldDiff defines a difference in a field of an object.
+type FieldDiff struct {
+	// Path of the field, e.g. spec.replicas.
+	Path string
+
+	// Live is the value of the field in the management cluster; nil if the field is going to be added.
+	Live interface{}
+
+	// Desired is the value of the field after the change; nil if the field is going to be removed.
+	Desired interface{}
+}
+
+// ObjectDiff defines the differences between an object and the corresponding object in the management cluster.
+type ObjectDiff struct {
+	// Object is the object as it would be persisted in the management cluster.
+	Object unstructured.Unstructured
+
+	// Operation defines how the object would change.
+	Operation DiffOperation
+
+	// Fields lists the changed fields, sorted by path; it is empty for added objects.
+	Fields []FieldDiff
+}
+
+func (o *objectsClient) Diff(objs []unstructured.Unstructured) ([]ObjectDiff, error) {
+	c, err := o.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := make([]ObjectDiff, 0, len(objs))
+	for i := range objs {
+		diff, err := diffObject(c, objs[i].DeepCopy())
+		if err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, *diff)
+	}
+	return diffs, nil
+}
+
+// diffObject compares an object with the corresponding object in the management cluster.
+func diffObject(c client.Client, desired *unstructured.Unstructured) (*ObjectDiff, error) {
+	live := &unstructured.Unstructured{}
+	live.SetGroupVersionKind(desired.GroupVersionKind())
+	exists := true
+	if err := c.Get(ctx, client.ObjectKeyFromObject(desired), live); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, errors.Wrapf(err, "failed to get %s %s/%s", desired.GroupVersionKind(), desired.GetNamespace(), desired.GetName())
+		}
+		exists = false
+	}
+
+	// Dry-run a server side apply of the object, so the result reflects the object as it would be persisted,
+	// including defaults and changes applied by webhooks, while preserving the fields set by controllers or
+	// other clients and not included in the desired object.
+	desired.SetResourceVersion("")
+	desired.SetManagedFields(nil)
+	if err := c.Patch(ctx, desired, client.Apply, client.FieldOwner(fieldOwner), client.ForceOwnership, client.DryRunAll); err != nil {
+		return nil, errors.Wrapf(err, "failed to dry-run apply %s %s/%s", desired.GroupVersionKind(), desired.GetNamespace(), desired.GetName())
+	}
+	if !exists {
+		return &ObjectDiff{Object: *desired, Operation: DiffAdded}, nil
+	}
+
+	fields := diffFields(live.Object, desired.Object)
+	operation := DiffChanged
+	if len(fields) == 0 {
+		operation = DiffUnchanged
+	}
+	return &ObjectDiff{Object: *desired, Operation: operation, Fields: fields}, nil
+}
+
+// diffFields returns the differences between two objects; only labels, annotations and top level fields other
+// than metadata and status are compared, because other fields are managed by the API server or by controllers.
+func diffFields(live, desired map[string]interface{}) []FieldDiff {
+	diffs := []FieldDiff{}
+	for _, field := range []string{"labels", "annotations"} {
+		liveValue, _, _ := unstructured.NestedFieldNoCopy(live, "metadata", field)
+		desiredValue, _, _ := unstructured.NestedFieldNoCopy(desired, "metadata", field)
+		diffs = appendFieldDiffs(diffs, "metadata."+field, liveValue, desiredValue)
+	}
+
+	for _, field := range sortedFieldNames(live, desired) {
+		switch field {
+		case "apiVersion", "kind", "metadata", "status":
+			continue
+		}
+		diffs = appendFieldDiffs(diffs, field, live[field], desired[field])
+	}
+	return diffs
+}
+
+// appendFieldDiffs compares two values recursively, appending a FieldDiff for each leaf field with different values.
+// NOTE: Lists are compared as a whole.
+func appendFieldDiffs(diffs []FieldDiff, path string, live, desired interface{}) []FieldDiff {
+	liveMap, liveIsMap := live.(map[string]interface{})
+	desiredMap, desiredIsMap := desired.(map[string]interface{})
+	if liveIsMap && desiredIsMap {
+		for _, field := range sortedFieldNames(liveMap, desiredMap) {
+			diffs = appendFieldDiffs(diffs, path+"."+field, liveMap[field], desiredMap[field])
+		}
+		return diffs
+	}
+
+	if reflect.DeepEqual(live, desired) {
+		return diffs
+	}
+	return append(diffs, FieldDiff{Path: path, Live: live, Desired: desired})
+}
+
+// sortedFieldNames returns the sorted union of the field names of two maps.
+func sortedFieldNames(a, b map[string]interface{}) []string {
+	names := make([]string, 0, len(a)+len(b))
+	for name := range a {
+		names = append(names, name)
+	}
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
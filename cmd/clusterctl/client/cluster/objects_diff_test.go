@@ -0,0 +1,170 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_objectsClient_Diff(t *testing.T) {
+	g := NewWithT(t)
+
+	replicas := int32(1)
+	live := &clusterv1.MachineDeployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: clusterv1.GroupVersion.String(),
+			Kind:       "MachineDeployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "md1",
+			Namespace: "ns1",
+			Labels:    map[string]string{"foo": "bar"},
+		},
+		Spec: clusterv1.MachineDeploymentSpec{
+			ClusterName:     "cluster1",
+			Replicas:        &replicas,
+			MinReadySeconds: &replicas,
+		},
+	}
+	proxy := test.NewFakeProxy().WithObjs(live)
+
+	desiredMD := func(name string, replicas int64) unstructured.Unstructured {
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(live)
+		g.Expect(err).NotTo(HaveOccurred())
+		obj := unstructured.Unstructured{Object: u}
+		obj.SetName(name)
+		g.Expect(unstructured.SetNestedField(obj.Object, replicas, "spec", "replicas")).To(Succeed())
+		g.Expect(unstructured.SetNestedField(obj.Object, true, "spec", "paused")).To(Succeed())
+		unstructured.RemoveNestedField(obj.Object, "spec", "minReadySeconds")
+		return obj
+	}
+
+	got, err := newObjectsClient(nil, proxy, wait.PollImmediate).Diff([]unstructured.Unstructured{
+		desiredMD("md1", 3),
+		desiredMD("md2", 1),
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(HaveLen(2))
+
+	g.Expect(got[0].Operation).To(Equal(DiffChanged))
+	g.Expect(got[0].Fields).To(Equal([]FieldDiff{
+		{Path: "spec.minReadySeconds", Live: int64(1), Desired: nil},
+		{Path: "spec.paused", Live: nil, Desired: true},
+		{Path: "spec.replicas", Live: int64(1), Desired: int64(3)},
+	}))
+
+	g.Expect(got[1].Operation).To(Equal(DiffAdded))
+	g.Expect(got[1].Object.GetName()).To(Equal("md2"))
+
+	// Objects in the management cluster are not changed.
+	c, err := proxy.NewClient()
+	g.Expect(err).NotTo(HaveOccurred())
+	md := &clusterv1.MachineDeployment{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: "md1"}, md)).To(Succeed())
+	g.Expect(*md.Spec.Replicas).To(Equal(int32(1)))
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: "md2"}, md)).NotTo(Succeed())
+}
+
+func Test_diffFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		live    map[string]interface{}
+		desired map[string]interface{}
+		want    []FieldDiff
+	}{
+		{
+			name: "no differences",
+			live: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "foo", "resourceVersion": "1"},
+				"spec":     map[string]interface{}{"a": "b"},
+			},
+			desired: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "foo"},
+				"spec":     map[string]interface{}{"a": "b"},
+			},
+			want: []FieldDiff{},
+		},
+		{
+			name: "status and metadata fields other than labels and annotations are ignored",
+			live: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "foo", "generation": int64(1)},
+				"status":   map[string]interface{}{"ready": true},
+			},
+			desired: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "foo", "generation": int64(2)},
+			},
+			want: []FieldDiff{},
+		},
+		{
+			name: "changed labels and annotations",
+			live: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels": map[string]interface{}{"a": "b"},
+				},
+			},
+			desired: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels":      map[string]interface{}{"a": "c"},
+					"annotations": map[string]interface{}{"d": "e"},
+				},
+			},
+			want: []FieldDiff{
+				{Path: "metadata.labels.a", Live: "b", Desired: "c"},
+				{Path: "metadata.annotations", Live: nil, Desired: map[string]interface{}{"d": "e"}},
+			},
+		},
+		{
+			name: "added, changed and removed nested fields, lists compared as a whole",
+			live: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"a": map[string]interface{}{"b": "c", "d": "e"},
+					"f": []interface{}{"g", "h"},
+				},
+			},
+			desired: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"a": map[string]interface{}{"b": "x", "y": "z"},
+					"f": []interface{}{"g"},
+				},
+				"data": "foo",
+			},
+			want: []FieldDiff{
+				{Path: "data", Live: nil, Desired: "foo"},
+				{Path: "spec.a.b", Live: "c", Desired: "x"},
+				{Path: "spec.a.d", Live: "e", Desired: nil},
+				{Path: "spec.a.y", Live: nil, Desired: "z"},
+				{Path: "spec.f", Live: []interface{}{"g", "h"}, Desired: []interface{}{"g"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(diffFields(tt.live, tt.desired)).To(Equal(tt.want))
+		})
+	}
+}
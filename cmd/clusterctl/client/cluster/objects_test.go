@@ -0,0 +1,148 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_objectsClient_ForceDelete(t *testing.T) {
+	machine := func(finalizers ...string) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: clusterv1.GroupVersion.String(),
+				Kind:       "Machine",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "machine1",
+				Namespace:  "ns1",
+				Finalizers: finalizers,
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		objs      []client.Object
+		wantEvent bool
+	}{
+		{
+			name:      "delete an object with finalizers",
+			objs:      []client.Object{machine(clusterv1.MachineFinalizer, "foo")},
+			wantEvent: true,
+		},
+		{
+			name:      "delete an object without finalizers",
+			objs:      []client.Object{machine()},
+			wantEvent: true,
+		},
+		{
+			name:      "no-op if the object does not exists",
+			objs:      []client.Object{},
+			wantEvent: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			proxy := test.NewFakeProxy().WithObjs(tt.objs...)
+			o := newObjectsClient(nil, proxy, wait.PollImmediate)
+
+			gvk := clusterv1.GroupVersion.WithKind("Machine")
+			err := o.ForceDelete(gvk, "ns1", "machine1")
+			g.Expect(err).NotTo(HaveOccurred())
+
+			c, err := proxy.NewClient()
+			g.Expect(err).NotTo(HaveOccurred())
+
+			err = c.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: "machine1"}, &clusterv1.Machine{})
+			g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+			events := &corev1.EventList{}
+			g.Expect(c.List(ctx, events, client.InNamespace("ns1"))).To(Succeed())
+			if !tt.wantEvent {
+				g.Expect(events.Items).To(BeEmpty())
+				return
+			}
+			g.Expect(events.Items).To(HaveLen(1))
+			g.Expect(events.Items[0].Reason).To(Equal(ForceDeletedEventReason))
+			g.Expect(events.Items[0].InvolvedObject.Name).To(Equal("machine1"))
+		})
+	}
+}
+
+// finalizerAddingClient is a client.Client adding a finalizer to an object right before the first patch,
+// simulating a controller changing the object after it has been read.
+type finalizerAddingClient struct {
+	client.Client
+	patched bool
+}
+
+func (c *finalizerAddingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if !c.patched {
+		c.patched = true
+		current := &clusterv1.Machine{}
+		if err := c.Client.Get(ctx, client.ObjectKeyFromObject(obj), current); err != nil {
+			return err
+		}
+		current.Finalizers = append(current.Finalizers, "bar")
+		if err := c.Client.Update(ctx, current); err != nil {
+			return err
+		}
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func Test_forceDelete_RetriesOnConflict(t *testing.T) {
+	g := NewWithT(t)
+
+	proxy := test.NewFakeProxy().WithObjs(&clusterv1.Machine{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: clusterv1.GroupVersion.String(),
+			Kind:       "Machine",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "machine1",
+			Namespace:  "ns1",
+			Finalizers: []string{clusterv1.MachineFinalizer},
+		},
+	})
+	fakeClient, err := proxy.NewClient()
+	g.Expect(err).NotTo(HaveOccurred())
+	c := &finalizerAddingClient{Client: fakeClient}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(clusterv1.GroupVersion.WithKind("Machine"))
+	g.Expect(forceDelete(c, obj, client.ObjectKey{Namespace: "ns1", Name: "machine1"}, newConflictBackoff(nil))).To(Succeed())
+
+	// The finalizer added after the object has been read is removed as well, after reading the object again.
+	g.Expect(c.patched).To(BeTrue())
+	err = fakeClient.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: "machine1"}, &clusterv1.Machine{})
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+}
@@ -0,0 +1,362 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ClusterPauser defines methods for pausing and resuming the reconciliation of a Cluster and of all its descendant objects.
+type ClusterPauser interface {
+	// Pause sets Cluster.Spec.Paused and the paused annotation on all the descendant objects of a Cluster.
+	// The objects paused by clusterctl are marked with the ClusterctlPausedAnnotation, while the objects already paused
+	// are left untouched. If pausing any of the objects fails, the objects already paused by this operation are resumed.
+	Pause(namespace, name string) error
+
+	// Resume removes the paused annotation from the descendant objects of a Cluster paused by clusterctl and then
+	// unsets Cluster.Spec.Paused, if it was set by clusterctl; the objects paused by others are left paused.
+	Resume(namespace, name string) error
+
+	// Status returns the paused state of a Cluster and of all its descendant objects.
+	Status(namespace, name string) (*PauseStatus, error)
+}
+
+// ObjectPauseStatus defines the paused state of an object.
+type ObjectPauseStatus struct {
+	Object corev1.ObjectReference
+	Paused bool
+
+	// PausedByClusterctl is true if the object has been paused by clusterctl, i.e. if it has the ClusterctlPausedAnnotation.
+	PausedByClusterctl bool
+}
+
+// PauseStatus defines the paused state of a Cluster and of all its descendant objects.
+type PauseStatus struct {
+	// Cluster is the paused state of the Cluster, i.e. the value of Cluster.Spec.Paused.
+	Cluster ObjectPauseStatus
+
+	// Objects is the paused state of the descendant objects of the Cluster, i.e. if they have the paused annotation.
+	Objects []ObjectPauseStatus
+}
+
+// IsPaused returns true if the Cluster and all its descendant objects are paused.
+func (s *PauseStatus) IsPaused() bool {
+	if !s.Cluster.Paused {
+		return false
+	}
+	for _, o := range s.Objects {
+		if !o.Paused {
+			return false
+		}
+	}
+	return true
+}
+
+// IsResumed returns true if neither the Cluster nor any of its descendant objects are paused.
+func (s *PauseStatus) IsResumed() bool {
+	if s.Cluster.Paused {
+		return false
+	}
+	for _, o := range s.Objects {
+		if o.Paused {
+			return false
+		}
+	}
+	return true
+}
+
+// clusterPauser implements ClusterPauser.
+type clusterPauser struct {
+	proxy         Proxy
+	eventRecorder EventRecorder
+}
+
+// ensure clusterPauser implements the ClusterPauser interface.
+var _ ClusterPauser = &clusterPauser{}
+
+func (p *clusterPauser) Pause(namespace, name string) error {
+	graph, cluster, err := discoverCluster(p.proxy, namespace, name)
+	if err != nil {
+		return err
+	}
+	if err := pauseClusterHierarchy(p.proxy, cluster, getDescendants(graph, cluster)); err != nil {
+		return err
+	}
+	recordNodeEvent(p.proxy, p.eventRecorder, cluster, corev1.EventTypeNormal, PausedEventReason, "Reconciliation paused by clusterctl")
+	return nil
+}
+
+func (p *clusterPauser) Resume(namespace, name string) error {
+	graph, cluster, err := discoverCluster(p.proxy, namespace, name)
+	if err != nil {
+		return err
+	}
+	if err := resumeClusterHierarchy(p.proxy, cluster, getDescendants(graph, cluster)); err != nil {
+		return err
+	}
+	recordNodeEvent(p.proxy, p.eventRecorder, cluster, corev1.EventTypeNormal, ResumedEventReason, "Reconciliation resumed by clusterctl")
+	return nil
+}
+
+func (p *clusterPauser) Status(namespace, name string) (*PauseStatus, error) {
+	graph, cluster, err := discoverCluster(p.proxy, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return getPauseStatus(p.proxy, cluster, getDescendants(graph, cluster))
+}
+
+// discoverCluster builds the object graph for a namespace and returns the node for the given Cluster.
+func discoverCluster(proxy Proxy, namespace, name string) (*objectGraph, *node, error) {
+	graph := newObjectGraph(proxy)
+	if err := graph.getDiscoveryTypes(); err != nil {
+		return nil, nil, err
+	}
+	if err := graph.Discovery(namespace); err != nil {
+		return nil, nil, err
+	}
+
+	cluster := getClusterNode(graph, namespace, name)
+	if cluster == nil {
+		return nil, nil, errors.Errorf("failed to find Cluster %s/%s", namespace, name)
+	}
+	return graph, cluster, nil
+}
+
+// getClusterNode returns the node for a Cluster in the object graph, if any.
+func getClusterNode(graph *objectGraph, namespace, name string) *node {
+	for _, cluster := range graph.getClusters() {
+		if cluster.identity.Namespace == namespace && cluster.identity.Name == name {
+			return cluster
+		}
+	}
+	return nil
+}
+
+// getDescendants returns the Cluster API objects belonging to a Cluster, sorted by kind and name.
+// Core Kubernetes objects like Secrets and ConfigMaps are ignored, given that they are not reconciled by providers.
+func getDescendants(graph *objectGraph, cluster *node) []*node {
+	descendants := []*node{}
+	for _, n := range graph.getNodes() {
+		if n == cluster || n.virtual || n.identity.APIVersion == "v1" {
+			continue
+		}
+		if _, ok := n.tenantClusters[cluster]; ok {
+			descendants = append(descendants, n)
+		}
+	}
+	sort.Slice(descendants, func(i, j int) bool {
+		if descendants[i].identity.Kind != descendants[j].identity.Kind {
+			return descendants[i].identity.Kind < descendants[j].identity.Kind
+		}
+		return descendants[i].identity.Name < descendants[j].identity.Name
+	})
+	return descendants
+}
+
+// pauseClusterHierarchy sets Cluster.Spec.Paused and then the paused annotation on all the descendants.
+// In case of errors, the objects paused by this operation are resumed.
+func pauseClusterHierarchy(proxy Proxy, cluster *node, descendants []*node) error {
+	log := logf.Log
+
+	status, err := getPauseStatus(proxy, cluster, descendants)
+	if err != nil {
+		return err
+	}
+
+	var paused []*node
+	rollback := func(cause error) error {
+		log.Info("Failed to pause the Cluster, resuming the objects already paused", "Cluster", cluster.identity.Name, "Namespace", cluster.identity.Namespace)
+		errList := []error{cause}
+		for _, n := range paused {
+			if n == cluster {
+				errList = append(errList, setClusterPausedByClusterctl(proxy, cluster, false))
+				continue
+			}
+			errList = append(errList, setPausedAnnotation(proxy, n, false))
+		}
+		return kerrors.NewAggregate(errList)
+	}
+
+	if !status.Cluster.Paused {
+		if err := setClusterPausedByClusterctl(proxy, cluster, true); err != nil {
+			return err
+		}
+		paused = append(paused, cluster)
+	}
+
+	for i, n := range descendants {
+		// Skip objects already paused, so they are not resumed in case of rollback.
+		if status.Objects[i].Paused {
+			continue
+		}
+		if err := setPausedAnnotation(proxy, n, true); err != nil {
+			return rollback(err)
+		}
+		paused = append(paused, n)
+	}
+	return nil
+}
+
+// resumeClusterHierarchy removes the paused annotation from the descendants paused by clusterctl and then unsets
+// Cluster.Spec.Paused, if set by clusterctl, so the Cluster is resumed only after all its descendants are resumed.
+// The objects paused by others, e.g. before pausing the Cluster with clusterctl, are left paused.
+func resumeClusterHierarchy(proxy Proxy, cluster *node, descendants []*node) error {
+	status, err := getPauseStatus(proxy, cluster, descendants)
+	if err != nil {
+		return err
+	}
+
+	errList := []error{}
+	for i, n := range descendants {
+		if !status.Objects[i].PausedByClusterctl {
+			continue
+		}
+		if err := setPausedAnnotation(proxy, n, false); err != nil {
+			errList = append(errList, err)
+		}
+	}
+	if len(errList) > 0 {
+		return kerrors.NewAggregate(errList)
+	}
+	if !status.Cluster.PausedByClusterctl {
+		return nil
+	}
+	return setClusterPausedByClusterctl(proxy, cluster, false)
+}
+
+// getPauseStatus returns the paused state of a Cluster and of its descendants.
+func getPauseStatus(proxy Proxy, cluster *node, descendants []*node) (*PauseStatus, error) {
+	c, err := proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	clusterObj := &clusterv1.Cluster{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: cluster.identity.Namespace, Name: cluster.identity.Name}, clusterObj); err != nil {
+		return nil, errors.Wrapf(err, "error reading Cluster %s/%s", cluster.identity.Namespace, cluster.identity.Name)
+	}
+
+	_, clusterPausedByClusterctl := clusterObj.GetAnnotations()[clusterctlv1.ClusterctlPausedAnnotation]
+	status := &PauseStatus{
+		Cluster: ObjectPauseStatus{Object: cluster.identity, Paused: clusterObj.Spec.Paused, PausedByClusterctl: clusterPausedByClusterctl},
+	}
+	for _, n := range descendants {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(n.identity.APIVersion)
+		obj.SetKind(n.identity.Kind)
+		if err := c.Get(ctx, client.ObjectKey{Namespace: n.identity.Namespace, Name: n.identity.Name}, obj); err != nil {
+			return nil, errors.Wrapf(err, "error reading %q %s/%s", obj.GroupVersionKind(), n.identity.Namespace, n.identity.Name)
+		}
+		_, paused := obj.GetAnnotations()[clusterv1.PausedAnnotation]
+		_, pausedByClusterctl := obj.GetAnnotations()[clusterctlv1.ClusterctlPausedAnnotation]
+		status.Objects = append(status.Objects, ObjectPauseStatus{Object: n.identity, Paused: paused, PausedByClusterctl: pausedByClusterctl})
+	}
+	return status, nil
+}
+
+// setClusterPausedByClusterctl sets or unsets Cluster.Spec.Paused, together with the ClusterctlPausedAnnotation.
+func setClusterPausedByClusterctl(proxy Proxy, cluster *node, value bool) error {
+	log := logf.Log
+	log.V(5).Info("Set Cluster.Spec.Paused", "Paused", value, "Cluster", cluster.identity.Name, "Namespace", cluster.identity.Namespace)
+
+	patchData, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				clusterctlv1.ClusterctlPausedAnnotation: annotationValue(value),
+			},
+		},
+		"spec": map[string]interface{}{
+			"paused": value,
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the Cluster paused patch")
+	}
+	patch := client.RawPatch(types.MergePatchType, patchData)
+
+	// Nb. The operation is wrapped in a retry loop to make setClusterPausedByClusterctl more resilient to unexpected conditions.
+	if err := retryWithExponentialBackoff(newWriteBackoff(), func() error {
+		return patchCluster(proxy, cluster, patch)
+	}); err != nil {
+		return errors.Wrapf(err, "error setting Cluster.Spec.Paused=%t", value)
+	}
+	return nil
+}
+
+// setPausedAnnotation adds or removes the paused annotation on a node, together with the ClusterctlPausedAnnotation.
+func setPausedAnnotation(proxy Proxy, n *node, value bool) error {
+	log := logf.Log
+	log.V(5).Info("Set paused annotation", "Paused", value, n.identity.Kind, n.identity.Name, "Namespace", n.identity.Namespace)
+
+	patchData, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				clusterv1.PausedAnnotation:              annotationValue(value),
+				clusterctlv1.ClusterctlPausedAnnotation: annotationValue(value),
+			},
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the paused annotation patch")
+	}
+	patch := client.RawPatch(types.MergePatchType, patchData)
+
+	// Nb. The operation is wrapped in a retry loop to make setPausedAnnotation more resilient to unexpected conditions.
+	return retryWithExponentialBackoff(newWriteBackoff(), func() error {
+		c, err := proxy.NewClient()
+		if err != nil {
+			return err
+		}
+
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(n.identity.APIVersion)
+		obj.SetKind(n.identity.Kind)
+		obj.SetNamespace(n.identity.Namespace)
+		obj.SetName(n.identity.Name)
+		if err := c.Patch(ctx, obj, patch); err != nil {
+			return errors.Wrapf(err, "error patching %q %s/%s", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+		}
+		return nil
+	})
+}
+
+// annotationValue returns the value for adding an annotation with a merge patch, or nil for removing it.
+func annotationValue(set bool) interface{} {
+	if set {
+		return ""
+	}
+	return nil
+}
+
+func newClusterPauser(proxy Proxy) *clusterPauser {
+	return &clusterPauser{
+		proxy:         proxy,
+		eventRecorder: newEventRecorder(proxy),
+	}
+}
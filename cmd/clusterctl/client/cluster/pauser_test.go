@@ -0,0 +1,167 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+)
+
+func Test_clusterPauser_PauseAndResume(t *testing.T) {
+	g := NewWithT(t)
+
+	objs := test.NewFakeCluster("ns1", "cluster1").
+		WithMachineDeployments(
+			test.NewFakeMachineDeployment("md1").
+				WithMachineSets(
+					test.NewFakeMachineSet("ms1").
+						WithMachines(test.NewFakeMachine("m1")),
+				),
+		).Objs()
+	objs = append(objs, test.NewFakeCluster("ns1", "cluster2").Objs()...)
+
+	// Create an objectGraph bound to a source cluster with all the CRDs for the types involved in the test.
+	graph := getObjectGraphWithObjs(objs)
+	g.Expect(getFakeDiscoveryTypes(graph)).To(Succeed())
+	g.Expect(graph.Discovery("ns1")).To(Succeed())
+
+	cluster := getClusterNode(graph, "ns1", "cluster1")
+	g.Expect(cluster).ToNot(BeNil())
+	g.Expect(getClusterNode(graph, "ns1", "does-not-exist")).To(BeNil())
+
+	descendants := getDescendants(graph, cluster)
+	g.Expect(descendants).ToNot(BeEmpty())
+	for _, n := range descendants {
+		g.Expect(n.identity.APIVersion).ToNot(Equal("v1"))
+	}
+
+	// Pause the cluster hierarchy.
+	g.Expect(pauseClusterHierarchy(graph.proxy, cluster, descendants)).To(Succeed())
+
+	status, err := getPauseStatus(graph.proxy, cluster, descendants)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(status.IsPaused()).To(BeTrue())
+	g.Expect(status.Objects).To(HaveLen(len(descendants)))
+
+	// Other clusters are not paused.
+	other := getClusterNode(graph, "ns1", "cluster2")
+	otherStatus, err := getPauseStatus(graph.proxy, other, getDescendants(graph, other))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(otherStatus.IsResumed()).To(BeTrue())
+
+	// Resume the cluster hierarchy.
+	g.Expect(resumeClusterHierarchy(graph.proxy, cluster, descendants)).To(Succeed())
+
+	status, err = getPauseStatus(graph.proxy, cluster, descendants)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(status.IsResumed()).To(BeTrue())
+}
+
+func Test_clusterPauser_ResumeOnlyObjectsPausedByClusterctl(t *testing.T) {
+	g := NewWithT(t)
+
+	objs := test.NewFakeCluster("ns1", "cluster1").
+		WithMachineDeployments(
+			test.NewFakeMachineDeployment("md1").
+				WithMachineSets(
+					test.NewFakeMachineSet("ms1").
+						WithMachines(test.NewFakeMachine("m1")),
+				),
+		).Objs()
+
+	// The Cluster and the MachineSet are already paused before pausing the Cluster with clusterctl.
+	for _, o := range objs {
+		switch o := o.(type) {
+		case *clusterv1.Cluster:
+			o.Spec.Paused = true
+		case *clusterv1.MachineSet:
+			o.SetAnnotations(map[string]string{clusterv1.PausedAnnotation: ""})
+		}
+	}
+
+	graph := getObjectGraphWithObjs(objs)
+	g.Expect(getFakeDiscoveryTypes(graph)).To(Succeed())
+	g.Expect(graph.Discovery("ns1")).To(Succeed())
+
+	cluster := getClusterNode(graph, "ns1", "cluster1")
+	g.Expect(cluster).ToNot(BeNil())
+	descendants := getDescendants(graph, cluster)
+
+	g.Expect(pauseClusterHierarchy(graph.proxy, cluster, descendants)).To(Succeed())
+
+	status, err := getPauseStatus(graph.proxy, cluster, descendants)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(status.IsPaused()).To(BeTrue())
+	g.Expect(status.Cluster.PausedByClusterctl).To(BeFalse())
+	for _, o := range status.Objects {
+		g.Expect(o.PausedByClusterctl).To(Equal(o.Object.Kind != "MachineSet"), "%s %s", o.Object.Kind, o.Object.Name)
+	}
+
+	g.Expect(resumeClusterHierarchy(graph.proxy, cluster, descendants)).To(Succeed())
+
+	// Only the objects paused by clusterctl are resumed.
+	status, err = getPauseStatus(graph.proxy, cluster, descendants)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(status.Cluster.Paused).To(BeTrue())
+	g.Expect(status.Objects).ToNot(BeEmpty())
+	for _, o := range status.Objects {
+		g.Expect(o.Paused).To(Equal(o.Object.Kind == "MachineSet"), "%s %s", o.Object.Kind, o.Object.Name)
+		g.Expect(o.PausedByClusterctl).To(BeFalse())
+	}
+}
+
+func Test_PauseStatus(t *testing.T) {
+	paused := ObjectPauseStatus{Paused: true}
+	notPaused := ObjectPauseStatus{Paused: false}
+
+	tests := []struct {
+		name        string
+		status      PauseStatus
+		wantPaused  bool
+		wantResumed bool
+	}{
+		{
+			name:        "all objects paused",
+			status:      PauseStatus{Cluster: paused, Objects: []ObjectPauseStatus{paused, paused}},
+			wantPaused:  true,
+			wantResumed: false,
+		},
+		{
+			name:        "no objects paused",
+			status:      PauseStatus{Cluster: notPaused, Objects: []ObjectPauseStatus{notPaused, notPaused}},
+			wantPaused:  false,
+			wantResumed: true,
+		},
+		{
+			name:        "partially paused",
+			status:      PauseStatus{Cluster: paused, Objects: []ObjectPauseStatus{paused, notPaused}},
+			wantPaused:  false,
+			wantResumed: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(tt.status.IsPaused()).To(Equal(tt.wantPaused))
+			g.Expect(tt.status.IsResumed()).To(Equal(tt.wantResumed))
+		})
+	}
+}
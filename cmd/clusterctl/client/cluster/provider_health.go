@@ -0,0 +1,295 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	waitProviderHealthInterval = 5 * time.Second
+
+	// webhookProbeName is the name of the objects created in dry run mode for checking that a webhook is serving.
+	webhookProbeName = "clusterctl-webhook-probe"
+)
+
+// ProviderHealthOptions carries the options supported by ProviderHealthClient.WaitForReady.
+type ProviderHealthOptions struct {
+	// Namespace where the provider components are installed.
+	Namespace string
+
+	// Timeout is the time to wait for the provider components to be ready; zero means checking only once.
+	Timeout time.Duration
+}
+
+// ProviderHealthClient has methods for checking that the components of a provider are ready to serve requests.
+type ProviderHealthClient interface {
+	// WaitForReady waits for the components installed by clusterctl in a provider namespace to be ready, i.e.
+	// - all the Deployments in the namespace are Available;
+	// - all the validating and mutating webhooks backed by a Service in the namespace are serving, as verified by
+	//   creating in dry run mode an object of one of the resources each webhook is registered for; this detects
+	//   webhooks not reachable yet or serving with a certificate not trusted by the API server yet, e.g. because
+	//   cert-manager did not inject the CA bundle.
+	// NB. Validating webhooks can be called only after the object passes the schema validation, so a rejection by
+	// the schema validation is considered a success.
+	WaitForReady(options ProviderHealthOptions) error
+}
+
+// providerHealthClient implements ProviderHealthClient.
+type providerHealthClient struct {
+	proxy               Proxy
+	pollImmediateWaiter PollImmediateWaiter
+}
+
+// ensure providerHealthClient implements ProviderHealthClient.
+var _ ProviderHealthClient = &providerHealthClient{}
+
+// newProviderHealthClient returns a providerHealthClient.
+func newProviderHealthClient(proxy Proxy, pollImmediateWaiter PollImmediateWaiter) *providerHealthClient {
+	return &providerHealthClient{
+		proxy:               proxy,
+		pollImmediateWaiter: pollImmediateWaiter,
+	}
+}
+
+func (h *providerHealthClient) WaitForReady(options ProviderHealthOptions) error {
+	log := logf.Log
+	log.Info("Waiting for provider components to be ready", "Namespace", options.Namespace)
+
+	c, err := h.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	deployments := &appsv1.DeploymentList{}
+	if err := c.List(ctx, deployments, client.InNamespace(options.Namespace), client.HasLabels{clusterctlv1.ClusterctlLabelName}); err != nil {
+		return errors.Wrapf(err, "failed to list the provider Deployments in namespace %s", options.Namespace)
+	}
+	if len(deployments.Items) == 0 {
+		return errors.Errorf("no Deployments installed by clusterctl found in namespace %s", options.Namespace)
+	}
+
+	for i := range deployments.Items {
+		key := client.ObjectKeyFromObject(&deployments.Items[i])
+		if err := h.wait(options.Timeout, func() (bool, error) {
+			return isDeploymentAvailable(c, key), nil
+		}); err != nil {
+			return errors.Wrapf(err, "error waiting for Deployment %s to be available", key.String())
+		}
+	}
+
+	probes, err := getWebhookProbes(c, options.Namespace)
+	if err != nil {
+		return err
+	}
+	for _, probe := range probes {
+		probe := probe
+		var lastErr error
+		if err := h.wait(options.Timeout, func() (bool, error) {
+			lastErr = probe.check(c)
+			if lastErr != nil {
+				log.V(5).Info("Webhook not serving, retrying", "Webhook", probe.webhook, "Cause", lastErr.Error())
+				return false, nil
+			}
+			return true, nil
+		}); err != nil {
+			if lastErr != nil {
+				err = lastErr
+			}
+			return errors.Wrapf(err, "error waiting for webhook %s to be serving", probe.webhook)
+		}
+	}
+	return nil
+}
+
+// wait checks a condition until it returns true or the timeout expires; if the timeout is zero, the condition is checked only once.
+func (h *providerHealthClient) wait(timeout time.Duration, condition func() (bool, error)) error {
+	if timeout == 0 {
+		done, err := condition()
+		if err != nil {
+			return err
+		}
+		if !done {
+			return errors.New("not ready")
+		}
+		return nil
+	}
+	return h.pollImmediateWaiter(waitProviderHealthInterval, timeout, condition)
+}
+
+// isDeploymentAvailable returns true if a Deployment has the Available condition set to true.
+// Errors reading the Deployment, e.g. because the API server is temporarily unavailable, are reported as not available.
+func isDeploymentAvailable(c client.Client, key client.ObjectKey) bool {
+	deployment := &appsv1.Deployment{}
+	if err := c.Get(ctx, key, deployment); err != nil {
+		logf.Log.V(5).Info("Failed to get provider Deployment, retrying", "Deployment", key.String(), "Error", err.Error())
+		return false
+	}
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentAvailable && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookProbe is an object that, once created in dry run mode, is sent to a webhook.
+type webhookProbe struct {
+	// webhook is the name of the webhook configuration followed by the name of the webhook.
+	webhook string
+
+	obj *unstructured.Unstructured
+}
+
+// check creates the probe object in dry run mode, and returns an error if the API server failed calling the webhook.
+// Any other error, e.g. the object being rejected by the webhook because it is invalid, proves the webhook is serving.
+func (p webhookProbe) check(c client.Client) error {
+	err := c.Create(ctx, p.obj.DeepCopy(), client.DryRunAll)
+	if isWebhookCallError(err) {
+		return err
+	}
+	return nil
+}
+
+// isWebhookCallError returns true if an error is returned by the API server because it failed calling a webhook,
+// e.g. because the webhook Service has no endpoints or the webhook certificate is not trusted.
+func isWebhookCallError(err error) bool {
+	return err != nil && apierrors.IsInternalError(err) && strings.Contains(err.Error(), "failed calling webhook")
+}
+
+// getWebhookProbes returns a probe for each validating and mutating webhook installed by clusterctl and backed by a Service
+// in the given namespace. Webhooks registered only for resources which are not defined by a CustomResourceDefinition,
+// or only for wildcards, are skipped, given that an object to be sent to them cannot be generated.
+func getWebhookProbes(c client.Client, namespace string) ([]webhookProbe, error) {
+	crds := &apiextensionsv1.CustomResourceDefinitionList{}
+	if err := c.List(ctx, crds); err != nil {
+		return nil, errors.Wrap(err, "failed to list CustomResourceDefinitions")
+	}
+
+	probes := []webhookProbe{}
+	addProbe := func(configuration, webhook string, clientConfig admissionregistrationv1.WebhookClientConfig, rules []admissionregistrationv1.RuleWithOperations) {
+		if clientConfig.Service == nil || clientConfig.Service.Namespace != namespace {
+			return
+		}
+		name := fmt.Sprintf("%s/%s", configuration, webhook)
+		obj := getWebhookProbeObject(crds, rules, namespace)
+		if obj == nil {
+			logf.Log.V(5).Info("Skipping webhook not registered for any custom resource", "Webhook", name)
+			return
+		}
+		probes = append(probes, webhookProbe{webhook: name, obj: obj})
+	}
+
+	mutating := &admissionregistrationv1.MutatingWebhookConfigurationList{}
+	if err := c.List(ctx, mutating, client.HasLabels{clusterctlv1.ClusterctlLabelName}); err != nil {
+		return nil, errors.Wrap(err, "failed to list MutatingWebhookConfigurations")
+	}
+	for _, configuration := range mutating.Items {
+		for _, webhook := range configuration.Webhooks {
+			addProbe(configuration.Name, webhook.Name, webhook.ClientConfig, webhook.Rules)
+		}
+	}
+
+	validating := &admissionregistrationv1.ValidatingWebhookConfigurationList{}
+	if err := c.List(ctx, validating, client.HasLabels{clusterctlv1.ClusterctlLabelName}); err != nil {
+		return nil, errors.Wrap(err, "failed to list ValidatingWebhookConfigurations")
+	}
+	for _, configuration := range validating.Items {
+		for _, webhook := range configuration.Webhooks {
+			addProbe(configuration.Name, webhook.Name, webhook.ClientConfig, webhook.Rules)
+		}
+	}
+
+	sort.Slice(probes, func(i, j int) bool {
+		return probes[i].webhook < probes[j].webhook
+	})
+	return probes, nil
+}
+
+// getWebhookProbeObject returns an empty object of the first custom resource matching a rule for the create operation,
+// or nil if there are no such resources.
+func getWebhookProbeObject(crds *apiextensionsv1.CustomResourceDefinitionList, rules []admissionregistrationv1.RuleWithOperations, namespace string) *unstructured.Unstructured {
+	for _, rule := range rules {
+		if !hasCreateOperation(rule.Operations) {
+			continue
+		}
+		for _, group := range rule.APIGroups {
+			for _, version := range rule.APIVersions {
+				for _, resource := range rule.Resources {
+					crd := getCRDForResource(crds, group, version, resource)
+					if crd == nil {
+						continue
+					}
+
+					obj := &unstructured.Unstructured{}
+					obj.SetGroupVersionKind(schema.GroupVersionKind{Group: group, Version: version, Kind: crd.Spec.Names.Kind})
+					obj.SetName(webhookProbeName)
+					if crd.Spec.Scope == apiextensionsv1.NamespaceScoped {
+						obj.SetNamespace(namespace)
+					}
+					return obj
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// hasCreateOperation returns true if the operations of a webhook rule include create.
+func hasCreateOperation(operations []admissionregistrationv1.OperationType) bool {
+	for _, o := range operations {
+		if o == admissionregistrationv1.Create || o == admissionregistrationv1.OperationAll {
+			return true
+		}
+	}
+	return false
+}
+
+// getCRDForResource returns the CustomResourceDefinition serving a resource in a group and version; wildcards and
+// subresources are not supported.
+func getCRDForResource(crds *apiextensionsv1.CustomResourceDefinitionList, group, version, resource string) *apiextensionsv1.CustomResourceDefinition {
+	if group == "*" || version == "*" || strings.Contains(resource, "*") || strings.Contains(resource, "/") {
+		return nil
+	}
+	for i := range crds.Items {
+		crd := &crds.Items[i]
+		if crd.Spec.Group != group || crd.Spec.Names.Plural != resource {
+			continue
+		}
+		for _, v := range crd.Spec.Versions {
+			if v.Name == version && v.Served {
+				return crd
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_providerHealthClient_WaitForReady(t *testing.T) {
+	deployment := func(name string, labels map[string]string, available corev1.ConditionStatus) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: appsv1.SchemeGroupVersion.String(),
+				Kind:       "Deployment",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "infra1-system",
+				Name:      name,
+				Labels:    labels,
+			},
+			Status: appsv1.DeploymentStatus{
+				Conditions: []appsv1.DeploymentCondition{
+					{Type: appsv1.DeploymentAvailable, Status: available},
+				},
+			},
+		}
+	}
+	clusterctlLabels := map[string]string{clusterctlv1.ClusterctlLabelName: ""}
+
+	tests := []struct {
+		name    string
+		objs    []client.Object
+		timeout time.Duration
+		wantErr bool
+	}{
+		{
+			name:    "provider deployments are available",
+			objs:    []client.Object{deployment("infra1-controller-manager", clusterctlLabels, corev1.ConditionTrue)},
+			timeout: time.Minute,
+			wantErr: false,
+		},
+		{
+			name:    "provider deployments are available, checked once",
+			objs:    []client.Object{deployment("infra1-controller-manager", clusterctlLabels, corev1.ConditionTrue)},
+			timeout: 0,
+			wantErr: false,
+		},
+		{
+			name: "provider deployments are not available",
+			objs: []client.Object{
+				deployment("infra1-controller-manager", clusterctlLabels, corev1.ConditionTrue),
+				deployment("infra1-other", clusterctlLabels, corev1.ConditionFalse),
+			},
+			timeout: time.Minute,
+			wantErr: true,
+		},
+		{
+			name:    "provider deployments are not available, checked once",
+			objs:    []client.Object{deployment("infra1-controller-manager", clusterctlLabels, corev1.ConditionFalse)},
+			timeout: 0,
+			wantErr: true,
+		},
+		{
+			name:    "deployments not installed by clusterctl are ignored",
+			objs:    []client.Object{deployment("infra1-controller-manager", nil, corev1.ConditionTrue)},
+			timeout: time.Minute,
+			wantErr: true,
+		},
+		{
+			name:    "provider deployments do not exist",
+			timeout: time.Minute,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			h := newProviderHealthClient(test.NewFakeProxy().WithObjs(tt.objs...), func(_, _ time.Duration, condition wait.ConditionFunc) error {
+				return wait.PollImmediate(time.Millisecond, 10*time.Millisecond, condition)
+			})
+
+			err := h.WaitForReady(ProviderHealthOptions{Namespace: "infra1-system", Timeout: tt.timeout})
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+		})
+	}
+}
+
+func Test_getWebhookProbes(t *testing.T) {
+	g := NewWithT(t)
+
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: apiextensionsv1.SchemeGroupVersion.String(),
+			Kind:       "CustomResourceDefinition",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "infra1machines.infrastructure.cluster.x-k8s.io",
+		},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: "infrastructure.cluster.x-k8s.io",
+			Names: apiextensionsv1.CustomResourceDefinitionNames{
+				Kind:   "Infra1Machine",
+				Plural: "infra1machines",
+			},
+			Scope: apiextensionsv1.NamespaceScoped,
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{Name: "v1alpha4", Served: true, Storage: true},
+			},
+		},
+	}
+	webhook := func(name, namespace string, operation admissionregistrationv1.OperationType, resource string) admissionregistrationv1.MutatingWebhook {
+		return admissionregistrationv1.MutatingWebhook{
+			Name: name,
+			ClientConfig: admissionregistrationv1.WebhookClientConfig{
+				Service: &admissionregistrationv1.ServiceReference{Namespace: namespace, Name: "webhook-service"},
+			},
+			Rules: []admissionregistrationv1.RuleWithOperations{
+				{
+					Operations: []admissionregistrationv1.OperationType{operation},
+					Rule: admissionregistrationv1.Rule{
+						APIGroups:   []string{"infrastructure.cluster.x-k8s.io"},
+						APIVersions: []string{"v1alpha4"},
+						Resources:   []string{resource},
+					},
+				},
+			},
+		}
+	}
+	mutating := &admissionregistrationv1.MutatingWebhookConfiguration{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: admissionregistrationv1.SchemeGroupVersion.String(),
+			Kind:       "MutatingWebhookConfiguration",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "infra1-mutating-webhook-configuration",
+			Labels: map[string]string{clusterctlv1.ClusterctlLabelName: ""},
+		},
+		Webhooks: []admissionregistrationv1.MutatingWebhook{
+			webhook("default.infra1machine", "infra1-system", admissionregistrationv1.Create, "infra1machines"),
+			webhook("default.other-namespace", "infra2-system", admissionregistrationv1.Create, "infra1machines"),
+			webhook("default.update-only", "infra1-system", admissionregistrationv1.Update, "infra1machines"),
+			webhook("default.wildcard", "infra1-system", admissionregistrationv1.OperationAll, "*"),
+			webhook("default.unknown", "infra1-system", admissionregistrationv1.Create, "infra1clusters"),
+		},
+	}
+
+	c, err := test.NewFakeProxy().WithObjs(crd, mutating).NewClient()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	probes, err := getWebhookProbes(c, "infra1-system")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(probes).To(HaveLen(1))
+	g.Expect(probes[0].webhook).To(Equal("infra1-mutating-webhook-configuration/default.infra1machine"))
+	g.Expect(probes[0].obj.GroupVersionKind()).To(Equal(schema.GroupVersionKind{Group: "infrastructure.cluster.x-k8s.io", Version: "v1alpha4", Kind: "Infra1Machine"}))
+	g.Expect(probes[0].obj.GetNamespace()).To(Equal("infra1-system"))
+	g.Expect(probes[0].obj.GetName()).To(Equal(webhookProbeName))
+}
+
+func Test_isWebhookCallError(t *testing.T) {
+	g := NewWithT(t)
+
+	gr := schema.GroupResource{Group: "infrastructure.cluster.x-k8s.io", Resource: "infra1machines"}
+	g.Expect(isWebhookCallError(nil)).To(BeFalse())
+	g.Expect(isWebhookCallError(apierrors.NewInternalError(
+		errors.New(`failed calling webhook "default.infra1machine": x509: certificate signed by unknown authority`)))).To(BeTrue())
+	g.Expect(isWebhookCallError(apierrors.NewInvalid(schema.GroupKind{Group: gr.Group, Kind: "Infra1Machine"}, webhookProbeName, nil))).To(BeFalse())
+	g.Expect(isWebhookCallError(apierrors.NewForbidden(gr, webhookProbeName, errors.New("denied by the webhook")))).To(BeFalse())
+}
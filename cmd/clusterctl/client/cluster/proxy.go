@@ -18,6 +18,9 @@ package cluster
 
 import (
 	"fmt"
+	"net/http"
+	"net/url"
+	"os"
 	"strings"
 	"time"
 
@@ -43,13 +46,25 @@ type proxy struct {
 	kubeconfig         Kubeconfig
 	timeout            time.Duration
 	configLoadingRules *clientcmd.ClientConfigLoadingRules
+	impersonate        rest.ImpersonationConfig
+	namespace          string
+
+	proxyURL              string
+	caData                []byte
+	caFiles               []string
+	insecureSkipTLSVerify bool
 }
 
 var _ Proxy = &proxy{}
 
 // CurrentNamespace returns the namespace for the specified context or the
 // first valid context as determined by the default config loading rules.
+// If a namespace is injected, it takes precedence over the namespace from the kubeconfig file.
 func (k *proxy) CurrentNamespace() (string, error) {
+	if k.namespace != "" {
+		return k.namespace, nil
+	}
+
 	config, err := k.configLoadingRules.Load()
 	if err != nil {
 		return "", errors.Wrap(err, "failed to load Kubeconfig")
@@ -77,29 +92,38 @@ func (k *proxy) CurrentNamespace() (string, error) {
 }
 
 func (k *proxy) ValidateKubernetesVersion() error {
-	config, err := k.GetConfig()
+	serverVersion, err := k.GetServerVersion()
 	if err != nil {
 		return err
 	}
 
-	client := discovery.NewDiscoveryClientForConfigOrDie(config)
-	serverVersion, err := client.ServerVersion()
-	if err != nil {
-		return errors.Wrap(err, "failed to retrieve server version")
-	}
-
-	compver, err := utilversion.MustParseGeneric(serverVersion.String()).Compare(minimumKubernetesVersion)
+	compver, err := utilversion.MustParseGeneric(serverVersion).Compare(minimumKubernetesVersion)
 	if err != nil {
 		return errors.Wrap(err, "failed to parse and compare server version")
 	}
 
 	if compver == -1 {
-		return errors.Errorf("unsupported management cluster server version: %s - minimum required version is %s", serverVersion.String(), minimumKubernetesVersion)
+		return errors.Errorf("unsupported management cluster server version: %s - minimum required version is %s", serverVersion, minimumKubernetesVersion)
 	}
 
 	return nil
 }
 
+// GetServerVersion returns the Kubernetes version of the management cluster.
+func (k *proxy) GetServerVersion() (string, error) {
+	config, err := k.GetConfig()
+	if err != nil {
+		return "", err
+	}
+
+	client := discovery.NewDiscoveryClientForConfigOrDie(config)
+	serverVersion, err := client.ServerVersion()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to retrieve server version")
+	}
+	return serverVersion.String(), nil
+}
+
 // GetConfig returns the config for a kubernetes client.
 func (k *proxy) GetConfig() (*rest.Config, error) {
 	config, err := k.configLoadingRules.Load()
@@ -120,6 +144,16 @@ func (k *proxy) GetConfig() (*rest.Config, error) {
 	}
 	restConfig.UserAgent = fmt.Sprintf("clusterctl/%s (%s)", version.Get().GitVersion, version.Get().Platform)
 
+	// If impersonation is injected, it takes precedence over the impersonation settings from the kubeconfig file.
+	if k.impersonate.UserName != "" {
+		restConfig.Impersonate = k.impersonate
+	}
+
+	// If connection settings are injected, they take precedence over the connection settings from the kubeconfig file.
+	if err := k.applyConnectionSettings(restConfig); err != nil {
+		return nil, err
+	}
+
 	// Set QPS and Burst to a threshold that ensures the controller runtime client/client go does't generate throttling log messages
 	restConfig.QPS = 20
 	restConfig.Burst = 100
@@ -127,6 +161,63 @@ func (k *proxy) GetConfig() (*rest.Config, error) {
 	return restConfig, nil
 }
 
+// applyConnectionSettings applies the injected proxy URL, certificate authorities and TLS verification settings to a rest.Config.
+func (k *proxy) applyConnectionSettings(config *rest.Config) error {
+	if k.proxyURL != "" {
+		u, err := url.Parse(k.proxyURL)
+		if err != nil {
+			return errors.Wrapf(err, "invalid proxy URL %q", k.proxyURL)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return errors.Errorf("invalid proxy URL %q: the scheme must be one of http, https or socks5", k.proxyURL)
+		}
+		config.Proxy = http.ProxyURL(u)
+	}
+
+	if k.insecureSkipTLSVerify {
+		// Certificate authorities can't be specified for insecure connections.
+		config.Insecure = true
+		config.CAFile = ""
+		config.CAData = nil
+		return nil
+	}
+
+	caData := k.caData
+	for _, f := range k.caFiles {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read the certificate authority file %q", f)
+		}
+		caData = appendPEM(caData, data)
+	}
+	if len(caData) == 0 {
+		return nil
+	}
+
+	// The additional certificate authorities are trusted in addition to the one from the kubeconfig file, if any.
+	if config.CAFile != "" {
+		data, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read the certificate authority file %q", config.CAFile)
+		}
+		config.CAData = data
+		config.CAFile = ""
+	}
+	config.CAData = appendPEM(config.CAData, caData)
+	// Injected certificate authorities imply the management cluster's certificate must be verified.
+	config.Insecure = false
+	return nil
+}
+
+func appendPEM(bundle, pem []byte) []byte {
+	if len(bundle) > 0 && bundle[len(bundle)-1] != '\n' {
+		bundle = append(bundle, '\n')
+	}
+	return append(bundle, pem...)
+}
+
 func (k *proxy) NewClient() (client.Client, error) {
 	config, err := k.GetConfig()
 	if err != nil {
@@ -231,6 +322,77 @@ func InjectKubeconfigPaths(paths []string) ProxyOption {
 	}
 }
 
+// InjectImpersonation makes all the requests to the management cluster impersonate the given user and groups,
+// so clusterctl operations can be executed on behalf of users with reduced privileges.
+func InjectImpersonation(user string, groups ...string) ProxyOption {
+	return func(p *proxy) {
+		p.impersonate = rest.ImpersonationConfig{
+			UserName: user,
+			Groups:   groups,
+		}
+	}
+}
+
+// InjectNamespace overrides the namespace from the kubeconfig file, so all the operations
+// defaulting to the current namespace are scoped to the given namespace.
+func InjectNamespace(namespace string) ProxyOption {
+	return func(p *proxy) {
+		p.namespace = namespace
+	}
+}
+
+// InjectProxyURL makes all the requests to the management cluster go through the given HTTP, HTTPS or SOCKS5 proxy,
+// e.g. socks5://localhost:1080, overriding the proxy-url from the kubeconfig file and the proxy environment variables.
+func InjectProxyURL(proxyURL string) ProxyOption {
+	return func(p *proxy) {
+		p.proxyURL = proxyURL
+	}
+}
+
+// InjectCAData adds PEM encoded certificate authorities to the ones trusted when connecting to the management cluster,
+// e.g. for management clusters using certificates signed by a private certificate authority.
+func InjectCAData(caData []byte) ProxyOption {
+	return func(p *proxy) {
+		p.caData = appendPEM(p.caData, caData)
+	}
+}
+
+// InjectCAFile adds the PEM encoded certificate authorities from a file to the ones trusted when connecting to the
+// management cluster; the file is read when connecting to the management cluster.
+func InjectCAFile(path string) ProxyOption {
+	return func(p *proxy) {
+		p.caFiles = append(p.caFiles, path)
+	}
+}
+
+// InjectInsecureSkipTLSVerify disables the verification of the management cluster's certificate, making the connection insecure.
+// The certificate authorities from the kubeconfig file or injected with InjectCAData or InjectCAFile are ignored.
+func InjectInsecureSkipTLSVerify(insecure bool) ProxyOption {
+	return func(p *proxy) {
+		p.insecureSkipTLSVerify = insecure
+	}
+}
+
+// namespacedProxy wraps a Proxy overriding its current namespace.
+type namespacedProxy struct {
+	Proxy
+	namespace string
+}
+
+func (p *namespacedProxy) CurrentNamespace() (string, error) {
+	return p.namespace, nil
+}
+
+// WithNamespace returns a Proxy scoped to the given namespace, i.e. a Proxy returning the given namespace as the
+// current namespace, so operations defaulting to the current namespace can be executed in a different namespace
+// without creating a new Proxy.
+func WithNamespace(proxy Proxy, namespace string) Proxy {
+	return &namespacedProxy{
+		Proxy:     proxy,
+		namespace: namespace,
+	}
+}
+
 func newProxy(kubeconfig Kubeconfig, opts ...ProxyOption) Proxy {
 	// If a kubeconfig file isn't provided, find one in the standard locations.
 	rules := clientcmd.NewDefaultClientConfigLoadingRules()
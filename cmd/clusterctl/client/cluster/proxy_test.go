@@ -18,6 +18,7 @@ package cluster
 
 import (
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
 	"testing"
@@ -100,6 +101,78 @@ func TestProxyGetConfig(t *testing.T) {
 		g.Expect(err).ToNot(HaveOccurred())
 		g.Expect(conf.Timeout.String()).To(Equal("23s"))
 	})
+
+	t.Run("configure impersonation", func(t *testing.T) {
+		g := NewWithT(t)
+		dir, err := os.MkdirTemp("", "clusterctl")
+		g.Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		configFile := filepath.Join(dir, ".test-kubeconfig.yaml")
+		g.Expect(os.WriteFile(configFile, []byte(kubeconfig("management", "default")), 0600)).To(Succeed())
+
+		proxy := newProxy(Kubeconfig{Path: configFile, Context: "management"}, InjectImpersonation("tenant", "tenant-group", "other-group"))
+		conf, err := proxy.GetConfig()
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(conf.Impersonate.UserName).To(Equal("tenant"))
+		g.Expect(conf.Impersonate.Groups).To(Equal([]string{"tenant-group", "other-group"}))
+	})
+
+	t.Run("configure proxy URL", func(t *testing.T) {
+		g := NewWithT(t)
+		dir, err := os.MkdirTemp("", "clusterctl")
+		g.Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		configFile := filepath.Join(dir, ".test-kubeconfig.yaml")
+		g.Expect(os.WriteFile(configFile, []byte(kubeconfig("management", "default")), 0600)).To(Succeed())
+
+		proxy := newProxy(Kubeconfig{Path: configFile, Context: "management"}, InjectProxyURL("socks5://localhost:1080"))
+		conf, err := proxy.GetConfig()
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(conf.Proxy).ToNot(BeNil())
+		proxyURL, err := conf.Proxy(&http.Request{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(proxyURL.String()).To(Equal("socks5://localhost:1080"))
+
+		proxy = newProxy(Kubeconfig{Path: configFile, Context: "management"}, InjectProxyURL("ftp://localhost:21"))
+		_, err = proxy.GetConfig()
+		g.Expect(err).To(HaveOccurred())
+	})
+
+	t.Run("configure certificate authorities", func(t *testing.T) {
+		g := NewWithT(t)
+		dir, err := os.MkdirTemp("", "clusterctl")
+		g.Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		configFile := filepath.Join(dir, ".test-kubeconfig.yaml")
+		g.Expect(os.WriteFile(configFile, []byte(kubeconfig("management", "default")), 0600)).To(Succeed())
+		caFile := filepath.Join(dir, "ca.pem")
+		g.Expect(os.WriteFile(caFile, []byte("file-ca"), 0600)).To(Succeed())
+
+		proxy := newProxy(Kubeconfig{Path: configFile, Context: "management"}, InjectCAData([]byte("data-ca")), InjectCAFile(caFile))
+		conf, err := proxy.GetConfig()
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(string(conf.CAData)).To(Equal("data-ca\nfile-ca"))
+		g.Expect(conf.Insecure).To(BeFalse())
+
+		proxy = newProxy(Kubeconfig{Path: configFile, Context: "management"}, InjectCAFile(filepath.Join(dir, "missing.pem")))
+		_, err = proxy.GetConfig()
+		g.Expect(err).To(HaveOccurred())
+	})
+
+	t.Run("configure insecure skip TLS verify", func(t *testing.T) {
+		g := NewWithT(t)
+		dir, err := os.MkdirTemp("", "clusterctl")
+		g.Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		configFile := filepath.Join(dir, ".test-kubeconfig.yaml")
+		g.Expect(os.WriteFile(configFile, []byte(kubeconfig("management", "default")), 0600)).To(Succeed())
+
+		proxy := newProxy(Kubeconfig{Path: configFile, Context: "management"}, InjectCAData([]byte("data-ca")), InjectInsecureSkipTLSVerify(true))
+		conf, err := proxy.GetConfig()
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(conf.Insecure).To(BeTrue())
+		g.Expect(conf.CAData).To(BeEmpty())
+	})
 }
 
 // These tests are emulating the files passed in via KUBECONFIG env var by
@@ -240,6 +313,40 @@ func TestProxyCurrentNamespace(t *testing.T) {
 	}
 }
 
+func TestProxyNamespaceOverride(t *testing.T) {
+	g := NewWithT(t)
+	dir, err := os.MkdirTemp("", "clusterctl")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir)
+	configFile := filepath.Join(dir, ".test-kubeconfig.yaml")
+	g.Expect(os.WriteFile(configFile, []byte(kubeconfig("workload", "mykindns")), 0600)).To(Succeed())
+
+	t.Run("InjectNamespace overrides the namespace from the kubeconfig file", func(t *testing.T) {
+		g := NewWithT(t)
+
+		proxy := newProxy(Kubeconfig{Path: configFile}, InjectNamespace("tenant-ns"))
+		ns, err := proxy.CurrentNamespace()
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(ns).To(Equal("tenant-ns"))
+	})
+
+	t.Run("WithNamespace scopes an existing proxy to a namespace", func(t *testing.T) {
+		g := NewWithT(t)
+
+		proxy := newProxy(Kubeconfig{Path: configFile})
+		scoped := WithNamespace(proxy, "tenant-ns")
+
+		ns, err := scoped.CurrentNamespace()
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(ns).To(Equal("tenant-ns"))
+
+		// The original proxy is not affected.
+		ns, err = proxy.CurrentNamespace()
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(ns).To(Equal("mykindns"))
+	})
+}
+
 func kubeconfig(currentContext, namespace string) string {
 	return fmt.Sprintf(`---
 apiVersion: v1
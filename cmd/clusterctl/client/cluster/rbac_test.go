@@ -0,0 +1,239 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/rbac"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Test_RBACRules checks that the RBAC rules generated for the clusterctl operations allow all the calls
+// the operations make to the management cluster.
+func Test_RBACRules(t *testing.T) {
+	tests := []struct {
+		name      string
+		operation rbac.Operation
+		// apiGroups is the list of the API groups of the providers, in addition to the Cluster API ones.
+		apiGroups []string
+		objs      func() []client.Object
+		run       func(g *WithT, proxy *test.FakeProxy, recorder *test.CallRecorder)
+	}{
+		{
+			name:      "init",
+			operation: rbac.OperationInit,
+			run: func(g *WithT, proxy *test.FakeProxy, _ *test.CallRecorder) {
+				inventory := newInventoryClient(proxy, fakePollImmediateWaiter)
+				g.Expect(inventory.EnsureCustomResourceDefinitions()).To(Succeed())
+
+				cm := newRBACTestCertManagerClient(g, proxy)
+				g.Expect(cm.install()).To(Succeed())
+
+				g.Expect(installComponentsAndUpdateInventory(newRBACTestComponents(), newComponentsClient(proxy), inventory)).To(Succeed())
+			},
+		},
+		{
+			name:      "upgrade",
+			operation: rbac.OperationUpgrade,
+			objs:      rbacTestProviderObjs,
+			run: func(g *WithT, proxy *test.FakeProxy, _ *test.CallRecorder) {
+				cm := newRBACTestCertManagerClient(g, proxy)
+				g.Expect(cm.EnsureLatestVersion()).To(Succeed())
+
+				components := newComponentsClient(proxy)
+				g.Expect(components.Delete(DeleteOptions{Provider: newRBACTestComponents().InventoryObject()})).To(Succeed())
+				g.Expect(installComponentsAndUpdateInventory(newRBACTestComponents(), components, newInventoryClient(proxy, fakePollImmediateWaiter))).To(Succeed())
+				g.Expect(components.DeleteWebhookNamespace()).To(Succeed())
+			},
+		},
+		{
+			name:      "delete, including CRDs",
+			operation: rbac.OperationDelete,
+			objs:      rbacTestProviderObjs,
+			run: func(g *WithT, proxy *test.FakeProxy, _ *test.CallRecorder) {
+				options := DeleteOptions{Provider: newRBACTestComponents().InventoryObject(), IncludeCRDs: true}
+				g.Expect(newComponentsClient(proxy).Delete(options)).To(Succeed())
+			},
+		},
+		{
+			name:      "delete, including CRDs and namespace",
+			operation: rbac.OperationDelete,
+			objs:      rbacTestProviderObjs,
+			run: func(g *WithT, proxy *test.FakeProxy, _ *test.CallRecorder) {
+				options := DeleteOptions{Provider: newRBACTestComponents().InventoryObject(), IncludeCRDs: true, IncludeNamespace: true}
+				g.Expect(newComponentsClient(proxy).Delete(options)).To(Succeed())
+			},
+		},
+		{
+			name:      "move",
+			operation: rbac.OperationMove,
+			apiGroups: []string{"external.cluster.x-k8s.io"},
+			objs: func() []client.Object {
+				objs := []client.Object{}
+				for _, crd := range test.FakeCRDList() {
+					objs = append(objs, crd)
+				}
+				objs = append(objs, test.NewFakeCluster("ns1", "foo").WithMachineDeployments(
+					test.NewFakeMachineDeployment("md1").
+						WithMachineSets(test.NewFakeMachineSet("ms1").WithMachines(test.NewFakeMachine("m1"))),
+				).Objs()...)
+				objs = append(objs, test.NewFakeClusterResourceSet("ns1", "crs1").
+					WithSecret("resource-s1").
+					WithConfigMap("resource-c1").
+					ApplyToCluster(test.SelectClusterObj(objs, "ns1", "foo")).
+					Objs()...)
+
+				// The provisioning is completed, so the Cluster can be moved.
+				for _, o := range objs {
+					switch o := o.(type) {
+					case *clusterv1.Cluster:
+						o.Status.InfrastructureReady = true
+						conditions.MarkTrue(o, clusterv1.ControlPlaneInitializedCondition)
+					case *clusterv1.Machine:
+						o.Status.NodeRef = &corev1.ObjectReference{Kind: "Node", Name: o.Name}
+					}
+				}
+				return objs
+			},
+			run: func(g *WithT, proxy *test.FakeProxy, recorder *test.CallRecorder) {
+				// The same rules are used on the source and on the target management cluster.
+				toProxy := getFakeProxyWithCRDs().WithCallRecorder(recorder)
+
+				mover := newObjectMover(nil, proxy, newInventoryClient(proxy, nil))
+				g.Expect(mover.checkTargetProviders("ns1", newInventoryClient(toProxy, nil))).To(Succeed())
+
+				graph := newObjectGraph(proxy)
+				g.Expect(getFakeDiscoveryTypes(graph)).To(Succeed())
+				g.Expect(graph.Discovery("ns1")).To(Succeed())
+				g.Expect(graph.filterCluster("ns1", "foo")).To(Succeed())
+				g.Expect(graph.checkReferences()).To(Succeed())
+				g.Expect(mover.checkProvisioningCompleted(graph)).To(Succeed())
+				g.Expect(mover.move(graph, toProxy)).To(Succeed())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			recorder := &test.CallRecorder{}
+			proxy := test.NewFakeProxy().WithCallRecorder(recorder)
+			if tt.objs != nil {
+				proxy.WithObjs(tt.objs()...)
+			}
+
+			tt.run(g, proxy, recorder)
+			g.Expect(recorder.Calls()).NotTo(BeEmpty())
+
+			roles, err := rbac.Generate(rbac.Options{Operations: []rbac.Operation{tt.operation}, AdditionalAPIGroups: tt.apiGroups})
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(recorder.NotAllowed(roles)).To(BeEmpty())
+		})
+	}
+}
+
+func newRBACTestCertManagerClient(g *WithT, proxy Proxy) *certManagerClient {
+	configClient, err := config.New("", config.InjectReader(test.NewFakeReader()))
+	g.Expect(err).NotTo(HaveOccurred())
+	cm, err := newCertManagerClient(configClient, proxy, fakePollImmediateWaiter)
+	g.Expect(err).NotTo(HaveOccurred())
+	return cm
+}
+
+// newRBACTestComponents returns the components of a provider, including all the kinds of objects usually included
+// in the provider components.
+func newRBACTestComponents() repository.Components {
+	inventoryObject := fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v1.0.0", "infra-system", "")
+	inventoryObject.ResourceVersion = ""
+
+	obj := func(apiVersion, kind, namespace, name string) unstructured.Unstructured {
+		u := unstructured.Unstructured{}
+		u.SetAPIVersion(apiVersion)
+		u.SetKind(kind)
+		u.SetNamespace(namespace)
+		u.SetName(name)
+		return u
+	}
+
+	crd := obj("apiextensions.k8s.io/v1", "CustomResourceDefinition", "", "genericinfrastructureclusters.infrastructure.cluster.x-k8s.io")
+	crd.Object["spec"] = map[string]interface{}{
+		"group": "infrastructure.cluster.x-k8s.io",
+		"names": map[string]interface{}{"kind": "GenericInfrastructureCluster"},
+		"versions": []interface{}{
+			map[string]interface{}{"name": "v1alpha4", "storage": true},
+		},
+	}
+
+	return &fakeComponents{
+		Provider:        config.NewProvider(inventoryObject.ProviderName, "", clusterctlv1.ProviderType(inventoryObject.Type)),
+		inventoryObject: inventoryObject,
+		instanceObjs: []unstructured.Unstructured{
+			obj("v1", "Namespace", "", "infra-system"),
+			obj("v1", "ServiceAccount", "infra-system", "infra-manager"),
+			obj("v1", "Secret", "infra-system", "infra-credentials"),
+			obj("v1", "ConfigMap", "infra-system", "infra-config"),
+			obj("v1", "Service", "infra-system", "infra-webhook-service"),
+			obj("apps/v1", "Deployment", "infra-system", "infra-controller-manager"),
+			obj("rbac.authorization.k8s.io/v1", "Role", "infra-system", "infra-leader-election-role"),
+			obj("rbac.authorization.k8s.io/v1", "RoleBinding", "infra-system", "infra-leader-election-rolebinding"),
+			obj("rbac.authorization.k8s.io/v1", "ClusterRole", "", "infra-system-infra-manager-role"),
+			obj("rbac.authorization.k8s.io/v1", "ClusterRoleBinding", "", "infra-system-infra-manager-rolebinding"),
+			obj("cert-manager.io/v1", "Issuer", "infra-system", "infra-selfsigned-issuer"),
+			obj("cert-manager.io/v1", "Certificate", "infra-system", "infra-serving-cert"),
+		},
+		sharedObjs: []unstructured.Unstructured{
+			crd,
+			obj("admissionregistration.k8s.io/v1", "MutatingWebhookConfiguration", "", "infra-mutating-webhook-configuration"),
+			obj("admissionregistration.k8s.io/v1", "ValidatingWebhookConfiguration", "", "infra-validating-webhook-configuration"),
+		},
+	}
+}
+
+// rbacTestProviderObjs returns the objects of a provider installed in the management cluster.
+func rbacTestProviderObjs() []client.Object {
+	components := newRBACTestComponents()
+	inventoryObject := components.InventoryObject()
+
+	objs := []client.Object{&inventoryObject}
+	for _, o := range append(components.InstanceObjs(), components.SharedObjs()...) {
+		o := o
+		labels := map[string]string{
+			clusterctlv1.ClusterctlLabelName: "",
+			clusterv1.ProviderLabelName:      inventoryObject.ManifestLabel(),
+		}
+		if o.GetKind() == "CustomResourceDefinition" || o.GetKind() == "MutatingWebhookConfiguration" || o.GetKind() == "ValidatingWebhookConfiguration" {
+			labels[clusterctlv1.ClusterctlResourceLifecyleLabelName] = string(clusterctlv1.ResourceLifecycleShared)
+		}
+		o.SetLabels(labels)
+		if o.GetKind() == "Namespace" {
+			o.SetAnnotations(map[string]string{clusterctlv1.ClusterctlNamespaceCreatedAnnotation: ""})
+		}
+		objs = append(objs, &o)
+	}
+	return objs
+}
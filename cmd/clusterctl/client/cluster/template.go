@@ -28,10 +28,12 @@ import (
 	"github.com/pkg/errors"
 	"golang.org/x/oauth2"
 	corev1 "k8s.io/api/core/v1"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
 	yaml "sigs.k8s.io/cluster-api/cmd/clusterctl/client/yamlprocessor"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	sigsyaml "sigs.k8s.io/yaml"
 )
 
 // TemplateClient has methods to work with templates stored in the cluster/out of the provider repository.
@@ -39,10 +41,34 @@ type TemplateClient interface {
 	// GetFromConfigMap returns a workload cluster template from the given ConfigMap.
 	GetFromConfigMap(namespace, name, dataKey, targetNamespace string, listVariablesOnly bool) (repository.Template, error)
 
+	// GetOverlayFromConfigMap returns the overlay for the workload cluster template stored in the given ConfigMap
+	// for an environment, read from the ConfigMap with the matching template and environment labels.
+	GetOverlayFromConfigMap(namespace, name, environment, dataKey string) (*TemplateOverlay, error)
+
+	// GetFromConfigMapWithOverlay returns a workload cluster template from the given ConfigMap, with the objects
+	// of the overlay merged into the template objects.
+	GetFromConfigMapWithOverlay(namespace, name, dataKey string, overlay *TemplateOverlay, targetNamespace string, listVariablesOnly bool) (repository.Template, error)
+
 	// GetFromURL returns a workload cluster template from the given URL.
 	GetFromURL(templateURL, targetNamespace string, listVariablesOnly bool) (repository.Template, error)
 }
 
+// TemplateOverlayVariablesKey is the ConfigMap.Data key of a template overlay where the values of the template
+// variables for the environment are stored, as a YAML map.
+const TemplateOverlayVariablesKey = "variables"
+
+// TemplateOverlay defines the environment specific customizations of a workload cluster template stored in a ConfigMap.
+type TemplateOverlay struct {
+	// Name of the ConfigMap the overlay has been read from.
+	Name string
+
+	// Variables are the values of the template variables for the environment.
+	Variables map[string]string
+
+	// RawTemplate contains the objects to be merged into the template objects, if any.
+	RawTemplate []byte
+}
+
 // templateClient implements TemplateClient.
 type templateClient struct {
 	proxy               Proxy
@@ -71,6 +97,10 @@ func newTemplateClient(input TemplateClientInput) *templateClient {
 }
 
 func (t *templateClient) GetFromConfigMap(configMapNamespace, configMapName, configMapDataKey, targetNamespace string, listVariablesOnly bool) (repository.Template, error) {
+	return t.GetFromConfigMapWithOverlay(configMapNamespace, configMapName, configMapDataKey, nil, targetNamespace, listVariablesOnly)
+}
+
+func (t *templateClient) GetFromConfigMapWithOverlay(configMapNamespace, configMapName, configMapDataKey string, overlay *TemplateOverlay, targetNamespace string, listVariablesOnly bool) (repository.Template, error) {
 	if configMapNamespace == "" {
 		return nil, errors.New("invalid GetFromConfigMap operation: missing configMapNamespace value")
 	}
@@ -98,15 +128,60 @@ func (t *templateClient) GetFromConfigMap(configMapNamespace, configMapName, con
 		return nil, errors.Errorf("the ConfigMap %s/%s does not have the %q data key", configMapNamespace, configMapName, configMapDataKey)
 	}
 
+	var rawOverlay []byte
+	if overlay != nil {
+		rawOverlay = overlay.RawTemplate
+	}
+
 	return repository.NewTemplate(repository.TemplateInput{
 		RawArtifact:           []byte(data),
 		ConfigVariablesClient: t.configClient.Variables(),
 		Processor:             t.processor,
 		TargetNamespace:       targetNamespace,
 		ListVariablesOnly:     listVariablesOnly,
+		RawOverlay:            rawOverlay,
 	})
 }
 
+func (t *templateClient) GetOverlayFromConfigMap(configMapNamespace, configMapName, environment, configMapDataKey string) (*TemplateOverlay, error) {
+	if environment == "" {
+		return nil, errors.New("invalid GetOverlayFromConfigMap operation: missing environment value")
+	}
+
+	c, err := t.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	configMaps := &corev1.ConfigMapList{}
+	if err := c.List(ctx, configMaps, client.InNamespace(configMapNamespace), client.MatchingLabels{
+		clusterctlv1.ClusterctlTemplateLabelName:            configMapName,
+		clusterctlv1.ClusterctlTemplateEnvironmentLabelName: environment,
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to list the overlays for the template in ConfigMap %s/%s", configMapNamespace, configMapName)
+	}
+	switch len(configMaps.Items) {
+	case 0:
+		return nil, errors.Errorf("failed to find the %q overlay for the template in ConfigMap %s/%s: no ConfigMap has the labels %s=%s and %s=%s",
+			environment, configMapNamespace, configMapName, clusterctlv1.ClusterctlTemplateLabelName, configMapName, clusterctlv1.ClusterctlTemplateEnvironmentLabelName, environment)
+	case 1:
+	default:
+		return nil, errors.Errorf("found %d ConfigMaps with the %q overlay for the template in ConfigMap %s/%s, only one is allowed", len(configMaps.Items), environment, configMapNamespace, configMapName)
+	}
+
+	configMap := configMaps.Items[0]
+	overlay := &TemplateOverlay{
+		Name:        configMap.Name,
+		RawTemplate: []byte(configMap.Data[configMapDataKey]),
+	}
+	if variables, ok := configMap.Data[TemplateOverlayVariablesKey]; ok {
+		if err := sigsyaml.Unmarshal([]byte(variables), &overlay.Variables); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the %q data key of the overlay ConfigMap %s/%s", TemplateOverlayVariablesKey, configMapNamespace, configMap.Name)
+		}
+	}
+	return overlay, nil
+}
+
 func (t *templateClient) GetFromURL(templateURL, targetNamespace string, listVariablesOnly bool) (repository.Template, error) {
 	if templateURL == "" {
 		return nil, errors.New("invalid GetFromURL operation: missing templateURL value")
@@ -0,0 +1,355 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// restartedAtAnnotation is the annotation triggering a rollout of a MachineDeployment when changed
+	// in spec.template.metadata, as set by clusterctl alpha rollout restart.
+	restartedAtAnnotation = "cluster.x-k8s.io/restartedAt"
+
+	// templateCloneHashLength is the length of the suffix of the name of the cloned templates.
+	templateCloneHashLength = 8
+)
+
+// TemplateRotationAction is the action performed by TemplateRotator.Rotate for an object referencing an infrastructure machine template.
+type TemplateRotationAction string
+
+const (
+	// TemplateRotationRecorded is the action of recording the hash of a template the first time the object is checked,
+	// or after the object is changed to reference another template, given that in both cases there is no template change to detect.
+	TemplateRotationRecorded = TemplateRotationAction("Recorded")
+
+	// TemplateRotationUpToDate is the action of an object whose template did not change since the last check.
+	TemplateRotationUpToDate = TemplateRotationAction("UpToDate")
+
+	// TemplateRotationRestarted is the action of triggering a rollout of the Machines of an object, because its template changed.
+	TemplateRotationRestarted = TemplateRotationAction("Restarted")
+
+	// TemplateRotationCloned is the action of cloning a changed template into a new template, and changing the object to
+	// reference the clone, which in turn triggers a rollout of the Machines of the object.
+	TemplateRotationCloned = TemplateRotationAction("Cloned")
+)
+
+// TemplateRotationOptions carries the options supported by TemplateRotator.Rotate.
+type TemplateRotationOptions struct {
+	// Namespace where the Cluster is located.
+	Namespace string
+
+	// ClusterName is the name of the Cluster.
+	ClusterName string
+
+	// Clone clones the changed templates into new templates, named after the hash of their spec, and changes the objects to
+	// reference the clones, instead of triggering a rollout of the Machines using the changed templates.
+	Clone bool
+
+	// DryRun reports the actions to be performed without changing any object.
+	DryRun bool
+}
+
+// TemplateRotation describes the action performed for an object referencing an infrastructure machine template.
+type TemplateRotation struct {
+	// Object is the object referencing the template, i.e. a MachineDeployment or a control plane.
+	Object corev1.ObjectReference
+
+	// Template is the infrastructure machine template referenced by the object.
+	Template corev1.ObjectReference
+
+	// Action is the action performed, or to be performed in case of dry run.
+	Action TemplateRotationAction
+
+	// ClonedTemplate is the name of the template cloned from Template, if Action is TemplateRotationCloned.
+	ClonedTemplate string
+}
+
+// TemplateRotator defines methods for rolling out Machines when the infrastructure machine templates they are created from change.
+type TemplateRotator interface {
+	// Rotate detects the changes to the infrastructure machine templates referenced by the control plane and by the
+	// MachineDeployments of a Cluster since the last check, as recorded in an annotation of the referencing object,
+	// and triggers a rollout for the changed templates, i.e.
+	// - by setting the restartedAt annotation in the Machine template of MachineDeployments, or spec.upgradeAfter for KubeadmControlPlanes;
+	// - or, if options.Clone is set, by cloning the changed template and changing the object to reference the clone, so templates
+	//   are never mutated after being used.
+	// The first check of an object only records the template hash, because there is no previous state to compare with.
+	Rotate(options TemplateRotationOptions) ([]TemplateRotation, error)
+}
+
+// templateRotator implements TemplateRotator.
+type templateRotator struct {
+	proxy Proxy
+}
+
+// ensure templateRotator implements the TemplateRotator interface.
+var _ TemplateRotator = &templateRotator{}
+
+// newTemplateRotator returns a templateRotator.
+func newTemplateRotator(proxy Proxy) *templateRotator {
+	return &templateRotator{
+		proxy: proxy,
+	}
+}
+
+// templateOwner is an object referencing an infrastructure machine template.
+type templateOwner struct {
+	obj *unstructured.Unstructured
+
+	// refPath is the path of the reference to the template in the object.
+	refPath []string
+}
+
+func (r *templateRotator) Rotate(options TemplateRotationOptions) ([]TemplateRotation, error) {
+	c, err := r.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	owners, err := getTemplateOwners(c, options.Namespace, options.ClusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	rotations := make([]TemplateRotation, 0, len(owners))
+	for _, owner := range owners {
+		rotation, err := rotateTemplate(c, owner, options)
+		if err != nil {
+			return rotations, err
+		}
+		rotations = append(rotations, *rotation)
+	}
+	return rotations, nil
+}
+
+// getTemplateOwners returns the control plane of a Cluster, if it references an infrastructure machine template,
+// followed by the MachineDeployments of the Cluster sorted by name.
+func getTemplateOwners(c client.Client, namespace, clusterName string) ([]templateOwner, error) {
+	cluster := &clusterv1.Cluster{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: clusterName}, cluster); err != nil {
+		return nil, errors.Wrapf(err, "failed to get Cluster %s/%s", namespace, clusterName)
+	}
+
+	owners := []templateOwner{}
+	if ref := cluster.Spec.ControlPlaneRef; ref != nil {
+		controlPlane := &unstructured.Unstructured{}
+		controlPlane.SetAPIVersion(ref.APIVersion)
+		controlPlane.SetKind(ref.Kind)
+		if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, controlPlane); err != nil {
+			return nil, errors.Wrapf(err, "failed to get %s %s/%s", ref.Kind, namespace, ref.Name)
+		}
+		// NB. Only control planes managing Machines created from an infrastructure machine template, like KubeadmControlPlane, are considered.
+		if _, ok, _ := unstructured.NestedMap(controlPlane.Object, "spec", "infrastructureTemplate"); ok {
+			owners = append(owners, templateOwner{obj: controlPlane, refPath: []string{"spec", "infrastructureTemplate"}})
+		}
+	}
+
+	machineDeployments := &unstructured.UnstructuredList{}
+	machineDeployments.SetAPIVersion(clusterv1.GroupVersion.String())
+	machineDeployments.SetKind("MachineDeploymentList")
+	if err := c.List(ctx, machineDeployments, client.InNamespace(namespace)); err != nil {
+		return nil, errors.Wrapf(err, "failed to list MachineDeployments in namespace %s", namespace)
+	}
+	sort.Slice(machineDeployments.Items, func(i, j int) bool {
+		return machineDeployments.Items[i].GetName() < machineDeployments.Items[j].GetName()
+	})
+	for i := range machineDeployments.Items {
+		md := &machineDeployments.Items[i]
+		if name, _, _ := unstructured.NestedString(md.Object, "spec", "clusterName"); name != clusterName {
+			continue
+		}
+		owners = append(owners, templateOwner{obj: md, refPath: []string{"spec", "template", "spec", "infrastructureRef"}})
+	}
+	return owners, nil
+}
+
+// rotateTemplate compares the hash of the template referenced by an object with the hash recorded in the object,
+// and triggers a rollout if they do not match.
+func rotateTemplate(c client.Client, owner templateOwner, options TemplateRotationOptions) (*TemplateRotation, error) {
+	log := logf.Log
+
+	ref, err := getTemplateRef(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &unstructured.Unstructured{}
+	template.SetAPIVersion(ref.APIVersion)
+	template.SetKind(ref.Kind)
+	if err := c.Get(ctx, client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, template); err != nil {
+		return nil, errors.Wrapf(err, "failed to get %s %s/%s", ref.Kind, ref.Namespace, ref.Name)
+	}
+	hash, err := templateHash(template)
+	if err != nil {
+		return nil, err
+	}
+
+	rotation := &TemplateRotation{
+		Object: corev1.ObjectReference{
+			APIVersion: owner.obj.GetAPIVersion(),
+			Kind:       owner.obj.GetKind(),
+			Namespace:  owner.obj.GetNamespace(),
+			Name:       owner.obj.GetName(),
+		},
+		Template: ref,
+	}
+
+	recordedName, recordedHash := "", ""
+	if value, ok := owner.obj.GetAnnotations()[clusterctlv1.ClusterctlInfrastructureTemplateHashAnnotation]; ok {
+		if i := strings.LastIndex(value, "/"); i >= 0 {
+			recordedName, recordedHash = value[:i], value[i+1:]
+		}
+	}
+
+	switch {
+	case recordedName != ref.Name:
+		rotation.Action = TemplateRotationRecorded
+	case recordedHash == hash:
+		rotation.Action = TemplateRotationUpToDate
+		return rotation, nil
+	case options.Clone:
+		rotation.Action = TemplateRotationCloned
+		rotation.ClonedTemplate = cloneTemplateName(template, hash)
+	default:
+		rotation.Action = TemplateRotationRestarted
+	}
+
+	log.Info(fmt.Sprintf("%s %s", rotation.Action, describeRef(rotation.Object)), "Template", ref.Name, "ClonedTemplate", rotation.ClonedTemplate)
+	if options.DryRun {
+		return rotation, nil
+	}
+
+	patchBase := client.MergeFrom(owner.obj.DeepCopy())
+	templateName := ref.Name
+	switch rotation.Action {
+	case TemplateRotationCloned:
+		if err := createTemplateClone(c, template, rotation.ClonedTemplate); err != nil {
+			return nil, err
+		}
+		templateName = rotation.ClonedTemplate
+		if err := unstructured.SetNestedField(owner.obj.Object, templateName, append(owner.refPath, "name")...); err != nil {
+			return nil, err
+		}
+	case TemplateRotationRestarted:
+		if err := setRestart(owner.obj); err != nil {
+			return nil, err
+		}
+	}
+
+	annotations := owner.obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[clusterctlv1.ClusterctlInfrastructureTemplateHashAnnotation] = fmt.Sprintf("%s/%s", templateName, hash)
+	owner.obj.SetAnnotations(annotations)
+
+	if err := c.Patch(ctx, owner.obj, patchBase); err != nil {
+		return nil, errors.Wrapf(err, "failed to patch %s", describeRef(rotation.Object))
+	}
+	return rotation, nil
+}
+
+// getTemplateRef returns the reference to the infrastructure machine template of an object, defaulting the namespace
+// to the namespace of the object.
+func getTemplateRef(owner templateOwner) (corev1.ObjectReference, error) {
+	refMap, ok, err := unstructured.NestedMap(owner.obj.Object, owner.refPath...)
+	if err != nil || !ok {
+		return corev1.ObjectReference{}, errors.Errorf("failed to get the infrastructure machine template of %s %s/%s",
+			owner.obj.GetKind(), owner.obj.GetNamespace(), owner.obj.GetName())
+	}
+
+	ref := corev1.ObjectReference{}
+	ref.APIVersion, _ = refMap["apiVersion"].(string)
+	ref.Kind, _ = refMap["kind"].(string)
+	ref.Name, _ = refMap["name"].(string)
+	ref.Namespace, _ = refMap["namespace"].(string)
+	if ref.Namespace == "" {
+		ref.Namespace = owner.obj.GetNamespace()
+	}
+	return ref, nil
+}
+
+// templateHash returns the hash of the spec of a template.
+func templateHash(template *unstructured.Unstructured) (string, error) {
+	spec, err := json.Marshal(template.Object["spec"])
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to marshal the spec of %s %s/%s", template.GetKind(), template.GetNamespace(), template.GetName())
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(spec))[:16], nil
+}
+
+// cloneTemplateName returns the name of the clone of a template, i.e. the name of the template the template itself
+// was cloned from, if any, or the name of the template, followed by the hash of the spec.
+func cloneTemplateName(template *unstructured.Unstructured, hash string) string {
+	name := template.GetName()
+	if clonedFrom, ok := template.GetAnnotations()[clusterctlv1.ClusterctlClonedFromAnnotation]; ok {
+		name = clonedFrom
+	}
+	return fmt.Sprintf("%s-%s", name, hash[:templateCloneHashLength])
+}
+
+// createTemplateClone creates a copy of a template with the given name, preserving its labels, annotations and OwnerReferences.
+func createTemplateClone(c client.Client, template *unstructured.Unstructured, name string) error {
+	clone := template.DeepCopy()
+	for _, field := range []string{"uid", "resourceVersion", "generation", "creationTimestamp", "selfLink", "managedFields"} {
+		unstructured.RemoveNestedField(clone.Object, "metadata", field)
+	}
+	unstructured.RemoveNestedField(clone.Object, "status")
+	clone.SetName(name)
+
+	annotations := clone.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	if _, ok := annotations[clusterctlv1.ClusterctlClonedFromAnnotation]; !ok {
+		annotations[clusterctlv1.ClusterctlClonedFromAnnotation] = template.GetName()
+	}
+	clone.SetAnnotations(annotations)
+
+	if err := c.Create(ctx, clone); err != nil && !apierrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "failed to create %s %s/%s", clone.GetKind(), clone.GetNamespace(), clone.GetName())
+	}
+	return nil
+}
+
+// setRestart triggers a rollout of the Machines of an object, by setting the restartedAt annotation in the Machine template
+// of a MachineDeployment or spec.upgradeAfter of a KubeadmControlPlane.
+func setRestart(obj *unstructured.Unstructured) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	switch obj.GetKind() {
+	case "MachineDeployment":
+		return unstructured.SetNestedField(obj.Object, now, "spec", "template", "metadata", "annotations", restartedAtAnnotation)
+	case "KubeadmControlPlane":
+		return unstructured.SetNestedField(obj.Object, now, "spec", "upgradeAfter")
+	default:
+		return errors.Errorf("triggering a rollout of %s %s/%s is not supported, clone the template instead", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+	}
+}
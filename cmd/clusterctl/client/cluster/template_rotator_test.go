@@ -0,0 +1,207 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	fakecontrolplane "sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test/providers/controlplane"
+	fakeinfrastructure "sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test/providers/infrastructure"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_templateRotator_Rotate(t *testing.T) {
+	g := NewWithT(t)
+
+	objs := test.NewFakeCluster("ns1", "cluster1").
+		WithControlPlane(test.NewFakeControlPlane("cp1")).
+		WithMachineDeployments(test.NewFakeMachineDeployment("md2"), test.NewFakeMachineDeployment("md1")).
+		Objs()
+	objs = append(objs, test.NewFakeCluster("ns1", "cluster2").
+		WithMachineDeployments(test.NewFakeMachineDeployment("other")).
+		Objs()...)
+	proxy := test.NewFakeProxy().WithObjs(objs...)
+	r := newTemplateRotator(proxy)
+
+	// The first check only records the hash of the templates, starting from the control plane;
+	// the MachineDeployments of other Clusters are ignored.
+	rotations, err := r.Rotate(TemplateRotationOptions{Namespace: "ns1", ClusterName: "cluster1"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(rotationActions(rotations)).To(Equal([]string{"cp1=Recorded", "md1=Recorded", "md2=Recorded"}))
+
+	md1 := getMachineDeployment(g, proxy, "md1")
+	g.Expect(md1.Annotations).To(HaveKey(clusterctlv1.ClusterctlInfrastructureTemplateHashAnnotation))
+	recorded := md1.Annotations[clusterctlv1.ClusterctlInfrastructureTemplateHashAnnotation]
+
+	// Without changes, the objects are up to date.
+	rotations, err = r.Rotate(TemplateRotationOptions{Namespace: "ns1", ClusterName: "cluster1"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(rotationActions(rotations)).To(Equal([]string{"cp1=UpToDate", "md1=UpToDate", "md2=UpToDate"}))
+
+	setRecordedHash(g, proxy, "md1", "md1/0000000000000000")
+	setRecordedHash(g, proxy, "md2", "md2/0000000000000000")
+
+	// A dry run does not change anything.
+	rotations, err = r.Rotate(TemplateRotationOptions{Namespace: "ns1", ClusterName: "cluster1", DryRun: true})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(rotationActions(rotations)).To(Equal([]string{"cp1=UpToDate", "md1=Restarted", "md2=Restarted"}))
+	md1 = getMachineDeployment(g, proxy, "md1")
+	g.Expect(md1.Spec.Template.Annotations).ToNot(HaveKey(restartedAtAnnotation))
+
+	// A rollout is triggered for the changed templates, and the new hash is recorded.
+	rotations, err = r.Rotate(TemplateRotationOptions{Namespace: "ns1", ClusterName: "cluster1"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(rotationActions(rotations)).To(Equal([]string{"cp1=UpToDate", "md1=Restarted", "md2=Restarted"}))
+	md1 = getMachineDeployment(g, proxy, "md1")
+	g.Expect(md1.Spec.Template.Annotations).To(HaveKey(restartedAtAnnotation))
+	g.Expect(md1.Spec.Template.Spec.InfrastructureRef.Name).To(Equal("md1"))
+	g.Expect(md1.Annotations).To(HaveKeyWithValue(clusterctlv1.ClusterctlInfrastructureTemplateHashAnnotation, recorded))
+
+	// A rollout cannot be triggered for control planes other than KubeadmControlPlane.
+	setRecordedHash(g, proxy, "cp1", "cp1/0000000000000000")
+	_, err = r.Rotate(TemplateRotationOptions{Namespace: "ns1", ClusterName: "cluster1"})
+	g.Expect(err).To(HaveOccurred())
+
+	// With clone, the changed templates are cloned and the objects reference the clones.
+	setRecordedHash(g, proxy, "md1", "md1/0000000000000000")
+	rotations, err = r.Rotate(TemplateRotationOptions{Namespace: "ns1", ClusterName: "cluster1", Clone: true})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(rotationActions(rotations)).To(Equal([]string{"cp1=Cloned", "md1=Cloned", "md2=UpToDate"}))
+
+	cp1 := &fakecontrolplane.GenericControlPlane{}
+	c, err := proxy.NewClient()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: "cp1"}, cp1)).To(Succeed())
+	g.Expect(cp1.Spec.InfrastructureTemplate.Name).To(Equal(rotations[0].ClonedTemplate))
+
+	clonedName := rotations[1].ClonedTemplate
+	g.Expect(clonedName).To(HavePrefix("md1-"))
+	md1 = getMachineDeployment(g, proxy, "md1")
+	g.Expect(md1.Spec.Template.Spec.InfrastructureRef.Name).To(Equal(clonedName))
+	g.Expect(md1.Annotations).To(HaveKeyWithValue(clusterctlv1.ClusterctlInfrastructureTemplateHashAnnotation, clonedName+"/"+recorded[len("md1/"):]))
+
+	clone := &fakeinfrastructure.GenericInfrastructureMachineTemplate{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: clonedName}, clone)).To(Succeed())
+	g.Expect(clone.Annotations).To(HaveKeyWithValue(clusterctlv1.ClusterctlClonedFromAnnotation, "md1"))
+	g.Expect(clone.OwnerReferences).ToNot(BeEmpty())
+
+	// Once the clone is referenced, the MachineDeployment is up to date.
+	rotations, err = r.Rotate(TemplateRotationOptions{Namespace: "ns1", ClusterName: "cluster1", Clone: true})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(rotationActions(rotations)).To(Equal([]string{"cp1=UpToDate", "md1=UpToDate", "md2=UpToDate"}))
+}
+
+func Test_cloneTemplateName(t *testing.T) {
+	g := NewWithT(t)
+
+	template := &unstructured.Unstructured{}
+	template.SetName("worker")
+	g.Expect(cloneTemplateName(template, "0123456789abcdef")).To(Equal("worker-01234567"))
+
+	// Clones of clones are named after the original template, so the names do not grow at each rotation.
+	template.SetName("worker-01234567")
+	template.SetAnnotations(map[string]string{clusterctlv1.ClusterctlClonedFromAnnotation: "worker"})
+	g.Expect(cloneTemplateName(template, "fedcba9876543210")).To(Equal("worker-fedcba98"))
+}
+
+func Test_templateHash(t *testing.T) {
+	g := NewWithT(t)
+
+	template := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{"instanceType": "m5.large"}}},
+	}}
+	hash, err := templateHash(template)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(hash).To(HaveLen(16))
+
+	// Changes to the metadata do not change the hash.
+	template.SetLabels(map[string]string{"foo": "bar"})
+	g.Expect(templateHash(template)).To(Equal(hash))
+
+	// Changes to the spec change the hash.
+	g.Expect(unstructured.SetNestedField(template.Object, "m5.xlarge", "spec", "template", "spec", "instanceType")).To(Succeed())
+	g.Expect(templateHash(template)).ToNot(Equal(hash))
+}
+
+func Test_setRestart(t *testing.T) {
+	tests := []struct {
+		kind    string
+		path    []string
+		wantErr bool
+	}{
+		{kind: "MachineDeployment", path: []string{"spec", "template", "metadata", "annotations", restartedAtAnnotation}},
+		{kind: "KubeadmControlPlane", path: []string{"spec", "upgradeAfter"}},
+		{kind: "GenericControlPlane", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &unstructured.Unstructured{}
+			obj.SetKind(tt.kind)
+			err := setRestart(obj)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+
+			value, ok, err := unstructured.NestedString(obj.Object, tt.path...)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(ok).To(BeTrue())
+			g.Expect(value).ToNot(BeEmpty())
+		})
+	}
+}
+
+func rotationActions(rotations []TemplateRotation) []string {
+	actions := make([]string, 0, len(rotations))
+	for _, r := range rotations {
+		actions = append(actions, r.Object.Name+"="+string(r.Action))
+	}
+	return actions
+}
+
+func getMachineDeployment(g *WithT, proxy Proxy, name string) *clusterv1.MachineDeployment {
+	c, err := proxy.NewClient()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	md := &clusterv1.MachineDeployment{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: name}, md)).To(Succeed())
+	return md
+}
+
+// setRecordedHash simulates a change to the template of an object, by changing the hash recorded in the object.
+func setRecordedHash(g *WithT, proxy Proxy, name, value string) {
+	c, err := proxy.NewClient()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	var obj client.Object = &clusterv1.MachineDeployment{}
+	if name == "cp1" {
+		obj = &fakecontrolplane.GenericControlPlane{}
+	}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: name}, obj)).To(Succeed())
+	annotations := obj.GetAnnotations()
+	annotations[clusterctlv1.ClusterctlInfrastructureTemplateHashAnnotation] = value
+	obj.SetAnnotations(annotations)
+	g.Expect(c.Update(ctx, obj)).To(Succeed())
+}
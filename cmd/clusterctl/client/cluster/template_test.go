@@ -30,6 +30,7 @@ import (
 	"github.com/google/go-github/v33/github"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
 	yaml "sigs.k8s.io/cluster-api/cmd/clusterctl/client/yamlprocessor"
@@ -155,6 +156,77 @@ func Test_templateClient_GetFromConfigMap(t *testing.T) {
 	}
 }
 
+func Test_templateClient_GetOverlayFromConfigMap(t *testing.T) {
+	overlay := func(name, environment string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "ConfigMap",
+				APIVersion: "v1",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns1",
+				Name:      name,
+				Labels: map[string]string{
+					clusterctlv1.ClusterctlTemplateLabelName:            "my-template",
+					clusterctlv1.ClusterctlTemplateEnvironmentLabelName: environment,
+				},
+			},
+			Data: map[string]string{
+				"template":                  "kind: Machine",
+				TemplateOverlayVariablesKey: "WORKER_MACHINE_COUNT: \"5\"\nZONE: eu-west-1a",
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		proxy       Proxy
+		environment string
+		want        *TemplateOverlay
+		wantErr     bool
+	}{
+		{
+			name:        "Return the overlay for the environment",
+			proxy:       test.NewFakeProxy().WithObjs(overlay("my-template-prod", "prod"), overlay("my-template-dev", "dev")),
+			environment: "prod",
+			want: &TemplateOverlay{
+				Name:        "my-template-prod",
+				Variables:   map[string]string{"WORKER_MACHINE_COUNT": "5", "ZONE": "eu-west-1a"},
+				RawTemplate: []byte("kind: Machine"),
+			},
+		},
+		{
+			name:        "Overlay for the environment does not exist",
+			proxy:       test.NewFakeProxy().WithObjs(overlay("my-template-dev", "dev")),
+			environment: "prod",
+			wantErr:     true,
+		},
+		{
+			name:        "More than one overlay for the environment",
+			proxy:       test.NewFakeProxy().WithObjs(overlay("my-template-prod", "prod"), overlay("my-template-prod2", "prod")),
+			environment: "prod",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			configClient, err := config.New("", config.InjectReader(test.NewFakeReader()))
+			g.Expect(err).NotTo(HaveOccurred())
+
+			c := newTemplateClient(TemplateClientInput{tt.proxy, configClient, nil})
+			got, err := c.GetOverlayFromConfigMap("ns1", "my-template", tt.environment, "template")
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
 func Test_templateClient_getGitHubFileContent(t *testing.T) {
 	g := NewWithT(t)
 
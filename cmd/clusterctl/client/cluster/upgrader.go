@@ -18,6 +18,7 @@ package cluster
 
 import (
 	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/version"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
@@ -37,10 +38,17 @@ type ProviderUpgrader interface {
 	Plan() ([]UpgradePlan, error)
 
 	// ApplyPlan executes an upgrade following an UpgradePlan generated by clusterctl.
-	ApplyPlan(coreProvider clusterctlv1.Provider, clusterAPIVersion string) error
+	ApplyPlan(opts UpgradeOptions, coreProvider clusterctlv1.Provider, clusterAPIVersion string) error
 
 	// ApplyCustomPlan plan executes an upgrade using the UpgradeItems provided by the user.
-	ApplyCustomPlan(coreProvider clusterctlv1.Provider, providersToUpgrade ...UpgradeItem) error
+	ApplyCustomPlan(opts UpgradeOptions, coreProvider clusterctlv1.Provider, providersToUpgrade ...UpgradeItem) error
+}
+
+// UpgradeOptions defines the options used to configure an upgrade.
+type UpgradeOptions struct {
+	// SkipCompatibilityCheck instructs the upgrader to skip the check of the compatibility of the target versions
+	// of the providers with the version of the core provider and with the Kubernetes version of the management cluster.
+	SkipCompatibilityCheck bool
 }
 
 // UpgradePlan defines a list of possible upgrade targets for a management group.
@@ -80,6 +88,7 @@ func (u *UpgradeItem) UpgradeRef() string {
 type providerUpgrader struct {
 	configClient            config.Client
 	repositoryClientFactory RepositoryClientFactory
+	proxy                   Proxy
 	providerInventory       InventoryClient
 	providerComponents      ComponentsClient
 }
@@ -144,7 +153,7 @@ func (u *providerUpgrader) Plan() ([]UpgradePlan, error) {
 	return ret, nil
 }
 
-func (u *providerUpgrader) ApplyPlan(coreProvider clusterctlv1.Provider, contract string) error {
+func (u *providerUpgrader) ApplyPlan(opts UpgradeOptions, coreProvider clusterctlv1.Provider, contract string) error {
 	if contract != clusterv1.GroupVersion.Version {
 		return errors.Errorf("current version of clusterctl could only upgrade to %s contract, requested %s", clusterv1.GroupVersion.Version, contract)
 	}
@@ -165,10 +174,10 @@ func (u *providerUpgrader) ApplyPlan(coreProvider clusterctlv1.Provider, contrac
 	}
 
 	// Do the upgrade
-	return u.doUpgrade(upgradePlan)
+	return u.doUpgrade(opts, upgradePlan)
 }
 
-func (u *providerUpgrader) ApplyCustomPlan(coreProvider clusterctlv1.Provider, upgradeItems ...UpgradeItem) error {
+func (u *providerUpgrader) ApplyCustomPlan(opts UpgradeOptions, coreProvider clusterctlv1.Provider, upgradeItems ...UpgradeItem) error {
 	log := logf.Log
 	log.Info("Performing upgrade...")
 
@@ -180,7 +189,7 @@ func (u *providerUpgrader) ApplyCustomPlan(coreProvider clusterctlv1.Provider, u
 	}
 
 	// Do the upgrade
-	return u.doUpgrade(upgradePlan)
+	return u.doUpgrade(opts, upgradePlan)
 }
 
 // getUpgradePlan returns the upgrade plan for a specific managementGroup/contract
@@ -354,7 +363,7 @@ func (u *providerUpgrader) getUpgradeComponents(provider UpgradeItem) (repositor
 	return components, nil
 }
 
-func (u *providerUpgrader) doUpgrade(upgradePlan *UpgradePlan) error {
+func (u *providerUpgrader) doUpgrade(opts UpgradeOptions, upgradePlan *UpgradePlan) error {
 	// Check for multiple instances of the same provider if current contract is v1alpha3.
 	if upgradePlan.Contract == clusterv1.GroupVersion.Version {
 		if err := u.providerInventory.CheckSingleProviderInstance(); err != nil {
@@ -362,6 +371,14 @@ func (u *providerUpgrader) doUpgrade(upgradePlan *UpgradePlan) error {
 		}
 	}
 
+	// Check if the target versions of the providers are compatible with the target version of the core provider
+	// and with the Kubernetes version of the management cluster.
+	if !opts.SkipCompatibilityCheck {
+		if err := u.checkCompatibility(upgradePlan); err != nil {
+			return err
+		}
+	}
+
 	for _, upgradeItem := range upgradePlan.Providers {
 		// If there is not a specified next version, skip it (we are already up-to-date).
 		if upgradeItem.NextVersion == "" {
@@ -399,10 +416,69 @@ func (u *providerUpgrader) doUpgrade(upgradePlan *UpgradePlan) error {
 	return nil
 }
 
-func newProviderUpgrader(configClient config.Client, repositoryClientFactory RepositoryClientFactory, providerInventory InventoryClient, providerComponents ComponentsClient) *providerUpgrader {
+// checkCompatibility checks if the target versions of the providers in an upgrade plan are compatible with the
+// target version of the core provider and with the Kubernetes version of the management cluster.
+func (u *providerUpgrader) checkCompatibility(upgradePlan *UpgradePlan) error {
+	targetVersion := func(item UpgradeItem) string {
+		if item.NextVersion != "" {
+			return item.NextVersion
+		}
+		return item.Version
+	}
+
+	coreProviderVersion := upgradePlan.CoreProvider.Version
+	for _, upgradeItem := range upgradePlan.Providers {
+		if upgradeItem.InstanceName() == upgradePlan.CoreProvider.InstanceName() {
+			coreProviderVersion = targetVersion(upgradeItem)
+		}
+	}
+
+	kubernetesVersion, err := u.proxy.GetServerVersion()
+	if err != nil {
+		return err
+	}
+
+	// NB. The upgrade items in a custom plan do not carry the current version of the providers, so the
+	// providers are read from the management group.
+	managementGroup, err := u.getManagementGroup(upgradePlan.CoreProvider)
+	if err != nil {
+		return err
+	}
+
+	var errList []error
+	for _, upgradeItem := range upgradePlan.Providers {
+		providerVersion := targetVersion(upgradeItem)
+		targetSemVersion, err := version.ParseSemantic(providerVersion)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse target version for the %s provider", upgradeItem.InstanceName())
+		}
+
+		provider := managementGroup.GetProviderByInstanceName(upgradeItem.InstanceName())
+		if provider == nil {
+			return errors.Errorf("unable to complete that upgrade: the provider %s in not part of the %s management group", upgradeItem.InstanceName(), upgradePlan.CoreProvider.InstanceName())
+		}
+
+		upgradeInfo, err := u.getUpgradeInfo(*provider)
+		if err != nil {
+			return err
+		}
+
+		releaseSeries := upgradeInfo.metadata.GetReleaseSeriesForVersion(targetSemVersion)
+		if err := checkCompatibility(upgradeItem.Provider, providerVersion, releaseSeries, coreProviderVersion, kubernetesVersion); err != nil {
+			errList = append(errList, err)
+		}
+	}
+	if len(errList) > 0 {
+		return errors.Wrap(kerrors.NewAggregate(errList), "unable to complete that upgrade")
+	}
+	return nil
+}
+
+func newProviderUpgrader(configClient config.Client, repositoryClientFactory RepositoryClientFactory, proxy Proxy, providerInventory InventoryClient, providerComponents ComponentsClient) *providerUpgrader {
 	return &providerUpgrader{
 		configClient:            configClient,
 		repositoryClientFactory: repositoryClientFactory,
+		proxy:                   proxy,
 		providerInventory:       providerInventory,
 		providerComponents:      providerComponents,
 	}
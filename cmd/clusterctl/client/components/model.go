@@ -0,0 +1,270 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package components implements a typed model of the objects defined in a provider components YAML.
+package components
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/scheme"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/util"
+	utilyaml "sigs.k8s.io/cluster-api/util/yaml"
+)
+
+const (
+	// certManagerGroup is the API group of the cert-manager objects, e.g. Certificates and Issuers.
+	certManagerGroup = "cert-manager.io"
+
+	// certManagerInjectCAFromAnnotation is the annotation used by the cert-manager CA injector for
+	// injecting a CA into CRDs and webhook configurations.
+	certManagerInjectCAFromAnnotation = "cert-manager.io/inject-ca-from"
+)
+
+// Model is a typed model of the objects defined in a provider components YAML.
+// Objects of kinds or API versions not modeled by a typed field, e.g. Services or cert-manager Certificates,
+// are stored in Others.
+type Model struct {
+	Namespaces                      []corev1.Namespace
+	CustomResourceDefinitions       []apiextensionsv1.CustomResourceDefinition
+	ServiceAccounts                 []corev1.ServiceAccount
+	ClusterRoles                    []rbacv1.ClusterRole
+	ClusterRoleBindings             []rbacv1.ClusterRoleBinding
+	Roles                           []rbacv1.Role
+	RoleBindings                    []rbacv1.RoleBinding
+	Deployments                     []appsv1.Deployment
+	ValidatingWebhookConfigurations []admissionregistrationv1.ValidatingWebhookConfiguration
+	MutatingWebhookConfigurations   []admissionregistrationv1.MutatingWebhookConfiguration
+	Others                          []unstructured.Unstructured
+}
+
+// Parse parses a provider components YAML into a Model.
+func Parse(rawyaml []byte) (*Model, error) {
+	objs, err := utilyaml.ToUnstructured(rawyaml)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse yaml")
+	}
+	return FromUnstructured(objs)
+}
+
+// FromUnstructured returns a Model for the given objects.
+func FromUnstructured(objs []unstructured.Unstructured) (*Model, error) {
+	m := &Model{}
+	for i := range objs {
+		o := objs[i]
+
+		var err error
+		switch o.GroupVersionKind() {
+		case corev1.SchemeGroupVersion.WithKind("Namespace"):
+			m.Namespaces = append(m.Namespaces, corev1.Namespace{})
+			err = convert(&o, &m.Namespaces[len(m.Namespaces)-1])
+		case apiextensionsv1.SchemeGroupVersion.WithKind("CustomResourceDefinition"):
+			m.CustomResourceDefinitions = append(m.CustomResourceDefinitions, apiextensionsv1.CustomResourceDefinition{})
+			err = convert(&o, &m.CustomResourceDefinitions[len(m.CustomResourceDefinitions)-1])
+		case corev1.SchemeGroupVersion.WithKind("ServiceAccount"):
+			m.ServiceAccounts = append(m.ServiceAccounts, corev1.ServiceAccount{})
+			err = convert(&o, &m.ServiceAccounts[len(m.ServiceAccounts)-1])
+		case rbacv1.SchemeGroupVersion.WithKind("ClusterRole"):
+			m.ClusterRoles = append(m.ClusterRoles, rbacv1.ClusterRole{})
+			err = convert(&o, &m.ClusterRoles[len(m.ClusterRoles)-1])
+		case rbacv1.SchemeGroupVersion.WithKind("ClusterRoleBinding"):
+			m.ClusterRoleBindings = append(m.ClusterRoleBindings, rbacv1.ClusterRoleBinding{})
+			err = convert(&o, &m.ClusterRoleBindings[len(m.ClusterRoleBindings)-1])
+		case rbacv1.SchemeGroupVersion.WithKind("Role"):
+			m.Roles = append(m.Roles, rbacv1.Role{})
+			err = convert(&o, &m.Roles[len(m.Roles)-1])
+		case rbacv1.SchemeGroupVersion.WithKind("RoleBinding"):
+			m.RoleBindings = append(m.RoleBindings, rbacv1.RoleBinding{})
+			err = convert(&o, &m.RoleBindings[len(m.RoleBindings)-1])
+		case appsv1.SchemeGroupVersion.WithKind("Deployment"):
+			m.Deployments = append(m.Deployments, appsv1.Deployment{})
+			err = convert(&o, &m.Deployments[len(m.Deployments)-1])
+		case admissionregistrationv1.SchemeGroupVersion.WithKind("ValidatingWebhookConfiguration"):
+			m.ValidatingWebhookConfigurations = append(m.ValidatingWebhookConfigurations, admissionregistrationv1.ValidatingWebhookConfiguration{})
+			err = convert(&o, &m.ValidatingWebhookConfigurations[len(m.ValidatingWebhookConfigurations)-1])
+		case admissionregistrationv1.SchemeGroupVersion.WithKind("MutatingWebhookConfiguration"):
+			m.MutatingWebhookConfigurations = append(m.MutatingWebhookConfigurations, admissionregistrationv1.MutatingWebhookConfiguration{})
+			err = convert(&o, &m.MutatingWebhookConfigurations[len(m.MutatingWebhookConfigurations)-1])
+		default:
+			m.Others = append(m.Others, *o.DeepCopy())
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to convert %s %s", o.GroupVersionKind(), o.GetName())
+		}
+	}
+	return m, nil
+}
+
+// Images returns the container images used by the objects in the model, sorted and without duplicates.
+func (m *Model) Images() ([]string, error) {
+	images := sets.NewString()
+	for _, d := range m.Deployments {
+		for _, c := range d.Spec.Template.Spec.InitContainers {
+			images.Insert(c.Image)
+		}
+		for _, c := range d.Spec.Template.Spec.Containers {
+			images.Insert(c.Image)
+		}
+	}
+
+	otherImages, err := util.InspectImages(m.Others)
+	if err != nil {
+		return nil, err
+	}
+	images.Insert(otherImages...)
+
+	return images.List(), nil
+}
+
+// RequiresCertManager returns true if the model depends on cert-manager, i.e. if it contains cert-manager objects
+// like Certificates and Issuers, or objects relying on the cert-manager CA injector.
+func (m *Model) RequiresCertManager() bool {
+	for _, o := range m.Others {
+		if o.GroupVersionKind().Group == certManagerGroup {
+			return true
+		}
+		if _, ok := o.GetAnnotations()[certManagerInjectCAFromAnnotation]; ok {
+			return true
+		}
+	}
+	for _, crd := range m.CustomResourceDefinitions {
+		if _, ok := crd.Annotations[certManagerInjectCAFromAnnotation]; ok {
+			return true
+		}
+	}
+	for _, w := range m.ValidatingWebhookConfigurations {
+		if _, ok := w.Annotations[certManagerInjectCAFromAnnotation]; ok {
+			return true
+		}
+	}
+	for _, w := range m.MutatingWebhookConfigurations {
+		if _, ok := w.Annotations[certManagerInjectCAFromAnnotation]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// TargetNamespaces returns the namespaces the model is installed into, i.e. the Namespaces defined in the model and the
+// namespaces of the namespaced objects, sorted and without duplicates.
+func (m *Model) TargetNamespaces() ([]string, error) {
+	objs, err := m.ToUnstructured()
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := sets.NewString()
+	for _, ns := range m.Namespaces {
+		namespaces.Insert(ns.Name)
+	}
+	for _, o := range objs {
+		if o.GetNamespace() != "" {
+			namespaces.Insert(o.GetNamespace())
+		}
+	}
+	return namespaces.List(), nil
+}
+
+// ToUnstructured returns the objects in the model, ordered so objects can be created in sequence, i.e.
+// Namespaces and CustomResourceDefinitions first, then RBAC, Deployments, webhook configurations and all the other objects.
+func (m *Model) ToUnstructured() ([]unstructured.Unstructured, error) {
+	typed := []runtime.Object{}
+	for i := range m.Namespaces {
+		typed = append(typed, &m.Namespaces[i])
+	}
+	for i := range m.CustomResourceDefinitions {
+		typed = append(typed, &m.CustomResourceDefinitions[i])
+	}
+	for i := range m.ServiceAccounts {
+		typed = append(typed, &m.ServiceAccounts[i])
+	}
+	for i := range m.ClusterRoles {
+		typed = append(typed, &m.ClusterRoles[i])
+	}
+	for i := range m.ClusterRoleBindings {
+		typed = append(typed, &m.ClusterRoleBindings[i])
+	}
+	for i := range m.Roles {
+		typed = append(typed, &m.Roles[i])
+	}
+	for i := range m.RoleBindings {
+		typed = append(typed, &m.RoleBindings[i])
+	}
+	for i := range m.Deployments {
+		typed = append(typed, &m.Deployments[i])
+	}
+	for i := range m.ValidatingWebhookConfigurations {
+		typed = append(typed, &m.ValidatingWebhookConfigurations[i])
+	}
+	for i := range m.MutatingWebhookConfigurations {
+		typed = append(typed, &m.MutatingWebhookConfigurations[i])
+	}
+
+	objs := make([]unstructured.Unstructured, 0, len(typed)+len(m.Others))
+	for _, t := range typed {
+		o := unstructured.Unstructured{}
+		if err := scheme.Scheme.Convert(t, &o, nil); err != nil {
+			return nil, errors.Wrapf(err, "failed to convert %T", t)
+		}
+		// Nb. the typeMeta is not preserved by the conversion, so it is restored from the scheme.
+		gvk, err := gvkForObject(t)
+		if err != nil {
+			return nil, err
+		}
+		o.SetGroupVersionKind(gvk)
+		objs = append(objs, o)
+	}
+	for i := range m.Others {
+		objs = append(objs, *m.Others[i].DeepCopy())
+	}
+	return objs, nil
+}
+
+// Yaml returns the YAML representation of the objects in the model, in the same order of ToUnstructured.
+func (m *Model) Yaml() ([]byte, error) {
+	objs, err := m.ToUnstructured()
+	if err != nil {
+		return nil, err
+	}
+	return utilyaml.FromUnstructured(objs)
+}
+
+// convert converts an unstructured object into a typed object.
+func convert(in *unstructured.Unstructured, out runtime.Object) error {
+	return scheme.Scheme.Convert(in, out, nil)
+}
+
+// gvkForObject returns the GroupVersionKind of a typed object.
+func gvkForObject(obj runtime.Object) (schema.GroupVersionKind, error) {
+	gvks, _, err := scheme.Scheme.ObjectKinds(obj)
+	if err != nil {
+		return schema.GroupVersionKind{}, errors.Wrapf(err, "failed to get the GroupVersionKind for %T", obj)
+	}
+	sort.Slice(gvks, func(i, j int) bool {
+		return gvks[i].String() < gvks[j].String()
+	})
+	return gvks[0], nil
+}
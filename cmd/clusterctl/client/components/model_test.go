@@ -0,0 +1,150 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+var providerComponentsYaml = []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: capi-controller-manager
+  namespace: capi-system
+spec:
+  selector:
+    matchLabels:
+      control-plane: controller-manager
+  template:
+    metadata:
+      labels:
+        control-plane: controller-manager
+    spec:
+      containers:
+      - name: manager
+        image: gcr.io/k8s-staging-cluster-api/cluster-api-controller:v0.4.0
+      - name: kube-rbac-proxy
+        image: gcr.io/kubebuilder/kube-rbac-proxy:v0.4.1
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: capi-system
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  annotations:
+    cert-manager.io/inject-ca-from: capi-system/capi-serving-cert
+  name: clusters.cluster.x-k8s.io
+spec:
+  group: cluster.x-k8s.io
+  names:
+    kind: Cluster
+    plural: clusters
+  scope: Namespaced
+  versions:
+  - name: v1alpha4
+    served: true
+    storage: true
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: capi-manager-role
+rules:
+- apiGroups:
+  - cluster.x-k8s.io
+  resources:
+  - clusters
+  verbs:
+  - get
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: capi-webhook-service
+  namespace: capi-webhook-system
+spec:
+  ports:
+  - port: 443
+`)
+
+func TestParse(t *testing.T) {
+	g := NewWithT(t)
+
+	m, err := Parse(providerComponentsYaml)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(m.Namespaces).To(HaveLen(1))
+	g.Expect(m.Namespaces[0].Name).To(Equal("capi-system"))
+	g.Expect(m.CustomResourceDefinitions).To(HaveLen(1))
+	g.Expect(m.CustomResourceDefinitions[0].Spec.Names.Kind).To(Equal("Cluster"))
+	g.Expect(m.ClusterRoles).To(HaveLen(1))
+	g.Expect(m.ClusterRoles[0].Rules).To(HaveLen(1))
+	g.Expect(m.Deployments).To(HaveLen(1))
+	g.Expect(m.Deployments[0].Spec.Template.Spec.Containers).To(HaveLen(2))
+	g.Expect(m.Others).To(HaveLen(1))
+	g.Expect(m.Others[0].GetKind()).To(Equal("Service"))
+}
+
+func TestModel_Queries(t *testing.T) {
+	g := NewWithT(t)
+
+	m, err := Parse(providerComponentsYaml)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	images, err := m.Images()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(images).To(Equal([]string{
+		"gcr.io/k8s-staging-cluster-api/cluster-api-controller:v0.4.0",
+		"gcr.io/kubebuilder/kube-rbac-proxy:v0.4.1",
+	}))
+
+	g.Expect(m.RequiresCertManager()).To(BeTrue())
+	m.CustomResourceDefinitions[0].Annotations = nil
+	g.Expect(m.RequiresCertManager()).To(BeFalse())
+
+	namespaces, err := m.TargetNamespaces()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(namespaces).To(Equal([]string{"capi-system", "capi-webhook-system"}))
+}
+
+func TestModel_RoundTrip(t *testing.T) {
+	g := NewWithT(t)
+
+	m, err := Parse(providerComponentsYaml)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	objs, err := m.ToUnstructured()
+	g.Expect(err).NotTo(HaveOccurred())
+	kinds := []string{}
+	for _, o := range objs {
+		kinds = append(kinds, o.GetKind())
+	}
+	g.Expect(kinds).To(Equal([]string{"Namespace", "CustomResourceDefinition", "ClusterRole", "Deployment", "Service"}))
+	g.Expect(objs[1].GetAPIVersion()).To(Equal("apiextensions.k8s.io/v1"))
+
+	out, err := m.Yaml()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	m2, err := Parse(out)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(m2).To(Equal(m))
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// RewriteNamespace re-targets the objects in the model installed in the from namespace to the to namespace, e.g. when
+// restoring provider components into a management cluster with a different namespace layout. Besides the Namespace
+// objects and the namespace of namespaced objects, it rewrites all the references to the from namespace:
+// - the namespace of the RoleBinding and ClusterRoleBinding subjects;
+// - the namespace of the services in the webhook client configs, including CRD conversion webhooks;
+// - the cert-manager inject-ca-from annotations;
+// - the DNS names of the cert-manager Certificates for the services in the from namespace.
+// Objects in other namespaces are left untouched, so components spanning many namespaces can be re-targeted one namespace at a time.
+func (m *Model) RewriteNamespace(from, to string) error {
+	if from == "" {
+		return errors.New("the namespace to rewrite is required")
+	}
+	if errs := validation.IsDNS1123Label(to); len(errs) > 0 {
+		return errors.Errorf("invalid target namespace %q: %s", to, strings.Join(errs, ", "))
+	}
+
+	for i := range m.Namespaces {
+		if m.Namespaces[i].Name == from {
+			m.Namespaces[i].Name = to
+		}
+	}
+
+	for i := range m.CustomResourceDefinitions {
+		crd := &m.CustomResourceDefinitions[i]
+		rewriteInjectCAFromAnnotation(&crd.ObjectMeta, from, to)
+		if crd.Spec.Conversion != nil && crd.Spec.Conversion.Webhook != nil && crd.Spec.Conversion.Webhook.ClientConfig != nil {
+			if s := crd.Spec.Conversion.Webhook.ClientConfig.Service; s != nil && s.Namespace == from {
+				s.Namespace = to
+			}
+		}
+	}
+
+	for i := range m.ServiceAccounts {
+		rewriteObjectNamespace(&m.ServiceAccounts[i].ObjectMeta, from, to)
+	}
+
+	for i := range m.ClusterRoleBindings {
+		rewriteSubjectsNamespace(m.ClusterRoleBindings[i].Subjects, from, to)
+	}
+
+	for i := range m.Roles {
+		rewriteObjectNamespace(&m.Roles[i].ObjectMeta, from, to)
+	}
+
+	for i := range m.RoleBindings {
+		rewriteObjectNamespace(&m.RoleBindings[i].ObjectMeta, from, to)
+		rewriteSubjectsNamespace(m.RoleBindings[i].Subjects, from, to)
+	}
+
+	for i := range m.Deployments {
+		rewriteObjectNamespace(&m.Deployments[i].ObjectMeta, from, to)
+	}
+
+	for i := range m.ValidatingWebhookConfigurations {
+		w := &m.ValidatingWebhookConfigurations[i]
+		rewriteInjectCAFromAnnotation(&w.ObjectMeta, from, to)
+		for j := range w.Webhooks {
+			rewriteWebhookClientConfigNamespace(&w.Webhooks[j].ClientConfig, from, to)
+		}
+	}
+
+	for i := range m.MutatingWebhookConfigurations {
+		w := &m.MutatingWebhookConfigurations[i]
+		rewriteInjectCAFromAnnotation(&w.ObjectMeta, from, to)
+		for j := range w.Webhooks {
+			rewriteWebhookClientConfigNamespace(&w.Webhooks[j].ClientConfig, from, to)
+		}
+	}
+
+	for i := range m.Others {
+		if err := rewriteOtherNamespace(&m.Others[i], from, to); err != nil {
+			return errors.Wrapf(err, "failed to rewrite the namespace of %s %s", m.Others[i].GroupVersionKind(), m.Others[i].GetName())
+		}
+	}
+	return nil
+}
+
+// rewriteObjectNamespace sets the namespace of an object to to if it is from.
+func rewriteObjectNamespace(obj *metav1.ObjectMeta, from, to string) {
+	if obj.Namespace == from {
+		obj.Namespace = to
+	}
+}
+
+// rewriteSubjectsNamespace sets the namespace of the subjects to to if it is from.
+func rewriteSubjectsNamespace(subjects []rbacv1.Subject, from, to string) {
+	for i := range subjects {
+		if subjects[i].Namespace == from {
+			subjects[i].Namespace = to
+		}
+	}
+}
+
+// rewriteWebhookClientConfigNamespace sets the namespace of the service of a webhook client config to to if it is from.
+func rewriteWebhookClientConfigNamespace(clientConfig *admissionregistrationv1.WebhookClientConfig, from, to string) {
+	if clientConfig.Service != nil && clientConfig.Service.Namespace == from {
+		clientConfig.Service.Namespace = to
+	}
+}
+
+// rewriteInjectCAFromAnnotation rewrites the cert-manager inject-ca-from annotation, in the namespace/name format,
+// if it refers to a Certificate in the from namespace.
+func rewriteInjectCAFromAnnotation(obj metav1.Object, from, to string) {
+	annotations := obj.GetAnnotations()
+	value, ok := annotations[certManagerInjectCAFromAnnotation]
+	if !ok || !strings.HasPrefix(value, from+"/") {
+		return
+	}
+	annotations[certManagerInjectCAFromAnnotation] = to + strings.TrimPrefix(value, from)
+	obj.SetAnnotations(annotations)
+}
+
+// rewriteOtherNamespace rewrites the namespace of an object not modeled by a typed field, and, for cert-manager
+// Certificates, the DNS names for the services in the from namespace, e.g. webhook-service.from.svc.
+func rewriteOtherNamespace(obj *unstructured.Unstructured, from, to string) error {
+	if obj.GetNamespace() == from {
+		obj.SetNamespace(to)
+	}
+	rewriteInjectCAFromAnnotation(obj, from, to)
+
+	if obj.GroupVersionKind().Group != certManagerGroup || obj.GetKind() != "Certificate" {
+		return nil
+	}
+	dnsNames, found, err := unstructured.NestedStringSlice(obj.Object, "spec", "dnsNames")
+	if err != nil || !found {
+		return err
+	}
+	for i, name := range dnsNames {
+		parts := strings.Split(name, ".")
+		if len(parts) >= 3 && parts[1] == from && parts[2] == "svc" {
+			parts[1] = to
+			dnsNames[i] = strings.Join(parts, ".")
+		}
+	}
+	return unstructured.SetNestedStringSlice(obj.Object, dnsNames, "spec", "dnsNames")
+}
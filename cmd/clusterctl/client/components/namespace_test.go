@@ -0,0 +1,170 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var webhookComponentsYaml = []byte(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: capi-system
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  annotations:
+    cert-manager.io/inject-ca-from: capi-system/capi-serving-cert
+  name: clusters.cluster.x-k8s.io
+spec:
+  group: cluster.x-k8s.io
+  names:
+    kind: Cluster
+    plural: clusters
+  scope: Namespaced
+  conversion:
+    strategy: Webhook
+    webhook:
+      conversionReviewVersions: ["v1"]
+      clientConfig:
+        service:
+          name: capi-webhook-service
+          namespace: capi-system
+          path: /convert
+  versions:
+  - name: v1alpha4
+    served: true
+    storage: true
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: capi-manager
+  namespace: capi-system
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: capi-manager-rolebinding
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: capi-manager-role
+subjects:
+- kind: ServiceAccount
+  name: capi-manager
+  namespace: capi-system
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: capi-leader-election-rolebinding
+  namespace: capi-system
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: capi-leader-election-role
+subjects:
+- kind: ServiceAccount
+  name: capi-manager
+  namespace: capi-system
+- kind: ServiceAccount
+  name: other
+  namespace: other-system
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  annotations:
+    cert-manager.io/inject-ca-from: capi-system/capi-serving-cert
+  name: capi-validating-webhook-configuration
+webhooks:
+- name: validation.cluster.cluster.x-k8s.io
+  admissionReviewVersions: ["v1"]
+  sideEffects: None
+  clientConfig:
+    service:
+      name: capi-webhook-service
+      namespace: capi-system
+      path: /validate-cluster
+---
+apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: capi-serving-cert
+  namespace: capi-system
+spec:
+  dnsNames:
+  - capi-webhook-service.capi-system.svc
+  - capi-webhook-service.capi-system.svc.cluster.local
+  secretName: capi-webhook-service-cert
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: other-service
+  namespace: other-system
+`)
+
+func TestModel_RewriteNamespace(t *testing.T) {
+	g := NewWithT(t)
+
+	m, err := Parse(webhookComponentsYaml)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(m.RewriteNamespace("capi-system", "restored-system")).To(Succeed())
+
+	g.Expect(m.Namespaces[0].Name).To(Equal("restored-system"))
+	g.Expect(m.CustomResourceDefinitions[0].Annotations[certManagerInjectCAFromAnnotation]).To(Equal("restored-system/capi-serving-cert"))
+	g.Expect(m.CustomResourceDefinitions[0].Spec.Conversion.Webhook.ClientConfig.Service.Namespace).To(Equal("restored-system"))
+	g.Expect(m.ServiceAccounts[0].Namespace).To(Equal("restored-system"))
+	g.Expect(m.ClusterRoleBindings[0].Subjects[0].Namespace).To(Equal("restored-system"))
+	g.Expect(m.RoleBindings[0].Namespace).To(Equal("restored-system"))
+	g.Expect(m.RoleBindings[0].Subjects[0].Namespace).To(Equal("restored-system"))
+	g.Expect(m.RoleBindings[0].Subjects[1].Namespace).To(Equal("other-system"))
+	g.Expect(m.ValidatingWebhookConfigurations[0].Annotations[certManagerInjectCAFromAnnotation]).To(Equal("restored-system/capi-serving-cert"))
+	g.Expect(m.ValidatingWebhookConfigurations[0].Webhooks[0].ClientConfig.Service.Namespace).To(Equal("restored-system"))
+
+	g.Expect(m.Others).To(HaveLen(2))
+	certificate := m.Others[0]
+	g.Expect(certificate.GetNamespace()).To(Equal("restored-system"))
+	dnsNames, _, err := unstructured.NestedStringSlice(certificate.Object, "spec", "dnsNames")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(dnsNames).To(Equal([]string{
+		"capi-webhook-service.restored-system.svc",
+		"capi-webhook-service.restored-system.svc.cluster.local",
+	}))
+	g.Expect(m.Others[1].GetNamespace()).To(Equal("other-system"))
+
+	namespaces, err := m.TargetNamespaces()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(namespaces).To(Equal([]string{"other-system", "restored-system"}))
+}
+
+func TestModel_RewriteNamespace_Invalid(t *testing.T) {
+	g := NewWithT(t)
+
+	m, err := Parse(webhookComponentsYaml)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(m.RewriteNamespace("", "restored-system")).NotTo(Succeed())
+	g.Expect(m.RewriteNamespace("capi-system", "Not_Valid")).NotTo(Succeed())
+}
@@ -158,6 +158,17 @@ type GetClusterTemplateOptions struct {
 	// YamlProcessor defines the yaml processor to use for the cluster
 	// template processing. If not defined, SimpleProcessor will be used.
 	YamlProcessor Processor
+
+	// ImageResolver defines the resolver used to set the MACHINE_IMAGE variable, if not already set, from the Kubernetes
+	// version and the MACHINE_IMAGE_OS and MACHINE_IMAGE_REGION variables. If not defined, a resolver reading the
+	// image lookup table from the clusterctl-image-lookup ConfigMap in the namespace where the core provider is
+	// installed will be used.
+	ImageResolver ImageResolver
+
+	// PromptVariable, if defined, is called before processing the template for each variable without a default value
+	// that is not set in the os environment variables or the .cluster-api/clusterctl.yaml config file, and returns
+	// the value for the variable, e.g. reading it from an interactive terminal.
+	PromptVariable func(variable TemplateVariable) (string, error)
 }
 
 // numSources return the number of template sources currently set on a GetClusterTemplateOptions.
@@ -196,6 +207,17 @@ type URLSourceOptions struct {
 // DefaultCustomTemplateConfigMapKey  where the workload cluster template is hosted.
 const DefaultCustomTemplateConfigMapKey = "template"
 
+const (
+	// MachineImageVariable is the variable set with the image resolved for the workload cluster machines.
+	MachineImageVariable = "MACHINE_IMAGE"
+
+	// MachineImageOSVariable is the variable defining the operating system used for resolving the machine image.
+	MachineImageOSVariable = "MACHINE_IMAGE_OS"
+
+	// MachineImageRegionVariable is the variable defining the region used for resolving the machine image.
+	MachineImageRegionVariable = "MACHINE_IMAGE_REGION"
+)
+
 // ConfigMapSourceOptions defines the options to be used when reading a workload cluster template from a ConfigMap.
 type ConfigMapSourceOptions struct {
 	// Namespace where the ConfigMap exists. If unspecified, the current namespace will be used.
@@ -207,6 +229,13 @@ type ConfigMapSourceOptions struct {
 	// DataKey where the workload cluster template is hosted. If unspecified, the
 	// DefaultCustomTemplateConfigMapKey will be used.
 	DataKey string
+
+	// Environment selects the overlay to apply to the workload cluster template, e.g. dev, staging or prod.
+	// The overlay is read from the ConfigMap in the same namespace with the clusterctl.cluster.x-k8s.io/template label
+	// set to Name and the clusterctl.cluster.x-k8s.io/template-environment label set to Environment; the values
+	// of the variables stored in the overlay are used unless set with flags, environment variables or the
+	// clusterctl config file, while the objects stored in the overlay under DataKey are merged into the template objects.
+	Environment string
 }
 
 func (c *clusterctlClient) GetClusterTemplate(options GetClusterTemplateOptions) (Template, error) {
@@ -245,12 +274,38 @@ func (c *clusterctlClient) GetClusterTemplate(options GetClusterTemplateOptions)
 		options.TargetNamespace = currentNamespace
 	}
 
+	// Sets the variables defined by the template overlay for the selected environment, if any; this must happen before
+	// injecting the templateOptions, so default values for the templateOptions do not take precedence over the overlay.
+	if options.ConfigMapSource != nil && options.ConfigMapSource.Environment != "" {
+		if err := c.setTemplateOverlayVariables(cluster, *options.ConfigMapSource); err != nil {
+			return nil, err
+		}
+	}
+
 	// Inject some of the templateOptions into the configClient so they can be consumed as a variables from the template.
 	if err := c.templateOptionsToVariables(options); err != nil {
 		return nil, err
 	}
 
-	// Gets the workload cluster template from the selected source
+	// Resolve the machine image, so templates do not have to hard-code image IDs.
+	if !options.ListVariablesOnly {
+		if err := c.resolveMachineImage(cluster, options); err != nil {
+			return nil, err
+		}
+	}
+
+	// Gets the values of the missing variables, if requested, so processing the template does not fail.
+	if options.PromptVariable != nil && !options.ListVariablesOnly {
+		if err := c.promptTemplateVariables(cluster, options); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.getTemplate(cluster, options)
+}
+
+// getTemplate returns the workload cluster template from the selected source.
+func (c *clusterctlClient) getTemplate(cluster cluster.Client, options GetClusterTemplateOptions) (Template, error) {
 	if options.ProviderRepositorySource != nil {
 		return c.getTemplateFromRepository(cluster, options)
 	}
@@ -264,6 +319,35 @@ func (c *clusterctlClient) GetClusterTemplate(options GetClusterTemplateOptions)
 	return nil, errors.New("unable to read custom template. Please specify a template source")
 }
 
+// promptTemplateVariables sets the values returned by options.PromptVariable for the variables without a default value
+// required by the workload cluster template, if not already set.
+func (c *clusterctlClient) promptTemplateVariables(cluster cluster.Client, options GetClusterTemplateOptions) error {
+	options.ListVariablesOnly = true
+	template, err := c.getTemplate(cluster, options)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range template.VariableDeclarations() {
+		if !v.Required() {
+			continue
+		}
+		if _, err := c.configClient.Variables().Get(v.Name); err == nil {
+			continue
+		}
+
+		value, err := options.PromptVariable(TemplateVariable(v))
+		if err != nil {
+			return errors.Wrapf(err, "failed to get the value for variable %s", v.Name)
+		}
+		if value == "" {
+			return errors.Errorf("invalid value for variable %s: a value is required", v.Name)
+		}
+		c.configClient.Variables().Set(v.Name, value)
+	}
+	return nil
+}
+
 // getTemplateFromRepository returns a workload cluster template from a provider repository.
 func (c *clusterctlClient) getTemplateFromRepository(cluster cluster.Client, options GetClusterTemplateOptions) (Template, error) {
 	source := *options.ProviderRepositorySource
@@ -336,13 +420,52 @@ func (c *clusterctlClient) getTemplateFromRepository(cluster cluster.Client, opt
 	return template, nil
 }
 
-// getTemplateFromConfigMap returns a workload cluster template from a ConfigMap.
+// getTemplateFromConfigMap returns a workload cluster template from a ConfigMap, with the overlay for the selected environment, if any.
 func (c *clusterctlClient) getTemplateFromConfigMap(cluster cluster.Client, source ConfigMapSourceOptions, targetNamespace string, listVariablesOnly bool) (Template, error) {
+	source, err := defaultConfigMapSource(cluster, source)
+	if err != nil {
+		return nil, err
+	}
+
+	if source.Environment == "" {
+		return cluster.Template().GetFromConfigMap(source.Namespace, source.Name, source.DataKey, targetNamespace, listVariablesOnly)
+	}
+
+	overlay, err := cluster.Template().GetOverlayFromConfigMap(source.Namespace, source.Name, source.Environment, source.DataKey)
+	if err != nil {
+		return nil, err
+	}
+	return cluster.Template().GetFromConfigMapWithOverlay(source.Namespace, source.Name, source.DataKey, overlay, targetNamespace, listVariablesOnly)
+}
+
+// setTemplateOverlayVariables sets the values of the variables stored in the template overlay for the selected environment,
+// unless already set e.g. as an environment variable or in the clusterctl config file.
+func (c *clusterctlClient) setTemplateOverlayVariables(cluster cluster.Client, source ConfigMapSourceOptions) error {
+	source, err := defaultConfigMapSource(cluster, source)
+	if err != nil {
+		return err
+	}
+
+	overlay, err := cluster.Template().GetOverlayFromConfigMap(source.Namespace, source.Name, source.Environment, source.DataKey)
+	if err != nil {
+		return err
+	}
+	for name, value := range overlay.Variables {
+		if _, err := c.configClient.Variables().Get(name); err == nil {
+			continue
+		}
+		c.configClient.Variables().Set(name, value)
+	}
+	return nil
+}
+
+// defaultConfigMapSource defaults the namespace and the data key of a ConfigMapSourceOptions.
+func defaultConfigMapSource(cluster cluster.Client, source ConfigMapSourceOptions) (ConfigMapSourceOptions, error) {
 	// If the option specifying the configMapNamespace is empty, default it to the current namespace.
 	if source.Namespace == "" {
 		currentNamespace, err := cluster.Proxy().CurrentNamespace()
 		if err != nil {
-			return nil, err
+			return source, err
 		}
 		source.Namespace = currentNamespace
 	}
@@ -351,8 +474,7 @@ func (c *clusterctlClient) getTemplateFromConfigMap(cluster cluster.Client, sour
 	if source.DataKey == "" {
 		source.DataKey = DefaultCustomTemplateConfigMapKey
 	}
-
-	return cluster.Template().GetFromConfigMap(source.Namespace, source.Name, source.DataKey, targetNamespace, listVariablesOnly)
+	return source, nil
 }
 
 // getTemplateFromURL returns a workload cluster template from an URL.
@@ -360,6 +482,72 @@ func (c *clusterctlClient) getTemplateFromURL(cluster cluster.Client, source URL
 	return cluster.Template().GetFromURL(source.URL, targetNamespace, listVariablesOnly)
 }
 
+// resolveMachineImage sets the MACHINE_IMAGE variable using the image resolver, unless the variable is already set
+// e.g. as an environment variable or in the clusterctl config file.
+func (c *clusterctlClient) resolveMachineImage(clusterClient cluster.Client, options GetClusterTemplateOptions) error {
+	if _, err := c.configClient.Variables().Get(MachineImageVariable); err == nil {
+		return nil
+	}
+
+	lookup := cluster.ImageLookup{
+		Provider: imageLookupProvider(clusterClient, options),
+	}
+	lookup.KubernetesVersion, _ = c.configClient.Variables().Get("KUBERNETES_VERSION")
+	lookup.OS, _ = c.configClient.Variables().Get(MachineImageOSVariable)
+	lookup.Region, _ = c.configClient.Variables().Get(MachineImageRegionVariable)
+
+	resolver := options.ImageResolver
+	if resolver == nil {
+		namespace, err := imageLookupNamespace(clusterClient)
+		if err != nil {
+			return err
+		}
+		// If the core provider namespace cannot be decided, there is no lookup table to read images from.
+		if namespace == "" {
+			return nil
+		}
+		resolver = cluster.NewConfigMapImageResolver(clusterClient.Proxy(), namespace, cluster.DefaultImageLookupConfigMapName)
+	}
+	image, err := resolver.Resolve(lookup)
+	if err != nil {
+		return errors.Wrap(err, "failed to resolve the machine image")
+	}
+	if image != "" {
+		c.configClient.Variables().Set(MachineImageVariable, image)
+	}
+	return nil
+}
+
+// imageLookupProvider returns the name of the infrastructure provider to resolve images for, that is the provider
+// the template is read from or the default infrastructure provider, if any.
+func imageLookupProvider(clusterClient cluster.Client, options GetClusterTemplateOptions) string {
+	if options.ProviderRepositorySource != nil && options.ProviderRepositorySource.InfrastructureProvider != "" {
+		name, _, err := parseProviderName(options.ProviderRepositorySource.InfrastructureProvider)
+		if err == nil {
+			return name
+		}
+	}
+	name, _ := clusterClient.ProviderInventory().GetDefaultProviderName(clusterctlv1.InfrastructureProviderType)
+	return name
+}
+
+// imageLookupNamespace returns the namespace hosting the default image lookup table, that is the namespace where the
+// core provider is installed according to the inventory; if the namespace cannot be decided, an empty string is returned.
+func imageLookupNamespace(clusterClient cluster.Client) (string, error) {
+	coreProvider, err := clusterClient.ProviderInventory().GetDefaultProviderName(clusterctlv1.CoreProviderType)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get the core provider from the inventory")
+	}
+	if coreProvider == "" {
+		return "", nil
+	}
+	namespace, err := clusterClient.ProviderInventory().GetDefaultProviderNamespace(coreProvider, clusterctlv1.CoreProviderType)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get the core provider namespace from the inventory")
+	}
+	return namespace, nil
+}
+
 // templateOptionsToVariables injects some of the templateOptions to the configClient so they can be consumed as a variables from the template.
 func (c *clusterctlClient) templateOptionsToVariables(options GetClusterTemplateOptions) error {
 	// the TargetNamespace, if valid, can be used in templates using the ${ NAMESPACE } variable.
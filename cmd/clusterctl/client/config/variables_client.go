@@ -19,6 +19,18 @@ package config
 const (
 	// GitHubTokenVariable defines a variable hosting the GitHub access token.
 	GitHubTokenVariable = "github-token"
+
+	// OCIUsernameVariable defines a variable hosting the username used to access OCI registries.
+	OCIUsernameVariable = "oci-username"
+
+	// OCIPasswordVariable defines a variable hosting the password used to access OCI registries.
+	OCIPasswordVariable = "oci-password"
+
+	// SSHUserVariable defines a variable hosting the user used by clusterctl to ssh into machines.
+	SSHUserVariable = "clusterctl-ssh-user"
+
+	// SSHIdentityFileVariable defines a variable hosting the identity file used by clusterctl to ssh into machines.
+	SSHIdentityFileVariable = "clusterctl-ssh-identity-file"
 )
 
 // VariablesClient has methods to work with environment variables and with variables defined in the clusterctl configuration file.
@@ -431,6 +431,162 @@ func Test_clusterctlClient_templateOptionsToVariables_withExistingMachineCountVa
 	}
 }
 
+type fakeImageResolver struct {
+	image  string
+	err    error
+	lookup *cluster.ImageLookup
+}
+
+func (r *fakeImageResolver) Resolve(lookup cluster.ImageLookup) (string, error) {
+	r.lookup = &lookup
+	return r.image, r.err
+}
+
+func Test_clusterctlClient_resolveMachineImage(t *testing.T) {
+	tests := []struct {
+		name       string
+		vars       map[string]string
+		resolver   *fakeImageResolver
+		wantLookup *cluster.ImageLookup
+		wantImage  string
+		wantErr    bool
+	}{
+		{
+			name: "resolves the machine image",
+			vars: map[string]string{
+				"KUBERNETES_VERSION":       "v1.20.4",
+				MachineImageOSVariable:     "ubuntu-20.04",
+				MachineImageRegionVariable: "eu-west-1",
+			},
+			resolver:   &fakeImageResolver{image: "ami-123"},
+			wantLookup: &cluster.ImageLookup{Provider: "infra", KubernetesVersion: "v1.20.4", OS: "ubuntu-20.04", Region: "eu-west-1"},
+			wantImage:  "ami-123",
+		},
+		{
+			name: "does not override a machine image already set",
+			vars: map[string]string{
+				"KUBERNETES_VERSION": "v1.20.4",
+				MachineImageVariable: "ami-456",
+			},
+			resolver:   &fakeImageResolver{image: "ami-123"},
+			wantLookup: nil,
+			wantImage:  "ami-456",
+		},
+		{
+			name: "does not set the machine image if no image is resolved",
+			vars: map[string]string{
+				"KUBERNETES_VERSION": "v1.20.4",
+			},
+			resolver:   &fakeImageResolver{},
+			wantLookup: &cluster.ImageLookup{Provider: "infra", KubernetesVersion: "v1.20.4"},
+			wantImage:  "",
+		},
+		{
+			name: "fails if the resolver fails",
+			vars: map[string]string{
+				"KUBERNETES_VERSION": "v1.20.4",
+			},
+			resolver: &fakeImageResolver{err: errors.New("failed")},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			configClient := newFakeConfig()
+			for k, v := range tt.vars {
+				configClient.WithVar(k, v)
+			}
+			c := &clusterctlClient{
+				configClient: configClient,
+			}
+			options := GetClusterTemplateOptions{
+				ProviderRepositorySource: &ProviderRepositorySourceOptions{InfrastructureProvider: "infra:v1.0.0"},
+				ImageResolver:            tt.resolver,
+			}
+			clusterClient := newFakeCluster(cluster.Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"}, configClient)
+
+			err := c.resolveMachineImage(clusterClient, options)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(tt.resolver.lookup).To(Equal(tt.wantLookup))
+
+			image, err := configClient.Variables().Get(MachineImageVariable)
+			if tt.wantImage == "" {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(image).To(Equal(tt.wantImage))
+		})
+	}
+}
+
+func Test_clusterctlClient_resolveMachineImage_defaultResolver(t *testing.T) {
+	imageLookup := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "my-capi-system",
+			Name:      cluster.DefaultImageLookupConfigMapName,
+		},
+		Data: map[string]string{
+			"infra": "- kubernetesVersion: v1.20.4\n  image: ami-123\n",
+		},
+	}
+
+	tests := []struct {
+		name         string
+		coreProvider bool
+		wantImage    string
+	}{
+		{
+			name:         "reads the lookup table from the core provider namespace",
+			coreProvider: true,
+			wantImage:    "ami-123",
+		},
+		{
+			name:         "does not set the machine image if there is no core provider",
+			coreProvider: false,
+			wantImage:    "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			configClient := newFakeConfig().WithVar("KUBERNETES_VERSION", "v1.20.4")
+			c := &clusterctlClient{
+				configClient: configClient,
+			}
+			options := GetClusterTemplateOptions{
+				ProviderRepositorySource: &ProviderRepositorySourceOptions{InfrastructureProvider: "infra:v1.0.0"},
+			}
+			clusterClient := newFakeCluster(cluster.Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"}, configClient).
+				WithObjs(imageLookup)
+			if tt.coreProvider {
+				clusterClient.WithProviderInventory(config.ClusterAPIProviderName, clusterctlv1.CoreProviderType, "v1.0.0", "my-capi-system", "")
+			}
+
+			g.Expect(c.resolveMachineImage(clusterClient, options)).To(Succeed())
+
+			image, err := configClient.Variables().Get(MachineImageVariable)
+			if tt.wantImage == "" {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(image).To(Equal(tt.wantImage))
+		})
+	}
+}
+
 func Test_clusterctlClient_GetClusterTemplate(t *testing.T) {
 	g := NewWithT(t)
 
@@ -614,6 +770,199 @@ func Test_clusterctlClient_GetClusterTemplate(t *testing.T) {
 	}
 }
 
+func Test_clusterctlClient_GetClusterTemplate_Environment(t *testing.T) {
+	rawTemplate := string(templateYAML("ns3", "${ CLUSTER_NAME }")) + `
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  workers: "${WORKER_MACHINE_COUNT}"
+  zone: "${ZONE}"
+  size: "${SIZE:=small}"`
+
+	configMap := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns1",
+			Name:      "my-template",
+		},
+		Data: map[string]string{
+			DefaultCustomTemplateConfigMapKey: rawTemplate,
+		},
+	}
+	overlay := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns1",
+			Name:      "my-template-prod",
+			Labels: map[string]string{
+				clusterctlv1.ClusterctlTemplateLabelName:            "my-template",
+				clusterctlv1.ClusterctlTemplateEnvironmentLabelName: "prod",
+			},
+		},
+		Data: map[string]string{
+			DefaultCustomTemplateConfigMapKey: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  tier: prod`,
+			cluster.TemplateOverlayVariablesKey: `
+WORKER_MACHINE_COUNT: "5"
+ZONE: eu-west-1a
+SIZE: large`,
+		},
+	}
+
+	tests := []struct {
+		name               string
+		vars               map[string]string
+		workerMachineCount *int64
+		environment        string
+		wantData           map[string]interface{}
+		wantErr            bool
+	}{
+		{
+			name:        "uses the variables and merges the objects of the overlay",
+			environment: "prod",
+			wantData:    map[string]interface{}{"workers": "5", "zone": "eu-west-1a", "size": "large", "tier": "prod"},
+		},
+		{
+			name:               "flags, environment variables and the config file take precedence over the overlay",
+			vars:               map[string]string{"ZONE": "us-east-1a"},
+			workerMachineCount: pointer.Int64Ptr(7),
+			environment:        "prod",
+			wantData:           map[string]interface{}{"workers": "7", "zone": "us-east-1a", "size": "large", "tier": "prod"},
+		},
+		{
+			name:        "fails if there is no overlay for the environment",
+			environment: "dev",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			config1 := newFakeConfig().
+				WithProvider(infraProviderConfig)
+			for k, v := range tt.vars {
+				config1.WithVar(k, v)
+			}
+
+			cluster1 := newFakeCluster(cluster.Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"}, config1).
+				WithObjs(configMap, overlay).
+				WithObjs(test.FakeCAPISetupObjects()...)
+
+			client := newFakeClient(config1).
+				WithCluster(cluster1)
+
+			got, err := client.GetClusterTemplate(GetClusterTemplateOptions{
+				Kubeconfig: Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+				ConfigMapSource: &ConfigMapSourceOptions{
+					Namespace:   "ns1",
+					Name:        "my-template",
+					Environment: tt.environment,
+				},
+				ClusterName:        "test",
+				TargetNamespace:    "ns1",
+				WorkerMachineCount: tt.workerMachineCount,
+			})
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			objs := got.Objs()
+			g.Expect(objs).To(HaveLen(2))
+			g.Expect(objs[0].GetName()).To(Equal("test"))
+			g.Expect(objs[1].GetName()).To(Equal("settings"))
+			g.Expect(objs[1].Object["data"]).To(Equal(tt.wantData))
+		})
+	}
+}
+
+func Test_clusterctlClient_GetClusterTemplate_PromptVariable(t *testing.T) {
+	g := NewWithT(t)
+
+	rawTemplate := append([]byte("# clusterctl:variable SUFFIX The suffix of the cluster name.\n"), templateYAML("ns3", "${ CLUSTER_NAME }-${SUFFIX}-${ZONE:=a}")...)
+
+	tmpDir, err := os.MkdirTemp("", "cc")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "cluster-template.yaml")
+	g.Expect(os.WriteFile(path, rawTemplate, 0600)).To(Succeed())
+
+	tests := []struct {
+		name        string
+		value       string
+		wantPrompts []TemplateVariable
+		wantYaml    []byte
+		wantErr     bool
+	}{
+		{
+			name:  "prompts for the required variables without a value",
+			value: "prod",
+			wantPrompts: []TemplateVariable{
+				{Name: "SUFFIX", Description: "The suffix of the cluster name."},
+			},
+			wantYaml: templateYAML("ns1", "test-prod-a"),
+		},
+		{
+			name:    "fails if the prompt does not return a value",
+			value:   "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := NewWithT(t)
+
+			config1 := newFakeConfig().
+				WithProvider(infraProviderConfig)
+
+			cluster1 := newFakeCluster(cluster.Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"}, config1).
+				WithObjs(test.FakeCAPISetupObjects()...)
+
+			client := newFakeClient(config1).
+				WithCluster(cluster1)
+
+			var prompts []TemplateVariable
+			got, err := client.GetClusterTemplate(GetClusterTemplateOptions{
+				Kubeconfig:      Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+				URLSource:       &URLSourceOptions{URL: path},
+				ClusterName:     "test",
+				TargetNamespace: "ns1",
+				PromptVariable: func(variable TemplateVariable) (string, error) {
+					prompts = append(prompts, variable)
+					return tt.value, nil
+				},
+			})
+			if tt.wantErr {
+				gs.Expect(err).To(HaveOccurred())
+				return
+			}
+			gs.Expect(err).NotTo(HaveOccurred())
+			gs.Expect(prompts).To(Equal(tt.wantPrompts))
+
+			gotYaml, err := got.Yaml()
+			gs.Expect(err).NotTo(HaveOccurred())
+			gs.Expect(gotYaml).To(Equal(tt.wantYaml))
+		})
+	}
+}
+
 func Test_clusterctlClient_ProcessYAML(t *testing.T) {
 	g := NewWithT(t)
 	template := `v1: ${VAR1:=default1}
@@ -17,6 +17,10 @@ limitations under the License.
 package client
 
 import (
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
@@ -55,17 +59,33 @@ type DeleteOptions struct {
 	// (and of all the contained objects).
 	IncludeNamespace bool
 
-	// IncludeCRDs forces the deletion of the provider's CRDs (and of all the related objects).
+	// IncludeCRDs forces the deletion of the provider's CRDs; CRDs with existing instances are deleted,
+	// together with all their instances, only if IncludeNamespace is also set.
 	// By Extension, this forces the deletion of all the resources shared among provider instances, like e.g. web-hooks.
 	IncludeCRDs bool
 }
 
-func (c *clusterctlClient) Delete(options DeleteOptions) error {
+func (c *clusterctlClient) Delete(options DeleteOptions) (err error) {
 	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{Kubeconfig: options.Kubeconfig})
 	if err != nil {
 		return err
 	}
 
+	// Records the operation in the audit log of the management cluster.
+	started := time.Now()
+	defer func() {
+		c.recordOperation(clusterClient, cluster.AuditOperationDelete, started, map[string]string{
+			"namespace":               options.Namespace,
+			"coreProvider":            options.CoreProvider,
+			"bootstrapProviders":      strings.Join(options.BootstrapProviders, ","),
+			"controlPlaneProviders":   strings.Join(options.ControlPlaneProviders, ","),
+			"infrastructureProviders": strings.Join(options.InfrastructureProviders, ","),
+			"deleteAll":               strconv.FormatBool(options.DeleteAll),
+			"includeNamespace":        strconv.FormatBool(options.IncludeNamespace),
+			"includeCRDs":             strconv.FormatBool(options.IncludeCRDs),
+		}, err)
+	}()
+
 	// Ensure this command only runs against management clusters with the current Cluster API contract.
 	if err := clusterClient.ProviderInventory().CheckCAPIContract(); err != nil {
 		return err
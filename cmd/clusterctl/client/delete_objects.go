@@ -0,0 +1,87 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+)
+
+// DeleteObjectsOptions carries the options supported by DeleteObjects.
+type DeleteObjectsOptions struct {
+	// Kubeconfig defines the kubeconfig to use for accessing the management cluster. If empty,
+	// default rules for kubeconfig discovery will be used.
+	Kubeconfig Kubeconfig
+
+	// Namespace where the objects without a namespace should be deleted. If unspecified, the current namespace will be used.
+	Namespace string
+
+	// Objects to delete from the management cluster, e.g. the objects of a manifest.
+	Objects []unstructured.Unstructured
+
+	// PropagationPolicy determines how the dependents of the deleted objects are handled.
+	// If empty, metav1.DeletePropagationForeground is used, so objects are removed only after their dependents.
+	PropagationPolicy metav1.DeletionPropagation
+
+	// WaitForRemoval, if true, waits for the deleted objects to be removed from the management cluster.
+	WaitForRemoval bool
+
+	// Timeout is the maximum time to wait for all the deleted objects to be removed. If unspecified, 5 minutes are used.
+	Timeout time.Duration
+}
+
+// DeleteObjects deletes objects from the management cluster in reverse order, so e.g. the objects in a Namespace defined
+// by the same manifest are deleted before the Namespace itself; objects which do not exist are ignored.
+func (c *clusterctlClient) DeleteObjects(options DeleteObjectsOptions) error {
+	// gets access to the management cluster
+	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{Kubeconfig: options.Kubeconfig})
+	if err != nil {
+		return err
+	}
+
+	// Ensure this command only runs against management clusters with the current Cluster API contract.
+	if err := clusterClient.ProviderInventory().CheckCAPIContract(); err != nil {
+		return err
+	}
+
+	// If the option specifying the Namespace is empty, try to detect it.
+	if options.Namespace == "" {
+		currentNamespace, err := clusterClient.Proxy().CurrentNamespace()
+		if err != nil {
+			return err
+		}
+		options.Namespace = currentNamespace
+	}
+
+	objs := make([]unstructured.Unstructured, 0, len(options.Objects))
+	for i := range options.Objects {
+		obj := options.Objects[i].DeepCopy()
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace(options.Namespace)
+		}
+		objs = append(objs, *obj)
+	}
+
+	return clusterClient.Objects().DeleteObjects(objs, cluster.DeleteObjectsOptions{
+		PropagationPolicy: options.PropagationPolicy,
+		WaitForRemoval:    options.WaitForRemoval,
+		Timeout:           options.Timeout,
+	})
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_clusterctlClient_DeleteObjects(t *testing.T) {
+	g := NewWithT(t)
+
+	core := config.NewProvider("cluster-api", "https://somewhere.com", clusterctlv1.CoreProviderType)
+	config1 := newFakeConfig().
+		WithProvider(core)
+
+	md := &clusterv1.MachineDeployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: clusterv1.GroupVersion.String(),
+			Kind:       "MachineDeployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "md1",
+		},
+	}
+	cluster1 := newFakeCluster(cluster.Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"}, config1).
+		WithProviderInventory(core.Name(), core.Type(), "v1.0.0", "cluster-api-system", "").
+		WithObjs(md)
+	cluster1.fakeProxy.WithFakeCAPISetup()
+
+	c := newFakeClient(config1).
+		WithCluster(cluster1)
+
+	// Objects without a namespace are deleted from the current namespace, and objects which do not exist are ignored.
+	toDelete := func(name string) unstructured.Unstructured {
+		u := unstructured.Unstructured{}
+		u.SetAPIVersion(clusterv1.GroupVersion.String())
+		u.SetKind("MachineDeployment")
+		u.SetName(name)
+		return u
+	}
+	g.Expect(c.DeleteObjects(DeleteObjectsOptions{
+		Kubeconfig:        Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+		Objects:           []unstructured.Unstructured{toDelete("md1"), toDelete("md2")},
+		PropagationPolicy: metav1.DeletePropagationBackground,
+	})).To(Succeed())
+
+	proxyClient, err := cluster1.Proxy().NewClient()
+	g.Expect(err).NotTo(HaveOccurred())
+	err = proxyClient.Get(ctx, client.ObjectKeyFromObject(md), &clusterv1.MachineDeployment{})
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+	// Invalid propagation policies are rejected.
+	g.Expect(c.DeleteObjects(DeleteObjectsOptions{
+		Kubeconfig:        Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+		Objects:           []unstructured.Unstructured{toDelete("md1")},
+		PropagationPolicy: "foo",
+	})).NotTo(Succeed())
+}
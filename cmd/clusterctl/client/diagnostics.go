@@ -0,0 +1,69 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+)
+
+// CollectDiagnosticsOptions carries the options supported by CollectDiagnostics.
+type CollectDiagnosticsOptions struct {
+	// Kubeconfig defines the kubeconfig to use for accessing the management cluster. If empty,
+	// default rules for kubeconfig discovery will be used.
+	Kubeconfig Kubeconfig
+
+	// Namespace where the Cluster API objects and the events are collected from. If empty, all the namespaces are collected.
+	Namespace string
+
+	// LogTailLines is the number of lines collected from the end of the controllers logs. If zero, the whole logs are collected.
+	LogTailLines int64
+
+	// WorkloadClusterNodeLogs enables collecting logs from the nodes of the workload clusters.
+	WorkloadClusterNodeLogs bool
+
+	// NodeLogFiles is the list of files collected from /var/log on the workload cluster nodes.
+	// If empty, cluster.DefaultNodeLogFiles are collected.
+	NodeLogFiles []string
+
+	// Output is where the gzipped tarball with the diagnostics is written to.
+	Output io.Writer
+}
+
+// CollectDiagnostics collects diagnostics from the management cluster, and optionally from the workload cluster nodes,
+// into a gzipped tarball for bug reports; sensitive values are redacted.
+func (c *clusterctlClient) CollectDiagnostics(options CollectDiagnosticsOptions) error {
+	if options.Output == nil {
+		return errors.New("an output for the diagnostics is required")
+	}
+
+	// gets access to the management cluster
+	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{Kubeconfig: options.Kubeconfig})
+	if err != nil {
+		return err
+	}
+
+	// NB. the contract is not checked, so diagnostics can be collected also from broken or outdated management clusters.
+	return clusterClient.Diagnostics().Collect(cluster.DiagnosticsOptions{
+		Namespace:               options.Namespace,
+		LogTailLines:            options.LogTailLines,
+		WorkloadClusterNodeLogs: options.WorkloadClusterNodeLogs,
+		NodeLogFiles:            options.NodeLogFiles,
+	}, options.Output)
+}
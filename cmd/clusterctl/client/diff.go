@@ -0,0 +1,91 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+)
+
+const (
+	// DiffAdded is used for objects not existing in the management cluster.
+	DiffAdded = cluster.DiffAdded
+
+	// DiffChanged is used for objects existing in the management cluster with different fields.
+	DiffChanged = cluster.DiffChanged
+
+	// DiffUnchanged is used for objects existing in the management cluster without differences.
+	DiffUnchanged = cluster.DiffUnchanged
+)
+
+// DiffOptions carries the options supported by Diff.
+type DiffOptions struct {
+	// Kubeconfig defines the kubeconfig to use for accessing the management cluster. If empty,
+	// default rules for kubeconfig discovery will be used.
+	Kubeconfig Kubeconfig
+
+	// Namespace where the objects without a namespace should be compared. If unspecified, the current namespace will be used.
+	Namespace string
+
+	// Objects to compare with the objects in the management cluster, e.g. the objects generated
+	// from an updated cluster template.
+	Objects []unstructured.Unstructured
+}
+
+// Diff compares objects with the corresponding objects in the management cluster using server-side dry-run,
+// so the result takes into account defaulting and validation applied by the API server and by webhooks.
+func (c *clusterctlClient) Diff(options DiffOptions) ([]ObjectDiff, error) {
+	// gets access to the management cluster
+	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{Kubeconfig: options.Kubeconfig})
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure this command only runs against management clusters with the current Cluster API contract.
+	if err := clusterClient.ProviderInventory().CheckCAPIContract(); err != nil {
+		return nil, err
+	}
+
+	// If the option specifying the Namespace is empty, try to detect it.
+	if options.Namespace == "" {
+		currentNamespace, err := clusterClient.Proxy().CurrentNamespace()
+		if err != nil {
+			return nil, err
+		}
+		options.Namespace = currentNamespace
+	}
+
+	objs := make([]unstructured.Unstructured, 0, len(options.Objects))
+	for i := range options.Objects {
+		obj := options.Objects[i].DeepCopy()
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace(options.Namespace)
+		}
+		objs = append(objs, *obj)
+	}
+
+	diffs, err := clusterClient.Objects().Diff(objs)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]ObjectDiff, 0, len(diffs))
+	for _, d := range diffs {
+		ret = append(ret, ObjectDiff(d))
+	}
+	return ret, nil
+}
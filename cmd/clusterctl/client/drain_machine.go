@@ -0,0 +1,93 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"strconv"
+	"time"
+
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+)
+
+// CordonAndDrainMachineOptions carries all the options supported by CordonAndDrainMachine.
+type CordonAndDrainMachineOptions struct {
+	// Kubeconfig defines the kubeconfig to use for accessing the management cluster. If empty,
+	// default rules for kubeconfig discovery will be used.
+	Kubeconfig Kubeconfig
+
+	// Namespace where the Machine is located. If unspecified, the current namespace will be used.
+	Namespace string
+
+	// MachineName is the name of the Machine.
+	MachineName string
+
+	// Timeout is the time to wait for the Pods to be evicted or deleted from the Node; zero means infinite.
+	Timeout time.Duration
+
+	// GracePeriodSeconds is the period of time in seconds given to each Pod to terminate gracefully.
+	// If negative, the default value specified in the Pod will be used.
+	GracePeriodSeconds int
+
+	// DeleteEmptyDirData allows to drain Pods using emptyDir volumes; data in emptyDir volumes is deleted.
+	DeleteEmptyDirData bool
+
+	// DisableEviction forces the drain to delete Pods rather than evicting them, thus bypassing PodDisruptionBudgets.
+	DisableEviction bool
+}
+
+// CordonAndDrainMachine cordons the Node of a Machine, connecting to the workload cluster with the kubeconfig
+// stored in the management cluster, and then evicts the Pods running on the Node; DaemonSet Pods are ignored.
+func (c *clusterctlClient) CordonAndDrainMachine(options CordonAndDrainMachineOptions) (err error) {
+	// gets access to the management cluster
+	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{Kubeconfig: options.Kubeconfig})
+	if err != nil {
+		return err
+	}
+
+	// Records the operation in the audit log of the management cluster.
+	started := time.Now()
+	defer func() {
+		c.recordOperation(clusterClient, cluster.AuditOperationDrain, started, map[string]string{
+			"namespace":       options.Namespace,
+			"machineName":     options.MachineName,
+			"disableEviction": strconv.FormatBool(options.DisableEviction),
+		}, err)
+	}()
+
+	// Ensure this command only runs against management clusters with the current Cluster API contract.
+	if err := clusterClient.ProviderInventory().CheckCAPIContract(); err != nil {
+		return err
+	}
+
+	// If the option specifying the Namespace is empty, try to detect it.
+	if options.Namespace == "" {
+		currentNamespace, err := clusterClient.Proxy().CurrentNamespace()
+		if err != nil {
+			return err
+		}
+		options.Namespace = currentNamespace
+	}
+
+	return clusterClient.MachineDrain().CordonAndDrain(cluster.MachineDrainOptions{
+		Namespace:          options.Namespace,
+		MachineName:        options.MachineName,
+		Timeout:            options.Timeout,
+		GracePeriodSeconds: options.GracePeriodSeconds,
+		DeleteEmptyDirData: options.DeleteEmptyDirData,
+		DisableEviction:    options.DisableEviction,
+	})
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+)
+
+// ExportClusterOptions carries the options supported by ExportCluster.
+type ExportClusterOptions struct {
+	// Kubeconfig defines the kubeconfig to use for accessing the management cluster. If empty,
+	// default rules for kubeconfig discovery will be used.
+	Kubeconfig Kubeconfig
+
+	// Namespace where the Cluster is located. If unspecified, the current namespace will be used.
+	Namespace string
+
+	// ClusterName is the name of the Cluster to export.
+	ClusterName string
+
+	// TargetNamespace is the namespace the exported objects are re-targeted to.
+	// If unspecified, the namespace of the Cluster is preserved.
+	TargetNamespace string
+}
+
+// ExportCluster exports the definition of a Cluster, i.e. the Cluster and all the objects belonging to it which are not generated
+// by a controller, as a bundle of objects with the status and the metadata fields set by the management cluster removed, so the
+// bundle can be applied to any management cluster, e.g. for migrating the Cluster or for onboarding it in a GitOps repository.
+func (c *clusterctlClient) ExportCluster(options ExportClusterOptions) ([]unstructured.Unstructured, error) {
+	if options.ClusterName == "" {
+		return nil, errors.New("the name of the Cluster is required")
+	}
+
+	// gets access to the management cluster
+	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{Kubeconfig: options.Kubeconfig})
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure this command only runs against management clusters with the current Cluster API contract.
+	if err := clusterClient.ProviderInventory().CheckCAPIContract(); err != nil {
+		return nil, err
+	}
+
+	// If the option specifying the Namespace is empty, try to detect it.
+	if options.Namespace == "" {
+		currentNamespace, err := clusterClient.Proxy().CurrentNamespace()
+		if err != nil {
+			return nil, err
+		}
+		options.Namespace = currentNamespace
+	}
+
+	return clusterClient.ClusterExporter().Export(cluster.ClusterExportOptions{
+		Namespace:       options.Namespace,
+		ClusterName:     options.ClusterName,
+		TargetNamespace: options.TargetNamespace,
+	})
+}
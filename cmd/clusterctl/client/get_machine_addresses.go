@@ -0,0 +1,137 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GetMachineAddressesOptions carries all the options supported by GetMachineAddresses.
+type GetMachineAddressesOptions struct {
+	// Kubeconfig defines the kubeconfig to use for accessing the management cluster. If empty,
+	// default rules for kubeconfig discovery will be used.
+	Kubeconfig Kubeconfig
+
+	// Namespace where the Machine is located. If unspecified, the current namespace will be used.
+	Namespace string
+
+	// MachineName is the name of the Machine.
+	MachineName string
+}
+
+// MachineAddresses defines the addresses that can be used to reach a Machine.
+type MachineAddresses struct {
+	// External addresses of the Machine, i.e. ExternalIP and ExternalDNS addresses.
+	External []string
+
+	// Internal addresses of the Machine, i.e. InternalIP and InternalDNS addresses.
+	Internal []string
+
+	// JumpHost is an external address of another Machine in the same Cluster, that can be used
+	// to reach the Machine via its internal addresses. It is set only if the Machine does not have external addresses.
+	JumpHost string
+}
+
+// GetMachineAddresses returns the addresses reported by the infrastructure provider for a Machine.
+func (c *clusterctlClient) GetMachineAddresses(options GetMachineAddressesOptions) (*MachineAddresses, error) {
+	// gets access to the management cluster
+	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{Kubeconfig: options.Kubeconfig})
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure this command only runs against management clusters with the current Cluster API contract.
+	if err := clusterClient.ProviderInventory().CheckCAPIContract(); err != nil {
+		return nil, err
+	}
+
+	// If the option specifying the Namespace is empty, try to detect it.
+	if options.Namespace == "" {
+		currentNamespace, err := clusterClient.Proxy().CurrentNamespace()
+		if err != nil {
+			return nil, err
+		}
+		options.Namespace = currentNamespace
+	}
+
+	cl, err := clusterClient.Proxy().NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	machine := &clusterv1.Machine{}
+	if err := cl.Get(context.TODO(), client.ObjectKey{Namespace: options.Namespace, Name: options.MachineName}, machine); err != nil {
+		return nil, errors.Wrapf(err, "failed to get Machine %s/%s", options.Namespace, options.MachineName)
+	}
+
+	addresses := &MachineAddresses{}
+	addresses.External, addresses.Internal = splitMachineAddresses(machine.Status.Addresses)
+	if len(addresses.External) == 0 && len(addresses.Internal) == 0 {
+		return nil, errors.Errorf("Machine %s/%s does not have addresses yet", options.Namespace, options.MachineName)
+	}
+	if len(addresses.External) > 0 {
+		return addresses, nil
+	}
+
+	// If the Machine has only internal addresses, look for another Machine in the same Cluster which can be used as a jump host.
+	machines := &clusterv1.MachineList{}
+	if err := cl.List(context.TODO(), machines, client.InNamespace(options.Namespace), client.MatchingLabels{clusterv1.ClusterLabelName: machine.Spec.ClusterName}); err != nil {
+		return nil, errors.Wrapf(err, "failed to list Machines for Cluster %s/%s", options.Namespace, machine.Spec.ClusterName)
+	}
+	addresses.JumpHost = selectJumpHost(machines.Items)
+
+	return addresses, nil
+}
+
+// splitMachineAddresses splits machine addresses into external and internal addresses, IPs first.
+func splitMachineAddresses(machineAddresses clusterv1.MachineAddresses) (external, internal []string) {
+	for _, addressType := range []clusterv1.MachineAddressType{clusterv1.MachineExternalIP, clusterv1.MachineExternalDNS, clusterv1.MachineInternalIP, clusterv1.MachineInternalDNS} {
+		for _, a := range machineAddresses {
+			if a.Type != addressType || a.Address == "" {
+				continue
+			}
+			if addressType == clusterv1.MachineExternalIP || addressType == clusterv1.MachineExternalDNS {
+				external = append(external, a.Address)
+				continue
+			}
+			internal = append(internal, a.Address)
+		}
+	}
+	return external, internal
+}
+
+// selectJumpHost returns the first external address of the given machines, giving precedence to control plane machines.
+func selectJumpHost(machines []clusterv1.Machine) string {
+	sort.SliceStable(machines, func(i, j int) bool {
+		return util.IsControlPlaneMachine(&machines[i]) && !util.IsControlPlaneMachine(&machines[j])
+	})
+	for i := range machines {
+		if !machines[i].DeletionTimestamp.IsZero() {
+			continue
+		}
+		if external, _ := splitMachineAddresses(machines[i].Status.Addresses); len(external) > 0 {
+			return external[0]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+)
+
+func Test_clusterctlClient_GetMachineAddresses(t *testing.T) {
+	machine := func(name string, controlPlane bool, addresses ...clusterv1.MachineAddress) *clusterv1.Machine {
+		m := &clusterv1.Machine{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Machine",
+				APIVersion: clusterv1.GroupVersion.String(),
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      name,
+				Labels: map[string]string{
+					clusterv1.ClusterLabelName: "cluster1",
+				},
+			},
+			Spec: clusterv1.MachineSpec{
+				ClusterName: "cluster1",
+			},
+			Status: clusterv1.MachineStatus{
+				Addresses: addresses,
+			},
+		}
+		if controlPlane {
+			m.Labels[clusterv1.MachineControlPlaneLabelName] = ""
+		}
+		return m
+	}
+
+	core := config.NewProvider("cluster-api", "https://somewhere.com", clusterctlv1.CoreProviderType)
+	config1 := newFakeConfig().
+		WithProvider(core)
+
+	cluster1 := newFakeCluster(cluster.Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"}, config1).
+		WithProviderInventory(core.Name(), core.Type(), "v1.0.0", "cluster-api-system", "").
+		WithObjs(
+			machine("no-addresses", false),
+			machine("external", false,
+				clusterv1.MachineAddress{Type: clusterv1.MachineInternalIP, Address: "10.0.0.1"},
+				clusterv1.MachineAddress{Type: clusterv1.MachineExternalDNS, Address: "external.example.com"},
+				clusterv1.MachineAddress{Type: clusterv1.MachineExternalIP, Address: "1.2.3.4"},
+			),
+			machine("internal", false,
+				clusterv1.MachineAddress{Type: clusterv1.MachineInternalIP, Address: "10.0.0.2"},
+			),
+			machine("control-plane", true,
+				clusterv1.MachineAddress{Type: clusterv1.MachineInternalIP, Address: "10.0.0.3"},
+				clusterv1.MachineAddress{Type: clusterv1.MachineExternalIP, Address: "5.6.7.8"},
+			),
+		)
+	cluster1.fakeProxy.WithFakeCAPISetup()
+
+	client := newFakeClient(config1).
+		WithCluster(cluster1)
+
+	tests := []struct {
+		name    string
+		machine string
+		want    *MachineAddresses
+		wantErr bool
+	}{
+		{
+			name:    "returns the external and internal addresses of a machine",
+			machine: "external",
+			want: &MachineAddresses{
+				External: []string{"1.2.3.4", "external.example.com"},
+				Internal: []string{"10.0.0.1"},
+			},
+		},
+		{
+			name:    "returns a jump host for a machine without external addresses, giving precedence to control plane machines",
+			machine: "internal",
+			want: &MachineAddresses{
+				Internal: []string{"10.0.0.2"},
+				JumpHost: "5.6.7.8",
+			},
+		},
+		{
+			name:    "fails if the machine does not have addresses",
+			machine: "no-addresses",
+			wantErr: true,
+		},
+		{
+			name:    "fails if the machine does not exist",
+			machine: "does-not-exist",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := client.GetMachineAddresses(GetMachineAddressesOptions{
+				Kubeconfig:  Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+				Namespace:   "default",
+				MachineName: tt.machine,
+			})
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+)
+
+// GetMachineLogsOptions carries all the options supported by GetMachineLogs.
+type GetMachineLogsOptions struct {
+	// Kubeconfig defines the kubeconfig to use for accessing the management cluster. If empty,
+	// default rules for kubeconfig discovery will be used.
+	Kubeconfig Kubeconfig
+
+	// Namespace where the Machine is located. If unspecified, the current namespace will be used.
+	Namespace string
+
+	// MachineName is the name of the Machine.
+	MachineName string
+
+	// NodeLogFiles is the list of files read from /var/log on the Node when the infrastructure provider does not expose
+	// console logs. If empty, cluster.DefaultNodeLogFiles are read.
+	NodeLogFiles []string
+}
+
+// GetMachineLogs returns the console logs exposed by the infrastructure provider for a Machine, e.g. for investigating
+// a Machine which failed to bootstrap; if the provider does not expose console logs, the logs are read from the Node of the Machine.
+func (c *clusterctlClient) GetMachineLogs(options GetMachineLogsOptions) (*MachineLogs, error) {
+	// gets access to the management cluster
+	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{Kubeconfig: options.Kubeconfig})
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure this command only runs against management clusters with the current Cluster API contract.
+	if err := clusterClient.ProviderInventory().CheckCAPIContract(); err != nil {
+		return nil, err
+	}
+
+	// If the option specifying the Namespace is empty, try to detect it.
+	if options.Namespace == "" {
+		currentNamespace, err := clusterClient.Proxy().CurrentNamespace()
+		if err != nil {
+			return nil, err
+		}
+		options.Namespace = currentNamespace
+	}
+
+	logs, err := clusterClient.MachineLogs().Get(cluster.MachineLogsOptions{
+		Namespace:    options.Namespace,
+		MachineName:  options.MachineName,
+		NodeLogFiles: options.NodeLogFiles,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return (*MachineLogs)(logs), nil
+}
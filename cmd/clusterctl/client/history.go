@@ -0,0 +1,106 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+)
+
+// GetOperationHistoryOptions carries the options supported by GetOperationHistory.
+type GetOperationHistoryOptions struct {
+	// Kubeconfig defines the kubeconfig to use for accessing the management cluster. If empty,
+	// default rules for kubeconfig discovery will be used.
+	Kubeconfig Kubeconfig
+
+	// Operation filters the history by operation, e.g. init or move; if empty, all the operations are returned.
+	Operation string
+
+	// Limit is the maximum number of operations to return, starting from the most recent; zero means no limit.
+	Limit int
+}
+
+// GetOperationHistory returns the operations performed by clusterctl on the management cluster, sorted from
+// the most recent to the oldest.
+func (c *clusterctlClient) GetOperationHistory(options GetOperationHistoryOptions) ([]OperationHistoryEntry, error) {
+	if options.Limit < 0 {
+		return nil, errors.New("the limit must not be negative")
+	}
+
+	// gets access to the management cluster
+	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{Kubeconfig: options.Kubeconfig})
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := clusterClient.Audit().List(cluster.AuditListOptions{
+		Operation: options.Operation,
+		Limit:     options.Limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]OperationHistoryEntry, len(entries))
+	for i := range entries {
+		history[i] = OperationHistoryEntry(entries[i])
+	}
+	return history, nil
+}
+
+// recordOperation logs the outcome of an operation performed on the management cluster through the logger of the client, with its
+// parameters and duration as structured values, and records the operation in the audit log of the management cluster.
+func (c *clusterctlClient) recordOperation(clusterClient cluster.Client, operation string, started time.Time, parameters map[string]string, err error) {
+	log := c.logger.WithValues(operationLogValues(operation, parameters)...)
+	duration := time.Since(started).Round(time.Millisecond).String()
+
+	entry := cluster.AuditEntry{
+		Operation:  operation,
+		Parameters: parameters,
+		Outcome:    cluster.AuditOutcomeSucceeded,
+	}
+	if err != nil {
+		entry.Outcome = cluster.AuditOutcomeFailed
+		entry.Message = err.Error()
+		log.V(1).Info("Operation failed", "Duration", duration, "Error", err.Error())
+	} else {
+		log.V(1).Info("Operation completed", "Duration", duration)
+	}
+	clusterClient.Audit().Record(entry)
+}
+
+// operationLogValues returns the key/value pairs identifying an operation in the logs, i.e. the name of the operation followed by
+// its non-empty parameters, sorted by name; following the logging conventions of clusterctl, the keys start with a capital letter.
+func operationLogValues(operation string, parameters map[string]string) []interface{} {
+	keys := make([]string, 0, len(parameters))
+	for k, v := range parameters {
+		if v != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	values := []interface{}{"Operation", operation}
+	for _, k := range keys {
+		values = append(values, strings.ToUpper(k[:1])+k[1:], parameters[k])
+	}
+	return values
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+)
+
+func Test_clusterctlClient_GetOperationHistory(t *testing.T) {
+	g := NewWithT(t)
+
+	client := fakeClusterForDelete()
+	kubeconfig := Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"}
+
+	// A successful and a failed operation are recorded in the audit log.
+	g.Expect(client.Delete(DeleteOptions{
+		Kubeconfig:         kubeconfig,
+		Namespace:          "capbpk-system",
+		BootstrapProviders: []string{bootstrapProviderConfig.Name()},
+	})).To(Succeed())
+	g.Expect(client.Delete(DeleteOptions{
+		Kubeconfig:         kubeconfig,
+		Namespace:          "capbpk-system",
+		BootstrapProviders: []string{"foo:bar:baz"},
+	})).NotTo(Succeed())
+
+	history, err := client.GetOperationHistory(GetOperationHistoryOptions{Kubeconfig: kubeconfig})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(history).To(HaveLen(2))
+
+	g.Expect(history[0].Operation).To(Equal(cluster.AuditOperationDelete))
+	g.Expect(history[0].Outcome).To(Equal(cluster.AuditOutcomeFailed))
+	g.Expect(history[0].Message).NotTo(BeEmpty())
+
+	g.Expect(history[1].Operation).To(Equal(cluster.AuditOperationDelete))
+	g.Expect(history[1].Outcome).To(Equal(cluster.AuditOutcomeSucceeded))
+	g.Expect(history[1].Parameters).To(HaveKeyWithValue("bootstrapProviders", bootstrapProviderConfig.Name()))
+	g.Expect(history[1].Parameters).To(HaveKeyWithValue("namespace", "capbpk-system"))
+
+	// The history can be filtered by operation.
+	history, err = client.GetOperationHistory(GetOperationHistoryOptions{Kubeconfig: kubeconfig, Operation: cluster.AuditOperationMove})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(history).To(BeEmpty())
+
+	_, err = client.GetOperationHistory(GetOperationHistoryOptions{Kubeconfig: kubeconfig, Limit: -1})
+	g.Expect(err).To(HaveOccurred())
+}
+
+func Test_recordOperation_Logs(t *testing.T) {
+	g := NewWithT(t)
+
+	logger := &recordingLogger{}
+	client, err := newClusterctlClient("", InjectConfig(newFakeConfig()), InjectLogger(logger))
+	g.Expect(err).NotTo(HaveOccurred())
+	// The injected logger is used by the client only, and the global logger is left untouched.
+	g.Expect(logf.Log).NotTo(BeIdenticalTo(logger))
+
+	clusterClient := newFakeCluster(cluster.Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"}, newFakeConfig())
+	parameters := map[string]string{"namespace": "ns1", "clusterName": "cluster1", "toContext": ""}
+
+	client.recordOperation(clusterClient, cluster.AuditOperationMove, time.Now(), parameters, nil)
+	client.recordOperation(clusterClient, cluster.AuditOperationMove, time.Now(), parameters, errors.New("failed"))
+
+	g.Expect(logger.entries).To(HaveLen(2))
+	g.Expect(logger.entries[0].msg).To(Equal("Operation completed"))
+	g.Expect(logger.entries[0].level).To(Equal(1))
+	g.Expect(logger.entries[0].values[:6]).To(Equal([]interface{}{"Operation", "move", "ClusterName", "cluster1", "Namespace", "ns1"}))
+	g.Expect(logger.entries[0].values[6]).To(Equal("Duration"))
+	g.Expect(logger.entries[1].msg).To(Equal("Operation failed"))
+	g.Expect(logger.entries[1].values).To(ContainElements("Error", "failed"))
+}
+
+// recordingLogger is a logr.Logger recording the log entries, so tests can check them.
+type recordingLogger struct {
+	level   int
+	values  []interface{}
+	entries []recordedLogEntry
+	root    *recordingLogger
+}
+
+type recordedLogEntry struct {
+	level  int
+	msg    string
+	values []interface{}
+}
+
+var _ logr.Logger = &recordingLogger{}
+
+func (l *recordingLogger) Enabled() bool { return true }
+
+func (l *recordingLogger) Info(msg string, kvs ...interface{}) {
+	root := l
+	if l.root != nil {
+		root = l.root
+	}
+	values := append(append([]interface{}{}, l.values...), kvs...)
+	root.entries = append(root.entries, recordedLogEntry{level: l.level, msg: msg, values: values})
+}
+
+func (l *recordingLogger) Error(err error, msg string, kvs ...interface{}) {
+	l.Info(msg, append(kvs, "Error", err.Error())...)
+}
+
+func (l *recordingLogger) V(level int) logr.Logger {
+	return l.derive(l.level+level, nil)
+}
+
+func (l *recordingLogger) WithValues(kvs ...interface{}) logr.Logger {
+	return l.derive(l.level, kvs)
+}
+
+func (l *recordingLogger) WithName(_ string) logr.Logger {
+	return l.derive(l.level, nil)
+}
+
+func (l *recordingLogger) derive(level int, kvs []interface{}) *recordingLogger {
+	root := l
+	if l.root != nil {
+		root = l.root
+	}
+	return &recordingLogger{
+		level:  level,
+		values: append(append([]interface{}{}, l.values...), kvs...),
+		root:   root,
+	}
+}
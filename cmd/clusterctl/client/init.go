@@ -18,6 +18,8 @@ package client
 
 import (
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
@@ -61,13 +63,24 @@ type InitOptions struct {
 	// LogUsageInstructions instructs the init command to print the usage instructions in case of first run.
 	LogUsageInstructions bool
 
+	// WaitProviders instructs the init command to wait for the providers each provider depends on to be ready before installing it,
+	// and for all the providers to be ready before returning.
+	WaitProviders bool
+
+	// WaitProviderTimeout sets the timeout for waiting for a provider to be ready.
+	WaitProviderTimeout time.Duration
+
+	// SkipCompatibilityCheck skips the check of the compatibility of the providers with the version of the core provider
+	// and with the Kubernetes version of the management cluster, as defined in the provider metadata.
+	SkipCompatibilityCheck bool
+
 	// skipVariables skips variable parsing in the provider components yaml.
 	// It is set to true for listing images of provider components.
 	skipVariables bool
 }
 
 // Init initializes a management cluster by adding the requested list of providers.
-func (c *clusterctlClient) Init(options InitOptions) ([]Components, error) {
+func (c *clusterctlClient) Init(options InitOptions) (_ []Components, err error) {
 	log := logf.Log
 
 	// gets access to the management cluster
@@ -76,6 +89,19 @@ func (c *clusterctlClient) Init(options InitOptions) ([]Components, error) {
 		return nil, err
 	}
 
+	// records the operation in the audit log of the management cluster, including the default providers added on first run.
+	started := time.Now()
+	defer func() {
+		c.recordOperation(clusterClient, cluster.AuditOperationInit, started, map[string]string{
+			"coreProvider":            options.CoreProvider,
+			"bootstrapProviders":      strings.Join(options.BootstrapProviders, ","),
+			"controlPlaneProviders":   strings.Join(options.ControlPlaneProviders, ","),
+			"infrastructureProviders": strings.Join(options.InfrastructureProviders, ","),
+			"targetNamespace":         options.TargetNamespace,
+			"watchingNamespace":       options.WatchingNamespace,
+		}, err)
+	}()
+
 	// ensure the custom resource definitions required by clusterctl are in place
 	if err := clusterClient.ProviderInventory().EnsureCustomResourceDefinitions(); err != nil {
 		return nil, err
@@ -105,7 +131,8 @@ func (c *clusterctlClient) Init(options InitOptions) ([]Components, error) {
 	// - Providers combines in valid management groups
 	//   - All the providers should belong to one/only one management groups
 	//   - All the providers in a management group must support the same API Version of Cluster API (contract)
-	if err := installer.Validate(); err != nil {
+	// - Providers are compatible with the version of the core provider and with the Kubernetes version of the management cluster.
+	if err := installer.Validate(cluster.ValidateOptions{SkipCompatibilityCheck: options.SkipCompatibilityCheck}); err != nil {
 		return nil, err
 	}
 
@@ -119,7 +146,10 @@ func (c *clusterctlClient) Init(options InitOptions) ([]Components, error) {
 		return nil, err
 	}
 
-	components, err := installer.Install()
+	components, err := installer.Install(cluster.InstallOptions{
+		WaitProviders:       options.WaitProviders,
+		WaitProviderTimeout: options.WaitProviderTimeout,
+	})
 	if err != nil {
 		return nil, err
 	}
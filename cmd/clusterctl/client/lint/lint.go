@@ -0,0 +1,371 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package lint inspects cluster templates for violations of the Cluster API contract.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util/version"
+)
+
+// Severity of a finding.
+type Severity string
+
+const (
+	// SeverityError is used for findings which prevent the template from working as expected.
+	SeverityError Severity = "error"
+
+	// SeverityWarning is used for findings which might be intended, but are likely to be mistakes.
+	SeverityWarning Severity = "warning"
+)
+
+const (
+	// RuleMissingRef is reported when an object does not reference a required object,
+	// e.g. a MachineDeployment without an infrastructure or bootstrap reference.
+	RuleMissingRef = "missing-ref"
+
+	// RuleUnresolvedRef is reported when a reference points to an object which is not defined in the template.
+	RuleUnresolvedRef = "unresolved-ref"
+
+	// RuleAPIVersionMismatch is reported when the apiVersion of a reference is different from the
+	// apiVersion of the referenced object defined in the template.
+	RuleAPIVersionMismatch = "apiversion-mismatch"
+
+	// RuleSelectorMismatch is reported when the selector of a MachineDeployment or a MachineSet does
+	// not match the labels of its machine template.
+	RuleSelectorMismatch = "selector-mismatch"
+
+	// RuleVersionMismatch is reported when the Kubernetes version of the machines of a Cluster
+	// is different from the version of its control plane.
+	RuleVersionMismatch = "version-mismatch"
+)
+
+var (
+	clusterGroup      = clusterv1.GroupVersion.Group
+	expGroup          = "exp." + clusterv1.GroupVersion.Group
+	controlPlaneGroup = "controlplane." + clusterv1.GroupVersion.Group
+)
+
+// Finding is a contract violation found in a template.
+type Finding struct {
+	// Rule is the identifier of the violated rule.
+	Rule string `json:"rule"`
+
+	// Severity of the finding.
+	Severity Severity `json:"severity"`
+
+	// Kind, Namespace and Name identify the object the finding is about.
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+
+	// Field is the path of the field the finding is about, if any.
+	Field string `json:"field,omitempty"`
+
+	// Message is a human readable description of the finding.
+	Message string `json:"message"`
+}
+
+// String returns a human readable representation of the finding.
+func (f Finding) String() string {
+	name := f.Name
+	if f.Namespace != "" {
+		name = f.Namespace + "/" + f.Name
+	}
+	field := ""
+	if f.Field != "" {
+		field = " " + f.Field + ":"
+	}
+	return fmt.Sprintf("%s [%s] %s %s%s %s", strings.ToUpper(string(f.Severity)), f.Rule, f.Kind, name, field, f.Message)
+}
+
+// HasErrors returns true if any of the findings has SeverityError.
+func HasErrors(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// linter holds the objects of the template being inspected and the findings.
+type linter struct {
+	objs     []unstructured.Unstructured
+	findings []Finding
+}
+
+// Lint inspects the objects of a cluster template and returns the contract violations found.
+// Variables in the template are not required to be replaced, so templates can be linted as they are published.
+func Lint(objs []unstructured.Unstructured) []Finding {
+	l := &linter{objs: objs, findings: []Finding{}}
+
+	for i := range objs {
+		obj := &objs[i]
+		gvk := obj.GroupVersionKind()
+		switch {
+		case gvk.Group == clusterGroup && gvk.Kind == "Cluster":
+			l.lintCluster(obj)
+		case gvk.Group == clusterGroup && (gvk.Kind == "MachineDeployment" || gvk.Kind == "MachineSet"):
+			l.lintMachineTemplate(obj, "spec", "template", "spec")
+			l.lintSelector(obj)
+		case gvk.Group == expGroup && gvk.Kind == "MachinePool":
+			l.lintMachineTemplate(obj, "spec", "template", "spec")
+		case gvk.Group == clusterGroup && gvk.Kind == "Machine":
+			l.lintMachineTemplate(obj, "spec")
+		case gvk.Group == controlPlaneGroup && gvk.Kind == "KubeadmControlPlane":
+			l.lintRef(obj, true, "spec", "infrastructureTemplate")
+		}
+	}
+	l.lintVersions()
+
+	sort.SliceStable(l.findings, func(i, j int) bool {
+		return l.findings[i].Severity == SeverityError && l.findings[j].Severity != SeverityError
+	})
+	return l.findings
+}
+
+// report adds a finding about obj.
+func (l *linter) report(obj *unstructured.Unstructured, rule string, severity Severity, fields []string, format string, args ...interface{}) {
+	l.findings = append(l.findings, Finding{
+		Rule:      rule,
+		Severity:  severity,
+		Kind:      obj.GetKind(),
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+		Field:     strings.Join(fields, "."),
+		Message:   fmt.Sprintf(format, args...),
+	})
+}
+
+// lintCluster checks the references of a Cluster; Clusters using a managed topology are skipped,
+// because their references are generated.
+func (l *linter) lintCluster(obj *unstructured.Unstructured) {
+	if _, ok, _ := unstructured.NestedMap(obj.Object, "spec", "topology"); ok {
+		return
+	}
+	l.lintRef(obj, true, "spec", "infrastructureRef")
+	l.lintRef(obj, false, "spec", "controlPlaneRef")
+}
+
+// lintMachineTemplate checks the infrastructure and bootstrap references of a machine spec at the given path.
+func (l *linter) lintMachineTemplate(obj *unstructured.Unstructured, fields ...string) {
+	l.lintRef(obj, true, fieldPath(fields, "infrastructureRef")...)
+
+	bootstrap := fieldPath(fields, "bootstrap")
+	if _, ok, _ := unstructured.NestedString(obj.Object, fieldPath(bootstrap, "dataSecretName")...); ok {
+		return
+	}
+	if _, ok, _ := unstructured.NestedMap(obj.Object, fieldPath(bootstrap, "configRef")...); !ok {
+		l.report(obj, RuleMissingRef, SeverityError, bootstrap, "either configRef or dataSecretName must be set")
+		return
+	}
+	l.lintRef(obj, true, fieldPath(bootstrap, "configRef")...)
+}
+
+// fieldPath returns a new field path obtained by appending fields to base.
+func fieldPath(base []string, fields ...string) []string {
+	return append(append([]string{}, base...), fields...)
+}
+
+// lintRef checks the object reference at the given path; if the referenced object is defined in the template,
+// the apiVersion of the reference must match the apiVersion of the object.
+func (l *linter) lintRef(obj *unstructured.Unstructured, required bool, fields ...string) {
+	ref, ok, _ := unstructured.NestedStringMap(obj.Object, fields...)
+	if !ok {
+		if required {
+			l.report(obj, RuleMissingRef, SeverityError, fields, "reference is required")
+		}
+		return
+	}
+
+	if ref["kind"] == "" || ref["name"] == "" || ref["apiVersion"] == "" {
+		l.report(obj, RuleMissingRef, SeverityError, fields, "reference must have apiVersion, kind and name")
+		return
+	}
+
+	namespace := ref["namespace"]
+	if namespace == "" {
+		namespace = obj.GetNamespace()
+	}
+	refGV, err := schema.ParseGroupVersion(ref["apiVersion"])
+	if err != nil {
+		l.report(obj, RuleMissingRef, SeverityError, fields, "invalid apiVersion %q", ref["apiVersion"])
+		return
+	}
+
+	target := l.find(refGV.Group, ref["kind"], namespace, ref["name"])
+	if target == nil {
+		l.report(obj, RuleUnresolvedRef, SeverityWarning, fields, "%s %s is not defined in the template", ref["kind"], ref["name"])
+		return
+	}
+	if target.GetAPIVersion() != ref["apiVersion"] {
+		l.report(obj, RuleAPIVersionMismatch, SeverityError, fields, "reference has apiVersion %s, but %s %s is defined with apiVersion %s",
+			ref["apiVersion"], target.GetKind(), target.GetName(), target.GetAPIVersion())
+	}
+}
+
+// find returns the object of the given group, kind, namespace and name, ignoring the version, or nil if not defined in the template.
+func (l *linter) find(group, kind, namespace, name string) *unstructured.Unstructured {
+	for i := range l.objs {
+		obj := &l.objs[i]
+		if obj.GroupVersionKind().Group == group && obj.GetKind() == kind && obj.GetNamespace() == namespace && obj.GetName() == name {
+			return obj
+		}
+	}
+	return nil
+}
+
+// lintSelector checks that the selector of a MachineDeployment or MachineSet matches the labels of its machine template,
+// taking into account the cluster name label which is added to both by the webhooks.
+// Label values are not validated, so selectors using variables can be checked.
+func (l *linter) lintSelector(obj *unstructured.Unstructured) {
+	selectorMap, ok, _ := unstructured.NestedMap(obj.Object, "spec", "selector")
+	if !ok {
+		return
+	}
+
+	selector := &metav1.LabelSelector{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(selectorMap, selector); err != nil {
+		l.report(obj, RuleSelectorMismatch, SeverityError, []string{"spec", "selector"}, "invalid selector: %v", err)
+		return
+	}
+
+	templateLabels, _, _ := unstructured.NestedStringMap(obj.Object, "spec", "template", "metadata", "labels")
+	if templateLabels == nil {
+		templateLabels = map[string]string{}
+	}
+	if clusterName, _, _ := unstructured.NestedString(obj.Object, "spec", "clusterName"); clusterName != "" {
+		templateLabels[clusterv1.ClusterLabelName] = clusterName
+	}
+
+	if !selectorMatches(selector, templateLabels) {
+		l.report(obj, RuleSelectorMismatch, SeverityError, []string{"spec", "selector"},
+			"selector %q does not match the labels of spec.template.metadata.labels", metav1.FormatLabelSelector(selector))
+	}
+}
+
+// selectorMatches returns true if the label selector matches the given labels.
+func selectorMatches(selector *metav1.LabelSelector, labels map[string]string) bool {
+	for k, v := range selector.MatchLabels {
+		if labels[k] != v {
+			return false
+		}
+	}
+	for _, r := range selector.MatchExpressions {
+		value, exists := labels[r.Key]
+		switch r.Operator {
+		case metav1.LabelSelectorOpIn:
+			if !exists || !contains(r.Values, value) {
+				return false
+			}
+		case metav1.LabelSelectorOpNotIn:
+			if exists && contains(r.Values, value) {
+				return false
+			}
+		case metav1.LabelSelectorOpExists:
+			if !exists {
+				return false
+			}
+		case metav1.LabelSelectorOpDoesNotExist:
+			if exists {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// lintVersions checks that the Kubernetes version of the MachineDeployments and MachinePools of each Cluster
+// is consistent with the version of the KubeadmControlPlane referenced by the Cluster.
+func (l *linter) lintVersions() {
+	for i := range l.objs {
+		cluster := &l.objs[i]
+		if cluster.GroupVersionKind().Group != clusterGroup || cluster.GetKind() != "Cluster" {
+			continue
+		}
+
+		ref, ok, _ := unstructured.NestedStringMap(cluster.Object, "spec", "controlPlaneRef")
+		if !ok {
+			continue
+		}
+		refGV, err := schema.ParseGroupVersion(ref["apiVersion"])
+		if err != nil {
+			continue
+		}
+		namespace := ref["namespace"]
+		if namespace == "" {
+			namespace = cluster.GetNamespace()
+		}
+		controlPlane := l.find(refGV.Group, ref["kind"], namespace, ref["name"])
+		if controlPlane == nil {
+			continue
+		}
+		controlPlaneVersion, ok, _ := unstructured.NestedString(controlPlane.Object, "spec", "version")
+		if !ok {
+			continue
+		}
+
+		for j := range l.objs {
+			obj := &l.objs[j]
+			gvk := obj.GroupVersionKind()
+			if !(gvk.Group == clusterGroup && gvk.Kind == "MachineDeployment") && !(gvk.Group == expGroup && gvk.Kind == "MachinePool") {
+				continue
+			}
+			if clusterName, _, _ := unstructured.NestedString(obj.Object, "spec", "clusterName"); clusterName != cluster.GetName() || obj.GetNamespace() != cluster.GetNamespace() {
+				continue
+			}
+			l.lintVersion(obj, controlPlane, controlPlaneVersion)
+		}
+	}
+}
+
+// lintVersion compares the version of the machines of obj with the version of the control plane.
+func (l *linter) lintVersion(obj, controlPlane *unstructured.Unstructured, controlPlaneVersion string) {
+	fields := []string{"spec", "template", "spec", "version"}
+	machineVersion, ok, _ := unstructured.NestedString(obj.Object, fields...)
+	if !ok || machineVersion == controlPlaneVersion {
+		return
+	}
+
+	cpv, cpErr := version.ParseMajorMinorPatch(controlPlaneVersion)
+	mv, mErr := version.ParseMajorMinorPatch(machineVersion)
+	if cpErr == nil && mErr == nil && mv.GT(cpv) {
+		l.report(obj, RuleVersionMismatch, SeverityError, fields, "version %s is newer than version %s of %s %s",
+			machineVersion, controlPlaneVersion, controlPlane.GetKind(), controlPlane.GetName())
+		return
+	}
+	l.report(obj, RuleVersionMismatch, SeverityWarning, fields, "version %s is different from version %s of %s %s",
+		machineVersion, controlPlaneVersion, controlPlane.GetKind(), controlPlane.GetName())
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	utilyaml "sigs.k8s.io/cluster-api/util/yaml"
+)
+
+const validTemplate = `
+apiVersion: cluster.x-k8s.io/v1alpha4
+kind: Cluster
+metadata:
+  name: ${CLUSTER_NAME}
+spec:
+  infrastructureRef:
+    apiVersion: infrastructure.cluster.x-k8s.io/v1alpha4
+    kind: DockerCluster
+    name: ${CLUSTER_NAME}
+  controlPlaneRef:
+    apiVersion: controlplane.cluster.x-k8s.io/v1alpha4
+    kind: KubeadmControlPlane
+    name: ${CLUSTER_NAME}-control-plane
+---
+apiVersion: infrastructure.cluster.x-k8s.io/v1alpha4
+kind: DockerCluster
+metadata:
+  name: ${CLUSTER_NAME}
+---
+apiVersion: infrastructure.cluster.x-k8s.io/v1alpha4
+kind: DockerMachineTemplate
+metadata:
+  name: ${CLUSTER_NAME}-control-plane
+---
+apiVersion: controlplane.cluster.x-k8s.io/v1alpha4
+kind: KubeadmControlPlane
+metadata:
+  name: ${CLUSTER_NAME}-control-plane
+spec:
+  infrastructureTemplate:
+    apiVersion: infrastructure.cluster.x-k8s.io/v1alpha4
+    kind: DockerMachineTemplate
+    name: ${CLUSTER_NAME}-control-plane
+  version: ${KUBERNETES_VERSION}
+---
+apiVersion: infrastructure.cluster.x-k8s.io/v1alpha4
+kind: DockerMachineTemplate
+metadata:
+  name: ${CLUSTER_NAME}-md-0
+---
+apiVersion: bootstrap.cluster.x-k8s.io/v1alpha4
+kind: KubeadmConfigTemplate
+metadata:
+  name: ${CLUSTER_NAME}-md-0
+---
+apiVersion: cluster.x-k8s.io/v1alpha4
+kind: MachineDeployment
+metadata:
+  name: ${CLUSTER_NAME}-md-0
+spec:
+  clusterName: ${CLUSTER_NAME}
+  selector:
+    matchLabels:
+      cluster.x-k8s.io/cluster-name: ${CLUSTER_NAME}
+  template:
+    spec:
+      clusterName: ${CLUSTER_NAME}
+      version: ${KUBERNETES_VERSION}
+      bootstrap:
+        configRef:
+          apiVersion: bootstrap.cluster.x-k8s.io/v1alpha4
+          kind: KubeadmConfigTemplate
+          name: ${CLUSTER_NAME}-md-0
+      infrastructureRef:
+        apiVersion: infrastructure.cluster.x-k8s.io/v1alpha4
+        kind: DockerMachineTemplate
+        name: ${CLUSTER_NAME}-md-0
+`
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name      string
+		mutate    func(template string) string
+		wantRules []string
+		wantErrs  bool
+	}{
+		{
+			name:      "valid template",
+			mutate:    func(template string) string { return template },
+			wantRules: []string{},
+			wantErrs:  false,
+		},
+		{
+			name: "missing infrastructureRef in a Cluster",
+			mutate: func(template string) string {
+				return strings.Replace(template, `  infrastructureRef:
+    apiVersion: infrastructure.cluster.x-k8s.io/v1alpha4
+    kind: DockerCluster
+    name: ${CLUSTER_NAME}
+`, "", 1)
+			},
+			wantRules: []string{RuleMissingRef},
+			wantErrs:  true,
+		},
+		{
+			name: "missing bootstrap in a MachineDeployment",
+			mutate: func(template string) string {
+				return strings.Replace(template, `      bootstrap:
+        configRef:
+          apiVersion: bootstrap.cluster.x-k8s.io/v1alpha4
+          kind: KubeadmConfigTemplate
+          name: ${CLUSTER_NAME}-md-0
+`, "", 1)
+			},
+			wantRules: []string{RuleMissingRef},
+			wantErrs:  true,
+		},
+		{
+			name: "apiVersion mismatch between a reference and the referenced object",
+			mutate: func(template string) string {
+				return strings.Replace(template, "apiVersion: bootstrap.cluster.x-k8s.io/v1alpha4\n          kind: KubeadmConfigTemplate",
+					"apiVersion: bootstrap.cluster.x-k8s.io/v1alpha3\n          kind: KubeadmConfigTemplate", 1)
+			},
+			wantRules: []string{RuleAPIVersionMismatch},
+			wantErrs:  true,
+		},
+		{
+			name: "reference to an object not defined in the template",
+			mutate: func(template string) string {
+				return strings.Replace(template, "name: ${CLUSTER_NAME}-md-0\n---\napiVersion: bootstrap", "name: other\n---\napiVersion: bootstrap", 1)
+			},
+			wantRules: []string{RuleUnresolvedRef},
+			wantErrs:  false,
+		},
+		{
+			name: "selector not matching the template labels",
+			mutate: func(template string) string {
+				return strings.Replace(template, "      cluster.x-k8s.io/cluster-name: ${CLUSTER_NAME}\n", "      pool: md-0\n", 1)
+			},
+			wantRules: []string{RuleSelectorMismatch},
+			wantErrs:  true,
+		},
+		{
+			name: "MachineDeployment version different from the control plane version",
+			mutate: func(template string) string {
+				return strings.Replace(template, "      version: ${KUBERNETES_VERSION}", "      version: ${WORKER_KUBERNETES_VERSION}", 1)
+			},
+			wantRules: []string{RuleVersionMismatch},
+			wantErrs:  false,
+		},
+		{
+			name: "MachineDeployment version newer than the control plane version",
+			mutate: func(template string) string {
+				template = strings.Replace(template, "  version: ${KUBERNETES_VERSION}", "  version: v1.20.1", 1)
+				return strings.Replace(template, "      version: ${KUBERNETES_VERSION}", "      version: v1.21.0", 1)
+			},
+			wantRules: []string{RuleVersionMismatch},
+			wantErrs:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			objs, err := utilyaml.ToUnstructured([]byte(tt.mutate(validTemplate)))
+			g.Expect(err).NotTo(HaveOccurred())
+
+			findings := Lint(objs)
+			rules := []string{}
+			for _, f := range findings {
+				rules = append(rules, f.Rule)
+			}
+			g.Expect(rules).To(ConsistOf(tt.wantRules), "findings: %v", findings)
+			g.Expect(HasErrors(findings)).To(Equal(tt.wantErrs))
+		})
+	}
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package client
 
 import (
+	"time"
+
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
 )
 
@@ -34,11 +36,15 @@ type MoveOptions struct {
 	// namespace will be used.
 	Namespace string
 
+	// ClusterName of the Cluster to be moved together with all the objects belonging to it. If unspecified,
+	// all the Clusters existing in the namespace will be moved.
+	ClusterName string
+
 	// DryRun means the move action is a dry run, no real action will be performed
 	DryRun bool
 }
 
-func (c *clusterctlClient) Move(options MoveOptions) error {
+func (c *clusterctlClient) Move(options MoveOptions) (err error) {
 	// Get the client for interacting with the source management cluster.
 	fromCluster, err := c.clusterClientFactory(ClusterClientFactoryInput{Kubeconfig: options.FromKubeconfig})
 	if err != nil {
@@ -83,7 +89,19 @@ func (c *clusterctlClient) Move(options MoveOptions) error {
 		options.Namespace = currentNamespace
 	}
 
-	if err := fromCluster.ObjectMover().Move(options.Namespace, toCluster, options.DryRun); err != nil {
+	// A dry run does not change the management cluster, so it is not recorded in the audit log.
+	if !options.DryRun {
+		started := time.Now()
+		defer func() {
+			c.recordOperation(fromCluster, cluster.AuditOperationMove, started, map[string]string{
+				"namespace":   options.Namespace,
+				"clusterName": options.ClusterName,
+				"toContext":   options.ToKubeconfig.Context,
+			}, err)
+		}()
+	}
+
+	if err := fromCluster.ObjectMover().Move(options.Namespace, options.ClusterName, toCluster, options.DryRun); err != nil {
 		return err
 	}
 
@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
@@ -125,6 +126,10 @@ type fakeObjectMover struct {
 	moveErr error
 }
 
-func (f *fakeObjectMover) Move(namespace string, toCluster cluster.Client, dryRun bool) error {
+func (f *fakeObjectMover) Move(namespace, clusterName string, toCluster cluster.Client, dryRun bool) error {
 	return f.moveErr
 }
+
+func (f *fakeObjectMover) GetClusterSecrets(namespace, name string) ([]corev1.ObjectReference, error) {
+	return nil, nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"github.com/pkg/errors"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+)
+
+// PauseClusterOptions carries the options supported by PauseCluster, ResumeCluster and GetClusterPauseStatus.
+type PauseClusterOptions struct {
+	// Kubeconfig defines the kubeconfig to use for accessing the management cluster. If empty,
+	// default rules for kubeconfig discovery will be used.
+	Kubeconfig Kubeconfig
+
+	// Namespace where the Cluster is located. If unspecified, the current namespace will be used.
+	Namespace string
+
+	// ClusterName is the name of the Cluster.
+	ClusterName string
+}
+
+// PauseCluster pauses the reconciliation of a Cluster and of all its descendant objects.
+func (c *clusterctlClient) PauseCluster(options PauseClusterOptions) error {
+	pauser, err := c.getClusterPauser(&options)
+	if err != nil {
+		return err
+	}
+	return pauser.Pause(options.Namespace, options.ClusterName)
+}
+
+// ResumeCluster resumes the reconciliation of a Cluster and of all its descendant objects.
+func (c *clusterctlClient) ResumeCluster(options PauseClusterOptions) error {
+	pauser, err := c.getClusterPauser(&options)
+	if err != nil {
+		return err
+	}
+	return pauser.Resume(options.Namespace, options.ClusterName)
+}
+
+// GetClusterPauseStatus returns the paused state of a Cluster and of all its descendant objects.
+func (c *clusterctlClient) GetClusterPauseStatus(options PauseClusterOptions) (*ClusterPauseStatus, error) {
+	pauser, err := c.getClusterPauser(&options)
+	if err != nil {
+		return nil, err
+	}
+	status, err := pauser.Status(options.Namespace, options.ClusterName)
+	if err != nil {
+		return nil, err
+	}
+	return (*ClusterPauseStatus)(status), nil
+}
+
+// getClusterPauser returns the ClusterPauser for the management cluster, defaulting the namespace if required.
+func (c *clusterctlClient) getClusterPauser(options *PauseClusterOptions) (cluster.ClusterPauser, error) {
+	if options.ClusterName == "" {
+		return nil, errors.New("the name of the Cluster is required")
+	}
+
+	// gets access to the management cluster
+	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{Kubeconfig: options.Kubeconfig})
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure this command only runs against management clusters with the current Cluster API contract.
+	if err := clusterClient.ProviderInventory().CheckCAPIContract(); err != nil {
+		return nil, err
+	}
+
+	// If the option specifying the Namespace is empty, try to detect it.
+	if options.Namespace == "" {
+		currentNamespace, err := clusterClient.Proxy().CurrentNamespace()
+		if err != nil {
+			return nil, err
+		}
+		options.Namespace = currentNamespace
+	}
+
+	return clusterClient.ClusterPauser(), nil
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+)
+
+// WaitForProviderComponentsReadyOptions carries the options supported by WaitForProviderComponentsReady.
+type WaitForProviderComponentsReadyOptions struct {
+	// Kubeconfig defines the kubeconfig to use for accessing the management cluster. If empty,
+	// default rules for kubeconfig discovery will be used.
+	Kubeconfig Kubeconfig
+
+	// Namespace where the provider components are installed, e.g. capi-system.
+	Namespace string
+
+	// Timeout is the time to wait for the provider components to be ready; zero means checking only once.
+	Timeout time.Duration
+}
+
+// WaitForProviderComponentsReady waits for the components installed by clusterctl in a provider namespace to be ready to serve
+// requests, i.e. for all the Deployments to be Available and for all the webhooks backed by a Service in the namespace to be
+// serving with a certificate trusted by the API server, as verified by creating objects in dry run mode.
+// This allows to create Cluster API objects right after installing the providers, without waiting for an arbitrary amount of time.
+func (c *clusterctlClient) WaitForProviderComponentsReady(options WaitForProviderComponentsReadyOptions) error {
+	if options.Namespace == "" {
+		return errors.New("the namespace of the provider components is required")
+	}
+	if options.Timeout < 0 {
+		return errors.New("the timeout must not be negative")
+	}
+
+	// gets access to the management cluster
+	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{Kubeconfig: options.Kubeconfig})
+	if err != nil {
+		return err
+	}
+
+	return clusterClient.ProviderHealth().WaitForReady(cluster.ProviderHealthOptions{
+		Namespace: options.Namespace,
+		Timeout:   options.Timeout,
+	})
+}
@@ -0,0 +1,288 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rbac generates the minimal RBAC rules required for running clusterctl operations,
+// so automation pipelines can use a dedicated service account instead of cluster-admin.
+package rbac
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Operation is a clusterctl operation RBAC rules can be generated for.
+type Operation string
+
+const (
+	// OperationInit is used for generating the rules required by clusterctl init.
+	OperationInit Operation = "init"
+
+	// OperationUpgrade is used for generating the rules required by clusterctl upgrade.
+	OperationUpgrade Operation = "upgrade"
+
+	// OperationDelete is used for generating the rules required by clusterctl delete.
+	OperationDelete Operation = "delete"
+
+	// OperationMove is used for generating the rules required by clusterctl move, on both the source and the target cluster.
+	OperationMove Operation = "move"
+
+	// OperationDescribe is used for generating the rules required by clusterctl describe cluster.
+	OperationDescribe Operation = "describe"
+
+	// OperationRollout is used for generating the rules required by clusterctl alpha rollout.
+	OperationRollout Operation = "rollout"
+)
+
+// Operations is the list of operations RBAC rules can be generated for.
+var Operations = []Operation{OperationInit, OperationUpgrade, OperationDelete, OperationMove, OperationDescribe, OperationRollout}
+
+// DefaultName is the name used for the generated ClusterRole and Role if not otherwise specified.
+const DefaultName = "clusterctl"
+
+// Options carries the options supported by Generate.
+type Options struct {
+	// Operations is the list of clusterctl operations the generated rules must allow.
+	Operations []Operation
+
+	// Name of the generated ClusterRole and Role. If empty, DefaultName is used.
+	Name string
+
+	// Namespace, if set, restricts the rules for the objects clusterctl reads or changes in the namespace
+	// where workload clusters are defined to a Role in this namespace; this applies to the move, describe and
+	// rollout operations only, because other operations work on provider objects across namespaces.
+	Namespace string
+
+	// AdditionalAPIGroups is the list of API groups, in addition to the Cluster API ones, of the providers
+	// objects clusterctl works with, e.g. the API group of an infrastructure provider not using infrastructure.cluster.x-k8s.io.
+	AdditionalAPIGroups []string
+}
+
+var (
+	coreGroup           = ""
+	appsGroup           = "apps"
+	rbacGroup           = rbacv1.GroupName
+	apiextensionsGroup  = "apiextensions.k8s.io"
+	admissionGroup      = "admissionregistration.k8s.io"
+	certManagerGroup    = "cert-manager.io"
+	clusterctlGroup     = clusterctlv1.GroupVersion.Group
+	clusterGroup        = clusterv1.GroupVersion.Group
+	clusterAPIGroups    = []string{clusterGroup, "exp." + clusterGroup, "infrastructure." + clusterGroup, "bootstrap." + clusterGroup, "controlplane." + clusterGroup, "addons." + clusterGroup}
+	readVerbs           = []string{"get", "list"}
+	componentsVerbs     = []string{"get", "list", "create", "patch", "update"}
+	componentsResources = map[string][]string{
+		coreGroup:          {"namespaces", "serviceaccounts", "services", "configmaps", "secrets"},
+		appsGroup:          {"deployments"},
+		rbacGroup:          {"roles", "rolebindings", "clusterroles", "clusterrolebindings"},
+		admissionGroup:     {"mutatingwebhookconfigurations", "validatingwebhookconfigurations"},
+		apiextensionsGroup: {"customresourcedefinitions"},
+		certManagerGroup:   {"certificates", "issuers"},
+	}
+)
+
+// rule grants verbs on a resource; namespaced rules are restricted to Options.Namespace, if set.
+type rule struct {
+	group      string
+	resource   string
+	verbs      []string
+	namespaced bool
+}
+
+// Generate returns a ClusterRole with the minimal rules required for running the given clusterctl operations,
+// based on the objects and verbs clusterctl uses. If Options.Namespace is set, rules for the objects in that
+// namespace are returned in a Role, while rules for cluster wide objects are still returned in a ClusterRole.
+func Generate(options Options) ([]client.Object, error) {
+	if len(options.Operations) == 0 {
+		return nil, errors.New("at least one operation is required")
+	}
+	name := options.Name
+	if name == "" {
+		name = DefaultName
+	}
+	providerGroups := append(append([]string{}, clusterAPIGroups...), options.AdditionalAPIGroups...)
+
+	rules := []rule{
+		// All the operations check the Cluster API contract and the installed providers.
+		{group: apiextensionsGroup, resource: "customresourcedefinitions", verbs: readVerbs},
+		{group: clusterctlGroup, resource: "providers", verbs: readVerbs},
+	}
+	for _, o := range options.Operations {
+		opRules, err := rulesFor(o, providerGroups)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, opRules...)
+	}
+
+	clusterRules := map[string]sets.String{}
+	namespacedRules := map[string]sets.String{}
+	for _, r := range rules {
+		target := clusterRules
+		if r.namespaced && options.Namespace != "" {
+			target = namespacedRules
+		}
+		key := r.group + "/" + r.resource
+		if _, ok := target[key]; !ok {
+			target[key] = sets.NewString()
+		}
+		target[key].Insert(r.verbs...)
+	}
+
+	objs := []client.Object{
+		&rbacv1.ClusterRole{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRole"},
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Rules:      policyRules(clusterRules),
+		},
+	}
+	if len(namespacedRules) > 0 {
+		objs = append(objs, &rbacv1.Role{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "Role"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: options.Namespace, Name: name},
+			Rules:      policyRules(namespacedRules),
+		})
+	}
+	return objs, nil
+}
+
+// rulesFor returns the rules required by an operation.
+func rulesFor(operation Operation, providerGroups []string) ([]rule, error) {
+	var rules []rule
+	switch operation {
+	case OperationInit:
+		// init creates the inventory and the provider components, including cert-manager if not already installed,
+		// and creating roles requires escalate and bind for granting permissions clusterctl does not have itself.
+		// Checking if the cert-manager API is ready creates and then deletes a test Namespace, Issuer and Certificate.
+		rules = append(rules, componentsRules(componentsVerbs)...)
+		rules = append(rules,
+			rule{group: coreGroup, resource: "namespaces", verbs: []string{"delete"}},
+			rule{group: certManagerGroup, resource: "issuers", verbs: []string{"delete"}},
+			rule{group: certManagerGroup, resource: "certificates", verbs: []string{"delete"}},
+			rule{group: clusterctlGroup, resource: "providers", verbs: []string{"create"}},
+			rule{group: rbacGroup, resource: "clusterroles", verbs: []string{"escalate", "bind"}},
+			rule{group: rbacGroup, resource: "roles", verbs: []string{"escalate", "bind"}},
+		)
+	case OperationUpgrade:
+		// upgrade deletes the components of the current provider versions, discovering them by label
+		// across all the resource types, and then installs the new ones.
+		rules = append(rules, componentsRules(append([]string{"delete"}, componentsVerbs...))...)
+		rules = append(rules,
+			rule{group: "*", resource: "*", verbs: []string{"list"}},
+			rule{group: clusterctlGroup, resource: "providers", verbs: []string{"create", "update", "delete"}},
+			rule{group: rbacGroup, resource: "clusterroles", verbs: []string{"escalate", "bind"}},
+			rule{group: rbacGroup, resource: "roles", verbs: []string{"escalate", "bind"}},
+		)
+	case OperationDelete:
+		// delete discovers the provider components by label across all the resource types, and it checks
+		// if objects of the provider kinds exist before deleting CRDs.
+		rules = append(rules, componentsRules([]string{"get", "list", "delete"})...)
+		rules = append(rules,
+			rule{group: "*", resource: "*", verbs: []string{"list"}},
+			rule{group: clusterctlGroup, resource: "providers", verbs: []string{"delete"}},
+		)
+		for _, g := range providerGroups {
+			rules = append(rules, rule{group: g, resource: "*", verbs: []string{"list"}})
+		}
+	case OperationMove:
+		// move reads the objects from the source cluster, pauses the Clusters, creates the objects and their namespaces
+		// in the target cluster, recording an Event for the moved Clusters, and then deletes the objects from the source cluster
+		// after removing their finalizers.
+		rules = append(rules, rule{group: coreGroup, resource: "namespaces", verbs: []string{"get", "list", "create"}})
+		moveVerbs := []string{"get", "list", "create", "update", "patch", "delete"}
+		for _, g := range providerGroups {
+			rules = append(rules, rule{group: g, resource: "*", verbs: moveVerbs, namespaced: true})
+		}
+		rules = append(rules,
+			rule{group: coreGroup, resource: "secrets", verbs: moveVerbs, namespaced: true},
+			rule{group: coreGroup, resource: "configmaps", verbs: moveVerbs, namespaced: true},
+			rule{group: coreGroup, resource: "events", verbs: []string{"create"}, namespaced: true},
+		)
+	case OperationDescribe:
+		// describe reads a Cluster and all the objects it references.
+		for _, g := range providerGroups {
+			rules = append(rules, rule{group: g, resource: "*", verbs: readVerbs, namespaced: true})
+		}
+	case OperationRollout:
+		// rollout patches MachineDeployments, reading the MachineSets for rolling back to a previous revision.
+		rules = append(rules,
+			rule{group: clusterGroup, resource: "machinedeployments", verbs: []string{"get", "list", "patch"}, namespaced: true},
+			rule{group: clusterGroup, resource: "machinesets", verbs: readVerbs, namespaced: true},
+		)
+	default:
+		return nil, errors.Errorf("invalid operation %q, accepted values are %v", operation, Operations)
+	}
+	return rules, nil
+}
+
+// componentsRules returns the rules for the kinds of objects included in the provider and cert-manager components.
+func componentsRules(verbs []string) []rule {
+	var rules []rule
+	for group, resources := range componentsResources {
+		for _, resource := range resources {
+			rules = append(rules, rule{group: group, resource: resource, verbs: verbs})
+		}
+	}
+	return rules
+}
+
+// policyRules converts the verbs by resource to policy rules, grouping resources with the same group and verbs.
+func policyRules(verbsByResource map[string]sets.String) []rbacv1.PolicyRule {
+	type ruleKey struct {
+		group string
+		verbs string
+	}
+	resourcesByKey := map[ruleKey][]string{}
+	for key, verbs := range verbsByResource {
+		group, resource := splitKey(key)
+		k := ruleKey{group: group, verbs: strings.Join(verbs.List(), ",")}
+		resourcesByKey[k] = append(resourcesByKey[k], resource)
+	}
+
+	keys := make([]ruleKey, 0, len(resourcesByKey))
+	for k := range resourcesByKey {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].group != keys[j].group {
+			return keys[i].group < keys[j].group
+		}
+		return keys[i].verbs < keys[j].verbs
+	})
+
+	rules := make([]rbacv1.PolicyRule, 0, len(keys))
+	for _, k := range keys {
+		resources := resourcesByKey[k]
+		sort.Strings(resources)
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups: []string{k.group},
+			Resources: resources,
+			Verbs:     strings.Split(k.verbs, ","),
+		})
+	}
+	return rules
+}
+
+func splitKey(key string) (string, string) {
+	i := strings.LastIndex(key, "/")
+	return key[:i], key[i+1:]
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbac
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name            string
+		options         Options
+		wantErr         bool
+		wantRole        bool
+		wantClusterRule rbacv1.PolicyRule
+		wantRoleRule    rbacv1.PolicyRule
+		notWantRule     rbacv1.PolicyRule
+	}{
+		{
+			name:    "fails without operations",
+			options: Options{},
+			wantErr: true,
+		},
+		{
+			name:    "fails with an invalid operation",
+			options: Options{Operations: []Operation{"scale"}},
+			wantErr: true,
+		},
+		{
+			name:            "describe reads provider objects cluster wide",
+			options:         Options{Operations: []Operation{OperationDescribe}},
+			wantClusterRule: rbacv1.PolicyRule{APIGroups: []string{"cluster.x-k8s.io"}, Resources: []string{"*"}, Verbs: []string{"get", "list"}},
+			notWantRule:     rbacv1.PolicyRule{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"list"}},
+		},
+		{
+			name:            "init creates components and inventory",
+			options:         Options{Operations: []Operation{OperationInit}},
+			wantClusterRule: rbacv1.PolicyRule{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"create", "get", "list", "patch", "update"}},
+		},
+		{
+			name:            "init grants escalate and bind on roles",
+			options:         Options{Operations: []Operation{OperationInit}},
+			wantClusterRule: rbacv1.PolicyRule{APIGroups: []string{"rbac.authorization.k8s.io"}, Resources: []string{"clusterroles", "roles"}, Verbs: []string{"bind", "create", "escalate", "get", "list", "patch", "update"}},
+		},
+		{
+			name:            "delete lists all the resources",
+			options:         Options{Operations: []Operation{OperationDelete}},
+			wantClusterRule: rbacv1.PolicyRule{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"list"}},
+		},
+		{
+			name:            "move in a namespace uses a Role for namespaced objects",
+			options:         Options{Operations: []Operation{OperationMove}, Namespace: "ns1"},
+			wantRole:        true,
+			wantClusterRule: rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"namespaces"}, Verbs: []string{"create", "get", "list"}},
+			wantRoleRule:    rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"configmaps", "secrets"}, Verbs: []string{"create", "delete", "get", "list", "patch", "update"}},
+		},
+		{
+			name:            "move includes additional API groups",
+			options:         Options{Operations: []Operation{OperationMove}, AdditionalAPIGroups: []string{"infrastructure.example.com"}},
+			wantClusterRule: rbacv1.PolicyRule{APIGroups: []string{"infrastructure.example.com"}, Resources: []string{"*"}, Verbs: []string{"create", "delete", "get", "list", "patch", "update"}},
+		},
+		{
+			name:            "rollout patches MachineDeployments",
+			options:         Options{Operations: []Operation{OperationRollout}, Namespace: "ns1"},
+			wantRole:        true,
+			wantClusterRule: rbacv1.PolicyRule{APIGroups: []string{"clusterctl.cluster.x-k8s.io"}, Resources: []string{"providers"}, Verbs: []string{"get", "list"}},
+			wantRoleRule:    rbacv1.PolicyRule{APIGroups: []string{"cluster.x-k8s.io"}, Resources: []string{"machinedeployments"}, Verbs: []string{"get", "list", "patch"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			objs, err := Generate(tt.options)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+
+			clusterRole, ok := objs[0].(*rbacv1.ClusterRole)
+			g.Expect(ok).To(BeTrue())
+			g.Expect(clusterRole.Name).To(Equal(DefaultName))
+			g.Expect(clusterRole.Rules).To(ContainElement(tt.wantClusterRule))
+			if len(tt.notWantRule.Verbs) > 0 {
+				g.Expect(clusterRole.Rules).ToNot(ContainElement(tt.notWantRule))
+			}
+
+			if !tt.wantRole {
+				g.Expect(objs).To(HaveLen(1))
+				return
+			}
+			g.Expect(objs).To(HaveLen(2))
+			role, ok := objs[1].(*rbacv1.Role)
+			g.Expect(ok).To(BeTrue())
+			g.Expect(role.Namespace).To(Equal(tt.options.Namespace))
+			g.Expect(role.Rules).To(ContainElement(tt.wantRoleRule))
+			g.Expect(clusterRole.Rules).ToNot(ContainElement(tt.wantRoleRule))
+		})
+	}
+}
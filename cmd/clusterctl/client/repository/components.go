@@ -290,8 +290,8 @@ func NewComponents(input ComponentsInput) (*components, error) {
 	}
 
 	// Add common labels to both the obj groups.
-	instanceObjs = addCommonLabels(instanceObjs, input.Provider)
-	sharedObjs = addCommonLabels(sharedObjs, input.Provider)
+	instanceObjs = addCommonLabels(instanceObjs, input.Provider, input.Options.Version)
+	sharedObjs = addCommonLabels(sharedObjs, input.Provider, input.Options.Version)
 
 	// Add an identifying label to shared components so next invocation of init, clusterctl delete and clusterctl upgrade can act accordingly.
 	// Additionally, the capi-webhook-system namespace gets detached from any provider, so we prevent that deleting
@@ -572,8 +572,9 @@ func remove(slice []string, i int) []string {
 	return slice[:len(slice)-1]
 }
 
-// addCommonLabels ensures all the provider components have a consistent set of labels.
-func addCommonLabels(objs []unstructured.Unstructured, provider config.Provider) []unstructured.Unstructured {
+// addCommonLabels ensures all the provider components have a consistent set of labels, including the
+// Kubernetes recommended labels identifying clusterctl as the manager and the version of the provider.
+func addCommonLabels(objs []unstructured.Unstructured, provider config.Provider, version string) []unstructured.Unstructured {
 	for _, o := range objs {
 		labels := o.GetLabels()
 		if labels == nil {
@@ -582,6 +583,10 @@ func addCommonLabels(objs []unstructured.Unstructured, provider config.Provider)
 		for k, v := range getCommonLabels(provider) {
 			labels[k] = v
 		}
+		labels[clusterctlv1.ClusterctlManagedByLabelName] = clusterctlv1.ClusterctlManagedByLabelValue
+		if version != "" {
+			labels[clusterctlv1.ClusterctlVersionLabelName] = version
+		}
 		o.SetLabels(labels)
 	}
 
@@ -602,9 +607,10 @@ func fixSharedLabels(objs []unstructured.Unstructured) []unstructured.Unstructur
 		labels := o.GetLabels()
 		labels[clusterctlv1.ClusterctlResourceLifecyleLabelName] = string(clusterctlv1.ResourceLifecycleShared)
 
-		// the capi-webhook-system namespace is shared among many providers, so removing the ProviderLabelName label.
+		// the capi-webhook-system namespace is shared among many providers, so removing the ProviderLabelName and the version labels.
 		if o.GetKind() == namespaceKind && o.GetName() == WebhookNamespaceName {
 			delete(labels, clusterv1.ProviderLabelName)
+			delete(labels, clusterctlv1.ClusterctlVersionLabelName)
 		}
 		o.SetLabels(labels)
 	}
@@ -784,6 +784,7 @@ func Test_addCommonLabels(t *testing.T) {
 		objs         []unstructured.Unstructured
 		name         string
 		providerType clusterctlv1.ProviderType
+		version      string
 	}
 	tests := []struct {
 		name string
@@ -802,6 +803,7 @@ func Test_addCommonLabels(t *testing.T) {
 				},
 				name:         "provider",
 				providerType: clusterctlv1.InfrastructureProviderType,
+				version:      "v1.0.0",
 			},
 			want: []unstructured.Unstructured{
 				{
@@ -809,8 +811,38 @@ func Test_addCommonLabels(t *testing.T) {
 						"kind": "ClusterRole",
 						"metadata": map[string]interface{}{
 							"labels": map[string]interface{}{
-								clusterctlv1.ClusterctlLabelName: "",
-								clusterv1.ProviderLabelName:      "infrastructure-provider",
+								clusterctlv1.ClusterctlLabelName:          "",
+								clusterv1.ProviderLabelName:               "infrastructure-provider",
+								clusterctlv1.ClusterctlManagedByLabelName: clusterctlv1.ClusterctlManagedByLabelValue,
+								clusterctlv1.ClusterctlVersionLabelName:   "v1.0.0",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "add labels without version",
+			args: args{
+				objs: []unstructured.Unstructured{
+					{
+						Object: map[string]interface{}{
+							"kind": "ClusterRole",
+						},
+					},
+				},
+				name:         "provider",
+				providerType: clusterctlv1.InfrastructureProviderType,
+			},
+			want: []unstructured.Unstructured{
+				{
+					Object: map[string]interface{}{
+						"kind": "ClusterRole",
+						"metadata": map[string]interface{}{
+							"labels": map[string]interface{}{
+								clusterctlv1.ClusterctlLabelName:          "",
+								clusterv1.ProviderLabelName:               "infrastructure-provider",
+								clusterctlv1.ClusterctlManagedByLabelName: clusterctlv1.ClusterctlManagedByLabelValue,
 							},
 						},
 					},
@@ -822,7 +854,7 @@ func Test_addCommonLabels(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			g := NewWithT(t)
 
-			got := addCommonLabels(tt.args.objs, config.NewProvider(tt.args.name, "", tt.args.providerType))
+			got := addCommonLabels(tt.args.objs, config.NewProvider(tt.args.name, "", tt.args.providerType), tt.args.version)
 			g.Expect(got).To(Equal(tt.want))
 		})
 	}
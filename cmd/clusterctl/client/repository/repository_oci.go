@@ -0,0 +1,432 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/version"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+)
+
+const (
+	ociScheme      = "oci"
+	ociLatestLabel = "latest"
+
+	// ociTitleAnnotation is the annotation used by OCI artifacts to store the name of the file hosted in a layer.
+	ociTitleAnnotation = "org.opencontainers.image.title"
+
+	ociManifestMediaType       = "application/vnd.oci.image.manifest.v1+json"
+	dockerManifestMediaType    = "application/vnd.docker.distribution.manifest.v2+json"
+	registryAuthenticateHeader = "WWW-Authenticate"
+)
+
+var (
+	// Caches used to limit the number of OCI registry API calls.
+
+	cacheOCIVersions  = map[string][]string{}
+	cacheOCIManifests = map[string]*ociManifest{}
+)
+
+// ociRepository provides support for providers hosted in an OCI registry.
+//
+// Each provider version is expected to be pushed as an OCI artifact tagged with the version,
+// where each layer hosts one file, e.g. the components YAML, metadata.yaml or cluster templates,
+// and the name of the file is stored in the "org.opencontainers.image.title" layer annotation
+// (this is the format used by tools like oras).
+//
+// The URL must be in the form oci://{registry}/{repository}/{latest|version}/{components.yaml}, e.g.
+// oci://registry.example.com/capi/infrastructure-aws/v0.6.0/infrastructure-components.yaml.
+//
+// Registries requiring authentication are supported via the OCI_USERNAME and OCI_PASSWORD variables.
+type ociRepository struct {
+	providerConfig        config.Provider
+	configVariablesClient config.VariablesClient
+	httpClient            *http.Client
+	registry              string
+	repository            string
+	defaultVersion        string
+	componentsPath        string
+	username              string
+	password              string
+	token                 string
+}
+
+var _ Repository = &ociRepository{}
+
+type ociRepositoryOption func(*ociRepository)
+
+func injectOCIHTTPClient(c *http.Client) ociRepositoryOption {
+	return func(r *ociRepository) {
+		r.httpClient = c
+	}
+}
+
+// ociManifest is the subset of an OCI image manifest used by clusterctl.
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers"`
+}
+
+// ociDescriptor is the subset of an OCI content descriptor used by clusterctl.
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// DefaultVersion returns defaultVersion field of ociRepository struct.
+func (r *ociRepository) DefaultVersion() string {
+	return r.defaultVersion
+}
+
+// RootPath returns the empty string as files are addressed by name in an OCI artifact.
+func (r *ociRepository) RootPath() string {
+	return ""
+}
+
+// ComponentsPath returns componentsPath field of ociRepository struct.
+func (r *ociRepository) ComponentsPath() string {
+	return r.componentsPath
+}
+
+// GetVersions returns the list of versions that are available in a provider repository,
+// that is the list of tags with a valid semantic version.
+func (r *ociRepository) GetVersions() ([]string, error) {
+	cacheID := fmt.Sprintf("%s/%s", r.registry, r.repository)
+	if versions, ok := cacheOCIVersions[cacheID]; ok {
+		return versions, nil
+	}
+
+	body, err := r.get(fmt.Sprintf("/v2/%s/tags/list", r.repository), "")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the list of tags for %q", cacheID)
+	}
+
+	tagList := struct {
+		Tags []string `json:"tags"`
+	}{}
+	if err := json.Unmarshal(body, &tagList); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the list of tags for %q", cacheID)
+	}
+
+	versions := []string{}
+	for _, tag := range tagList.Tags {
+		if _, err := version.ParseSemantic(tag); err != nil {
+			// Discard tags that are not a valid semantic versions (the user can point explicitly to such tags).
+			continue
+		}
+		versions = append(versions, tag)
+	}
+
+	cacheOCIVersions[cacheID] = versions
+	return versions, nil
+}
+
+// GetFile returns a file for a given provider version.
+func (r *ociRepository) GetFile(version, fileName string) ([]byte, error) {
+	if version == "" {
+		version = r.defaultVersion
+	}
+
+	manifest, err := r.getManifest(version)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, layer := range manifest.Layers {
+		if layer.Annotations[ociTitleAnnotation] != fileName {
+			continue
+		}
+
+		content, err := r.get(fmt.Sprintf("/v2/%s/blobs/%s", r.repository, layer.Digest), "")
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to download file %q from %q artifact", fileName, version)
+		}
+		if digest := fmt.Sprintf("sha256:%x", sha256.Sum256(content)); strings.HasPrefix(layer.Digest, "sha256:") && digest != layer.Digest {
+			return nil, errors.Errorf("failed to download file %q from %q artifact: digest %s does not match %s", fileName, version, digest, layer.Digest)
+		}
+		return content, nil
+	}
+
+	return nil, errors.Errorf("failed to get file %q from %q artifact", fileName, version)
+}
+
+// newOCIRepository returns an ociRepository implementation.
+func newOCIRepository(providerConfig config.Provider, configVariablesClient config.VariablesClient, opts ...ociRepositoryOption) (*ociRepository, error) {
+	if configVariablesClient == nil {
+		return nil, errors.New("invalid arguments: configVariablesClient can't be nil")
+	}
+
+	rURL, err := url.Parse(providerConfig.URL())
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid url")
+	}
+
+	if rURL.Scheme != ociScheme {
+		return nil, errors.New("invalid url: an OCI repository url should start with oci://")
+	}
+
+	// Extracts repository, version and componentsPath from the url
+	// NB. format is {registry}/{repository}/{version}/{components.yaml}
+	urlSplit := strings.Split(strings.Trim(rURL.Path, "/"), "/")
+	if rURL.Host == "" || len(urlSplit) < 3 {
+		return nil, errors.New("invalid url: an OCI repository url should be in the form oci://{registry}/{repository}/{latest|version}/{components.yaml}")
+	}
+
+	componentsPath := urlSplit[len(urlSplit)-1]
+	defaultVersion := urlSplit[len(urlSplit)-2]
+	repository := strings.Join(urlSplit[:len(urlSplit)-2], "/")
+
+	repo := &ociRepository{
+		providerConfig:        providerConfig,
+		configVariablesClient: configVariablesClient,
+		httpClient:            http.DefaultClient,
+		registry:              rURL.Host,
+		repository:            repository,
+		defaultVersion:        defaultVersion,
+		componentsPath:        componentsPath,
+	}
+
+	for _, o := range opts {
+		o(repo)
+	}
+
+	if username, err := configVariablesClient.Get(config.OCIUsernameVariable); err == nil {
+		repo.username = username
+	}
+	if password, err := configVariablesClient.Get(config.OCIPasswordVariable); err == nil {
+		repo.password = password
+	}
+
+	if defaultVersion == ociLatestLabel {
+		repo.defaultVersion, err = repo.getLatestRelease()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get latest version")
+		}
+	}
+
+	return repo, nil
+}
+
+// getLatestRelease returns the latest release for an OCI repository, according to
+// semantic version order of the tags.
+func (r *ociRepository) getLatestRelease() (string, error) {
+	versions, err := r.GetVersions()
+	if err != nil {
+		return "", err
+	}
+
+	var latestTag string
+	var latestPrereleaseTag string
+
+	var latestReleaseVersion *version.Version
+	var latestPrereleaseVersion *version.Version
+
+	for _, v := range versions {
+		sv, err := version.ParseSemantic(v)
+		if err != nil {
+			continue
+		}
+
+		// track prereleases separately
+		if sv.PreRelease() != "" {
+			if latestPrereleaseVersion == nil || latestPrereleaseVersion.LessThan(sv) {
+				latestPrereleaseTag = v
+				latestPrereleaseVersion = sv
+			}
+			continue
+		}
+
+		if latestReleaseVersion == nil || latestReleaseVersion.LessThan(sv) {
+			latestTag = v
+			latestReleaseVersion = sv
+		}
+	}
+
+	// Fall back to returning latest prereleases if no release has been cut or bail if it's also empty
+	if latestTag == "" {
+		if latestPrereleaseTag == "" {
+			return "", errors.New("failed to find tags with a valid semantic version number")
+		}
+
+		return latestPrereleaseTag, nil
+	}
+	return latestTag, nil
+}
+
+// getManifest returns the manifest of the artifact with a specific tag.
+func (r *ociRepository) getManifest(tag string) (*ociManifest, error) {
+	cacheID := fmt.Sprintf("%s/%s:%s", r.registry, r.repository, tag)
+	if manifest, ok := cacheOCIManifests[cacheID]; ok {
+		return manifest, nil
+	}
+
+	body, err := r.get(fmt.Sprintf("/v2/%s/manifests/%s", r.repository, tag), strings.Join([]string{ociManifestMediaType, dockerManifestMediaType}, ","))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the manifest for %q", cacheID)
+	}
+
+	manifest := &ociManifest{}
+	if err := json.Unmarshal(body, manifest); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the manifest for %q", cacheID)
+	}
+
+	cacheOCIManifests[cacheID] = manifest
+	return manifest, nil
+}
+
+// get executes a GET request against the registry, taking care of authenticating if required.
+func (r *ociRepository) get(urlPath, accept string) ([]byte, error) {
+	resp, err := r.do(urlPath, accept)
+	if err != nil {
+		return nil, err
+	}
+
+	// If the registry requires authentication, gets a token following the challenge and retry.
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get(registryAuthenticateHeader)
+		resp.Body.Close()
+		if err := r.authenticate(challenge); err != nil {
+			return nil, err
+		}
+		if resp, err = r.do(urlPath, accept); err != nil {
+			return nil, err
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected response from %s: %s", r.registry, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (r *ociRepository) do(urlPath, accept string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("https://%s%s", r.registry, urlPath), nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	switch {
+	case r.token != "":
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	case r.username != "":
+		req.SetBasicAuth(r.username, r.password)
+	}
+	return r.httpClient.Do(req)
+}
+
+// authenticate gets a bearer token according to the registry authentication challenge.
+// See https://docs.docker.com/registry/spec/auth/token/ for more details.
+func (r *ociRepository) authenticate(challenge string) error {
+	scheme, params := parseAuthenticateChallenge(challenge)
+	if !strings.EqualFold(scheme, "bearer") {
+		if r.username == "" {
+			return errors.Errorf("registry %s requires authentication. Please set the OCI_USERNAME and OCI_PASSWORD variables", r.registry)
+		}
+		return errors.Errorf("failed to authenticate to registry %s", r.registry)
+	}
+
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return errors.Errorf("invalid authentication realm %q from registry %s", params["realm"], r.registry)
+	}
+	query := realm.Query()
+	if service, ok := params["service"]; ok {
+		query.Set("service", service)
+	}
+	if scope, ok := params["scope"]; ok {
+		query.Set("scope", scope)
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return err
+	}
+	if r.username != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to authenticate to registry %s", r.registry)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("failed to authenticate to registry %s: %s", r.registry, resp.Status)
+	}
+
+	token := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return errors.Wrapf(err, "failed to parse the authentication token from registry %s", r.registry)
+	}
+	r.token = token.Token
+	if r.token == "" {
+		r.token = token.AccessToken
+	}
+	return nil
+}
+
+// parseAuthenticateChallenge parses a WWW-Authenticate header value, e.g.
+// Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:foo:pull".
+func parseAuthenticateChallenge(challenge string) (string, map[string]string) {
+	params := map[string]string{}
+	parts := strings.SplitN(strings.TrimSpace(challenge), " ", 2)
+	if len(parts) < 2 {
+		return parts[0], params
+	}
+	// Splits on commas outside of quoted values, given that e.g. scopes can contain commas.
+	var key, value strings.Builder
+	inValue, inQuotes := false, false
+	flush := func() {
+		if k := strings.ToLower(strings.TrimSpace(key.String())); k != "" {
+			params[k] = value.String()
+		}
+		key.Reset()
+		value.Reset()
+		inValue = false
+	}
+	for _, c := range parts[1] {
+		switch {
+		case c == '"':
+			inQuotes = !inQuotes
+		case c == ',' && !inQuotes:
+			flush()
+		case c == '=' && !inValue:
+			inValue = true
+		case inValue:
+			value.WriteRune(c)
+		default:
+			key.WriteRune(c)
+		}
+	}
+	flush()
+	return parts[0], params
+}
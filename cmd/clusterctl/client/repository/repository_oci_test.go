@@ -0,0 +1,264 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+)
+
+// newFakeRegistry returns a TLS server emulating an OCI registry hosting a repository with the given files for each tag;
+// if token is not empty, the registry requires bearer token authentication.
+func newFakeRegistry(repository string, tags map[string]map[string]string, token string) *httptest.Server {
+	blobs := map[string]string{}
+	manifests := map[string]ociManifest{}
+	for tag, files := range tags {
+		manifest := ociManifest{}
+		for name, content := range files {
+			digest := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(content)))
+			blobs[digest] = content
+			manifest.Layers = append(manifest.Layers, ociDescriptor{
+				MediaType:   "application/vnd.oci.image.layer.v1.tar",
+				Digest:      digest,
+				Size:        int64(len(content)),
+				Annotations: map[string]string{ociTitleAnnotation: name},
+			})
+		}
+		manifests[tag] = manifest
+	}
+
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			_ = json.NewEncoder(w).Encode(map[string]string{"token": token})
+			return
+		}
+		if token != "" && r.Header.Get("Authorization") != "Bearer "+token {
+			w.Header().Set(registryAuthenticateHeader, fmt.Sprintf("Bearer realm=\"%s/token\",service=\"registry\",scope=\"repository:%s:pull\"", server.URL, repository))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		prefix := fmt.Sprintf("/v2/%s/", repository)
+		if !strings.HasPrefix(r.URL.Path, prefix) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch p := strings.TrimPrefix(r.URL.Path, prefix); {
+		case p == "tags/list":
+			tagList := []string{}
+			for tag := range tags {
+				tagList = append(tagList, tag)
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"name": repository, "tags": tagList})
+		case strings.HasPrefix(p, "manifests/"):
+			manifest, ok := manifests[strings.TrimPrefix(p, "manifests/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", ociManifestMediaType)
+			_ = json.NewEncoder(w).Encode(manifest)
+		case strings.HasPrefix(p, "blobs/"):
+			blob, ok := blobs[strings.TrimPrefix(p, "blobs/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(blob))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return server
+}
+
+func Test_ociRepository_newOCIRepository(t *testing.T) {
+	type want struct {
+		registry       string
+		repository     string
+		defaultVersion string
+		componentsPath string
+	}
+	tests := []struct {
+		name    string
+		url     string
+		want    want
+		wantErr bool
+	}{
+		{
+			name: "successfully creates a new OCI repository",
+			url:  "oci://registry.example.com/capi/infrastructure-foo/v1.0.0/infrastructure-components.yaml",
+			want: want{
+				registry:       "registry.example.com",
+				repository:     "capi/infrastructure-foo",
+				defaultVersion: "v1.0.0",
+				componentsPath: "infrastructure-components.yaml",
+			},
+		},
+		{
+			name: "successfully creates a new OCI repository with a registry port",
+			url:  "oci://localhost:5000/infrastructure-foo/v1.0.0/infrastructure-components.yaml",
+			want: want{
+				registry:       "localhost:5000",
+				repository:     "infrastructure-foo",
+				defaultVersion: "v1.0.0",
+				componentsPath: "infrastructure-components.yaml",
+			},
+		},
+		{
+			name:    "fails if the scheme is not oci",
+			url:     "https://registry.example.com/infrastructure-foo/v1.0.0/infrastructure-components.yaml",
+			wantErr: true,
+		},
+		{
+			name:    "fails if the repository is missing",
+			url:     "oci://registry.example.com/v1.0.0/infrastructure-components.yaml",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			provider := config.NewProvider("foo", tt.url, clusterctlv1.InfrastructureProviderType)
+			got, err := newOCIRepository(provider, test.NewFakeVariableClient())
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got.registry).To(Equal(tt.want.registry))
+			g.Expect(got.repository).To(Equal(tt.want.repository))
+			g.Expect(got.DefaultVersion()).To(Equal(tt.want.defaultVersion))
+			g.Expect(got.RootPath()).To(Equal(""))
+			g.Expect(got.ComponentsPath()).To(Equal(tt.want.componentsPath))
+		})
+	}
+}
+
+func Test_ociRepository_GetFile(t *testing.T) {
+	tags := map[string]map[string]string{
+		"v1.0.0": {
+			"infrastructure-components.yaml": "v1.0.0 components",
+			"metadata.yaml":                  "v1.0.0 metadata",
+		},
+		"v1.1.0": {
+			"infrastructure-components.yaml": "v1.1.0 components",
+		},
+		"v1.2.0-beta.0": {
+			"infrastructure-components.yaml": "v1.2.0-beta.0 components",
+		},
+		"not-a-version": {},
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		version string
+		file    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "get a file from the latest version",
+			version: "",
+			file:    "infrastructure-components.yaml",
+			want:    "v1.1.0 components",
+		},
+		{
+			name:    "get a file from a specific version",
+			version: "v1.0.0",
+			file:    "metadata.yaml",
+			want:    "v1.0.0 metadata",
+		},
+		{
+			name:    "get a file from a registry requiring authentication",
+			token:   "secret",
+			version: "v1.0.0",
+			file:    "infrastructure-components.yaml",
+			want:    "v1.0.0 components",
+		},
+		{
+			name:    "fails if the file does not exist",
+			version: "v1.1.0",
+			file:    "metadata.yaml",
+			wantErr: true,
+		},
+		{
+			name:    "fails if the version does not exist",
+			version: "v2.0.0",
+			file:    "infrastructure-components.yaml",
+			wantErr: true,
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			repository := fmt.Sprintf("capi%d/infrastructure-foo", i)
+			server := newFakeRegistry(repository, tags, tt.token)
+			defer server.Close()
+
+			url := fmt.Sprintf("oci://%s/%s/latest/infrastructure-components.yaml", strings.TrimPrefix(server.URL, "https://"), repository)
+			provider := config.NewProvider("foo", url, clusterctlv1.InfrastructureProviderType)
+			r, err := newOCIRepository(provider, test.NewFakeVariableClient(), injectOCIHTTPClient(server.Client()))
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(r.DefaultVersion()).To(Equal("v1.1.0"))
+
+			versions, err := r.GetVersions()
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(versions).To(ConsistOf("v1.0.0", "v1.1.0", "v1.2.0-beta.0"))
+
+			got, err := r.GetFile(tt.version, tt.file)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(string(got)).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_parseAuthenticateChallenge(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme, params := parseAuthenticateChallenge(`Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:foo:pull,push"`)
+	g.Expect(scheme).To(Equal("Bearer"))
+	g.Expect(params).To(Equal(map[string]string{
+		"realm":   "https://auth.example.com/token",
+		"service": "registry.example.com",
+		"scope":   "repository:foo:pull,push",
+	}))
+
+	scheme, params = parseAuthenticateChallenge(`Basic realm="registry"`)
+	g.Expect(scheme).To(Equal("Basic"))
+	g.Expect(params).To(HaveKeyWithValue("realm", "registry"))
+}
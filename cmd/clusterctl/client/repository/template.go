@@ -17,6 +17,9 @@ limitations under the License.
 package repository
 
 import (
+	"encoding/json"
+
+	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
@@ -34,6 +37,10 @@ type Template interface {
 	// This value is derived by the template YAML.
 	Variables() []string
 
+	// VariableDeclarations returns the variables required by the template, with their default values and descriptions, if any.
+	// This value is derived by the template YAML.
+	VariableDeclarations() []yaml.Variable
+
 	// TargetNamespace where the template objects will be installed.
 	TargetNamespace() string
 
@@ -46,7 +53,7 @@ type Template interface {
 
 // template implements Template.
 type template struct {
-	variables       []string
+	variables       []yaml.Variable
 	targetNamespace string
 	objs            []unstructured.Unstructured
 }
@@ -55,6 +62,14 @@ type template struct {
 var _ Template = &template{}
 
 func (t *template) Variables() []string {
+	names := make([]string, 0, len(t.variables))
+	for _, v := range t.variables {
+		names = append(names, v.Name)
+	}
+	return names
+}
+
+func (t *template) VariableDeclarations() []yaml.Variable {
 	return t.variables
 }
 
@@ -76,14 +91,25 @@ type TemplateInput struct {
 	Processor             yaml.Processor
 	TargetNamespace       string
 	ListVariablesOnly     bool
+
+	// RawOverlay, if set, contains objects to be merged into the objects of RawArtifact with the same apiVersion,
+	// kind and name, using JSON merge patch semantics; objects without a match are added to the template.
+	RawOverlay []byte
 }
 
 // NewTemplate returns a new objects embedding a cluster template YAML file.
 func NewTemplate(input TemplateInput) (*template, error) {
-	variables, err := input.Processor.GetVariables(input.RawArtifact)
+	variables, err := yaml.GetVariableDeclarations(input.Processor, input.RawArtifact)
 	if err != nil {
 		return nil, err
 	}
+	if len(input.RawOverlay) > 0 {
+		overlayVariables, err := yaml.GetVariableDeclarations(input.Processor, input.RawOverlay)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get the variables of the template overlay")
+		}
+		variables = mergeVariables(variables, overlayVariables)
+	}
 
 	if input.ListVariablesOnly {
 		return &template{
@@ -103,6 +129,21 @@ func NewTemplate(input TemplateInput) (*template, error) {
 		return nil, errors.Wrap(err, "failed to parse yaml")
 	}
 
+	if len(input.RawOverlay) > 0 {
+		processedOverlay, err := input.Processor.Process(input.RawOverlay, input.ConfigVariablesClient.Get)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to process the template overlay")
+		}
+		overlayObjs, err := utilyaml.ToUnstructured(processedOverlay)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse the template overlay yaml")
+		}
+		objs, err = mergeOverlay(objs, overlayObjs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Ensures all the template components are deployed in the target namespace (applies only to namespaced objects)
 	// This is required in order to ensure a cluster and all the related objects are in a single namespace, that is a requirement for
 	// the clusterctl move operation (and also for many controller reconciliation loops).
@@ -114,3 +155,60 @@ func NewTemplate(input TemplateInput) (*template, error) {
 		objs:            objs,
 	}, nil
 }
+
+// mergeVariables returns the variables declared by the template and the variables declared only by the overlay.
+func mergeVariables(variables, overlayVariables []yaml.Variable) []yaml.Variable {
+	declared := map[string]bool{}
+	for _, v := range variables {
+		declared[v.Name] = true
+	}
+	for _, v := range overlayVariables {
+		if !declared[v.Name] {
+			variables = append(variables, v)
+			declared[v.Name] = true
+		}
+	}
+	return variables
+}
+
+// mergeOverlay merges each overlay object into the object with the same apiVersion, kind and name using JSON merge
+// patch semantics, so maps are merged, lists are replaced and null values remove fields; overlay objects without a
+// match are appended.
+func mergeOverlay(objs, overlayObjs []unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+	for _, overlay := range overlayObjs {
+		i := findObject(objs, overlay)
+		if i < 0 {
+			objs = append(objs, overlay)
+			continue
+		}
+
+		original, err := json.Marshal(objs[i].Object)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to marshal %s %s", objs[i].GetKind(), objs[i].GetName())
+		}
+		patch, err := json.Marshal(overlay.Object)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to marshal the overlay for %s %s", overlay.GetKind(), overlay.GetName())
+		}
+		merged, err := jsonpatch.MergePatch(original, patch)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to apply the overlay to %s %s", overlay.GetKind(), overlay.GetName())
+		}
+		obj := unstructured.Unstructured{}
+		if err := json.Unmarshal(merged, &obj.Object); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal %s %s", overlay.GetKind(), overlay.GetName())
+		}
+		objs[i] = obj
+	}
+	return objs, nil
+}
+
+// findObject returns the index of the object with the same apiVersion, kind and name of obj, or -1.
+func findObject(objs []unstructured.Unstructured, obj unstructured.Unstructured) int {
+	for i := range objs {
+		if objs[i].GetAPIVersion() == obj.GetAPIVersion() && objs[i].GetKind() == obj.GetKind() && objs[i].GetName() == obj.GetName() {
+			return i
+		}
+	}
+	return -1
+}
@@ -116,3 +116,37 @@ func Test_newTemplate(t *testing.T) {
 		})
 	}
 }
+
+func Test_newTemplate_withOverlay(t *testing.T) {
+	g := NewWithT(t)
+
+	rawOverlay := []byte("apiVersion: v1\n" +
+		"data:\n" +
+		"  size: ${SIZE}\n" +
+		"kind: ConfigMap\n" +
+		"metadata:\n" +
+		"  name: manager\n" +
+		"---\n" +
+		"apiVersion: v1\n" +
+		"kind: ConfigMap\n" +
+		"metadata:\n" +
+		"  name: extra")
+
+	got, err := NewTemplate(TemplateInput{
+		RawArtifact:           templateMapYaml,
+		ConfigVariablesClient: test.NewFakeVariableClient().WithVar(variableName, variableValue).WithVar("SIZE", "large"),
+		Processor:             yaml.NewSimpleProcessor(),
+		TargetNamespace:       "ns1",
+		RawOverlay:            rawOverlay,
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got.Variables()).To(ConsistOf(variableName, "SIZE"))
+
+	objs := got.Objs()
+	g.Expect(objs).To(HaveLen(2))
+	g.Expect(objs[0].GetName()).To(Equal("manager"))
+	g.Expect(objs[0].GetNamespace()).To(Equal("ns1"))
+	g.Expect(objs[0].Object["data"]).To(Equal(map[string]interface{}{"variable": variableValue, "size": "large"}))
+	g.Expect(objs[1].GetName()).To(Equal("extra"))
+	g.Expect(objs[1].GetNamespace()).To(Equal("ns1"))
+}
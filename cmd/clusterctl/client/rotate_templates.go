@@ -0,0 +1,98 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+)
+
+// RotateMachineTemplatesOptions carries the options supported by RotateMachineTemplates.
+type RotateMachineTemplatesOptions struct {
+	// Kubeconfig defines the kubeconfig to use for accessing the management cluster. If empty,
+	// default rules for kubeconfig discovery will be used.
+	Kubeconfig Kubeconfig
+
+	// Namespace where the Cluster is located. If unspecified, the current namespace will be used.
+	Namespace string
+
+	// ClusterName is the name of the Cluster.
+	ClusterName string
+
+	// Clone clones the changed templates and changes the objects to reference the clones, instead of
+	// triggering a rollout of the Machines created from the changed templates.
+	Clone bool
+
+	// DryRun reports the actions to be performed without changing any object.
+	DryRun bool
+}
+
+// RotateMachineTemplates detects the changes to the infrastructure machine templates referenced by the control plane and by the
+// MachineDeployments of a Cluster since the last check, and rolls out the Machines created from the changed templates, either by
+// triggering a rollout or by cloning the changed templates.
+func (c *clusterctlClient) RotateMachineTemplates(options RotateMachineTemplatesOptions) (_ []MachineTemplateRotation, err error) {
+	if options.ClusterName == "" {
+		return nil, errors.New("the name of the Cluster is required")
+	}
+
+	// gets access to the management cluster
+	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{Kubeconfig: options.Kubeconfig})
+	if err != nil {
+		return nil, err
+	}
+
+	// Records the operation in the audit log of the management cluster, unless nothing is changed.
+	if !options.DryRun {
+		started := time.Now()
+		defer func() {
+			c.recordOperation(clusterClient, cluster.AuditOperationRotateTemplates, started, map[string]string{
+				"namespace":   options.Namespace,
+				"clusterName": options.ClusterName,
+				"clone":       strconv.FormatBool(options.Clone),
+			}, err)
+		}()
+	}
+
+	// Ensure this command only runs against management clusters with the current Cluster API contract.
+	if err := clusterClient.ProviderInventory().CheckCAPIContract(); err != nil {
+		return nil, err
+	}
+
+	// If the option specifying the Namespace is empty, try to detect it.
+	if options.Namespace == "" {
+		currentNamespace, err := clusterClient.Proxy().CurrentNamespace()
+		if err != nil {
+			return nil, err
+		}
+		options.Namespace = currentNamespace
+	}
+
+	rotations, err := clusterClient.TemplateRotator().Rotate(cluster.TemplateRotationOptions{
+		Namespace:   options.Namespace,
+		ClusterName: options.ClusterName,
+		Clone:       options.Clone,
+		DryRun:      options.DryRun,
+	})
+	result := make([]MachineTemplateRotation, len(rotations))
+	for i := range rotations {
+		result[i] = MachineTemplateRotation(rotations[i])
+	}
+	return result, err
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package scaffold generates the skeleton of a new Cluster API infrastructure provider.
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ClusterAPIVersion is the Cluster API release the generated provider depends on.
+	ClusterAPIVersion = "v0.4.0"
+
+	templatesDir      = "templates"
+	templateSuffix    = ".tmpl"
+	namePlaceholder   = "NAME"
+	boilerplateFile   = "hack/boilerplate.go.txt.tmpl"
+	boilerplateName   = "boilerplate"
+	defaultModuleBase = "sigs.k8s.io/cluster-api-provider-"
+)
+
+//go:embed templates
+var templates embed.FS
+
+var nameRegexp = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+
+// Options are the options for generating a provider.
+type Options struct {
+	// Name of the provider, e.g. "foo"; it is used for deriving the kind of the provider types, e.g. FooCluster.
+	// It must start with a lowercase letter and contain only lowercase letters and digits.
+	Name string
+
+	// Module is the Go module of the generated provider.
+	// If empty, it defaults to sigs.k8s.io/cluster-api-provider-<name>.
+	Module string
+
+	// OutputDir is the directory where the provider is generated; it must not exist or be empty.
+	// If empty, it defaults to cluster-api-provider-<name> in the current directory.
+	OutputDir string
+}
+
+// data is the data available to the templates.
+type data struct {
+	Name              string
+	Kind              string
+	LowerKind         string
+	Module            string
+	ClusterAPIVersion string
+}
+
+// Generate writes the skeleton of a new infrastructure provider, including API types, controllers,
+// manifests and an e2e test suite, and returns the list of the generated files.
+func Generate(options Options) ([]string, error) {
+	if !nameRegexp.MatchString(options.Name) {
+		return nil, errors.Errorf("invalid provider name %q: it must start with a lowercase letter and contain only lowercase letters and digits", options.Name)
+	}
+	if options.Module == "" {
+		options.Module = defaultModuleBase + options.Name
+	}
+	if options.OutputDir == "" {
+		options.OutputDir = "cluster-api-provider-" + options.Name
+	}
+
+	if err := checkOutputDir(options.OutputDir); err != nil {
+		return nil, err
+	}
+
+	d := data{
+		Name:              options.Name,
+		Kind:              strings.ToUpper(options.Name[:1]) + options.Name[1:],
+		LowerKind:         options.Name,
+		Module:            options.Module,
+		ClusterAPIVersion: ClusterAPIVersion,
+	}
+
+	boilerplate, err := templates.ReadFile(path.Join(templatesDir, boilerplateFile))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the boilerplate template")
+	}
+	base, err := template.New(boilerplateName).Parse(string(boilerplate))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the boilerplate template")
+	}
+
+	files := []string{}
+	err = fs.WalkDir(templates, templatesDir, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		target, err := render(base, p, d)
+		if err != nil {
+			return err
+		}
+
+		rel := strings.TrimSuffix(strings.TrimPrefix(p, templatesDir+"/"), templateSuffix)
+		rel = strings.ReplaceAll(rel, namePlaceholder, d.Name)
+		dest := filepath.Join(options.OutputDir, filepath.FromSlash(rel))
+
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return errors.Wrapf(err, "failed to create directory for %q", dest)
+		}
+		if err := os.WriteFile(dest, target, 0600); err != nil {
+			return errors.Wrapf(err, "failed to write %q", dest)
+		}
+		files = append(files, dest)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// render executes the template stored at the given path of the embedded file system.
+func render(base *template.Template, p string, d data) ([]byte, error) {
+	content, err := templates.ReadFile(p)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read template %q", p)
+	}
+
+	t, err := base.Clone()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to clone the boilerplate template for %q", p)
+	}
+	t, err = t.New(path.Base(p)).Parse(string(content))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse template %q", p)
+	}
+
+	var out bytes.Buffer
+	if err := t.Execute(&out, d); err != nil {
+		return nil, errors.Wrapf(err, "failed to execute template %q", p)
+	}
+	return out.Bytes(), nil
+}
+
+// checkOutputDir returns an error if the output directory already exists and is not empty.
+func checkOutputDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to read the output directory %q", dir)
+	}
+	if len(entries) > 0 {
+		return errors.Errorf("the output directory %q is not empty", dir)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaffold
+
+import (
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Run("generates a provider", func(t *testing.T) {
+		g := NewWithT(t)
+
+		dir := filepath.Join(t.TempDir(), "capfoo")
+		files, err := Generate(Options{Name: "foo", OutputDir: dir})
+		g.Expect(err).NotTo(HaveOccurred())
+
+		g.Expect(files).To(ContainElements(
+			filepath.Join(dir, "go.mod"),
+			filepath.Join(dir, "main.go"),
+			filepath.Join(dir, "api", "v1alpha4", "foocluster_types.go"),
+			filepath.Join(dir, "api", "v1alpha4", "foomachine_types.go"),
+			filepath.Join(dir, "api", "v1alpha4", "foomachinetemplate_types.go"),
+			filepath.Join(dir, "controllers", "foocluster_controller.go"),
+			filepath.Join(dir, "controllers", "foomachine_controller.go"),
+			filepath.Join(dir, "test", "e2e", "config", "foo.yaml"),
+		))
+
+		for _, f := range files {
+			g.Expect(f).NotTo(HaveSuffix(templateSuffix))
+
+			content, err := os.ReadFile(f)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(string(content)).NotTo(ContainSubstring("<no value>"), "file %s", f)
+
+			if !strings.HasSuffix(f, ".go") {
+				continue
+			}
+			formatted, err := format.Source(content)
+			g.Expect(err).NotTo(HaveOccurred(), "file %s", f)
+			g.Expect(string(formatted)).To(Equal(string(content)), "file %s is not gofmt-ed", f)
+		}
+
+		goMod, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(string(goMod)).To(HavePrefix("module sigs.k8s.io/cluster-api-provider-foo\n"))
+
+		types, err := os.ReadFile(filepath.Join(dir, "api", "v1alpha4", "foocluster_types.go"))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(string(types)).To(ContainSubstring("type FooCluster struct"))
+	})
+
+	t.Run("returns error for invalid names", func(t *testing.T) {
+		g := NewWithT(t)
+
+		for _, name := range []string{"", "Foo", "foo-bar", "1foo"} {
+			_, err := Generate(Options{Name: name, OutputDir: t.TempDir()})
+			g.Expect(err).To(HaveOccurred(), "name %q", name)
+		}
+	})
+
+	t.Run("returns error if the output directory is not empty", func(t *testing.T) {
+		g := NewWithT(t)
+
+		dir := t.TempDir()
+		g.Expect(os.WriteFile(filepath.Join(dir, "existing"), []byte{}, 0600)).To(Succeed())
+
+		_, err := Generate(Options{Name: "foo", OutputDir: dir})
+		g.Expect(err).To(HaveOccurred())
+	})
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package topology renders the objects generated from Cluster managed topologies without
+// requiring a management cluster.
+package topology
+
+import (
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/controllers/topology/desiredstate"
+)
+
+// Plan renders the objects the topology controller would create for each Cluster with a managed topology
+// defined in objs; the ClusterClass and the templates it references must be defined in objs as well.
+//
+// For each Cluster, the returned objects are the Cluster itself, with its infrastructure and control plane
+// references set, the infrastructure cluster, the control plane, and for each MachineDeployment topology the
+// bootstrap template, the infrastructure machine template and the MachineDeployment.
+//
+// Objects are generated by the same code used by the topology controller, so they get the same names and
+// content. Owner references are not set, given that the Cluster UID is not known until the Cluster is created.
+func Plan(objs []unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+	p := &planner{objs: objs}
+
+	var ret []unstructured.Unstructured
+	var errs []error
+	for i := range objs {
+		o := &objs[i]
+		if o.GroupVersionKind() != clusterv1.GroupVersion.WithKind("Cluster") {
+			continue
+		}
+
+		cluster := &clusterv1.Cluster{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.Object, cluster); err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to convert Cluster %q", o.GetName()))
+			continue
+		}
+		if cluster.Spec.Topology == nil {
+			continue
+		}
+
+		rendered, err := p.planCluster(cluster)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to render the topology for Cluster %q", cluster.Name))
+			continue
+		}
+		ret = append(ret, rendered...)
+	}
+
+	if len(errs) > 0 {
+		return nil, kerrors.NewAggregate(errs)
+	}
+	return ret, nil
+}
+
+type planner struct {
+	objs []unstructured.Unstructured
+}
+
+// planCluster renders the objects generated from the topology of a Cluster.
+func (p *planner) planCluster(cluster *clusterv1.Cluster) ([]unstructured.Unstructured, error) {
+	topology := cluster.Spec.Topology
+
+	classObj, err := p.get(clusterv1.GroupVersion.String(), "ClusterClass", cluster.Namespace, topology.Class)
+	if err != nil {
+		return nil, err
+	}
+	class := &clusterv1.ClusterClass{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(classObj.Object, class); err != nil {
+		return nil, errors.Wrapf(err, "failed to convert ClusterClass %q", topology.Class)
+	}
+
+	var ret []unstructured.Unstructured
+	cluster = cluster.DeepCopy()
+
+	if cluster.Spec.InfrastructureRef == nil {
+		template, err := p.getRef(class.Spec.Infrastructure.Ref, cluster.Namespace)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to render the infrastructure cluster")
+		}
+		infraCluster, err := desiredstate.InfrastructureCluster(cluster, class, template)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to render the infrastructure cluster")
+		}
+		cluster.Spec.InfrastructureRef = external.GetObjectReference(infraCluster)
+		ret = append(ret, *infraCluster)
+	}
+
+	if cluster.Spec.ControlPlaneRef == nil {
+		template, err := p.getRef(class.Spec.ControlPlane.Ref, cluster.Namespace)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to render the control plane")
+		}
+		controlPlane, err := desiredstate.ControlPlane(cluster, class, template)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to render the control plane")
+		}
+		cluster.Spec.ControlPlaneRef = external.GetObjectReference(controlPlane)
+		ret = append(ret, *controlPlane)
+	}
+
+	if topology.Workers != nil {
+		classes := map[string]*clusterv1.MachineDeploymentClass{}
+		for i := range class.Spec.Workers.MachineDeployments {
+			mdClass := &class.Spec.Workers.MachineDeployments[i]
+			classes[mdClass.Class] = mdClass
+		}
+
+		for i := range topology.Workers.MachineDeployments {
+			mdTopology := &topology.Workers.MachineDeployments[i]
+			mdClass, ok := classes[mdTopology.Class]
+			if !ok {
+				return nil, errors.Errorf("failed to find MachineDeploymentClass %q in ClusterClass %q", mdTopology.Class, class.Name)
+			}
+
+			objs, err := p.planMachineDeployment(cluster, mdTopology, mdClass)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to render MachineDeployment topology %q", mdTopology.Name)
+			}
+			ret = append(ret, objs...)
+		}
+	}
+
+	clusterObj, err := toUnstructured(cluster, "Cluster")
+	if err != nil {
+		return nil, err
+	}
+	return append([]unstructured.Unstructured{*clusterObj}, ret...), nil
+}
+
+// planMachineDeployment renders a MachineDeployment, together with its bootstrap and infrastructure templates,
+// from a MachineDeploymentTopology and the corresponding MachineDeploymentClass.
+func (p *planner) planMachineDeployment(cluster *clusterv1.Cluster, mdTopology *clusterv1.MachineDeploymentTopology, mdClass *clusterv1.MachineDeploymentClass) ([]unstructured.Unstructured, error) {
+	labels := desiredstate.MachineDeploymentLabels(cluster, mdTopology)
+
+	bootstrapTemplate, err := p.clone(cluster, mdClass.Template.Bootstrap.Ref, desiredstate.BootstrapTemplateName(cluster, mdTopology), labels)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to render the bootstrap template")
+	}
+	infrastructureTemplate, err := p.clone(cluster, mdClass.Template.Infrastructure.Ref, desiredstate.InfrastructureMachineTemplateName(cluster, mdTopology), labels)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to render the infrastructure template")
+	}
+
+	md := desiredstate.MachineDeployment(cluster, mdTopology, mdClass,
+		external.GetObjectReference(bootstrapTemplate), external.GetObjectReference(infrastructureTemplate), cluster.Spec.Topology.Version)
+	mdObj, err := toUnstructured(md, "MachineDeployment")
+	if err != nil {
+		return nil, err
+	}
+
+	return []unstructured.Unstructured{*bootstrapTemplate, *infrastructureTemplate, *mdObj}, nil
+}
+
+// clone renders a copy of a template referenced from a ClusterClass.
+func (p *planner) clone(cluster *clusterv1.Cluster, ref *corev1.ObjectReference, name string, labels map[string]string) (*unstructured.Unstructured, error) {
+	from, err := p.getRef(ref, cluster.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	return desiredstate.TemplateClone(cluster, ref, from, name, labels), nil
+}
+
+// getRef returns the object referenced from a ClusterClass.
+func (p *planner) getRef(ref *corev1.ObjectReference, namespace string) (*unstructured.Unstructured, error) {
+	if ref == nil {
+		return nil, errors.New("reference is not set")
+	}
+	return p.get(ref.APIVersion, ref.Kind, namespace, ref.Name)
+}
+
+// get returns a copy of the object with the given apiVersion, kind, namespace and name.
+func (p *planner) get(apiVersion, kind, namespace, name string) (*unstructured.Unstructured, error) {
+	for i := range p.objs {
+		o := &p.objs[i]
+		if o.GetAPIVersion() == apiVersion && o.GetKind() == kind && o.GetNamespace() == namespace && o.GetName() == name {
+			return o.DeepCopy(), nil
+		}
+	}
+	return nil, errors.Errorf("failed to find %s %s %q", apiVersion, kind, name)
+}
+
+// toUnstructured converts a Cluster API object to unstructured, setting its apiVersion and kind.
+func toUnstructured(obj runtime.Object, kind string) (*unstructured.Unstructured, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to convert %s to unstructured", kind)
+	}
+	u := &unstructured.Unstructured{Object: content}
+	u.SetAPIVersion(clusterv1.GroupVersion.String())
+	u.SetKind(kind)
+	unstructured.RemoveNestedField(u.Object, "status")
+	unstructured.RemoveNestedField(u.Object, "metadata", "creationTimestamp")
+	return u, nil
+}
@@ -0,0 +1,293 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package topology
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	topologycontroller "sigs.k8s.io/cluster-api/controllers/topology"
+	utilyaml "sigs.k8s.io/cluster-api/util/yaml"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var ctx = context.Background()
+
+const classTemplates = `
+apiVersion: cluster.x-k8s.io/v1alpha4
+kind: ClusterClass
+metadata:
+  name: quick-start
+  namespace: ns1
+spec:
+  infrastructure:
+    ref:
+      apiVersion: infrastructure.cluster.x-k8s.io/v1alpha4
+      kind: DockerClusterTemplate
+      name: quick-start-cluster
+  controlPlane:
+    ref:
+      apiVersion: controlplane.cluster.x-k8s.io/v1alpha4
+      kind: KubeadmControlPlaneTemplate
+      name: quick-start-control-plane
+  workers:
+    machineDeployments:
+    - class: default-worker
+      template:
+        metadata:
+          labels:
+            class-label: foo
+        bootstrap:
+          ref:
+            apiVersion: bootstrap.cluster.x-k8s.io/v1alpha4
+            kind: KubeadmConfigTemplate
+            name: quick-start-default-worker
+        infrastructure:
+          ref:
+            apiVersion: infrastructure.cluster.x-k8s.io/v1alpha4
+            kind: DockerMachineTemplate
+            name: quick-start-default-worker
+---
+apiVersion: infrastructure.cluster.x-k8s.io/v1alpha4
+kind: DockerClusterTemplate
+metadata:
+  name: quick-start-cluster
+  namespace: ns1
+spec:
+  template:
+    spec:
+      loadBalancer:
+        imageTag: v1
+---
+apiVersion: controlplane.cluster.x-k8s.io/v1alpha4
+kind: KubeadmControlPlaneTemplate
+metadata:
+  name: quick-start-control-plane
+  namespace: ns1
+spec:
+  template:
+    spec:
+      kubeadmConfigSpec: {}
+---
+apiVersion: bootstrap.cluster.x-k8s.io/v1alpha4
+kind: KubeadmConfigTemplate
+metadata:
+  name: quick-start-default-worker
+  namespace: ns1
+spec:
+  template:
+    spec: {}
+---
+apiVersion: infrastructure.cluster.x-k8s.io/v1alpha4
+kind: DockerMachineTemplate
+metadata:
+  name: quick-start-default-worker
+  namespace: ns1
+spec:
+  template:
+    spec:
+      customImage: kindest/node
+`
+
+const topologyCluster = `
+apiVersion: cluster.x-k8s.io/v1alpha4
+kind: Cluster
+metadata:
+  name: my-cluster
+  namespace: ns1
+spec:
+  topology:
+    class: quick-start
+    version: v1.21.1
+    controlPlane:
+      replicas: 3
+      metadata:
+        annotations:
+          cp-annotation: bar
+    workers:
+      machineDeployments:
+      - class: default-worker
+        name: md-0
+        replicas: 2
+`
+
+func TestPlan(t *testing.T) {
+	g := NewWithT(t)
+
+	objs, err := utilyaml.ToUnstructured([]byte(topologyCluster + "---" + classTemplates))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	rendered, err := Plan(objs)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(rendered).To(HaveLen(6))
+
+	get := func(kind, name string) *unstructured.Unstructured {
+		for i := range rendered {
+			if rendered[i].GetKind() == kind && rendered[i].GetName() == name {
+				return &rendered[i]
+			}
+		}
+		return nil
+	}
+
+	cluster := get("Cluster", "my-cluster")
+	g.Expect(cluster).ToNot(BeNil())
+	g.Expect(nestedString(cluster.Object, "spec", "infrastructureRef", "kind")).To(Equal("DockerCluster"))
+	g.Expect(nestedString(cluster.Object, "spec", "infrastructureRef", "name")).To(Equal("my-cluster"))
+	g.Expect(nestedString(cluster.Object, "spec", "controlPlaneRef", "kind")).To(Equal("KubeadmControlPlane"))
+	g.Expect(nestedString(cluster.Object, "spec", "controlPlaneRef", "name")).To(Equal("my-cluster"))
+
+	infraCluster := get("DockerCluster", "my-cluster")
+	g.Expect(infraCluster).ToNot(BeNil())
+	g.Expect(infraCluster.GetNamespace()).To(Equal("ns1"))
+	g.Expect(infraCluster.GetLabels()).To(HaveKeyWithValue(clusterv1.ClusterLabelName, "my-cluster"))
+	g.Expect(infraCluster.GetLabels()).To(HaveKey(clusterv1.ClusterTopologyOwnedLabel))
+	g.Expect(infraCluster.GetAnnotations()).To(HaveKeyWithValue(clusterv1.TemplateClonedFromNameAnnotation, "quick-start-cluster"))
+	g.Expect(nestedString(infraCluster.Object, "spec", "loadBalancer", "imageTag")).To(Equal("v1"))
+
+	controlPlane := get("KubeadmControlPlane", "my-cluster")
+	g.Expect(controlPlane).ToNot(BeNil())
+	g.Expect(controlPlane.GetAnnotations()).To(HaveKeyWithValue("cp-annotation", "bar"))
+	g.Expect(nestedString(controlPlane.Object, "spec", "version")).To(Equal("v1.21.1"))
+	g.Expect(nestedInt64(controlPlane.Object, "spec", "replicas")).To(Equal(int64(3)))
+
+	g.Expect(get("KubeadmConfigTemplate", "my-cluster-md-0-bootstrap")).ToNot(BeNil())
+	machineTemplate := get("DockerMachineTemplate", "my-cluster-md-0-infrastructure")
+	g.Expect(machineTemplate).ToNot(BeNil())
+	g.Expect(machineTemplate.GetLabels()).To(HaveKeyWithValue(clusterv1.ClusterTopologyMachineDeploymentLabelName, "md-0"))
+	g.Expect(nestedString(machineTemplate.Object, "spec", "template", "spec", "customImage")).To(Equal("kindest/node"))
+
+	md := get("MachineDeployment", "my-cluster-md-0")
+	g.Expect(md).ToNot(BeNil())
+	g.Expect(md.GetAPIVersion()).To(Equal(clusterv1.GroupVersion.String()))
+	g.Expect(nestedInt64(md.Object, "spec", "replicas")).To(Equal(int64(2)))
+	g.Expect(nestedString(md.Object, "spec", "template", "spec", "version")).To(Equal("v1.21.1"))
+	g.Expect(nestedString(md.Object, "spec", "template", "spec", "infrastructureRef", "name")).To(Equal("my-cluster-md-0-infrastructure"))
+	g.Expect(nestedString(md.Object, "spec", "template", "spec", "bootstrap", "configRef", "kind")).To(Equal("KubeadmConfigTemplate"))
+	g.Expect(nestedStringMap(md.Object, "spec", "template", "metadata", "labels")).To(HaveKeyWithValue("class-label", "foo"))
+}
+
+func TestPlanMatchesTopologyController(t *testing.T) {
+	g := NewWithT(t)
+
+	objs, err := utilyaml.ToUnstructured([]byte(topologyCluster + "---" + classTemplates))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	rendered, err := Plan(objs)
+	g.Expect(err).ToNot(HaveOccurred())
+	planned := sets.NewString()
+	for _, o := range rendered {
+		planned.Insert(o.GetKind() + "/" + o.GetName())
+	}
+
+	scheme := runtime.NewScheme()
+	g.Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+	clientObjs := []client.Object{}
+	for i := range objs {
+		clientObjs = append(clientObjs, &objs[i])
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(clientObjs...).Build()
+	r := &topologycontroller.ClusterReconciler{Client: c}
+	_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKey{Namespace: "ns1", Name: "my-cluster"}})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	reconciled := sets.NewString()
+	cluster := &clusterv1.Cluster{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: "my-cluster"}, cluster)).To(Succeed())
+	reconciled.Insert("Cluster/" + cluster.Name)
+	reconciled.Insert(cluster.Spec.InfrastructureRef.Kind + "/" + cluster.Spec.InfrastructureRef.Name)
+	reconciled.Insert(cluster.Spec.ControlPlaneRef.Kind + "/" + cluster.Spec.ControlPlaneRef.Name)
+	mds := &clusterv1.MachineDeploymentList{}
+	g.Expect(c.List(ctx, mds, client.InNamespace("ns1"))).To(Succeed())
+	for _, md := range mds.Items {
+		reconciled.Insert("MachineDeployment/" + md.Name)
+		reconciled.Insert(md.Spec.Template.Spec.Bootstrap.ConfigRef.Kind + "/" + md.Spec.Template.Spec.Bootstrap.ConfigRef.Name)
+		reconciled.Insert(md.Spec.Template.Spec.InfrastructureRef.Kind + "/" + md.Spec.Template.Spec.InfrastructureRef.Name)
+	}
+
+	g.Expect(planned.List()).To(Equal(reconciled.List()))
+}
+
+func nestedString(obj map[string]interface{}, fields ...string) string {
+	v, _, _ := unstructured.NestedString(obj, fields...)
+	return v
+}
+
+func nestedInt64(obj map[string]interface{}, fields ...string) int64 {
+	v, _, _ := unstructured.NestedInt64(obj, fields...)
+	return v
+}
+
+func nestedStringMap(obj map[string]interface{}, fields ...string) map[string]string {
+	v, _, _ := unstructured.NestedStringMap(obj, fields...)
+	return v
+}
+
+func TestPlanIgnoresClustersWithoutTopology(t *testing.T) {
+	g := NewWithT(t)
+
+	objs, err := utilyaml.ToUnstructured([]byte(`
+apiVersion: cluster.x-k8s.io/v1alpha4
+kind: Cluster
+metadata:
+  name: my-cluster
+`))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	rendered, err := Plan(objs)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(rendered).To(BeEmpty())
+}
+
+func TestPlanFailsOnMissingObjects(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "missing ClusterClass",
+			content: topologyCluster,
+		},
+		{
+			name: "missing MachineDeploymentClass",
+			content: topologyCluster + `
+      - class: not-defined
+        name: md-1
+---` + classTemplates,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			objs, err := utilyaml.ToUnstructured([]byte(tt.content))
+			g.Expect(err).ToNot(HaveOccurred())
+
+			_, err = Plan(objs)
+			g.Expect(err).To(HaveOccurred())
+		})
+	}
+}
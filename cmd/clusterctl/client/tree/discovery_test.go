@@ -23,6 +23,7 @@ import (
 
 	. "github.com/onsi/gomega"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/rbac"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -303,3 +304,37 @@ func Test_Discovery(t *testing.T) {
 		})
 	}
 }
+
+func Test_Discovery_RBACRules(t *testing.T) {
+	g := NewWithT(t)
+
+	objs := test.NewFakeCluster("ns1", "cluster1").
+		WithControlPlane(
+			test.NewFakeControlPlane("cp").
+				WithMachines(
+					test.NewFakeMachine("cp1"),
+				),
+		).
+		WithMachineDeployments(
+			test.NewFakeMachineDeployment("md1").
+				WithMachineSets(
+					test.NewFakeMachineSet("ms1").
+						WithMachines(
+							test.NewFakeMachine("m1"),
+						),
+				),
+		).
+		Objs()
+
+	recorder := &test.CallRecorder{}
+	client, err := test.NewFakeProxy().WithCallRecorder(recorder).WithObjs(objs...).NewClient()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	_, err = Discovery(context.TODO(), client, "ns1", "cluster1", DiscoverOptions{ShowOtherConditions: "all", DisableGrouping: true, DisableNoEcho: true})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(recorder.Calls()).ToNot(BeEmpty())
+
+	roles, err := rbac.Generate(rbac.Options{Operations: []rbac.Operation{rbac.OperationDescribe}})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(recorder.NotAllowed(roles)).To(BeEmpty())
+}
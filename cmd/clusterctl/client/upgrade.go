@@ -18,6 +18,7 @@ package client
 
 import (
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -107,9 +108,13 @@ type ApplyUpgradeOptions struct {
 
 	// InfrastructureProviders instance and versions (e.g. capa-system/aws:v0.5.0) to upgrade to. This field can be used as alternative to Contract.
 	InfrastructureProviders []string
+
+	// SkipCompatibilityCheck skips the check of the compatibility of the target versions of the providers with the version
+	// of the core provider and with the Kubernetes version of the management cluster, as defined in the provider metadata.
+	SkipCompatibilityCheck bool
 }
 
-func (c *clusterctlClient) ApplyUpgrade(options ApplyUpgradeOptions) error {
+func (c *clusterctlClient) ApplyUpgrade(options ApplyUpgradeOptions) (err error) {
 	if options.Contract != "" && options.Contract != clusterv1.GroupVersion.Version {
 		return errors.Errorf("current version of clusterctl could only upgrade to %s contract, requested %s", clusterv1.GroupVersion.Version, options.Contract)
 	}
@@ -120,6 +125,19 @@ func (c *clusterctlClient) ApplyUpgrade(options ApplyUpgradeOptions) error {
 		return err
 	}
 
+	// Records the operation in the audit log of the management cluster.
+	started := time.Now()
+	defer func() {
+		c.recordOperation(clusterClient, cluster.AuditOperationUpgradeApply, started, map[string]string{
+			"managementGroup":         options.ManagementGroup,
+			"contract":                options.Contract,
+			"coreProvider":            options.CoreProvider,
+			"bootstrapProviders":      strings.Join(options.BootstrapProviders, ","),
+			"controlPlaneProviders":   strings.Join(options.ControlPlaneProviders, ","),
+			"infrastructureProviders": strings.Join(options.InfrastructureProviders, ","),
+		}, err)
+	}()
+
 	// Ensure this command only runs against management clusters with the current Cluster API contract (default) or the previous one.
 	if err := clusterClient.ProviderInventory().CheckCAPIContract(cluster.AllowCAPIContract{Contract: clusterv1old.GroupVersion.Version}); err != nil {
 		return err
@@ -151,6 +169,10 @@ func (c *clusterctlClient) ApplyUpgrade(options ApplyUpgradeOptions) error {
 		return err
 	}
 
+	upgradeOptions := cluster.UpgradeOptions{
+		SkipCompatibilityCheck: options.SkipCompatibilityCheck,
+	}
+
 	// Check if the user want a custom upgrade
 	isCustomUpgrade := options.CoreProvider != "" ||
 		len(options.BootstrapProviders) > 0 ||
@@ -182,7 +204,7 @@ func (c *clusterctlClient) ApplyUpgrade(options ApplyUpgradeOptions) error {
 		}
 
 		// Execute the upgrade using the custom upgrade items
-		if err := clusterClient.ProviderUpgrader().ApplyCustomPlan(coreProvider, upgradeItems...); err != nil {
+		if err := clusterClient.ProviderUpgrader().ApplyCustomPlan(upgradeOptions, coreProvider, upgradeItems...); err != nil {
 			return err
 		}
 
@@ -190,7 +212,7 @@ func (c *clusterctlClient) ApplyUpgrade(options ApplyUpgradeOptions) error {
 	}
 
 	// Otherwise we are upgrading a whole management group according to a clusterctl generated upgrade plan.
-	if err := clusterClient.ProviderUpgrader().ApplyPlan(coreProvider, options.Contract); err != nil {
+	if err := clusterClient.ProviderUpgrader().ApplyPlan(upgradeOptions, coreProvider, options.Contract); err != nil {
 		return err
 	}
 
@@ -0,0 +1,340 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// upgradeClusterInterval is the interval between checks of the rollout status during UpgradeCluster.
+var upgradeClusterInterval = 10 * time.Second
+
+// UpgradeClusterOptions carries the options supported by UpgradeCluster.
+type UpgradeClusterOptions struct {
+	// Kubeconfig defines the kubeconfig to use for accessing the management cluster. If empty,
+	// default rules for kubeconfig discovery will be used.
+	Kubeconfig Kubeconfig
+
+	// Namespace where the Cluster is located. If unspecified, the current namespace will be used.
+	Namespace string
+
+	// ClusterName is the name of the Cluster to upgrade.
+	ClusterName string
+
+	// KubernetesVersion is the Kubernetes version to upgrade the Cluster to, e.g. v1.21.1.
+	KubernetesVersion string
+
+	// MaxUnhealthy is the maximum number of unhealthy Machines tolerated during the rollout of the control plane
+	// or of a MachineDeployment; when exceeded, the rollout is paused and the upgrade is aborted.
+	MaxUnhealthy int
+
+	// Timeout defines how long to wait for the rollout of the control plane and of each MachineDeployment.
+	Timeout time.Duration
+}
+
+// UpgradeClusterAbortedError is returned by UpgradeCluster when the upgrade is aborted because the number of
+// unhealthy Machines exceeds the error budget; the rollout of the object being upgraded is left paused.
+type UpgradeClusterAbortedError struct {
+	// Kind and Name identify the object whose rollout has been paused.
+	Kind string
+	Name string
+
+	// UnhealthyMachines is the list of the unhealthy Machines that caused the upgrade to be aborted.
+	UnhealthyMachines []string
+}
+
+func (e *UpgradeClusterAbortedError) Error() string {
+	return fmt.Sprintf("upgrade aborted: %d unhealthy machines during the rollout of %s/%s %v; the rollout has been paused",
+		len(e.UnhealthyMachines), e.Kind, e.Name, e.UnhealthyMachines)
+}
+
+// UpgradeCluster upgrades the Kubernetes version of a Cluster; the control plane is upgraded first, and then,
+// once all the control plane Machines are rolled out, each MachineDeployment is upgraded sequentially.
+// Before and during each step the number of unhealthy Machines is checked against MaxUnhealthy.
+func (c *clusterctlClient) UpgradeCluster(options UpgradeClusterOptions) (err error) {
+	if options.ClusterName == "" {
+		return errors.New("the name of the Cluster is required")
+	}
+	if options.KubernetesVersion == "" {
+		return errors.New("the Kubernetes version to upgrade to is required")
+	}
+	if options.MaxUnhealthy < 0 {
+		return errors.New("the maximum number of unhealthy Machines must not be negative")
+	}
+
+	// gets access to the management cluster
+	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{Kubeconfig: options.Kubeconfig})
+	if err != nil {
+		return err
+	}
+
+	// Records the operation in the audit log of the management cluster.
+	started := time.Now()
+	defer func() {
+		c.recordOperation(clusterClient, cluster.AuditOperationUpgradeCluster, started, map[string]string{
+			"namespace":         options.Namespace,
+			"clusterName":       options.ClusterName,
+			"kubernetesVersion": options.KubernetesVersion,
+			"maxUnhealthy":      strconv.Itoa(options.MaxUnhealthy),
+		}, err)
+	}()
+
+	// Ensure this command only runs against management clusters with the current Cluster API contract.
+	if err := clusterClient.ProviderInventory().CheckCAPIContract(); err != nil {
+		return err
+	}
+
+	// If the option specifying the Namespace is empty, try to detect it.
+	if options.Namespace == "" {
+		currentNamespace, err := clusterClient.Proxy().CurrentNamespace()
+		if err != nil {
+			return err
+		}
+		options.Namespace = currentNamespace
+	}
+
+	cl, err := clusterClient.Proxy().NewClient()
+	if err != nil {
+		return err
+	}
+
+	u := &clusterUpgrader{
+		client:   cl,
+		options:  options,
+		interval: upgradeClusterInterval,
+	}
+	return u.run()
+}
+
+// clusterUpgrader orchestrates the upgrade of a Cluster.
+type clusterUpgrader struct {
+	client   client.Client
+	options  UpgradeClusterOptions
+	interval time.Duration
+}
+
+func (u *clusterUpgrader) run() error {
+	log := logf.Log
+	ctx := context.TODO()
+
+	cluster := &clusterv1.Cluster{}
+	if err := u.client.Get(ctx, client.ObjectKey{Namespace: u.options.Namespace, Name: u.options.ClusterName}, cluster); err != nil {
+		return errors.Wrapf(err, "failed to get Cluster %s/%s", u.options.Namespace, u.options.ClusterName)
+	}
+	if cluster.Spec.ControlPlaneRef == nil {
+		return errors.Errorf("Cluster %s/%s does not have a control plane", cluster.Namespace, cluster.Name)
+	}
+
+	// Upgrade the control plane first; workers must never run a newer version than the control plane.
+	controlPlane := &unstructured.Unstructured{}
+	controlPlane.SetAPIVersion(cluster.Spec.ControlPlaneRef.APIVersion)
+	controlPlane.SetKind(cluster.Spec.ControlPlaneRef.Kind)
+	controlPlaneKey := client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Spec.ControlPlaneRef.Name}
+	if err := u.client.Get(ctx, controlPlaneKey, controlPlane); err != nil {
+		return errors.Wrapf(err, "failed to get %s %s", controlPlane.GetKind(), controlPlaneKey)
+	}
+
+	controlPlaneSelector := client.MatchingLabels{clusterv1.ClusterLabelName: cluster.Name, clusterv1.MachineControlPlaneLabelName: ""}
+	if err := u.checkHealth(controlPlane, controlPlaneSelector); err != nil {
+		return err
+	}
+
+	log.Info("Upgrading the control plane", "kind", controlPlane.GetKind(), "name", controlPlane.GetName(), "version", u.options.KubernetesVersion)
+	patch := client.MergeFrom(controlPlane.DeepCopy())
+	if err := unstructured.SetNestedField(controlPlane.Object, u.options.KubernetesVersion, "spec", "version"); err != nil {
+		return err
+	}
+	if err := u.client.Patch(ctx, controlPlane, patch); err != nil {
+		return errors.Wrapf(err, "failed to set the version of %s %s", controlPlane.GetKind(), controlPlaneKey)
+	}
+	if err := u.waitForRollout(controlPlane, controlPlaneSelector, controlPlaneRolledOut); err != nil {
+		return err
+	}
+
+	// Then upgrade the MachineDeployments, one at a time.
+	machineDeployments := &unstructured.UnstructuredList{}
+	machineDeployments.SetGroupVersionKind(clusterv1.GroupVersion.WithKind("MachineDeploymentList"))
+	if err := u.client.List(ctx, machineDeployments, client.InNamespace(cluster.Namespace), client.MatchingLabels{clusterv1.ClusterLabelName: cluster.Name}); err != nil {
+		return errors.Wrapf(err, "failed to list MachineDeployments for Cluster %s/%s", cluster.Namespace, cluster.Name)
+	}
+	sort.Slice(machineDeployments.Items, func(i, j int) bool {
+		return machineDeployments.Items[i].GetName() < machineDeployments.Items[j].GetName()
+	})
+
+	for i := range machineDeployments.Items {
+		md := &machineDeployments.Items[i]
+		mdSelector := client.MatchingLabels{clusterv1.ClusterLabelName: cluster.Name, clusterv1.MachineDeploymentLabelName: md.GetName()}
+		if err := u.checkHealth(md, mdSelector); err != nil {
+			return err
+		}
+
+		log.Info("Upgrading MachineDeployment", "name", md.GetName(), "version", u.options.KubernetesVersion)
+		patch := client.MergeFrom(md.DeepCopy())
+		if err := unstructured.SetNestedField(md.Object, u.options.KubernetesVersion, "spec", "template", "spec", "version"); err != nil {
+			return err
+		}
+		if err := u.client.Patch(ctx, md, patch); err != nil {
+			return errors.Wrapf(err, "failed to set the version of MachineDeployment %s/%s", md.GetNamespace(), md.GetName())
+		}
+		if err := u.waitForRollout(md, mdSelector, machineDeploymentRolledOut); err != nil {
+			return err
+		}
+	}
+
+	log.Info("Cluster upgraded", "name", cluster.Name, "version", u.options.KubernetesVersion)
+	return nil
+}
+
+// waitForRollout waits for the rollout of an object to complete, checking the health of its Machines on every poll.
+func (u *clusterUpgrader) waitForRollout(obj *unstructured.Unstructured, selector client.MatchingLabels, rolledOut func(*unstructured.Unstructured) (bool, string, error)) error {
+	log := logf.Log
+	key := client.ObjectKey{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+
+	var reason string
+	err := wait.PollImmediate(u.interval, u.options.Timeout, func() (bool, error) {
+		if err := u.client.Get(context.TODO(), key, obj); err != nil {
+			return false, errors.Wrapf(err, "failed to get %s %s", obj.GetKind(), key)
+		}
+		if err := u.checkHealth(obj, selector); err != nil {
+			return false, err
+		}
+		done, r, err := rolledOut(obj)
+		if err != nil {
+			return false, err
+		}
+		reason = r
+		if !done {
+			log.V(3).Info("Waiting for rollout", "kind", obj.GetKind(), "name", obj.GetName(), "reason", reason)
+		}
+		return done, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return errors.Errorf("timed out waiting for the rollout of %s %s: %s", obj.GetKind(), key, reason)
+	}
+	return err
+}
+
+// checkHealth checks the number of unhealthy Machines selected by selector against the error budget, pausing the
+// rollout of obj and returning an UpgradeClusterAbortedError if the budget is exceeded.
+func (u *clusterUpgrader) checkHealth(obj *unstructured.Unstructured, selector client.MatchingLabels) error {
+	machines := &clusterv1.MachineList{}
+	if err := u.client.List(context.TODO(), machines, client.InNamespace(obj.GetNamespace()), selector); err != nil {
+		return errors.Wrapf(err, "failed to list Machines for %s %s/%s", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+	}
+
+	var unhealthy []string
+	for i := range machines.Items {
+		if !isMachineHealthy(&machines.Items[i]) {
+			unhealthy = append(unhealthy, machines.Items[i].Name)
+		}
+	}
+	if len(unhealthy) <= u.options.MaxUnhealthy {
+		return nil
+	}
+
+	if err := u.pauseRollout(obj); err != nil {
+		return err
+	}
+	return &UpgradeClusterAbortedError{Kind: obj.GetKind(), Name: obj.GetName(), UnhealthyMachines: unhealthy}
+}
+
+// pauseRollout pauses the rollout of an object; MachineDeployments are paused using spec.paused, while
+// control planes, which do not support it, are paused using the paused annotation.
+func (u *clusterUpgrader) pauseRollout(obj *unstructured.Unstructured) error {
+	patch := client.MergeFrom(obj.DeepCopy())
+	if obj.GetKind() == "MachineDeployment" {
+		if err := unstructured.SetNestedField(obj.Object, true, "spec", "paused"); err != nil {
+			return err
+		}
+	} else {
+		annotations := obj.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[clusterv1.PausedAnnotation] = "true"
+		obj.SetAnnotations(annotations)
+	}
+	if err := u.client.Patch(context.TODO(), obj, patch); err != nil {
+		return errors.Wrapf(err, "failed to pause the rollout of %s %s/%s", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+	}
+	return nil
+}
+
+// isMachineHealthy returns false for Machines reporting a terminal failure, or failing a MachineHealthCheck.
+func isMachineHealthy(m *clusterv1.Machine) bool {
+	if m.Status.FailureReason != nil || m.Status.FailureMessage != nil {
+		return false
+	}
+	return !conditions.IsFalse(m, clusterv1.MachineHealthCheckSuccededCondition)
+}
+
+// controlPlaneRolledOut checks if all the replicas of a control plane are up to date and ready, returning the reason why not.
+func controlPlaneRolledOut(obj *unstructured.Unstructured) (bool, string, error) {
+	return replicasRolledOut(obj, "readyReplicas")
+}
+
+// machineDeploymentRolledOut checks if all the replicas of a MachineDeployment are up to date and available, returning the reason why not.
+func machineDeploymentRolledOut(obj *unstructured.Unstructured) (bool, string, error) {
+	return replicasRolledOut(obj, "availableReplicas")
+}
+
+// replicasRolledOut checks if the status of an object reports all the desired replicas as updated and as counted by
+// readyField, with no additional replicas left, returning the reason why not.
+func replicasRolledOut(obj *unstructured.Unstructured, readyField string) (bool, string, error) {
+	observedGeneration, _, err := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if err != nil {
+		return false, "", err
+	}
+	if observedGeneration < obj.GetGeneration() {
+		return false, "status.observedGeneration is older than metadata.generation", nil
+	}
+
+	replicas, found, err := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if err != nil || !found {
+		return false, "spec.replicas is not set", err
+	}
+	counts := map[string]int64{}
+	for _, f := range []string{"replicas", "updatedReplicas", readyField} {
+		counts[f], _, err = unstructured.NestedInt64(obj.Object, "status", f)
+		if err != nil {
+			return false, "", err
+		}
+	}
+	if counts["updatedReplicas"] < replicas {
+		return false, fmt.Sprintf("%d of %d replicas updated", counts["updatedReplicas"], replicas), nil
+	}
+	if counts["replicas"] > replicas {
+		return false, fmt.Sprintf("%d old replicas pending deletion", counts["replicas"]-replicas), nil
+	}
+	if counts[readyField] < replicas {
+		return false, fmt.Sprintf("status.%s is %d of %d", readyField, counts[readyField], replicas), nil
+	}
+	return true, "", nil
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_clusterUpgrader_run(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	g.Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(controlplanev1.AddToScheme(scheme)).To(Succeed())
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-cluster"},
+		Spec: clusterv1.ClusterSpec{
+			ControlPlaneRef: &corev1.ObjectReference{
+				APIVersion: controlplanev1.GroupVersion.String(),
+				Kind:       "KubeadmControlPlane",
+				Name:       "my-cluster-control-plane",
+			},
+		},
+	}
+	controlPlane := &controlplanev1.KubeadmControlPlane{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-cluster-control-plane"},
+		Spec:       controlplanev1.KubeadmControlPlaneSpec{Replicas: pointer.Int32Ptr(3), Version: "v1.20.1"},
+		Status:     controlplanev1.KubeadmControlPlaneStatus{Replicas: 3, UpdatedReplicas: 3, ReadyReplicas: 3},
+	}
+	machineDeployment := func(name string, availableReplicas int32) *clusterv1.MachineDeployment {
+		return &clusterv1.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: map[string]string{clusterv1.ClusterLabelName: "my-cluster"}},
+			Spec: clusterv1.MachineDeploymentSpec{
+				Replicas: pointer.Int32Ptr(2),
+				Template: clusterv1.MachineTemplateSpec{Spec: clusterv1.MachineSpec{Version: pointer.StringPtr("v1.20.1")}},
+			},
+			Status: clusterv1.MachineDeploymentStatus{Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: availableReplicas},
+		}
+	}
+	unhealthyMachine := func(name string, labels map[string]string) *clusterv1.Machine {
+		m := &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: labels},
+		}
+		conditions.MarkFalse(m, clusterv1.MachineHealthCheckSuccededCondition, clusterv1.NodeNotFoundReason, clusterv1.ConditionSeverityWarning, "")
+		return m
+	}
+	controlPlaneLabels := map[string]string{clusterv1.ClusterLabelName: "my-cluster", clusterv1.MachineControlPlaneLabelName: ""}
+	mdLabels := map[string]string{clusterv1.ClusterLabelName: "my-cluster", clusterv1.MachineDeploymentLabelName: "md-b"}
+
+	tests := []struct {
+		name                   string
+		objs                   []client.Object
+		maxUnhealthy           int
+		wantErr                bool
+		wantAborted            bool
+		wantUpgraded           []string
+		wantNotUpgraded        []string
+		wantControlPlane       string
+		wantControlPlanePaused bool
+		wantPaused             []string
+	}{
+		{
+			name:             "upgrades the control plane and then all the MachineDeployments",
+			objs:             []client.Object{cluster, controlPlane, machineDeployment("md-a", 2), machineDeployment("md-b", 2)},
+			wantControlPlane: "v1.21.1",
+			wantUpgraded:     []string{"md-a", "md-b"},
+		},
+		{
+			name:                   "aborts before upgrading the control plane if too many control plane Machines are unhealthy",
+			objs:                   []client.Object{cluster, controlPlane, machineDeployment("md-a", 2), unhealthyMachine("cp-1", controlPlaneLabels)},
+			wantErr:                true,
+			wantAborted:            true,
+			wantControlPlane:       "v1.20.1",
+			wantControlPlanePaused: true,
+			wantNotUpgraded:        []string{"md-a"},
+		},
+		{
+			name:             "tolerates unhealthy Machines within the error budget",
+			objs:             []client.Object{cluster, controlPlane, machineDeployment("md-a", 2), unhealthyMachine("cp-1", controlPlaneLabels)},
+			maxUnhealthy:     1,
+			wantControlPlane: "v1.21.1",
+			wantUpgraded:     []string{"md-a"},
+		},
+		{
+			name:             "pauses the MachineDeployment with too many unhealthy Machines and stops the upgrade",
+			objs:             []client.Object{cluster, controlPlane, machineDeployment("md-a", 2), machineDeployment("md-b", 2), machineDeployment("md-c", 2), unhealthyMachine("md-b-1", mdLabels)},
+			wantErr:          true,
+			wantAborted:      true,
+			wantControlPlane: "v1.21.1",
+			wantUpgraded:     []string{"md-a"},
+			wantNotUpgraded:  []string{"md-b", "md-c"},
+			wantPaused:       []string{"md-b"},
+		},
+		{
+			name:             "fails if a MachineDeployment does not complete the rollout before the timeout",
+			objs:             []client.Object{cluster, controlPlane, machineDeployment("md-a", 1), machineDeployment("md-b", 2)},
+			wantErr:          true,
+			wantControlPlane: "v1.21.1",
+			wantUpgraded:     []string{"md-a"},
+			wantNotUpgraded:  []string{"md-b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			objs := make([]client.Object, 0, len(tt.objs))
+			for _, o := range tt.objs {
+				objs = append(objs, o.DeepCopyObject().(client.Object))
+			}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+
+			u := &clusterUpgrader{
+				client: c,
+				options: UpgradeClusterOptions{
+					Namespace:         "default",
+					ClusterName:       "my-cluster",
+					KubernetesVersion: "v1.21.1",
+					MaxUnhealthy:      tt.maxUnhealthy,
+					Timeout:           50 * time.Millisecond,
+				},
+				interval: 10 * time.Millisecond,
+			}
+			err := u.run()
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			_, aborted := err.(*UpgradeClusterAbortedError)
+			g.Expect(aborted).To(Equal(tt.wantAborted))
+
+			gotControlPlane := &controlplanev1.KubeadmControlPlane{}
+			g.Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(controlPlane), gotControlPlane)).To(Succeed())
+			g.Expect(gotControlPlane.Spec.Version).To(Equal(tt.wantControlPlane))
+			if tt.wantControlPlanePaused {
+				g.Expect(gotControlPlane.Annotations).To(HaveKey(clusterv1.PausedAnnotation))
+			} else {
+				g.Expect(gotControlPlane.Annotations).NotTo(HaveKey(clusterv1.PausedAnnotation))
+			}
+
+			getMachineDeployment := func(name string) *clusterv1.MachineDeployment {
+				md := &clusterv1.MachineDeployment{}
+				g.Expect(c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: name}, md)).To(Succeed())
+				return md
+			}
+			for _, name := range tt.wantUpgraded {
+				g.Expect(*getMachineDeployment(name).Spec.Template.Spec.Version).To(Equal("v1.21.1"), name)
+			}
+			for _, name := range tt.wantNotUpgraded {
+				g.Expect(*getMachineDeployment(name).Spec.Template.Spec.Version).To(Equal("v1.20.1"), name)
+			}
+			for _, name := range tt.wantPaused {
+				g.Expect(getMachineDeployment(name).Spec.Paused).To(BeTrue(), name)
+			}
+		})
+	}
+}
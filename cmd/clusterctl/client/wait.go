@@ -0,0 +1,420 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha4"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// NodeRefWaitCondition is a pseudo condition that is satisfied when a Machine has a NodeRef.
+	NodeRefWaitCondition = "NodeRef"
+
+	// InitializedWaitCondition is a pseudo condition that is satisfied when a control plane reports status.initialized.
+	InitializedWaitCondition = "Initialized"
+
+	// AvailableWaitCondition is a pseudo condition that is satisfied when an object reports the Available condition,
+	// or, for objects not reporting it like MachineDeployments and MachineSets, when all the desired replicas are available.
+	AvailableWaitCondition = "Available"
+
+	waitInterval = 5 * time.Second
+)
+
+// WaitOptions carries the options supported by Wait.
+type WaitOptions struct {
+	// Kubeconfig defines the kubeconfig to use for accessing the management cluster. If empty,
+	// default rules for kubeconfig discovery will be used.
+	Kubeconfig Kubeconfig
+
+	// Namespace where the objects are located. If unspecified, the current namespace will be used.
+	Namespace string
+
+	// For is the expression defining what to wait for. The expression is composed of terms in the
+	// RESOURCE/NAME[=CONDITION] format, e.g. machinedeployment/md-0=Available, combined with the
+	// && and || operators, with && taking precedence over ||.
+	// If the condition is omitted, the default condition for the resource is used.
+	For string
+
+	// Timeout defines how long to wait for the expression to be satisfied.
+	Timeout time.Duration
+
+	// ResourceTimeouts defines, by resource, how long to wait for the terms on that resource to be satisfied,
+	// e.g. {"machine": 20 * time.Minute}; terms still unsatisfied after the deadline for their resource are
+	// considered failed, and the wait is aborted as soon as the expression can no longer be satisfied.
+	// Well-known resources can be identified either by resource name or by short name.
+	ResourceTimeouts map[string]time.Duration
+
+	// Skip is a list of resources whose terms are considered satisfied without checking them.
+	// Well-known resources can be identified either by resource name or by short name.
+	Skip []string
+}
+
+// PendingWaitTerm describes a term of a wait expression which is not satisfied.
+type PendingWaitTerm struct {
+	// Resource, Name and Condition identify the term, as defined in the expression.
+	Resource  string
+	Name      string
+	Condition string
+
+	// Reason describes why the term is not satisfied, e.g. the object does not exist yet.
+	Reason string
+
+	// DeadlineExceeded is true if the term is not satisfied after the deadline defined for its resource.
+	DeadlineExceeded bool
+}
+
+func (t PendingWaitTerm) String() string {
+	s := fmt.Sprintf("%s/%s=%s", t.Resource, t.Name, t.Condition)
+	if t.Reason != "" {
+		s = fmt.Sprintf("%s (%s)", s, t.Reason)
+	}
+	if t.DeadlineExceeded {
+		s = fmt.Sprintf("%s [deadline exceeded]", s)
+	}
+	return s
+}
+
+// WaitTimeoutError is returned by Wait when the expression is not satisfied before the timeout, or before
+// the deadlines defined for the resources it depends on; it holds a snapshot of the unsatisfied terms.
+type WaitTimeoutError struct {
+	// For is the expression which has not been satisfied.
+	For string
+
+	// Pending is the list of terms not satisfied when the wait has been aborted.
+	Pending []PendingWaitTerm
+}
+
+func (e *WaitTimeoutError) Error() string {
+	terms := make([]string, 0, len(e.Pending))
+	for _, t := range e.Pending {
+		terms = append(terms, t.String())
+	}
+	return fmt.Sprintf("timed out waiting for %q; unsatisfied conditions: %s", e.For, strings.Join(terms, ", "))
+}
+
+// waitResource defines a resource supported by Wait.
+type waitResource struct {
+	gvk              schema.GroupVersionKind
+	defaultCondition string
+}
+
+// waitResources defines the well-known resources supported by Wait, by resource name and short name.
+var waitResources = map[string]waitResource{
+	"cluster":             {gvk: clusterv1.GroupVersion.WithKind("Cluster"), defaultCondition: string(clusterv1.ReadyCondition)},
+	"kubeadmcontrolplane": {gvk: controlplanev1.GroupVersion.WithKind("KubeadmControlPlane"), defaultCondition: InitializedWaitCondition},
+	"kcp":                 {gvk: controlplanev1.GroupVersion.WithKind("KubeadmControlPlane"), defaultCondition: InitializedWaitCondition},
+	"machinedeployment":   {gvk: clusterv1.GroupVersion.WithKind("MachineDeployment"), defaultCondition: AvailableWaitCondition},
+	"md":                  {gvk: clusterv1.GroupVersion.WithKind("MachineDeployment"), defaultCondition: AvailableWaitCondition},
+	"machineset":          {gvk: clusterv1.GroupVersion.WithKind("MachineSet"), defaultCondition: AvailableWaitCondition},
+	"ms":                  {gvk: clusterv1.GroupVersion.WithKind("MachineSet"), defaultCondition: AvailableWaitCondition},
+	"machine":             {gvk: clusterv1.GroupVersion.WithKind("Machine"), defaultCondition: NodeRefWaitCondition},
+	"machinepool":         {gvk: expv1.GroupVersion.WithKind("MachinePool"), defaultCondition: string(clusterv1.ReadyCondition)},
+	"mp":                  {gvk: expv1.GroupVersion.WithKind("MachinePool"), defaultCondition: string(clusterv1.ReadyCondition)},
+	"machinehealthcheck":  {gvk: clusterv1.GroupVersion.WithKind("MachineHealthCheck"), defaultCondition: string(clusterv1.RemediationAllowedCondition)},
+	"mhc":                 {gvk: clusterv1.GroupVersion.WithKind("MachineHealthCheck"), defaultCondition: string(clusterv1.RemediationAllowedCondition)},
+}
+
+// waitTerm is a single RESOURCE/NAME=CONDITION term of a wait expression.
+type waitTerm struct {
+	resource  string
+	name      string
+	condition string
+}
+
+// waitExpression is a wait expression in disjunctive normal form, i.e. an OR of ANDs of terms.
+type waitExpression [][]waitTerm
+
+// waitResult is the result of the evaluation of a wait expression.
+type waitResult struct {
+	// satisfied is true if at least one of the alternatives is satisfied.
+	satisfied bool
+
+	// failed is true if every alternative has a term which exceeded the deadline for its resource,
+	// so the expression can no longer be satisfied.
+	failed bool
+
+	// pending is the list of unsatisfied terms.
+	pending []PendingWaitTerm
+}
+
+// Wait waits for an expression of conditions on Cluster API objects to be satisfied.
+func (c *clusterctlClient) Wait(options WaitOptions) error {
+	expression, err := parseWaitExpression(options.For)
+	if err != nil {
+		return err
+	}
+
+	// gets access to the management cluster
+	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{Kubeconfig: options.Kubeconfig})
+	if err != nil {
+		return err
+	}
+
+	// Ensure this command only runs against management clusters with the current Cluster API contract.
+	if err := clusterClient.ProviderInventory().CheckCAPIContract(); err != nil {
+		return err
+	}
+
+	// If the option specifying the Namespace is empty, try to detect it.
+	if options.Namespace == "" {
+		currentNamespace, err := clusterClient.Proxy().CurrentNamespace()
+		if err != nil {
+			return err
+		}
+		options.Namespace = currentNamespace
+	}
+
+	cl, err := clusterClient.Proxy().NewClient()
+	if err != nil {
+		return err
+	}
+
+	skip := map[string]bool{}
+	for _, r := range options.Skip {
+		skip[canonicalWaitResource(r)] = true
+	}
+	deadlines := map[string]time.Duration{}
+	for r, d := range options.ResourceTimeouts {
+		deadlines[canonicalWaitResource(r)] = d
+	}
+
+	log := logf.Log
+	start := time.Now()
+	deadlineExceeded := func(term waitTerm) bool {
+		d, ok := deadlines[canonicalWaitResource(term.resource)]
+		return ok && time.Since(start) > d
+	}
+
+	var pending []PendingWaitTerm
+	err = wait.PollImmediate(waitInterval, options.Timeout, func() (bool, error) {
+		result, err := evaluateWaitExpression(cl, options.Namespace, expression, skip, deadlineExceeded)
+		if err != nil {
+			return false, err
+		}
+		pending = result.pending
+		if result.failed {
+			return false, errWaitDeadlineExceeded
+		}
+		if !result.satisfied {
+			log.V(3).Info("Waiting for", "conditions", pending)
+		}
+		return result.satisfied, nil
+	})
+	if err == wait.ErrWaitTimeout || err == errWaitDeadlineExceeded {
+		return &WaitTimeoutError{For: options.For, Pending: pending}
+	}
+	return err
+}
+
+// errWaitDeadlineExceeded is used to abort polling when the expression can no longer be satisfied.
+var errWaitDeadlineExceeded = errors.New("deadline exceeded")
+
+// canonicalWaitResource returns the resource name used to identify a resource in Skip and ResourceTimeouts,
+// resolving the short names of well-known resources, e.g. md to machinedeployment.
+func canonicalWaitResource(resource string) string {
+	resource = strings.ToLower(resource)
+	if r, ok := waitResources[resource]; ok {
+		return strings.ToLower(r.gvk.Kind)
+	}
+	return resource
+}
+
+// parseWaitExpression parses an expression composed of RESOURCE/NAME[=CONDITION] terms combined with && and ||.
+func parseWaitExpression(expression string) (waitExpression, error) {
+	if strings.TrimSpace(expression) == "" {
+		return nil, errors.New("the expression to wait for is required")
+	}
+
+	var ret waitExpression
+	for _, or := range strings.Split(expression, "||") {
+		var and []waitTerm
+		for _, s := range strings.Split(or, "&&") {
+			term, err := parseWaitTerm(strings.TrimSpace(s))
+			if err != nil {
+				return nil, err
+			}
+			and = append(and, term)
+		}
+		ret = append(ret, and)
+	}
+	return ret, nil
+}
+
+// parseWaitTerm parses a RESOURCE/NAME[=CONDITION] term.
+func parseWaitTerm(s string) (waitTerm, error) {
+	ref := s
+	condition := ""
+	if i := strings.Index(s, "="); i >= 0 {
+		ref, condition = s[:i], s[i+1:]
+		if condition == "" {
+			return waitTerm{}, errors.Errorf("invalid term %q: condition must not be empty", s)
+		}
+	}
+
+	parts := strings.Split(ref, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return waitTerm{}, errors.Errorf("invalid term %q: terms must be in the RESOURCE/NAME[=CONDITION] format, e.g. machinedeployment/md-0=Available", s)
+	}
+	term := waitTerm{resource: strings.ToLower(parts[0]), name: parts[1], condition: condition}
+
+	if term.condition == "" {
+		r, ok := waitResources[term.resource]
+		if !ok {
+			return waitTerm{}, errors.Errorf("invalid term %q: a condition is required for resource %q", s, term.resource)
+		}
+		term.condition = r.defaultCondition
+	}
+	return term, nil
+}
+
+// evaluateWaitExpression evaluates an expression, returning the unsatisfied terms if the expression is not satisfied.
+// Terms on skipped resources are considered satisfied, while unsatisfied terms for which deadlineExceeded returns true
+// are considered failed.
+func evaluateWaitExpression(c client.Client, namespace string, expression waitExpression, skip map[string]bool, deadlineExceeded func(waitTerm) bool) (waitResult, error) {
+	result := waitResult{failed: true}
+	for _, and := range expression {
+		satisfied, failed := true, false
+		for _, term := range and {
+			if skip[canonicalWaitResource(term.resource)] {
+				continue
+			}
+			ok, reason, err := evaluateWaitTerm(c, namespace, term)
+			if err != nil {
+				return waitResult{}, err
+			}
+			if !ok {
+				satisfied = false
+				exceeded := deadlineExceeded(term)
+				failed = failed || exceeded
+				result.pending = append(result.pending, PendingWaitTerm{
+					Resource:         term.resource,
+					Name:             term.name,
+					Condition:        term.condition,
+					Reason:           reason,
+					DeadlineExceeded: exceeded,
+				})
+			}
+		}
+		if satisfied {
+			return waitResult{satisfied: true}, nil
+		}
+		result.failed = result.failed && failed
+	}
+	return result, nil
+}
+
+// evaluateWaitTerm checks if the condition of a term is satisfied, returning the reason why it is not;
+// objects not yet existing do not satisfy any condition.
+func evaluateWaitTerm(c client.Client, namespace string, term waitTerm) (bool, string, error) {
+	gvk, err := waitResourceGVK(c, term.resource)
+	if err != nil {
+		return false, "", err
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	if err := c.Get(context.TODO(), client.ObjectKey{Namespace: namespace, Name: term.name}, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, "object does not exist", nil
+		}
+		return false, "", errors.Wrapf(err, "failed to get %s/%s", term.resource, term.name)
+	}
+
+	switch term.condition {
+	case NodeRefWaitCondition:
+		_, found, err := unstructured.NestedMap(obj.Object, "status", "nodeRef")
+		return found, "status.nodeRef is not set", err
+	case InitializedWaitCondition:
+		initialized, _, err := unstructured.NestedBool(obj.Object, "status", "initialized")
+		return initialized, "status.initialized is not true", err
+	case AvailableWaitCondition:
+		getter := conditions.UnstructuredGetter(obj)
+		if conditions.Has(getter, clusterv1.ConditionType(term.condition)) {
+			return conditions.IsTrue(getter, clusterv1.ConditionType(term.condition)), conditionReason(getter, clusterv1.ConditionType(term.condition)), nil
+		}
+		return allReplicasAvailable(obj)
+	default:
+		getter := conditions.UnstructuredGetter(obj)
+		return conditions.IsTrue(getter, clusterv1.ConditionType(term.condition)), conditionReason(getter, clusterv1.ConditionType(term.condition)), nil
+	}
+}
+
+// conditionReason describes why a condition is not true.
+func conditionReason(getter conditions.Getter, t clusterv1.ConditionType) string {
+	c := conditions.Get(getter, t)
+	if c == nil {
+		return fmt.Sprintf("%s condition is not reported", t)
+	}
+	reason := fmt.Sprintf("%s condition is %s", t, c.Status)
+	if c.Reason != "" {
+		reason = fmt.Sprintf("%s: %s", reason, c.Reason)
+	}
+	if c.Message != "" {
+		reason = fmt.Sprintf("%s, %s", reason, c.Message)
+	}
+	return reason
+}
+
+// waitResourceGVK returns the GroupVersionKind for a resource, either a well-known Cluster API resource
+// or any other resource in the RESOURCE.GROUP format, e.g. dockermachines.infrastructure.cluster.x-k8s.io.
+func waitResourceGVK(c client.Client, resource string) (schema.GroupVersionKind, error) {
+	if r, ok := waitResources[resource]; ok {
+		return r.gvk, nil
+	}
+	gvr := schema.ParseGroupResource(resource).WithVersion("")
+	gvk, err := c.RESTMapper().KindFor(gvr)
+	if err != nil {
+		return schema.GroupVersionKind{}, errors.Wrapf(err, "failed to resolve resource %q", resource)
+	}
+	return gvk, nil
+}
+
+// allReplicasAvailable checks if the status of an object reports the desired number of available replicas,
+// returning the reason why it does not.
+func allReplicasAvailable(obj *unstructured.Unstructured) (bool, string, error) {
+	replicas, found, err := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if err != nil || !found {
+		return false, "spec.replicas is not set", err
+	}
+	availableReplicas, _, err := unstructured.NestedInt64(obj.Object, "status", "availableReplicas")
+	if err != nil {
+		return false, "", err
+	}
+	observedGeneration, _, err := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if err != nil {
+		return false, "", err
+	}
+	if observedGeneration < obj.GetGeneration() {
+		return false, "status.observedGeneration is older than metadata.generation", nil
+	}
+	return availableReplicas >= replicas, fmt.Sprintf("%d of %d replicas available", availableReplicas, replicas), nil
+}
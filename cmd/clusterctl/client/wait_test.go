@@ -0,0 +1,240 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	"sigs.k8s.io/cluster-api/util/conditions"
+)
+
+func Test_parseWaitExpression(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		want       waitExpression
+		wantErr    bool
+	}{
+		{
+			name:       "term with explicit condition",
+			expression: "cluster/foo=ControlPlaneReady",
+			want:       waitExpression{{{resource: "cluster", name: "foo", condition: "ControlPlaneReady"}}},
+		},
+		{
+			name:       "terms with default conditions",
+			expression: "Cluster/foo && kcp/foo-cp && md/foo-md-0 && machine/foo-m",
+			want: waitExpression{{
+				{resource: "cluster", name: "foo", condition: "Ready"},
+				{resource: "kcp", name: "foo-cp", condition: InitializedWaitCondition},
+				{resource: "md", name: "foo-md-0", condition: AvailableWaitCondition},
+				{resource: "machine", name: "foo-m", condition: NodeRefWaitCondition},
+			}},
+		},
+		{
+			name:       "&& takes precedence over ||",
+			expression: "cluster/foo && cluster/bar || cluster/baz",
+			want: waitExpression{
+				{{resource: "cluster", name: "foo", condition: "Ready"}, {resource: "cluster", name: "bar", condition: "Ready"}},
+				{{resource: "cluster", name: "baz", condition: "Ready"}},
+			},
+		},
+		{
+			name:       "arbitrary resource with explicit condition",
+			expression: "dockermachines.infrastructure.cluster.x-k8s.io/foo=Ready",
+			want:       waitExpression{{{resource: "dockermachines.infrastructure.cluster.x-k8s.io", name: "foo", condition: "Ready"}}},
+		},
+		{
+			name:       "fails for empty expressions",
+			expression: " ",
+			wantErr:    true,
+		},
+		{
+			name:       "fails for terms not in the RESOURCE/NAME format",
+			expression: "cluster/foo && foo",
+			wantErr:    true,
+		},
+		{
+			name:       "fails for empty conditions",
+			expression: "cluster/foo=",
+			wantErr:    true,
+		},
+		{
+			name:       "fails for arbitrary resources without conditions",
+			expression: "dockermachines.infrastructure.cluster.x-k8s.io/foo",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := parseWaitExpression(tt.expression)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_clusterctlClient_Wait(t *testing.T) {
+	readyCluster := &clusterv1.Cluster{
+		TypeMeta:   metav1.TypeMeta{Kind: "Cluster", APIVersion: clusterv1.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "ready"},
+	}
+	conditions.MarkTrue(readyCluster, clusterv1.ReadyCondition)
+
+	notReadyCluster := &clusterv1.Cluster{
+		TypeMeta:   metav1.TypeMeta{Kind: "Cluster", APIVersion: clusterv1.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "not-ready"},
+	}
+	conditions.MarkFalse(notReadyCluster, clusterv1.ReadyCondition, "Foo", clusterv1.ConditionSeverityInfo, "")
+
+	availableMachineDeployment := &clusterv1.MachineDeployment{
+		TypeMeta:   metav1.TypeMeta{Kind: "MachineDeployment", APIVersion: clusterv1.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "available"},
+		Spec:       clusterv1.MachineDeploymentSpec{Replicas: pointer.Int32Ptr(2)},
+		Status:     clusterv1.MachineDeploymentStatus{AvailableReplicas: 2},
+	}
+	unavailableMachineDeployment := &clusterv1.MachineDeployment{
+		TypeMeta:   metav1.TypeMeta{Kind: "MachineDeployment", APIVersion: clusterv1.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "unavailable"},
+		Spec:       clusterv1.MachineDeploymentSpec{Replicas: pointer.Int32Ptr(2)},
+		Status:     clusterv1.MachineDeploymentStatus{AvailableReplicas: 1},
+	}
+
+	machineWithNode := &clusterv1.Machine{
+		TypeMeta:   metav1.TypeMeta{Kind: "Machine", APIVersion: clusterv1.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "with-node"},
+		Status:     clusterv1.MachineStatus{NodeRef: &corev1.ObjectReference{Kind: "Node", Name: "node"}},
+	}
+
+	core := config.NewProvider("cluster-api", "https://somewhere.com", clusterctlv1.CoreProviderType)
+	config1 := newFakeConfig().
+		WithProvider(core)
+
+	cluster1 := newFakeCluster(cluster.Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"}, config1).
+		WithProviderInventory(core.Name(), core.Type(), "v1.0.0", "cluster-api-system", "").
+		WithObjs(readyCluster, notReadyCluster, availableMachineDeployment, unavailableMachineDeployment, machineWithNode)
+	cluster1.fakeProxy.WithFakeCAPISetup()
+
+	client := newFakeClient(config1).
+		WithCluster(cluster1)
+
+	tests := []struct {
+		name             string
+		For              string
+		timeout          time.Duration
+		resourceTimeouts map[string]time.Duration
+		skip             []string
+		wantErr          bool
+		wantPending      []PendingWaitTerm
+	}{
+		{
+			name: "all the terms are satisfied",
+			For:  "cluster/ready && machinedeployment/available && machine/with-node",
+		},
+		{
+			name: "one of the alternatives is satisfied",
+			For:  "cluster/not-ready || cluster/ready && md/available",
+		},
+		{
+			name:    "a condition is not satisfied",
+			For:     "cluster/ready && cluster/not-ready",
+			wantErr: true,
+			wantPending: []PendingWaitTerm{
+				{Resource: "cluster", Name: "not-ready", Condition: "Ready", Reason: "Ready condition is False: Foo"},
+			},
+		},
+		{
+			name:    "a MachineDeployment is not available",
+			For:     "md/unavailable",
+			wantErr: true,
+			wantPending: []PendingWaitTerm{
+				{Resource: "md", Name: "unavailable", Condition: AvailableWaitCondition, Reason: "1 of 2 replicas available"},
+			},
+		},
+		{
+			name:    "an object does not exist",
+			For:     "machine/does-not-exist",
+			wantErr: true,
+			wantPending: []PendingWaitTerm{
+				{Resource: "machine", Name: "does-not-exist", Condition: NodeRefWaitCondition, Reason: "object does not exist"},
+			},
+		},
+		{
+			name: "terms on skipped resources are satisfied",
+			For:  "cluster/not-ready && md/available",
+			skip: []string{"cluster"},
+		},
+		{
+			name: "skipped resources can be identified by short name",
+			For:  "machinedeployment/unavailable && cluster/ready",
+			skip: []string{"md"},
+		},
+		{
+			name:             "the wait is aborted when the deadline for a resource is exceeded",
+			For:              "md/unavailable && cluster/ready",
+			timeout:          time.Hour,
+			resourceTimeouts: map[string]time.Duration{"machinedeployment": 0},
+			wantErr:          true,
+			wantPending: []PendingWaitTerm{
+				{Resource: "md", Name: "unavailable", Condition: AvailableWaitCondition, Reason: "1 of 2 replicas available", DeadlineExceeded: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			timeout := tt.timeout
+			if timeout == 0 {
+				timeout = time.Millisecond
+			}
+
+			err := client.Wait(WaitOptions{
+				Kubeconfig:       Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+				Namespace:        "default",
+				For:              tt.For,
+				Timeout:          timeout,
+				ResourceTimeouts: tt.resourceTimeouts,
+				Skip:             tt.skip,
+			})
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring("timed out"))
+
+				timeoutErr, ok := err.(*WaitTimeoutError)
+				g.Expect(ok).To(BeTrue())
+				g.Expect(timeoutErr.Pending).To(Equal(tt.wantPending))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+		})
+	}
+}
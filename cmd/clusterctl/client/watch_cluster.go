@@ -0,0 +1,394 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/cluster-api/controllers/external"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// defaultWatchClusterEvents is the default number of recent events reported in a WatchClusterStatus.
+	defaultWatchClusterEvents = 5
+
+	// watchClusterResyncInterval is the interval the status is computed at also without receiving any event,
+	// e.g. if watches are not supported for a resource.
+	watchClusterResyncInterval = 30 * time.Second
+)
+
+// watchClusterResources are the resources watched for changes affecting the status of a cluster.
+var watchClusterResources = []schema.GroupVersionResource{
+	clusterv1.GroupVersion.WithResource("clusters"),
+	clusterv1.GroupVersion.WithResource("machinedeployments"),
+	clusterv1.GroupVersion.WithResource("machines"),
+	controlplanev1.GroupVersion.WithResource("kubeadmcontrolplanes"),
+	corev1.SchemeGroupVersion.WithResource("events"),
+}
+
+// WatchClusterOptions carries the options supported by WatchCluster.
+type WatchClusterOptions struct {
+	// Kubeconfig defines the kubeconfig to use for accessing the management cluster. If empty,
+	// default rules for kubeconfig discovery will be used.
+	Kubeconfig Kubeconfig
+
+	// Namespace where the workload cluster is located. If unspecified, the current namespace will be used.
+	Namespace string
+
+	// ClusterName is the name of the workload cluster to watch.
+	ClusterName string
+
+	// Events is the number of recent events to report in each status. Defaults to 5.
+	Events int
+
+	// Timeout defines how long to watch for the cluster to become ready.
+	Timeout time.Duration
+
+	// OnUpdate is called with the status of the cluster each time it is computed.
+	OnUpdate func(status *WatchClusterStatus)
+}
+
+// WatchClusterStatus is an aggregated view of the provisioning status of a cluster.
+type WatchClusterStatus struct {
+	// Namespace and Name of the Cluster.
+	Namespace string
+	Name      string
+
+	// Phase of the Cluster.
+	Phase string
+
+	// Ready is true when the Ready condition of the Cluster is true.
+	Ready bool
+
+	// FailureMessage reports a terminal failure of the Cluster or of its control plane, if any.
+	FailureMessage string
+
+	// Conditions of the Cluster.
+	Conditions clusterv1.Conditions
+
+	// ControlPlane reports the replicas of the control plane, if the Cluster has one.
+	ControlPlane *WatchReplicasStatus
+
+	// MachineDeployments reports the replicas of the MachineDeployments of the Cluster.
+	MachineDeployments []WatchReplicasStatus
+
+	// Machines reports the status of the Machines of the Cluster.
+	Machines []WatchMachineStatus
+
+	// Events are the most recent events for the objects above, the oldest first.
+	Events []WatchEvent
+}
+
+// Failed returns true if the cluster reports a terminal failure.
+func (s *WatchClusterStatus) Failed() bool {
+	return s.FailureMessage != ""
+}
+
+// WatchReplicasStatus reports the replicas of an object managing Machines, e.g. a control plane or a MachineDeployment.
+type WatchReplicasStatus struct {
+	Kind          string
+	Name          string
+	Replicas      int32
+	ReadyReplicas int32
+	Updated       int32
+}
+
+// WatchMachineStatus reports the status of a Machine.
+type WatchMachineStatus struct {
+	Name     string
+	Phase    string
+	NodeName string
+	Version  string
+}
+
+// WatchEvent is an event for one of the objects of a cluster.
+type WatchEvent struct {
+	Time    time.Time
+	Type    string
+	Object  string
+	Reason  string
+	Message string
+}
+
+// WatchCluster watches the provisioning status of a Cluster, until it becomes ready or reports a terminal failure.
+func (c *clusterctlClient) WatchCluster(options WatchClusterOptions) error {
+	if options.ClusterName == "" {
+		return errors.New("the name of the cluster to watch is required")
+	}
+	if options.Events == 0 {
+		options.Events = defaultWatchClusterEvents
+	}
+
+	// gets access to the management cluster
+	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{Kubeconfig: options.Kubeconfig})
+	if err != nil {
+		return err
+	}
+
+	// Ensure this command only runs against management clusters with the current Cluster API contract.
+	if err := clusterClient.ProviderInventory().CheckCAPIContract(); err != nil {
+		return err
+	}
+
+	// If the option specifying the Namespace is empty, try to detect it.
+	if options.Namespace == "" {
+		currentNamespace, err := clusterClient.Proxy().CurrentNamespace()
+		if err != nil {
+			return err
+		}
+		options.Namespace = currentNamespace
+	}
+
+	cl, err := clusterClient.Proxy().NewClient()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), options.Timeout)
+	defer cancel()
+
+	// Watches are started only if the cluster is not yet ready, so a status is reported at least once.
+	updates := make(chan struct{}, 1)
+	startWatches := func() error {
+		config, err := clusterClient.Proxy().GetConfig()
+		if err != nil {
+			return err
+		}
+		dynamicClient, err := dynamic.NewForConfig(config)
+		if err != nil {
+			return errors.Wrap(err, "failed to create the dynamic client for the management cluster")
+		}
+		for _, gvr := range watchClusterResources {
+			go watchResource(ctx, dynamicClient, gvr, options.Namespace, updates)
+		}
+		return nil
+	}
+
+	return watchClusterStatus(ctx, cl, options, updates, startWatches)
+}
+
+// watchClusterStatus computes the status of a Cluster each time a change is notified on updates, or at least every
+// watchClusterResyncInterval, until the cluster becomes ready, reports a terminal failure, or the context is done.
+// start is called after the first status is computed, if the cluster is neither ready nor failed.
+func watchClusterStatus(ctx context.Context, c client.Client, options WatchClusterOptions, updates <-chan struct{}, start func() error) error {
+	started := false
+	ticker := time.NewTicker(watchClusterResyncInterval)
+	defer ticker.Stop()
+
+	for {
+		status, err := getWatchClusterStatus(ctx, c, options.Namespace, options.ClusterName, options.Events)
+		if err != nil {
+			return err
+		}
+		if options.OnUpdate != nil {
+			options.OnUpdate(status)
+		}
+		if status.Ready {
+			return nil
+		}
+		if status.Failed() {
+			return errors.Errorf("cluster %s/%s failed: %s", status.Namespace, status.Name, status.FailureMessage)
+		}
+
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+			started = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Errorf("timed out waiting for cluster %s/%s to be ready", options.Namespace, options.ClusterName)
+		case <-updates:
+		case <-ticker.C:
+		}
+	}
+}
+
+// watchResource notifies updates each time an object of the given resource changes in the namespace, until the context is done.
+// If the resource can not be watched, e.g. because it does not exist in the management cluster, changes are not notified.
+func watchResource(ctx context.Context, c dynamic.Interface, gvr schema.GroupVersionResource, namespace string, updates chan<- struct{}) {
+	log := logf.Log
+	for ctx.Err() == nil {
+		w, err := c.Resource(gvr).Namespace(namespace).Watch(ctx, metav1.ListOptions{})
+		if err != nil {
+			log.V(5).Info("Failed to watch resource, changes are detected periodically", "resource", gvr.GroupResource().String(), "error", err.Error())
+			return
+		}
+		for range w.ResultChan() {
+			// Notify without blocking; a single pending notification is enough to compute the status again.
+			select {
+			case updates <- struct{}{}:
+			default:
+			}
+		}
+		w.Stop()
+	}
+}
+
+// getWatchClusterStatus computes the aggregated status of a Cluster.
+func getWatchClusterStatus(ctx context.Context, c client.Client, namespace, name string, maxEvents int) (*WatchClusterStatus, error) {
+	cluster := &clusterv1.Cluster{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, errors.Errorf("cluster %s/%s not found", namespace, name)
+		}
+		return nil, errors.Wrapf(err, "failed to get cluster %s/%s", namespace, name)
+	}
+
+	status := &WatchClusterStatus{
+		Namespace:  namespace,
+		Name:       name,
+		Phase:      cluster.Status.Phase,
+		Ready:      conditions.IsTrue(cluster, clusterv1.ReadyCondition),
+		Conditions: cluster.Status.Conditions,
+	}
+	if cluster.Status.FailureMessage != nil {
+		status.FailureMessage = *cluster.Status.FailureMessage
+	}
+
+	// involvedObjects are the objects of the cluster the events are reported for.
+	involvedObjects := map[string]bool{"Cluster/" + name: true}
+
+	if ref := cluster.Spec.ControlPlaneRef; ref != nil {
+		controlPlane, err := external.Get(ctx, c, ref, namespace)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+		if controlPlane != nil {
+			status.ControlPlane = &WatchReplicasStatus{
+				Kind:          ref.Kind,
+				Name:          ref.Name,
+				Replicas:      nestedInt32(controlPlane, "spec", "replicas"),
+				ReadyReplicas: nestedInt32(controlPlane, "status", "readyReplicas"),
+				Updated:       nestedInt32(controlPlane, "status", "updatedReplicas"),
+			}
+			if message, _, _ := unstructured.NestedString(controlPlane.Object, "status", "failureMessage"); message != "" && status.FailureMessage == "" {
+				status.FailureMessage = message
+			}
+			involvedObjects[ref.Kind+"/"+ref.Name] = true
+		}
+	}
+
+	clusterLabels := client.MatchingLabels{clusterv1.ClusterLabelName: name}
+
+	machineDeployments := &clusterv1.MachineDeploymentList{}
+	if err := c.List(ctx, machineDeployments, client.InNamespace(namespace), clusterLabels); err != nil {
+		return nil, errors.Wrap(err, "failed to list MachineDeployments")
+	}
+	for i := range machineDeployments.Items {
+		md := &machineDeployments.Items[i]
+		replicas := int32(0)
+		if md.Spec.Replicas != nil {
+			replicas = *md.Spec.Replicas
+		}
+		status.MachineDeployments = append(status.MachineDeployments, WatchReplicasStatus{
+			Kind:          "MachineDeployment",
+			Name:          md.Name,
+			Replicas:      replicas,
+			ReadyReplicas: md.Status.ReadyReplicas,
+			Updated:       md.Status.UpdatedReplicas,
+		})
+		involvedObjects["MachineDeployment/"+md.Name] = true
+	}
+	sort.Slice(status.MachineDeployments, func(i, j int) bool {
+		return status.MachineDeployments[i].Name < status.MachineDeployments[j].Name
+	})
+
+	machines := &clusterv1.MachineList{}
+	if err := c.List(ctx, machines, client.InNamespace(namespace), clusterLabels); err != nil {
+		return nil, errors.Wrap(err, "failed to list Machines")
+	}
+	for i := range machines.Items {
+		m := &machines.Items[i]
+		machine := WatchMachineStatus{
+			Name:  m.Name,
+			Phase: m.Status.Phase,
+		}
+		if m.Status.NodeRef != nil {
+			machine.NodeName = m.Status.NodeRef.Name
+		}
+		if m.Spec.Version != nil {
+			machine.Version = *m.Spec.Version
+		}
+		status.Machines = append(status.Machines, machine)
+		involvedObjects["Machine/"+m.Name] = true
+	}
+	sort.Slice(status.Machines, func(i, j int) bool {
+		return status.Machines[i].Name < status.Machines[j].Name
+	})
+
+	events := &corev1.EventList{}
+	if err := c.List(ctx, events, client.InNamespace(namespace)); err != nil {
+		return nil, errors.Wrap(err, "failed to list Events")
+	}
+	for i := range events.Items {
+		e := &events.Items[i]
+		object := e.InvolvedObject.Kind + "/" + e.InvolvedObject.Name
+		if !involvedObjects[object] {
+			continue
+		}
+		status.Events = append(status.Events, WatchEvent{
+			Time:    eventTime(e),
+			Type:    e.Type,
+			Object:  object,
+			Reason:  e.Reason,
+			Message: e.Message,
+		})
+	}
+	sort.SliceStable(status.Events, func(i, j int) bool {
+		return status.Events[i].Time.Before(status.Events[j].Time)
+	})
+	if len(status.Events) > maxEvents {
+		status.Events = status.Events[len(status.Events)-maxEvents:]
+	}
+
+	return status, nil
+}
+
+// eventTime returns the last time an event occurred.
+func eventTime(e *corev1.Event) time.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	default:
+		return e.CreationTimestamp.Time
+	}
+}
+
+// nestedInt32 returns the value of an integer field of an unstructured object, or zero if the field does not exist.
+func nestedInt32(obj *unstructured.Unstructured, fields ...string) int32 {
+	value, _, _ := unstructured.NestedInt64(obj.Object, fields...)
+	return int32(value)
+}
@@ -0,0 +1,251 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_getWatchClusterStatus(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	g.Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(controlplanev1.AddToScheme(scheme)).To(Succeed())
+
+	clusterLabels := map[string]string{clusterv1.ClusterLabelName: "my-cluster"}
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-cluster"},
+		Spec: clusterv1.ClusterSpec{
+			ControlPlaneRef: &corev1.ObjectReference{
+				APIVersion: controlplanev1.GroupVersion.String(),
+				Kind:       "KubeadmControlPlane",
+				Name:       "my-cluster-control-plane",
+			},
+		},
+		Status: clusterv1.ClusterStatus{Phase: string(clusterv1.ClusterPhaseProvisioned)},
+	}
+	conditions.MarkFalse(cluster, clusterv1.ReadyCondition, "WaitingForControlPlane", clusterv1.ConditionSeverityInfo, "")
+
+	controlPlane := &controlplanev1.KubeadmControlPlane{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-cluster-control-plane"},
+		Spec:       controlplanev1.KubeadmControlPlaneSpec{Replicas: pointer.Int32Ptr(3)},
+		Status:     controlplanev1.KubeadmControlPlaneStatus{ReadyReplicas: 1, UpdatedReplicas: 2},
+	}
+	machineDeployment := &clusterv1.MachineDeployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-cluster-md-0", Labels: clusterLabels},
+		Spec:       clusterv1.MachineDeploymentSpec{Replicas: pointer.Int32Ptr(2)},
+		Status:     clusterv1.MachineDeploymentStatus{ReadyReplicas: 1, UpdatedReplicas: 2},
+	}
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-cluster-md-0-abcde", Labels: clusterLabels},
+		Spec:       clusterv1.MachineSpec{Version: pointer.StringPtr("v1.20.1")},
+		Status: clusterv1.MachineStatus{
+			Phase:   string(clusterv1.MachinePhaseRunning),
+			NodeRef: &corev1.ObjectReference{Kind: "Node", Name: "node-1"},
+		},
+	}
+	otherMachine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "other-machine", Labels: map[string]string{clusterv1.ClusterLabelName: "other"}},
+	}
+
+	objs := []client.Object{cluster, controlPlane, machineDeployment, machine, otherMachine}
+	now := time.Now().Truncate(time.Second)
+	event := func(i int, kind, name string) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Namespace: "default", Name: fmt.Sprintf("event-%d", i)},
+			InvolvedObject: corev1.ObjectReference{Kind: kind, Name: name},
+			LastTimestamp:  metav1.NewTime(now.Add(time.Duration(i) * time.Second)),
+			Type:           corev1.EventTypeNormal,
+			Reason:         fmt.Sprintf("Reason%d", i),
+		}
+	}
+	objs = append(objs,
+		event(1, "Cluster", "my-cluster"),
+		event(2, "KubeadmControlPlane", "my-cluster-control-plane"),
+		event(3, "Machine", "other-machine"),
+		event(4, "MachineDeployment", "my-cluster-md-0"),
+		event(5, "Machine", "my-cluster-md-0-abcde"),
+	)
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+
+	status, err := getWatchClusterStatus(context.Background(), c, "default", "my-cluster", 3)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(status.Phase).To(Equal(string(clusterv1.ClusterPhaseProvisioned)))
+	g.Expect(status.Ready).To(BeFalse())
+	g.Expect(status.Failed()).To(BeFalse())
+	g.Expect(status.Conditions).To(HaveLen(1))
+	g.Expect(status.ControlPlane).To(Equal(&WatchReplicasStatus{Kind: "KubeadmControlPlane", Name: "my-cluster-control-plane", Replicas: 3, ReadyReplicas: 1, Updated: 2}))
+	g.Expect(status.MachineDeployments).To(Equal([]WatchReplicasStatus{{Kind: "MachineDeployment", Name: "my-cluster-md-0", Replicas: 2, ReadyReplicas: 1, Updated: 2}}))
+	g.Expect(status.Machines).To(Equal([]WatchMachineStatus{{Name: "my-cluster-md-0-abcde", Phase: "Running", NodeName: "node-1", Version: "v1.20.1"}}))
+
+	// Only the most recent events for the objects of the cluster are reported, the oldest first.
+	reasons := []string{}
+	for _, e := range status.Events {
+		reasons = append(reasons, e.Reason)
+	}
+	g.Expect(reasons).To(Equal([]string{"Reason2", "Reason4", "Reason5"}))
+
+	_, err = getWatchClusterStatus(context.Background(), c, "default", "does-not-exist", 3)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func Test_watchClusterStatus(t *testing.T) {
+	newCluster := func(name string) *clusterv1.Cluster {
+		return &clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		}
+	}
+
+	readyCluster := newCluster("ready")
+	conditions.MarkTrue(readyCluster, clusterv1.ReadyCondition)
+
+	failedCluster := newCluster("failed")
+	failedCluster.Status.FailureMessage = pointer.StringPtr("failed to provision the load balancer")
+
+	provisioningCluster := newCluster("provisioning")
+	conditions.MarkFalse(provisioningCluster, clusterv1.ReadyCondition, "WaitingForControlPlane", clusterv1.ConditionSeverityInfo, "")
+
+	t.Run("returns when the cluster is ready", func(t *testing.T) {
+		g := NewWithT(t)
+
+		c := fake.NewClientBuilder().WithScheme(test.FakeScheme).WithObjects(readyCluster.DeepCopy()).Build()
+		updates := 0
+		started := false
+		err := watchClusterStatus(context.Background(), c, WatchClusterOptions{
+			Namespace:   "default",
+			ClusterName: "ready",
+			OnUpdate:    func(*WatchClusterStatus) { updates++ },
+		}, nil, func() error { started = true; return nil })
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(updates).To(Equal(1))
+		g.Expect(started).To(BeFalse())
+	})
+
+	t.Run("fails when the cluster reports a failure", func(t *testing.T) {
+		g := NewWithT(t)
+
+		c := fake.NewClientBuilder().WithScheme(test.FakeScheme).WithObjects(failedCluster.DeepCopy()).Build()
+		err := watchClusterStatus(context.Background(), c, WatchClusterOptions{
+			Namespace:   "default",
+			ClusterName: "failed",
+		}, nil, func() error { return nil })
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("failed to provision the load balancer"))
+	})
+
+	t.Run("computes the status again on updates until the cluster is ready", func(t *testing.T) {
+		g := NewWithT(t)
+
+		c := fake.NewClientBuilder().WithScheme(test.FakeScheme).WithObjects(provisioningCluster.DeepCopy()).Build()
+		updates := make(chan struct{}, 1)
+		statuses := []bool{}
+		err := watchClusterStatus(context.Background(), c, WatchClusterOptions{
+			Namespace:   "default",
+			ClusterName: "provisioning",
+			OnUpdate: func(status *WatchClusterStatus) {
+				statuses = append(statuses, status.Ready)
+			},
+		}, updates, func() error {
+			// Simulate the cluster becoming ready after watches are started.
+			cluster := &clusterv1.Cluster{}
+			g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "provisioning"}, cluster)).To(Succeed())
+			conditions.MarkTrue(cluster, clusterv1.ReadyCondition)
+			g.Expect(c.Update(context.Background(), cluster)).To(Succeed())
+			updates <- struct{}{}
+			return nil
+		})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(statuses).To(Equal([]bool{false, true}))
+	})
+
+	t.Run("times out if the cluster does not become ready", func(t *testing.T) {
+		g := NewWithT(t)
+
+		c := fake.NewClientBuilder().WithScheme(test.FakeScheme).WithObjects(provisioningCluster.DeepCopy()).Build()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		err := watchClusterStatus(ctx, c, WatchClusterOptions{
+			Namespace:   "default",
+			ClusterName: "provisioning",
+		}, make(chan struct{}), func() error { return nil })
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("timed out"))
+	})
+}
+
+func Test_clusterctlClient_WatchCluster(t *testing.T) {
+	g := NewWithT(t)
+
+	readyCluster := &clusterv1.Cluster{
+		TypeMeta:   metav1.TypeMeta{Kind: "Cluster", APIVersion: clusterv1.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "ready"},
+	}
+	conditions.MarkTrue(readyCluster, clusterv1.ReadyCondition)
+
+	core := config.NewProvider("cluster-api", "https://somewhere.com", clusterctlv1.CoreProviderType)
+	config1 := newFakeConfig().
+		WithProvider(core)
+
+	cluster1 := newFakeCluster(cluster.Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"}, config1).
+		WithProviderInventory(core.Name(), core.Type(), "v1.0.0", "cluster-api-system", "").
+		WithObjs(readyCluster)
+	cluster1.fakeProxy.WithFakeCAPISetup()
+
+	client := newFakeClient(config1).
+		WithCluster(cluster1)
+
+	var got *WatchClusterStatus
+	err := client.WatchCluster(WatchClusterOptions{
+		Kubeconfig:  Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+		Namespace:   "default",
+		ClusterName: "ready",
+		Timeout:     time.Minute,
+		OnUpdate:    func(status *WatchClusterStatus) { got = status },
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).NotTo(BeNil())
+	g.Expect(got.Ready).To(BeTrue())
+
+	err = client.WatchCluster(WatchClusterOptions{
+		Kubeconfig: Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+		Namespace:  "default",
+	})
+	g.Expect(err).To(HaveOccurred())
+}
@@ -30,3 +30,52 @@ type Processor interface {
 	// yaml with values retrieved from the values getter
 	Process([]byte, func(string) (string, error)) ([]byte, error)
 }
+
+// VariableDeclarationsGetter defines the methods for processors which can
+// provide details about the variables required by a template.
+// NOTE: This is an optional interface, so existing processors implementing
+// only Processor keep working.
+type VariableDeclarationsGetter interface {
+	// GetVariableDeclarations parses the template blob of bytes and provides
+	// the list of variables that the template requires, with their default
+	// values and descriptions, if any.
+	GetVariableDeclarations([]byte) ([]Variable, error)
+}
+
+// GetVariableDeclarations returns the variables required by a template using
+// the given processor; if the processor does not implement
+// VariableDeclarationsGetter, the variables are returned without defaults and
+// descriptions.
+func GetVariableDeclarations(p Processor, rawArtifact []byte) ([]Variable, error) {
+	if getter, ok := p.(VariableDeclarationsGetter); ok {
+		return getter.GetVariableDeclarations(rawArtifact)
+	}
+
+	names, err := p.GetVariables(rawArtifact)
+	if err != nil {
+		return nil, err
+	}
+	variables := make([]Variable, 0, len(names))
+	for _, name := range names {
+		variables = append(variables, Variable{Name: name})
+	}
+	return variables, nil
+}
+
+// Variable describes a variable required by a template.
+type Variable struct {
+	// Name of the variable.
+	Name string
+
+	// Default value of the variable, if any.
+	Default *string
+
+	// Description of the variable, if declared by the template.
+	Description string
+}
+
+// Required returns true if the variable does not have a default value, and
+// thus a value must be provided when processing the template.
+func (v Variable) Required() bool {
+	return v.Default == nil
+}
@@ -30,9 +30,14 @@ import (
 // for variables in the format ${var}. It also allows default values if
 // specified in the format ${var:=default}.
 // See https://github.com/drone/envsubst for more details.
+//
+// Variables can be documented with comments in the format
+// "# clusterctl:variable VAR description", which are reported by
+// GetVariableDeclarations.
 type SimpleProcessor struct{}
 
 var _ Processor = &SimpleProcessor{}
+var _ VariableDeclarationsGetter = &SimpleProcessor{}
 
 func NewSimpleProcessor() *SimpleProcessor {
 	return &SimpleProcessor{}
@@ -68,6 +73,32 @@ func (tp *SimpleProcessor) GetVariables(rawArtifact []byte) ([]string, error) {
 	return varNames, nil
 }
 
+// GetVariableDeclarations returns the variables specified in the yaml, sorted
+// by name, with their default values and the descriptions declared in the
+// template, if any.
+func (tp *SimpleProcessor) GetVariableDeclarations(rawArtifact []byte) ([]Variable, error) {
+	strArtifact := convertLegacyVars(string(rawArtifact))
+
+	variables, err := inspectVariables(strArtifact)
+	if err != nil {
+		return nil, err
+	}
+	descriptions := inspectVariableDescriptions(strArtifact)
+
+	declarations := make([]Variable, 0, len(variables))
+	for name, defaultValue := range variables {
+		declarations = append(declarations, Variable{
+			Name:        name,
+			Default:     defaultValue,
+			Description: descriptions[name],
+		})
+	}
+	sort.Slice(declarations, func(i, j int) bool {
+		return declarations[i].Name < declarations[j].Name
+	})
+	return declarations, nil
+}
+
 // Process returns the final yaml with all the variables replaced with their
 // respective values. If there are variables without corresponding values, it
 // will return the raw yaml along with an error.
@@ -81,11 +112,11 @@ func (tp *SimpleProcessor) Process(rawArtifact []byte, variablesClient func(stri
 
 	var missingVariables []string
 	// keep track of missing variables to return as error later
-	for name, hasDefault := range variables {
+	for name, defaultValue := range variables {
 		_, err := variablesClient(name)
 		// add to missingVariables list if the variable does not exist in the
 		// variablesClient AND it does not have a default value
-		if err != nil && !hasDefault {
+		if err != nil && defaultValue == nil {
 			missingVariables = append(missingVariables, name)
 			continue
 		}
@@ -119,10 +150,10 @@ func (e *errMissingVariables) Error() string {
 }
 
 // inspectVariables parses through the yaml and returns a map of the variable
-// names and if they have default values. It returns an error if it cannot
-// parse the yaml.
-func inspectVariables(data string) (map[string]bool, error) {
-	variables := make(map[string]bool)
+// names and their default values, nil if a variable does not have a default
+// value. It returns an error if it cannot parse the yaml.
+func inspectVariables(data string) (map[string]*string, error) {
+	variables := make(map[string]*string)
 	t, err := parse.Parse(data)
 	if err != nil {
 		return nil, err
@@ -132,8 +163,8 @@ func inspectVariables(data string) (map[string]bool, error) {
 }
 
 // traverse recursively walks down the root node and tracks the variables
-// which are FuncNodes and if the variables have default values.
-func traverse(root parse.Node, variables map[string]bool) {
+// which are FuncNodes and their default values.
+func traverse(root parse.Node, variables map[string]*string) {
 	switch v := root.(type) {
 	case *parse.ListNode:
 		// iterate through the list node
@@ -143,9 +174,45 @@ func traverse(root parse.Node, variables map[string]bool) {
 	case *parse.FuncNode:
 		if _, ok := variables[v.Param]; !ok {
 			// if there are args, then the variable has a default value
-			variables[v.Param] = len(v.Args) > 0
+			var defaultValue *string
+			if len(v.Args) > 0 {
+				value := nodesToString(v.Args)
+				defaultValue = &value
+			}
+			variables[v.Param] = defaultValue
+		}
+	}
+}
+
+// nodesToString returns the text of a list of nodes, e.g. of the default value
+// of a variable; nested variables are returned in the format ${var}.
+func nodesToString(nodes []parse.Node) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		switch v := n.(type) {
+		case *parse.TextNode:
+			b.WriteString(v.Value)
+		case *parse.FuncNode:
+			b.WriteString("${" + v.Param + "}")
+		case *parse.ListNode:
+			b.WriteString(nodesToString(v.Nodes))
 		}
 	}
+	return b.String()
+}
+
+// variableDescriptionRegEx defines the regexp used for searching variable
+// descriptions inside a YAML, in the format "# clusterctl:variable VAR description".
+var variableDescriptionRegEx = regexp.MustCompile(`(?m)^\s*#\s*clusterctl:variable\s+([A-Za-z0-9_]+)[ \t]*(.*)$`)
+
+// inspectVariableDescriptions parses through the yaml and returns a map of the
+// variable names and their descriptions.
+func inspectVariableDescriptions(data string) map[string]string {
+	descriptions := make(map[string]string)
+	for _, match := range variableDescriptionRegEx.FindAllStringSubmatch(data, -1) {
+		descriptions[match[1]] = strings.TrimSpace(match[2])
+	}
+	return descriptions
 }
 
 // legacyVariableRegEx defines the regexp used for searching variables inside a YAML.
@@ -19,6 +19,7 @@ import (
 	"testing"
 
 	. "github.com/onsi/gomega"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
 )
@@ -209,3 +210,57 @@ func TestSimpleProcessor_Process(t *testing.T) {
 		})
 	}
 }
+
+func TestSimpleProcessor_GetVariableDeclarations(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []Variable
+		wantErr bool
+	}{
+		{
+			name: "variables without default values are required",
+			data: "yaml with ${B} ${ A }",
+			want: []Variable{
+				{Name: "A"},
+				{Name: "B"},
+			},
+		},
+		{
+			name: "variables with default values",
+			data: "yaml with ${A:=foo} ${B=bar} ${C:=${A}-bar}",
+			want: []Variable{
+				{Name: "A", Default: pointer.StringPtr("foo")},
+				{Name: "B", Default: pointer.StringPtr("bar")},
+				{Name: "C", Default: pointer.StringPtr("${A}-bar")},
+			},
+		},
+		{
+			name: "variables with descriptions",
+			data: "# clusterctl:variable A The A variable.\n#clusterctl:variable   B\nyaml with ${A} ${B:=foo}",
+			want: []Variable{
+				{Name: "A", Description: "The A variable."},
+				{Name: "B", Default: pointer.StringPtr("foo")},
+			},
+		},
+		{
+			name:    "returns error for variables with regex metacharacters",
+			data:    "yaml with ${BA$R}\n${FOO}",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			p := NewSimpleProcessor()
+			actual, err := p.GetVariableDeclarations([]byte(tt.data))
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(actual).To(Equal(tt.want))
+		})
+	}
+}
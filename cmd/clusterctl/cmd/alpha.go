@@ -29,6 +29,22 @@ var alphaCmd = &cobra.Command{
 func init() {
 	// Alpha commands should be added here.
 	alphaCmd.AddCommand(rolloutCmd)
+	alphaCmd.AddCommand(sshCmd)
+	alphaCmd.AddCommand(waitCmd)
+	alphaCmd.AddCommand(diffCmd)
+	alphaCmd.AddCommand(applyCmd)
+	alphaCmd.AddCommand(deleteObjectsCmd)
+	alphaCmd.AddCommand(pluginCmd)
+	alphaCmd.AddCommand(diagnosticsCmd)
+	alphaCmd.AddCommand(lintCmd)
+	alphaCmd.AddCommand(watchCmd)
+	alphaCmd.AddCommand(logsCmd)
+	alphaCmd.AddCommand(topologyCmd)
+	alphaCmd.AddCommand(drainCmd)
+	alphaCmd.AddCommand(upgradeClusterCmd)
+	alphaCmd.AddCommand(historyCmd)
+	alphaCmd.AddCommand(exportCmd)
+	alphaCmd.AddCommand(rotateTemplatesCmd)
 
 	RootCmd.AddCommand(alphaCmd)
 }
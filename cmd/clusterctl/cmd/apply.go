@@ -0,0 +1,119 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+	utilyaml "sigs.k8s.io/cluster-api/util/yaml"
+)
+
+type applyOptions struct {
+	kubeconfig        string
+	kubeconfigContext string
+	namespace         string
+	url               string
+	sourceNamespace   string
+	targetNamespace   string
+}
+
+var applyOpts = &applyOptions{}
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Applies Cluster API objects to the management cluster",
+	Long: LongDesc(`
+		Creates or updates Cluster API objects, e.g. the objects exported from another management cluster,
+		in the management cluster.
+
+		The objects are processed using clusterctl's yaml processor, so variables are replaced with values
+		sourced from the clusterctl config file or from environment variables.
+
+		Objects are applied using server-side apply, so fields not included in the objects, e.g. fields set
+		by controllers, are preserved. If the objects depend on cert-manager, e.g. provider components,
+		cert-manager is installed before applying them.
+
+		The objects in a namespace can be re-targeted to another namespace, e.g. when restoring provider
+		components into a management cluster with a different namespace layout.`),
+
+	Example: Examples(`
+		# Applies the objects defined in a local file to the management cluster.
+		clusterctl alpha apply --from ~/workspace/my-cluster.yaml
+
+		# Applies the objects exported from another management cluster.
+		clusterctl alpha export my-cluster --kubeconfig old.kubeconfig | clusterctl alpha apply --kubeconfig new.kubeconfig
+
+		# Restores provider components saved from a management cluster into the capi-providers namespace.
+		clusterctl alpha apply --from components.yaml --source-namespace capi-system --target-namespace capi-providers`),
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runApply(os.Stdin)
+	},
+}
+
+func init() {
+	applyCmd.Flags().StringVar(&applyOpts.kubeconfig, "kubeconfig", "",
+		"Path to the kubeconfig file to use for accessing the management cluster. If unspecified, default discovery rules apply.")
+	applyCmd.Flags().StringVar(&applyOpts.kubeconfigContext, "kubeconfig-context", "",
+		"Context to be used within the kubeconfig file. If empty, current context will be used.")
+	applyCmd.Flags().StringVarP(&applyOpts.namespace, "namespace", "n", "",
+		"Namespace of the objects without a namespace. If unspecified, the current namespace will be used.")
+	applyCmd.Flags().StringVar(&applyOpts.url, "from", "-",
+		"The URL to read the objects from. It defaults to '-' which reads from stdin.")
+	applyCmd.Flags().StringVar(&applyOpts.sourceNamespace, "source-namespace", "",
+		"The namespace of the objects to re-target to the namespace defined by --target-namespace.")
+	applyCmd.Flags().StringVar(&applyOpts.targetNamespace, "target-namespace", "",
+		"The namespace the objects in the namespace defined by --source-namespace are re-targeted to, including the references to it in RBAC subjects, webhook configurations and cert-manager objects.")
+}
+
+func runApply(r io.Reader) error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	options := client.ProcessYAMLOptions{}
+	if applyOpts.url == "-" {
+		options.ReaderSource = &client.ReaderSourceOptions{Reader: r}
+	} else {
+		options.URLSource = &client.URLSourceOptions{URL: applyOpts.url}
+	}
+	printer, err := c.ProcessYAML(options)
+	if err != nil {
+		return err
+	}
+	out, err := printer.Yaml()
+	if err != nil {
+		return err
+	}
+	objs, err := utilyaml.ToUnstructured(out)
+	if err != nil {
+		return err
+	}
+
+	return c.Apply(client.ApplyOptions{
+		Kubeconfig:      client.Kubeconfig{Path: applyOpts.kubeconfig, Context: applyOpts.kubeconfigContext},
+		Namespace:       applyOpts.namespace,
+		Objects:         objs,
+		SourceNamespace: applyOpts.sourceNamespace,
+		TargetNamespace: applyOpts.targetNamespace,
+	})
+}
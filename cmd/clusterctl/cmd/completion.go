@@ -18,11 +18,20 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const completionBoilerPlate = `# Copyright 2021 The Kubernetes Authors.
@@ -138,3 +147,109 @@ func runCompletionZsh(out io.Writer, cmd *cobra.Command) error {
 
 	return nil
 }
+
+// completionOptions defines the flags used for querying the management cluster when generating completions.
+type completionOptions struct {
+	kubeconfig        *string
+	kubeconfigContext *string
+	namespace         *string
+}
+
+// newCompletionProxy returns the proxy used for querying the management cluster when generating completions.
+func newCompletionProxy(options completionOptions) (cluster.Proxy, error) {
+	configClient, err := config.New(cfgFile)
+	if err != nil {
+		return nil, err
+	}
+	return cluster.New(cluster.Kubeconfig{Path: *options.kubeconfig, Context: *options.kubeconfigContext}, configClient).Proxy(), nil
+}
+
+// namespaceCompletionFunc returns a function completing the names of the namespaces in the management cluster.
+func namespaceCompletionFunc(options completionOptions) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		proxy, err := newCompletionProxy(options)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		c, err := proxy.NewClient()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		namespaces := &corev1.NamespaceList{}
+		if err := c.List(context.TODO(), namespaces); err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		names := make([]string, 0, len(namespaces.Items))
+		for _, ns := range namespaces.Items {
+			names = append(names, ns.Name)
+		}
+		return filterCompletions(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// resourceNameCompletionFunc returns a function completing the names of the objects of the given kind in the management cluster;
+// only the first argument is completed.
+func resourceNameCompletionFunc(options completionOptions, gvk schema.GroupVersionKind) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		proxy, err := newCompletionProxy(options)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		namespace := *options.namespace
+		if namespace == "" {
+			if namespace, err = proxy.CurrentNamespace(); err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+		}
+		c, err := proxy.NewClient()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		names, err := listResourceNames(c, gvk, namespace)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return filterCompletions(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// listResourceNames returns the names of the objects of the given kind in a namespace.
+func listResourceNames(c client.Client, gvk schema.GroupVersionKind, namespace string) ([]string, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := c.List(context.TODO(), list, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(list.Items))
+	for _, obj := range list.Items {
+		names = append(names, obj.GetName())
+	}
+	return names, nil
+}
+
+// filterCompletions returns the completions starting with the given prefix.
+func filterCompletions(completions []string, toComplete string) []string {
+	ret := []string{}
+	for _, c := range completions {
+		if strings.HasPrefix(c, toComplete) {
+			ret = append(ret, c)
+		}
+	}
+	return ret
+}
+
+// clusterNameCompletionFunc returns a function completing the names of the Clusters in the management cluster.
+func clusterNameCompletionFunc(options completionOptions) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return resourceNameCompletionFunc(options, clusterv1.GroupVersion.WithKind("Cluster"))
+}
+
+// machineNameCompletionFunc returns a function completing the names of the Machines in the management cluster.
+func machineNameCompletionFunc(options completionOptions) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return resourceNameCompletionFunc(options, clusterv1.GroupVersion.WithKind("Machine"))
+}
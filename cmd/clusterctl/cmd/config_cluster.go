@@ -17,8 +17,11 @@ limitations under the License.
 package cmd
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -40,8 +43,11 @@ type configClusterOptions struct {
 	configMapNamespace string
 	configMapName      string
 	configMapDataKey   string
+	environment        string
 
-	listVariables bool
+	listVariables       bool
+	showVariableDetails bool
+	interactive         bool
 }
 
 var cc = &configClusterOptions{}
@@ -80,11 +86,25 @@ var configClusterClusterCmd = &cobra.Command{
 		# Generates a configuration file for creating workload clusters using a template stored in a ConfigMap.
 		clusterctl config cluster my-cluster --from-config-map MyTemplates
 
+		# Generates a configuration file for creating workload clusters using a template stored in a ConfigMap,
+		# customized with the overlay for the prod environment.
+		clusterctl config cluster my-cluster --from-config-map MyTemplates --environment prod
+
 		# Generates a configuration file for creating workload clusters using a template from a specific URL.
 		clusterctl config cluster my-cluster --from https://github.com/foo-org/foo-repository/blob/master/cluster-template.yaml
 
 		# Generates a configuration file for creating workload clusters using a template stored locally.
-		clusterctl config cluster my-cluster --from ~/workspace/cluster-template.yaml`),
+		clusterctl config cluster my-cluster --from ~/workspace/cluster-template.yaml
+
+		# Prints the variables required by the template.
+		clusterctl config cluster my-cluster --list-variables
+
+		# Prints the required and the optional variables of the template, with their default values and descriptions.
+		clusterctl config cluster my-cluster --list-variables --show-variable-details
+
+		# Generates a configuration file for creating workload clusters, prompting for the values of
+		# the required variables not set in the OS environment variables or the clusterctl config file.
+		clusterctl config cluster my-cluster --interactive`),
 
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -125,10 +145,16 @@ func init() {
 		"The namespace where the ConfigMap exists. If unspecified, the current namespace will be used")
 	configClusterClusterCmd.Flags().StringVar(&cc.configMapDataKey, "from-config-map-key", "",
 		fmt.Sprintf("The ConfigMap.Data key where the workload cluster template is hosted. If unspecified, %q will be used", client.DefaultCustomTemplateConfigMapKey))
+	configClusterClusterCmd.Flags().StringVar(&cc.environment, "environment", "",
+		"The environment, e.g. dev, staging or prod, whose overlay is applied to the template read from the ConfigMap. The overlay is read from the ConfigMap with matching template and environment labels")
 
 	// other flags
 	configClusterClusterCmd.Flags().BoolVar(&cc.listVariables, "list-variables", false,
 		"Returns the list of variables expected by the template instead of the template yaml")
+	configClusterClusterCmd.Flags().BoolVar(&cc.showVariableDetails, "show-variable-details", false,
+		"Groups the variables returned by --list-variables into required and optional variables, with their default values and descriptions")
+	configClusterClusterCmd.Flags().BoolVar(&cc.interactive, "interactive", false,
+		"Prompts for the values of the variables required by the template which are not set in the OS environment variables or the clusterctl config file")
 
 	configCmd.AddCommand(configClusterClusterCmd)
 }
@@ -154,17 +180,22 @@ func runGetClusterTemplate(cmd *cobra.Command, name string) error {
 		templateOptions.WorkerMachineCount = &cc.workerMachineCount
 	}
 
+	if cc.interactive {
+		templateOptions.PromptVariable = newVariablePrompter(os.Stdin, os.Stderr)
+	}
+
 	if cc.url != "" {
 		templateOptions.URLSource = &client.URLSourceOptions{
 			URL: cc.url,
 		}
 	}
 
-	if cc.configMapNamespace != "" || cc.configMapName != "" || cc.configMapDataKey != "" {
+	if cc.configMapNamespace != "" || cc.configMapName != "" || cc.configMapDataKey != "" || cc.environment != "" {
 		templateOptions.ConfigMapSource = &client.ConfigMapSourceOptions{
-			Namespace: cc.configMapNamespace,
-			Name:      cc.configMapName,
-			DataKey:   cc.configMapDataKey,
+			Namespace:   cc.configMapNamespace,
+			Name:        cc.configMapName,
+			DataKey:     cc.configMapDataKey,
+			Environment: cc.environment,
 		}
 	}
 
@@ -181,23 +212,85 @@ func runGetClusterTemplate(cmd *cobra.Command, name string) error {
 	}
 
 	if cc.listVariables {
-		return templateListVariablesOutput(template)
+		if cc.showVariableDetails {
+			return templateListVariableDetailsOutput(os.Stdout, template)
+		}
+		return templateListVariablesOutput(os.Stdout, template)
 	}
 
 	return templateYAMLOutput(template)
 }
 
-func templateListVariablesOutput(template client.Template) error {
+func templateListVariablesOutput(w io.Writer, template client.Template) error {
 	if len(template.Variables()) > 0 {
-		fmt.Println("Variables:")
+		fmt.Fprintln(w, "Variables:")
 		for _, v := range template.Variables() {
-			fmt.Printf("  - %s\n", v)
+			fmt.Fprintf(w, "  - %s\n", v)
 		}
 	}
-	fmt.Println()
+	fmt.Fprintln(w)
 	return nil
 }
 
+func templateListVariableDetailsOutput(w io.Writer, template client.Template) error {
+	var required, optional []string
+	for _, v := range template.VariableDeclarations() {
+		line := v.Name
+		if v.Default != nil {
+			line += fmt.Sprintf(" (defaults to %q)", *v.Default)
+		}
+		if v.Description != "" {
+			line += ": " + v.Description
+		}
+
+		if v.Required() {
+			required = append(required, line)
+			continue
+		}
+		optional = append(optional, line)
+	}
+
+	if len(required) > 0 {
+		fmt.Fprintln(w, "Required Variables:")
+		for _, v := range required {
+			fmt.Fprintf(w, "  - %s\n", v)
+		}
+	}
+	if len(optional) > 0 {
+		fmt.Fprintln(w, "Optional Variables:")
+		for _, v := range optional {
+			fmt.Fprintf(w, "  - %s\n", v)
+		}
+	}
+	fmt.Fprintln(w)
+	return nil
+}
+
+// newVariablePrompter returns a function prompting for the value of a template variable on w and reading it from r;
+// the prompt is repeated until a value is provided.
+func newVariablePrompter(r io.Reader, w io.Writer) func(variable client.TemplateVariable) (string, error) {
+	reader := bufio.NewReader(r)
+	return func(variable client.TemplateVariable) (string, error) {
+		if variable.Description != "" {
+			fmt.Fprintf(w, "%s: %s\n", variable.Name, variable.Description)
+		}
+		for {
+			fmt.Fprintf(w, "Enter a value for %s: ", variable.Name)
+			line, err := reader.ReadString('\n')
+			if value := strings.TrimSpace(line); value != "" {
+				return value, nil
+			}
+			if err != nil {
+				if err == io.EOF {
+					return "", errors.Errorf("no value provided for variable %s", variable.Name)
+				}
+				return "", err
+			}
+			fmt.Fprintf(w, "A value is required for %s.\n", variable.Name)
+		}
+	}
+}
+
 func templateYAMLOutput(template client.Template) error {
 	yaml, err := template.Yaml()
 	if err != nil {
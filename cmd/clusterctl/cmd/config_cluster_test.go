@@ -0,0 +1,141 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+	yaml "sigs.k8s.io/cluster-api/cmd/clusterctl/client/yamlprocessor"
+)
+
+type fakeTemplate struct {
+	variables []yaml.Variable
+}
+
+var _ client.Template = &fakeTemplate{}
+
+func (t *fakeTemplate) Variables() []string {
+	names := []string{}
+	for _, v := range t.variables {
+		names = append(names, v.Name)
+	}
+	return names
+}
+
+func (t *fakeTemplate) VariableDeclarations() []yaml.Variable { return t.variables }
+
+func (t *fakeTemplate) TargetNamespace() string { return "" }
+
+func (t *fakeTemplate) Yaml() ([]byte, error) { return nil, nil }
+
+func (t *fakeTemplate) Objs() []unstructured.Unstructured { return nil }
+
+func Test_templateListVariablesOutput(t *testing.T) {
+	g := NewWithT(t)
+
+	template := &fakeTemplate{
+		variables: []yaml.Variable{
+			{Name: "CLUSTER_NAME"},
+			{Name: "KUBERNETES_VERSION", Description: "The Kubernetes version."},
+			{Name: "WORKER_MACHINE_COUNT", Default: pointer.StringPtr("1"), Description: "The number of workers."},
+		},
+	}
+
+	var buf bytes.Buffer
+	g.Expect(templateListVariablesOutput(&buf, template)).To(Succeed())
+	g.Expect(buf.String()).To(Equal(`Variables:
+  - CLUSTER_NAME
+  - KUBERNETES_VERSION
+  - WORKER_MACHINE_COUNT
+
+`))
+}
+
+func Test_templateListVariableDetailsOutput(t *testing.T) {
+	g := NewWithT(t)
+
+	template := &fakeTemplate{
+		variables: []yaml.Variable{
+			{Name: "CLUSTER_NAME"},
+			{Name: "KUBERNETES_VERSION", Description: "The Kubernetes version."},
+			{Name: "WORKER_MACHINE_COUNT", Default: pointer.StringPtr("1"), Description: "The number of workers."},
+		},
+	}
+
+	var buf bytes.Buffer
+	g.Expect(templateListVariableDetailsOutput(&buf, template)).To(Succeed())
+	g.Expect(buf.String()).To(Equal(`Required Variables:
+  - CLUSTER_NAME
+  - KUBERNETES_VERSION: The Kubernetes version.
+Optional Variables:
+  - WORKER_MACHINE_COUNT (defaults to "1"): The number of workers.
+
+`))
+}
+
+func Test_newVariablePrompter(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		want       string
+		wantErr    bool
+		wantOutput string
+	}{
+		{
+			name:       "returns the value entered",
+			input:      "v1.20.0\n",
+			want:       "v1.20.0",
+			wantOutput: "KUBERNETES_VERSION: The Kubernetes version.\nEnter a value for KUBERNETES_VERSION: ",
+		},
+		{
+			name:  "prompts again until a value is entered",
+			input: "\n  \nv1.20.0",
+			want:  "v1.20.0",
+			wantOutput: "KUBERNETES_VERSION: The Kubernetes version.\n" +
+				"Enter a value for KUBERNETES_VERSION: A value is required for KUBERNETES_VERSION.\n" +
+				"Enter a value for KUBERNETES_VERSION: A value is required for KUBERNETES_VERSION.\n" +
+				"Enter a value for KUBERNETES_VERSION: ",
+		},
+		{
+			name:    "fails if the input ends without a value",
+			input:   "\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			var out bytes.Buffer
+			prompt := newVariablePrompter(strings.NewReader(tt.input), &out)
+			got, err := prompt(client.TemplateVariable{Name: "KUBERNETES_VERSION", Description: "The Kubernetes version."})
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+			g.Expect(out.String()).To(Equal(tt.wantOutput))
+		})
+	}
+}
@@ -80,8 +80,8 @@ var deleteCmd = &cobra.Command{
 		# ongoing costs incurred as a result of this.
 		clusterctl delete --core cluster-api --infrastructure aws
 
-		# Delete the AWS infrastructure provider and related CRDs. Please note that this forces deletion of
-		# all the related objects (e.g. AWSClusters, AWSMachines etc.).
+		# Delete the AWS infrastructure provider and related CRDs. Please note that CRDs are not deleted
+		# if instances of the CRDs exist (e.g. AWSClusters, AWSMachines etc.), unless also --include-namespace is used.
 		# Important! As a consequence of this operation, all the corresponding resources managed by
 		# the AWS infrastructure provider are orphaned and there might be ongoing costs incurred as a result of this.
 		clusterctl delete --infrastructure aws --include-crd
@@ -112,7 +112,7 @@ func init() {
 	deleteCmd.Flags().BoolVar(&dd.includeNamespace, "include-namespace", false,
 		"Forces the deletion of the namespace where the providers are hosted (and of all the contained objects)")
 	deleteCmd.Flags().BoolVar(&dd.includeCRDs, "include-crd", false,
-		"Forces the deletion of the provider's CRDs (and of all the related objects)")
+		"Forces the deletion of the provider's CRDs. CRDs with existing instances are deleted, together with all their instances, only if also --include-namespace is set")
 
 	deleteCmd.Flags().StringVar(&dd.coreProvider, "core", "",
 		"Core provider version (e.g. cluster-api:v0.3.0) to delete from the management cluster")
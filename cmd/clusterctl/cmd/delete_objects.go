@@ -0,0 +1,119 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+	utilyaml "sigs.k8s.io/cluster-api/util/yaml"
+)
+
+type deleteObjectsOptions struct {
+	kubeconfig        string
+	kubeconfigContext string
+	namespace         string
+	url               string
+	cascade           string
+	wait              bool
+	timeout           time.Duration
+}
+
+var deleteObjectsOpts = &deleteObjectsOptions{}
+
+var deleteObjectsCmd = &cobra.Command{
+	Use:   "delete-objects",
+	Short: "Deletes Cluster API objects from the management cluster",
+	Long: LongDesc(`
+		Deletes Cluster API objects, e.g. the objects of a manifest applied with clusterctl alpha apply,
+		from the management cluster.
+
+		The objects are processed using clusterctl's yaml processor, so variables are replaced with values
+		sourced from the clusterctl config file or from environment variables.
+
+		Objects are deleted in reverse order, and objects which do not exist are ignored. By default dependents
+		are deleted before their owners (foreground deletion); optionally, the command waits for all the objects
+		to be removed, and reports the objects which were not removed before the timeout.`),
+
+	Example: Examples(`
+		# Deletes the objects defined in a local file from the management cluster, waiting for them to be removed.
+		clusterctl alpha delete-objects --from ~/workspace/my-cluster.yaml --wait
+
+		# Deletes the objects defined in a local file, leaving their dependents in the management cluster.
+		clusterctl alpha delete-objects --from ~/workspace/my-cluster.yaml --cascade orphan`),
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDeleteObjects(os.Stdin)
+	},
+}
+
+func init() {
+	deleteObjectsCmd.Flags().StringVar(&deleteObjectsOpts.kubeconfig, "kubeconfig", "",
+		"Path to the kubeconfig file to use for accessing the management cluster. If unspecified, default discovery rules apply.")
+	deleteObjectsCmd.Flags().StringVar(&deleteObjectsOpts.kubeconfigContext, "kubeconfig-context", "",
+		"Context to be used within the kubeconfig file. If empty, current context will be used.")
+	deleteObjectsCmd.Flags().StringVarP(&deleteObjectsOpts.namespace, "namespace", "n", "",
+		"Namespace of the objects without a namespace. If unspecified, the current namespace will be used.")
+	deleteObjectsCmd.Flags().StringVar(&deleteObjectsOpts.url, "from", "-",
+		"The URL to read the objects from. It defaults to '-' which reads from stdin.")
+	deleteObjectsCmd.Flags().StringVar(&deleteObjectsOpts.cascade, "cascade", string(metav1.DeletePropagationForeground),
+		"How the dependents of the deleted objects are handled; one of Foreground, Background or Orphan.")
+	deleteObjectsCmd.Flags().BoolVar(&deleteObjectsOpts.wait, "wait", false,
+		"Wait for the deleted objects to be removed from the management cluster.")
+	deleteObjectsCmd.Flags().DurationVar(&deleteObjectsOpts.timeout, "timeout", 5*time.Minute,
+		"The maximum time to wait for all the deleted objects to be removed, used only if --wait is set.")
+}
+
+func runDeleteObjects(r io.Reader) error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	options := client.ProcessYAMLOptions{}
+	if deleteObjectsOpts.url == "-" {
+		options.ReaderSource = &client.ReaderSourceOptions{Reader: r}
+	} else {
+		options.URLSource = &client.URLSourceOptions{URL: deleteObjectsOpts.url}
+	}
+	printer, err := c.ProcessYAML(options)
+	if err != nil {
+		return err
+	}
+	out, err := printer.Yaml()
+	if err != nil {
+		return err
+	}
+	objs, err := utilyaml.ToUnstructured(out)
+	if err != nil {
+		return err
+	}
+
+	return c.DeleteObjects(client.DeleteObjectsOptions{
+		Kubeconfig:        client.Kubeconfig{Path: deleteObjectsOpts.kubeconfig, Context: deleteObjectsOpts.kubeconfigContext},
+		Namespace:         deleteObjectsOpts.namespace,
+		Objects:           objs,
+		PropagationPolicy: metav1.DeletionPropagation(deleteObjectsOpts.cascade),
+		WaitForRemoval:    deleteObjectsOpts.wait,
+		Timeout:           deleteObjectsOpts.timeout,
+	})
+}
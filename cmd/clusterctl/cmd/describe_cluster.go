@@ -109,6 +109,10 @@ func init() {
 	describeClusterClusterCmd.Flags().BoolVar(&dc.disableGrouping, "disable-grouping", false,
 		"Disable grouping machines when ready condition has the same Status, Severity and Reason.")
 
+	completion := completionOptions{kubeconfig: &dc.kubeconfig, kubeconfigContext: &dc.kubeconfigContext, namespace: &dc.namespace}
+	describeClusterClusterCmd.ValidArgsFunction = clusterNameCompletionFunc(completion)
+	_ = describeClusterClusterCmd.RegisterFlagCompletionFunc("namespace", namespaceCompletionFunc(completion))
+
 	describeCmd.AddCommand(describeClusterClusterCmd)
 }
 
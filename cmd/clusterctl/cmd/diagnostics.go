@@ -0,0 +1,123 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+)
+
+type diagnosticsOptions struct {
+	kubeconfig              string
+	kubeconfigContext       string
+	namespace               string
+	output                  string
+	logTailLines            int64
+	workloadClusterNodeLogs bool
+	nodeLogFiles            []string
+}
+
+var diagnosticsOpts = &diagnosticsOptions{}
+
+var diagnosticsCmd = &cobra.Command{
+	Use:   "collect-diagnostics",
+	Short: "Collect diagnostics from a management cluster for bug reports",
+	Long: LongDesc(`
+		Collect diagnostics from a management cluster into a tarball, to be attached to bug reports.
+
+		The tarball contains the Cluster API objects, the logs of the controllers, the events, the versions of the CRDs
+		and the webhook configurations installed by clusterctl; optionally, log files can be collected from the nodes
+		of the workload clusters through the API server node proxy.
+
+		Secret values and the last applied configurations are redacted, however please review the content of the
+		tarball before sharing it.`),
+
+	Example: Examples(`
+		# Collect diagnostics from the management cluster.
+		clusterctl alpha collect-diagnostics
+
+		# Collect diagnostics for the objects in the foo namespace, including the last 1000 lines of the controller logs.
+		clusterctl alpha collect-diagnostics --namespace foo --tail 1000 --output foo-diagnostics.tar.gz
+
+		# Collect diagnostics including the cloud-init output of the workload cluster nodes.
+		clusterctl alpha collect-diagnostics --workload-cluster-node-logs --node-log-files cloud-init-output.log`),
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCollectDiagnostics()
+	},
+}
+
+func init() {
+	diagnosticsCmd.Flags().StringVar(&diagnosticsOpts.kubeconfig, "kubeconfig", "",
+		"Path to the kubeconfig file to use for accessing the management cluster. If unspecified, default discovery rules apply.")
+	diagnosticsCmd.Flags().StringVar(&diagnosticsOpts.kubeconfigContext, "kubeconfig-context", "",
+		"Context to be used within the kubeconfig file. If empty, current context will be used.")
+	diagnosticsCmd.Flags().StringVarP(&diagnosticsOpts.namespace, "namespace", "n", "",
+		"The namespace where the Cluster API objects and the events are collected from. If unspecified, all the namespaces are collected.")
+	diagnosticsCmd.Flags().StringVarP(&diagnosticsOpts.output, "output", "o", "",
+		"The file the tarball is written to. If unspecified, a file named clusterctl-diagnostics-<timestamp>.tar.gz is created in the current directory.")
+	diagnosticsCmd.Flags().Int64Var(&diagnosticsOpts.logTailLines, "tail", 0,
+		"The number of lines collected from the end of the controller logs. If unspecified, the whole logs are collected.")
+	diagnosticsCmd.Flags().BoolVar(&diagnosticsOpts.workloadClusterNodeLogs, "workload-cluster-node-logs", false,
+		"Collect log files from the nodes of the workload clusters.")
+	diagnosticsCmd.Flags().StringSliceVar(&diagnosticsOpts.nodeLogFiles, "node-log-files", nil,
+		"The files collected from /var/log on the workload cluster nodes. If unspecified, cloud-init-output.log and kubelet.log are collected.")
+
+	completion := completionOptions{kubeconfig: &diagnosticsOpts.kubeconfig, kubeconfigContext: &diagnosticsOpts.kubeconfigContext}
+	_ = diagnosticsCmd.RegisterFlagCompletionFunc("namespace", namespaceCompletionFunc(completion))
+}
+
+func runCollectDiagnostics() error {
+	if diagnosticsOpts.logTailLines < 0 {
+		return errors.New("--tail must be a positive number")
+	}
+
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	output := diagnosticsOpts.output
+	if output == "" {
+		output = fmt.Sprintf("clusterctl-diagnostics-%s.tar.gz", time.Now().UTC().Format("20060102T150405Z"))
+	}
+	f, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", output)
+	}
+	defer f.Close()
+
+	if err := c.CollectDiagnostics(client.CollectDiagnosticsOptions{
+		Kubeconfig:              client.Kubeconfig{Path: diagnosticsOpts.kubeconfig, Context: diagnosticsOpts.kubeconfigContext},
+		Namespace:               diagnosticsOpts.namespace,
+		LogTailLines:            diagnosticsOpts.logTailLines,
+		WorkloadClusterNodeLogs: diagnosticsOpts.workloadClusterNodeLogs,
+		NodeLogFiles:            diagnosticsOpts.nodeLogFiles,
+		Output:                  f,
+	}); err != nil {
+		return err
+	}
+
+	fmt.Printf("Diagnostics written to %s\n", output)
+	return nil
+}
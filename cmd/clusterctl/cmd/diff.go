@@ -0,0 +1,151 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+	utilyaml "sigs.k8s.io/cluster-api/util/yaml"
+)
+
+type diffOptions struct {
+	kubeconfig        string
+	kubeconfigContext string
+	namespace         string
+	url               string
+}
+
+var diffOpts = &diffOptions{}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compares Cluster API objects with the objects in the management cluster",
+	Long: LongDesc(`
+		Compares Cluster API objects, e.g. the objects generated from an updated cluster template,
+		with the objects in the management cluster.
+
+		The objects are processed using clusterctl's yaml processor, so variables are replaced with values
+		sourced from the clusterctl config file or from environment variables.
+
+		Changes are computed using server-side dry-run, so defaulting and validation applied by the API server
+		and by webhooks are taken into account; the objects in the management cluster are not changed.`),
+
+	Example: Examples(`
+		# Compares the objects defined in a local file with the objects in the management cluster.
+		clusterctl alpha diff --from ~/workspace/my-cluster.yaml
+
+		# Compares the objects generated from a template with the objects in the management cluster.
+		clusterctl generate cluster my-cluster --kubernetes-version v1.21.1 | clusterctl alpha diff`),
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDiff(os.Stdin, os.Stdout)
+	},
+}
+
+func init() {
+	diffCmd.Flags().StringVar(&diffOpts.kubeconfig, "kubeconfig", "",
+		"Path to the kubeconfig file to use for accessing the management cluster. If unspecified, default discovery rules apply.")
+	diffCmd.Flags().StringVar(&diffOpts.kubeconfigContext, "kubeconfig-context", "",
+		"Context to be used within the kubeconfig file. If empty, current context will be used.")
+	diffCmd.Flags().StringVarP(&diffOpts.namespace, "namespace", "n", "",
+		"Namespace of the objects without a namespace. If unspecified, the current namespace will be used.")
+	diffCmd.Flags().StringVar(&diffOpts.url, "from", "-",
+		"The URL to read the objects from. It defaults to '-' which reads from stdin.")
+}
+
+func runDiff(r io.Reader, w io.Writer) error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	options := client.ProcessYAMLOptions{}
+	if diffOpts.url == "-" {
+		options.ReaderSource = &client.ReaderSourceOptions{Reader: r}
+	} else {
+		options.URLSource = &client.URLSourceOptions{URL: diffOpts.url}
+	}
+	printer, err := c.ProcessYAML(options)
+	if err != nil {
+		return err
+	}
+	out, err := printer.Yaml()
+	if err != nil {
+		return err
+	}
+	objs, err := utilyaml.ToUnstructured(out)
+	if err != nil {
+		return err
+	}
+
+	diffs, err := c.Diff(client.DiffOptions{
+		Kubeconfig: client.Kubeconfig{Path: diffOpts.kubeconfig, Context: diffOpts.kubeconfigContext},
+		Namespace:  diffOpts.namespace,
+		Objects:    objs,
+	})
+	if err != nil {
+		return err
+	}
+
+	printDiffs(w, diffs)
+	return nil
+}
+
+// printDiffs prints added and changed objects, with the list of changed fields; unchanged objects are omitted.
+func printDiffs(w io.Writer, diffs []client.ObjectDiff) {
+	changes := 0
+	for _, d := range diffs {
+		obj := d.Object
+		switch d.Operation {
+		case client.DiffAdded:
+			fmt.Fprintf(w, "+ %s %s/%s\n", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+		case client.DiffChanged:
+			fmt.Fprintf(w, "~ %s %s/%s\n", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+			for _, f := range d.Fields {
+				switch {
+				case f.Live == nil:
+					fmt.Fprintf(w, "    + %s: %s\n", f.Path, diffValue(f.Desired))
+				case f.Desired == nil:
+					fmt.Fprintf(w, "    - %s: %s\n", f.Path, diffValue(f.Live))
+				default:
+					fmt.Fprintf(w, "    ~ %s: %s -> %s\n", f.Path, diffValue(f.Live), diffValue(f.Desired))
+				}
+			}
+		default:
+			continue
+		}
+		changes++
+	}
+	if changes == 0 {
+		fmt.Fprintln(w, "No changes.")
+	}
+}
+
+// diffValue returns a compact representation of a field value.
+func diffValue(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+)
+
+func Test_printDiffs(t *testing.T) {
+	obj := func(kind, name string) unstructured.Unstructured {
+		u := unstructured.Unstructured{}
+		u.SetKind(kind)
+		u.SetNamespace("ns1")
+		u.SetName(name)
+		return u
+	}
+
+	tests := []struct {
+		name  string
+		diffs []client.ObjectDiff
+		want  string
+	}{
+		{
+			name: "added and changed objects",
+			diffs: []client.ObjectDiff{
+				{Object: obj("MachineDeployment", "md-0"), Operation: client.DiffUnchanged},
+				{Object: obj("MachineDeployment", "md-1"), Operation: client.DiffAdded},
+				{Object: obj("KubeadmControlPlane", "cp"), Operation: client.DiffChanged, Fields: []cluster.FieldDiff{
+					{Path: "spec.replicas", Live: int64(1), Desired: int64(3)},
+					{Path: "spec.version", Live: "v1.20.0", Desired: "v1.21.0"},
+					{Path: "metadata.labels.foo", Desired: "bar"},
+					{Path: "spec.rolloutAfter", Live: "2021-01-01T00:00:00Z"},
+				}},
+			},
+			want: `+ MachineDeployment ns1/md-1
+~ KubeadmControlPlane ns1/cp
+    ~ spec.replicas: 1 -> 3
+    ~ spec.version: "v1.20.0" -> "v1.21.0"
+    + metadata.labels.foo: "bar"
+    - spec.rolloutAfter: "2021-01-01T00:00:00Z"
+`,
+		},
+		{
+			name: "no changes",
+			diffs: []client.ObjectDiff{
+				{Object: obj("MachineDeployment", "md-0"), Operation: client.DiffUnchanged},
+			},
+			want: "No changes.\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			var b bytes.Buffer
+			printDiffs(&b, tt.diffs)
+			g.Expect(b.String()).To(Equal(tt.want))
+		})
+	}
+}
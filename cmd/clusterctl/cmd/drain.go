@@ -0,0 +1,100 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+)
+
+type drainOptions struct {
+	kubeconfig         string
+	kubeconfigContext  string
+	namespace          string
+	timeout            time.Duration
+	gracePeriodSeconds int
+	deleteEmptyDirData bool
+	disableEviction    bool
+}
+
+var drainOpts = &drainOptions{}
+
+var drainCmd = &cobra.Command{
+	Use:   "drain MACHINE",
+	Short: "Cordons and drains the Node of a Machine",
+	Long: LongDesc(`
+		Cordons the Node of a Machine, and then evicts the Pods running on it, without requiring access to the
+		workload cluster; the Node is read from the Machine, and the workload cluster is accessed using the
+		kubeconfig stored in the management cluster.
+
+		Evictions blocked by a PodDisruptionBudget are retried until the timeout expires; DaemonSet Pods are ignored.`),
+
+	Example: Examples(`
+		# Cordons and drains the Node of a Machine.
+		clusterctl alpha drain my-machine
+
+		# Cordons and drains the Node of a Machine in a particular namespace, giving up after 5 minutes.
+		clusterctl alpha drain my-machine --namespace foo --timeout 5m
+
+		# Cordons and drains the Node of a Machine, deleting Pods using emptyDir volumes.
+		clusterctl alpha drain my-machine --delete-emptydir-data`),
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDrain(args[0])
+	},
+}
+
+func init() {
+	drainCmd.Flags().StringVar(&drainOpts.kubeconfig, "kubeconfig", "",
+		"Path to the kubeconfig file to use for accessing the management cluster. If unspecified, default discovery rules apply.")
+	drainCmd.Flags().StringVar(&drainOpts.kubeconfigContext, "kubeconfig-context", "",
+		"Context to be used within the kubeconfig file. If empty, current context will be used.")
+	drainCmd.Flags().StringVarP(&drainOpts.namespace, "namespace", "n", "",
+		"Namespace where the Machine exists. If unspecified, the current namespace will be used.")
+	drainCmd.Flags().DurationVar(&drainOpts.timeout, "timeout", 0,
+		"The time to wait for the Pods to be evicted or deleted from the Node. Zero means infinite.")
+	drainCmd.Flags().IntVar(&drainOpts.gracePeriodSeconds, "grace-period", -1,
+		"Period of time in seconds given to each Pod to terminate gracefully. If negative, the default value specified in the Pod will be used.")
+	drainCmd.Flags().BoolVar(&drainOpts.deleteEmptyDirData, "delete-emptydir-data", false,
+		"Continue even if there are Pods using emptyDir volumes; data in emptyDir volumes is deleted.")
+	drainCmd.Flags().BoolVar(&drainOpts.disableEviction, "disable-eviction", false,
+		"Delete Pods instead of evicting them, thus bypassing PodDisruptionBudgets.")
+
+	completion := completionOptions{kubeconfig: &drainOpts.kubeconfig, kubeconfigContext: &drainOpts.kubeconfigContext, namespace: &drainOpts.namespace}
+	drainCmd.ValidArgsFunction = machineNameCompletionFunc(completion)
+	_ = drainCmd.RegisterFlagCompletionFunc("namespace", namespaceCompletionFunc(completion))
+}
+
+func runDrain(machineName string) error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	return c.CordonAndDrainMachine(client.CordonAndDrainMachineOptions{
+		Kubeconfig:         client.Kubeconfig{Path: drainOpts.kubeconfig, Context: drainOpts.kubeconfigContext},
+		Namespace:          drainOpts.namespace,
+		MachineName:        machineName,
+		Timeout:            drainOpts.timeout,
+		GracePeriodSeconds: drainOpts.gracePeriodSeconds,
+		DeleteEmptyDirData: drainOpts.deleteEmptyDirData,
+		DisableEviction:    drainOpts.disableEviction,
+	})
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+	utilyaml "sigs.k8s.io/cluster-api/util/yaml"
+)
+
+type exportOptions struct {
+	kubeconfig        string
+	kubeconfigContext string
+	namespace         string
+	targetNamespace   string
+}
+
+var exportOpts = &exportOptions{}
+
+var exportCmd = &cobra.Command{
+	Use:   "export CLUSTER",
+	Short: "Export the definition of a Cluster as a bundle of objects that can be applied to any management cluster",
+	Long: LongDesc(`
+		Export the definition of a Cluster as a YAML bundle, e.g. for migrating the Cluster to another management cluster
+		or for onboarding it in a GitOps repository.
+
+		The bundle contains the Cluster and all the objects belonging to it which are not generated by a controller, e.g. the
+		control plane, the MachineDeployments, their templates and the Secrets holding the certificates of the Cluster, plus
+		all the objects they reference. Status, OwnerReferences, finalizers and the other fields set by the management cluster
+		are removed, so the bundle can be applied as is.
+
+		The bundle contains Secrets; handle it with care.`),
+
+	Example: Examples(`
+		# Export the definition of a Cluster.
+		clusterctl alpha export my-cluster > my-cluster.yaml
+
+		# Export the definition of a Cluster in a particular namespace, re-targeting it to another namespace.
+		clusterctl alpha export my-cluster --namespace foo --target-namespace bar`),
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runExport(os.Stdout, args[0])
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportOpts.kubeconfig, "kubeconfig", "",
+		"Path to the kubeconfig file to use for accessing the management cluster. If unspecified, default discovery rules apply.")
+	exportCmd.Flags().StringVar(&exportOpts.kubeconfigContext, "kubeconfig-context", "",
+		"Context to be used within the kubeconfig file. If empty, current context will be used.")
+	exportCmd.Flags().StringVarP(&exportOpts.namespace, "namespace", "n", "",
+		"Namespace where the Cluster exists. If unspecified, the current namespace will be used.")
+	exportCmd.Flags().StringVar(&exportOpts.targetNamespace, "target-namespace", "",
+		"The namespace the exported objects are re-targeted to. If unspecified, the namespace of the Cluster is preserved.")
+
+	completion := completionOptions{kubeconfig: &exportOpts.kubeconfig, kubeconfigContext: &exportOpts.kubeconfigContext, namespace: &exportOpts.namespace}
+	exportCmd.ValidArgsFunction = clusterNameCompletionFunc(completion)
+	_ = exportCmd.RegisterFlagCompletionFunc("namespace", namespaceCompletionFunc(completion))
+}
+
+func runExport(w io.Writer, clusterName string) error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	objs, err := c.ExportCluster(client.ExportClusterOptions{
+		Kubeconfig:      client.Kubeconfig{Path: exportOpts.kubeconfig, Context: exportOpts.kubeconfigContext},
+		Namespace:       exportOpts.namespace,
+		ClusterName:     clusterName,
+		TargetNamespace: exportOpts.targetNamespace,
+	})
+	if err != nil {
+		return err
+	}
+
+	out, err := utilyaml.FromUnstructured(objs)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(out))
+	return err
+}
@@ -22,8 +22,8 @@ import (
 
 var generateCmd = &cobra.Command{
 	Use:   "generate",
-	Short: "Generate yaml using clusterctl yaml processor.",
-	Long:  `Generate yaml using clusterctl yaml processor.`,
+	Short: "Generate yaml using clusterctl yaml processor, or the skeleton of a new provider.",
+	Long:  `Generate yaml using clusterctl yaml processor, or the skeleton of a new provider.`,
 }
 
 func init() {
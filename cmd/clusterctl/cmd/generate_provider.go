@@ -0,0 +1,86 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/scaffold"
+)
+
+type generateProviderOptions struct {
+	name      string
+	module    string
+	outputDir string
+}
+
+var gpOpts = &generateProviderOptions{}
+
+var generateProviderCmd = &cobra.Command{
+	Use:   "provider",
+	Short: "Generate the skeleton of a new infrastructure provider",
+	Long: LongDesc(`
+		Generate the skeleton of a new infrastructure provider.
+
+		The generated provider includes the InfrastructureCluster, InfrastructureMachine and
+		InfrastructureMachineTemplate types with their webhooks, the controllers reconciling them,
+		the manifests required for deploying the provider with clusterctl and an e2e test suite
+		based on the Cluster API test framework.
+
+		The provider logic is left to be implemented where marked with TODO.`),
+
+	Example: Examples(`
+		# Generates the skeleton of the foo infrastructure provider in the cluster-api-provider-foo directory.
+		clusterctl generate provider --name foo
+
+		# Generates the skeleton of the foo infrastructure provider using a custom Go module and output directory.
+		clusterctl generate provider --name foo --module github.com/foo-org/cluster-api-provider-foo --output-dir ~/workspace/capfoo`),
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runGenerateProvider()
+	},
+}
+
+func init() {
+	generateProviderCmd.Flags().StringVar(&gpOpts.name, "name", "",
+		"The name of the provider, e.g. foo. It is used for naming the provider types, e.g. FooCluster.")
+	generateProviderCmd.Flags().StringVar(&gpOpts.module, "module", "",
+		"The Go module of the provider. If not specified, sigs.k8s.io/cluster-api-provider-<name> is used.")
+	generateProviderCmd.Flags().StringVar(&gpOpts.outputDir, "output-dir", "",
+		"The directory where the provider is generated. If not specified, cluster-api-provider-<name> is used.")
+	_ = generateProviderCmd.MarkFlagRequired("name")
+
+	generateCmd.AddCommand(generateProviderCmd)
+}
+
+func runGenerateProvider() error {
+	files, err := scaffold.Generate(scaffold.Options{
+		Name:      gpOpts.name,
+		Module:    gpOpts.module,
+		OutputDir: gpOpts.outputDir,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		fmt.Println(f)
+	}
+	return nil
+}
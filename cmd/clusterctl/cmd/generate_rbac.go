@@ -0,0 +1,102 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/rbac"
+	utilyaml "sigs.k8s.io/cluster-api/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+type generateRBACOptions struct {
+	operations          []string
+	name                string
+	namespace           string
+	additionalAPIGroups []string
+}
+
+var grOpts = &generateRBACOptions{}
+
+var generateRBACCmd = &cobra.Command{
+	Use:   "rbac",
+	Short: "Generate the minimal RBAC rules required for running clusterctl operations",
+	Long: LongDesc(`
+		Generate the minimal RBAC rules required for running clusterctl operations.
+
+		The generated ClusterRole, and Role if a namespace is specified, can be bound to the
+		service account used by automation pipelines running clusterctl, instead of cluster-admin.`),
+
+	Example: Examples(`
+		# Generates a ClusterRole for running clusterctl init and clusterctl upgrade.
+		clusterctl generate rbac --operations init,upgrade
+
+		# Generates a ClusterRole and a Role in the foo namespace for moving the clusters defined in that namespace.
+		clusterctl generate rbac --operations move --namespace foo
+
+		# Generates the rules for describing clusters using an infrastructure provider with a custom API group.
+		clusterctl generate rbac --operations describe --additional-api-groups infrastructure.example.com`),
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runGenerateRBAC()
+	},
+}
+
+func init() {
+	generateRBACCmd.Flags().StringSliceVar(&grOpts.operations, "operations", nil,
+		fmt.Sprintf("The clusterctl operations the rules must allow. Accepted values are %v.", rbac.Operations))
+	generateRBACCmd.Flags().StringVar(&grOpts.name, "name", rbac.DefaultName,
+		"The name of the generated ClusterRole and Role.")
+	generateRBACCmd.Flags().StringVarP(&grOpts.namespace, "namespace", "n", "",
+		"The namespace where the clusters are defined. If specified, rules for the objects in this namespace are generated in a Role.")
+	generateRBACCmd.Flags().StringSliceVar(&grOpts.additionalAPIGroups, "additional-api-groups", nil,
+		"API groups of the providers objects in addition to the Cluster API ones.")
+	_ = generateRBACCmd.MarkFlagRequired("operations")
+
+	generateCmd.AddCommand(generateRBACCmd)
+}
+
+func runGenerateRBAC() error {
+	operations := make([]rbac.Operation, 0, len(grOpts.operations))
+	for _, o := range grOpts.operations {
+		operations = append(operations, rbac.Operation(o))
+	}
+
+	objs, err := rbac.Generate(rbac.Options{
+		Operations:          operations,
+		Name:                grOpts.name,
+		Namespace:           grOpts.namespace,
+		AdditionalAPIGroups: grOpts.additionalAPIGroups,
+	})
+	if err != nil {
+		return err
+	}
+
+	yamls := make([][]byte, 0, len(objs))
+	for _, o := range objs {
+		out, err := yaml.Marshal(o)
+		if err != nil {
+			return err
+		}
+		yamls = append(yamls, out)
+	}
+	fmt.Print(string(utilyaml.JoinYaml(yamls...)))
+	return nil
+}
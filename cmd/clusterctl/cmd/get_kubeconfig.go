@@ -57,6 +57,11 @@ func init() {
 		"Path to the kubeconfig file to use for accessing the management cluster. If unspecified, default discovery rules apply.")
 	getKubeconfigCmd.Flags().StringVar(&gk.kubeconfigContext, "kubeconfig-context", "",
 		"Context to be used within the kubeconfig file. If empty, current context will be used.")
+
+	completion := completionOptions{kubeconfig: &gk.kubeconfig, kubeconfigContext: &gk.kubeconfigContext, namespace: &gk.namespace}
+	getKubeconfigCmd.ValidArgsFunction = clusterNameCompletionFunc(completion)
+	_ = getKubeconfigCmd.RegisterFlagCompletionFunc("namespace", namespaceCompletionFunc(completion))
+
 	getCmd.AddCommand(getKubeconfigCmd)
 }
 
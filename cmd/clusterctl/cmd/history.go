@@ -0,0 +1,108 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+)
+
+type historyOptions struct {
+	kubeconfig        string
+	kubeconfigContext string
+	operation         string
+	limit             int
+}
+
+var historyOpts = &historyOptions{}
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show the operations performed by clusterctl on a management cluster",
+	Long: LongDesc(`
+		Show the operations performed by clusterctl on a management cluster, e.g. init, move or upgrade apply,
+		including who performed each operation, its parameters and its outcome.
+
+		The operations are read from the audit log stored in the management cluster, sorted from the most recent to the oldest.`),
+
+	Example: Examples(`
+		# Show the operations performed on the management cluster.
+		clusterctl alpha history
+
+		# Show the last 5 move operations performed on the management cluster.
+		clusterctl alpha history --operation move --limit 5`),
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runHistory(os.Stdout)
+	},
+}
+
+func init() {
+	historyCmd.Flags().StringVar(&historyOpts.kubeconfig, "kubeconfig", "",
+		"Path to the kubeconfig file to use for accessing the management cluster. If unspecified, default discovery rules apply.")
+	historyCmd.Flags().StringVar(&historyOpts.kubeconfigContext, "kubeconfig-context", "",
+		"Context to be used within the kubeconfig file. If empty, current context will be used.")
+	historyCmd.Flags().StringVar(&historyOpts.operation, "operation", "",
+		"Show only the operations of this type, e.g. init, delete, move, upgrade-apply, upgrade-cluster, drain, force-delete or rotate-templates.")
+	historyCmd.Flags().IntVar(&historyOpts.limit, "limit", 0,
+		"The maximum number of operations to show, starting from the most recent. Zero means no limit.")
+}
+
+func runHistory(out io.Writer) error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	history, err := c.GetOperationHistory(client.GetOperationHistoryOptions{
+		Kubeconfig: client.Kubeconfig{Path: historyOpts.kubeconfig, Context: historyOpts.kubeconfigContext},
+		Operation:  historyOpts.operation,
+		Limit:      historyOpts.limit,
+	})
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 10, 4, 3, ' ', 0)
+	fmt.Fprintln(w, "TIMESTAMP\tOPERATION\tUSER\tOUTCOME\tPARAMETERS\tMESSAGE")
+	for _, e := range history {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", e.Timestamp.Format(time.RFC3339), e.Operation, e.User, e.Outcome, formatParameters(e.Parameters), e.Message)
+	}
+	return w.Flush()
+}
+
+// formatParameters returns the non-empty parameters of an operation as a sorted, comma separated list of key=value pairs.
+func formatParameters(parameters map[string]string) string {
+	pairs := make([]string, 0, len(parameters))
+	for k, v := range parameters {
+		if v == "" {
+			continue
+		}
+		pairs = append(pairs, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
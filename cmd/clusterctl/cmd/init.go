@@ -18,6 +18,7 @@ package cmd
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
@@ -33,6 +34,9 @@ type initOptions struct {
 	targetNamespace         string
 	watchingNamespace       string
 	listImages              bool
+	waitProviders           bool
+	waitProviderTimeout     time.Duration
+	skipCompatibilityCheck  bool
 }
 
 var initOpts = &initOptions{}
@@ -106,6 +110,12 @@ func init() {
 		"The target namespace where the providers should be deployed. If unspecified, the provider components' default namespace is used.")
 	initCmd.Flags().StringVar(&initOpts.watchingNamespace, "watching-namespace", "",
 		"Namespace the providers should watch when reconciling objects. If unspecified, all namespaces are watched.")
+	initCmd.Flags().BoolVar(&initOpts.waitProviders, "wait-providers", false,
+		"Wait for the providers each provider depends on to be ready before installing it, and for all the providers to be ready before returning.")
+	initCmd.Flags().DurationVar(&initOpts.waitProviderTimeout, "wait-provider-timeout", 5*time.Minute,
+		"Timeout for waiting for each provider to be ready, used only if --wait-providers is set.")
+	initCmd.Flags().BoolVar(&initOpts.skipCompatibilityCheck, "skip-compatibility-check", false,
+		"Skip the check of the compatibility of the providers with the core provider and the Kubernetes version of the management cluster, as defined in the provider metadata.")
 
 	// TODO: Move this to a sub-command or similar, it shouldn't really be a flag.
 	initCmd.Flags().BoolVar(&initOpts.listImages, "list-images", false,
@@ -129,6 +139,9 @@ func runInit() error {
 		TargetNamespace:         initOpts.targetNamespace,
 		WatchingNamespace:       initOpts.watchingNamespace,
 		LogUsageInstructions:    true,
+		WaitProviders:           initOpts.waitProviders,
+		WaitProviderTimeout:     initOpts.waitProviderTimeout,
+		SkipCompatibilityCheck:  initOpts.skipCompatibilityCheck,
 	}
 
 	if initOpts.listImages {
@@ -0,0 +1,142 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/lint"
+	utilyaml "sigs.k8s.io/cluster-api/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// LintOutputText is an option used to print the lint findings in text format.
+	LintOutputText = "text"
+	// LintOutputJSON is an option used to print the lint findings in json format.
+	LintOutputJSON = "json"
+	// LintOutputYaml is an option used to print the lint findings in yaml format.
+	LintOutputYaml = "yaml"
+)
+
+var (
+	// LintOutputs is a list of valid lint outputs.
+	LintOutputs = []string{LintOutputText, LintOutputJSON, LintOutputYaml}
+)
+
+type lintOptions struct {
+	file   string
+	output string
+}
+
+var lintOpts = &lintOptions{}
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Checks a cluster template for violations of the Cluster API contract",
+	Long: LongDesc(`
+		Checks a cluster template for violations of the Cluster API contract, e.g. missing
+		infrastructure or bootstrap references, references whose apiVersion is different from the
+		apiVersion of the referenced objects, MachineDeployment selectors not matching the
+		machine template labels, or machines with a Kubernetes version inconsistent with the control plane.
+
+		Variables in the template are not replaced, so templates can be checked as they are published.
+
+		The command fails if any of the findings is an error, so it can be used in CI.`),
+
+	Example: Examples(`
+		# Checks a cluster template stored locally.
+		clusterctl alpha lint --from ~/workspace/cluster-template.yaml
+
+		# Checks a cluster template passed in via stdin, and prints the findings in json format.
+		cat ~/workspace/cluster-template.yaml | clusterctl alpha lint -o json`),
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLint(os.Stdin, os.Stdout)
+	},
+}
+
+func init() {
+	lintCmd.Flags().StringVar(&lintOpts.file, "from", "-",
+		"The file to read the template from. It defaults to '-' which reads from stdin.")
+	lintCmd.Flags().StringVarP(&lintOpts.output, "output", "o", LintOutputText,
+		fmt.Sprintf("Output format. Valid values: %v.", LintOutputs))
+}
+
+func runLint(r io.Reader, w io.Writer) error {
+	if lintOpts.output != LintOutputText && lintOpts.output != LintOutputJSON && lintOpts.output != LintOutputYaml {
+		return errors.Errorf("invalid output format %q. Valid values: %v", lintOpts.output, LintOutputs)
+	}
+
+	var content []byte
+	var err error
+	if lintOpts.file == "-" {
+		content, err = io.ReadAll(r)
+	} else {
+		content, err = os.ReadFile(lintOpts.file)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to read the template")
+	}
+
+	objs, err := utilyaml.ToUnstructured(content)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the template")
+	}
+
+	findings := lint.Lint(objs)
+	if err := printLintFindings(w, findings, lintOpts.output); err != nil {
+		return err
+	}
+
+	if lint.HasErrors(findings) {
+		return errors.New("the template does not comply with the Cluster API contract")
+	}
+	return nil
+}
+
+// printLintFindings prints the findings in the given output format.
+func printLintFindings(w io.Writer, findings []lint.Finding, output string) error {
+	switch output {
+	case LintOutputJSON:
+		b, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(b))
+	case LintOutputYaml:
+		b, err := yaml.Marshal(findings)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(w, string(b))
+	default:
+		if len(findings) == 0 {
+			fmt.Fprintln(w, "No findings.")
+		}
+		for _, f := range findings {
+			fmt.Fprintln(w, f.String())
+		}
+	}
+	return nil
+}
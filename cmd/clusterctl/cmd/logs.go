@@ -0,0 +1,108 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+)
+
+type logsOptions struct {
+	kubeconfig        string
+	kubeconfigContext string
+	namespace         string
+	nodeLogFiles      []string
+}
+
+var logsOpts = &logsOptions{}
+
+var logsCmd = &cobra.Command{
+	Use:   "logs MACHINE",
+	Short: "Prints the logs of a Machine",
+	Long: LongDesc(`
+		Prints the console logs of a Machine, as exposed by the infrastructure provider; console logs are available also
+		when the Machine fails to bootstrap, and thus can be used for investigating why a Machine does not become a Node.
+
+		If the infrastructure provider does not expose console logs, the log files are read from /var/log on the Node
+		of the Machine, if any, through the API server node proxy of the workload cluster.`),
+
+	Example: Examples(`
+		# Prints the logs of a Machine.
+		clusterctl alpha logs my-machine
+
+		# Prints the logs of a Machine in a particular namespace, reading the kubelet logs from the Node
+		# if the infrastructure provider does not expose console logs.
+		clusterctl alpha logs my-machine --namespace foo --node-log-files kubelet.log`),
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLogs(os.Stdout, args[0])
+	},
+}
+
+func init() {
+	logsCmd.Flags().StringVar(&logsOpts.kubeconfig, "kubeconfig", "",
+		"Path to the kubeconfig file to use for accessing the management cluster. If unspecified, default discovery rules apply.")
+	logsCmd.Flags().StringVar(&logsOpts.kubeconfigContext, "kubeconfig-context", "",
+		"Context to be used within the kubeconfig file. If empty, current context will be used.")
+	logsCmd.Flags().StringVarP(&logsOpts.namespace, "namespace", "n", "",
+		"Namespace where the Machine exists. If unspecified, the current namespace will be used.")
+	logsCmd.Flags().StringSliceVar(&logsOpts.nodeLogFiles, "node-log-files", nil,
+		"The files read from /var/log on the Node when console logs are not available. If unspecified, cloud-init-output.log and kubelet.log are read.")
+
+	completion := completionOptions{kubeconfig: &logsOpts.kubeconfig, kubeconfigContext: &logsOpts.kubeconfigContext, namespace: &logsOpts.namespace}
+	logsCmd.ValidArgsFunction = machineNameCompletionFunc(completion)
+	_ = logsCmd.RegisterFlagCompletionFunc("namespace", namespaceCompletionFunc(completion))
+}
+
+func runLogs(w io.Writer, machineName string) error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	logs, err := c.GetMachineLogs(client.GetMachineLogsOptions{
+		Kubeconfig:   client.Kubeconfig{Path: logsOpts.kubeconfig, Context: logsOpts.kubeconfigContext},
+		Namespace:    logsOpts.namespace,
+		MachineName:  machineName,
+		NodeLogFiles: logsOpts.nodeLogFiles,
+	})
+	if err != nil {
+		return err
+	}
+
+	printMachineLogs(w, logs)
+	return nil
+}
+
+// printMachineLogs prints the logs of a Machine; when the logs are read from the Node, each file is preceded by a header like tail does.
+func printMachineLogs(w io.Writer, logs *client.MachineLogs) {
+	for _, f := range logs.Files {
+		if logs.Source == cluster.NodeMachineLogsSource {
+			fmt.Fprintf(w, "==> %s <==\n", f.Name)
+		}
+		fmt.Fprintf(w, "%s", f.Content)
+		if len(f.Content) > 0 && f.Content[len(f.Content)-1] != '\n' {
+			fmt.Fprintln(w)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+)
+
+func Test_printMachineLogs(t *testing.T) {
+	tests := []struct {
+		name string
+		logs *client.MachineLogs
+		want string
+	}{
+		{
+			name: "prints the console logs as they are",
+			logs: &client.MachineLogs{
+				Source: cluster.ConsoleMachineLogsSource,
+				Files:  []cluster.MachineLogFile{{Name: "console", Content: []byte("boot\nkubeadm init failed\n")}},
+			},
+			want: "boot\nkubeadm init failed\n",
+		},
+		{
+			name: "prints a header for each Node log file",
+			logs: &client.MachineLogs{
+				Source: cluster.NodeMachineLogsSource,
+				Files: []cluster.MachineLogFile{
+					{Name: "cloud-init-output.log", Content: []byte("kubeadm join")},
+					{Name: "kubelet.log", Content: []byte("kubelet started\n")},
+				},
+			},
+			want: "==> cloud-init-output.log <==\nkubeadm join\n==> kubelet.log <==\nkubelet started\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			var buf bytes.Buffer
+			printMachineLogs(&buf, tt.logs)
+			g.Expect(buf.String()).To(Equal(tt.want))
+		})
+	}
+}
@@ -28,6 +28,7 @@ type moveOptions struct {
 	toKubeconfig          string
 	toKubeconfigContext   string
 	namespace             string
+	clusterName           string
 	dryRun                bool
 }
 
@@ -43,7 +44,10 @@ var moveCmd = &cobra.Command{
 
 	Example: Examples(`
 		Move Cluster API objects and all dependencies between management clusters.
-		clusterctl move --to-kubeconfig=target-kubeconfig.yaml`),
+		clusterctl move --to-kubeconfig=target-kubeconfig.yaml
+
+		Move a single Cluster and all its dependencies between management clusters.
+		clusterctl move --to-kubeconfig=target-kubeconfig.yaml --cluster=my-cluster`),
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runMove()
@@ -61,9 +65,14 @@ func init() {
 		"Context to be used within the kubeconfig file for the destination management cluster. If empty, current context will be used.")
 	moveCmd.Flags().StringVarP(&mo.namespace, "namespace", "n", "",
 		"The namespace where the workload cluster is hosted. If unspecified, the current context's namespace is used.")
+	moveCmd.Flags().StringVar(&mo.clusterName, "cluster", "",
+		"The name of the Cluster to move. If unspecified, all the Clusters in the namespace are moved.")
 	moveCmd.Flags().BoolVar(&mo.dryRun, "dry-run", false,
 		"Enable dry run, don't really perform the move actions")
 
+	completion := completionOptions{kubeconfig: &mo.fromKubeconfig, kubeconfigContext: &mo.fromKubeconfigContext, namespace: &mo.namespace}
+	_ = moveCmd.RegisterFlagCompletionFunc("namespace", namespaceCompletionFunc(completion))
+
 	RootCmd.AddCommand(moveCmd)
 }
 
@@ -82,6 +91,7 @@ func runMove() error {
 		FromKubeconfig: client.Kubeconfig{Path: mo.fromKubeconfig, Context: mo.fromKubeconfigContext},
 		ToKubeconfig:   client.Kubeconfig{Path: mo.toKubeconfig, Context: mo.toKubeconfigContext},
 		Namespace:      mo.namespace,
+		ClusterName:    mo.clusterName,
 		DryRun:         mo.dryRun,
 	}); err != nil {
 		return err
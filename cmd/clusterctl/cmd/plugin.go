@@ -0,0 +1,172 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// PluginPrefix is the prefix of the name of the binaries on PATH that are discovered as clusterctl plugins,
+// e.g. a binary named clusterctl-foo-bar is invoked by running clusterctl foo bar.
+const PluginPrefix = "clusterctl-"
+
+// pluginHandler defines methods for finding and executing plugins.
+type pluginHandler interface {
+	// Lookup returns the path of the plugin with the given name, if any.
+	Lookup(name string) (string, bool)
+
+	// Execute runs a plugin with the given arguments and environment.
+	Execute(executablePath string, args, environment []string) error
+}
+
+// defaultPluginHandler implements pluginHandler looking up plugins on PATH.
+type defaultPluginHandler struct{}
+
+// ensure defaultPluginHandler implements the pluginHandler interface.
+var _ pluginHandler = &defaultPluginHandler{}
+
+func (h *defaultPluginHandler) Lookup(name string) (string, bool) {
+	path, err := exec.LookPath(PluginPrefix + name)
+	if err != nil || path == "" {
+		return "", false
+	}
+	return path, true
+}
+
+func (h *defaultPluginHandler) Execute(executablePath string, args, environment []string) error {
+	cmd := exec.Command(executablePath, args...) //nolint:gosec
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = environment
+	return cmd.Run()
+}
+
+// handlePluginCommand looks up a plugin for the given arguments and executes it, returning true if a plugin was found.
+// The longest sequence of arguments matching a plugin name is used, e.g. for clusterctl foo bar baz, clusterctl-foo-bar-baz
+// takes precedence over clusterctl-foo-bar, which takes precedence over clusterctl-foo; remaining arguments
+// and flags are passed to the plugin.
+func handlePluginCommand(handler pluginHandler, args []string) (bool, error) {
+	var names []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			break
+		}
+		// Dashes in the arguments are not supported, because they would make plugin names ambiguous.
+		names = append(names, strings.ReplaceAll(arg, "-", "_"))
+	}
+
+	for len(names) > 0 {
+		path, found := handler.Lookup(strings.Join(names, "-"))
+		if found {
+			return true, handler.Execute(path, args[len(names):], os.Environ())
+		}
+		names = names[:len(names)-1]
+	}
+	return false, nil
+}
+
+// listPlugins returns the paths of the plugins found in the given directories, sorted by name.
+// If a plugin exists in more than one directory, only the first one is returned, like when looking up plugins on PATH.
+func listPlugins(dirs []string) []string {
+	seen := map[string]bool{}
+	plugins := []string{}
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, f := range files {
+			if f.IsDir() || !strings.HasPrefix(f.Name(), PluginPrefix) || !isExecutable(f) {
+				continue
+			}
+			if seen[f.Name()] {
+				continue
+			}
+			seen[f.Name()] = true
+			plugins = append(plugins, filepath.Join(dir, f.Name()))
+		}
+	}
+	sort.Slice(plugins, func(i, j int) bool {
+		return filepath.Base(plugins[i]) < filepath.Base(plugins[j])
+	})
+	return plugins
+}
+
+// isExecutable returns true if a file is executable; on Windows, executables are identified by extension.
+func isExecutable(f os.FileInfo) bool {
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(f.Name()))
+		return ext == ".exe" || ext == ".bat" || ext == ".cmd" || ext == ".com"
+	}
+	return f.Mode()&0111 != 0
+}
+
+var pluginCmd = &cobra.Command{
+	Use:   "plugin",
+	Short: "Provides utilities for interacting with plugins",
+	Long: LongDesc(`
+		Provides utilities for interacting with plugins.
+
+		Plugins are binaries on PATH whose name starts with clusterctl-, e.g. clusterctl-foo-bar;
+		plugins are invoked by running clusterctl followed by the plugin name, with dashes replaced by spaces,
+		e.g. clusterctl foo bar. Dashes in plugin arguments must be replaced with underscores in the binary name.`),
+}
+
+var pluginListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all the plugins found on PATH",
+	Long: LongDesc(`
+		List all the binaries on PATH that can be invoked as clusterctl plugins.`),
+
+	Example: Examples(`
+		# List all the available plugins.
+		clusterctl alpha plugin list`),
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPluginList()
+	},
+}
+
+func init() {
+	pluginCmd.AddCommand(pluginListCmd)
+}
+
+func runPluginList() error {
+	plugins := listPlugins(filepath.SplitList(os.Getenv("PATH")))
+	if len(plugins) == 0 {
+		return errors.New("unable to find any clusterctl plugins on PATH")
+	}
+	for _, p := range plugins {
+		fmt.Println(p)
+	}
+	return nil
+}
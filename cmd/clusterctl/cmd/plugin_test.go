@@ -0,0 +1,135 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+type fakePluginHandler struct {
+	plugins      map[string]string
+	executedPath string
+	executedArgs []string
+}
+
+func (h *fakePluginHandler) Lookup(name string) (string, bool) {
+	path, ok := h.plugins[name]
+	return path, ok
+}
+
+func (h *fakePluginHandler) Execute(executablePath string, args, environment []string) error {
+	h.executedPath = executablePath
+	h.executedArgs = args
+	return nil
+}
+
+func Test_handlePluginCommand(t *testing.T) {
+	plugins := map[string]string{
+		"foo":     "/bin/clusterctl-foo",
+		"foo-bar": "/bin/clusterctl-foo-bar",
+		"foo_baz": "/bin/clusterctl-foo_baz",
+	}
+
+	tests := []struct {
+		name      string
+		args      []string
+		wantFound bool
+		wantPath  string
+		wantArgs  []string
+	}{
+		{
+			name:      "executes a plugin",
+			args:      []string{"foo"},
+			wantFound: true,
+			wantPath:  "/bin/clusterctl-foo",
+			wantArgs:  []string{},
+		},
+		{
+			name:      "executes the plugin with the longest name",
+			args:      []string{"foo", "bar", "--flag", "value"},
+			wantFound: true,
+			wantPath:  "/bin/clusterctl-foo-bar",
+			wantArgs:  []string{"--flag", "value"},
+		},
+		{
+			name:      "passes the remaining arguments to the plugin",
+			args:      []string{"foo", "qux", "--flag"},
+			wantFound: true,
+			wantPath:  "/bin/clusterctl-foo",
+			wantArgs:  []string{"qux", "--flag"},
+		},
+		{
+			name:      "dashes in arguments are replaced with underscores",
+			args:      []string{"foo-baz"},
+			wantFound: true,
+			wantPath:  "/bin/clusterctl-foo_baz",
+			wantArgs:  []string{},
+		},
+		{
+			name:      "no plugin found",
+			args:      []string{"qux", "foo"},
+			wantFound: false,
+		},
+		{
+			name:      "flags are not used for looking up plugins",
+			args:      []string{"--foo"},
+			wantFound: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			h := &fakePluginHandler{plugins: plugins}
+			found, err := handlePluginCommand(h, tt.args)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(found).To(Equal(tt.wantFound))
+			if !tt.wantFound {
+				return
+			}
+			g.Expect(h.executedPath).To(Equal(tt.wantPath))
+			g.Expect(h.executedArgs).To(Equal(tt.wantArgs))
+		})
+	}
+}
+
+func Test_listPlugins(t *testing.T) {
+	g := NewWithT(t)
+
+	dir1, err := ioutil.TempDir("", "plugins1")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir1)
+	dir2, err := ioutil.TempDir("", "plugins2")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir2)
+
+	g.Expect(ioutil.WriteFile(filepath.Join(dir1, "clusterctl-foo"), []byte{}, 0755)).To(Succeed())
+	g.Expect(ioutil.WriteFile(filepath.Join(dir1, "clusterctl-not-executable"), []byte{}, 0600)).To(Succeed())
+	g.Expect(ioutil.WriteFile(filepath.Join(dir1, "kubectl-foo"), []byte{}, 0755)).To(Succeed())
+	g.Expect(ioutil.WriteFile(filepath.Join(dir2, "clusterctl-foo"), []byte{}, 0755)).To(Succeed())
+	g.Expect(ioutil.WriteFile(filepath.Join(dir2, "clusterctl-bar"), []byte{}, 0755)).To(Succeed())
+
+	g.Expect(listPlugins([]string{dir1, "", dir2, "/does-not-exist"})).To(Equal([]string{
+		filepath.Join(dir2, "clusterctl-bar"),
+		filepath.Join(dir1, "clusterctl-foo"),
+	}))
+}
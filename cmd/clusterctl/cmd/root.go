@@ -20,6 +20,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -88,6 +89,23 @@ var RootCmd = &cobra.Command{
 }
 
 func Execute() {
+	// If the command is not a clusterctl command, look for a plugin implementing it.
+	if len(os.Args) > 1 {
+		if _, _, err := RootCmd.Find(os.Args[1:]); err != nil {
+			found, err := handlePluginCommand(&defaultPluginHandler{}, os.Args[1:])
+			if found {
+				if exitErr, ok := err.(*exec.ExitError); ok {
+					os.Exit(exitErr.ExitCode())
+				}
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+				os.Exit(0)
+			}
+		}
+	}
+
 	if err := RootCmd.Execute(); err != nil {
 		if verbosity != nil && *verbosity >= 5 {
 			if err, ok := err.(stackTracer); ok {
@@ -0,0 +1,112 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+)
+
+type rotateTemplatesOptions struct {
+	kubeconfig        string
+	kubeconfigContext string
+	namespace         string
+	clone             bool
+	dryRun            bool
+}
+
+var rotateTemplatesOpts = &rotateTemplatesOptions{}
+
+var rotateTemplatesCmd = &cobra.Command{
+	Use:   "rotate-templates CLUSTER",
+	Short: "Roll out the Machines of a Cluster created from infrastructure machine templates which changed",
+	Long: LongDesc(`
+		Roll out the Machines of a Cluster created from infrastructure machine templates which changed since the last check,
+		e.g. because the templates are managed by a GitOps tool which updates them in place.
+
+		The hash of the template referenced by the control plane and by each MachineDeployment of the Cluster is recorded
+		in an annotation of the referencing object; when the hash changes, a rollout is triggered by setting the restartedAt
+		annotation of the MachineDeployment, or spec.upgradeAfter of the KubeadmControlPlane.
+
+		With --clone, the changed templates are cloned instead, and the referencing objects are changed to use the clones,
+		so the templates used by existing Machines are never changed.
+
+		The first time an object is checked, the hash of its template is only recorded.`),
+
+	Example: Examples(`
+		# Roll out the Machines created from changed templates.
+		clusterctl alpha rotate-templates my-cluster
+
+		# Show the objects whose templates changed, without changing anything.
+		clusterctl alpha rotate-templates my-cluster --dry-run
+
+		# Clone the changed templates and change the objects to reference the clones.
+		clusterctl alpha rotate-templates my-cluster --clone`),
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRotateTemplates(os.Stdout, args[0])
+	},
+}
+
+func init() {
+	rotateTemplatesCmd.Flags().StringVar(&rotateTemplatesOpts.kubeconfig, "kubeconfig", "",
+		"Path to the kubeconfig file to use for accessing the management cluster. If unspecified, default discovery rules apply.")
+	rotateTemplatesCmd.Flags().StringVar(&rotateTemplatesOpts.kubeconfigContext, "kubeconfig-context", "",
+		"Context to be used within the kubeconfig file. If empty, current context will be used.")
+	rotateTemplatesCmd.Flags().StringVarP(&rotateTemplatesOpts.namespace, "namespace", "n", "",
+		"Namespace where the Cluster exists. If unspecified, the current namespace will be used.")
+	rotateTemplatesCmd.Flags().BoolVar(&rotateTemplatesOpts.clone, "clone", false,
+		"Clone the changed templates and change the objects to reference the clones, instead of triggering a rollout.")
+	rotateTemplatesCmd.Flags().BoolVar(&rotateTemplatesOpts.dryRun, "dry-run", false,
+		"Show the actions to be performed without changing any object.")
+
+	completion := completionOptions{kubeconfig: &rotateTemplatesOpts.kubeconfig, kubeconfigContext: &rotateTemplatesOpts.kubeconfigContext, namespace: &rotateTemplatesOpts.namespace}
+	rotateTemplatesCmd.ValidArgsFunction = clusterNameCompletionFunc(completion)
+	_ = rotateTemplatesCmd.RegisterFlagCompletionFunc("namespace", namespaceCompletionFunc(completion))
+}
+
+func runRotateTemplates(out io.Writer, clusterName string) error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	rotations, err := c.RotateMachineTemplates(client.RotateMachineTemplatesOptions{
+		Kubeconfig:  client.Kubeconfig{Path: rotateTemplatesOpts.kubeconfig, Context: rotateTemplatesOpts.kubeconfigContext},
+		Namespace:   rotateTemplatesOpts.namespace,
+		ClusterName: clusterName,
+		Clone:       rotateTemplatesOpts.clone,
+		DryRun:      rotateTemplatesOpts.dryRun,
+	})
+
+	// Prints the actions performed before the error, if any, so the user knows which objects were changed.
+	w := tabwriter.NewWriter(out, 10, 4, 3, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAME\tTEMPLATE\tACTION\tCLONED TEMPLATE")
+	for _, r := range rotations {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.Object.Kind, r.Object.Name, r.Template.Name, r.Action, r.ClonedTemplate)
+	}
+	if flushErr := w.Flush(); flushErr != nil && err == nil {
+		err = flushErr
+	}
+	return err
+}
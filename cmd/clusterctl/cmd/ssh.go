@@ -0,0 +1,178 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+)
+
+type sshOptions struct {
+	kubeconfig        string
+	kubeconfigContext string
+	namespace         string
+	user              string
+	identityFile      string
+	jumpHost          string
+	internal          bool
+}
+
+var sshOpts = &sshOptions{}
+
+var sshCmd = &cobra.Command{
+	Use:   "ssh MACHINE [-- COMMAND [ARGS...]]",
+	Short: "Opens a ssh session to a Machine",
+	Long: LongDesc(`
+		Opens a ssh session to a Machine, using the addresses reported by the infrastructure provider.
+
+		If the Machine does not have external addresses, another Machine of the same Cluster
+		with an external address, giving precedence to control plane Machines, is used as a jump host.
+
+		The ssh user and identity file can be set in the clusterctl configuration file or as environment variables
+		using CLUSTERCTL_SSH_USER and CLUSTERCTL_SSH_IDENTITY_FILE.`),
+
+	Example: Examples(`
+		# Opens a ssh session to a Machine.
+		clusterctl alpha ssh my-machine --user ubuntu
+
+		# Opens a ssh session to a Machine in a particular namespace using a specific identity file.
+		clusterctl alpha ssh my-machine --namespace foo --user ubuntu --identity-file ~/.ssh/my-key
+
+		# Runs a command on a Machine.
+		clusterctl alpha ssh my-machine -- sudo journalctl -u kubelet`),
+
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSSH(args[0], args[1:])
+	},
+}
+
+func init() {
+	sshCmd.Flags().StringVar(&sshOpts.kubeconfig, "kubeconfig", "",
+		"Path to the kubeconfig file to use for accessing the management cluster. If unspecified, default discovery rules apply.")
+	sshCmd.Flags().StringVar(&sshOpts.kubeconfigContext, "kubeconfig-context", "",
+		"Context to be used within the kubeconfig file. If empty, current context will be used.")
+	sshCmd.Flags().StringVarP(&sshOpts.namespace, "namespace", "n", "",
+		"Namespace where the Machine exists. If unspecified, the current namespace will be used.")
+	sshCmd.Flags().StringVarP(&sshOpts.user, "user", "u", "",
+		"The user to log in as on the Machine. If unspecified, the value of CLUSTERCTL_SSH_USER will be used.")
+	sshCmd.Flags().StringVarP(&sshOpts.identityFile, "identity-file", "i", "",
+		"The identity file to use for authentication. If unspecified, the value of CLUSTERCTL_SSH_IDENTITY_FILE will be used.")
+	sshCmd.Flags().StringVar(&sshOpts.jumpHost, "jump-host", "",
+		"The jump host to use for reaching the Machine. If unspecified, a jump host is selected if the Machine does not have external addresses.")
+	sshCmd.Flags().BoolVar(&sshOpts.internal, "internal", false,
+		"Connect to the Machine using its internal address, e.g. when running clusterctl from inside the cluster network.")
+
+	completion := completionOptions{kubeconfig: &sshOpts.kubeconfig, kubeconfigContext: &sshOpts.kubeconfigContext, namespace: &sshOpts.namespace}
+	sshCmd.ValidArgsFunction = machineNameCompletionFunc(completion)
+	_ = sshCmd.RegisterFlagCompletionFunc("namespace", namespaceCompletionFunc(completion))
+}
+
+func runSSH(machineName string, command []string) error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	addresses, err := c.GetMachineAddresses(client.GetMachineAddressesOptions{
+		Kubeconfig:  client.Kubeconfig{Path: sshOpts.kubeconfig, Context: sshOpts.kubeconfigContext},
+		Namespace:   sshOpts.namespace,
+		MachineName: machineName,
+	})
+	if err != nil {
+		return err
+	}
+
+	configClient, err := config.New(cfgFile)
+	if err != nil {
+		return err
+	}
+	// Flags take precedence over environment/config file variables.
+	if sshOpts.user == "" {
+		sshOpts.user, _ = configClient.Variables().Get(config.SSHUserVariable)
+	}
+	if sshOpts.identityFile == "" {
+		sshOpts.identityFile, _ = configClient.Variables().Get(config.SSHIdentityFileVariable)
+	}
+
+	args, err := sshArgs(addresses, sshOpts, command)
+	if err != nil {
+		return err
+	}
+
+	ssh := exec.Command("ssh", args...)
+	ssh.Stdin = os.Stdin
+	ssh.Stdout = os.Stdout
+	ssh.Stderr = os.Stderr
+	return ssh.Run()
+}
+
+// sshArgs returns the ssh arguments for reaching a Machine with the given addresses.
+func sshArgs(addresses *client.MachineAddresses, options *sshOptions, command []string) ([]string, error) {
+	host := ""
+	jumpHost := options.jumpHost
+	switch {
+	case !options.internal && len(addresses.External) > 0:
+		host = addresses.External[0]
+	case len(addresses.Internal) > 0:
+		host = addresses.Internal[0]
+		if jumpHost == "" && !options.internal {
+			if addresses.JumpHost == "" {
+				return nil, errors.New("the Machine does not have external addresses and there are no jump hosts available; use --jump-host to provide one")
+			}
+			jumpHost = addresses.JumpHost
+		}
+	default:
+		return nil, errors.New("the Machine does not have internal addresses")
+	}
+
+	withUser := func(h string) string {
+		if options.user == "" {
+			return h
+		}
+		return options.user + "@" + h
+	}
+
+	args := []string{}
+	if options.identityFile != "" {
+		args = append(args, "-i", options.identityFile)
+	}
+	switch {
+	case jumpHost != "" && options.identityFile != "":
+		// The identity file is not used when connecting to a jump host defined with -J, so a proxy command is used instead.
+		// The proxy command is run by a shell, so its arguments are quoted.
+		args = append(args, "-o", fmt.Sprintf("ProxyCommand=ssh -i %s -W %%h:%%p -- %s", proxyCommandQuote(options.identityFile), proxyCommandQuote(withUser(jumpHost))))
+	case jumpHost != "":
+		args = append(args, "-J", withUser(jumpHost))
+	}
+	// The host is read from the Machine addresses, so it is separated from the options in order not to be parsed as one.
+	args = append(args, "--", withUser(host))
+	return append(args, command...), nil
+}
+
+// proxyCommandQuote quotes a ssh ProxyCommand argument, for both the shell running the command and the ssh
+// expansion of the % tokens.
+func proxyCommandQuote(s string) string {
+	return strings.ReplaceAll("'"+strings.ReplaceAll(s, "'", `'\''`)+"'", "%", "%%")
+}
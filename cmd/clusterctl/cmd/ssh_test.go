@@ -0,0 +1,92 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+)
+
+func Test_sshArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		addresses *client.MachineAddresses
+		options   *sshOptions
+		command   []string
+		want      []string
+		wantErr   bool
+	}{
+		{
+			name:      "connects to the external address",
+			addresses: &client.MachineAddresses{External: []string{"1.2.3.4"}, Internal: []string{"10.0.0.1"}},
+			options:   &sshOptions{user: "ubuntu"},
+			want:      []string{"--", "ubuntu@1.2.3.4"},
+		},
+		{
+			name:      "connects to the internal address if requested",
+			addresses: &client.MachineAddresses{External: []string{"1.2.3.4"}, Internal: []string{"10.0.0.1"}},
+			options:   &sshOptions{internal: true},
+			command:   []string{"uptime"},
+			want:      []string{"--", "10.0.0.1", "uptime"},
+		},
+		{
+			name:      "connects to the internal address via the jump host",
+			addresses: &client.MachineAddresses{Internal: []string{"10.0.0.1"}, JumpHost: "5.6.7.8"},
+			options:   &sshOptions{user: "ubuntu"},
+			want:      []string{"-J", "ubuntu@5.6.7.8", "--", "ubuntu@10.0.0.1"},
+		},
+		{
+			name:      "connects to the internal address via the jump host using an identity file",
+			addresses: &client.MachineAddresses{Internal: []string{"10.0.0.1"}, JumpHost: "5.6.7.8"},
+			options:   &sshOptions{user: "ubuntu", identityFile: "key", jumpHost: "9.9.9.9"},
+			want:      []string{"-i", "key", "-o", "ProxyCommand=ssh -i 'key' -W %h:%p -- 'ubuntu@9.9.9.9'", "--", "ubuntu@10.0.0.1"},
+		},
+		{
+			name:      "quotes the identity file and the jump host in the proxy command",
+			addresses: &client.MachineAddresses{Internal: []string{"10.0.0.1"}, JumpHost: "5.6.7.8;reboot"},
+			options:   &sshOptions{identityFile: "/home/me/my key's %d"},
+			want:      []string{"-i", "/home/me/my key's %d", "-o", `ProxyCommand=ssh -i '/home/me/my key'\''s %%d' -W %h:%p -- '5.6.7.8;reboot'`, "--", "10.0.0.1"},
+		},
+		{
+			name:      "does not parse a host starting with a dash as an option",
+			addresses: &client.MachineAddresses{External: []string{"-oProxyCommand=reboot"}},
+			options:   &sshOptions{},
+			want:      []string{"--", "-oProxyCommand=reboot"},
+		},
+		{
+			name:      "fails if a jump host is required but not available",
+			addresses: &client.MachineAddresses{Internal: []string{"10.0.0.1"}},
+			options:   &sshOptions{},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := sshArgs(tt.addresses, tt.options, tt.command)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/topology"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/yamlprocessor"
+	utilyaml "sigs.k8s.io/cluster-api/util/yaml"
+)
+
+type topologyPlanOptions struct {
+	file string
+}
+
+var tpOpts = &topologyPlanOptions{}
+
+var topologyCmd = &cobra.Command{
+	Use:   "topology",
+	Short: "Commands for Cluster managed topologies",
+	Long: LongDesc(`
+		Commands for Cluster managed topologies.`),
+}
+
+var topologyPlanCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Renders the objects generated from Cluster managed topologies",
+	Long: LongDesc(`
+		Renders the objects generated from Cluster managed topologies, without requiring a management cluster.
+
+		The input must contain the Clusters with a managed topology, the ClusterClasses they use and the
+		templates referenced from the ClusterClasses. The output contains, for each Cluster, the Cluster with
+		its infrastructure and control plane references set, the infrastructure cluster, the control plane,
+		and the MachineDeployments with their bootstrap and infrastructure templates, so they can be reviewed,
+		diffed or committed to a git repository.
+
+		Variables in the input are replaced using clusterctl's yaml processor; variable values are either
+		sourced from the clusterctl config file or from environment variables.`),
+
+	Example: Examples(`
+		# Renders the objects generated from the topology of the Clusters defined in a local file.
+		clusterctl alpha topology plan --from ~/workspace/cluster-with-topology.yaml
+
+		# Renders the objects generated from a topology passed in via stdin.
+		cat ~/workspace/cluster-with-topology.yaml | clusterctl alpha topology plan`),
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTopologyPlan(os.Stdin, os.Stdout)
+	},
+}
+
+func init() {
+	topologyPlanCmd.Flags().StringVar(&tpOpts.file, "from", "-",
+		"The file to read the Clusters, ClusterClasses and templates from. It defaults to '-' which reads from stdin.")
+
+	topologyCmd.AddCommand(topologyPlanCmd)
+}
+
+func runTopologyPlan(r io.Reader, w io.Writer) error {
+	if tpOpts.file != "-" {
+		f, err := os.Open(tpOpts.file)
+		if err != nil {
+			return errors.Wrap(err, "failed to read the input file")
+		}
+		defer f.Close()
+		r = f
+	}
+
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return errors.Wrap(err, "failed to read the input")
+	}
+
+	configClient, err := config.New(cfgFile)
+	if err != nil {
+		return err
+	}
+	content, err := yamlprocessor.NewSimpleProcessor().Process(raw, configClient.Variables().Get)
+	if err != nil {
+		return err
+	}
+
+	objs, err := utilyaml.ToUnstructured(content)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the input")
+	}
+
+	rendered, err := topology.Plan(objs)
+	if err != nil {
+		return err
+	}
+	if len(rendered) == 0 {
+		return errors.New("the input does not contain any Cluster with a managed topology")
+	}
+
+	out, err := utilyaml.FromUnstructured(rendered)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(out))
+	return err
+}
@@ -32,6 +32,7 @@ type upgradeApplyOptions struct {
 	bootstrapProviders      []string
 	controlPlaneProviders   []string
 	infrastructureProviders []string
+	skipCompatibilityCheck  bool
 }
 
 var ua = &upgradeApplyOptions{}
@@ -76,6 +77,8 @@ func init() {
 		"Bootstrap providers instance and versions (e.g. capi-kubeadm-bootstrap-system/kubeadm:v0.3.0) to upgrade to. This flag can be used as alternative to --contract.")
 	upgradeApplyCmd.Flags().StringSliceVarP(&ua.controlPlaneProviders, "control-plane", "c", nil,
 		"ControlPlane providers instance and versions (e.g. capi-kubeadm-control-plane-system/kubeadm:v0.3.0) to upgrade to. This flag can be used as alternative to --contract.")
+	upgradeApplyCmd.Flags().BoolVar(&ua.skipCompatibilityCheck, "skip-compatibility-check", false,
+		"Skip the check of the compatibility of the providers with the core provider and the Kubernetes version of the management cluster, as defined in the provider metadata.")
 }
 
 func runUpgradeApply() error {
@@ -101,6 +104,7 @@ func runUpgradeApply() error {
 		BootstrapProviders:      ua.bootstrapProviders,
 		ControlPlaneProviders:   ua.controlPlaneProviders,
 		InfrastructureProviders: ua.infrastructureProviders,
+		SkipCompatibilityCheck:  ua.skipCompatibilityCheck,
 	}); err != nil {
 		return err
 	}
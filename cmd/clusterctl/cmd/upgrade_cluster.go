@@ -0,0 +1,96 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+)
+
+type upgradeClusterOptions struct {
+	kubeconfig        string
+	kubeconfigContext string
+	namespace         string
+	kubernetesVersion string
+	maxUnhealthy      int
+	timeout           time.Duration
+}
+
+var upgradeClusterOpts = &upgradeClusterOptions{}
+
+var upgradeClusterCmd = &cobra.Command{
+	Use:   "upgrade-cluster CLUSTER",
+	Short: "Upgrade the Kubernetes version of a Cluster",
+	Long: LongDesc(`
+		Upgrade the Kubernetes version of a Cluster.
+
+		The control plane is upgraded first; once all the control plane Machines are rolled out, the
+		MachineDeployments of the Cluster are upgraded one at a time, in alphabetical order.
+
+		Before and during each step the Machines failing a MachineHealthCheck or reporting a failure are
+		counted; if they exceed --max-unhealthy, the rollout in progress is paused and the upgrade is aborted.`),
+
+	Example: Examples(`
+		# Upgrade a Cluster to Kubernetes v1.21.1.
+		clusterctl alpha upgrade-cluster my-cluster --kubernetes-version v1.21.1
+
+		# Upgrade a Cluster tolerating up to 2 unhealthy Machines, waiting up to 30 minutes for each rollout.
+		clusterctl alpha upgrade-cluster my-cluster --kubernetes-version v1.21.1 --max-unhealthy 2 --timeout 30m`),
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runUpgradeCluster(args[0])
+	},
+}
+
+func init() {
+	upgradeClusterCmd.Flags().StringVar(&upgradeClusterOpts.kubeconfig, "kubeconfig", "",
+		"Path to the kubeconfig file to use for accessing the management cluster. If unspecified, default discovery rules apply.")
+	upgradeClusterCmd.Flags().StringVar(&upgradeClusterOpts.kubeconfigContext, "kubeconfig-context", "",
+		"Context to be used within the kubeconfig file. If empty, current context will be used.")
+	upgradeClusterCmd.Flags().StringVarP(&upgradeClusterOpts.namespace, "namespace", "n", "",
+		"Namespace where the Cluster exists. If unspecified, the current namespace will be used.")
+	upgradeClusterCmd.Flags().StringVar(&upgradeClusterOpts.kubernetesVersion, "kubernetes-version", "",
+		"The Kubernetes version to upgrade the Cluster to, e.g. v1.21.1.")
+	upgradeClusterCmd.Flags().IntVar(&upgradeClusterOpts.maxUnhealthy, "max-unhealthy", 0,
+		"The maximum number of unhealthy Machines tolerated during each rollout before the upgrade is aborted.")
+	upgradeClusterCmd.Flags().DurationVar(&upgradeClusterOpts.timeout, "timeout", 30*time.Minute,
+		"The time to wait for the rollout of the control plane and of each MachineDeployment.")
+	_ = upgradeClusterCmd.MarkFlagRequired("kubernetes-version")
+
+	completion := completionOptions{kubeconfig: &upgradeClusterOpts.kubeconfig, kubeconfigContext: &upgradeClusterOpts.kubeconfigContext, namespace: &upgradeClusterOpts.namespace}
+	upgradeClusterCmd.ValidArgsFunction = clusterNameCompletionFunc(completion)
+	_ = upgradeClusterCmd.RegisterFlagCompletionFunc("namespace", namespaceCompletionFunc(completion))
+}
+
+func runUpgradeCluster(clusterName string) error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	return c.UpgradeCluster(client.UpgradeClusterOptions{
+		Kubeconfig:        client.Kubeconfig{Path: upgradeClusterOpts.kubeconfig, Context: upgradeClusterOpts.kubeconfigContext},
+		Namespace:         upgradeClusterOpts.namespace,
+		ClusterName:       clusterName,
+		KubernetesVersion: upgradeClusterOpts.kubernetesVersion,
+		MaxUnhealthy:      upgradeClusterOpts.maxUnhealthy,
+		Timeout:           upgradeClusterOpts.timeout,
+	})
+}
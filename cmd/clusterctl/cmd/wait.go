@@ -0,0 +1,120 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+)
+
+type waitOptions struct {
+	kubeconfig        string
+	kubeconfigContext string
+	namespace         string
+	timeout           time.Duration
+	resourceTimeouts  map[string]string
+	skip              []string
+}
+
+var waitOpts = &waitOptions{}
+
+var waitCmd = &cobra.Command{
+	Use:   "wait EXPRESSION",
+	Short: "Waits for conditions on Cluster API objects",
+	Long: LongDesc(`
+		Waits for an expression of conditions on Cluster API objects to be satisfied.
+
+		The expression is composed of terms in the RESOURCE/NAME[=CONDITION] format, combined with
+		the && and || operators, with && taking precedence over ||.
+
+		If the condition is omitted, a default condition is used for the well-known Cluster API resources:
+		Ready for clusters, machinepools, Initialized for kubeadmcontrolplanes, Available for machinedeployments
+		and machinesets, NodeRef for machines and RemediationAllowed for machinehealthchecks.
+
+		Any other resource can be used in the RESOURCE.GROUP format, e.g. dockermachines.infrastructure.cluster.x-k8s.io,
+		providing a condition to be checked.
+
+		Deadlines shorter than the timeout can be set for specific resources; the command fails as soon as the expression
+		can no longer be satisfied because of the terms exceeding their deadline. Terms on skipped resources are
+		considered satisfied.
+
+		On failure, the command reports the unsatisfied terms and why they are not satisfied.`),
+
+	Example: Examples(`
+		# Waits for a Cluster to be ready.
+		clusterctl alpha wait cluster/my-cluster
+
+		# Waits for the control plane to be initialized and for a MachineDeployment to be available.
+		clusterctl alpha wait "kcp/my-cluster-control-plane && md/my-cluster-md-0" --timeout 20m
+
+		# Waits for a custom condition on a Cluster.
+		clusterctl alpha wait "cluster/my-cluster=ControlPlaneReady"
+
+		# Waits up to 20 minutes, but fails if the control plane is not initialized within 10 minutes.
+		clusterctl alpha wait "kcp/my-cluster-control-plane && md/my-cluster-md-0" --timeout 20m --resource-timeout kcp=10m`),
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWait(args[0])
+	},
+}
+
+func init() {
+	waitCmd.Flags().StringVar(&waitOpts.kubeconfig, "kubeconfig", "",
+		"Path to the kubeconfig file to use for accessing the management cluster. If unspecified, default discovery rules apply.")
+	waitCmd.Flags().StringVar(&waitOpts.kubeconfigContext, "kubeconfig-context", "",
+		"Context to be used within the kubeconfig file. If empty, current context will be used.")
+	waitCmd.Flags().StringVarP(&waitOpts.namespace, "namespace", "n", "",
+		"Namespace where the objects exist. If unspecified, the current namespace will be used.")
+	waitCmd.Flags().DurationVar(&waitOpts.timeout, "timeout", 10*time.Minute,
+		"The length of time to wait before giving up.")
+	waitCmd.Flags().StringToStringVar(&waitOpts.resourceTimeouts, "resource-timeout", nil,
+		"Deadlines for specific resources in the RESOURCE=DURATION format, e.g. machine=20m.")
+	waitCmd.Flags().StringSliceVar(&waitOpts.skip, "skip", nil,
+		"Resources whose terms are considered satisfied without checking them, e.g. machinehealthcheck.")
+
+	completion := completionOptions{kubeconfig: &waitOpts.kubeconfig, kubeconfigContext: &waitOpts.kubeconfigContext, namespace: &waitOpts.namespace}
+	_ = waitCmd.RegisterFlagCompletionFunc("namespace", namespaceCompletionFunc(completion))
+}
+
+func runWait(expression string) error {
+	resourceTimeouts := map[string]time.Duration{}
+	for resource, v := range waitOpts.resourceTimeouts {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return errors.Wrapf(err, "invalid timeout %q for resource %q", v, resource)
+		}
+		resourceTimeouts[resource] = d
+	}
+
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	return c.Wait(client.WaitOptions{
+		Kubeconfig:       client.Kubeconfig{Path: waitOpts.kubeconfig, Context: waitOpts.kubeconfigContext},
+		Namespace:        waitOpts.namespace,
+		For:              expression,
+		Timeout:          waitOpts.timeout,
+		ResourceTimeouts: resourceTimeouts,
+		Skip:             waitOpts.skip,
+	})
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+)
+
+type watchOptions struct {
+	kubeconfig        string
+	kubeconfigContext string
+	namespace         string
+	events            int
+	timeout           time.Duration
+}
+
+var watchOpts = &watchOptions{}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch CLUSTER_NAME",
+	Short: "Watches the provisioning status of a workload cluster",
+	Long: LongDesc(`
+		Watches the provisioning status of a workload cluster, printing an aggregated view of the Cluster,
+		its control plane, MachineDeployments and Machines, together with the most recent events, each time it changes.
+
+		The command terminates when the Cluster is ready, and fails if the Cluster or its control plane
+		report a terminal failure or if the Cluster is not ready before the timeout.`),
+
+	Example: Examples(`
+		# Watches the cluster named test-1 until it is ready.
+		clusterctl alpha watch test-1
+
+		# Watches the cluster named test-1 for up to 30 minutes, showing the 10 most recent events.
+		clusterctl alpha watch test-1 --timeout 30m --events 10`),
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWatch(os.Stdout, args[0])
+	},
+}
+
+func init() {
+	watchCmd.Flags().StringVar(&watchOpts.kubeconfig, "kubeconfig", "",
+		"Path to the kubeconfig file to use for accessing the management cluster. If unspecified, default discovery rules apply.")
+	watchCmd.Flags().StringVar(&watchOpts.kubeconfigContext, "kubeconfig-context", "",
+		"Context to be used within the kubeconfig file. If empty, current context will be used.")
+	watchCmd.Flags().StringVarP(&watchOpts.namespace, "namespace", "n", "",
+		"The namespace where the workload cluster is located. If unspecified, the current namespace will be used.")
+	watchCmd.Flags().IntVar(&watchOpts.events, "events", 5,
+		"The number of recent events to show.")
+	watchCmd.Flags().DurationVar(&watchOpts.timeout, "timeout", 30*time.Minute,
+		"The length of time to wait for the cluster to be ready before giving up.")
+
+	completion := completionOptions{kubeconfig: &watchOpts.kubeconfig, kubeconfigContext: &watchOpts.kubeconfigContext, namespace: &watchOpts.namespace}
+	watchCmd.ValidArgsFunction = clusterNameCompletionFunc(completion)
+	_ = watchCmd.RegisterFlagCompletionFunc("namespace", namespaceCompletionFunc(completion))
+}
+
+func runWatch(w io.Writer, name string) error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	// Print the status only when it changes, like kubectl get --watch.
+	var last *client.WatchClusterStatus
+	return c.WatchCluster(client.WatchClusterOptions{
+		Kubeconfig:  client.Kubeconfig{Path: watchOpts.kubeconfig, Context: watchOpts.kubeconfigContext},
+		Namespace:   watchOpts.namespace,
+		ClusterName: name,
+		Events:      watchOpts.events,
+		Timeout:     watchOpts.timeout,
+		OnUpdate: func(status *client.WatchClusterStatus) {
+			if reflect.DeepEqual(status, last) {
+				return
+			}
+			last = status
+			printWatchClusterStatus(w, status, time.Now())
+			fmt.Fprintln(w)
+		},
+	})
+}
+
+// printWatchClusterStatus prints the aggregated status of a cluster; times are printed relative to now.
+func printWatchClusterStatus(w io.Writer, status *client.WatchClusterStatus, now time.Time) {
+	ready := "False"
+	if status.Ready {
+		ready = "True"
+	}
+	fmt.Fprintf(w, "Cluster %s/%s  Phase: %s  Ready: %s\n", status.Namespace, status.Name, status.Phase, ready)
+	if status.Failed() {
+		fmt.Fprintf(w, "Failure: %s\n", status.FailureMessage)
+	}
+
+	if len(status.Conditions) > 0 {
+		tbl := uitable.New()
+		tbl.Separator = "  "
+		tbl.AddRow("CONDITION", "STATUS", "REASON", "MESSAGE")
+		for _, c := range status.Conditions {
+			tbl.AddRow(c.Type, c.Status, c.Reason, c.Message)
+		}
+		fmt.Fprintf(w, "\n%s\n", tbl)
+	}
+
+	if status.ControlPlane != nil || len(status.MachineDeployments) > 0 {
+		tbl := uitable.New()
+		tbl.Separator = "  "
+		tbl.AddRow("KIND", "NAME", "REPLICAS", "READY", "UPDATED")
+		if cp := status.ControlPlane; cp != nil {
+			tbl.AddRow(cp.Kind, cp.Name, cp.Replicas, cp.ReadyReplicas, cp.Updated)
+		}
+		for _, md := range status.MachineDeployments {
+			tbl.AddRow(md.Kind, md.Name, md.Replicas, md.ReadyReplicas, md.Updated)
+		}
+		fmt.Fprintf(w, "\n%s\n", tbl)
+	}
+
+	if len(status.Machines) > 0 {
+		tbl := uitable.New()
+		tbl.Separator = "  "
+		tbl.AddRow("MACHINE", "PHASE", "NODE", "VERSION")
+		for _, m := range status.Machines {
+			tbl.AddRow(m.Name, m.Phase, m.NodeName, m.Version)
+		}
+		fmt.Fprintf(w, "\n%s\n", tbl)
+	}
+
+	if len(status.Events) > 0 {
+		tbl := uitable.New()
+		tbl.Separator = "  "
+		tbl.MaxColWidth = 100
+		tbl.AddRow("AGE", "TYPE", "OBJECT", "REASON", "MESSAGE")
+		for _, e := range status.Events {
+			tbl.AddRow(duration.HumanDuration(now.Sub(e.Time)), e.Type, e.Object, e.Reason, e.Message)
+		}
+		fmt.Fprintf(w, "\n%s\n", tbl)
+	}
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+)
+
+func Test_printWatchClusterStatus(t *testing.T) {
+	g := NewWithT(t)
+
+	now := time.Now()
+	status := &client.WatchClusterStatus{
+		Namespace:      "default",
+		Name:           "my-cluster",
+		Phase:          "Provisioned",
+		FailureMessage: "failed to create the load balancer",
+		Conditions: clusterv1.Conditions{
+			{Type: clusterv1.ReadyCondition, Status: corev1.ConditionFalse, Reason: "WaitingForControlPlane"},
+		},
+		ControlPlane:       &client.WatchReplicasStatus{Kind: "KubeadmControlPlane", Name: "my-cluster-control-plane", Replicas: 3, ReadyReplicas: 1, Updated: 3},
+		MachineDeployments: []client.WatchReplicasStatus{{Kind: "MachineDeployment", Name: "my-cluster-md-0", Replicas: 2}},
+		Machines:           []client.WatchMachineStatus{{Name: "my-cluster-md-0-abcde", Phase: "Provisioning", Version: "v1.20.1"}},
+		Events:             []client.WatchEvent{{Time: now.Add(-time.Minute), Type: "Normal", Object: "Cluster/my-cluster", Reason: "Provisioned", Message: "Cluster provisioned"}},
+	}
+
+	var b bytes.Buffer
+	printWatchClusterStatus(&b, status, now)
+	out := b.String()
+
+	g.Expect(out).To(ContainSubstring("Cluster default/my-cluster  Phase: Provisioned  Ready: False"))
+	g.Expect(out).To(ContainSubstring("Failure: failed to create the load balancer"))
+	g.Expect(out).To(MatchRegexp(`Ready\s+False\s+WaitingForControlPlane`))
+	g.Expect(out).To(MatchRegexp(`KubeadmControlPlane\s+my-cluster-control-plane\s+3\s+1\s+3`))
+	g.Expect(out).To(MatchRegexp(`MachineDeployment\s+my-cluster-md-0\s+2\s+0\s+0`))
+	g.Expect(out).To(MatchRegexp(`my-cluster-md-0-abcde\s+Provisioning\s+v1.20.1`))
+	g.Expect(out).To(MatchRegexp(`60s\s+Normal\s+Cluster/my-cluster\s+Provisioned\s+Cluster provisioned`))
+}
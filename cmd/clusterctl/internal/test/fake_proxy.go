@@ -36,9 +36,11 @@ import (
 )
 
 type FakeProxy struct {
-	cs        client.Client
-	namespace string
-	objs      []client.Object
+	cs            client.Client
+	namespace     string
+	serverVersion string
+	objs          []client.Object
+	recorder      *CallRecorder
 }
 
 var (
@@ -67,6 +69,10 @@ func (f *FakeProxy) ValidateKubernetesVersion() error {
 	return nil
 }
 
+func (f *FakeProxy) GetServerVersion() (string, error) {
+	return f.serverVersion, nil
+}
+
 func (f *FakeProxy) GetConfig() (*rest.Config, error) {
 	return nil, nil
 }
@@ -76,11 +82,19 @@ func (f *FakeProxy) NewClient() (client.Client, error) {
 		return f.cs, nil
 	}
 	f.cs = fake.NewClientBuilder().WithScheme(FakeScheme).WithObjects(f.objs...).Build()
+	if f.recorder != nil {
+		f.cs = &recordingClient{Client: f.cs, recorder: f.recorder}
+	}
 	return f.cs, nil
 }
 
 // ListResources returns all the resources known by the FakeProxy.
 func (f *FakeProxy) ListResources(labels map[string]string, namespaces ...string) ([]unstructured.Unstructured, error) {
+	// NB. The actual proxy lists all the resource types supporting list and delete.
+	if f.recorder != nil {
+		f.recorder.calls = append(f.recorder.calls, Call{Verb: "list", Group: "*", Resource: "*"})
+	}
+
 	var ret []unstructured.Unstructured //nolint
 	for _, o := range f.objs {
 		u := unstructured.Unstructured{}
@@ -124,7 +138,8 @@ func (f *FakeProxy) ListResources(labels map[string]string, namespaces ...string
 
 func NewFakeProxy() *FakeProxy {
 	return &FakeProxy{
-		namespace: "default",
+		namespace:     "default",
+		serverVersion: "v1.19.1",
 	}
 }
 
@@ -133,11 +148,23 @@ func (f *FakeProxy) WithObjs(objs ...client.Object) *FakeProxy {
 	return f
 }
 
+// WithCallRecorder records the calls made using the FakeProxy, so tests can check the RBAC rules they require.
+func (f *FakeProxy) WithCallRecorder(recorder *CallRecorder) *FakeProxy {
+	f.recorder = recorder
+	return f
+}
+
 func (f *FakeProxy) WithNamespace(n string) *FakeProxy {
 	f.namespace = n
 	return f
 }
 
+// WithServerVersion sets the Kubernetes version reported by the fake management cluster.
+func (f *FakeProxy) WithServerVersion(v string) *FakeProxy {
+	f.serverVersion = v
+	return f
+}
+
 // WithProviderInventory can be used as a fast track for setting up test scenarios requiring an already initialized management cluster.
 // NB. this method adds an items to the Provider inventory, but it doesn't install the corresponding provider; if the
 // test case requires the actual provider to be installed, use the the fake client to install both the provider
@@ -0,0 +1,169 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// Call is a call to the API server, described by the RBAC verb, API group and resource it requires.
+type Call struct {
+	Verb     string
+	Group    string
+	Resource string
+}
+
+func (c Call) String() string {
+	return fmt.Sprintf("%s %s/%s", c.Verb, c.Group, c.Resource)
+}
+
+// CallRecorder records the calls to the API server.
+type CallRecorder struct {
+	calls []Call
+}
+
+// Calls returns the calls recorded so far.
+func (r *CallRecorder) Calls() []Call {
+	return r.calls
+}
+
+// Reset forgets the calls recorded so far.
+func (r *CallRecorder) Reset() {
+	r.calls = nil
+}
+
+func (r *CallRecorder) record(verb string, obj runtime.Object, subresource string) {
+	gvk, err := apiutil.GVKForObject(obj, FakeScheme)
+	if err != nil {
+		panic(fmt.Sprintf("failed to get the GroupVersionKind of %T: %v", obj, err))
+	}
+	if meta.IsListType(obj) {
+		gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+	}
+	resource, _ := meta.UnsafeGuessKindToResource(gvk)
+	call := Call{Verb: verb, Group: gvk.Group, Resource: resource.Resource}
+	if subresource != "" {
+		call.Resource += "/" + subresource
+	}
+	r.calls = append(r.calls, call)
+}
+
+// NotAllowed returns the recorded calls not allowed by the rules of the given ClusterRoles and Roles.
+func (r *CallRecorder) NotAllowed(objs []client.Object) []Call {
+	var rules []rbacv1.PolicyRule
+	for _, o := range objs {
+		switch role := o.(type) {
+		case *rbacv1.ClusterRole:
+			rules = append(rules, role.Rules...)
+		case *rbacv1.Role:
+			rules = append(rules, role.Rules...)
+		}
+	}
+
+	var notAllowed []Call
+	for _, call := range r.calls {
+		allowed := false
+		for _, rule := range rules {
+			if matches(rule.Verbs, call.Verb) && matches(rule.APIGroups, call.Group) && matches(rule.Resources, call.Resource) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			notAllowed = append(notAllowed, call)
+		}
+	}
+	return notAllowed
+}
+
+func matches(values []string, value string) bool {
+	for _, v := range values {
+		if v == rbacv1.VerbAll || v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// recordingClient is a client.Client recording the calls to the API server.
+type recordingClient struct {
+	client.Client
+	recorder *CallRecorder
+}
+
+func (c *recordingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	c.recorder.record("get", obj, "")
+	return c.Client.Get(ctx, key, obj)
+}
+
+func (c *recordingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	c.recorder.record("list", list, "")
+	return c.Client.List(ctx, list, opts...)
+}
+
+func (c *recordingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	c.recorder.record("create", obj, "")
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *recordingClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	c.recorder.record("delete", obj, "")
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func (c *recordingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	c.recorder.record("update", obj, "")
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *recordingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	c.recorder.record("patch", obj, "")
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c *recordingClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	c.recorder.record("deletecollection", obj, "")
+	return c.Client.DeleteAllOf(ctx, obj, opts...)
+}
+
+func (c *recordingClient) Status() client.StatusWriter {
+	return &recordingStatusWriter{StatusWriter: c.Client.Status(), recorder: c.recorder}
+}
+
+// recordingStatusWriter is a client.StatusWriter recording the calls to the API server.
+type recordingStatusWriter struct {
+	client.StatusWriter
+	recorder *CallRecorder
+}
+
+func (w *recordingStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	w.recorder.record("update", obj, "status")
+	return w.StatusWriter.Update(ctx, obj, opts...)
+}
+
+func (w *recordingStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	w.recorder.record("patch", obj, "status")
+	return w.StatusWriter.Patch(ctx, obj, patch, opts...)
+}
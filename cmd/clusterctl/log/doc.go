@@ -18,7 +18,7 @@ limitations under the License.
 Package log mirrors the controller runtime approach to logging, by defining a global logger
 that defaults to NullLogger.
 
-You can set a custom logger by calling log.SetLogger.
+You can set a custom logger by calling log.SetLogger; client.InjectLogger sets a custom logger for a single clusterctl client only.
 
 NewLogger returns a clusterctl friendly logr.Logger derived from
 https://git.k8s.io/klog/klogr/klogr.go.
@@ -34,6 +34,8 @@ import (
 	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
+	addonsv1 "sigs.k8s.io/cluster-api/exp/addons/api/v1alpha4"
 	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/feature"
 	"sigs.k8s.io/cluster-api/util"
@@ -61,6 +63,8 @@ const (
 // +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io;bootstrap.cluster.x-k8s.io;controlplane.cluster.x-k8s.io,resources=*,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinehealthchecks,verbs=get;list;watch;delete
+// +kubebuilder:rbac:groups=addons.cluster.x-k8s.io,resources=clusterresourcesetbindings,verbs=get;list;watch;delete
 // +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch
 
 // ClusterReconciler reconciles a Cluster object.
@@ -68,31 +72,60 @@ type ClusterReconciler struct {
 	Client           client.Client
 	WatchFilterValue string
 
+	// OrphanedInfrastructurePolicy defines how infrastructure machines not owned by any Machine are handled.
+	// If empty, ReportOrphanedInfrastructurePolicy is used.
+	OrphanedInfrastructurePolicy OrphanedInfrastructurePolicy
+
 	restConfig      *rest.Config
 	recorder        record.EventRecorder
 	externalTracker external.ObjectTracker
+
+	// unstructuredCachingClient reads unstructured objects, e.g. infrastructure machines, from the manager cache;
+	// the Client reads them directly from the API server instead.
+	unstructuredCachingClient client.Client
 }
 
 func (r *ClusterReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	switch r.OrphanedInfrastructurePolicy {
+	case "", ReportOrphanedInfrastructurePolicy, AdoptOrphanedInfrastructurePolicy, DeleteOrphanedInfrastructurePolicy:
+	default:
+		return errors.Errorf("invalid orphaned infrastructure policy %q, must be one of %s, %s or %s", r.OrphanedInfrastructurePolicy,
+			ReportOrphanedInfrastructurePolicy, AdoptOrphanedInfrastructurePolicy, DeleteOrphanedInfrastructurePolicy)
+	}
+
 	controller, err := ctrl.NewControllerManagedBy(mgr).
 		For(&clusterv1.Cluster{}).
 		Watches(
 			&source.Kind{Type: &clusterv1.Machine{}},
 			handler.EnqueueRequestsFromMapFunc(r.controlPlaneMachineToCluster),
 		).
+		Watches(
+			&source.Kind{Type: &clusterv1.MachineDeployment{}},
+			handler.EnqueueRequestsFromMapFunc(r.machineDeploymentToCluster),
+		).
 		WithOptions(options).
 		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
-		Build(r)
+		Build(metrics.NewInstrumentedReconciler("cluster", r))
 
 	if err != nil {
 		return errors.Wrap(err, "failed setting up with a controller manager")
 	}
 
+	unstructuredCachingClient, err := client.NewDelegatingClient(client.NewDelegatingClientInput{
+		CacheReader:       mgr.GetCache(),
+		Client:            r.Client,
+		CacheUnstructured: true,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to create the client for reading unstructured objects from the cache")
+	}
+
 	r.recorder = mgr.GetEventRecorderFor("cluster-controller")
 	r.restConfig = mgr.GetConfig()
 	r.externalTracker = external.ObjectTracker{
 		Controller: controller,
 	}
+	r.unstructuredCachingClient = unstructuredCachingClient
 	return nil
 }
 
@@ -171,6 +204,9 @@ func patchCluster(ctx context.Context, patchHelper *patch.Helper, cluster *clust
 			clusterv1.ReadyCondition,
 			clusterv1.ControlPlaneReadyCondition,
 			clusterv1.InfrastructureReadyCondition,
+			clusterv1.DescendantsDeletedCondition,
+			clusterv1.WorkersReadyCondition,
+			clusterv1.InfrastructureMachinesOwnedCondition,
 		}},
 	)
 	return patchHelper.Patch(ctx, cluster, options...)
@@ -183,6 +219,8 @@ func (r *ClusterReconciler) reconcile(ctx context.Context, cluster *clusterv1.Cl
 		r.reconcileControlPlane,
 		r.reconcileKubeconfig,
 		r.reconcileControlPlaneInitialized,
+		r.reconcileWorkers,
+		r.reconcileOrphanedInfrastructure,
 	}
 
 	res := ctrl.Result{}
@@ -217,38 +255,36 @@ func (r *ClusterReconciler) reconcileDelete(ctx context.Context, cluster *cluste
 		return reconcile.Result{}, err
 	}
 
+	// Delete the MachineHealthChecks targeting the Cluster together with the owned descendants, so Machines
+	// being deleted do not get remediated.
+	children = append(descendants.machineHealthCheckObjects(), children...)
 	if len(children) > 0 {
 		log.Info("Cluster still has children - deleting them first", "count", len(children))
-
-		var errs []error
-
-		for _, child := range children {
-			if !child.GetDeletionTimestamp().IsZero() {
-				// Don't handle deleted child
-				continue
-			}
-			gvk := child.GetObjectKind().GroupVersionKind().String()
-
-			log.Info("Deleting child object", "gvk", gvk, "name", child.GetName())
-			if err := r.Client.Delete(ctx, child); err != nil {
-				err = errors.Wrapf(err, "error deleting cluster %s/%s: failed to delete %s %s", cluster.Namespace, cluster.Name, gvk, child.GetName())
-				log.Error(err, "Error deleting resource", "gvk", gvk, "name", child.GetName())
-				errs = append(errs, err)
-			}
-		}
-
-		if len(errs) > 0 {
-			return ctrl.Result{}, kerrors.NewAggregate(errs)
+		if err := r.deleteChildren(ctx, cluster, children); err != nil {
+			return ctrl.Result{}, err
 		}
 	}
 
 	if descendantCount := descendants.length(); descendantCount > 0 {
 		indirect := descendantCount - len(children)
 		log.Info("Cluster still has descendants - need to requeue", "descendants", descendants.descendantNames(), "indirect descendants count", indirect)
+		conditions.MarkFalse(cluster, clusterv1.DescendantsDeletedCondition, clusterv1.WaitingForDescendantsDeletionReason, clusterv1.ConditionSeverityInfo, descendants.descendantNames())
 		// Requeue so we can check the next time to see if there are still any descendants left.
 		return ctrl.Result{RequeueAfter: deleteRequeueAfter}, nil
 	}
 
+	// ClusterResourceSetBindings are deleted only after all the Machines are gone, so the resources applied
+	// to the workload cluster are tracked until the very end.
+	if bindings := descendants.clusterResourceSetBindingObjects(); len(bindings) > 0 {
+		log.Info("Cluster still has ClusterResourceSetBindings - deleting them", "count", len(bindings))
+		if err := r.deleteChildren(ctx, cluster, bindings); err != nil {
+			return ctrl.Result{}, err
+		}
+		conditions.MarkFalse(cluster, clusterv1.DescendantsDeletedCondition, clusterv1.WaitingForDescendantsDeletionReason, clusterv1.ConditionSeverityInfo, descendants.descendantNames())
+		return ctrl.Result{RequeueAfter: deleteRequeueAfter}, nil
+	}
+	conditions.MarkTrue(cluster, clusterv1.DescendantsDeletedCondition)
+
 	if cluster.Spec.ControlPlaneRef != nil {
 		obj, err := external.Get(ctx, r.Client, cluster.Spec.ControlPlaneRef, cluster.Namespace)
 		switch {
@@ -312,24 +348,51 @@ func (r *ClusterReconciler) reconcileDelete(ctx context.Context, cluster *cluste
 	}
 
 	controllerutil.RemoveFinalizer(cluster, clusterv1.ClusterFinalizer)
+	metrics.DeleteOrphanedInfrastructureMachines(cluster.Namespace, cluster.Name)
 	return ctrl.Result{}, nil
 }
 
+// deleteChildren issues a deletion request for all the given objects, skipping the ones already being deleted.
+func (r *ClusterReconciler) deleteChildren(ctx context.Context, cluster *clusterv1.Cluster, children []client.Object) error {
+	log := ctrl.LoggerFrom(ctx)
+
+	var errs []error
+	for _, child := range children {
+		if !child.GetDeletionTimestamp().IsZero() {
+			// Don't handle deleted child
+			continue
+		}
+		gvk := child.GetObjectKind().GroupVersionKind().String()
+
+		log.Info("Deleting child object", "gvk", gvk, "name", child.GetName())
+		if err := r.Client.Delete(ctx, child); err != nil && !apierrors.IsNotFound(err) {
+			err = errors.Wrapf(err, "error deleting cluster %s/%s: failed to delete %s %s", cluster.Namespace, cluster.Name, gvk, child.GetName())
+			log.Error(err, "Error deleting resource", "gvk", gvk, "name", child.GetName())
+			errs = append(errs, err)
+		}
+	}
+
+	return kerrors.NewAggregate(errs)
+}
+
 type clusterDescendants struct {
-	machineDeployments   clusterv1.MachineDeploymentList
-	machineSets          clusterv1.MachineSetList
-	controlPlaneMachines clusterv1.MachineList
-	workerMachines       clusterv1.MachineList
-	machinePools         expv1.MachinePoolList
+	machineDeployments         clusterv1.MachineDeploymentList
+	machineSets                clusterv1.MachineSetList
+	controlPlaneMachines       clusterv1.MachineList
+	workerMachines             clusterv1.MachineList
+	machinePools               expv1.MachinePoolList
+	machineHealthChecks        clusterv1.MachineHealthCheckList
+	clusterResourceSetBindings addonsv1.ClusterResourceSetBindingList
 }
 
-// length returns the number of descendants.
+// length returns the number of descendants, excluding ClusterResourceSetBindings which are deleted last.
 func (c *clusterDescendants) length() int {
 	return len(c.machineDeployments.Items) +
 		len(c.machineSets.Items) +
 		len(c.controlPlaneMachines.Items) +
 		len(c.workerMachines.Items) +
-		len(c.machinePools.Items)
+		len(c.machinePools.Items) +
+		len(c.machineHealthChecks.Items)
 }
 
 func (c *clusterDescendants) descendantNames() string {
@@ -371,17 +434,49 @@ func (c *clusterDescendants) descendantNames() string {
 			descendants = append(descendants, "Machine pools: "+strings.Join(machinePoolNames, ","))
 		}
 	}
+	machineHealthCheckNames := make([]string, len(c.machineHealthChecks.Items))
+	for i, machineHealthCheck := range c.machineHealthChecks.Items {
+		machineHealthCheckNames[i] = machineHealthCheck.Name
+	}
+	if len(machineHealthCheckNames) > 0 {
+		descendants = append(descendants, "Machine health checks: "+strings.Join(machineHealthCheckNames, ","))
+	}
+	if feature.Gates.Enabled(feature.ClusterResourceSet) {
+		clusterResourceSetBindingNames := make([]string, len(c.clusterResourceSetBindings.Items))
+		for i, clusterResourceSetBinding := range c.clusterResourceSetBindings.Items {
+			clusterResourceSetBindingNames[i] = clusterResourceSetBinding.Name
+		}
+		if len(clusterResourceSetBindingNames) > 0 {
+			descendants = append(descendants, "Cluster resource set bindings: "+strings.Join(clusterResourceSetBindingNames, ","))
+		}
+	}
 	return strings.Join(descendants, ";")
 }
 
-// listDescendants returns a list of all MachineDeployments, MachineSets, MachinePools and Machines for the cluster.
+// machineHealthCheckObjects returns the MachineHealthChecks targeting the cluster.
+func (c *clusterDescendants) machineHealthCheckObjects() []client.Object {
+	objs := make([]client.Object, 0, len(c.machineHealthChecks.Items))
+	for i := range c.machineHealthChecks.Items {
+		objs = append(objs, &c.machineHealthChecks.Items[i])
+	}
+	return objs
+}
+
+// clusterResourceSetBindingObjects returns the ClusterResourceSetBindings owned by the cluster.
+func (c *clusterDescendants) clusterResourceSetBindingObjects() []client.Object {
+	objs := make([]client.Object, 0, len(c.clusterResourceSetBindings.Items))
+	for i := range c.clusterResourceSetBindings.Items {
+		objs = append(objs, &c.clusterResourceSetBindings.Items[i])
+	}
+	return objs
+}
+
+// listDescendants returns a list of all MachineDeployments, MachineSets, MachinePools, Machines, MachineHealthChecks
+// and ClusterResourceSetBindings for the cluster.
 func (r *ClusterReconciler) listDescendants(ctx context.Context, cluster *clusterv1.Cluster) (clusterDescendants, error) {
 	var descendants clusterDescendants
 
-	listOptions := []client.ListOption{
-		client.InNamespace(cluster.Namespace),
-		client.MatchingLabels(map[string]string{clusterv1.ClusterLabelName: cluster.Name}),
-	}
+	listOptions := byClusterName(cluster)
 
 	if err := r.Client.List(ctx, &descendants.machineDeployments, listOptions...); err != nil {
 		return descendants, errors.Wrapf(err, "failed to list MachineDeployments for cluster %s/%s", cluster.Namespace, cluster.Name)
@@ -392,7 +487,8 @@ func (r *ClusterReconciler) listDescendants(ctx context.Context, cluster *cluste
 	}
 
 	if feature.Gates.Enabled(feature.MachinePool) {
-		if err := r.Client.List(ctx, &descendants.machinePools, listOptions...); err != nil {
+		// MachinePools are not indexed, given that the MachinePool CRD is installed only if the feature is enabled.
+		if err := r.Client.List(ctx, &descendants.machinePools, client.InNamespace(cluster.Namespace), client.MatchingLabels{clusterv1.ClusterLabelName: cluster.Name}); err != nil {
 			return descendants, errors.Wrapf(err, "failed to list MachinePools for the cluster %s/%s", cluster.Namespace, cluster.Name)
 		}
 	}
@@ -411,9 +507,42 @@ func (r *ClusterReconciler) listDescendants(ctx context.Context, cluster *cluste
 		descendants.controlPlaneMachines = collections.ToMachineList(controlPlaneMachines)
 	}
 
+	// MachineHealthChecks reference the cluster by name, and they are usually not owned by it.
+	var machineHealthChecks clusterv1.MachineHealthCheckList
+	if err := r.Client.List(ctx, &machineHealthChecks, client.InNamespace(cluster.Namespace)); err != nil {
+		return descendants, errors.Wrapf(err, "failed to list MachineHealthChecks for cluster %s/%s", cluster.Namespace, cluster.Name)
+	}
+	for i := range machineHealthChecks.Items {
+		if machineHealthChecks.Items[i].Spec.ClusterName == cluster.Name {
+			descendants.machineHealthChecks.Items = append(descendants.machineHealthChecks.Items, machineHealthChecks.Items[i])
+		}
+	}
+
+	if feature.Gates.Enabled(feature.ClusterResourceSet) {
+		var bindings addonsv1.ClusterResourceSetBindingList
+		if err := r.Client.List(ctx, &bindings, client.InNamespace(cluster.Namespace)); err != nil {
+			return descendants, errors.Wrapf(err, "failed to list ClusterResourceSetBindings for cluster %s/%s", cluster.Namespace, cluster.Name)
+		}
+		for i := range bindings.Items {
+			if util.IsOwnedByObject(&bindings.Items[i], cluster) {
+				descendants.clusterResourceSetBindings.Items = append(descendants.clusterResourceSetBindings.Items, bindings.Items[i])
+			}
+		}
+	}
+
 	return descendants, nil
 }
 
+// byClusterName returns the options for listing the MachineDeployments, MachineSets or Machines of a Cluster using the
+// cluster name index, so they are retrieved from the cache without scanning all the objects in the namespace.
+// NOTE: the index is added to the manager by util.AddClusterNameIndex.
+func byClusterName(cluster *clusterv1.Cluster) []client.ListOption {
+	return []client.ListOption{
+		client.InNamespace(cluster.Namespace),
+		client.MatchingFields{clusterv1.ClusterNameIndex: cluster.Name},
+	}
+}
+
 // filterOwnedDescendants returns an array of runtime.Objects containing only those descendants that have the cluster
 // as an owner reference, with control plane machines sorted last.
 func (c clusterDescendants) filterOwnedDescendants(cluster *clusterv1.Cluster) ([]client.Object, error) {
@@ -486,6 +615,44 @@ func (r *ClusterReconciler) reconcileControlPlaneInitialized(ctx context.Context
 	return ctrl.Result{}, nil
 }
 
+// reconcileWorkers aggregates the Ready condition of the MachineDeployments of the Cluster into the WorkersReady condition.
+func (r *ClusterReconciler) reconcileWorkers(ctx context.Context, cluster *clusterv1.Cluster) (ctrl.Result, error) {
+	machineDeployments := &clusterv1.MachineDeploymentList{}
+	if err := r.Client.List(ctx, machineDeployments, byClusterName(cluster)...); err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to list MachineDeployments for Cluster %s/%s", cluster.Namespace, cluster.Name)
+	}
+
+	getters := make([]conditions.Getter, 0, len(machineDeployments.Items))
+	for i := range machineDeployments.Items {
+		if !machineDeployments.Items[i].DeletionTimestamp.IsZero() {
+			continue
+		}
+		getters = append(getters, &machineDeployments.Items[i])
+	}
+	if len(getters) == 0 {
+		conditions.Delete(cluster, clusterv1.WorkersReadyCondition)
+		return ctrl.Result{}, nil
+	}
+
+	// While aggregating we are adding the source ref (reason@machinedeployment/name) so the problem can be easily
+	// tracked down to its source MachineDeployment.
+	conditions.SetAggregate(cluster, clusterv1.WorkersReadyCondition, getters, conditions.AddSourceRef(), conditions.WithStepCounterIf(false))
+	return ctrl.Result{}, nil
+}
+
+// machineDeploymentToCluster is a handler.ToRequestsFunc to be used to enqueue requests for reconciliation
+// for Cluster to update its WorkersReady condition.
+func (r *ClusterReconciler) machineDeploymentToCluster(o client.Object) []ctrl.Request {
+	md, ok := o.(*clusterv1.MachineDeployment)
+	if !ok {
+		panic(fmt.Sprintf("Expected a MachineDeployment but got a %T", o))
+	}
+
+	return []ctrl.Request{{
+		NamespacedName: client.ObjectKey{Namespace: md.Namespace, Name: md.Spec.ClusterName},
+	}}
+}
+
 // controlPlaneMachineToCluster is a handler.ToRequestsFunc to be used to enqueue requests for reconciliation
 // for Cluster to update its status.controlPlaneInitialized field.
 func (r *ClusterReconciler) controlPlaneMachineToCluster(o client.Object) []ctrl.Request {
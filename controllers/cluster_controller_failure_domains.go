@@ -0,0 +1,203 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// reconcileFailureDomains copies the failure domains reported by the infrastructure provider in status.failureDomains
+// into Cluster.Status.FailureDomains, after normalizing and validating them; if the failure domains changed, an event
+// is recorded, and the consumers watching the Cluster, e.g. the control plane, are reconciled so the new failure domains
+// are used for the Machines they create or delete from then on; existing Machines are not moved.
+// If the infrastructure provider does not report failure domains, Cluster.Status.FailureDomains is left untouched.
+func (r *ClusterReconciler) reconcileFailureDomains(ctx context.Context, cluster *clusterv1.Cluster, infraConfig *unstructured.Unstructured) error {
+	log := ctrl.LoggerFrom(ctx)
+
+	value, ok, err := unstructured.NestedFieldNoCopy(infraConfig.Object, "status", "failureDomains")
+	if err != nil || !ok {
+		return nil
+	}
+
+	failureDomains, err := normalizeFailureDomains(value, field.NewPath("status", "failureDomains"))
+	if err != nil {
+		r.recorder.Eventf(cluster, corev1.EventTypeWarning, "InvalidFailureDomains", "Invalid failure domains reported by %s %s: %v",
+			infraConfig.GetKind(), infraConfig.GetName(), err)
+		return errors.Wrapf(err, "failed to retrieve Status.FailureDomains from infrastructure provider for Cluster %q in namespace %q",
+			cluster.Name, cluster.Namespace)
+	}
+
+	if failureDomainsEqual(cluster.Status.FailureDomains, failureDomains) {
+		return nil
+	}
+
+	added, removed, changed := diffFailureDomains(cluster.Status.FailureDomains, failureDomains)
+	log.Info("Failure domains changed", "added", added, "removed", removed, "changed", changed)
+	r.recorder.Eventf(cluster, corev1.EventTypeNormal, "FailureDomainsChanged", "Failure domains changed: added %v, removed %v, changed %v",
+		added, removed, changed)
+	if len(failureDomains) > 0 && len(failureDomains.FilterControlPlane()) == 0 {
+		r.recorder.Eventf(cluster, corev1.EventTypeWarning, "NoControlPlaneFailureDomains",
+			"None of the %d failure domains reported by %s %s is suitable for control plane machines", len(failureDomains), infraConfig.GetKind(), infraConfig.GetName())
+	}
+
+	cluster.Status.FailureDomains = failureDomains
+	return nil
+}
+
+// normalizeFailureDomains converts the failure domains reported by an infrastructure provider into FailureDomains.
+// Both the map form defined by the contract, i.e. a map from the failure domain name to its spec, and the list form used
+// by some providers, i.e. a list of specs with a name field, are supported. The controlPlane flag must be a boolean, or a
+// string representation of it, and attributes with non-string values are converted to strings.
+func normalizeFailureDomains(value interface{}, fldPath *field.Path) (clusterv1.FailureDomains, error) {
+	failureDomains := clusterv1.FailureDomains{}
+	var allErrs field.ErrorList
+
+	switch v := value.(type) {
+	case nil:
+		return failureDomains, nil
+	case map[string]interface{}:
+		for name, spec := range v {
+			if name == "" {
+				allErrs = append(allErrs, field.Invalid(fldPath, name, "failure domain names must not be empty"))
+				continue
+			}
+			failureDomain, errs := normalizeFailureDomainSpec(spec, fldPath.Key(name))
+			allErrs = append(allErrs, errs...)
+			failureDomains[name] = failureDomain
+		}
+	case []interface{}:
+		for i, spec := range v {
+			specMap, ok := spec.(map[string]interface{})
+			if !ok {
+				allErrs = append(allErrs, field.Invalid(fldPath.Index(i), spec, "must be an object"))
+				continue
+			}
+			name, _ := specMap["name"].(string)
+			if name == "" {
+				allErrs = append(allErrs, field.Required(fldPath.Index(i).Child("name"), "failure domain names must not be empty"))
+				continue
+			}
+			if _, ok := failureDomains[name]; ok {
+				allErrs = append(allErrs, field.Duplicate(fldPath.Index(i).Child("name"), name))
+				continue
+			}
+			failureDomain, errs := normalizeFailureDomainSpec(spec, fldPath.Index(i))
+			allErrs = append(allErrs, errs...)
+			failureDomains[name] = failureDomain
+		}
+	default:
+		allErrs = append(allErrs, field.Invalid(fldPath, value, "must be a map or a list of failure domains"))
+	}
+
+	if len(allErrs) > 0 {
+		return nil, allErrs.ToAggregate()
+	}
+	return failureDomains, nil
+}
+
+// normalizeFailureDomainSpec converts the spec of a failure domain reported by an infrastructure provider into a FailureDomainSpec.
+func normalizeFailureDomainSpec(value interface{}, fldPath *field.Path) (clusterv1.FailureDomainSpec, field.ErrorList) {
+	failureDomain := clusterv1.FailureDomainSpec{}
+	var allErrs field.ErrorList
+
+	spec, ok := value.(map[string]interface{})
+	if !ok {
+		if value != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath, value, "must be an object"))
+		}
+		return failureDomain, allErrs
+	}
+
+	switch controlPlane := spec["controlPlane"].(type) {
+	case nil:
+	case bool:
+		failureDomain.ControlPlane = controlPlane
+	case string:
+		b, err := strconv.ParseBool(controlPlane)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("controlPlane"), controlPlane, "must be a boolean"))
+			break
+		}
+		failureDomain.ControlPlane = b
+	default:
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("controlPlane"), controlPlane, "must be a boolean"))
+	}
+
+	switch attributes := spec["attributes"].(type) {
+	case nil:
+	case map[string]interface{}:
+		if len(attributes) > 0 {
+			failureDomain.Attributes = make(map[string]string, len(attributes))
+		}
+		for k, v := range attributes {
+			switch v := v.(type) {
+			case string:
+				failureDomain.Attributes[k] = v
+			case bool, int64, float64:
+				failureDomain.Attributes[k] = fmt.Sprint(v)
+			default:
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("attributes").Key(k), v, "must be a string"))
+			}
+		}
+	default:
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("attributes"), attributes, "must be a map of strings"))
+	}
+
+	return failureDomain, allErrs
+}
+
+// failureDomainsEqual returns true if two sets of failure domains are equal, considering nil and empty as equal.
+func failureDomainsEqual(a, b clusterv1.FailureDomains) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return apiequality.Semantic.DeepEqual(a, b)
+}
+
+// diffFailureDomains returns the sorted names of the failure domains added, removed and changed between two sets of failure domains.
+func diffFailureDomains(before, after clusterv1.FailureDomains) (added, removed, changed []string) {
+	added, removed, changed = []string{}, []string{}, []string{}
+	for name, spec := range after {
+		oldSpec, ok := before[name]
+		switch {
+		case !ok:
+			added = append(added, name)
+		case !apiequality.Semantic.DeepEqual(oldSpec, spec):
+			changed = append(changed, name)
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+	return added, removed, changed
+}
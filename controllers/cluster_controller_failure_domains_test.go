@@ -0,0 +1,220 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+func TestNormalizeFailureDomains(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    clusterv1.FailureDomains
+		wantErr string
+	}{
+		{
+			name: "map form",
+			value: map[string]interface{}{
+				"us-east-1a": map[string]interface{}{"controlPlane": true, "attributes": map[string]interface{}{"zone": "a"}},
+				"us-east-1b": map[string]interface{}{},
+			},
+			want: clusterv1.FailureDomains{
+				"us-east-1a": {ControlPlane: true, Attributes: map[string]string{"zone": "a"}},
+				"us-east-1b": {},
+			},
+		},
+		{
+			name: "list form",
+			value: []interface{}{
+				map[string]interface{}{"name": "us-east-1a", "controlPlane": true},
+				map[string]interface{}{"name": "us-east-1b", "controlPlane": false},
+			},
+			want: clusterv1.FailureDomains{
+				"us-east-1a": {ControlPlane: true},
+				"us-east-1b": {ControlPlane: false},
+			},
+		},
+		{
+			name: "controlPlane as a string and attributes with non-string values are converted",
+			value: map[string]interface{}{
+				"fd1": map[string]interface{}{"controlPlane": "true", "attributes": map[string]interface{}{"weight": int64(3), "ssd": true}},
+			},
+			want: clusterv1.FailureDomains{
+				"fd1": {ControlPlane: true, Attributes: map[string]string{"weight": "3", "ssd": "true"}},
+			},
+		},
+		{
+			name:  "no failure domains",
+			value: map[string]interface{}{},
+			want:  clusterv1.FailureDomains{},
+		},
+		{
+			name: "invalid controlPlane",
+			value: map[string]interface{}{
+				"fd1": map[string]interface{}{"controlPlane": "maybe"},
+			},
+			wantErr: "status.failureDomains[fd1].controlPlane",
+		},
+		{
+			name: "invalid attributes",
+			value: map[string]interface{}{
+				"fd1": map[string]interface{}{"attributes": map[string]interface{}{"zones": []interface{}{"a"}}},
+			},
+			wantErr: "status.failureDomains[fd1].attributes[zones]",
+		},
+		{
+			name: "list form without a name",
+			value: []interface{}{
+				map[string]interface{}{"controlPlane": true},
+			},
+			wantErr: "status.failureDomains[0].name",
+		},
+		{
+			name: "list form with duplicate names",
+			value: []interface{}{
+				map[string]interface{}{"name": "fd1"},
+				map[string]interface{}{"name": "fd1"},
+			},
+			wantErr: "status.failureDomains[1].name",
+		},
+		{
+			name:    "neither a map nor a list",
+			value:   "fd1",
+			wantErr: "status.failureDomains",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := normalizeFailureDomains(tt.value, field.NewPath("status", "failureDomains"))
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestReconcileFailureDomains(t *testing.T) {
+	infraConfig := func(failureDomains interface{}) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"kind":       "GenericInfrastructureCluster",
+			"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha4",
+			"metadata": map[string]interface{}{
+				"name":      "test",
+				"namespace": "test-namespace",
+			},
+		}}
+		if failureDomains != nil {
+			obj.Object["status"] = map[string]interface{}{"failureDomains": failureDomains}
+		}
+		return obj
+	}
+
+	tests := []struct {
+		name       string
+		current    clusterv1.FailureDomains
+		infra      *unstructured.Unstructured
+		want       clusterv1.FailureDomains
+		wantErr    bool
+		wantEvents []string
+	}{
+		{
+			name:    "failure domains not reported are left untouched",
+			current: clusterv1.FailureDomains{"fd1": {ControlPlane: true}},
+			infra:   infraConfig(nil),
+			want:    clusterv1.FailureDomains{"fd1": {ControlPlane: true}},
+		},
+		{
+			name:    "unchanged failure domains do not record events",
+			current: clusterv1.FailureDomains{"fd1": {ControlPlane: true}},
+			infra:   infraConfig(map[string]interface{}{"fd1": map[string]interface{}{"controlPlane": true}}),
+			want:    clusterv1.FailureDomains{"fd1": {ControlPlane: true}},
+		},
+		{
+			name:    "changed failure domains are copied and reported",
+			current: clusterv1.FailureDomains{"fd1": {ControlPlane: true}, "fd2": {ControlPlane: true}},
+			infra: infraConfig(map[string]interface{}{
+				"fd1": map[string]interface{}{"controlPlane": false},
+				"fd3": map[string]interface{}{"controlPlane": true},
+			}),
+			want:       clusterv1.FailureDomains{"fd1": {ControlPlane: false}, "fd3": {ControlPlane: true}},
+			wantEvents: []string{"Normal FailureDomainsChanged Failure domains changed: added [fd3], removed [fd2], changed [fd1]"},
+		},
+		{
+			name:    "failure domains not suitable for the control plane are reported",
+			current: nil,
+			infra:   infraConfig(map[string]interface{}{"fd1": map[string]interface{}{}}),
+			want:    clusterv1.FailureDomains{"fd1": {}},
+			wantEvents: []string{
+				"Normal FailureDomainsChanged Failure domains changed: added [fd1], removed [], changed []",
+				"Warning NoControlPlaneFailureDomains None of the 1 failure domains reported by GenericInfrastructureCluster test is suitable for control plane machines",
+			},
+		},
+		{
+			name:       "invalid failure domains are not copied",
+			current:    clusterv1.FailureDomains{"fd1": {ControlPlane: true}},
+			infra:      infraConfig(map[string]interface{}{"fd1": map[string]interface{}{"controlPlane": "maybe"}}),
+			want:       clusterv1.FailureDomains{"fd1": {ControlPlane: true}},
+			wantErr:    true,
+			wantEvents: []string{"Warning InvalidFailureDomains"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			cluster := &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "test-namespace"},
+				Status:     clusterv1.ClusterStatus{FailureDomains: tt.current},
+			}
+			recorder := record.NewFakeRecorder(32)
+			r := &ClusterReconciler{recorder: recorder}
+
+			err := r.reconcileFailureDomains(ctx, cluster, tt.infra)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			g.Expect(cluster.Status.FailureDomains).To(Equal(tt.want))
+
+			close(recorder.Events)
+			events := []string{}
+			for e := range recorder.Events {
+				events = append(events, e)
+			}
+			g.Expect(events).To(HaveLen(len(tt.wantEvents)))
+			for i := range tt.wantEvents {
+				g.Expect(events[i]).To(HavePrefix(tt.wantEvents[i]))
+			}
+		})
+	}
+}
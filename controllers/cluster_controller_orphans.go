@@ -0,0 +1,201 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// OrphanedInfrastructurePolicy defines how the Cluster controller handles infrastructure machines not owned by any Machine.
+type OrphanedInfrastructurePolicy string
+
+const (
+	// ReportOrphanedInfrastructurePolicy only reports orphaned infrastructure machines, using the
+	// InfrastructureMachinesOwned condition of the Cluster and the capi_orphaned_infrastructure_machines metric.
+	ReportOrphanedInfrastructurePolicy = OrphanedInfrastructurePolicy("Report")
+
+	// AdoptOrphanedInfrastructurePolicy sets the Machine referencing an orphaned infrastructure machine, if any, as its
+	// controller; the other orphaned infrastructure machines are reported.
+	AdoptOrphanedInfrastructurePolicy = OrphanedInfrastructurePolicy("Adopt")
+
+	// DeleteOrphanedInfrastructurePolicy adopts the orphaned infrastructure machines referenced by a Machine, like
+	// AdoptOrphanedInfrastructurePolicy, and deletes the ones not referenced by any Machine.
+	DeleteOrphanedInfrastructurePolicy = OrphanedInfrastructurePolicy("Delete")
+)
+
+// orphanedInfrastructureGracePeriod is how long an infrastructure machine can exist without an owner Machine before
+// being considered orphaned, so infrastructure machines being created, e.g. by a MachineSet before the Machine, are ignored.
+const orphanedInfrastructureGracePeriod = 10 * time.Minute
+
+// reconcileOrphanedInfrastructure detects the infrastructure machines of the Cluster not owned by any Machine, e.g.
+// left behind by a failed move, reports them in the InfrastructureMachinesOwned condition and applies the
+// OrphanedInfrastructurePolicy. Infrastructure machines are discovered by the cluster name label, for the kinds
+// referenced by the Machines and the MachineSets of the Cluster; all the objects are read from the cache.
+func (r *ClusterReconciler) reconcileOrphanedInfrastructure(ctx context.Context, cluster *clusterv1.Cluster) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	machines := &clusterv1.MachineList{}
+	if err := r.Client.List(ctx, machines, byClusterName(cluster)...); err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to list Machines for Cluster %s/%s", cluster.Namespace, cluster.Name)
+	}
+	machineSets := &clusterv1.MachineSetList{}
+	if err := r.Client.List(ctx, machineSets, byClusterName(cluster)...); err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to list MachineSets for Cluster %s/%s", cluster.Namespace, cluster.Name)
+	}
+
+	gvks := map[schema.GroupVersionKind]bool{}
+	machineUIDs := map[types.UID]bool{}
+	referencingMachines := map[string]*clusterv1.Machine{}
+	for i := range machines.Items {
+		m := &machines.Items[i]
+		ref := m.Spec.InfrastructureRef
+		gvks[ref.GroupVersionKind()] = true
+		machineUIDs[m.UID] = true
+		referencingMachines[infrastructureMachineKey(ref.GroupVersionKind().GroupKind(), ref.Name)] = m
+	}
+	for i := range machineSets.Items {
+		ref := machineSets.Items[i].Spec.Template.Spec.InfrastructureRef
+		gvk := ref.GroupVersionKind()
+		gvk.Kind = strings.TrimSuffix(gvk.Kind, external.TemplateSuffix)
+		gvks[gvk] = true
+	}
+
+	res := ctrl.Result{}
+	total := 0
+	var orphans []string
+	for gvk := range gvks {
+		infraMachines := &unstructured.UnstructuredList{}
+		infraMachines.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err := r.unstructuredCachingClient.List(ctx, infraMachines, client.InNamespace(cluster.Namespace), client.MatchingLabels{clusterv1.ClusterLabelName: cluster.Name}); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return ctrl.Result{}, errors.Wrapf(err, "failed to list %s for Cluster %s/%s", gvk.Kind, cluster.Namespace, cluster.Name)
+		}
+
+		for i := range infraMachines.Items {
+			infraMachine := &infraMachines.Items[i]
+			total++
+			if !infraMachine.GetDeletionTimestamp().IsZero() || isOwnedByMachine(infraMachine, machineUIDs) {
+				continue
+			}
+			if age := time.Since(infraMachine.GetCreationTimestamp().Time); age < orphanedInfrastructureGracePeriod {
+				res = util.LowestNonZeroResult(res, ctrl.Result{RequeueAfter: orphanedInfrastructureGracePeriod - age})
+				continue
+			}
+
+			name := fmt.Sprintf("%s/%s", gvk.Kind, infraMachine.GetName())
+			machine := referencingMachines[infrastructureMachineKey(gvk.GroupKind(), infraMachine.GetName())]
+			switch {
+			case machine != nil && r.OrphanedInfrastructurePolicy != "" && r.OrphanedInfrastructurePolicy != ReportOrphanedInfrastructurePolicy:
+				if err := r.adoptInfrastructureMachine(ctx, machine, infraMachine, machineUIDs); err != nil {
+					return ctrl.Result{}, err
+				}
+				log.Info("Adopted orphaned infrastructure machine", "infraMachine", name, "machine", machine.Name)
+				r.recorder.Eventf(cluster, corev1.EventTypeNormal, "AdoptedOrphanedInfrastructure", "Adopted orphaned infrastructure machine %s by Machine %s", name, machine.Name)
+			case machine == nil && r.OrphanedInfrastructurePolicy == DeleteOrphanedInfrastructurePolicy:
+				if err := r.Client.Delete(ctx, infraMachine); err != nil && !apierrors.IsNotFound(err) {
+					return ctrl.Result{}, errors.Wrapf(err, "failed to delete orphaned infrastructure machine %s", name)
+				}
+				log.Info("Deleted orphaned infrastructure machine", "infraMachine", name)
+				r.recorder.Eventf(cluster, corev1.EventTypeNormal, "DeletedOrphanedInfrastructure", "Deleted orphaned infrastructure machine %s", name)
+			default:
+				orphans = append(orphans, name)
+			}
+		}
+	}
+
+	metrics.RecordOrphanedInfrastructureMachines(cluster.Namespace, cluster.Name, len(orphans))
+	if total == 0 {
+		conditions.Delete(cluster, clusterv1.InfrastructureMachinesOwnedCondition)
+		return res, nil
+	}
+	if len(orphans) > 0 {
+		sort.Strings(orphans)
+		conditions.MarkFalse(cluster, clusterv1.InfrastructureMachinesOwnedCondition, clusterv1.OrphanedInfrastructureReason, clusterv1.ConditionSeverityWarning,
+			"Infrastructure machines not owned by any Machine: %s", strings.Join(orphans, ", "))
+		return res, nil
+	}
+	conditions.MarkTrue(cluster, clusterv1.InfrastructureMachinesOwnedCondition)
+	return res, nil
+}
+
+// adoptInfrastructureMachine sets the Machine as the controller of the infrastructure machine, removing owner
+// references to Machines which do not exist anymore.
+func (r *ClusterReconciler) adoptInfrastructureMachine(ctx context.Context, machine *clusterv1.Machine, infraMachine *unstructured.Unstructured, machineUIDs map[types.UID]bool) error {
+	patchHelper, err := patch.NewHelper(infraMachine, r.Client)
+	if err != nil {
+		return err
+	}
+
+	ownerRefs := infraMachine.GetOwnerReferences()
+	for _, ref := range infraMachine.GetOwnerReferences() {
+		if isMachineOwnerRef(ref.APIVersion, ref.Kind) && !machineUIDs[ref.UID] {
+			ownerRefs = util.RemoveOwnerRef(ownerRefs, ref)
+		}
+	}
+	infraMachine.SetOwnerReferences(ownerRefs)
+
+	if err := controllerutil.SetControllerReference(machine, infraMachine, r.Client.Scheme()); err != nil {
+		return errors.Wrapf(err, "failed to set Machine %s as the controller of %s %s", machine.Name, infraMachine.GetKind(), infraMachine.GetName())
+	}
+	return patchHelper.Patch(ctx, infraMachine)
+}
+
+// isOwnedByMachine returns true if one of the owners of the object is an existing Machine.
+func isOwnedByMachine(obj client.Object, machineUIDs map[types.UID]bool) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if isMachineOwnerRef(ref.APIVersion, ref.Kind) && machineUIDs[ref.UID] {
+			return true
+		}
+	}
+	return false
+}
+
+// isMachineOwnerRef returns true if the owner reference points to a Machine.
+func isMachineOwnerRef(apiVersion, kind string) bool {
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return false
+	}
+	return gv.Group == clusterv1.GroupVersion.Group && kind == "Machine"
+}
+
+// infrastructureMachineKey returns the key identifying an infrastructure machine within a namespace.
+func infrastructureMachineKey(gk schema.GroupKind, name string) string {
+	return fmt.Sprintf("%s/%s", gk, name)
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconcileOrphanedInfrastructure(t *testing.T) {
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-cluster",
+			Namespace: "test-namespace",
+		},
+	}
+	machine := func(name string, uid types.UID) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "test-namespace",
+				UID:       uid,
+				Labels:    map[string]string{clusterv1.ClusterLabelName: "test-cluster"},
+			},
+			Spec: clusterv1.MachineSpec{
+				ClusterName: "test-cluster",
+				InfrastructureRef: corev1.ObjectReference{
+					APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha4",
+					Kind:       "InfrastructureMachine",
+					Name:       name,
+				},
+			},
+		}
+	}
+	infraMachine := func(name string, age time.Duration, ownerUID types.UID) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("infrastructure.cluster.x-k8s.io/v1alpha4")
+		u.SetKind("InfrastructureMachine")
+		u.SetName(name)
+		u.SetNamespace("test-namespace")
+		u.SetLabels(map[string]string{clusterv1.ClusterLabelName: "test-cluster"})
+		u.SetCreationTimestamp(metav1.NewTime(time.Now().Add(-age)))
+		if ownerUID != "" {
+			u.SetOwnerReferences([]metav1.OwnerReference{{
+				APIVersion: clusterv1.GroupVersion.String(),
+				Kind:       "Machine",
+				Name:       name,
+				UID:        ownerUID,
+			}})
+		}
+		return u
+	}
+
+	tests := []struct {
+		name            string
+		policy          OrphanedInfrastructurePolicy
+		objs            []client.Object
+		wantCondition   bool
+		wantOwned       bool
+		wantDeleted     []string
+		wantAdopted     []string
+		wantRequeue     bool
+		wantOrphansText string
+	}{
+		{
+			name: "infrastructure machines owned by existing Machines",
+			objs: []client.Object{
+				machine("m1", "uid-1"), infraMachine("m1", time.Hour, "uid-1"),
+			},
+			wantCondition: true,
+			wantOwned:     true,
+		},
+		{
+			name: "reports infrastructure machines owned by Machines which do not exist anymore",
+			objs: []client.Object{
+				machine("m1", "uid-1"), infraMachine("m1", time.Hour, "uid-old"), infraMachine("m2", time.Hour, ""),
+			},
+			wantCondition:   true,
+			wantOwned:       false,
+			wantOrphansText: "InfrastructureMachine/m1, InfrastructureMachine/m2",
+		},
+		{
+			name: "ignores recently created infrastructure machines",
+			objs: []client.Object{
+				machine("m1", "uid-1"), infraMachine("m1", time.Hour, "uid-1"), infraMachine("m2", time.Minute, ""),
+			},
+			wantCondition: true,
+			wantOwned:     true,
+			wantRequeue:   true,
+		},
+		{
+			name:   "adopts infrastructure machines referenced by a Machine",
+			policy: AdoptOrphanedInfrastructurePolicy,
+			objs: []client.Object{
+				machine("m1", "uid-1"), infraMachine("m1", time.Hour, "uid-old"), infraMachine("m2", time.Hour, ""),
+			},
+			wantCondition:   true,
+			wantOwned:       false,
+			wantAdopted:     []string{"m1"},
+			wantOrphansText: "InfrastructureMachine/m2",
+		},
+		{
+			name:   "deletes infrastructure machines not referenced by any Machine",
+			policy: DeleteOrphanedInfrastructurePolicy,
+			objs: []client.Object{
+				machine("m1", "uid-1"), infraMachine("m1", time.Hour, "uid-old"), infraMachine("m2", time.Hour, ""),
+			},
+			wantCondition: true,
+			wantOwned:     true,
+			wantAdopted:   []string{"m1"},
+			wantDeleted:   []string{"m2"},
+		},
+		{
+			name:          "does not set the condition without infrastructure machines",
+			objs:          []client.Object{},
+			wantCondition: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			scheme := runtime.NewScheme()
+			g.Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+			infraMachineGV := schema.GroupVersion{Group: "infrastructure.cluster.x-k8s.io", Version: "v1alpha4"}
+			scheme.AddKnownTypeWithName(infraMachineGV.WithKind("InfrastructureMachine"), &unstructured.Unstructured{})
+			scheme.AddKnownTypeWithName(infraMachineGV.WithKind("InfrastructureMachineList"), &unstructured.UnstructuredList{})
+
+			c := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(tt.objs...).
+				Build()
+			r := &ClusterReconciler{
+				Client:                       c,
+				OrphanedInfrastructurePolicy: tt.policy,
+				unstructuredCachingClient:    c,
+				recorder:                     record.NewFakeRecorder(32),
+			}
+
+			cluster := cluster.DeepCopy()
+			res, err := r.reconcileOrphanedInfrastructure(ctx, cluster)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(res.RequeueAfter > 0).To(Equal(tt.wantRequeue))
+
+			if !tt.wantCondition {
+				g.Expect(conditions.Has(cluster, clusterv1.InfrastructureMachinesOwnedCondition)).To(BeFalse())
+				return
+			}
+			g.Expect(conditions.IsTrue(cluster, clusterv1.InfrastructureMachinesOwnedCondition)).To(Equal(tt.wantOwned))
+			if tt.wantOrphansText != "" {
+				g.Expect(conditions.GetReason(cluster, clusterv1.InfrastructureMachinesOwnedCondition)).To(Equal(clusterv1.OrphanedInfrastructureReason))
+				g.Expect(conditions.GetMessage(cluster, clusterv1.InfrastructureMachinesOwnedCondition)).To(ContainSubstring(tt.wantOrphansText))
+			}
+
+			for _, name := range tt.wantAdopted {
+				obj := infraMachine(name, 0, "")
+				g.Expect(c.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+				controller := metav1.GetControllerOf(obj)
+				g.Expect(controller).NotTo(BeNil())
+				g.Expect(controller.Name).To(Equal(name))
+				g.Expect(obj.GetOwnerReferences()).To(HaveLen(1))
+			}
+			for _, name := range tt.wantDeleted {
+				obj := infraMachine(name, 0, "")
+				g.Expect(apierrors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(obj), obj))).To(BeTrue())
+			}
+		})
+	}
+}
@@ -187,10 +187,9 @@ func (r *ClusterReconciler) reconcileInfrastructure(ctx context.Context, cluster
 		}
 	}
 
-	// Get and parse Status.FailureDomains from the infrastructure provider.
-	if err := util.UnstructuredUnmarshalField(infraConfig, &cluster.Status.FailureDomains, "status", "failureDomains"); err != nil && err != util.ErrUnstructuredFieldNotFound {
-		return ctrl.Result{}, errors.Wrapf(err, "failed to retrieve Status.FailureDomains from infrastructure provider for Cluster %q in namespace %q",
-			cluster.Name, cluster.Namespace)
+	// Get, normalize and validate Status.FailureDomains from the infrastructure provider.
+	if err := r.reconcileFailureDomains(ctx, cluster, infraConfig); err != nil {
+		return ctrl.Result{}, err
 	}
 
 	return ctrl.Result{}, nil
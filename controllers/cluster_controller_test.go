@@ -29,6 +29,7 @@ import (
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	addonsv1 "sigs.k8s.io/cluster-api/exp/addons/api/v1alpha4"
 	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/feature"
 	"sigs.k8s.io/cluster-api/util"
@@ -711,6 +712,114 @@ func TestDescendantsLength(t *testing.T) {
 	g.Expect(d.length()).To(Equal(15))
 }
 
+func TestListDescendantsReferencingCluster(t *testing.T) {
+	g := NewWithT(t)
+
+	c := &clusterv1.Cluster{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: clusterv1.GroupVersion.String(),
+			Kind:       "Cluster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "c",
+			Namespace: "test",
+		},
+	}
+
+	mhcForCluster := &clusterv1.MachineHealthCheck{
+		ObjectMeta: metav1.ObjectMeta{Name: "mhc1", Namespace: "test"},
+		Spec:       clusterv1.MachineHealthCheckSpec{ClusterName: "c"},
+	}
+	mhcForOtherCluster := &clusterv1.MachineHealthCheck{
+		ObjectMeta: metav1.ObjectMeta{Name: "mhc2", Namespace: "test"},
+		Spec:       clusterv1.MachineHealthCheckSpec{ClusterName: "other"},
+	}
+	bindingOwnedByCluster := &addonsv1.ClusterResourceSetBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "c",
+			Namespace: "test",
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: clusterv1.GroupVersion.String(), Kind: "Cluster", Name: "c"},
+			},
+		},
+	}
+	bindingNotOwnedByCluster := &addonsv1.ClusterResourceSetBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "test"},
+	}
+
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+	g.Expect(addonsv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	r := &ClusterReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(c, mhcForCluster, mhcForOtherCluster, bindingOwnedByCluster, bindingNotOwnedByCluster).Build(),
+	}
+
+	descendants, err := r.listDescendants(ctx, c)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(descendants.machineHealthChecks.Items).To(HaveLen(1))
+	g.Expect(descendants.machineHealthChecks.Items[0].Name).To(Equal("mhc1"))
+	g.Expect(descendants.clusterResourceSetBindings.Items).To(HaveLen(1))
+	g.Expect(descendants.clusterResourceSetBindings.Items[0].Name).To(Equal("c"))
+	g.Expect(descendants.length()).To(Equal(1))
+	g.Expect(descendants.descendantNames()).To(Equal("Machine health checks: mhc1;Cluster resource set bindings: c"))
+}
+
+func TestReconcileDeleteDescendantsReferencingCluster(t *testing.T) {
+	g := NewWithT(t)
+
+	c := &clusterv1.Cluster{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: clusterv1.GroupVersion.String(),
+			Kind:       "Cluster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "c",
+			Namespace:  "test",
+			Finalizers: []string{clusterv1.ClusterFinalizer},
+		},
+	}
+	mhc := &clusterv1.MachineHealthCheck{
+		ObjectMeta: metav1.ObjectMeta{Name: "mhc1", Namespace: "test"},
+		Spec:       clusterv1.MachineHealthCheckSpec{ClusterName: "c"},
+	}
+	binding := &addonsv1.ClusterResourceSetBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "c",
+			Namespace: "test",
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: clusterv1.GroupVersion.String(), Kind: "Cluster", Name: "c"},
+			},
+		},
+	}
+
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+	g.Expect(addonsv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(c, mhc, binding).Build()
+	r := &ClusterReconciler{Client: fakeClient}
+
+	// MachineHealthChecks are deleted first, while ClusterResourceSetBindings are preserved.
+	res, err := r.reconcileDelete(ctx, c)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(Equal(deleteRequeueAfter))
+	g.Expect(conditions.IsFalse(c, clusterv1.DescendantsDeletedCondition)).To(BeTrue())
+	g.Expect(conditions.GetMessage(c, clusterv1.DescendantsDeletedCondition)).To(ContainSubstring("mhc1"))
+	g.Expect(fakeClient.Get(ctx, util.ObjectKey(mhc), &clusterv1.MachineHealthCheck{})).NotTo(Succeed())
+	g.Expect(fakeClient.Get(ctx, util.ObjectKey(binding), &addonsv1.ClusterResourceSetBinding{})).To(Succeed())
+
+	// ClusterResourceSetBindings are deleted once all the other descendants are gone.
+	res, err = r.reconcileDelete(ctx, c)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(Equal(deleteRequeueAfter))
+	g.Expect(fakeClient.Get(ctx, util.ObjectKey(binding), &addonsv1.ClusterResourceSetBinding{})).NotTo(Succeed())
+
+	// Then the cluster finalizer gets removed.
+	_, err = r.reconcileDelete(ctx, c)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(conditions.IsTrue(c, clusterv1.DescendantsDeletedCondition)).To(BeTrue())
+	g.Expect(c.Finalizers).To(BeEmpty())
+}
+
 func TestReconcileControlPlaneInitializedControlPlaneRef(t *testing.T) {
 	g := NewWithT(t)
 
@@ -733,3 +842,75 @@ func TestReconcileControlPlaneInitializedControlPlaneRef(t *testing.T) {
 	g.Expect(err).ToNot(HaveOccurred())
 	g.Expect(conditions.Has(c, clusterv1.ControlPlaneInitializedCondition)).To(BeFalse())
 }
+
+func TestReconcileWorkers(t *testing.T) {
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "c",
+			Namespace: "default",
+		},
+	}
+	machineDeployment := func(name string, ready bool) *clusterv1.MachineDeployment {
+		md := &clusterv1.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels: map[string]string{
+					clusterv1.ClusterLabelName: cluster.Name,
+				},
+			},
+			Spec: clusterv1.MachineDeploymentSpec{
+				ClusterName: cluster.Name,
+			},
+		}
+		if ready {
+			conditions.MarkTrue(md, clusterv1.ReadyCondition)
+		} else {
+			conditions.MarkFalse(md, clusterv1.ReadyCondition, clusterv1.WaitingForAvailableMachinesReason, clusterv1.ConditionSeverityWarning, "")
+		}
+		return md
+	}
+
+	tests := []struct {
+		name       string
+		objs       []client.Object
+		wantStatus corev1.ConditionStatus
+		wantReason string
+	}{
+		{
+			name: "no condition without MachineDeployments",
+		},
+		{
+			name:       "ready if all the MachineDeployments are ready",
+			objs:       []client.Object{machineDeployment("md1", true), machineDeployment("md2", true)},
+			wantStatus: corev1.ConditionTrue,
+		},
+		{
+			name:       "not ready if a MachineDeployment is not ready",
+			objs:       []client.Object{machineDeployment("md1", true), machineDeployment("md2", false)},
+			wantStatus: corev1.ConditionFalse,
+			wantReason: clusterv1.WaitingForAvailableMachinesReason,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c := cluster.DeepCopy()
+			r := &ClusterReconciler{
+				Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(tt.objs...).Build(),
+			}
+			res, err := r.reconcileWorkers(ctx, c)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(res.IsZero()).To(BeTrue())
+
+			if tt.wantStatus == "" {
+				g.Expect(conditions.Has(c, clusterv1.WorkersReadyCondition)).To(BeFalse())
+				return
+			}
+			g.Expect(conditions.Get(c, clusterv1.WorkersReadyCondition).Status).To(Equal(tt.wantStatus))
+			g.Expect(conditions.GetReason(c, clusterv1.WorkersReadyCondition)).To(HavePrefix(tt.wantReason))
+		})
+	}
+}
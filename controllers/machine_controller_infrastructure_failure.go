@@ -0,0 +1,154 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// reconcileInfrastructureFailure applies the Machine's infrastructure failure policy when the infrastructure machine
+// reports a terminal failure. It returns true if the failed infrastructure machine has been replaced by a new one.
+func (r *MachineReconciler) reconcileInfrastructureFailure(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine, infraConfig *unstructured.Unstructured) (bool, error) {
+	log := ctrl.LoggerFrom(ctx, "cluster", cluster.Name)
+
+	failureReason, failureMessage, err := external.FailuresFrom(infraConfig)
+	if err != nil {
+		return false, err
+	}
+	if failureReason == "" && failureMessage == "" {
+		// The condition is added only after the first failure, so it is marked true only if it already exists,
+		// e.g. after the infrastructure machine has been re-created.
+		if conditions.Has(m, clusterv1.InfrastructureHealthyCondition) {
+			conditions.MarkTrue(m, clusterv1.InfrastructureHealthyCondition)
+		}
+		return false, nil
+	}
+	failure := fmt.Sprintf("%v %q reported a failure: %s %s", infraConfig.GroupVersionKind().Kind, infraConfig.GetName(), failureReason, failureMessage)
+
+	policy := m.Spec.InfrastructureFailurePolicy
+	if policy == nil {
+		policy = &clusterv1.MachineInfrastructureFailurePolicy{}
+	}
+
+	switch policy.Action {
+	case clusterv1.InspectInfrastructureFailureAction:
+		if r.markInfrastructureFailure(m, clusterv1.InfrastructureLeftForInspectionReason, clusterv1.ConditionSeverityError, "%s; left for inspection", failure) {
+			log.Info("Infrastructure machine failed, leaving it for inspection", "failure", failure)
+			r.recorder.Eventf(m, corev1.EventTypeWarning, "InfrastructureFailureLeftForInspection", "%s; left for inspection", failure)
+		}
+		return false, nil
+	case clusterv1.RecreateInfrastructureFailureAction:
+		maxRecreations := clusterv1.DefaultMaxInfrastructureRecreations
+		if policy.MaxRecreations != nil {
+			maxRecreations = *policy.MaxRecreations
+		}
+
+		var reason string
+		switch {
+		case m.Status.NodeRef != nil:
+			reason = "the Machine already has a Node"
+		case m.Status.InfrastructureRecreations >= maxRecreations:
+			reason = fmt.Sprintf("the infrastructure machine has already been re-created %d times", m.Status.InfrastructureRecreations)
+		case infraConfig.GetAnnotations()[clusterv1.TemplateClonedFromNameAnnotation] == "",
+			infraConfig.GetAnnotations()[clusterv1.TemplateClonedFromGroupKindAnnotation] == "":
+			reason = "the infrastructure machine has not been cloned from a template"
+		}
+		if reason != "" {
+			r.escalateInfrastructureFailure(ctx, m, fmt.Sprintf("%s; it can't be re-created because %s", failure, reason))
+			return false, nil
+		}
+
+		if err := r.recreateInfrastructure(ctx, m, infraConfig); err != nil {
+			return false, err
+		}
+		r.markInfrastructureFailure(m, clusterv1.InfrastructureRecreatingReason, clusterv1.ConditionSeverityWarning,
+			"%s; re-created %d of %d times", failure, m.Status.InfrastructureRecreations, maxRecreations)
+		log.Info("Infrastructure machine failed, re-created it", "failure", failure, "infrastructureRef", m.Spec.InfrastructureRef.Name)
+		r.recorder.Eventf(m, corev1.EventTypeWarning, "InfrastructureFailureRecreated", "%s; re-created as %q (%d of %d)",
+			failure, m.Spec.InfrastructureRef.Name, m.Status.InfrastructureRecreations, maxRecreations)
+		return true, nil
+	default:
+		r.escalateInfrastructureFailure(ctx, m, failure)
+		return false, nil
+	}
+}
+
+// escalateInfrastructureFailure documents a failure which is left to MachineHealthCheck remediation.
+func (r *MachineReconciler) escalateInfrastructureFailure(ctx context.Context, m *clusterv1.Machine, message string) {
+	if r.markInfrastructureFailure(m, clusterv1.InfrastructureFailureEscalatedReason, clusterv1.ConditionSeverityError, "%s; escalated to remediation", message) {
+		ctrl.LoggerFrom(ctx).Info("Infrastructure machine failed, escalating to remediation", "failure", message)
+		r.recorder.Eventf(m, corev1.EventTypeWarning, "InfrastructureFailureEscalated", "%s; escalated to remediation", message)
+	}
+}
+
+// markInfrastructureFailure sets the InfrastructureHealthy condition to false, and returns true if the reason changed,
+// so each decision is reported only once.
+func (r *MachineReconciler) markInfrastructureFailure(m *clusterv1.Machine, reason string, severity clusterv1.ConditionSeverity, messageFormat string, messageArgs ...interface{}) bool {
+	changed := !conditions.IsFalse(m, clusterv1.InfrastructureHealthyCondition) || conditions.GetReason(m, clusterv1.InfrastructureHealthyCondition) != reason
+	conditions.MarkFalse(m, clusterv1.InfrastructureHealthyCondition, reason, severity, messageFormat, messageArgs...)
+	return changed
+}
+
+// recreateInfrastructure clones a new infrastructure machine from the template the failed one has been cloned from,
+// points the Machine to it and deletes the failed one.
+func (r *MachineReconciler) recreateInfrastructure(ctx context.Context, m *clusterv1.Machine, infraConfig *unstructured.Unstructured) error {
+	groupKind := schema.ParseGroupKind(infraConfig.GetAnnotations()[clusterv1.TemplateClonedFromGroupKindAnnotation])
+	templateRef := &corev1.ObjectReference{
+		APIVersion: groupKind.WithVersion(infraConfig.GroupVersionKind().Version).GroupVersion().String(),
+		Kind:       groupKind.Kind,
+		Name:       infraConfig.GetAnnotations()[clusterv1.TemplateClonedFromNameAnnotation],
+		Namespace:  m.Namespace,
+	}
+
+	infraRef, err := external.CloneTemplate(ctx, &external.CloneTemplateInput{
+		Client:      r.Client,
+		TemplateRef: templateRef,
+		Namespace:   m.Namespace,
+		ClusterName: m.Spec.ClusterName,
+		OwnerRef:    metav1.NewControllerRef(m, clusterv1.GroupVersion.WithKind("Machine")),
+		Labels:      m.Labels,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to re-create infrastructure machine for Machine %q in namespace %q", m.Name, m.Namespace)
+	}
+
+	if err := r.Client.Delete(ctx, infraConfig); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete failed infrastructure machine %q for Machine %q in namespace %q", infraConfig.GetName(), m.Name, m.Namespace)
+	}
+
+	m.Spec.InfrastructureRef = *infraRef
+	m.Spec.ProviderID = nil
+	m.Status.InfrastructureReady = false
+	m.Status.Addresses = nil
+	m.Status.FailureReason = nil
+	m.Status.FailureMessage = nil
+	m.Status.InfrastructureRecreations++
+	conditions.MarkFalse(m, clusterv1.InfrastructureReadyCondition, clusterv1.WaitingForInfrastructureFallbackReason, clusterv1.ConditionSeverityInfo, "")
+	return nil
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconcileInfrastructureFailure(t *testing.T) {
+	cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: metav1.NamespaceDefault}}
+
+	infraTemplate := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind":       "InfrastructureMachineTemplate",
+			"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha4",
+			"metadata": map[string]interface{}{
+				"name":      "infra-template",
+				"namespace": metav1.NamespaceDefault,
+			},
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"size": "3xlarge",
+					},
+				},
+			},
+		},
+	}
+
+	infraConfig := func(failed bool) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind":       "InfrastructureMachine",
+				"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha4",
+				"metadata": map[string]interface{}{
+					"name":      "infra-config1",
+					"namespace": metav1.NamespaceDefault,
+					"annotations": map[string]interface{}{
+						clusterv1.TemplateClonedFromNameAnnotation:      "infra-template",
+						clusterv1.TemplateClonedFromGroupKindAnnotation: "InfrastructureMachineTemplate.infrastructure.cluster.x-k8s.io",
+					},
+				},
+				"spec": map[string]interface{}{
+					"size": "3xlarge",
+				},
+				"status": map[string]interface{}{},
+			},
+		}
+		if failed {
+			u.Object["status"] = map[string]interface{}{
+				"failureReason":  "CreateError",
+				"failureMessage": "instance terminated",
+			}
+		}
+		return u
+	}
+
+	machine := func(policy *clusterv1.MachineInfrastructureFailurePolicy) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "machine-test",
+				Namespace: metav1.NamespaceDefault,
+			},
+			Spec: clusterv1.MachineSpec{
+				ClusterName: cluster.Name,
+				InfrastructureRef: corev1.ObjectReference{
+					APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha4",
+					Kind:       "InfrastructureMachine",
+					Name:       "infra-config1",
+					Namespace:  metav1.NamespaceDefault,
+				},
+				InfrastructureFailurePolicy: policy,
+			},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		machine       *clusterv1.Machine
+		infraConfig   *unstructured.Unstructured
+		wantRecreated bool
+		wantReason    string
+		wantEvent     bool
+	}{
+		{
+			name:        "no failure, no condition",
+			machine:     machine(nil),
+			infraConfig: infraConfig(false),
+		},
+		{
+			name:        "failure is escalated to remediation by default",
+			machine:     machine(nil),
+			infraConfig: infraConfig(true),
+			wantReason:  clusterv1.InfrastructureFailureEscalatedReason,
+			wantEvent:   true,
+		},
+		{
+			name:        "failure is left for inspection",
+			machine:     machine(&clusterv1.MachineInfrastructureFailurePolicy{Action: clusterv1.InspectInfrastructureFailureAction}),
+			infraConfig: infraConfig(true),
+			wantReason:  clusterv1.InfrastructureLeftForInspectionReason,
+			wantEvent:   true,
+		},
+		{
+			name:          "failed infrastructure machine is re-created",
+			machine:       machine(&clusterv1.MachineInfrastructureFailurePolicy{Action: clusterv1.RecreateInfrastructureFailureAction}),
+			infraConfig:   infraConfig(true),
+			wantRecreated: true,
+			wantReason:    clusterv1.InfrastructureRecreatingReason,
+			wantEvent:     true,
+		},
+		{
+			name: "failure is escalated when the re-creations are exhausted",
+			machine: func() *clusterv1.Machine {
+				m := machine(&clusterv1.MachineInfrastructureFailurePolicy{Action: clusterv1.RecreateInfrastructureFailureAction, MaxRecreations: pointer.Int32Ptr(1)})
+				m.Status.InfrastructureRecreations = 1
+				return m
+			}(),
+			infraConfig: infraConfig(true),
+			wantReason:  clusterv1.InfrastructureFailureEscalatedReason,
+			wantEvent:   true,
+		},
+		{
+			name: "failure is escalated when the Machine already has a Node",
+			machine: func() *clusterv1.Machine {
+				m := machine(&clusterv1.MachineInfrastructureFailurePolicy{Action: clusterv1.RecreateInfrastructureFailureAction})
+				m.Status.NodeRef = &corev1.ObjectReference{Kind: "Node", Name: "node-1"}
+				return m
+			}(),
+			infraConfig: infraConfig(true),
+			wantReason:  clusterv1.InfrastructureFailureEscalatedReason,
+			wantEvent:   true,
+		},
+		{
+			name: "decisions already taken are not reported again",
+			machine: func() *clusterv1.Machine {
+				m := machine(nil)
+				conditions.MarkFalse(m, clusterv1.InfrastructureHealthyCondition, clusterv1.InfrastructureFailureEscalatedReason, clusterv1.ConditionSeverityError, "")
+				return m
+			}(),
+			infraConfig: infraConfig(true),
+			wantReason:  clusterv1.InfrastructureFailureEscalatedReason,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			recorder := record.NewFakeRecorder(32)
+			r := &MachineReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme.Scheme).
+					WithObjects(tt.machine, infraTemplate.DeepCopy(), tt.infraConfig,
+						external.TestGenericInfrastructureCRD.DeepCopy(),
+						external.TestGenericInfrastructureTemplateCRD.DeepCopy()).
+					Build(),
+				recorder: recorder,
+			}
+
+			recreated, err := r.reconcileInfrastructureFailure(ctx, cluster, tt.machine, tt.infraConfig)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(recreated).To(Equal(tt.wantRecreated))
+
+			if tt.wantReason == "" {
+				g.Expect(conditions.Has(tt.machine, clusterv1.InfrastructureHealthyCondition)).To(BeFalse())
+			} else {
+				g.Expect(conditions.IsFalse(tt.machine, clusterv1.InfrastructureHealthyCondition)).To(BeTrue())
+				g.Expect(conditions.GetReason(tt.machine, clusterv1.InfrastructureHealthyCondition)).To(Equal(tt.wantReason))
+			}
+			if tt.wantEvent {
+				g.Expect(recorder.Events).To(HaveLen(1))
+			} else {
+				g.Expect(recorder.Events).To(BeEmpty())
+			}
+
+			if tt.wantRecreated {
+				g.Expect(tt.machine.Spec.InfrastructureRef.Name).NotTo(Equal("infra-config1"))
+				g.Expect(tt.machine.Status.InfrastructureRecreations).To(Equal(int32(1)))
+				g.Expect(tt.machine.Status.FailureReason).To(BeNil())
+
+				// The failed infrastructure machine is deleted, and replaced by a new one cloned from the template.
+				err := r.Client.Get(ctx, client.ObjectKeyFromObject(tt.infraConfig), tt.infraConfig.DeepCopy())
+				g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+				recreatedConfig, err := external.Get(ctx, r.Client, &tt.machine.Spec.InfrastructureRef, tt.machine.Namespace)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(recreatedConfig.GetAnnotations()).To(HaveKeyWithValue(clusterv1.TemplateClonedFromNameAnnotation, "infra-template"))
+				g.Expect(metav1.IsControlledBy(recreatedConfig, tt.machine)).To(BeTrue())
+			}
+		})
+	}
+}
@@ -19,7 +19,9 @@ package controllers
 import (
 	"context"
 
+	"github.com/pkg/errors"
 	apicorev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/controllers/external"
@@ -92,3 +94,75 @@ func (r *MachineReconciler) setInterruptibleNodeLabel(ctx context.Context, remot
 
 	return patchHelper.Patch(ctx, node)
 }
+
+// reconcileInterruptibleNodeTermination deletes the Machines whose interruptible Node reports an imminent termination,
+// so the Node gets cordoned and drained before the instance is preempted, and the Machine gets replaced by its owner.
+func (r *MachineReconciler) reconcileInterruptibleNodeTermination(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) (ctrl.Result, error) {
+	// Check that the Machine hasn't been deleted or in the process
+	// and that the Machine has a NodeRef.
+	if !machine.DeletionTimestamp.IsZero() || machine.Status.NodeRef == nil {
+		return ctrl.Result{}, nil
+	}
+
+	log := ctrl.LoggerFrom(ctx)
+
+	remoteClient, err := r.Tracker.GetClient(ctx, util.ObjectKey(cluster))
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	node := &apicorev1.Node{}
+	if err := remoteClient.Get(ctx, client.ObjectKey{Name: machine.Status.NodeRef.Name}, node); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if !isInterruptibleNodeTerminating(node) {
+		// Reset the marker, so a new termination request is reported again.
+		delete(machine.Annotations, clusterv1.NodeTerminationRequestedAnnotation)
+		return ctrl.Result{}, nil
+	}
+
+	// Control plane Machines are not deleted, because the control plane provider must take care of e.g. etcd membership;
+	// they are left to MachineHealthCheck remediation, which is handled by the control plane provider.
+	// The termination is reported only when it is first detected; the marker annotation is persisted by the Machine patch.
+	if util.IsControlPlaneMachine(machine) {
+		if _, ok := machine.Annotations[clusterv1.NodeTerminationRequestedAnnotation]; ok {
+			return ctrl.Result{}, nil
+		}
+		log.Info("Interruptible control plane Machine's Node is going to be terminated, skipping deletion", "nodename", node.Name)
+		r.recorder.Eventf(machine, apicorev1.EventTypeWarning, "InterruptibleNodeTerminationRequested", "Node %s is going to be terminated, control plane Machines are left to MachineHealthCheck remediation", node.Name)
+		if machine.Annotations == nil {
+			machine.Annotations = map[string]string{}
+		}
+		machine.Annotations[clusterv1.NodeTerminationRequestedAnnotation] = ""
+		return ctrl.Result{}, nil
+	}
+
+	log.Info("Deleting Machine, the interruptible Node is going to be terminated", "nodename", node.Name)
+	if err := r.Client.Delete(ctx, machine); err != nil && !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to delete Machine %s/%s", machine.Namespace, machine.Name)
+	}
+	r.recorder.Eventf(machine, apicorev1.EventTypeWarning, "InterruptibleNodeTerminationRequested", "Deleted Machine, Node %s is going to be terminated", node.Name)
+
+	return ctrl.Result{}, nil
+}
+
+// isInterruptibleNodeTerminating returns true if a Node runs on an interruptible instance and
+// reports an imminent termination of the instance.
+func isInterruptibleNodeTerminating(node *apicorev1.Node) bool {
+	if node == nil {
+		return false
+	}
+	if _, ok := node.Labels[clusterv1.InterruptibleLabel]; !ok {
+		return false
+	}
+	for _, c := range node.Status.Conditions {
+		if c.Type == clusterv1.NodeTerminationRequestedCondition {
+			return c.Status == apicorev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -31,6 +31,7 @@ import (
 
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/controllers/remote"
+	"sigs.k8s.io/cluster-api/test/helpers"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -138,3 +139,114 @@ func TestReconcileInterruptibleNodeLabel(t *testing.T) {
 		return ok
 	}, 10*time.Second).Should(BeTrue())
 }
+
+func TestIsInterruptibleNodeTerminating(t *testing.T) {
+	node := func(labels map[string]string, conditions ...corev1.NodeCondition) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: labels},
+			Status:     corev1.NodeStatus{Conditions: conditions},
+		}
+	}
+	interruptible := map[string]string{clusterv1.InterruptibleLabel: ""}
+	terminationRequested := corev1.NodeCondition{Type: clusterv1.NodeTerminationRequestedCondition, Status: corev1.ConditionTrue}
+
+	tests := []struct {
+		name string
+		node *corev1.Node
+		want bool
+	}{
+		{
+			name: "nil node",
+			node: nil,
+			want: false,
+		},
+		{
+			name: "interruptible node without termination requested condition",
+			node: node(interruptible, corev1.NodeCondition{Type: corev1.NodeReady, Status: corev1.ConditionTrue}),
+			want: false,
+		},
+		{
+			name: "interruptible node with termination requested condition false",
+			node: node(interruptible, corev1.NodeCondition{Type: clusterv1.NodeTerminationRequestedCondition, Status: corev1.ConditionFalse}),
+			want: false,
+		},
+		{
+			name: "not interruptible node with termination requested condition",
+			node: node(nil, terminationRequested),
+			want: false,
+		},
+		{
+			name: "interruptible node with termination requested condition",
+			node: node(interruptible, terminationRequested),
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(isInterruptibleNodeTerminating(tt.node)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestReconcileInterruptibleNodeTerminationControlPlane(t *testing.T) {
+	g := NewWithT(t)
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster-1",
+			Namespace: "default",
+		},
+	}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "node-1",
+			Labels: map[string]string{clusterv1.InterruptibleLabel: ""},
+		},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{
+				{Type: clusterv1.NodeTerminationRequestedCondition, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "machine-test",
+			Namespace: "default",
+			Labels:    map[string]string{clusterv1.MachineControlPlaneLabelName: ""},
+		},
+		Spec: clusterv1.MachineSpec{
+			ClusterName: cluster.Name,
+		},
+		Status: clusterv1.MachineStatus{
+			NodeRef: &corev1.ObjectReference{
+				Name: node.Name,
+			},
+		},
+	}
+
+	c := helpers.NewFakeClientWithScheme(scheme.Scheme, node, machine)
+	recorder := record.NewFakeRecorder(32)
+	r := &MachineReconciler{
+		Client:   c,
+		Tracker:  remote.NewTestClusterCacheTracker(log.NullLogger{}, c, scheme.Scheme, client.ObjectKey{Name: cluster.Name, Namespace: cluster.Namespace}),
+		recorder: recorder,
+	}
+
+	// The termination is reported on the first reconcile only, and the control plane Machine is not deleted.
+	for i := 0; i < 3; i++ {
+		_, err := r.reconcileInterruptibleNodeTermination(ctx, cluster, machine)
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+	g.Expect(recorder.Events).To(HaveLen(1))
+	g.Expect(machine.Annotations).To(HaveKey(clusterv1.NodeTerminationRequestedAnnotation))
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(machine), &clusterv1.Machine{})).To(Succeed())
+
+	// The marker is removed when the termination is no longer requested, so a new termination request is reported again.
+	node.Status.Conditions[0].Status = corev1.ConditionFalse
+	g.Expect(c.Status().Update(ctx, node)).To(Succeed())
+	_, err := r.reconcileInterruptibleNodeTermination(ctx, cluster, machine)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(machine.Annotations).ToNot(HaveKey(clusterv1.NodeTerminationRequestedAnnotation))
+}
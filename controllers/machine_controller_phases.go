@@ -30,6 +30,7 @@ import (
 	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/controllers/machinephase"
 	capierrors "sigs.k8s.io/cluster-api/errors"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
@@ -46,43 +47,7 @@ var (
 )
 
 func (r *MachineReconciler) reconcilePhase(_ context.Context, m *clusterv1.Machine) {
-	originalPhase := m.Status.Phase
-
-	// Set the phase to "pending" if nil.
-	if m.Status.Phase == "" {
-		m.Status.SetTypedPhase(clusterv1.MachinePhasePending)
-	}
-
-	// Set the phase to "provisioning" if bootstrap is ready and the infrastructure isn't.
-	if m.Status.BootstrapReady && !m.Status.InfrastructureReady {
-		m.Status.SetTypedPhase(clusterv1.MachinePhaseProvisioning)
-	}
-
-	// Set the phase to "provisioned" if there is a NodeRef.
-	if m.Status.NodeRef != nil {
-		m.Status.SetTypedPhase(clusterv1.MachinePhaseProvisioned)
-	}
-
-	// Set the phase to "running" if there is a NodeRef field and infrastructure is ready.
-	if m.Status.NodeRef != nil && m.Status.InfrastructureReady {
-		m.Status.SetTypedPhase(clusterv1.MachinePhaseRunning)
-	}
-
-	// Set the phase to "failed" if any of Status.FailureReason or Status.FailureMessage is not-nil.
-	if m.Status.FailureReason != nil || m.Status.FailureMessage != nil {
-		m.Status.SetTypedPhase(clusterv1.MachinePhaseFailed)
-	}
-
-	// Set the phase to "deleting" if the deletion timestamp is set.
-	if !m.DeletionTimestamp.IsZero() {
-		m.Status.SetTypedPhase(clusterv1.MachinePhaseDeleting)
-	}
-
-	// If the phase has changed, update the LastUpdated timestamp
-	if m.Status.Phase != originalPhase {
-		now := metav1.Now()
-		m.Status.LastUpdated = &now
-	}
+	machinephase.Transition(m, machinephase.Compute(m), metav1.Now())
 }
 
 // reconcileExternal handles generic unstructured objects referenced by a Machine.
@@ -173,11 +138,11 @@ func (r *MachineReconciler) reconcileExternal(ctx context.Context, cluster *clus
 func (r *MachineReconciler) reconcileBootstrap(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine) (ctrl.Result, error) {
 	log := ctrl.LoggerFrom(ctx, "cluster", cluster.Name)
 
-	// If the bootstrap data is populated, set ready and return.
+	// If the bootstrap data is populated, set ready, check if the bootstrap data is up to date and return.
 	if m.Spec.Bootstrap.DataSecretName != nil {
 		m.Status.BootstrapReady = true
 		conditions.MarkTrue(m, clusterv1.BootstrapReadyCondition)
-		return ctrl.Result{}, nil
+		return ctrl.Result{}, r.reconcileBootstrapDataUpToDate(ctx, m)
 	}
 
 	// If the Boostrap ref is nil (and so the machine should use user generated data secret), return.
@@ -234,6 +199,34 @@ func (r *MachineReconciler) reconcileBootstrap(ctx context.Context, cluster *clu
 	return ctrl.Result{}, nil
 }
 
+// reconcileBootstrapDataUpToDate surfaces on the Machine the BootstrapDataUpToDate condition reported by the bootstrap
+// provider, if any, so the Machine owner can replace Machines with stale bootstrap data.
+func (r *MachineReconciler) reconcileBootstrapDataUpToDate(ctx context.Context, m *clusterv1.Machine) error {
+	log := ctrl.LoggerFrom(ctx)
+
+	if m.Spec.Bootstrap.ConfigRef == nil {
+		return nil
+	}
+
+	bootstrapConfig, err := external.Get(ctx, r.Client, m.Spec.Bootstrap.ConfigRef, m.Namespace)
+	if err != nil {
+		if apierrors.IsNotFound(errors.Cause(err)) {
+			return nil
+		}
+		return err
+	}
+
+	// Ensure we add a watcher to the bootstrap config, so changes to the condition are promptly surfaced.
+	if err := r.externalTracker.Watch(log, bootstrapConfig, &handler.EnqueueRequestForOwner{OwnerType: &clusterv1.Machine{}}); err != nil {
+		return err
+	}
+
+	if c := conditions.Get(conditions.UnstructuredGetter(bootstrapConfig), clusterv1.BootstrapDataUpToDateCondition); c != nil {
+		conditions.Set(m, c)
+	}
+	return nil
+}
+
 // reconcileInfrastructure reconciles the Spec.InfrastructureRef object on a Machine.
 func (r *MachineReconciler) reconcileInfrastructure(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine) (ctrl.Result, error) {
 	log := ctrl.LoggerFrom(ctx, "cluster", cluster.Name)
@@ -264,6 +257,15 @@ func (r *MachineReconciler) reconcileInfrastructure(ctx context.Context, cluster
 		return ctrl.Result{}, nil
 	}
 
+	// Apply the infrastructure failure policy, if the infrastructure provider reports a terminal failure.
+	recreated, err := r.reconcileInfrastructureFailure(ctx, cluster, m, infraConfig)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if recreated {
+		return ctrl.Result{RequeueAfter: externalReadyWait}, nil
+	}
+
 	// Determine if the infrastructure provider is ready.
 	ready, err := external.IsReady(infraConfig)
 	if err != nil {
@@ -728,6 +728,56 @@ func TestReconcileBootstrap(t *testing.T) {
 				g.Expect(*m.Spec.Bootstrap.DataSecretName).To(BeEquivalentTo("secret-data"))
 			},
 		},
+		{
+			name: "existing machine, bootstrap provider reports stale bootstrap data",
+			bootstrapConfig: map[string]interface{}{
+				"kind":       "BootstrapMachine",
+				"apiVersion": "bootstrap.cluster.x-k8s.io/v1alpha4",
+				"metadata": map[string]interface{}{
+					"name":      "bootstrap-config1",
+					"namespace": "default",
+				},
+				"spec": map[string]interface{}{},
+				"status": map[string]interface{}{
+					"ready":          true,
+					"dataSecretName": "secret-data",
+					"conditions": []interface{}{
+						map[string]interface{}{
+							"type":     string(clusterv1.BootstrapDataUpToDateCondition),
+							"status":   string(corev1.ConditionFalse),
+							"severity": string(clusterv1.ConditionSeverityWarning),
+							"reason":   clusterv1.BootstrapDataStaleReason,
+						},
+					},
+				},
+			},
+			machine: &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "bootstrap-test-existing",
+					Namespace: "default",
+				},
+				Spec: clusterv1.MachineSpec{
+					Bootstrap: clusterv1.Bootstrap{
+						ConfigRef: &corev1.ObjectReference{
+							APIVersion: "bootstrap.cluster.x-k8s.io/v1alpha4",
+							Kind:       "BootstrapMachine",
+							Name:       "bootstrap-config1",
+						},
+						DataSecretName: pointer.StringPtr("secret-data"),
+					},
+				},
+				Status: clusterv1.MachineStatus{
+					BootstrapReady: true,
+				},
+			},
+			expectResult: ctrl.Result{},
+			expectError:  false,
+			expected: func(g *WithT, m *clusterv1.Machine) {
+				g.Expect(m.Status.BootstrapReady).To(BeTrue())
+				g.Expect(conditions.IsFalse(m, clusterv1.BootstrapDataUpToDateCondition)).To(BeTrue())
+				g.Expect(conditions.GetReason(m, clusterv1.BootstrapDataUpToDateCondition)).To(Equal(clusterv1.BootstrapDataStaleReason))
+			},
+		},
 		{
 			name: "existing machine, bootstrap provider is not ready, and ownerref updated",
 			bootstrapConfig: map[string]interface{}{
@@ -18,19 +18,25 @@ package controllers
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/controllers/mdutil"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/cluster-api/util/predicates"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -75,7 +81,7 @@ func (r *MachineDeploymentReconciler) SetupWithManager(ctx context.Context, mgr
 		).
 		WithOptions(options).
 		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
-		Build(r)
+		Build(metrics.NewInstrumentedReconciler("machinedeployment", r))
 	if err != nil {
 		return errors.Wrap(err, "failed setting up with a controller manager")
 	}
@@ -129,7 +135,7 @@ func (r *MachineDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Re
 
 	defer func() {
 		// Always attempt to patch the object and status after each reconciliation.
-		if err := patchHelper.Patch(ctx, deployment); err != nil {
+		if err := patchMachineDeployment(ctx, patchHelper, deployment); err != nil {
 			reterr = kerrors.NewAggregate([]error{reterr, err})
 		}
 	}()
@@ -148,6 +154,24 @@ func (r *MachineDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Re
 	return result, err
 }
 
+func patchMachineDeployment(ctx context.Context, patchHelper *patch.Helper, d *clusterv1.MachineDeployment, options ...patch.Option) error {
+	// Always update the readyCondition by summarizing the state of other conditions.
+	conditions.SetSummary(d,
+		conditions.WithConditions(
+			clusterv1.MachineDeploymentAvailableCondition,
+		),
+	)
+
+	// Patch the object, ignoring conflicts on the conditions owned by this controller.
+	options = append(options,
+		patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{
+			clusterv1.ReadyCondition,
+			clusterv1.MachineDeploymentAvailableCondition,
+		}},
+	)
+	return patchHelper.Patch(ctx, d, options...)
+}
+
 func (r *MachineDeploymentReconciler) reconcile(ctx context.Context, cluster *clusterv1.Cluster, d *clusterv1.MachineDeployment) (ctrl.Result, error) {
 	log := ctrl.LoggerFrom(ctx)
 	log.V(4).Info("Reconcile MachineDeployment")
@@ -176,12 +200,15 @@ func (r *MachineDeploymentReconciler) reconcile(ctx context.Context, cluster *cl
 	}
 
 	// Make sure to reconcile the external infrastructure reference.
-	if err := reconcileExternalTemplateReference(ctx, r.Client, r.restConfig, cluster, &d.Spec.Template.Spec.InfrastructureRef); err != nil {
+	if _, err := reconcileExternalTemplateReference(ctx, r.Client, r.restConfig, cluster, &d.Spec.Template.Spec.InfrastructureRef); err != nil {
 		return ctrl.Result{}, err
 	}
 	// Make sure to reconcile the external bootstrap reference, if any.
+	var bootstrapTemplate *unstructured.Unstructured
 	if d.Spec.Template.Spec.Bootstrap.ConfigRef != nil {
-		if err := reconcileExternalTemplateReference(ctx, r.Client, r.restConfig, cluster, d.Spec.Template.Spec.Bootstrap.ConfigRef); err != nil {
+		var err error
+		bootstrapTemplate, err = reconcileExternalTemplateReference(ctx, r.Client, r.restConfig, cluster, d.Spec.Template.Spec.Bootstrap.ConfigRef)
+		if err != nil {
 			return ctrl.Result{}, err
 		}
 	}
@@ -191,10 +218,19 @@ func (r *MachineDeploymentReconciler) reconcile(ctx context.Context, cluster *cl
 		return ctrl.Result{}, err
 	}
 
+	if err := r.reconcileBootstrapTemplateHash(ctx, d, bootstrapTemplate, msList); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	if d.Spec.Paused {
 		return ctrl.Result{}, r.sync(ctx, d, msList)
 	}
 
+	// Process the rollback requests before the rollout, so the rollout targets the restored machine template.
+	if _, ok := getRollbackTo(d); ok {
+		return ctrl.Result{}, r.rollback(ctx, d, msList)
+	}
+
 	if d.Spec.Strategy.Type == clusterv1.RollingUpdateMachineDeploymentStrategyType {
 		return ctrl.Result{}, r.rolloutRolling(ctx, d, msList)
 	}
@@ -206,6 +242,67 @@ func (r *MachineDeploymentReconciler) reconcile(ctx context.Context, cluster *cl
 	return ctrl.Result{}, errors.Errorf("unexpected deployment strategy type: %s", d.Spec.Strategy.Type)
 }
 
+// reconcileBootstrapTemplateHash records the hash of the bootstrap template in the machine template of the
+// MachineDeployment, so changes to the bootstrap template are rolled out like any other change to the machine template,
+// honouring the deployment strategy.
+func (r *MachineDeploymentReconciler) reconcileBootstrapTemplateHash(ctx context.Context, d *clusterv1.MachineDeployment, bootstrapTemplate *unstructured.Unstructured, msList []*clusterv1.MachineSet) error {
+	if bootstrapTemplate == nil {
+		return nil
+	}
+
+	hash, err := computeBootstrapTemplateHash(bootstrapTemplate)
+	if err != nil {
+		return err
+	}
+
+	current, ok := d.Spec.Template.Annotations[clusterv1.BootstrapTemplateHashAnnotation]
+	if current == hash {
+		return nil
+	}
+
+	// If the MachineDeployment was created before the hash was tracked, record the hash on the MachineSet matching the
+	// machine template as well, so it is not rolled out.
+	if !ok {
+		if ms := mdutil.FindNewMachineSet(d, msList); ms != nil {
+			patchHelper, err := patch.NewHelper(ms, r.Client)
+			if err != nil {
+				return err
+			}
+			if ms.Spec.Template.Annotations == nil {
+				ms.Spec.Template.Annotations = map[string]string{}
+			}
+			ms.Spec.Template.Annotations[clusterv1.BootstrapTemplateHashAnnotation] = hash
+			if err := patchHelper.Patch(ctx, ms); err != nil {
+				return errors.Wrapf(err, "failed to set the bootstrap template hash on MachineSet %q", ms.Name)
+			}
+		}
+	}
+
+	if d.Spec.Template.Annotations == nil {
+		d.Spec.Template.Annotations = map[string]string{}
+	}
+	d.Spec.Template.Annotations[clusterv1.BootstrapTemplateHashAnnotation] = hash
+	return nil
+}
+
+// computeBootstrapTemplateHash returns a 32-bit FNV-1a hash of the json representation of spec.template of a bootstrap
+// template.
+func computeBootstrapTemplateHash(template *unstructured.Unstructured) (string, error) {
+	spec, _, err := unstructured.NestedFieldNoCopy(template.Object, "spec", "template")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read spec.template of bootstrap template %s %q", template.GetKind(), template.GetName())
+	}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to marshal bootstrap template %s %q", template.GetKind(), template.GetName())
+	}
+	hasher := fnv.New32a()
+	if _, err := hasher.Write(data); err != nil {
+		return "", errors.Wrapf(err, "failed to compute hash of bootstrap template %s %q", template.GetKind(), template.GetName())
+	}
+	return fmt.Sprintf("%d", hasher.Sum32()), nil
+}
+
 // getMachineSetsForDeployment returns a list of MachineSets associated with a MachineDeployment.
 func (r *MachineDeploymentReconciler) getMachineSetsForDeployment(ctx context.Context, d *clusterv1.MachineDeployment) ([]*clusterv1.MachineSet, error) {
 	log := ctrl.LoggerFrom(ctx)
@@ -34,6 +34,7 @@ import (
 
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/controllers/mdutil"
 	"sigs.k8s.io/cluster-api/util"
 )
 
@@ -723,3 +724,122 @@ func TestGetMachineSetsForDeployment(t *testing.T) {
 		})
 	}
 }
+
+func TestReconcileBootstrapTemplateHash(t *testing.T) {
+	bootstrapTemplate := func(command string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"kind":       "GenericBootstrapConfigTemplate",
+			"apiVersion": "bootstrap.cluster.x-k8s.io/v1alpha4",
+			"metadata": map[string]interface{}{
+				"name":      "md-bootstrap-template",
+				"namespace": "default",
+			},
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"preKubeadmCommands": []interface{}{command},
+					},
+				},
+			},
+		}}
+	}
+	v1, err := computeBootstrapTemplateHash(bootstrapTemplate("echo v1"))
+	NewWithT(t).Expect(err).NotTo(HaveOccurred())
+	v2, err := computeBootstrapTemplateHash(bootstrapTemplate("echo v2"))
+	NewWithT(t).Expect(err).NotTo(HaveOccurred())
+	NewWithT(t).Expect(v1).NotTo(Equal(v2))
+
+	// machineTemplate returns a machine template with the given bootstrap template hash, if any.
+	machineTemplate := func(hash string) clusterv1.MachineTemplateSpec {
+		template := clusterv1.MachineTemplateSpec{
+			Spec: clusterv1.MachineSpec{
+				ClusterName: "test-cluster",
+				Bootstrap: clusterv1.Bootstrap{
+					ConfigRef: external.GetObjectReference(bootstrapTemplate("echo v1")),
+				},
+			},
+		}
+		if hash != "" {
+			template.Annotations = map[string]string{clusterv1.BootstrapTemplateHashAnnotation: hash}
+		}
+		return template
+	}
+
+	testCases := []struct {
+		name                     string
+		bootstrapTemplate        *unstructured.Unstructured
+		deploymentHash           string
+		machineSetHash           string
+		expectedDeploymentHash   string
+		expectedMachineSetHash   string
+		expectNewMachineSetFound bool
+	}{
+		{
+			name:                     "does nothing without a bootstrap template",
+			expectNewMachineSetFound: true,
+		},
+		{
+			name:                     "records the hash on the MachineDeployment and on the MachineSet matching its machine template",
+			bootstrapTemplate:        bootstrapTemplate("echo v1"),
+			expectedDeploymentHash:   v1,
+			expectedMachineSetHash:   v1,
+			expectNewMachineSetFound: true,
+		},
+		{
+			name:                     "does nothing if the bootstrap template did not change",
+			bootstrapTemplate:        bootstrapTemplate("echo v1"),
+			deploymentHash:           v1,
+			machineSetHash:           v1,
+			expectedDeploymentHash:   v1,
+			expectedMachineSetHash:   v1,
+			expectNewMachineSetFound: true,
+		},
+		{
+			name:                     "rolls out a new MachineSet if the bootstrap template changed",
+			bootstrapTemplate:        bootstrapTemplate("echo v2"),
+			deploymentHash:           v1,
+			machineSetHash:           v1,
+			expectedDeploymentHash:   v2,
+			expectedMachineSetHash:   v1,
+			expectNewMachineSetFound: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+			md := &clusterv1.MachineDeployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "md", Namespace: "default"},
+				Spec: clusterv1.MachineDeploymentSpec{
+					ClusterName: "test-cluster",
+					Template:    machineTemplate(tc.deploymentHash),
+				},
+			}
+			ms := &clusterv1.MachineSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "ms", Namespace: "default"},
+				Spec: clusterv1.MachineSetSpec{
+					ClusterName: "test-cluster",
+					Template:    machineTemplate(tc.machineSetHash),
+				},
+			}
+
+			r := &MachineDeploymentReconciler{
+				Client:   fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(md, ms).Build(),
+				recorder: record.NewFakeRecorder(32),
+			}
+			msList := []*clusterv1.MachineSet{ms.DeepCopy()}
+			g.Expect(r.reconcileBootstrapTemplateHash(ctx, md, tc.bootstrapTemplate, msList)).To(Succeed())
+
+			g.Expect(md.Spec.Template.Annotations[clusterv1.BootstrapTemplateHashAnnotation]).To(Equal(tc.expectedDeploymentHash))
+
+			gotMS := &clusterv1.MachineSet{}
+			g.Expect(r.Client.Get(ctx, client.ObjectKeyFromObject(ms), gotMS)).To(Succeed())
+			g.Expect(gotMS.Spec.Template.Annotations[clusterv1.BootstrapTemplateHashAnnotation]).To(Equal(tc.expectedMachineSetHash))
+
+			g.Expect(mdutil.FindNewMachineSet(md, []*clusterv1.MachineSet{gotMS}) != nil).To(Equal(tc.expectNewMachineSetFound))
+		})
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/controllers/mdutil"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// getRollbackTo returns the revision requested by the rollback annotation, if any.
+func getRollbackTo(d *clusterv1.MachineDeployment) (string, bool) {
+	revision, ok := d.Annotations[clusterv1.RollbackToRevisionAnnotation]
+	return revision, ok
+}
+
+// rollback replaces the machine template of the MachineDeployment with the one of the MachineSet for the requested revision,
+// and removes the rollback annotation; the rollout to the restored template is then performed by the next reconcile.
+// Invalid or unknown revisions are reported with an event and the rollback request is dropped, given that retrying cannot fix them.
+func (r *MachineDeploymentReconciler) rollback(ctx context.Context, d *clusterv1.MachineDeployment, msList []*clusterv1.MachineSet) error {
+	log := ctrl.LoggerFrom(ctx)
+
+	value, _ := getRollbackTo(d)
+	delete(d.Annotations, clusterv1.RollbackToRevisionAnnotation)
+
+	toRevision, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || toRevision < 0 {
+		log.Info("Skipping rollback, invalid revision", "revision", value)
+		r.recorder.Eventf(d, corev1.EventTypeWarning, "RollbackRevisionNotFound", "Unable to rollback to revision %q: it must be a non-negative integer", value)
+		return nil
+	}
+
+	ms, err := mdutil.FindMachineSetForRevision(toRevision, msList)
+	if err != nil {
+		log.Info("Skipping rollback, revision not found", "revision", toRevision, "reason", err.Error())
+		r.recorder.Eventf(d, corev1.EventTypeWarning, "RollbackRevisionNotFound", "Unable to rollback to revision %d: %v", toRevision, err)
+		return nil
+	}
+
+	// Copy the template of the MachineSet into the MachineDeployment, excluding the hash label.
+	template := ms.Spec.Template.DeepCopy()
+	delete(template.Labels, mdutil.DefaultMachineDeploymentUniqueLabelKey)
+
+	if mdutil.EqualMachineTemplate(&d.Spec.Template, template) {
+		log.Info("Skipping rollback, the machine template is already the one of the requested revision", "machineset", ms.Name)
+		r.recorder.Eventf(d, corev1.EventTypeNormal, "RollbackTemplateUnchanged", "The rollback revision contains the same template as the current one")
+		return nil
+	}
+
+	log.Info("Rolling back to a previous revision", "machineset", ms.Name, "revision", ms.Annotations[clusterv1.RevisionAnnotation])
+	d.Spec.Template = *template
+	r.recorder.Eventf(d, corev1.EventTypeNormal, "RollbackDone", "Rolled back to the machine template of MachineSet %s, revision %s", ms.Name, ms.Annotations[clusterv1.RevisionAnnotation])
+	return nil
+}
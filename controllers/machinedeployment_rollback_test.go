@@ -0,0 +1,104 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/controllers/mdutil"
+)
+
+func TestMachineDeploymentRollback(t *testing.T) {
+	newMachineSet := func(name, revision, version string) *clusterv1.MachineSet {
+		return &clusterv1.MachineSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Annotations: map[string]string{clusterv1.RevisionAnnotation: revision},
+			},
+			Spec: clusterv1.MachineSetSpec{
+				Template: clusterv1.MachineTemplateSpec{
+					ObjectMeta: clusterv1.ObjectMeta{
+						Labels: map[string]string{"foo": "bar", mdutil.DefaultMachineDeploymentUniqueLabelKey: name},
+					},
+					Spec: clusterv1.MachineSpec{
+						Version: &version,
+					},
+				},
+			},
+		}
+	}
+	msList := []*clusterv1.MachineSet{
+		newMachineSet("ms-1", "1", "v1.19.1"),
+		newMachineSet("ms-2", "2", "v1.20.1"),
+	}
+
+	tests := []struct {
+		name        string
+		rollbackTo  string
+		wantVersion string
+	}{
+		{
+			name:        "rolls back to a specific revision",
+			rollbackTo:  "1",
+			wantVersion: "v1.19.1",
+		},
+		{
+			name:        "rolls back to the previous revision",
+			rollbackTo:  "0",
+			wantVersion: "v1.19.1",
+		},
+		{
+			name:        "ignores revisions which do not exist",
+			rollbackTo:  "3",
+			wantVersion: "v1.20.1",
+		},
+		{
+			name:        "ignores invalid revisions",
+			rollbackTo:  "foo",
+			wantVersion: "v1.20.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			d := &clusterv1.MachineDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "md",
+					Annotations: map[string]string{clusterv1.RollbackToRevisionAnnotation: tt.rollbackTo},
+				},
+				Spec: clusterv1.MachineDeploymentSpec{
+					Template: *msList[1].Spec.Template.DeepCopy(),
+				},
+			}
+			delete(d.Spec.Template.Labels, mdutil.DefaultMachineDeploymentUniqueLabelKey)
+
+			r := &MachineDeploymentReconciler{recorder: record.NewFakeRecorder(32)}
+			g.Expect(r.rollback(ctx, d, msList)).To(Succeed())
+
+			g.Expect(d.Annotations).NotTo(HaveKey(clusterv1.RollbackToRevisionAnnotation))
+			g.Expect(*d.Spec.Template.Spec.Version).To(Equal(tt.wantVersion))
+			g.Expect(d.Spec.Template.Labels).To(Equal(map[string]string{"foo": "bar"}))
+		})
+	}
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconcileOldMachineSetsOnDelete(t *testing.T) {
+	machineDeployment := func(replicas int32) *clusterv1.MachineDeployment {
+		return &clusterv1.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "foo",
+				Name:      "bar",
+			},
+			Spec: clusterv1.MachineDeploymentSpec{
+				Strategy: &clusterv1.MachineDeploymentStrategy{
+					Type: clusterv1.OnDeleteMachineDeploymentStrategyType,
+				},
+				Replicas: pointer.Int32Ptr(replicas),
+			},
+		}
+	}
+	machineSet := func(name string, replicas int32) *clusterv1.MachineSet {
+		return &clusterv1.MachineSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "foo",
+				Name:      name,
+			},
+			Spec: clusterv1.MachineSetSpec{
+				Replicas: pointer.Int32Ptr(replicas),
+				Selector: metav1.LabelSelector{
+					MatchLabels: map[string]string{"machine-set": name},
+				},
+			},
+		}
+	}
+	machines := func(ms *clusterv1.MachineSet, count, deleting int) []client.Object {
+		objs := []client.Object{}
+		for i := 0; i < count; i++ {
+			m := &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: ms.Namespace,
+					Name:      fmt.Sprintf("%s-%d", ms.Name, i),
+					Labels:    ms.Spec.Selector.MatchLabels,
+				},
+			}
+			if i < deleting {
+				m.DeletionTimestamp = &metav1.Time{Time: metav1.Now().Time}
+				m.Finalizers = []string{clusterv1.MachineFinalizer}
+			}
+			objs = append(objs, m)
+		}
+		return objs
+	}
+
+	testCases := []struct {
+		name                          string
+		machineDeployment             *clusterv1.MachineDeployment
+		newMachineSet                 *clusterv1.MachineSet
+		oldMachineSet                 *clusterv1.MachineSet
+		oldMachines                   int
+		deletingOldMachines           int
+		expectedOldMachineSetReplicas int32
+	}{
+		{
+			name:                          "Old MachineSet is not scaled down if no machines are deleted",
+			machineDeployment:             machineDeployment(2),
+			newMachineSet:                 machineSet("new", 0),
+			oldMachineSet:                 machineSet("old", 2),
+			oldMachines:                   2,
+			deletingOldMachines:           0,
+			expectedOldMachineSetReplicas: 2,
+		},
+		{
+			name:                          "Old MachineSet is scaled down for deleting machines",
+			machineDeployment:             machineDeployment(2),
+			newMachineSet:                 machineSet("new", 0),
+			oldMachineSet:                 machineSet("old", 2),
+			oldMachines:                   2,
+			deletingOldMachines:           1,
+			expectedOldMachineSetReplicas: 1,
+		},
+		{
+			name:                          "Old MachineSet is scaled down for machines already replaced by the new MachineSet",
+			machineDeployment:             machineDeployment(2),
+			newMachineSet:                 machineSet("new", 2),
+			oldMachineSet:                 machineSet("old", 1),
+			oldMachines:                   1,
+			deletingOldMachines:           0,
+			expectedOldMachineSetReplicas: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+			resources := []client.Object{
+				tc.machineDeployment,
+				tc.newMachineSet,
+				tc.oldMachineSet,
+			}
+			resources = append(resources, machines(tc.oldMachineSet, tc.oldMachines, tc.deletingOldMachines)...)
+
+			r := &MachineDeploymentReconciler{
+				Client:   fake.NewClientBuilder().WithObjects(resources...).Build(),
+				recorder: record.NewFakeRecorder(32),
+			}
+
+			oldMachineSets := []*clusterv1.MachineSet{tc.oldMachineSet}
+			allMachineSets := []*clusterv1.MachineSet{tc.oldMachineSet, tc.newMachineSet}
+			g.Expect(r.reconcileOldMachineSetsOnDelete(ctx, oldMachineSets, allMachineSets, tc.machineDeployment)).To(Succeed())
+
+			freshOldMachineSet := &clusterv1.MachineSet{}
+			g.Expect(r.Client.Get(ctx, client.ObjectKeyFromObject(tc.oldMachineSet), freshOldMachineSet)).To(Succeed())
+			g.Expect(*freshOldMachineSet.Spec.Replicas).To(Equal(tc.expectedOldMachineSetReplicas))
+			g.Expect(freshOldMachineSet.Annotations).To(HaveKey(clusterv1.DisableMachineCreate))
+		})
+	}
+}
+
+func TestReconcileNewMachineSetOnDelete(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	machineDeployment := &clusterv1.MachineDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "foo",
+			Name:      "bar",
+		},
+		Spec: clusterv1.MachineDeploymentSpec{
+			Strategy: &clusterv1.MachineDeploymentStrategy{
+				Type: clusterv1.OnDeleteMachineDeploymentStrategyType,
+			},
+			Replicas: pointer.Int32Ptr(3),
+		},
+	}
+	newMachineSet := &clusterv1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "foo",
+			Name:        "new",
+			Annotations: map[string]string{clusterv1.DisableMachineCreate: "true"},
+		},
+		Spec: clusterv1.MachineSetSpec{
+			Replicas: pointer.Int32Ptr(0),
+		},
+	}
+	oldMachineSet := &clusterv1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "foo",
+			Name:      "old",
+		},
+		Spec: clusterv1.MachineSetSpec{
+			Replicas: pointer.Int32Ptr(2),
+		},
+	}
+
+	r := &MachineDeploymentReconciler{
+		Client:   fake.NewClientBuilder().WithObjects(machineDeployment, newMachineSet, oldMachineSet).Build(),
+		recorder: record.NewFakeRecorder(32),
+	}
+
+	// The new MachineSet is scaled up only to replace the machines deleted from the old MachineSets.
+	allMachineSets := []*clusterv1.MachineSet{oldMachineSet, newMachineSet}
+	g.Expect(r.reconcileNewMachineSetOnDelete(ctx, allMachineSets, newMachineSet, machineDeployment)).To(Succeed())
+
+	freshNewMachineSet := &clusterv1.MachineSet{}
+	g.Expect(r.Client.Get(ctx, client.ObjectKeyFromObject(newMachineSet), freshNewMachineSet)).To(Succeed())
+	g.Expect(*freshNewMachineSet.Spec.Replicas).To(Equal(int32(1)))
+	g.Expect(freshNewMachineSet.Annotations).NotTo(HaveKey(clusterv1.DisableMachineCreate))
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"time"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
@@ -31,7 +32,9 @@ import (
 	"k8s.io/client-go/util/retry"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/controllers/mdutil"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -352,7 +355,23 @@ func (r *MachineDeploymentReconciler) scale(ctx context.Context, deployment *clu
 
 // syncDeploymentStatus checks if the status is up-to-date and sync it if necessary.
 func (r *MachineDeploymentReconciler) syncDeploymentStatus(allMSs []*clusterv1.MachineSet, newMS *clusterv1.MachineSet, d *clusterv1.MachineDeployment) error {
-	d.Status = calculateStatus(allMSs, newMS, d)
+	status := calculateStatus(allMSs, newMS, d)
+
+	// A rollout is completed when there were outdated Machines, and now all the Machines are updated and available.
+	if newMS != nil && d.Status.UpdatedReplicas < d.Status.Replicas && mdutil.DeploymentComplete(d, &status) {
+		metrics.RecordRollout(machineDeploymentKind.Kind, time.Since(newMS.CreationTimestamp.Time))
+	}
+
+	d.Status = status
+
+	// The MachineDeployment is available if it has at least the minimum number of available Machines
+	// allowed by its rollout strategy.
+	if minAvailable := *(d.Spec.Replicas) - mdutil.MaxUnavailable(*d); status.AvailableReplicas >= minAvailable {
+		conditions.MarkTrue(d, clusterv1.MachineDeploymentAvailableCondition)
+	} else {
+		conditions.MarkFalse(d, clusterv1.MachineDeploymentAvailableCondition, clusterv1.WaitingForAvailableMachinesReason, clusterv1.ConditionSeverityWarning,
+			"Minimum availability requires %d replicas, current %d available", minAvailable, status.AvailableReplicas)
+	}
 	return nil
 }
 
@@ -380,6 +399,7 @@ func calculateStatus(allMSs []*clusterv1.MachineSet, newMS *clusterv1.MachineSet
 		ReadyReplicas:       mdutil.GetReadyReplicaCountForMachineSets(allMSs),
 		AvailableReplicas:   availableReplicas,
 		UnavailableReplicas: unavailableReplicas,
+		Conditions:          deployment.Status.Conditions,
 	}
 
 	if *deployment.Spec.Replicas == status.ReadyReplicas {
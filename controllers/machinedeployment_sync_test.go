@@ -20,10 +20,13 @@ import (
 	"testing"
 
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/cluster-api/util/conditions"
 )
 
 func TestCalculateStatus(t *testing.T) {
@@ -219,3 +222,65 @@ func TestCalculateStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestSyncDeploymentStatusAvailableCondition(t *testing.T) {
+	machineSet := func(available int32) *clusterv1.MachineSet {
+		return &clusterv1.MachineSet{
+			Spec: clusterv1.MachineSetSpec{
+				Replicas: pointer.Int32Ptr(3),
+			},
+			Status: clusterv1.MachineSetStatus{
+				AvailableReplicas: available,
+				ReadyReplicas:     available,
+				Replicas:          3,
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		machineSet *clusterv1.MachineSet
+		wantStatus corev1.ConditionStatus
+	}{
+		{
+			name:       "available if all the machines are available",
+			machineSet: machineSet(3),
+			wantStatus: corev1.ConditionTrue,
+		},
+		{
+			name:       "available if the unavailable machines are within maxUnavailable",
+			machineSet: machineSet(2),
+			wantStatus: corev1.ConditionTrue,
+		},
+		{
+			name:       "not available if the unavailable machines exceed maxUnavailable",
+			machineSet: machineSet(1),
+			wantStatus: corev1.ConditionFalse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			maxUnavailable := intstr.FromInt(1)
+			maxSurge := intstr.FromInt(0)
+			d := &clusterv1.MachineDeployment{
+				Spec: clusterv1.MachineDeploymentSpec{
+					Replicas: pointer.Int32Ptr(3),
+					Strategy: &clusterv1.MachineDeploymentStrategy{
+						Type: clusterv1.RollingUpdateMachineDeploymentStrategyType,
+						RollingUpdate: &clusterv1.MachineRollingUpdateDeployment{
+							MaxUnavailable: &maxUnavailable,
+							MaxSurge:       &maxSurge,
+						},
+					},
+				},
+			}
+
+			r := &MachineDeploymentReconciler{}
+			g.Expect(r.syncDeploymentStatus([]*clusterv1.MachineSet{tt.machineSet}, tt.machineSet, d)).To(Succeed())
+			g.Expect(conditions.Get(d, clusterv1.MachineDeploymentAvailableCondition).Status).To(Equal(tt.wantStatus))
+		})
+	}
+}
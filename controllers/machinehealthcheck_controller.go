@@ -37,6 +37,7 @@ import (
 	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
 	"sigs.k8s.io/cluster-api/controllers/remote"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
@@ -83,7 +84,7 @@ func (r *MachineHealthCheckReconciler) SetupWithManager(ctx context.Context, mgr
 		).
 		WithOptions(options).
 		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
-		Build(r)
+		Build(metrics.NewInstrumentedReconciler("machinehealthcheck", r))
 	if err != nil {
 		return errors.Wrap(err, "failed setting up with a controller manager")
 	}
@@ -212,6 +213,7 @@ func (r *MachineHealthCheckReconciler) reconcile(ctx context.Context, logger log
 	// health check all targets and reconcile mhc status
 	healthy, unhealthy, nextCheckTimes := r.healthCheckTargets(targets, logger, m.Spec.NodeStartupTimeout.Duration)
 	m.Status.CurrentHealthy = int32(len(healthy))
+	m.Status.StartingMachines, m.Status.RestartingMachines = countPendingTargets(targets)
 
 	var unhealthyLimitKey, unhealthyLimitValue interface{}
 
@@ -287,8 +289,15 @@ func (r *MachineHealthCheckReconciler) reconcile(ctx context.Context, logger log
 	m.Status.RemediationsAllowed = remediationCount
 	conditions.MarkTrue(m, clusterv1.RemediationAllowedCondition)
 
+	// check the health of each failure domain against MaxUnhealthyPerFailureDomain
+	unhealthy, restricted, err := filterUnhealthyTargetsPerFailureDomain(m, targets, healthy, unhealthy)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "error checking if remediation is allowed per failure domain")
+	}
+
 	errList := r.PatchUnhealthyTargets(ctx, logger, unhealthy, cluster, m)
 	errList = append(errList, r.PatchHealthyTargets(ctx, logger, healthy, cluster, m)...)
+	errList = append(errList, r.PatchRestrictedTargets(ctx, logger, restricted, m)...)
 
 	// handle update errors
 	if len(errList) > 0 {
@@ -337,6 +346,28 @@ func (r *MachineHealthCheckReconciler) PatchHealthyTargets(ctx context.Context,
 	return errList
 }
 
+// PatchRestrictedTargets patches unhealthy machines which can't be remediated because
+// the number of unhealthy machines in their failure domain exceeds MaxUnhealthyPerFailureDomain.
+func (r *MachineHealthCheckReconciler) PatchRestrictedTargets(ctx context.Context, logger logr.Logger, restricted []healthCheckTarget, m *clusterv1.MachineHealthCheck) []error {
+	errList := []error{}
+	for _, t := range restricted {
+		logger.V(3).Info("Short-circuiting remediation for failure domain", "target", t.string(), "failure domain", machineFailureDomain(t.Machine), "max unhealthy per failure domain", m.Spec.MaxUnhealthyPerFailureDomain)
+		r.recorder.Eventf(
+			m,
+			corev1.EventTypeWarning,
+			EventRemediationRestricted,
+			"Remediation of machine %v is not allowed, the number of not started or unhealthy machines in failure domain %q exceeds maxUnhealthyPerFailureDomain (maxUnhealthyPerFailureDomain: %v)",
+			t.Machine.Name,
+			machineFailureDomain(t.Machine),
+			m.Spec.MaxUnhealthyPerFailureDomain,
+		)
+		if err := t.patchHelper.Patch(ctx, t.Machine); err != nil {
+			errList = append(errList, errors.Wrapf(err, "failed to patch machine status for machine: %s/%s", t.Machine.Namespace, t.Machine.Name))
+		}
+	}
+	return errList
+}
+
 // PatchUnhealthyTargets patches machines with MachineOwnerRemediatedCondition for remediation.
 func (r *MachineHealthCheckReconciler) PatchUnhealthyTargets(ctx context.Context, logger logr.Logger, unhealthy []healthCheckTarget, cluster *clusterv1.Cluster, m *clusterv1.MachineHealthCheck) []error {
 	// mark for remediation
@@ -396,6 +427,7 @@ func (r *MachineHealthCheckReconciler) PatchUnhealthyTargets(ctx context.Context
 					errList = append(errList, errors.Wrapf(err, "error creating remediation request for machine %q in namespace %q within cluster %q", t.Machine.Name, t.Machine.Namespace, t.Machine.ClusterName))
 					return errList
 				}
+				metrics.RecordRemediation(metrics.ExternalRemediation)
 			} else {
 				logger.Info("Target has failed health check, marking for remediation", "target", t.string(), "reason", condition.Reason, "message", condition.Message)
 				// NOTE: MHC is responsible for creating MachineOwnerRemediatedCondition if missing or to trigger another remediation if the previous one is completed;
@@ -597,6 +629,49 @@ func getMaxUnhealthy(mhc *clusterv1.MachineHealthCheck) (int, error) {
 	return maxUnhealthy, nil
 }
 
+// filterUnhealthyTargetsPerFailureDomain splits the unhealthy targets into the targets that can be remediated
+// and the targets that can't be remediated because the number of not started or unhealthy machines in their
+// failure domain exceeds MaxUnhealthyPerFailureDomain.
+// Percentages are calculated on the number of targets in each failure domain.
+func filterUnhealthyTargetsPerFailureDomain(mhc *clusterv1.MachineHealthCheck, targets, healthy, unhealthy []healthCheckTarget) ([]healthCheckTarget, []healthCheckTarget, error) {
+	if mhc.Spec.MaxUnhealthyPerFailureDomain == nil {
+		return unhealthy, nil, nil
+	}
+
+	total := map[string]int{}
+	for _, t := range targets {
+		total[machineFailureDomain(t.Machine)]++
+	}
+	currentHealthy := map[string]int{}
+	for _, t := range healthy {
+		currentHealthy[machineFailureDomain(t.Machine)]++
+	}
+
+	var allowed, restricted []healthCheckTarget
+	for _, t := range unhealthy {
+		failureDomain := machineFailureDomain(t.Machine)
+		maxUnhealthy, err := intstr.GetValueFromIntOrPercent(mhc.Spec.MaxUnhealthyPerFailureDomain, total[failureDomain], false)
+		if err != nil {
+			return nil, nil, err
+		}
+		if total[failureDomain]-currentHealthy[failureDomain] > maxUnhealthy {
+			restricted = append(restricted, t)
+			continue
+		}
+		allowed = append(allowed, t)
+	}
+	return allowed, restricted, nil
+}
+
+// machineFailureDomain returns the failure domain of a machine, or an empty string if the machine
+// does not have a failure domain.
+func machineFailureDomain(m *clusterv1.Machine) string {
+	if m.Spec.FailureDomain == nil {
+		return ""
+	}
+	return *m.Spec.FailureDomain
+}
+
 // unhealthyMachineCount calculates the number of presently unhealthy or missing machines
 // ie the delta between the expected number of machines and the current number deemed healthy.
 func unhealthyMachineCount(mhc *clusterv1.MachineHealthCheck) int {
@@ -2232,6 +2232,65 @@ func TestGetMaxUnhealthy(t *testing.T) {
 	}
 }
 
+func TestFilterUnhealthyTargetsPerFailureDomain(t *testing.T) {
+	target := func(name, failureDomain string) healthCheckTarget {
+		m := newTestMachine(name, "default", "test-cluster", name, map[string]string{})
+		if failureDomain != "" {
+			m.Spec.FailureDomain = pointer.StringPtr(failureDomain)
+		}
+		return healthCheckTarget{Machine: m}
+	}
+	fd1Healthy, fd1Unhealthy := target("fd1-healthy", "fd1"), target("fd1-unhealthy", "fd1")
+	fd2Unhealthy1, fd2Unhealthy2 := target("fd2-unhealthy-1", "fd2"), target("fd2-unhealthy-2", "fd2")
+	noFDUnhealthy := target("no-fd-unhealthy", "")
+
+	targets := []healthCheckTarget{fd1Healthy, fd1Unhealthy, fd2Unhealthy1, fd2Unhealthy2, noFDUnhealthy}
+	healthy := []healthCheckTarget{fd1Healthy}
+	unhealthy := []healthCheckTarget{fd1Unhealthy, fd2Unhealthy1, fd2Unhealthy2, noFDUnhealthy}
+
+	testCases := []struct {
+		name               string
+		maxUnhealthy       *intstr.IntOrString
+		expectedAllowed    []healthCheckTarget
+		expectedRestricted []healthCheckTarget
+	}{
+		{
+			name:            "when maxUnhealthyPerFailureDomain is nil",
+			maxUnhealthy:    nil,
+			expectedAllowed: unhealthy,
+		},
+		{
+			name:               "when maxUnhealthyPerFailureDomain is an int",
+			maxUnhealthy:       &intstr.IntOrString{Type: intstr.Int, IntVal: 1},
+			expectedAllowed:    []healthCheckTarget{fd1Unhealthy, noFDUnhealthy},
+			expectedRestricted: []healthCheckTarget{fd2Unhealthy1, fd2Unhealthy2},
+		},
+		{
+			name:               "when maxUnhealthyPerFailureDomain is a percentage of the machines in the failure domain",
+			maxUnhealthy:       &intstr.IntOrString{Type: intstr.String, StrVal: "50%"},
+			expectedAllowed:    []healthCheckTarget{fd1Unhealthy},
+			expectedRestricted: []healthCheckTarget{fd2Unhealthy1, fd2Unhealthy2, noFDUnhealthy},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			mhc := &clusterv1.MachineHealthCheck{
+				Spec: clusterv1.MachineHealthCheckSpec{
+					MaxUnhealthyPerFailureDomain: tc.maxUnhealthy,
+				},
+			}
+
+			allowed, restricted, err := filterUnhealthyTargetsPerFailureDomain(mhc, targets, healthy, unhealthy)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(allowed).To(Equal(tc.expectedAllowed))
+			g.Expect(restricted).To(Equal(tc.expectedRestricted))
+		})
+	}
+}
+
 func ownerReferenceForCluster(ctx context.Context, g *WithT, c *clusterv1.Cluster) metav1.OwnerReference {
 	// Fetch the cluster to populate the UID
 	cc := &clusterv1.Cluster{}
@@ -26,8 +26,11 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
@@ -47,12 +50,20 @@ const (
 // healthCheckTarget contains the information required to perform a health check
 // on the node to determine if any remediation is required.
 type healthCheckTarget struct {
-	Cluster     *clusterv1.Cluster
-	Machine     *clusterv1.Machine
-	Node        *corev1.Node
-	MHC         *clusterv1.MachineHealthCheck
-	patchHelper *patch.Helper
-	nodeMissing bool
+	Cluster      *clusterv1.Cluster
+	Machine      *clusterv1.Machine
+	Node         *corev1.Node
+	InfraMachine *unstructured.Unstructured
+	MHC          *clusterv1.MachineHealthCheck
+	patchHelper  *patch.Helper
+	nodeMissing  bool
+
+	// startupPending is set by needsRemediation when the Machine has no Node, but the node startup timeout
+	// has not expired yet.
+	startupPending bool
+	// restartPending is set by needsRemediation when the Node became unhealthy after having been healthy,
+	// but the node restart timeout has not expired yet.
+	restartPending bool
 }
 
 func (t *healthCheckTarget) string() string {
@@ -77,7 +88,9 @@ func (t *healthCheckTarget) nodeName() string {
 // - The Machine has failed for some reason
 // - The Machine did not get a node before `timeoutForMachineToHaveNode` elapses
 // - The Node has gone away
-// - Any condition on the node is matched for the given timeout
+// - Any condition on the node, the machine or the infrastructure machine is matched for the given timeout
+// Conditions on a node which was healthy before, e.g. a node rebooted or under maintenance, are matched
+// for the node restart timeout instead, if set.
 // If the target doesn't currently need rememdiation, provide a duration after
 // which the target should next be checked.
 // The target should be requeued after this duration.
@@ -85,6 +98,10 @@ func (t *healthCheckTarget) needsRemediation(logger logr.Logger, timeoutForMachi
 	var nextCheckTimes []time.Duration
 	now := time.Now()
 
+	// The MachineHealthCheckSucceeded condition is left untouched while a target is likely to go unhealthy,
+	// so it is true only for machines that have been healthy before.
+	previouslyHealthy := conditions.IsTrue(t.Machine, clusterv1.MachineHealthCheckSuccededCondition)
+
 	if t.Machine.Status.FailureReason != nil {
 		conditions.MarkFalse(t.Machine, clusterv1.MachineHealthCheckSuccededCondition, clusterv1.MachineHasFailureReason, clusterv1.ConditionSeverityWarning, "FailureReason: %v", t.Machine.Status.FailureReason)
 		logger.V(3).Info("Target is unhealthy", "failureReason", t.Machine.Status.FailureReason)
@@ -141,39 +158,100 @@ func (t *healthCheckTarget) needsRemediation(logger logr.Logger, timeoutForMachi
 			return true, time.Duration(0)
 		}
 
+		// Conditions reported by the machine or by the infrastructure machine
+		// are checked even if the node has not been set yet.
 		durationUnhealthy := now.Sub(comparisonTime)
-		nextCheck := timeoutForMachineToHaveNode - durationUnhealthy + time.Second
-
-		return false, nextCheck
+		nextCheckTimes = append(nextCheckTimes, timeoutForMachineToHaveNode-durationUnhealthy+time.Second)
+		t.startupPending = true
 	}
 
 	// check conditions
 	for _, c := range t.MHC.Spec.UnhealthyConditions {
-		nodeCondition := getNodeCondition(t.Node, c.Type)
+		status, lastTransitionTime, found := t.getCondition(c)
 
-		// Skip when current node condition is different from the one reported
+		// Skip when current condition is different from the one reported
 		// in the MachineHealthCheck.
-		if nodeCondition == nil || nodeCondition.Status != c.Status {
+		if !found || status != c.Status {
 			continue
 		}
 
+		// Nodes which were healthy before get the node restart timeout to recover, if set.
+		timeout := c.Timeout.Duration
+		restarting := false
+		if conditionSource(c) == clusterv1.NodeUnhealthyConditionSource && previouslyHealthy && t.MHC.Spec.NodeRestartTimeout != nil {
+			timeout = t.MHC.Spec.NodeRestartTimeout.Duration
+			restarting = true
+		}
+
 		// If the condition has been in the unhealthy state for longer than the
 		// timeout, return true with no requeue time.
-		if nodeCondition.LastTransitionTime.Add(c.Timeout.Duration).Before(now) {
-			conditions.MarkFalse(t.Machine, clusterv1.MachineHealthCheckSuccededCondition, clusterv1.UnhealthyNodeConditionReason, clusterv1.ConditionSeverityWarning, "Condition %s on node is reporting status %s for more than %s", c.Type, c.Status, c.Timeout.Duration.String())
-			logger.V(3).Info("Target is unhealthy: condition is in state longer than allowed timeout", "condition", c.Type, "state", c.Status, "timeout", c.Timeout.Duration.String())
+		if lastTransitionTime.Add(timeout).Before(now) {
+			reason := clusterv1.UnhealthyNodeConditionReason
+			if conditionSource(c) != clusterv1.NodeUnhealthyConditionSource {
+				reason = clusterv1.UnhealthyMachineConditionReason
+			}
+			conditions.MarkFalse(t.Machine, clusterv1.MachineHealthCheckSuccededCondition, reason, clusterv1.ConditionSeverityWarning, "Condition %s on %s is reporting status %s for more than %s", c.Type, conditionSource(c), c.Status, timeout.String())
+			logger.V(3).Info("Target is unhealthy: condition is in state longer than allowed timeout", "source", conditionSource(c), "condition", c.Type, "state", c.Status, "timeout", timeout.String(), "restarting", restarting)
 			return true, time.Duration(0)
 		}
 
-		durationUnhealthy := now.Sub(nodeCondition.LastTransitionTime.Time)
-		nextCheck := c.Timeout.Duration - durationUnhealthy + time.Second
+		durationUnhealthy := now.Sub(lastTransitionTime)
+		nextCheck := timeout - durationUnhealthy + time.Second
 		if nextCheck > 0 {
 			nextCheckTimes = append(nextCheckTimes, nextCheck)
+			if restarting {
+				t.restartPending = true
+			}
 		}
 	}
 	return false, minDuration(nextCheckTimes)
 }
 
+// getCondition returns the status and the last transition time of an unhealthy condition,
+// looking it up on the object defined by the condition source.
+func (t *healthCheckTarget) getCondition(c clusterv1.UnhealthyCondition) (corev1.ConditionStatus, time.Time, bool) {
+	switch conditionSource(c) {
+	case clusterv1.MachineUnhealthyConditionSource:
+		if condition := conditions.Get(t.Machine, clusterv1.ConditionType(c.Type)); condition != nil {
+			return condition.Status, condition.LastTransitionTime.Time, true
+		}
+	case clusterv1.InfrastructureMachineUnhealthyConditionSource:
+		if t.InfraMachine == nil {
+			return "", time.Time{}, false
+		}
+		if condition := conditions.Get(conditions.UnstructuredGetter(t.InfraMachine), clusterv1.ConditionType(c.Type)); condition != nil {
+			return condition.Status, condition.LastTransitionTime.Time, true
+		}
+	default:
+		if t.Node == nil {
+			return "", time.Time{}, false
+		}
+		if condition := getNodeCondition(t.Node, c.Type); condition != nil {
+			return condition.Status, condition.LastTransitionTime.Time, true
+		}
+	}
+	return "", time.Time{}, false
+}
+
+// conditionSource returns the source of an unhealthy condition, defaulting to the Node.
+func conditionSource(c clusterv1.UnhealthyCondition) clusterv1.UnhealthyConditionSource {
+	if c.Source == "" {
+		return clusterv1.NodeUnhealthyConditionSource
+	}
+	return c.Source
+}
+
+// hasInfrastructureMachineConditions returns true if the MachineHealthCheck
+// checks conditions reported by infrastructure machines.
+func hasInfrastructureMachineConditions(mhc *clusterv1.MachineHealthCheck) bool {
+	for _, c := range mhc.Spec.UnhealthyConditions {
+		if conditionSource(c) == clusterv1.InfrastructureMachineUnhealthyConditionSource {
+			return true
+		}
+	}
+	return false
+}
+
 // getTargetsFromMHC uses the MachineHealthCheck's selector to fetch machines
 // and their nodes targeted by the health check, ready for health checking.
 func (r *MachineHealthCheckReconciler) getTargetsFromMHC(ctx context.Context, logger logr.Logger, clusterClient client.Reader, cluster *clusterv1.Cluster, mhc *clusterv1.MachineHealthCheck) ([]healthCheckTarget, error) {
@@ -213,6 +291,23 @@ func (r *MachineHealthCheckReconciler) getTargetsFromMHC(ctx context.Context, lo
 			target.nodeMissing = true
 		}
 		target.Node = node
+
+		// Machines running on interruptible Nodes going to be terminated are deleted by the Machine controller,
+		// so they are not remediated nor they are counted as unhealthy. Control plane Machines are not deleted
+		// by the Machine controller, so they are left to remediation, which is handled by the control plane provider.
+		if isInterruptibleNodeTerminating(node) && !util.IsControlPlaneMachine(target.Machine) {
+			logger.Info("skipping remediation", "machine", target.Machine.Name, "reason", "interruptible node is going to be terminated")
+			continue
+		}
+
+		// Fetch the infrastructure machine only if there are conditions to check on it.
+		if hasInfrastructureMachineConditions(mhc) {
+			infraMachine, err := external.Get(ctx, r.Client, &target.Machine.Spec.InfrastructureRef, target.Machine.Namespace)
+			if err != nil && !apierrors.IsNotFound(err) {
+				return nil, errors.Wrap(err, "error getting infrastructure machine")
+			}
+			target.InfraMachine = infraMachine
+		}
 		targets = append(targets, target)
 	}
 	return targets, nil
@@ -266,13 +361,16 @@ func (r *MachineHealthCheckReconciler) healthCheckTargets(targets []healthCheckT
 	var unhealthy []healthCheckTarget
 	var healthy []healthCheckTarget
 
-	for _, t := range targets {
+	for i := range targets {
+		t := &targets[i]
 		logger = logger.WithValues("Target", t.string())
 		logger.V(3).Info("Health checking target")
 		needsRemediation, nextCheck := t.needsRemediation(logger, timeoutForMachineToHaveNode)
 
 		if needsRemediation {
-			unhealthy = append(unhealthy, t)
+			// Unhealthy targets are not pending anymore, even if a timeout had not expired yet when checking them.
+			t.startupPending, t.restartPending = false, false
+			unhealthy = append(unhealthy, *t)
 			continue
 		}
 
@@ -292,12 +390,26 @@ func (r *MachineHealthCheckReconciler) healthCheckTargets(targets []healthCheckT
 
 		if t.Machine.DeletionTimestamp.IsZero() {
 			conditions.MarkTrue(t.Machine, clusterv1.MachineHealthCheckSuccededCondition)
-			healthy = append(healthy, t)
+			healthy = append(healthy, *t)
 		}
 	}
 	return healthy, unhealthy, nextCheckTimes
 }
 
+// countPendingTargets returns the number of targets without a Node within the node startup timeout, and the number of
+// targets with a Node recovering within the node restart timeout, as recorded by healthCheckTargets.
+func countPendingTargets(targets []healthCheckTarget) (starting, restarting int32) {
+	for _, t := range targets {
+		if t.startupPending {
+			starting++
+		}
+		if t.restartPending {
+			restarting++
+		}
+	}
+	return starting, restarting
+}
+
 // getNodeCondition returns node condition by type.
 func getNodeCondition(node *corev1.Node, conditionType corev1.NodeConditionType) *corev1.NodeCondition {
 	for _, cond := range node.Status.Conditions {
@@ -334,5 +446,9 @@ func shouldSkipRemediation(m *clusterv1.Machine) (bool, string) {
 		return true, fmt.Sprintf("machine has %q annotation", clusterv1.MachineSkipRemediationAnnotation)
 	}
 
+	if conditions.GetReason(m, clusterv1.InfrastructureHealthyCondition) == clusterv1.InfrastructureLeftForInspectionReason {
+		return true, "machine infrastructure has been left for inspection"
+	}
+
 	return false, ""
 }
@@ -23,6 +23,7 @@ import (
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
@@ -90,6 +91,18 @@ func TestGetTargetsFromMHC(t *testing.T) {
 	testNode6 := newTestNode("node6")
 	testMachine6 := newTestMachine("machine6", namespace, clusterName, testNode6.Name, mhcSelector)
 	testMachine6.Annotations = map[string]string{"cluster.x-k8s.io/paused": ""}
+	testMachine8 := newTestMachine("machine8", namespace, clusterName, "node8", mhcSelector)
+	conditions.MarkFalse(testMachine8, clusterv1.InfrastructureHealthyCondition, clusterv1.InfrastructureLeftForInspectionReason, clusterv1.ConditionSeverityError, "")
+
+	// machine running on an interruptible node going to be terminated
+	testNode7 := newTestUnhealthyNode("node7", clusterv1.NodeTerminationRequestedCondition, corev1.ConditionTrue, time.Minute)
+	testNode7.Labels = map[string]string{clusterv1.InterruptibleLabel: ""}
+	testMachine7 := newTestMachine("machine7", namespace, clusterName, testNode7.Name, mhcSelector)
+	testNode9 := newTestNode("node9")
+	testNode9.Labels = map[string]string{clusterv1.InterruptibleLabel: ""}
+	testNode9.Status.Conditions = []corev1.NodeCondition{{Type: clusterv1.NodeTerminationRequestedCondition, Status: corev1.ConditionTrue}}
+	testMachine9 := newTestMachine("machine9", namespace, clusterName, testNode9.Name, mhcSelector)
+	testMachine9.Labels[clusterv1.MachineControlPlaneLabelName] = ""
 
 	testCases := []struct {
 		desc            string
@@ -141,8 +154,8 @@ func TestGetTargetsFromMHC(t *testing.T) {
 			},
 		},
 		{
-			desc:     "with machines having skip-remediation or paused annotation",
-			toCreate: append(baseObjects, testNode1, testMachine1, testMachine5, testMachine6),
+			desc:     "with machines having skip-remediation or paused annotation, or left for inspection",
+			toCreate: append(baseObjects, testNode1, testMachine1, testMachine5, testMachine6, testMachine8),
 			expectedTargets: []healthCheckTarget{
 				{
 					Machine: testMachine1,
@@ -151,6 +164,28 @@ func TestGetTargetsFromMHC(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc:     "with machines running on interruptible nodes going to be terminated",
+			toCreate: append(baseObjects, testNode1, testMachine1, testNode7, testMachine7),
+			expectedTargets: []healthCheckTarget{
+				{
+					Machine: testMachine1,
+					MHC:     testMHC,
+					Node:    testNode1,
+				},
+			},
+		},
+		{
+			desc:     "with control plane machines running on interruptible nodes going to be terminated",
+			toCreate: append(baseObjects, testNode9, testMachine9),
+			expectedTargets: []healthCheckTarget{
+				{
+					Machine: testMachine9,
+					MHC:     testMHC,
+					Node:    testNode9,
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -291,12 +326,99 @@ func TestHealthCheckTargets(t *testing.T) {
 		nodeMissing: false,
 	}
 
+	// MHC checking conditions reported by the machine and by the infrastructure machine
+	testProviderConditionsMHC := testMHC.DeepCopy()
+	testProviderConditionsMHC.Spec.UnhealthyConditions = []clusterv1.UnhealthyCondition{
+		{
+			Type:    "InstanceReady",
+			Status:  corev1.ConditionFalse,
+			Timeout: metav1.Duration{Duration: 5 * time.Minute},
+			Source:  clusterv1.InfrastructureMachineUnhealthyConditionSource,
+		},
+		{
+			Type:    corev1.NodeConditionType(clusterv1.BootstrapReadyCondition),
+			Status:  corev1.ConditionFalse,
+			Timeout: metav1.Duration{Duration: 5 * time.Minute},
+			Source:  clusterv1.MachineUnhealthyConditionSource,
+		},
+	}
+
+	// Target for when the infrastructure machine has been reporting an unhealthy condition for longer than the timeout,
+	// even if the node has not yet started
+	testInfraMachineUnhealthy400 := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	conditions.UnstructuredSetter(testInfraMachineUnhealthy400).SetConditions(clusterv1.Conditions{
+		{
+			Type:               "InstanceReady",
+			Status:             corev1.ConditionFalse,
+			LastTransitionTime: metav1.NewTime(time.Now().Add(-400 * time.Second)),
+		},
+	})
+	infraMachineUnhealthy400 := healthCheckTarget{
+		Cluster:      cluster,
+		MHC:          testProviderConditionsMHC,
+		Machine:      testMachineLastUpdated400s,
+		InfraMachine: testInfraMachineUnhealthy400,
+		Node:         nil,
+	}
+
+	// Target for when the machine has been reporting an unhealthy condition for shorter than the timeout
+	testMachineUnhealthy200 := testMachine.DeepCopy()
+	testMachineUnhealthy200.Status.Conditions = clusterv1.Conditions{
+		{
+			Type:               clusterv1.BootstrapReadyCondition,
+			Status:             corev1.ConditionFalse,
+			LastTransitionTime: metav1.NewTime(time.Now().Add(-200 * time.Second)),
+		},
+	}
+	machineUnhealthy200 := healthCheckTarget{
+		Cluster:     cluster,
+		MHC:         testProviderConditionsMHC,
+		Machine:     testMachineUnhealthy200,
+		Node:        testNodeHealthy,
+		nodeMissing: false,
+	}
+
+	// MHC giving nodes which were healthy before more time to recover, e.g. after a reboot
+	testNodeRestartMHC := testMHC.DeepCopy()
+	testNodeRestartMHC.Spec.NodeRestartTimeout = &metav1.Duration{Duration: 10 * time.Minute}
+
+	testMachinePreviouslyHealthy := newTestMachine("machine2", namespace, clusterName, "node2", mhcSelector)
+	conditions.MarkTrue(testMachinePreviouslyHealthy, clusterv1.MachineHealthCheckSuccededCondition)
+
+	// Target for when a node which was healthy before has been in an unknown state for longer than the
+	// unhealthy condition timeout, but shorter than the node restart timeout
+	nodeRestarting400 := healthCheckTarget{
+		Cluster: cluster,
+		MHC:     testNodeRestartMHC,
+		Machine: testMachinePreviouslyHealthy.DeepCopy(),
+		Node:    newTestUnhealthyNode("node2", corev1.NodeReady, corev1.ConditionUnknown, 400*time.Second),
+	}
+
+	// Target for when a node which was healthy before has been in an unknown state for longer than the node restart timeout
+	nodeRestarting700 := healthCheckTarget{
+		Cluster: cluster,
+		MHC:     testNodeRestartMHC,
+		Machine: testMachinePreviouslyHealthy.DeepCopy(),
+		Node:    newTestUnhealthyNode("node2", corev1.NodeReady, corev1.ConditionUnknown, 700*time.Second),
+	}
+
+	// Target for when a node which was never healthy has been in an unknown state for longer than the
+	// unhealthy condition timeout, but shorter than the node restart timeout
+	nodeNeverHealthy400 := healthCheckTarget{
+		Cluster: cluster,
+		MHC:     testNodeRestartMHC,
+		Machine: newTestMachine("machine3", namespace, clusterName, "node3", mhcSelector),
+		Node:    newTestUnhealthyNode("node3", corev1.NodeReady, corev1.ConditionUnknown, 400*time.Second),
+	}
+
 	testCases := []struct {
 		desc                     string
 		targets                  []healthCheckTarget
 		expectedHealthy          []healthCheckTarget
 		expectedNeedsRemediation []healthCheckTarget
 		expectedNextCheckTimes   []time.Duration
+		expectedStarting         int32
+		expectedRestarting       int32
 	}{
 		{
 			desc:                     "when the node has not yet started",
@@ -304,6 +426,7 @@ func TestHealthCheckTargets(t *testing.T) {
 			expectedHealthy:          []healthCheckTarget{},
 			expectedNeedsRemediation: []healthCheckTarget{},
 			expectedNextCheckTimes:   []time.Duration{timeoutForMachineToHaveNode},
+			expectedStarting:         1,
 		},
 		{
 			desc:                     "when the node has gone away",
@@ -340,6 +463,50 @@ func TestHealthCheckTargets(t *testing.T) {
 			expectedNeedsRemediation: []healthCheckTarget{nodeUnknown400},
 			expectedNextCheckTimes:   []time.Duration{200 * time.Second, 100 * time.Second},
 		},
+		{
+			desc:                     "when the infrastructure machine has been reporting an unhealthy condition for longer than the timeout",
+			targets:                  []healthCheckTarget{infraMachineUnhealthy400},
+			expectedHealthy:          []healthCheckTarget{},
+			expectedNeedsRemediation: []healthCheckTarget{infraMachineUnhealthy400},
+			expectedNextCheckTimes:   []time.Duration{},
+		},
+		{
+			desc:                     "when the machine has been reporting an unhealthy condition for shorter than the timeout",
+			targets:                  []healthCheckTarget{machineUnhealthy200},
+			expectedHealthy:          []healthCheckTarget{},
+			expectedNeedsRemediation: []healthCheckTarget{},
+			expectedNextCheckTimes:   []time.Duration{100 * time.Second},
+		},
+		{
+			desc:                     "when a node which was healthy before has been in an unknown state for shorter than the node restart timeout",
+			targets:                  []healthCheckTarget{nodeRestarting400},
+			expectedHealthy:          []healthCheckTarget{},
+			expectedNeedsRemediation: []healthCheckTarget{},
+			expectedNextCheckTimes:   []time.Duration{200 * time.Second},
+			expectedRestarting:       1,
+		},
+		{
+			desc:                     "when a node which was healthy before has been in an unknown state for longer than the node restart timeout",
+			targets:                  []healthCheckTarget{nodeRestarting700},
+			expectedHealthy:          []healthCheckTarget{},
+			expectedNeedsRemediation: []healthCheckTarget{nodeRestarting700},
+			expectedNextCheckTimes:   []time.Duration{},
+		},
+		{
+			desc:                     "when a node which was never healthy has been in an unknown state for longer than the timeout",
+			targets:                  []healthCheckTarget{nodeNeverHealthy400},
+			expectedHealthy:          []healthCheckTarget{},
+			expectedNeedsRemediation: []healthCheckTarget{nodeNeverHealthy400},
+			expectedNextCheckTimes:   []time.Duration{},
+		},
+		{
+			desc:                     "when the infrastructure machine of a machine without a node is unhealthy",
+			targets:                  []healthCheckTarget{infraMachineUnhealthy400, nodeNotYetStartedTarget},
+			expectedHealthy:          []healthCheckTarget{},
+			expectedNeedsRemediation: []healthCheckTarget{infraMachineUnhealthy400},
+			expectedNextCheckTimes:   []time.Duration{timeoutForMachineToHaveNode},
+			expectedStarting:         1,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -366,6 +533,10 @@ func TestHealthCheckTargets(t *testing.T) {
 			gs.Expect(healthy).To(ConsistOf(tc.expectedHealthy))
 			gs.Expect(unhealthy).To(ConsistOf(tc.expectedNeedsRemediation))
 			gs.Expect(nextCheckTimes).To(WithTransform(roundDurations, ConsistOf(tc.expectedNextCheckTimes)))
+
+			starting, restarting := countPendingTargets(tc.targets)
+			gs.Expect(starting).To(Equal(tc.expectedStarting))
+			gs.Expect(restarting).To(Equal(tc.expectedRestarting))
 		})
 	}
 }
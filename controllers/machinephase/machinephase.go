@@ -0,0 +1,107 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package machinephase implements the state machine used by the Machine controller for computing
+// the phase of a Machine, recording the phase transitions in the Machine status and the time spent in each phase.
+package machinephase
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
+)
+
+// Compute returns the phase of a Machine according to its current state:
+// - Pending, when the Machine has no phase yet.
+// - Provisioning, when the bootstrap data is ready and the infrastructure is not.
+// - Provisioned, when the Machine has a NodeRef.
+// - Running, when the Machine has a NodeRef and the infrastructure is ready.
+// - Failed, when the Machine has a FailureReason or a FailureMessage.
+// - Deleting, when the Machine is being deleted.
+// Later phases in the list take precedence; if none applies, the current phase is returned.
+func Compute(m *clusterv1.Machine) clusterv1.MachinePhase {
+	switch {
+	case !m.DeletionTimestamp.IsZero():
+		return clusterv1.MachinePhaseDeleting
+	case m.Status.FailureReason != nil || m.Status.FailureMessage != nil:
+		return clusterv1.MachinePhaseFailed
+	case m.Status.NodeRef != nil && m.Status.InfrastructureReady:
+		return clusterv1.MachinePhaseRunning
+	case m.Status.NodeRef != nil:
+		return clusterv1.MachinePhaseProvisioned
+	case m.Status.BootstrapReady && !m.Status.InfrastructureReady:
+		return clusterv1.MachinePhaseProvisioning
+	case m.Status.Phase == "":
+		return clusterv1.MachinePhasePending
+	}
+	return clusterv1.MachinePhase(m.Status.Phase)
+}
+
+// Transition moves a Machine to the given phase, if different from the current one, updating
+// Status.LastUpdated and Status.PhaseTransitions and recording the time spent in the previous phase.
+// It returns true if the phase has changed.
+func Transition(m *clusterv1.Machine, phase clusterv1.MachinePhase, now metav1.Time) bool {
+	previous := m.Status.Phase
+	if previous == string(phase) {
+		return false
+	}
+
+	if previous != "" {
+		observePhaseDuration(m, previous, now)
+	}
+
+	m.Status.SetTypedPhase(phase)
+	m.Status.LastUpdated = &now
+
+	// Keep only the last transition to each phase, so the list is bounded by the number of phases.
+	transitions := make([]clusterv1.MachinePhaseTransition, 0, len(m.Status.PhaseTransitions)+1)
+	for _, t := range m.Status.PhaseTransitions {
+		if t.Phase != string(phase) {
+			transitions = append(transitions, t)
+		}
+	}
+	m.Status.PhaseTransitions = append(transitions, clusterv1.MachinePhaseTransition{
+		Phase:              string(phase),
+		LastTransitionTime: now,
+	})
+	return true
+}
+
+// ObserveDeleted records the time spent by a Machine in the Deleting phase; it must be called when the
+// Machine controller completes the deletion, given that the Machine never leaves the Deleting phase.
+func ObserveDeleted(m *clusterv1.Machine, now metav1.Time) {
+	if m.Status.Phase == string(clusterv1.MachinePhaseDeleting) {
+		observePhaseDuration(m, m.Status.Phase, now)
+	}
+}
+
+// LastTransitionTime returns the last time a Machine entered the given phase, or nil if not recorded.
+func LastTransitionTime(m *clusterv1.Machine, phase clusterv1.MachinePhase) *metav1.Time {
+	for i := range m.Status.PhaseTransitions {
+		if m.Status.PhaseTransitions[i].Phase == string(phase) {
+			return &m.Status.PhaseTransitions[i].LastTransitionTime
+		}
+	}
+	return nil
+}
+
+// observePhaseDuration records the time spent in a phase, if the transition to the phase has been recorded;
+// this is not the case for Machines created before phase transitions were recorded.
+func observePhaseDuration(m *clusterv1.Machine, phase string, now metav1.Time) {
+	if t := LastTransitionTime(m, clusterv1.MachinePhase(phase)); t != nil {
+		metrics.RecordMachinePhaseDuration(phase, now.Sub(t.Time))
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machinephase
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+func TestCompute(t *testing.T) {
+	deletionTimestamp := metav1.Now()
+
+	tests := []struct {
+		name    string
+		machine *clusterv1.Machine
+		want    clusterv1.MachinePhase
+	}{
+		{
+			name:    "new Machine is pending",
+			machine: &clusterv1.Machine{},
+			want:    clusterv1.MachinePhasePending,
+		},
+		{
+			name: "Machine with bootstrap ready and infrastructure not ready is provisioning",
+			machine: &clusterv1.Machine{
+				Status: clusterv1.MachineStatus{Phase: string(clusterv1.MachinePhasePending), BootstrapReady: true},
+			},
+			want: clusterv1.MachinePhaseProvisioning,
+		},
+		{
+			name: "Machine with infrastructure ready and without NodeRef keeps the current phase",
+			machine: &clusterv1.Machine{
+				Status: clusterv1.MachineStatus{Phase: string(clusterv1.MachinePhaseProvisioning), BootstrapReady: true, InfrastructureReady: true},
+			},
+			want: clusterv1.MachinePhaseProvisioning,
+		},
+		{
+			name: "Machine with NodeRef and infrastructure not ready is provisioned",
+			machine: &clusterv1.Machine{
+				Status: clusterv1.MachineStatus{BootstrapReady: true, NodeRef: &corev1.ObjectReference{Name: "node"}},
+			},
+			want: clusterv1.MachinePhaseProvisioned,
+		},
+		{
+			name: "Machine with NodeRef and infrastructure ready is running",
+			machine: &clusterv1.Machine{
+				Status: clusterv1.MachineStatus{BootstrapReady: true, InfrastructureReady: true, NodeRef: &corev1.ObjectReference{Name: "node"}},
+			},
+			want: clusterv1.MachinePhaseRunning,
+		},
+		{
+			name: "Machine with failure message is failed",
+			machine: &clusterv1.Machine{
+				Status: clusterv1.MachineStatus{InfrastructureReady: true, NodeRef: &corev1.ObjectReference{Name: "node"}, FailureMessage: pointer.StringPtr("failure")},
+			},
+			want: clusterv1.MachinePhaseFailed,
+		},
+		{
+			name: "Machine being deleted is deleting",
+			machine: &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &deletionTimestamp},
+				Status:     clusterv1.MachineStatus{FailureMessage: pointer.StringPtr("failure")},
+			},
+			want: clusterv1.MachinePhaseDeleting,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(Compute(tt.machine)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestTransition(t *testing.T) {
+	g := NewWithT(t)
+
+	m := &clusterv1.Machine{}
+	start := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+
+	g.Expect(Transition(m, clusterv1.MachinePhasePending, start)).To(BeTrue())
+	g.Expect(m.Status.Phase).To(Equal(string(clusterv1.MachinePhasePending)))
+	g.Expect(m.Status.LastUpdated).To(Equal(&start))
+	g.Expect(LastTransitionTime(m, clusterv1.MachinePhasePending)).To(Equal(&start))
+
+	// Transitioning to the current phase is a no-op.
+	g.Expect(Transition(m, clusterv1.MachinePhasePending, metav1.Now())).To(BeFalse())
+	g.Expect(m.Status.LastUpdated).To(Equal(&start))
+
+	provisioning := metav1.NewTime(start.Add(time.Minute))
+	g.Expect(Transition(m, clusterv1.MachinePhaseProvisioning, provisioning)).To(BeTrue())
+	g.Expect(m.Status.PhaseTransitions).To(Equal([]clusterv1.MachinePhaseTransition{
+		{Phase: string(clusterv1.MachinePhasePending), LastTransitionTime: start},
+		{Phase: string(clusterv1.MachinePhaseProvisioning), LastTransitionTime: provisioning},
+	}))
+
+	// Entering a phase again replaces the previous transition to the phase.
+	failed := metav1.NewTime(start.Add(2 * time.Minute))
+	g.Expect(Transition(m, clusterv1.MachinePhaseFailed, failed)).To(BeTrue())
+	provisioningAgain := metav1.NewTime(start.Add(3 * time.Minute))
+	g.Expect(Transition(m, clusterv1.MachinePhaseProvisioning, provisioningAgain)).To(BeTrue())
+	g.Expect(m.Status.PhaseTransitions).To(Equal([]clusterv1.MachinePhaseTransition{
+		{Phase: string(clusterv1.MachinePhasePending), LastTransitionTime: start},
+		{Phase: string(clusterv1.MachinePhaseFailed), LastTransitionTime: failed},
+		{Phase: string(clusterv1.MachinePhaseProvisioning), LastTransitionTime: provisioningAgain},
+	}))
+	g.Expect(LastTransitionTime(m, clusterv1.MachinePhaseRunning)).To(BeNil())
+}
@@ -26,16 +26,19 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
 	"sigs.k8s.io/cluster-api/controllers/noderefutil"
 	"sigs.k8s.io/cluster-api/controllers/remote"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/collections"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	utilconversion "sigs.k8s.io/cluster-api/util/conversion"
 	"sigs.k8s.io/cluster-api/util/patch"
@@ -71,6 +74,18 @@ type MachineSetReconciler struct {
 	Tracker          *remote.ClusterCacheTracker
 	WatchFilterValue string
 
+	// MachineCreationLimit, if greater than zero, is the maximum number of Machines a MachineSet creates
+	// within MachineCreationLimitInterval; creation of further Machines is spread over time.
+	MachineCreationLimit int
+
+	// MachineCreationLimitInterval is the interval MachineCreationLimit applies to; it defaults to one minute.
+	MachineCreationLimitInterval time.Duration
+
+	// ResourceQuotaAware, if true, makes MachineSets create only the Machines allowed by the ResourceQuotas
+	// in their namespace, and wait for quota to be available before creating the others, instead of
+	// failing the creation mid-way.
+	ResourceQuotaAware bool
+
 	recorder   record.EventRecorder
 	restConfig *rest.Config
 }
@@ -90,7 +105,7 @@ func (r *MachineSetReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Ma
 		).
 		WithOptions(options).
 		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
-		Build(r)
+		Build(metrics.NewInstrumentedReconciler("machineset", r))
 	if err != nil {
 		return errors.Wrap(err, "failed setting up with a controller manager")
 	}
@@ -143,7 +158,7 @@ func (r *MachineSetReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 	defer func() {
 		// Always attempt to patch the object and status after each reconciliation.
-		if err := patchHelper.Patch(ctx, machineSet); err != nil {
+		if err := patchMachineSet(ctx, patchHelper, machineSet); err != nil {
 			reterr = kerrors.NewAggregate([]error{reterr, err})
 		}
 	}()
@@ -162,6 +177,24 @@ func (r *MachineSetReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	return result, err
 }
 
+func patchMachineSet(ctx context.Context, patchHelper *patch.Helper, machineSet *clusterv1.MachineSet, options ...patch.Option) error {
+	// Always update the readyCondition by summarizing the state of other conditions.
+	conditions.SetSummary(machineSet,
+		conditions.WithConditions(
+			clusterv1.MachinesReadyCondition,
+		),
+	)
+
+	// Patch the object, ignoring conflicts on the conditions owned by this controller.
+	options = append(options,
+		patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{
+			clusterv1.ReadyCondition,
+			clusterv1.MachinesReadyCondition,
+		}},
+	)
+	return patchHelper.Patch(ctx, machineSet, options...)
+}
+
 func (r *MachineSetReconciler) reconcile(ctx context.Context, cluster *clusterv1.Cluster, machineSet *clusterv1.MachineSet) (ctrl.Result, error) {
 	log := ctrl.LoggerFrom(ctx)
 	log.V(4).Info("Reconcile MachineSet")
@@ -182,12 +215,12 @@ func (r *MachineSetReconciler) reconcile(ctx context.Context, cluster *clusterv1
 	}
 
 	// Make sure to reconcile the external infrastructure reference.
-	if err := reconcileExternalTemplateReference(ctx, r.Client, r.restConfig, cluster, &machineSet.Spec.Template.Spec.InfrastructureRef); err != nil {
+	if _, err := reconcileExternalTemplateReference(ctx, r.Client, r.restConfig, cluster, &machineSet.Spec.Template.Spec.InfrastructureRef); err != nil {
 		return ctrl.Result{}, err
 	}
 	// Make sure to reconcile the external bootstrap reference, if any.
 	if machineSet.Spec.Template.Spec.Bootstrap.ConfigRef != nil {
-		if err := reconcileExternalTemplateReference(ctx, r.Client, r.restConfig, cluster, machineSet.Spec.Template.Spec.Bootstrap.ConfigRef); err != nil {
+		if _, err := reconcileExternalTemplateReference(ctx, r.Client, r.restConfig, cluster, machineSet.Spec.Template.Spec.Bootstrap.ConfigRef); err != nil {
 			return ctrl.Result{}, err
 		}
 	}
@@ -256,6 +289,8 @@ func (r *MachineSetReconciler) reconcile(ctx context.Context, cluster *clusterv1
 				errs = append(errs, errors.Wrap(err, "failed to delete"))
 				continue
 			}
+			metrics.RecordMachineDeleted(machineSetKind.Kind)
+			metrics.RecordRemediation(machineSetKind.Kind)
 			conditions.MarkTrue(machine, clusterv1.MachineOwnerRemediatedCondition)
 			if err := r.Client.Status().Patch(ctx, machine, patch); err != nil && !apierrors.IsNotFound(err) {
 				errs = append(errs, errors.Wrap(err, "failed to update status"))
@@ -269,7 +304,7 @@ func (r *MachineSetReconciler) reconcile(ctx context.Context, cluster *clusterv1
 		return ctrl.Result{}, errors.Wrap(err, "failed to remediate machines")
 	}
 
-	syncErr := r.syncReplicas(ctx, machineSet, filteredMachines)
+	syncResult, syncErr := r.syncReplicas(ctx, machineSet, filteredMachines)
 
 	// Always updates status as machines come up or die.
 	if err := r.updateStatus(ctx, cluster, machineSet, filteredMachines); err != nil {
@@ -301,17 +336,24 @@ func (r *MachineSetReconciler) reconcile(ctx context.Context, cluster *clusterv1
 	// Quickly reconcile until the nodes become Ready.
 	if machineSet.Status.ReadyReplicas != replicas {
 		log.V(4).Info("Some nodes are not ready yet, requeuing until they are ready")
-		return ctrl.Result{RequeueAfter: 15 * time.Second}, nil
+		requeueAfter := 15 * time.Second
+		// Resync earlier if the creation of further Machines is allowed before.
+		if syncResult.RequeueAfter > 0 && syncResult.RequeueAfter < requeueAfter {
+			requeueAfter = syncResult.RequeueAfter
+		}
+		return ctrl.Result{RequeueAfter: requeueAfter}, nil
 	}
 
-	return ctrl.Result{}, nil
+	// Resync the MachineSet when the creation of further Machines is allowed.
+	return syncResult, nil
 }
 
 // syncReplicas scales Machine resources up or down.
-func (r *MachineSetReconciler) syncReplicas(ctx context.Context, ms *clusterv1.MachineSet, machines []*clusterv1.Machine) error {
+// If the creation of some of the Machines is held back, the returned result has RequeueAfter set.
+func (r *MachineSetReconciler) syncReplicas(ctx context.Context, ms *clusterv1.MachineSet, machines []*clusterv1.Machine) (ctrl.Result, error) {
 	log := ctrl.LoggerFrom(ctx)
 	if ms.Spec.Replicas == nil {
-		return errors.Errorf("the Replicas field in Spec for machineset %v is nil, this should not be allowed", ms.Name)
+		return ctrl.Result{}, errors.Errorf("the Replicas field in Spec for machineset %v is nil, this should not be allowed", ms.Name)
 	}
 	diff := len(machines) - int(*(ms.Spec.Replicas))
 	switch {
@@ -321,9 +363,22 @@ func (r *MachineSetReconciler) syncReplicas(ctx context.Context, ms *clusterv1.M
 		if ms.Annotations != nil {
 			if _, ok := ms.Annotations[clusterv1.DisableMachineCreate]; ok {
 				log.V(2).Info("Automatic creation of new machines disabled for machine set")
-				return nil
+				return ctrl.Result{}, nil
 			}
 		}
+
+		allowed, requeueAfter, err := r.machineCreationBudget(ctx, ms, machines, diff)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if allowed < diff {
+			log.Info("Holding back creation of machines", "allowed", allowed, "held back", diff-allowed, "retry after", requeueAfter)
+			r.recorder.Eventf(ms, corev1.EventTypeNormal, "CreationThrottled",
+				"Creating %d of %d machines; the others will be created when allowed by the creation rate limit or by the namespace ResourceQuotas", allowed, diff)
+			diff = allowed
+		}
+		result := ctrl.Result{RequeueAfter: requeueAfter}
+
 		var (
 			machineList []*clusterv1.Machine
 			errs        []error
@@ -350,7 +405,7 @@ func (r *MachineSetReconciler) syncReplicas(ctx context.Context, ms *clusterv1.M
 					Labels:      machine.Labels,
 				})
 				if err != nil {
-					return errors.Wrapf(err, "failed to clone bootstrap configuration for MachineSet %q in namespace %q", ms.Name, ms.Namespace)
+					return ctrl.Result{}, errors.Wrapf(err, "failed to clone bootstrap configuration for MachineSet %q in namespace %q", ms.Name, ms.Namespace)
 				}
 				machine.Spec.Bootstrap.ConfigRef = bootstrapRef
 			}
@@ -363,7 +418,7 @@ func (r *MachineSetReconciler) syncReplicas(ctx context.Context, ms *clusterv1.M
 				Labels:      machine.Labels,
 			})
 			if err != nil {
-				return errors.Wrapf(err, "failed to clone infrastructure configuration for MachineSet %q in namespace %q", ms.Name, ms.Namespace)
+				return ctrl.Result{}, errors.Wrapf(err, "failed to clone infrastructure configuration for MachineSet %q in namespace %q", ms.Name, ms.Namespace)
 			}
 			machine.Spec.InfrastructureRef = *infraRef
 
@@ -385,20 +440,21 @@ func (r *MachineSetReconciler) syncReplicas(ctx context.Context, ms *clusterv1.M
 			}
 
 			log.Info(fmt.Sprintf("Created machine %d of %d with name %q", i+1, diff, machine.Name))
+			metrics.RecordMachineCreated(machineSetKind.Kind)
 			r.recorder.Eventf(ms, corev1.EventTypeNormal, "SuccessfulCreate", "Created machine %q", machine.Name)
 			machineList = append(machineList, machine)
 		}
 
 		if len(errs) > 0 {
-			return kerrors.NewAggregate(errs)
+			return result, kerrors.NewAggregate(errs)
 		}
-		return r.waitForMachineCreation(ctx, machineList)
+		return result, r.waitForMachineCreation(ctx, machineList)
 	case diff > 0:
 		log.Info("Too many replicas", "need", *(ms.Spec.Replicas), "deleting", diff)
 
 		deletePriorityFunc, err := getDeletePriorityFunc(ms)
 		if err != nil {
-			return err
+			return ctrl.Result{}, err
 		}
 		log.Info("Found delete policy", "delete-policy", ms.Spec.DeletePolicy)
 
@@ -412,16 +468,17 @@ func (r *MachineSetReconciler) syncReplicas(ctx context.Context, ms *clusterv1.M
 				continue
 			}
 			log.Info("Deleted machine", "machine", machine.Name)
+			metrics.RecordMachineDeleted(machineSetKind.Kind)
 			r.recorder.Eventf(ms, corev1.EventTypeNormal, "SuccessfulDelete", "Deleted machine %q", machine.Name)
 		}
 
 		if len(errs) > 0 {
-			return kerrors.NewAggregate(errs)
+			return ctrl.Result{}, kerrors.NewAggregate(errs)
 		}
-		return r.waitForMachineDeletion(ctx, machinesToDelete)
+		return ctrl.Result{}, r.waitForMachineDeletion(ctx, machinesToDelete)
 	}
 
-	return nil
+	return ctrl.Result{}, nil
 }
 
 // getNewMachine creates a new Machine object. The name of the newly created resource is going
@@ -434,7 +491,7 @@ func (r *MachineSetReconciler) getNewMachine(machineSet *clusterv1.MachineSet) *
 			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(machineSet, machineSetKind)},
 			Namespace:       machineSet.Namespace,
 			Labels:          machineSet.Spec.Template.Labels,
-			Annotations:     machineSet.Spec.Template.Annotations,
+			Annotations:     make(map[string]string, len(machineSet.Spec.Template.Annotations)),
 		},
 		TypeMeta: metav1.TypeMeta{
 			Kind:       gv.WithKind("Machine").Kind,
@@ -443,6 +500,9 @@ func (r *MachineSetReconciler) getNewMachine(machineSet *clusterv1.MachineSet) *
 		Spec: machineSet.Spec.Template.Spec,
 	}
 	machine.Spec.ClusterName = machineSet.Spec.ClusterName
+	for k, v := range machineSet.Spec.Template.Annotations {
+		machine.Annotations[k] = v
+	}
 	if machine.Labels == nil {
 		machine.Labels = make(map[string]string)
 	}
@@ -671,6 +731,14 @@ func (r *MachineSetReconciler) updateStatus(ctx context.Context, cluster *cluste
 			fmt.Sprintf("sequence No: %v->%v", ms.Status.ObservedGeneration, newStatus.ObservedGeneration))
 	}
 
+	// Aggregate the operational state of all the machines; while aggregating we are adding the
+	// source ref (reason@machine/name) so the problem can be easily tracked down to its source machine.
+	if len(filteredMachines) == 0 {
+		conditions.MarkTrue(ms, clusterv1.MachinesReadyCondition)
+	} else {
+		conditions.SetAggregate(ms, clusterv1.MachinesReadyCondition, collections.FromMachines(filteredMachines...).ConditionGetters(), conditions.AddSourceRef(), conditions.WithStepCounterIf(false))
+	}
+
 	return nil
 }
 
@@ -686,23 +754,25 @@ func (r *MachineSetReconciler) getMachineNode(ctx context.Context, cluster *clus
 	return node, nil
 }
 
-func reconcileExternalTemplateReference(ctx context.Context, c client.Client, restConfig *rest.Config, cluster *clusterv1.Cluster, ref *corev1.ObjectReference) error {
+// reconcileExternalTemplateReference ensures the referenced template is owned by the Cluster, and returns it; it
+// returns nil if the reference is not a template.
+func reconcileExternalTemplateReference(ctx context.Context, c client.Client, restConfig *rest.Config, cluster *clusterv1.Cluster, ref *corev1.ObjectReference) (*unstructured.Unstructured, error) {
 	if !strings.HasSuffix(ref.Kind, external.TemplateSuffix) {
-		return nil
+		return nil, nil
 	}
 
 	if err := utilconversion.ConvertReferenceAPIContract(ctx, c, restConfig, ref); err != nil {
-		return err
+		return nil, err
 	}
 
 	obj, err := external.Get(ctx, c, ref, cluster.Namespace)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	patchHelper, err := patch.NewHelper(obj, c)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	obj.SetOwnerReferences(util.EnsureOwnerRef(obj.GetOwnerReferences(), metav1.OwnerReference{
@@ -713,7 +783,7 @@ func reconcileExternalTemplateReference(ctx context.Context, c client.Client, re
 	}))
 
 	if err := patchHelper.Patch(ctx, obj); err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return obj, nil
 }
@@ -29,9 +29,11 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/controllers/external"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -793,3 +795,64 @@ func newMachineSet(name, cluster string) *clusterv1.MachineSet {
 		},
 	}
 }
+
+func TestMachineSetUpdateStatusMachinesReady(t *testing.T) {
+	machine := func(name string, ready bool) *clusterv1.Machine {
+		m := &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+		}
+		if ready {
+			conditions.MarkTrue(m, clusterv1.ReadyCondition)
+		} else {
+			conditions.MarkFalse(m, clusterv1.ReadyCondition, clusterv1.WaitingForInfrastructureFallbackReason, clusterv1.ConditionSeverityInfo, "")
+		}
+		return m
+	}
+
+	testCases := []struct {
+		name       string
+		machines   []*clusterv1.Machine
+		wantStatus corev1.ConditionStatus
+		wantReason string
+	}{
+		{
+			name:       "ready without machines",
+			wantStatus: corev1.ConditionTrue,
+		},
+		{
+			name:       "ready if all the machines are ready",
+			machines:   []*clusterv1.Machine{machine("m1", true), machine("m2", true)},
+			wantStatus: corev1.ConditionTrue,
+		},
+		{
+			name:       "not ready if a machine is not ready",
+			machines:   []*clusterv1.Machine{machine("m1", true), machine("m2", false)},
+			wantStatus: corev1.ConditionFalse,
+			wantReason: clusterv1.WaitingForInfrastructureFallbackReason,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			ms := &clusterv1.MachineSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "ms",
+					Namespace: "default",
+				},
+				Spec: clusterv1.MachineSetSpec{
+					Replicas: pointer.Int32Ptr(int32(len(tc.machines))),
+				},
+			}
+
+			r := &MachineSetReconciler{}
+			g.Expect(r.updateStatus(ctx, &clusterv1.Cluster{}, ms, tc.machines)).To(Succeed())
+			g.Expect(conditions.Get(ms, clusterv1.MachinesReadyCondition).Status).To(Equal(tc.wantStatus))
+			g.Expect(conditions.GetReason(ms, clusterv1.MachinesReadyCondition)).To(HavePrefix(tc.wantReason))
+		})
+	}
+}
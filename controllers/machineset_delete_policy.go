@@ -32,6 +32,7 @@ type (
 
 const (
 	mustDelete    deletePriority = 100.0
+	shouldDelete  deletePriority = 75.0
 	betterDelete  deletePriority = 50.0
 	couldDelete   deletePriority = 20.0
 	mustNotDelete deletePriority = 0.0
@@ -83,8 +84,10 @@ func randomDeletePolicy(machine *clusterv1.Machine) deletePriority {
 	if !machine.DeletionTimestamp.IsZero() {
 		return mustDelete
 	}
+	// Machines with the delete machine annotation are deleted before unhealthy Machines, so users
+	// can surgically remove specific Machines when downscaling.
 	if _, ok := machine.ObjectMeta.Annotations[clusterv1.DeleteMachineAnnotation]; ok {
-		return betterDelete
+		return shouldDelete
 	}
 	if machine.Status.NodeRef == nil {
 		return betterDelete
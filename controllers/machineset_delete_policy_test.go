@@ -162,6 +162,32 @@ func TestMachineToDelete(t *testing.T) {
 				deleteMachineWithMachineAnnotation,
 			},
 		},
+		{
+			desc: "func=randomDeletePolicy, DeleteMachineAnnotation before betterDelete, diff=1",
+			diff: 1,
+			machines: []*clusterv1.Machine{
+				betterDeleteMachine,
+				deleteMachineWithoutNodeRef,
+				deleteMachineWithMachineAnnotation,
+				healthyMachine,
+			},
+			expect: []*clusterv1.Machine{
+				deleteMachineWithMachineAnnotation,
+			},
+		},
+		{
+			desc: "func=randomDeletePolicy, DeleteMachineAnnotation after mustDelete, diff=2",
+			diff: 2,
+			machines: []*clusterv1.Machine{
+				deleteMachineWithMachineAnnotation,
+				betterDeleteMachine,
+				mustDeleteMachine,
+			},
+			expect: []*clusterv1.Machine{
+				mustDeleteMachine,
+				deleteMachineWithMachineAnnotation,
+			},
+		},
 		{
 			desc: "func=randomDeletePolicy, MachineWithNoNodeRef, diff=1",
 			diff: 1,
@@ -0,0 +1,133 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	// machinesCountResourceName is the ResourceQuota object count resource for Machines.
+	machinesCountResourceName = corev1.ResourceName("count/machines." + clusterv1.GroupVersion.Group)
+
+	// resourceQuotaRequeueAfter is the amount of time before checking again a ResourceQuota which does not
+	// allow to create all the required Machines.
+	resourceQuotaRequeueAfter = 30 * time.Second
+)
+
+// +kubebuilder:rbac:groups=core,resources=resourcequotas,verbs=get;list;watch
+
+// machineCreationBudget returns how many of the desired new Machines the MachineSet is allowed to create now,
+// according to the MachineCreationLimit and to the namespace ResourceQuotas, if ResourceQuotaAware is set, and
+// the amount of time after which the MachineSet should be reconciled again if some of the Machines are held back.
+func (r *MachineSetReconciler) machineCreationBudget(ctx context.Context, ms *clusterv1.MachineSet, machines []*clusterv1.Machine, desired int) (int, time.Duration, error) {
+	allowed := desired
+	var requeueAfter time.Duration
+
+	if r.MachineCreationLimit > 0 {
+		interval := r.machineCreationLimitInterval()
+		since := time.Now().Add(-interval)
+		recent := 0
+		oldestRecent := time.Now()
+		for _, m := range machines {
+			if created := m.CreationTimestamp.Time; created.After(since) {
+				recent++
+				if created.Before(oldestRecent) {
+					oldestRecent = created
+				}
+			}
+		}
+		if remaining := r.MachineCreationLimit - recent; remaining < allowed {
+			allowed = nonNegative(remaining)
+			// Check again when the oldest of the recently created Machines falls out of the interval.
+			requeueAfter = time.Until(oldestRecent.Add(interval))
+			if requeueAfter <= 0 {
+				requeueAfter = interval
+			}
+		}
+	}
+
+	if r.ResourceQuotaAware && allowed > 0 {
+		available, limited, err := r.availableMachinesQuota(ctx, ms.Namespace)
+		if err != nil {
+			return 0, 0, err
+		}
+		if limited && available < allowed {
+			allowed = nonNegative(available)
+			if requeueAfter == 0 || resourceQuotaRequeueAfter < requeueAfter {
+				requeueAfter = resourceQuotaRequeueAfter
+			}
+		}
+	}
+
+	return allowed, requeueAfter, nil
+}
+
+// machineCreationLimitInterval returns the interval MachineCreationLimit applies to.
+func (r *MachineSetReconciler) machineCreationLimitInterval() time.Duration {
+	if r.MachineCreationLimitInterval == 0 {
+		return time.Minute
+	}
+	return r.MachineCreationLimitInterval
+}
+
+// availableMachinesQuota returns how many Machines can be created in the namespace before exceeding the most restrictive
+// ResourceQuota with a hard limit for the Machine count, and false if no such ResourceQuota exists.
+func (r *MachineSetReconciler) availableMachinesQuota(ctx context.Context, namespace string) (int, bool, error) {
+	quotas := &corev1.ResourceQuotaList{}
+	if err := r.Client.List(ctx, quotas, client.InNamespace(namespace)); err != nil {
+		return 0, false, errors.Wrapf(err, "failed to list ResourceQuotas in namespace %q", namespace)
+	}
+
+	available := 0
+	limited := false
+	for i := range quotas.Items {
+		quota := &quotas.Items[i]
+		// Object count quotas do not support scopes; skip scoped quotas, which cannot limit Machines.
+		if len(quota.Spec.Scopes) > 0 || quota.Spec.ScopeSelector != nil {
+			continue
+		}
+		hard, ok := quota.Status.Hard[machinesCountResourceName]
+		if !ok {
+			hard, ok = quota.Spec.Hard[machinesCountResourceName]
+		}
+		if !ok {
+			continue
+		}
+		used := quota.Status.Used[machinesCountResourceName]
+		remaining := int(hard.Value() - used.Value())
+		if !limited || remaining < available {
+			available = remaining
+			limited = true
+		}
+	}
+	return available, limited, nil
+}
+
+// nonNegative returns n, or zero if n is negative.
+func nonNegative(n int) int {
+	if n < 0 {
+		return 0
+	}
+	return n
+}
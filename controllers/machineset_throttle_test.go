@@ -0,0 +1,142 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestMachineSetMachineCreationBudget(t *testing.T) {
+	ms := &clusterv1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ms",
+			Namespace: metav1.NamespaceDefault,
+		},
+	}
+
+	machineCreatedAt := func(created time.Time) *clusterv1.Machine {
+		return &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(created)}}
+	}
+
+	quota := func(hard, used int64, scopes ...corev1.ResourceQuotaScope) *corev1.ResourceQuota {
+		return &corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "quota-" + resource.NewQuantity(hard, resource.DecimalSI).String(),
+				Namespace: metav1.NamespaceDefault,
+			},
+			Spec: corev1.ResourceQuotaSpec{
+				Scopes: scopes,
+			},
+			Status: corev1.ResourceQuotaStatus{
+				Hard: corev1.ResourceList{machinesCountResourceName: *resource.NewQuantity(hard, resource.DecimalSI)},
+				Used: corev1.ResourceList{machinesCountResourceName: *resource.NewQuantity(used, resource.DecimalSI)},
+			},
+		}
+	}
+
+	tests := []struct {
+		name             string
+		reconciler       *MachineSetReconciler
+		objs             []client.Object
+		machines         []*clusterv1.Machine
+		desired          int
+		wantAllowed      int
+		wantRequeueAfter bool
+	}{
+		{
+			name:        "without limits all the Machines are created",
+			reconciler:  &MachineSetReconciler{},
+			desired:     10,
+			wantAllowed: 10,
+		},
+		{
+			name:             "creation limit caps the Machines created at once",
+			reconciler:       &MachineSetReconciler{MachineCreationLimit: 3},
+			desired:          10,
+			wantAllowed:      3,
+			wantRequeueAfter: true,
+		},
+		{
+			name:       "creation limit takes into account the recently created Machines",
+			reconciler: &MachineSetReconciler{MachineCreationLimit: 3},
+			machines: []*clusterv1.Machine{
+				machineCreatedAt(time.Now().Add(-10 * time.Second)),
+				machineCreatedAt(time.Now().Add(-20 * time.Second)),
+				machineCreatedAt(time.Now().Add(-time.Hour)),
+			},
+			desired:          10,
+			wantAllowed:      1,
+			wantRequeueAfter: true,
+		},
+		{
+			name:        "ResourceQuotas are ignored if not resource quota aware",
+			reconciler:  &MachineSetReconciler{},
+			objs:        []client.Object{quota(5, 3)},
+			desired:     10,
+			wantAllowed: 10,
+		},
+		{
+			name:             "the most restrictive ResourceQuota caps the Machines created",
+			reconciler:       &MachineSetReconciler{ResourceQuotaAware: true},
+			objs:             []client.Object{quota(10, 3), quota(5, 3)},
+			desired:          10,
+			wantAllowed:      2,
+			wantRequeueAfter: true,
+		},
+		{
+			name:             "no Machines are created if the ResourceQuota is exceeded",
+			reconciler:       &MachineSetReconciler{ResourceQuotaAware: true},
+			objs:             []client.Object{quota(5, 6)},
+			desired:          1,
+			wantAllowed:      0,
+			wantRequeueAfter: true,
+		},
+		{
+			name:        "scoped ResourceQuotas are ignored",
+			reconciler:  &MachineSetReconciler{ResourceQuotaAware: true},
+			objs:        []client.Object{quota(5, 5, corev1.ResourceQuotaScopeBestEffort)},
+			desired:     3,
+			wantAllowed: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			tt.reconciler.Client = fake.NewClientBuilder().WithObjects(tt.objs...).Build()
+			allowed, requeueAfter, err := tt.reconciler.machineCreationBudget(ctx, ms, tt.machines, tt.desired)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(allowed).To(Equal(tt.wantAllowed))
+			if tt.wantRequeueAfter {
+				g.Expect(requeueAfter).To(BeNumerically(">", 0))
+			} else {
+				g.Expect(requeueAfter).To(BeZero())
+			}
+		})
+	}
+}
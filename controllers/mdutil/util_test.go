@@ -819,3 +819,66 @@ func TestReplicasAnnotationsNeedUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestFindMachineSetForRevision(t *testing.T) {
+	newMSWithRevision := func(name, revision string) *clusterv1.MachineSet {
+		return &clusterv1.MachineSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Annotations: map[string]string{clusterv1.RevisionAnnotation: revision},
+			},
+		}
+	}
+	msList := []*clusterv1.MachineSet{
+		newMSWithRevision("ms-1", "1"),
+		newMSWithRevision("ms-3", "3"),
+		newMSWithRevision("ms-2", "2"),
+	}
+
+	tests := []struct {
+		name       string
+		toRevision int64
+		msList     []*clusterv1.MachineSet
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "a specific revision",
+			toRevision: 1,
+			msList:     msList,
+			want:       "ms-1",
+		},
+		{
+			name:       "the revision before the latest one",
+			toRevision: 0,
+			msList:     msList,
+			want:       "ms-2",
+		},
+		{
+			name:       "a revision which does not exist",
+			toRevision: 4,
+			msList:     msList,
+			wantErr:    true,
+		},
+		{
+			name:       "no revision before the latest one",
+			toRevision: 0,
+			msList:     msList[:1],
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := FindMachineSetForRevision(tt.toRevision, tt.msList)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got.Name).To(Equal(tt.want))
+		})
+	}
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics implements the Cluster API metrics, that are exposed in addition to the
+// default controller-runtime metrics by the metrics endpoint of the manager.
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// ResultSuccess is the result of a reconcile which completed without errors and without requeueing.
+	ResultSuccess = "success"
+
+	// ResultRequeue is the result of a reconcile which completed without errors, requesting to be requeued.
+	ResultRequeue = "requeue"
+
+	// ResultError is the result of a reconcile which returned an error.
+	ResultError = "error"
+
+	// UnknownErrorReason is the error reason used for errors which are not Kubernetes API errors.
+	UnknownErrorReason = "Unknown"
+
+	// ExternalRemediation identifies remediations performed by an external remediation controller,
+	// i.e. the remediation requests created by MachineHealthCheck.
+	ExternalRemediation = "External"
+)
+
+var (
+	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "capi_reconcile_duration_seconds",
+		Help:    "Duration of the reconciliations per controller and result.",
+		Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"controller", "result"})
+
+	reconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "capi_reconcile_errors_total",
+		Help: "Total number of reconcile errors per controller and reason, i.e. the reason of the Kubernetes API error or Unknown.",
+	}, []string{"controller", "reason"})
+
+	machinesCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "capi_machines_created_total",
+		Help: "Total number of Machines created per owner kind.",
+	}, []string{"owner_kind"})
+
+	machinesDeleted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "capi_machines_deleted_total",
+		Help: "Total number of Machines deleted per owner kind.",
+	}, []string{"owner_kind"})
+
+	rolloutDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "capi_rollout_duration_seconds",
+		Help:    "Duration of the completed rollouts per kind, from the creation of the new Machines to all of them being available.",
+		Buckets: []float64{30, 60, 120, 300, 600, 900, 1200, 1800, 2700, 3600, 7200},
+	}, []string{"kind"})
+
+	remediations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "capi_remediations_total",
+		Help: "Total number of remediations of unhealthy Machines performed per remediator, i.e. the owner kind or External.",
+	}, []string{"remediator"})
+
+	machinePhaseDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "capi_machine_phase_duration_seconds",
+		Help:    "Time spent by Machines in each phase, observed when leaving the phase.",
+		Buckets: []float64{1, 5, 15, 30, 60, 120, 300, 600, 900, 1200, 1800, 3600, 7200},
+	}, []string{"phase"})
+
+	orphanedInfrastructureMachines = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "capi_orphaned_infrastructure_machines",
+		Help: "Number of infrastructure machines not owned by any Machine per Cluster.",
+	}, []string{"namespace", "cluster"})
+
+	bootstrapDataWaits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "capi_bootstrap_data_waits_total",
+		Help: "Total number of reconciliations of bootstrap configs deferring the generation of the bootstrap data per controller and reason, e.g. WaitingForClusterInfrastructure.",
+	}, []string{"controller", "reason"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		reconcileDuration,
+		reconcileErrors,
+		machinesCreated,
+		machinesDeleted,
+		rolloutDuration,
+		remediations,
+		machinePhaseDuration,
+		orphanedInfrastructureMachines,
+		bootstrapDataWaits,
+	)
+}
+
+// instrumentedReconciler records the duration and the errors of the reconciliations of a reconciler.
+type instrumentedReconciler struct {
+	controller string
+	reconciler reconcile.Reconciler
+}
+
+// NewInstrumentedReconciler returns a reconciler recording the duration and the errors of the reconciliations
+// of the given reconciler, using the controller name as a label; the controller name should be the lowercase kind
+// of the reconciled objects, like for the default controller-runtime metrics.
+func NewInstrumentedReconciler(controller string, r reconcile.Reconciler) reconcile.Reconciler {
+	return &instrumentedReconciler{
+		controller: controller,
+		reconciler: r,
+	}
+}
+
+func (r *instrumentedReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	start := time.Now()
+	result, err := r.reconciler.Reconcile(ctx, req)
+	ObserveReconcile(r.controller, time.Since(start), result, err)
+	return result, err
+}
+
+// ObserveReconcile records the duration and the error, if any, of a reconciliation.
+func ObserveReconcile(controller string, duration time.Duration, result ctrl.Result, err error) {
+	outcome := ResultSuccess
+	switch {
+	case err != nil:
+		outcome = ResultError
+		reconcileErrors.WithLabelValues(controller, ErrorReason(err)).Inc()
+	case result.Requeue || result.RequeueAfter > 0:
+		outcome = ResultRequeue
+	}
+	reconcileDuration.WithLabelValues(controller, outcome).Observe(duration.Seconds())
+}
+
+// ErrorReason returns the reason of a Kubernetes API error, e.g. Conflict, or Unknown for any other error.
+func ErrorReason(err error) string {
+	if reason := apierrors.ReasonForError(errors.Cause(err)); reason != metav1.StatusReasonUnknown {
+		return string(reason)
+	}
+	return UnknownErrorReason
+}
+
+// RecordMachineCreated records the creation of a Machine by the controller of the given owner kind.
+func RecordMachineCreated(ownerKind string) {
+	machinesCreated.WithLabelValues(ownerKind).Inc()
+}
+
+// RecordMachineDeleted records the deletion of a Machine by the controller of the given owner kind.
+func RecordMachineDeleted(ownerKind string) {
+	machinesDeleted.WithLabelValues(ownerKind).Inc()
+}
+
+// RecordRollout records the duration of a completed rollout of an object of the given kind.
+func RecordRollout(kind string, duration time.Duration) {
+	rolloutDuration.WithLabelValues(kind).Observe(duration.Seconds())
+}
+
+// RecordRemediation records a remediation of an unhealthy Machine by the given remediator,
+// i.e. the kind of the owner of the Machine or ExternalRemediation.
+func RecordRemediation(remediator string) {
+	remediations.WithLabelValues(remediator).Inc()
+}
+
+// RecordMachinePhaseDuration records the time spent by a Machine in the given phase.
+func RecordMachinePhaseDuration(phase string, duration time.Duration) {
+	machinePhaseDuration.WithLabelValues(phase).Observe(duration.Seconds())
+}
+
+// RecordOrphanedInfrastructureMachines records the number of infrastructure machines of a Cluster not owned by any Machine.
+func RecordOrphanedInfrastructureMachines(namespace, cluster string, count int) {
+	orphanedInfrastructureMachines.WithLabelValues(namespace, cluster).Set(float64(count))
+}
+
+// DeleteOrphanedInfrastructureMachines removes the number of orphaned infrastructure machines recorded for a Cluster,
+// e.g. when the Cluster is deleted.
+func DeleteOrphanedInfrastructureMachines(namespace, cluster string) {
+	orphanedInfrastructureMachines.DeleteLabelValues(namespace, cluster)
+}
+
+// RecordBootstrapDataWait records a reconciliation of a bootstrap config deferring the generation of the bootstrap data
+// for the given reason, e.g. because the Cluster infrastructure is not ready yet.
+func RecordBootstrapDataWait(controller, reason string) {
+	bootstrapDataWaits.WithLabelValues(controller, reason).Inc()
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestErrorReason(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "Kubernetes API error",
+			err:  apierrors.NewConflict(schema.GroupResource{Resource: "machines"}, "foo", errors.New("conflict")),
+			want: "Conflict",
+		},
+		{
+			name: "wrapped Kubernetes API error",
+			err:  errors.Wrap(apierrors.NewNotFound(schema.GroupResource{Resource: "machines"}, "foo"), "failed to get machine"),
+			want: "NotFound",
+		},
+		{
+			name: "other error",
+			err:  errors.New("something went wrong"),
+			want: UnknownErrorReason,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(ErrorReason(tt.err)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestInstrumentedReconciler(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	reconcileErr := apierrors.NewConflict(schema.GroupResource{Resource: "machines"}, "foo", errors.New("conflict"))
+	calls := 0
+	r := NewInstrumentedReconciler("test", reconcile.Func(func(_ context.Context, _ ctrl.Request) (ctrl.Result, error) {
+		calls++
+		switch calls {
+		case 1:
+			return ctrl.Result{}, nil
+		case 2:
+			return ctrl.Result{RequeueAfter: time.Minute}, nil
+		default:
+			return ctrl.Result{}, reconcileErr
+		}
+	}))
+
+	res, err := r.Reconcile(ctx, ctrl.Request{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res).To(Equal(ctrl.Result{}))
+
+	res, err = r.Reconcile(ctx, ctrl.Request{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res).To(Equal(ctrl.Result{RequeueAfter: time.Minute}))
+
+	_, err = r.Reconcile(ctx, ctrl.Request{})
+	g.Expect(err).To(Equal(reconcileErr))
+
+	g.Expect(testutil.CollectAndCount(reconcileDuration)).To(Equal(3))
+	g.Expect(testutil.ToFloat64(reconcileErrors.WithLabelValues("test", "Conflict"))).To(Equal(1.0))
+}
+
+func TestRecordMachines(t *testing.T) {
+	g := NewWithT(t)
+
+	RecordMachineCreated("TestOwner")
+	RecordMachineCreated("TestOwner")
+	RecordMachineDeleted("TestOwner")
+	RecordRemediation("TestOwner")
+
+	g.Expect(testutil.ToFloat64(machinesCreated.WithLabelValues("TestOwner"))).To(Equal(2.0))
+	g.Expect(testutil.ToFloat64(machinesDeleted.WithLabelValues("TestOwner"))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(remediations.WithLabelValues("TestOwner"))).To(Equal(1.0))
+}
+
+func TestRecordMachinePhaseDuration(t *testing.T) {
+	g := NewWithT(t)
+
+	RecordMachinePhaseDuration("Provisioning", 30*time.Second)
+	RecordMachinePhaseDuration("Provisioned", 10*time.Second)
+
+	g.Expect(testutil.CollectAndCount(machinePhaseDuration)).To(Equal(2))
+}
+
+func TestRecordOrphanedInfrastructureMachines(t *testing.T) {
+	g := NewWithT(t)
+
+	RecordOrphanedInfrastructureMachines("ns1", "cluster1", 2)
+	g.Expect(testutil.ToFloat64(orphanedInfrastructureMachines.WithLabelValues("ns1", "cluster1"))).To(Equal(2.0))
+
+	DeleteOrphanedInfrastructureMachines("ns1", "cluster1")
+	g.Expect(testutil.CollectAndCount(orphanedInfrastructureMachines)).To(Equal(0))
+}
+
+func TestRecordBootstrapDataWait(t *testing.T) {
+	g := NewWithT(t)
+
+	RecordBootstrapDataWait("test", "WaitingForClusterInfrastructure")
+	RecordBootstrapDataWait("test", "WaitingForClusterInfrastructure")
+	g.Expect(testutil.ToFloat64(bootstrapDataWaits.WithLabelValues("test", "WaitingForClusterInfrastructure"))).To(Equal(2.0))
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultClientRegistryTTL is the default time a workload cluster client is cached by a ClientRegistry.
+	DefaultClientRegistryTTL = 10 * time.Minute
+)
+
+// ClientRegistry caches clients for workload clusters, keyed by the cluster namespace/name.
+// Cached clients are rebuilt from the kubeconfig secret after a TTL, so rotated certificates are picked up
+// without rebuilding clients and TLS sessions on every call.
+type ClientRegistry struct {
+	sourceName string
+	client     client.Client
+	ttl        time.Duration
+
+	// newClient and now can be overridden for testing.
+	newClient ClusterClientGetter
+	now       func() time.Time
+
+	lock    sync.Mutex
+	entries map[client.ObjectKey]*clientRegistryEntry
+}
+
+// clientRegistryEntry is a client cached by the ClientRegistry, with the time it was created.
+type clientRegistryEntry struct {
+	client    client.Client
+	createdAt time.Time
+}
+
+// NewClientRegistry creates a new ClientRegistry; clients are created using the kubeconfig secrets read via the
+// given client, and cached for the given TTL. If ttl is zero, DefaultClientRegistryTTL is used.
+func NewClientRegistry(sourceName string, c client.Client, ttl time.Duration) *ClientRegistry {
+	if ttl == 0 {
+		ttl = DefaultClientRegistryTTL
+	}
+	return &ClientRegistry{
+		sourceName: sourceName,
+		client:     c,
+		ttl:        ttl,
+		newClient:  NewClusterClient,
+		now:        time.Now,
+		entries:    make(map[client.ObjectKey]*clientRegistryEntry),
+	}
+}
+
+// GetClient returns a client for the given cluster, reusing the cached one if it is not expired.
+// The lock is not held while creating a new client, so slow or unreachable clusters do not block
+// getting the clients for other clusters.
+func (r *ClientRegistry) GetClient(ctx context.Context, cluster client.ObjectKey) (client.Client, error) {
+	r.lock.Lock()
+	expired, ok := r.entries[cluster]
+	if ok && r.now().Sub(expired.createdAt) < r.ttl {
+		r.lock.Unlock()
+		return expired.client, nil
+	}
+	r.lock.Unlock()
+
+	c, err := r.newClient(ctx, r.sourceName, r.client, cluster)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err != nil {
+		// Drop the expired entry, if any, so a client with stale credentials is not used anymore; entries
+		// stored in the meantime by other callers are kept.
+		if e, ok := r.entries[cluster]; ok && e == expired {
+			delete(r.entries, cluster)
+		}
+		return nil, err
+	}
+
+	r.entries[cluster] = &clientRegistryEntry{
+		client:    c,
+		createdAt: r.now(),
+	}
+	return c, nil
+}
+
+// Delete removes the client for the given cluster from the registry, e.g. when the cluster is deleted
+// or its kubeconfig secret is known to be changed.
+func (r *ClientRegistry) Delete(cluster client.ObjectKey) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.entries, cluster)
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestClientRegistry(t *testing.T) {
+	g := NewWithT(t)
+
+	now := time.Now()
+	calls := map[client.ObjectKey]int{}
+	fail := false
+
+	r := NewClientRegistry("test-source", fake.NewClientBuilder().Build(), time.Minute)
+	r.now = func() time.Time { return now }
+	r.newClient = func(_ context.Context, _ string, _ client.Client, cluster client.ObjectKey) (client.Client, error) {
+		if fail {
+			return nil, errors.New("failed to create client")
+		}
+		calls[cluster]++
+		return fake.NewClientBuilder().Build(), nil
+	}
+
+	cluster1 := client.ObjectKey{Namespace: "test", Name: "cluster1"}
+	cluster2 := client.ObjectKey{Namespace: "test", Name: "cluster2"}
+
+	// Clients are created once per cluster and then reused.
+	c1, err := r.GetClient(ctx, cluster1)
+	g.Expect(err).NotTo(HaveOccurred())
+	c1Again, err := r.GetClient(ctx, cluster1)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(c1Again).To(BeIdenticalTo(c1))
+
+	c2, err := r.GetClient(ctx, cluster2)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(c2).NotTo(BeIdenticalTo(c1))
+	g.Expect(calls).To(Equal(map[client.ObjectKey]int{cluster1: 1, cluster2: 1}))
+
+	// Clients are rebuilt after the TTL expires.
+	now = now.Add(time.Minute)
+	c1Refreshed, err := r.GetClient(ctx, cluster1)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(c1Refreshed).NotTo(BeIdenticalTo(c1))
+	g.Expect(calls[cluster1]).To(Equal(2))
+
+	// Clients are rebuilt after being deleted.
+	r.Delete(cluster1)
+	_, err = r.GetClient(ctx, cluster1)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(calls[cluster1]).To(Equal(3))
+
+	// Expired clients are dropped if they can't be rebuilt.
+	now = now.Add(time.Minute)
+	fail = true
+	_, err = r.GetClient(ctx, cluster2)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(r.entries).NotTo(HaveKey(cluster2))
+}
+
+func TestClientRegistryGetClientDoesNotBlockOtherClusters(t *testing.T) {
+	g := NewWithT(t)
+
+	cluster1 := client.ObjectKey{Namespace: "test", Name: "cluster1"}
+	cluster2 := client.ObjectKey{Namespace: "test", Name: "cluster2"}
+
+	creating := make(chan struct{})
+	release := make(chan struct{})
+
+	r := NewClientRegistry("test-source", fake.NewClientBuilder().Build(), time.Minute)
+	r.newClient = func(_ context.Context, _ string, _ client.Client, cluster client.ObjectKey) (client.Client, error) {
+		if cluster == cluster1 {
+			// Simulate a slow workload cluster.
+			close(creating)
+			<-release
+		}
+		return fake.NewClientBuilder().Build(), nil
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := r.GetClient(ctx, cluster1)
+		errs <- err
+	}()
+	<-creating
+
+	// Getting a client for another cluster succeeds while the client for cluster1 is being created.
+	_, err := r.GetClient(ctx, cluster2)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	close(release)
+	g.Expect(<-errs).NotTo(HaveOccurred())
+	g.Expect(r.entries).To(HaveKey(cluster1))
+	g.Expect(r.entries).To(HaveKey(cluster2))
+}
@@ -35,7 +35,20 @@ const (
 type ClusterClientGetter func(ctx context.Context, sourceName string, c client.Client, cluster client.ObjectKey) (client.Client, error)
 
 // NewClusterClient returns a Client for interacting with a remote Cluster using the given scheme for encoding and decoding objects.
+// If a request fails because the certificates in the kubeconfig are expired, the client is transparently rebuilt
+// from the kubeconfig secret and the request is retried.
 func NewClusterClient(ctx context.Context, sourceName string, c client.Client, cluster client.ObjectKey) (client.Client, error) {
+	ret, err := newClusterClient(ctx, sourceName, c, cluster)
+	if err != nil {
+		return nil, err
+	}
+	return newRefreshingClient(ret, func(ctx context.Context) (client.Client, error) {
+		return newClusterClient(ctx, sourceName, c, cluster)
+	}), nil
+}
+
+// newClusterClient returns a Client for interacting with a remote Cluster, built from the current kubeconfig secret.
+func newClusterClient(ctx context.Context, sourceName string, c client.Client, cluster client.ObjectKey) (client.Client, error) {
 	restConfig, err := RESTConfig(ctx, sourceName, c, cluster)
 	if err != nil {
 		return nil, err
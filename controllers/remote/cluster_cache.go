@@ -329,6 +329,11 @@ func (t *ClusterCacheTracker) healthCheckCluster(ctx context.Context, in *health
 		// If no error occurs, reset the unhealthy counter.
 		_, err := restClient.Get().AbsPath(in.path).Timeout(in.requestTimeout).DoRaw(ctx)
 		if err != nil {
+			// If the credentials in use are expired, there is no point in retrying; give up immediately,
+			// so the cache is rebuilt from the current kubeconfig secret on the next request.
+			if shouldRefreshCredentials(err) {
+				return false, errors.Wrap(err, "credentials for the remote cluster are expired")
+			}
 			unhealthyCount++
 		} else {
 			unhealthyCount = 0
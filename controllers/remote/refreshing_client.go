@@ -0,0 +1,195 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"context"
+	"crypto/x509"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// tlsExpiredCertificateAlert is the error reported by crypto/tls when the remote end rejects an expired certificate.
+const tlsExpiredCertificateAlert = "tls: expired certificate"
+
+// IsCertificateExpiredError returns true if the error is caused by an expired certificate, either the
+// certificate of the workload cluster API server or the client certificate embedded in the kubeconfig.
+func IsCertificateExpiredError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var certErr x509.CertificateInvalidError
+	if errors.As(err, &certErr) && certErr.Reason == x509.Expired {
+		return true
+	}
+	return strings.Contains(err.Error(), tlsExpiredCertificateAlert)
+}
+
+// shouldRefreshCredentials returns true if the error suggests the credentials used for connecting to the
+// workload cluster are no longer valid. Please note that the API server answers requests authenticated with an
+// expired client certificate with Unauthorized, so this error is considered as well.
+func shouldRefreshCredentials(err error) bool {
+	return IsCertificateExpiredError(err) || apierrors.IsUnauthorized(err)
+}
+
+// refreshingClient is a client for a workload cluster which, when a request fails because the credentials
+// in use are expired, is rebuilt from the kubeconfig secret and retries the request once.
+type refreshingClient struct {
+	lock    sync.RWMutex
+	current client.Client
+	refresh func(ctx context.Context) (client.Client, error)
+}
+
+var _ client.Client = &refreshingClient{}
+
+// newRefreshingClient returns a refreshingClient wrapping c, and using refresh for rebuilding it.
+func newRefreshingClient(c client.Client, refresh func(ctx context.Context) (client.Client, error)) *refreshingClient {
+	return &refreshingClient{
+		current: c,
+		refresh: refresh,
+	}
+}
+
+// get returns the client currently in use.
+func (r *refreshingClient) get() client.Client {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.current
+}
+
+// rebuild replaces the given client with a new one, unless it has already been replaced by a concurrent request.
+func (r *refreshingClient) rebuild(ctx context.Context, stale client.Client) (client.Client, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.current != stale {
+		return r.current, nil
+	}
+
+	c, err := r.refresh(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.current = c
+	return c, nil
+}
+
+// do runs fn with the current client; if fn fails because of expired credentials, the client is
+// rebuilt and fn is run once more.
+func (r *refreshingClient) do(ctx context.Context, fn func(c client.Client) error) error {
+	c := r.get()
+	err := fn(c)
+	if !shouldRefreshCredentials(err) {
+		return err
+	}
+
+	c, refreshErr := r.rebuild(ctx, c)
+	if refreshErr != nil {
+		return errors.Wrapf(refreshErr, "failed to refresh the client after error: %v", err)
+	}
+	return fn(c)
+}
+
+// Get implements client.Client.
+func (r *refreshingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	return r.do(ctx, func(c client.Client) error {
+		return c.Get(ctx, key, obj)
+	})
+}
+
+// List implements client.Client.
+func (r *refreshingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return r.do(ctx, func(c client.Client) error {
+		return c.List(ctx, list, opts...)
+	})
+}
+
+// Create implements client.Client.
+func (r *refreshingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	return r.do(ctx, func(c client.Client) error {
+		return c.Create(ctx, obj, opts...)
+	})
+}
+
+// Delete implements client.Client.
+func (r *refreshingClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	return r.do(ctx, func(c client.Client) error {
+		return c.Delete(ctx, obj, opts...)
+	})
+}
+
+// Update implements client.Client.
+func (r *refreshingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	return r.do(ctx, func(c client.Client) error {
+		return c.Update(ctx, obj, opts...)
+	})
+}
+
+// Patch implements client.Client.
+func (r *refreshingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return r.do(ctx, func(c client.Client) error {
+		return c.Patch(ctx, obj, patch, opts...)
+	})
+}
+
+// DeleteAllOf implements client.Client.
+func (r *refreshingClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	return r.do(ctx, func(c client.Client) error {
+		return c.DeleteAllOf(ctx, obj, opts...)
+	})
+}
+
+// Status implements client.Client.
+func (r *refreshingClient) Status() client.StatusWriter {
+	return &refreshingStatusWriter{client: r}
+}
+
+// Scheme implements client.Client.
+func (r *refreshingClient) Scheme() *runtime.Scheme {
+	return r.get().Scheme()
+}
+
+// RESTMapper implements client.Client.
+func (r *refreshingClient) RESTMapper() meta.RESTMapper {
+	return r.get().RESTMapper()
+}
+
+// refreshingStatusWriter is the client.StatusWriter of a refreshingClient.
+type refreshingStatusWriter struct {
+	client *refreshingClient
+}
+
+// Update implements client.StatusWriter.
+func (w *refreshingStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	return w.client.do(ctx, func(c client.Client) error {
+		return c.Status().Update(ctx, obj, opts...)
+	})
+}
+
+// Patch implements client.StatusWriter.
+func (w *refreshingStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return w.client.do(ctx, func(c client.Client) error {
+		return c.Status().Patch(ctx, obj, patch, opts...)
+	})
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"context"
+	"crypto/x509"
+	"net/url"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// expiredClient is a client whose credentials are expired.
+type expiredClient struct {
+	client.Client
+}
+
+func (c *expiredClient) Get(_ context.Context, _ client.ObjectKey, _ client.Object) error {
+	return &url.Error{Op: "Get", URL: "https://example.com", Err: x509.CertificateInvalidError{Reason: x509.Expired}}
+}
+
+func TestIsCertificateExpiredError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "nil",
+			err:  nil,
+			want: false,
+		},
+		{
+			name: "expired x509 certificate",
+			err:  &url.Error{Op: "Get", URL: "https://example.com", Err: x509.CertificateInvalidError{Reason: x509.Expired}},
+			want: true,
+		},
+		{
+			name: "wrapped expired x509 certificate",
+			err:  errors.Wrap(x509.CertificateInvalidError{Reason: x509.Expired}, "failed to get"),
+			want: true,
+		},
+		{
+			name: "other x509 error",
+			err:  x509.CertificateInvalidError{Reason: x509.NotAuthorizedToSign},
+			want: false,
+		},
+		{
+			name: "expired certificate tls alert",
+			err:  errors.New("Get \"https://example.com\": remote error: tls: expired certificate"),
+			want: true,
+		},
+		{
+			name: "other error",
+			err:  errors.New("connection refused"),
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(IsCertificateExpiredError(tt.err)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestRefreshingClient(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod",
+			Namespace: metav1.NamespaceDefault,
+		},
+	}
+
+	t.Run("rebuilds the client and retries when the certificates are expired", func(t *testing.T) {
+		g := NewWithT(t)
+
+		refreshed := 0
+		c := newRefreshingClient(&expiredClient{Client: fake.NewClientBuilder().Build()}, func(ctx context.Context) (client.Client, error) {
+			refreshed++
+			return fake.NewClientBuilder().WithObjects(pod).Build(), nil
+		})
+
+		g.Expect(c.Get(ctx, client.ObjectKeyFromObject(pod), &corev1.Pod{})).To(Succeed())
+		g.Expect(refreshed).To(Equal(1))
+
+		// The refreshed client is used by the following requests.
+		g.Expect(c.Get(ctx, client.ObjectKeyFromObject(pod), &corev1.Pod{})).To(Succeed())
+		g.Expect(refreshed).To(Equal(1))
+	})
+
+	t.Run("does not rebuild the client for other errors", func(t *testing.T) {
+		g := NewWithT(t)
+
+		refreshed := 0
+		c := newRefreshingClient(fake.NewClientBuilder().Build(), func(ctx context.Context) (client.Client, error) {
+			refreshed++
+			return fake.NewClientBuilder().WithObjects(pod).Build(), nil
+		})
+
+		err := c.Get(ctx, client.ObjectKeyFromObject(pod), &corev1.Pod{})
+		g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		g.Expect(refreshed).To(Equal(0))
+	})
+
+	t.Run("returns error if the client cannot be rebuilt", func(t *testing.T) {
+		g := NewWithT(t)
+
+		c := newRefreshingClient(&expiredClient{Client: fake.NewClientBuilder().Build()}, func(ctx context.Context) (client.Client, error) {
+			return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, "cluster-kubeconfig")
+		})
+
+		err := c.Get(ctx, client.ObjectKeyFromObject(pod), &corev1.Pod{})
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("failed to refresh the client"))
+	})
+}
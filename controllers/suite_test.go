@@ -31,6 +31,7 @@ import (
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/controllers/remote"
 	"sigs.k8s.io/cluster-api/test/helpers"
+	"sigs.k8s.io/cluster-api/util"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/envtest/printer"
@@ -51,6 +52,10 @@ func TestMain(m *testing.M) {
 	fmt.Println("Creating new test environment")
 	testEnv = helpers.NewTestEnvironment()
 
+	if err := util.AddClusterNameIndex(ctx, testEnv.Manager.GetFieldIndexer(), &clusterv1.MachineDeployment{}, &clusterv1.MachineSet{}, &clusterv1.Machine{}); err != nil {
+		panic(fmt.Sprintf("unable to add the cluster name index: %v", err))
+	}
+
 	// Set up a ClusterCacheTracker and ClusterCacheReconciler to provide to controllers
 	// requiring a connection to a remote cluster
 	tracker, err := remote.NewClusterCacheTracker(
@@ -0,0 +1,187 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package topology
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io;bootstrap.cluster.x-k8s.io;controlplane.cluster.x-k8s.io,resources=*,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusterclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinedeployments;machinedeployments/status,verbs=get;list;watch;create;update;patch;delete
+
+// ClusterReconciler reconciles the managed topology of a Cluster object, creating and
+// continuously reconciling its infrastructure cluster, control plane and MachineDeployments
+// from the ClusterClass referenced in Cluster.Spec.Topology.
+type ClusterReconciler struct {
+	Client           client.Client
+	WatchFilterValue string
+
+	externalTracker external.ObjectTracker
+}
+
+func (r *ClusterReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	c, err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterv1.Cluster{}, builder.WithPredicates(
+			// Only reconcile Cluster with topology.
+			predicates.ClusterHasTopology(ctrl.LoggerFrom(ctx)),
+		)).
+		Named("topology/cluster").
+		Watches(
+			&source.Kind{Type: &clusterv1.ClusterClass{}},
+			handler.EnqueueRequestsFromMapFunc(r.clusterClassToCluster),
+		).
+		Watches(
+			&source.Kind{Type: &clusterv1.MachineDeployment{}},
+			handler.EnqueueRequestsFromMapFunc(r.machineDeploymentToCluster),
+		).
+		WithOptions(options).
+		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
+		Build(metrics.NewInstrumentedReconciler("topology/cluster", r))
+
+	if err != nil {
+		return errors.Wrap(err, "failed setting up with a controller manager")
+	}
+
+	r.externalTracker = external.ObjectTracker{
+		Controller: c,
+	}
+	return nil
+}
+
+func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	// Fetch the Cluster instance.
+	cluster := &clusterv1.Cluster{}
+	if err := r.Client.Get(ctx, req.NamespacedName, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return ctrl.Result{}, err
+	}
+
+	// Return early if the Cluster does not use a managed topology.
+	if cluster.Spec.Topology == nil {
+		return ctrl.Result{}, nil
+	}
+
+	// Return early if the object or Cluster is paused.
+	if annotations.IsPaused(cluster, cluster) {
+		log.Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
+	// Objects generated from the topology are owned by the Cluster and get deleted together with it.
+	if !cluster.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	// Initialize the patch helper.
+	patchHelper, err := patch.NewHelper(cluster, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		// Always attempt to patch the Cluster object, so references to the objects created
+		// from the topology are persisted.
+		if err := patchHelper.Patch(ctx, cluster); err != nil {
+			reterr = kerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
+	return ctrl.Result{}, r.reconcile(ctx, cluster)
+}
+
+// reconcile handles the reconciliation of a Cluster with a managed topology.
+func (r *ClusterReconciler) reconcile(ctx context.Context, cluster *clusterv1.Cluster) error {
+	class := &clusterv1.ClusterClass{}
+	classKey := client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Spec.Topology.Class}
+	if err := r.Client.Get(ctx, classKey, class); err != nil {
+		return errors.Wrapf(err, "failed to retrieve ClusterClass %q", classKey.String())
+	}
+
+	// The infrastructure cluster and the control plane must be reconciled before the MachineDeployments,
+	// so Kubernetes version upgrades are rolled out to the control plane first.
+	if err := r.reconcileInfrastructureCluster(ctx, cluster, class); err != nil {
+		return err
+	}
+	if err := r.reconcileControlPlane(ctx, cluster, class); err != nil {
+		return err
+	}
+	return r.reconcileMachineDeployments(ctx, cluster, class)
+}
+
+// clusterClassToCluster is a handler.ToRequestsFunc to be used to enqueue requests for reconciliation
+// for the Clusters using a ClusterClass.
+func (r *ClusterReconciler) clusterClassToCluster(o client.Object) []ctrl.Request {
+	class, ok := o.(*clusterv1.ClusterClass)
+	if !ok {
+		panic(errors.Errorf("expected a ClusterClass but got a %T", o))
+	}
+
+	clusterList := &clusterv1.ClusterList{}
+	if err := r.Client.List(context.TODO(), clusterList, client.InNamespace(class.Namespace)); err != nil {
+		return nil
+	}
+
+	var result []ctrl.Request
+	for i := range clusterList.Items {
+		cluster := &clusterList.Items[i]
+		if cluster.Spec.Topology != nil && cluster.Spec.Topology.Class == class.Name {
+			result = append(result, ctrl.Request{NamespacedName: util.ObjectKey(cluster)})
+		}
+	}
+	return result
+}
+
+// machineDeploymentToCluster is a handler.ToRequestsFunc to be used to enqueue requests for reconciliation
+// for the Cluster owning a MachineDeployment generated from a managed topology.
+func (r *ClusterReconciler) machineDeploymentToCluster(o client.Object) []ctrl.Request {
+	md, ok := o.(*clusterv1.MachineDeployment)
+	if !ok {
+		panic(errors.Errorf("expected a MachineDeployment but got a %T", o))
+	}
+
+	if _, ok := md.Labels[clusterv1.ClusterTopologyOwnedLabel]; !ok {
+		return nil
+	}
+
+	return []ctrl.Request{{
+		NamespacedName: client.ObjectKey{Namespace: md.Namespace, Name: md.Spec.ClusterName},
+	}}
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package desiredstate computes the objects generated from the managed topology of a Cluster; it is shared
+// by the topology controller and by clusterctl alpha topology plan, so both generate the same objects.
+package desiredstate
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/controllers/external"
+)
+
+// InfrastructureCluster returns the infrastructure cluster generated from the ClusterClass infrastructure template.
+// The infrastructure cluster is named after the Cluster, so it is not created twice if the reference to it
+// is not persisted in the Cluster.
+func InfrastructureCluster(cluster *clusterv1.Cluster, class *clusterv1.ClusterClass, template *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	infraCluster, err := external.GenerateTemplate(&external.GenerateTemplateInput{
+		Template:    template,
+		TemplateRef: class.Spec.Infrastructure.Ref,
+		Namespace:   cluster.Namespace,
+		ClusterName: cluster.Name,
+		Labels:      Labels(cluster),
+	})
+	if err != nil {
+		return nil, err
+	}
+	infraCluster.SetName(cluster.Name)
+	return infraCluster, nil
+}
+
+// ControlPlane returns the control plane generated from the ClusterClass control plane template, with the
+// fields managed by the topology set. The control plane is named after the Cluster, so it is not created twice
+// if the reference to it is not persisted in the Cluster.
+func ControlPlane(cluster *clusterv1.Cluster, class *clusterv1.ClusterClass, template *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	topology := cluster.Spec.Topology
+
+	controlPlane, err := external.GenerateTemplate(&external.GenerateTemplateInput{
+		Template:    template,
+		TemplateRef: class.Spec.ControlPlane.Ref,
+		Namespace:   cluster.Namespace,
+		ClusterName: cluster.Name,
+		Labels:      MergeMap(Labels(cluster), topology.ControlPlane.Metadata.Labels),
+	})
+	if err != nil {
+		return nil, err
+	}
+	controlPlane.SetAnnotations(MergeMap(controlPlane.GetAnnotations(), topology.ControlPlane.Metadata.Annotations))
+	controlPlane.SetName(cluster.Name)
+
+	if err := SetControlPlaneFields(controlPlane, topology); err != nil {
+		return nil, err
+	}
+	return controlPlane, nil
+}
+
+// SetControlPlaneFields sets the control plane fields managed by the topology.
+func SetControlPlaneFields(controlPlane *unstructured.Unstructured, topology *clusterv1.Topology) error {
+	if err := unstructured.SetNestedField(controlPlane.Object, topology.Version, "spec", "version"); err != nil {
+		return errors.Wrapf(err, "failed to set spec.version on %s %q", controlPlane.GetKind(), controlPlane.GetName())
+	}
+	if topology.ControlPlane.Replicas != nil {
+		if err := unstructured.SetNestedField(controlPlane.Object, int64(*topology.ControlPlane.Replicas), "spec", "replicas"); err != nil {
+			return errors.Wrapf(err, "failed to set spec.replicas on %s %q", controlPlane.GetKind(), controlPlane.GetName())
+		}
+	}
+	return nil
+}
+
+// MachineDeploymentName returns the name of the MachineDeployment generated from a MachineDeploymentTopology.
+// The MachineDeployment and its templates are named after the Cluster and the MachineDeployment topology, so
+// templates created by a previous attempt are reused if the MachineDeployment failed to be created.
+func MachineDeploymentName(cluster *clusterv1.Cluster, mdTopology *clusterv1.MachineDeploymentTopology) string {
+	return fmt.Sprintf("%s-%s", cluster.Name, mdTopology.Name)
+}
+
+// BootstrapTemplateName returns the name of the bootstrap template generated for a MachineDeploymentTopology.
+func BootstrapTemplateName(cluster *clusterv1.Cluster, mdTopology *clusterv1.MachineDeploymentTopology) string {
+	return MachineDeploymentName(cluster, mdTopology) + "-bootstrap"
+}
+
+// InfrastructureMachineTemplateName returns the name of the infrastructure machine template generated for
+// a MachineDeploymentTopology.
+func InfrastructureMachineTemplateName(cluster *clusterv1.Cluster, mdTopology *clusterv1.MachineDeploymentTopology) string {
+	return MachineDeploymentName(cluster, mdTopology) + "-infrastructure"
+}
+
+// MachineDeploymentLabels returns the labels to be applied to the MachineDeployment generated from
+// a MachineDeploymentTopology and to its templates.
+func MachineDeploymentLabels(cluster *clusterv1.Cluster, mdTopology *clusterv1.MachineDeploymentTopology) map[string]string {
+	labels := Labels(cluster)
+	labels[clusterv1.ClusterTopologyMachineDeploymentLabelName] = mdTopology.Name
+	return labels
+}
+
+// MachineDeployment returns the MachineDeployment generated from a MachineDeploymentTopology and the corresponding
+// MachineDeploymentClass, using the given bootstrap and infrastructure templates and Kubernetes version.
+func MachineDeployment(cluster *clusterv1.Cluster, mdTopology *clusterv1.MachineDeploymentTopology, mdClass *clusterv1.MachineDeploymentClass, bootstrapRef, infrastructureRef *corev1.ObjectReference, version string) *clusterv1.MachineDeployment {
+	selectorLabels := map[string]string{
+		clusterv1.ClusterLabelName:                          cluster.Name,
+		clusterv1.ClusterTopologyMachineDeploymentLabelName: mdTopology.Name,
+	}
+
+	return &clusterv1.MachineDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      MachineDeploymentName(cluster, mdTopology),
+			Namespace: cluster.Namespace,
+			Labels:    MachineDeploymentLabels(cluster, mdTopology),
+		},
+		Spec: clusterv1.MachineDeploymentSpec{
+			ClusterName: cluster.Name,
+			Replicas:    mdTopology.Replicas,
+			Selector:    metav1.LabelSelector{MatchLabels: selectorLabels},
+			Template: clusterv1.MachineTemplateSpec{
+				ObjectMeta: clusterv1.ObjectMeta{
+					Labels:      MergeMap(mdClass.Template.Metadata.Labels, mdTopology.Metadata.Labels, selectorLabels),
+					Annotations: MergeMap(mdClass.Template.Metadata.Annotations, mdTopology.Metadata.Annotations),
+				},
+				Spec: clusterv1.MachineSpec{
+					ClusterName:       cluster.Name,
+					Version:           &version,
+					Bootstrap:         clusterv1.Bootstrap{ConfigRef: bootstrapRef},
+					InfrastructureRef: *infrastructureRef,
+				},
+			},
+		},
+	}
+}
+
+// TemplateClone returns a copy of a template referenced from a ClusterClass, so the objects generated from the
+// topology do not depend on the lifecycle of the ClusterClass templates.
+func TemplateClone(cluster *clusterv1.Cluster, ref *corev1.ObjectReference, from *unstructured.Unstructured, name string, labels map[string]string) *unstructured.Unstructured {
+	to := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	to.SetAPIVersion(from.GetAPIVersion())
+	to.SetKind(from.GetKind())
+	to.SetName(name)
+	to.SetNamespace(cluster.Namespace)
+	to.SetLabels(MergeMap(from.GetLabels(), labels))
+	to.SetAnnotations(MergeMap(from.GetAnnotations(), map[string]string{
+		clusterv1.TemplateClonedFromNameAnnotation:      ref.Name,
+		clusterv1.TemplateClonedFromGroupKindAnnotation: ref.GroupVersionKind().GroupKind().String(),
+	}))
+	if spec, ok := from.Object["spec"]; ok {
+		to.Object["spec"] = runtime.DeepCopyJSONValue(spec)
+	}
+	return to
+}
+
+// Labels returns the labels to be applied to all the objects generated from a Cluster topology.
+func Labels(cluster *clusterv1.Cluster) map[string]string {
+	return map[string]string{
+		clusterv1.ClusterLabelName:          cluster.Name,
+		clusterv1.ClusterTopologyOwnedLabel: "",
+	}
+}
+
+// MergeMap merges maps, with values from later maps overriding values from earlier ones.
+func MergeMap(maps ...map[string]string) map[string]string {
+	m := make(map[string]string)
+	for i := range maps {
+		for k, v := range maps[i] {
+			m[k] = v
+		}
+	}
+	return m
+}
@@ -0,0 +1,317 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package topology
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/controllers/topology/desiredstate"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+)
+
+// reconcileInfrastructureCluster creates the infrastructure cluster from the ClusterClass infrastructure
+// template, if the Cluster does not reference one yet.
+func (r *ClusterReconciler) reconcileInfrastructureCluster(ctx context.Context, cluster *clusterv1.Cluster, class *clusterv1.ClusterClass) error {
+	log := ctrl.LoggerFrom(ctx)
+
+	if cluster.Spec.InfrastructureRef != nil {
+		return nil
+	}
+
+	template, err := external.Get(ctx, r.Client, class.Spec.Infrastructure.Ref, cluster.Namespace)
+	if err != nil {
+		return err
+	}
+
+	infraCluster, err := desiredstate.InfrastructureCluster(cluster, class, template)
+	if err != nil {
+		return err
+	}
+	if err := r.createOrAdopt(ctx, cluster, infraCluster); err != nil {
+		return errors.Wrapf(err, "failed to create the infrastructure cluster for Cluster %q", cluster.Name)
+	}
+
+	ref := external.GetObjectReference(infraCluster)
+	log.Info("Created infrastructure cluster from topology", "kind", ref.Kind, "name", ref.Name)
+	cluster.Spec.InfrastructureRef = ref
+	return nil
+}
+
+// reconcileControlPlane creates the control plane from the ClusterClass control plane template, if the
+// Cluster does not reference one yet, and then keeps its version and replicas in sync with the topology.
+func (r *ClusterReconciler) reconcileControlPlane(ctx context.Context, cluster *clusterv1.Cluster, class *clusterv1.ClusterClass) error {
+	log := ctrl.LoggerFrom(ctx)
+	topology := cluster.Spec.Topology
+
+	if cluster.Spec.ControlPlaneRef == nil {
+		template, err := external.Get(ctx, r.Client, class.Spec.ControlPlane.Ref, cluster.Namespace)
+		if err != nil {
+			return err
+		}
+
+		controlPlane, err := desiredstate.ControlPlane(cluster, class, template)
+		if err != nil {
+			return err
+		}
+		if err := r.createOrAdopt(ctx, cluster, controlPlane); err != nil {
+			return errors.Wrapf(err, "failed to create the control plane for Cluster %q", cluster.Name)
+		}
+
+		log.Info("Created control plane from topology", "kind", controlPlane.GetKind(), "name", controlPlane.GetName())
+		cluster.Spec.ControlPlaneRef = external.GetObjectReference(controlPlane)
+		return nil
+	}
+
+	controlPlane, err := external.Get(ctx, r.Client, cluster.Spec.ControlPlaneRef, cluster.Namespace)
+	if err != nil {
+		return err
+	}
+
+	// Watch the control plane, so MachineDeployments are upgraded as soon as it reports the topology version.
+	if err := r.externalTracker.Watch(log, controlPlane, &handler.EnqueueRequestForOwner{OwnerType: &clusterv1.Cluster{}}); err != nil {
+		return err
+	}
+
+	patchHelper, err := patch.NewHelper(controlPlane, r.Client)
+	if err != nil {
+		return err
+	}
+	if err := desiredstate.SetControlPlaneFields(controlPlane, topology); err != nil {
+		return err
+	}
+	if err := patchHelper.Patch(ctx, controlPlane); err != nil {
+		return errors.Wrapf(err, "failed to patch the control plane for Cluster %q", cluster.Name)
+	}
+	return nil
+}
+
+// reconcileMachineDeployments creates, updates and deletes the MachineDeployments generated from the
+// topology, so they always match the list of MachineDeploymentTopology defined in the Cluster.
+func (r *ClusterReconciler) reconcileMachineDeployments(ctx context.Context, cluster *clusterv1.Cluster, class *clusterv1.ClusterClass) error {
+	log := ctrl.LoggerFrom(ctx)
+
+	mdList := &clusterv1.MachineDeploymentList{}
+	if err := r.Client.List(ctx, mdList,
+		client.InNamespace(cluster.Namespace),
+		client.MatchingLabels{
+			clusterv1.ClusterLabelName:          cluster.Name,
+			clusterv1.ClusterTopologyOwnedLabel: "",
+		},
+	); err != nil {
+		return errors.Wrapf(err, "failed to list MachineDeployments for Cluster %q", cluster.Name)
+	}
+
+	current := map[string]*clusterv1.MachineDeployment{}
+	for i := range mdList.Items {
+		md := &mdList.Items[i]
+		name, ok := md.Labels[clusterv1.ClusterTopologyMachineDeploymentLabelName]
+		if !ok {
+			continue
+		}
+		current[name] = md
+	}
+
+	classes := map[string]*clusterv1.MachineDeploymentClass{}
+	for i := range class.Spec.Workers.MachineDeployments {
+		mdClass := &class.Spec.Workers.MachineDeployments[i]
+		classes[mdClass.Class] = mdClass
+	}
+
+	version, err := r.machineDeploymentVersion(ctx, cluster)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	desired := map[string]bool{}
+	if cluster.Spec.Topology.Workers != nil {
+		for i := range cluster.Spec.Topology.Workers.MachineDeployments {
+			mdTopology := &cluster.Spec.Topology.Workers.MachineDeployments[i]
+			desired[mdTopology.Name] = true
+
+			mdClass, ok := classes[mdTopology.Class]
+			if !ok {
+				errs = append(errs, errors.Errorf("failed to find MachineDeploymentClass %q in ClusterClass %q", mdTopology.Class, class.Name))
+				continue
+			}
+
+			if md, ok := current[mdTopology.Name]; ok {
+				if err := r.updateMachineDeployment(ctx, md, mdTopology, mdClass, version); err != nil {
+					errs = append(errs, err)
+				}
+				continue
+			}
+			if err := r.createMachineDeployment(ctx, cluster, mdTopology, mdClass, version); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	// Delete the MachineDeployments which are no longer part of the topology.
+	for name, md := range current {
+		if desired[name] {
+			continue
+		}
+		if err := r.Client.Delete(ctx, md); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, errors.Wrapf(err, "failed to delete MachineDeployment %q", md.Name))
+			continue
+		}
+		log.Info("Deleted MachineDeployment no longer part of the topology", "machineDeployment", md.Name)
+	}
+
+	return kerrors.NewAggregate(errs)
+}
+
+// machineDeploymentVersion returns the Kubernetes version to be used for MachineDeployments; workers are upgraded
+// only after the control plane reports it is running the version defined in the topology.
+// An empty version means existing MachineDeployments must be left at their current version.
+func (r *ClusterReconciler) machineDeploymentVersion(ctx context.Context, cluster *clusterv1.Cluster) (string, error) {
+	if cluster.Spec.ControlPlaneRef == nil {
+		return "", nil
+	}
+
+	controlPlane, err := external.Get(ctx, r.Client, cluster.Spec.ControlPlaneRef, cluster.Namespace)
+	if err != nil {
+		return "", err
+	}
+
+	version, _, err := unstructured.NestedString(controlPlane.Object, "status", "version")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read status.version from %s %q", controlPlane.GetKind(), controlPlane.GetName())
+	}
+	if version != cluster.Spec.Topology.Version {
+		return "", nil
+	}
+	return version, nil
+}
+
+// createMachineDeployment creates a MachineDeployment, together with its bootstrap and infrastructure templates,
+// from a MachineDeploymentTopology and the corresponding MachineDeploymentClass.
+func (r *ClusterReconciler) createMachineDeployment(ctx context.Context, cluster *clusterv1.Cluster, mdTopology *clusterv1.MachineDeploymentTopology, mdClass *clusterv1.MachineDeploymentClass, version string) error {
+	log := ctrl.LoggerFrom(ctx)
+
+	// New MachineDeployments are created with the topology version, unless the control plane is being upgraded.
+	if version == "" {
+		version = cluster.Spec.Topology.Version
+	}
+
+	labels := desiredstate.MachineDeploymentLabels(cluster, mdTopology)
+
+	bootstrapRef, err := r.cloneTemplate(ctx, cluster, mdClass.Template.Bootstrap.Ref, desiredstate.BootstrapTemplateName(cluster, mdTopology), labels)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the bootstrap template for MachineDeployment topology %q", mdTopology.Name)
+	}
+	infrastructureRef, err := r.cloneTemplate(ctx, cluster, mdClass.Template.Infrastructure.Ref, desiredstate.InfrastructureMachineTemplateName(cluster, mdTopology), labels)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the infrastructure template for MachineDeployment topology %q", mdTopology.Name)
+	}
+
+	md := desiredstate.MachineDeployment(cluster, mdTopology, mdClass, bootstrapRef, infrastructureRef, version)
+	if err := r.createOrAdopt(ctx, cluster, md); err != nil {
+		return errors.Wrapf(err, "failed to create MachineDeployment for topology %q", mdTopology.Name)
+	}
+
+	log.Info("Created MachineDeployment from topology", "machineDeployment", md.Name)
+	return nil
+}
+
+// updateMachineDeployment keeps an existing MachineDeployment in sync with its MachineDeploymentTopology.
+func (r *ClusterReconciler) updateMachineDeployment(ctx context.Context, md *clusterv1.MachineDeployment, mdTopology *clusterv1.MachineDeploymentTopology, mdClass *clusterv1.MachineDeploymentClass, version string) error {
+	patchHelper, err := patch.NewHelper(md, r.Client)
+	if err != nil {
+		return err
+	}
+
+	if mdTopology.Replicas != nil {
+		md.Spec.Replicas = mdTopology.Replicas
+	}
+	if version != "" {
+		md.Spec.Template.Spec.Version = &version
+	}
+	md.Spec.Template.Labels = desiredstate.MergeMap(md.Spec.Template.Labels, mdClass.Template.Metadata.Labels, mdTopology.Metadata.Labels)
+	md.Spec.Template.Annotations = desiredstate.MergeMap(md.Spec.Template.Annotations, mdClass.Template.Metadata.Annotations, mdTopology.Metadata.Annotations)
+
+	if err := patchHelper.Patch(ctx, md); err != nil {
+		return errors.Wrapf(err, "failed to patch MachineDeployment %q", md.Name)
+	}
+	return nil
+}
+
+// cloneTemplate creates a copy of a template referenced from a ClusterClass, so the objects generated from the
+// topology do not depend on the lifecycle of the ClusterClass templates.
+func (r *ClusterReconciler) cloneTemplate(ctx context.Context, cluster *clusterv1.Cluster, ref *corev1.ObjectReference, name string, labels map[string]string) (*corev1.ObjectReference, error) {
+	from, err := external.Get(ctx, r.Client, ref, cluster.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	to := desiredstate.TemplateClone(cluster, ref, from, name, labels)
+	if err := r.createOrAdopt(ctx, cluster, to); err != nil {
+		return nil, err
+	}
+	return external.GetObjectReference(to), nil
+}
+
+// createOrAdopt creates an object generated from the topology of a Cluster; if an object with the same name
+// already exists, e.g. because a previous reconcile failed before persisting the reference to it, the existing
+// object is used instead, provided it was generated from the topology of the same Cluster.
+func (r *ClusterReconciler) createOrAdopt(ctx context.Context, cluster *clusterv1.Cluster, obj client.Object) error {
+	// Objects generated from the topology are owned by the Cluster and get deleted together with it.
+	obj.SetOwnerReferences([]metav1.OwnerReference{*ownerReferenceTo(cluster)})
+
+	err := r.Client.Create(ctx, obj)
+	if err == nil || !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	gvk, err := apiutil.GVKForObject(obj, r.Client.Scheme())
+	if err != nil {
+		return err
+	}
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(gvk)
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(obj), existing); err != nil {
+		return err
+	}
+	labels := existing.GetLabels()
+	if _, ok := labels[clusterv1.ClusterTopologyOwnedLabel]; !ok || labels[clusterv1.ClusterLabelName] != cluster.Name {
+		return errors.Errorf("%q already exists and is not managed by the topology of Cluster %q", obj.GetName(), cluster.Name)
+	}
+	return nil
+}
+
+// ownerReferenceTo returns an owner reference to the Cluster.
+func ownerReferenceTo(cluster *clusterv1.Cluster) *metav1.OwnerReference {
+	return &metav1.OwnerReference{
+		APIVersion: clusterv1.GroupVersion.String(),
+		Kind:       "Cluster",
+		Name:       cluster.Name,
+		UID:        cluster.UID,
+	}
+}
@@ -0,0 +1,275 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package topology
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTemplate(apiVersion, kind, name string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": metav1.NamespaceDefault,
+		},
+		"spec": spec,
+	}}
+}
+
+func refTo(obj *unstructured.Unstructured) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Name:       obj.GetName(),
+		Namespace:  obj.GetNamespace(),
+	}
+}
+
+// newTopologyTestObjects returns a Cluster with a managed topology, together with the ClusterClass and the templates it references.
+func newTopologyTestObjects() (*clusterv1.Cluster, []client.Object) {
+	infraClusterTemplate := newTemplate("infrastructure.cluster.x-k8s.io/v1alpha4", "GenericInfrastructureClusterTemplate", "infra-cluster-template",
+		map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{"foo": "bar"}}})
+	controlPlaneTemplate := newTemplate("controlplane.cluster.x-k8s.io/v1alpha4", "GenericControlPlaneTemplate", "control-plane-template",
+		map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{"foo": "bar"}}})
+	bootstrapTemplate := newTemplate("bootstrap.cluster.x-k8s.io/v1alpha4", "GenericBootstrapConfigTemplate", "bootstrap-template",
+		map[string]interface{}{"template": map[string]interface{}{}})
+	infraMachineTemplate := newTemplate("infrastructure.cluster.x-k8s.io/v1alpha4", "GenericInfrastructureMachineTemplate", "infra-machine-template",
+		map[string]interface{}{"template": map[string]interface{}{}})
+
+	class := &clusterv1.ClusterClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "class1",
+			Namespace: metav1.NamespaceDefault,
+		},
+		Spec: clusterv1.ClusterClassSpec{
+			Infrastructure: clusterv1.LocalObjectTemplate{Ref: refTo(infraClusterTemplate)},
+			ControlPlane:   clusterv1.LocalObjectTemplate{Ref: refTo(controlPlaneTemplate)},
+			Workers: clusterv1.WorkersClass{
+				MachineDeployments: []clusterv1.MachineDeploymentClass{
+					{
+						Class: "linux-worker",
+						Template: clusterv1.MachineDeploymentClassTemplate{
+							Metadata: clusterv1.ObjectMeta{
+								Labels: map[string]string{"os": "linux", "tier": "class"},
+							},
+							Bootstrap:      clusterv1.LocalObjectTemplate{Ref: refTo(bootstrapTemplate)},
+							Infrastructure: clusterv1.LocalObjectTemplate{Ref: refTo(infraMachineTemplate)},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster1",
+			Namespace: metav1.NamespaceDefault,
+		},
+		Spec: clusterv1.ClusterSpec{
+			Topology: &clusterv1.Topology{
+				Class:   "class1",
+				Version: "v1.20.1",
+				ControlPlane: clusterv1.ControlPlaneTopology{
+					Replicas: pointer.Int32Ptr(3),
+				},
+				Workers: &clusterv1.WorkersTopology{
+					MachineDeployments: []clusterv1.MachineDeploymentTopology{
+						{
+							Class: "linux-worker",
+							Name:  "md-0",
+							Metadata: clusterv1.ObjectMeta{
+								Labels: map[string]string{"tier": "topology"},
+							},
+							Replicas: pointer.Int32Ptr(2),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	return cluster, []client.Object{class, infraClusterTemplate, controlPlaneTemplate, bootstrapTemplate, infraMachineTemplate}
+}
+
+func TestReconcileTopology(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	ctx := context.Background()
+
+	cluster, objs := newTopologyTestObjects()
+
+	c := fake.NewClientBuilder().
+		WithObjects(append(objs, cluster)...).
+		Build()
+	r := &ClusterReconciler{Client: c}
+
+	t.Run("creates the objects defined by the topology", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(r.reconcile(ctx, cluster)).To(Succeed())
+
+		g.Expect(cluster.Spec.InfrastructureRef).NotTo(BeNil())
+		g.Expect(cluster.Spec.InfrastructureRef.Kind).To(Equal("GenericInfrastructureCluster"))
+		infraCluster, err := external.Get(ctx, c, cluster.Spec.InfrastructureRef, cluster.Namespace)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(infraCluster.GetLabels()).To(HaveKeyWithValue(clusterv1.ClusterLabelName, cluster.Name))
+		g.Expect(infraCluster.GetLabels()).To(HaveKey(clusterv1.ClusterTopologyOwnedLabel))
+
+		g.Expect(cluster.Spec.ControlPlaneRef).NotTo(BeNil())
+		g.Expect(cluster.Spec.ControlPlaneRef.Kind).To(Equal("GenericControlPlane"))
+		controlPlane, err := external.Get(ctx, c, cluster.Spec.ControlPlaneRef, cluster.Namespace)
+		g.Expect(err).NotTo(HaveOccurred())
+		version, _, _ := unstructured.NestedString(controlPlane.Object, "spec", "version")
+		g.Expect(version).To(Equal("v1.20.1"))
+		replicas, _, _ := unstructured.NestedInt64(controlPlane.Object, "spec", "replicas")
+		g.Expect(replicas).To(Equal(int64(3)))
+
+		mds := &clusterv1.MachineDeploymentList{}
+		g.Expect(c.List(ctx, mds, client.MatchingLabels{clusterv1.ClusterTopologyMachineDeploymentLabelName: "md-0"})).To(Succeed())
+		g.Expect(mds.Items).To(HaveLen(1))
+		md := mds.Items[0]
+		g.Expect(md.Spec.ClusterName).To(Equal(cluster.Name))
+		g.Expect(*md.Spec.Replicas).To(Equal(int32(2)))
+		g.Expect(*md.Spec.Template.Spec.Version).To(Equal("v1.20.1"))
+		g.Expect(md.Spec.Template.Labels).To(HaveKeyWithValue("os", "linux"))
+		g.Expect(md.Spec.Template.Labels).To(HaveKeyWithValue("tier", "topology"))
+		g.Expect(md.Spec.Template.Spec.Bootstrap.ConfigRef.Kind).To(Equal("GenericBootstrapConfigTemplate"))
+		g.Expect(md.Spec.Template.Spec.Bootstrap.ConfigRef.Name).To(Equal("cluster1-md-0-bootstrap"))
+		g.Expect(md.Spec.Template.Spec.InfrastructureRef.Kind).To(Equal("GenericInfrastructureMachineTemplate"))
+		g.Expect(md.Spec.Template.Spec.InfrastructureRef.Name).To(Equal("cluster1-md-0-infrastructure"))
+	})
+
+	t.Run("upgrades MachineDeployments only after the control plane", func(t *testing.T) {
+		g := NewWithT(t)
+
+		cluster.Spec.Topology.Version = "v1.21.0"
+		cluster.Spec.Topology.Workers.MachineDeployments[0].Replicas = pointer.Int32Ptr(5)
+		g.Expect(r.reconcile(ctx, cluster)).To(Succeed())
+
+		controlPlane, err := external.Get(ctx, c, cluster.Spec.ControlPlaneRef, cluster.Namespace)
+		g.Expect(err).NotTo(HaveOccurred())
+		version, _, _ := unstructured.NestedString(controlPlane.Object, "spec", "version")
+		g.Expect(version).To(Equal("v1.21.0"))
+
+		mds := &clusterv1.MachineDeploymentList{}
+		g.Expect(c.List(ctx, mds, client.MatchingLabels{clusterv1.ClusterTopologyMachineDeploymentLabelName: "md-0"})).To(Succeed())
+		g.Expect(mds.Items).To(HaveLen(1))
+		g.Expect(*mds.Items[0].Spec.Replicas).To(Equal(int32(5)))
+		g.Expect(*mds.Items[0].Spec.Template.Spec.Version).To(Equal("v1.20.1"))
+
+		// Simulate the control plane completing the upgrade.
+		g.Expect(unstructured.SetNestedField(controlPlane.Object, "v1.21.0", "status", "version")).To(Succeed())
+		g.Expect(c.Update(ctx, controlPlane)).To(Succeed())
+		g.Expect(r.reconcile(ctx, cluster)).To(Succeed())
+
+		g.Expect(c.List(ctx, mds, client.MatchingLabels{clusterv1.ClusterTopologyMachineDeploymentLabelName: "md-0"})).To(Succeed())
+		g.Expect(mds.Items).To(HaveLen(1))
+		g.Expect(*mds.Items[0].Spec.Template.Spec.Version).To(Equal("v1.21.0"))
+	})
+
+	t.Run("deletes MachineDeployments removed from the topology", func(t *testing.T) {
+		g := NewWithT(t)
+
+		cluster.Spec.Topology.Workers.MachineDeployments = nil
+		g.Expect(r.reconcile(ctx, cluster)).To(Succeed())
+
+		mds := &clusterv1.MachineDeploymentList{}
+		g.Expect(c.List(ctx, mds, client.MatchingLabels{clusterv1.ClusterLabelName: cluster.Name})).To(Succeed())
+		g.Expect(mds.Items).To(BeEmpty())
+	})
+
+	t.Run("returns error when the MachineDeploymentClass does not exist", func(t *testing.T) {
+		g := NewWithT(t)
+
+		cluster.Spec.Topology.Workers.MachineDeployments = []clusterv1.MachineDeploymentTopology{
+			{Class: "windows-worker", Name: "md-1"},
+		}
+		g.Expect(r.reconcile(ctx, cluster)).NotTo(Succeed())
+	})
+}
+
+func TestReconcileTopologyReusesObjectsNotReferencedByTheCluster(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	ctx := context.Background()
+
+	cluster, objs := newTopologyTestObjects()
+	c := fake.NewClientBuilder().
+		WithObjects(append(objs, cluster)...).
+		Build()
+	r := &ClusterReconciler{Client: c}
+
+	g.Expect(r.reconcile(ctx, cluster.DeepCopy())).To(Succeed())
+
+	// Simulate a failure persisting the references to the generated objects in the Cluster.
+	g.Expect(r.reconcile(ctx, cluster)).To(Succeed())
+
+	g.Expect(cluster.Spec.InfrastructureRef.Name).To(Equal(cluster.Name))
+	g.Expect(cluster.Spec.ControlPlaneRef.Name).To(Equal(cluster.Name))
+
+	mds := &clusterv1.MachineDeploymentList{}
+	g.Expect(c.List(ctx, mds, client.InNamespace(cluster.Namespace))).To(Succeed())
+	g.Expect(mds.Items).To(HaveLen(1))
+}
+
+func TestReconcileTopologyReusesTemplatesFromFailedMachineDeploymentCreate(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	ctx := context.Background()
+
+	cluster, objs := newTopologyTestObjects()
+	// A MachineDeployment not generated from the topology makes the creation of the md-0 MachineDeployment fail.
+	conflicting := &clusterv1.MachineDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster1-md-0",
+			Namespace: metav1.NamespaceDefault,
+		},
+	}
+	c := fake.NewClientBuilder().
+		WithObjects(append(objs, cluster, conflicting)...).
+		Build()
+	r := &ClusterReconciler{Client: c}
+
+	g.Expect(r.reconcile(ctx, cluster)).NotTo(Succeed())
+	g.Expect(c.Delete(ctx, conflicting)).To(Succeed())
+	g.Expect(r.reconcile(ctx, cluster)).To(Succeed())
+
+	mds := &clusterv1.MachineDeploymentList{}
+	g.Expect(c.List(ctx, mds, client.InNamespace(cluster.Namespace))).To(Succeed())
+	g.Expect(mds.Items).To(HaveLen(1))
+	g.Expect(mds.Items[0].Spec.Template.Spec.Bootstrap.ConfigRef.Name).To(Equal("cluster1-md-0-bootstrap"))
+	g.Expect(mds.Items[0].Spec.Template.Spec.InfrastructureRef.Name).To(Equal("cluster1-md-0-infrastructure"))
+}
@@ -18,6 +18,7 @@ package v1alpha3
 
 import (
 	apiconversion "k8s.io/apimachinery/pkg/conversion"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha4"
 
 	utilconversion "sigs.k8s.io/cluster-api/util/conversion"
@@ -38,6 +39,15 @@ func (src *KubeadmControlPlane) ConvertTo(destRaw conversion.Hub) error {
 	}
 
 	dest.Spec.RolloutStrategy = restored.Spec.RolloutStrategy
+	dest.Spec.FailureDomainInfrastructureTemplates = restored.Spec.FailureDomainInfrastructureTemplates
+	dest.Spec.PreUpgradeEtcdBackup = restored.Spec.PreUpgradeEtcdBackup
+	dest.Spec.MachineNamingStrategy = restored.Spec.MachineNamingStrategy
+	dest.Spec.KubeadmConfigSpec.ContainerRuntime = restored.Spec.KubeadmConfigSpec.ContainerRuntime
+	dest.Spec.KubeadmConfigSpec.Proxy = restored.Spec.KubeadmConfigSpec.Proxy
+	dest.Spec.KubeadmConfigSpec.AdditionalCACertificates = restored.Spec.KubeadmConfigSpec.AdditionalCACertificates
+	dest.Spec.KubeadmConfigSpec.Patches = restored.Spec.KubeadmConfigSpec.Patches
+	restoreFileSources(dest.Spec.KubeadmConfigSpec.Files, restored.Spec.KubeadmConfigSpec.Files)
+	restoreIgnorePreflightErrors(&dest.Spec.KubeadmConfigSpec, &restored.Spec.KubeadmConfigSpec)
 
 	return nil
 }
@@ -74,3 +84,31 @@ func Convert_v1alpha4_KubeadmControlPlaneSpec_To_v1alpha3_KubeadmControlPlaneSpe
 func Convert_v1alpha3_KubeadmControlPlaneSpec_To_v1alpha4_KubeadmControlPlaneSpec(in *KubeadmControlPlaneSpec, out *v1alpha4.KubeadmControlPlaneSpec, s apiconversion.Scope) error { //nolint
 	return autoConvert_v1alpha3_KubeadmControlPlaneSpec_To_v1alpha4_KubeadmControlPlaneSpec(in, out, s)
 }
+
+// restoreFileSources restores the file sources which can't be represented in v1alpha3, as long as the
+// files have not been changed since the conversion data annotation was written.
+func restoreFileSources(dest, restored []bootstrapv1.File) {
+	if len(dest) != len(restored) {
+		return
+	}
+	for i := range dest {
+		if dest[i].Path != restored[i].Path || dest[i].ContentFrom == nil || restored[i].ContentFrom == nil {
+			continue
+		}
+		if restored[i].ContentFrom.Secret == nil {
+			dest[i].ContentFrom.Secret = nil
+		}
+		dest[i].ContentFrom.ConfigMap = restored[i].ContentFrom.ConfigMap
+	}
+}
+
+// restoreIgnorePreflightErrors restores the NodeRegistrationOptions.IgnorePreflightErrors fields, which can't be represented
+// in v1alpha3 because it uses the kubeadm v1beta1 types.
+func restoreIgnorePreflightErrors(dest, restored *bootstrapv1.KubeadmConfigSpec) {
+	if dest.InitConfiguration != nil && restored.InitConfiguration != nil {
+		dest.InitConfiguration.NodeRegistration.IgnorePreflightErrors = restored.InitConfiguration.NodeRegistration.IgnorePreflightErrors
+	}
+	if dest.JoinConfiguration != nil && restored.JoinConfiguration != nil {
+		dest.JoinConfiguration.NodeRegistration.IgnorePreflightErrors = restored.JoinConfiguration.NodeRegistration.IgnorePreflightErrors
+	}
+}
@@ -36,6 +36,21 @@ const (
 	CertificatesGenerationFailedReason = "CertificatesGenerationFailed"
 )
 
+const (
+	// KubeconfigUpToDateCondition documents that the kubeconfig Secret generated by the KubeadmControlPlane
+	// is signed by the current cluster CA, and that its client certificate is not expiring.
+	KubeconfigUpToDateCondition clusterv1.ConditionType = "KubeconfigUpToDate"
+
+	// KubeconfigRegenerationInProgressReason (Severity=Info) documents a KubeadmControlPlane regenerating the kubeconfig
+	// Secret, e.g. because the cluster CA was rotated.
+	KubeconfigRegenerationInProgressReason = "KubeconfigRegenerationInProgress"
+
+	// KubeconfigRegenerationFailedReason (Severity=Warning) documents a KubeadmControlPlane controller detecting
+	// an error while regenerating the kubeconfig Secret; those kind of errors are usually temporary and the controller
+	// automatically recover from them.
+	KubeconfigRegenerationFailedReason = "KubeconfigRegenerationFailed"
+)
+
 const (
 	// AvailableCondition documents that the first control plane instance has completed the kubeadm init operation
 	// and so the control plane is available and an API server instance is ready for processing requests.
@@ -56,6 +71,16 @@ const (
 	RollingUpdateInProgressReason = "RollingUpdateInProgress"
 )
 
+const (
+	// EtcdSnapshotTakenCondition documents that a snapshot of the etcd cluster was taken before rolling out
+	// control plane machines for a Kubernetes version upgrade, as requested by spec.preUpgradeEtcdBackup.
+	EtcdSnapshotTakenCondition clusterv1.ConditionType = "EtcdSnapshotTaken"
+
+	// EtcdSnapshotFailedReason (Severity=Warning) documents a KubeadmControlPlane controller failing to take
+	// or to save a snapshot of the etcd cluster; the upgrade does not start until the snapshot succeeds.
+	EtcdSnapshotFailedReason = "EtcdSnapshotFailed"
+)
+
 const (
 	// ResizedCondition documents a KubeadmControlPlane that is resizing the set of controlled machines.
 	ResizedCondition clusterv1.ConditionType = "Resized"
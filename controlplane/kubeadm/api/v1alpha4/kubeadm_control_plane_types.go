@@ -45,6 +45,10 @@ const (
 	// KubeadmClusterConfigurationAnnotation is a machine annotation that stores the json-marshalled string of KCP ClusterConfiguration.
 	// This annotation is used to detect any changes in ClusterConfiguration and trigger machine rollout in KCP.
 	KubeadmClusterConfigurationAnnotation = "controlplane.cluster.x-k8s.io/kubeadm-cluster-configuration"
+
+	// EtcdSnapshotVersionAnnotation is a KubeadmControlPlane annotation that stores the Kubernetes version of the last
+	// upgrade for which an etcd snapshot was taken, so the snapshot is taken only once for each upgrade.
+	EtcdSnapshotVersionAnnotation = "controlplane.cluster.x-k8s.io/etcd-snapshot-version"
 )
 
 // KubeadmControlPlaneSpec defines the desired state of KubeadmControlPlane.
@@ -62,6 +66,14 @@ type KubeadmControlPlaneSpec struct {
 	// offered by an infrastructure provider.
 	InfrastructureTemplate corev1.ObjectReference `json:"infrastructureTemplate"`
 
+	// FailureDomainInfrastructureTemplates defines infrastructure templates to be used, instead of
+	// InfrastructureTemplate, for machines placed in specific failure domains, e.g. to use a different
+	// subnet or instance type in each zone.
+	// +optional
+	// +listType=map
+	// +listMapKey=failureDomain
+	FailureDomainInfrastructureTemplates []FailureDomainInfrastructureTemplate `json:"failureDomainInfrastructureTemplates,omitempty"`
+
 	// KubeadmConfigSpec is a KubeadmConfigSpec
 	// to use for initializing and joining machines to the control plane.
 	KubeadmConfigSpec cabpkv1.KubeadmConfigSpec `json:"kubeadmConfigSpec"`
@@ -82,6 +94,52 @@ type KubeadmControlPlaneSpec struct {
 	// new ones.
 	// +optional
 	RolloutStrategy *RolloutStrategy `json:"rolloutStrategy,omitempty"`
+
+	// PreUpgradeEtcdBackup, if set, makes the KubeadmControlPlane take a snapshot of the etcd cluster
+	// before rolling out control plane machines for a Kubernetes version upgrade.
+	// NOTE: This field is supported only when using a stacked etcd cluster.
+	// +optional
+	PreUpgradeEtcdBackup *EtcdBackup `json:"preUpgradeEtcdBackup,omitempty"`
+
+	// MachineNamingStrategy allows changing the naming pattern used when creating control plane Machines.
+	// If not set, the names of the Machines are generated from the name of the KubeadmControlPlane.
+	// +optional
+	MachineNamingStrategy *MachineNamingStrategy `json:"machineNamingStrategy,omitempty"`
+}
+
+// MachineNamingStrategy defines the naming pattern of the control plane Machines.
+type MachineNamingStrategy struct {
+	// Template defines the template used to generate the names of the control plane Machines, e.g.
+	// "{{ .cluster }}-cp-{{ .random }}". The following variables are supported:
+	// - cluster: the name of the Cluster;
+	// - kubeadmControlPlane: the name of the KubeadmControlPlane;
+	// - random: a random string of 5 lowercase alphanumeric characters; it is required to avoid name collisions.
+	// Generated names must be valid DNS-1123 labels, i.e. at most 63 lowercase alphanumeric characters or '-'.
+	// +optional
+	Template string `json:"template,omitempty"`
+}
+
+// EtcdBackup defines where etcd snapshots are saved.
+type EtcdBackup struct {
+	// Target is a reference to the object where etcd snapshots are saved; supported core kinds are PersistentVolumeClaim and ConfigMap.
+	// PersistentVolumeClaims must be mounted into the KubeadmControlPlane controller in a subdirectory, named after
+	// the claim, of the directory set with the --etcd-snapshot-volumes-dir flag; the namespace of the target must not be set.
+	// ConfigMaps configure an object storage bucket, and must be in the namespace of the KubeadmControlPlane: the endpoint
+	// key is the URL of the bucket, and the optional credentialsSecret key is the name of a Secret whose token key is used
+	// as a bearer token.
+	// Snapshots are saved to files, or uploaded to objects, named <namespace>-<name>-<version>.db.
+	// Kinds in other API groups are supported by the EtcdSnapshotters registered on the KubeadmControlPlane controller.
+	Target corev1.ObjectReference `json:"target"`
+}
+
+// FailureDomainInfrastructureTemplate defines the infrastructure template to be used for machines in a failure domain.
+type FailureDomainInfrastructureTemplate struct {
+	// FailureDomain is the name of the failure domain, as reported in the Cluster status.
+	FailureDomain string `json:"failureDomain"`
+
+	// InfrastructureTemplate is a reference to a custom resource
+	// offered by an infrastructure provider.
+	InfrastructureTemplate corev1.ObjectReference `json:"infrastructureTemplate"`
 }
 
 // RolloutStrategy describes how to replace existing machines
@@ -103,7 +161,9 @@ type RolloutStrategy struct {
 type RollingUpdate struct {
 	// The maximum number of control planes that can be scheduled above or under the
 	// desired number of control planes.
-	// Value can be an absolute number 1 or 0.
+	// Value can be an absolute number 1 or 0, or a percentage of the desired number
+	// of control planes (ex: 33%) resolving to 1 or 0.
+	// Absolute number is calculated from percentage by rounding up.
 	// Defaults to 1.
 	// Example: when this is set to 1, the control plane can be scaled
 	// up immediately when the rolling update starts.
@@ -195,6 +255,27 @@ type KubeadmControlPlane struct {
 	Status KubeadmControlPlaneStatus `json:"status,omitempty"`
 }
 
+// InfrastructureTemplateForFailureDomain returns the infrastructure template to be used for machines in
+// the given failure domain.
+func (in *KubeadmControlPlane) InfrastructureTemplateForFailureDomain(failureDomain *string) *corev1.ObjectReference {
+	if failureDomain != nil {
+		for i := range in.Spec.FailureDomainInfrastructureTemplates {
+			if in.Spec.FailureDomainInfrastructureTemplates[i].FailureDomain == *failureDomain {
+				return &in.Spec.FailureDomainInfrastructureTemplates[i].InfrastructureTemplate
+			}
+		}
+	}
+	return &in.Spec.InfrastructureTemplate
+}
+
+// MachineNameTemplateVariables returns the variables available to the MachineNamingStrategy template.
+func (in *KubeadmControlPlane) MachineNameTemplateVariables(clusterName string) map[string]string {
+	return map[string]string{
+		"cluster":             clusterName,
+		"kubeadmControlPlane": in.Name,
+	}
+}
+
 func (in *KubeadmControlPlane) GetConditions() clusterv1.Conditions {
 	return in.Status.Conditions
 }
@@ -25,12 +25,16 @@ import (
 	"github.com/coredns/corefile-migration/migration"
 	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/util/container"
+	"sigs.k8s.io/cluster-api/util/names"
 	"sigs.k8s.io/cluster-api/util/version"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -59,6 +63,12 @@ func (in *KubeadmControlPlane) Default() {
 		in.Spec.InfrastructureTemplate.Namespace = in.Namespace
 	}
 
+	for i := range in.Spec.FailureDomainInfrastructureTemplates {
+		if in.Spec.FailureDomainInfrastructureTemplates[i].InfrastructureTemplate.Namespace == "" {
+			in.Spec.FailureDomainInfrastructureTemplates[i].InfrastructureTemplate.Namespace = in.Namespace
+		}
+	}
+
 	if !strings.HasPrefix(in.Spec.Version, "v") {
 		in.Spec.Version = "v" + in.Spec.Version
 	}
@@ -110,6 +120,14 @@ const (
 	scheduler            = "scheduler"
 )
 
+// The kinds of core objects supported as spec.preUpgradeEtcdBackup.target; objects in other API groups are supported
+// by the EtcdSnapshotters registered on the KubeadmControlPlane controller.
+const (
+	etcdBackupConfigMapKind             = "ConfigMap"
+	etcdBackupPersistentVolumeClaimKind = "PersistentVolumeClaim"
+	etcdBackupSecretKind                = "Secret"
+)
+
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
 func (in *KubeadmControlPlane) ValidateUpdate(old runtime.Object) error {
 	// add a * to indicate everything beneath is ok.
@@ -129,14 +147,25 @@ func (in *KubeadmControlPlane) ValidateUpdate(old runtime.Object) error {
 		{spec, kubeadmConfigSpec, preKubeadmCommands},
 		{spec, kubeadmConfigSpec, postKubeadmCommands},
 		{spec, kubeadmConfigSpec, files},
+		{spec, kubeadmConfigSpec, "containerRuntime"},
+		{spec, kubeadmConfigSpec, "containerRuntime", "*"},
+		{spec, kubeadmConfigSpec, "proxy"},
+		{spec, kubeadmConfigSpec, "proxy", "*"},
+		{spec, kubeadmConfigSpec, "additionalCACertificates"},
+		{spec, kubeadmConfigSpec, "patches"},
 		{spec, kubeadmConfigSpec, "verbosity"},
 		{spec, kubeadmConfigSpec, users},
 		{spec, "infrastructureTemplate", "name"},
+		{spec, "failureDomainInfrastructureTemplates"},
 		{spec, "replicas"},
 		{spec, "version"},
 		{spec, "upgradeAfter"},
 		{spec, "nodeDrainTimeout"},
 		{spec, "rolloutStrategy", "*"},
+		{spec, "preUpgradeEtcdBackup"},
+		{spec, "preUpgradeEtcdBackup", "*"},
+		{spec, "machineNamingStrategy"},
+		{spec, "machineNamingStrategy", "*"},
 	}
 
 	allErrs := in.validateCommon()
@@ -256,6 +285,16 @@ func (in *KubeadmControlPlane) validateCommon() (allErrs field.ErrorList) {
 		)
 	}
 
+	if in.Spec.KubeadmConfigSpec.Format == bootstrapv1.CloudbaseInit {
+		allErrs = append(
+			allErrs,
+			field.Forbidden(
+				field.NewPath(spec, kubeadmConfigSpec, "format"),
+				bootstrapv1.WindowsControlPlaneMsg,
+			),
+		)
+	}
+
 	externalEtcd := false
 	if in.Spec.KubeadmConfigSpec.ClusterConfiguration != nil {
 		if in.Spec.KubeadmConfigSpec.ClusterConfiguration.Etcd.External != nil {
@@ -286,6 +325,103 @@ func (in *KubeadmControlPlane) validateCommon() (allErrs field.ErrorList) {
 		)
 	}
 
+	failureDomains := map[string]bool{}
+	for i, t := range in.Spec.FailureDomainInfrastructureTemplates {
+		path := field.NewPath("spec", "failureDomainInfrastructureTemplates").Index(i)
+		if t.FailureDomain == "" {
+			allErrs = append(allErrs, field.Required(path.Child("failureDomain"), "must be set"))
+		}
+		if failureDomains[t.FailureDomain] {
+			allErrs = append(allErrs, field.Duplicate(path.Child("failureDomain"), t.FailureDomain))
+		}
+		failureDomains[t.FailureDomain] = true
+		if t.InfrastructureTemplate.Namespace != in.Namespace {
+			allErrs = append(
+				allErrs,
+				field.Invalid(
+					path.Child("infrastructureTemplate", "namespace"),
+					t.InfrastructureTemplate.Namespace,
+					"must match metadata.namespace",
+				),
+			)
+		}
+	}
+
+	if in.Spec.PreUpgradeEtcdBackup != nil {
+		path := field.NewPath("spec", "preUpgradeEtcdBackup")
+		if externalEtcd {
+			allErrs = append(allErrs, field.Forbidden(path, "cannot be used with external etcd"))
+		}
+		target := in.Spec.PreUpgradeEtcdBackup.Target
+		isCore := target.APIVersion == "" || target.APIVersion == corev1.SchemeGroupVersion.String()
+		switch {
+		case target.Kind == "":
+			allErrs = append(allErrs, field.Required(path.Child("target", "kind"), "must be set"))
+		case !isCore:
+			// Objects in other API groups are saved by the EtcdSnapshotters registered on the controller.
+		case target.Kind == etcdBackupSecretKind:
+			// Secrets are limited to 1MiB, so they cannot hold the snapshots of most etcd clusters.
+			allErrs = append(
+				allErrs,
+				field.Invalid(
+					path.Child("target", "kind"),
+					target.Kind,
+					"Secrets are limited to 1MiB and cannot hold etcd snapshots, use a PersistentVolumeClaim or a ConfigMap configuring an object storage bucket instead",
+				),
+			)
+		case target.Kind != etcdBackupConfigMapKind && target.Kind != etcdBackupPersistentVolumeClaimKind:
+			allErrs = append(
+				allErrs,
+				field.NotSupported(
+					path.Child("target", "kind"),
+					target.Kind,
+					[]string{etcdBackupConfigMapKind, etcdBackupPersistentVolumeClaimKind},
+				),
+			)
+		}
+		if target.Name == "" {
+			allErrs = append(allErrs, field.Required(path.Child("target", "name"), "must be set"))
+		} else if errs := validation.IsDNS1123Subdomain(target.Name); len(errs) > 0 {
+			allErrs = append(allErrs, field.Invalid(path.Child("target", "name"), target.Name, strings.Join(errs, "; ")))
+		}
+		if isCore && target.Kind == etcdBackupPersistentVolumeClaimKind && target.Namespace != "" {
+			// PersistentVolumeClaims are mounted into the KubeadmControlPlane controller, so they are in the controller namespace.
+			allErrs = append(
+				allErrs,
+				field.Forbidden(
+					path.Child("target", "namespace"),
+					"cannot be set for PersistentVolumeClaim targets, which are mounted into the KubeadmControlPlane controller",
+				),
+			)
+		} else if target.Namespace != "" && target.Namespace != in.Namespace {
+			allErrs = append(
+				allErrs,
+				field.Invalid(
+					path.Child("target", "namespace"),
+					target.Namespace,
+					"must match metadata.namespace",
+				),
+			)
+		}
+	}
+
+	if in.Spec.MachineNamingStrategy != nil && in.Spec.MachineNamingStrategy.Template != "" {
+		path := field.NewPath("spec", "machineNamingStrategy", "template")
+		template, err := names.ParseTemplate(in.Spec.MachineNamingStrategy.Template)
+		if err == nil {
+			// The name of the Cluster is known only if the KubeadmControlPlane is labeled with it; otherwise the shortest
+			// possible name is used, so only the characters and the length of the rest of the template are validated.
+			clusterName := in.Labels[clusterv1.ClusterLabelName]
+			if clusterName == "" {
+				clusterName = "x"
+			}
+			err = template.Validate(in.MachineNameTemplateVariables(clusterName))
+		}
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(path, in.Spec.MachineNamingStrategy.Template, err.Error()))
+		}
+	}
+
 	if !version.KubeSemver.MatchString(in.Spec.Version) {
 		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "version"), in.Spec.Version, "must be a valid semantic version"))
 	}
@@ -301,10 +437,24 @@ func (in *KubeadmControlPlane) validateCommon() (allErrs field.ErrorList) {
 			)
 		}
 
-		ios1 := intstr.FromInt(1)
-		ios0 := intstr.FromInt(0)
+		// MaxSurge can be a percentage of the desired replicas, rounded up as for MachineDeployments.
+		replicas := int32(0)
+		if in.Spec.Replicas != nil {
+			replicas = *in.Spec.Replicas
+		}
+		maxSurge, err := intstr.GetValueFromIntOrPercent(in.Spec.RolloutStrategy.RollingUpdate.MaxSurge, int(replicas), true)
+		if err != nil {
+			allErrs = append(
+				allErrs,
+				field.Invalid(
+					field.NewPath("spec", "rolloutStrategy", "rollingUpdate", "maxSurge"),
+					in.Spec.RolloutStrategy.RollingUpdate.MaxSurge.String(),
+					"must be an integer or a percentage, e.g. 33%",
+				),
+			)
+		}
 
-		if *in.Spec.RolloutStrategy.RollingUpdate.MaxSurge == ios0 && *in.Spec.Replicas < int32(3) {
+		if err == nil && maxSurge == 0 && in.Spec.Replicas != nil && replicas < int32(3) {
 			allErrs = append(
 				allErrs,
 				field.Required(
@@ -314,12 +464,12 @@ func (in *KubeadmControlPlane) validateCommon() (allErrs field.ErrorList) {
 			)
 		}
 
-		if *in.Spec.RolloutStrategy.RollingUpdate.MaxSurge != ios1 && *in.Spec.RolloutStrategy.RollingUpdate.MaxSurge != ios0 {
+		if err == nil && maxSurge != 1 && maxSurge != 0 {
 			allErrs = append(
 				allErrs,
 				field.Required(
 					field.NewPath("spec", "rolloutStrategy", "rollingUpdate", "maxSurge"),
-					"value must be 1 or 0",
+					"value must be 1 or 0, or a percentage of the replicas resolving to 1 or 0",
 				),
 			)
 		}
@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha4
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -25,6 +26,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha4"
 	utildefaulting "sigs.k8s.io/cluster-api/util/defaulting"
 )
@@ -39,7 +41,10 @@ func TestKubeadmControlPlaneDefault(t *testing.T) {
 		Spec: KubeadmControlPlaneSpec{
 			Version:                "v1.18.3",
 			InfrastructureTemplate: corev1.ObjectReference{},
-			RolloutStrategy:        &RolloutStrategy{},
+			FailureDomainInfrastructureTemplates: []FailureDomainInfrastructureTemplate{
+				{FailureDomain: "zone-a", InfrastructureTemplate: corev1.ObjectReference{}},
+			},
+			RolloutStrategy: &RolloutStrategy{},
 		},
 	}
 	updateDefaultingValidationKCP := kcp.DeepCopy()
@@ -47,10 +52,14 @@ func TestKubeadmControlPlaneDefault(t *testing.T) {
 	updateDefaultingValidationKCP.Spec.InfrastructureTemplate = corev1.ObjectReference{
 		Namespace: "foo",
 	}
+	updateDefaultingValidationKCP.Spec.FailureDomainInfrastructureTemplates[0].InfrastructureTemplate = corev1.ObjectReference{
+		Namespace: "foo",
+	}
 	t.Run("for KubeadmControlPLane", utildefaulting.DefaultValidateTest(updateDefaultingValidationKCP))
 	kcp.Default()
 
 	g.Expect(kcp.Spec.InfrastructureTemplate.Namespace).To(Equal(kcp.Namespace))
+	g.Expect(kcp.Spec.FailureDomainInfrastructureTemplates[0].InfrastructureTemplate.Namespace).To(Equal(kcp.Namespace))
 	g.Expect(kcp.Spec.Version).To(Equal("v1.18.3"))
 	g.Expect(kcp.Spec.RolloutStrategy.Type).To(Equal(RollingUpdateStrategyType))
 	g.Expect(kcp.Spec.RolloutStrategy.RollingUpdate.MaxSurge.IntVal).To(Equal(int32(1)))
@@ -83,6 +92,16 @@ func TestKubeadmControlPlaneValidateCreate(t *testing.T) {
 	invalidMaxSurge := valid.DeepCopy()
 	invalidMaxSurge.Spec.RolloutStrategy.RollingUpdate.MaxSurge.IntVal = int32(3)
 
+	validMaxSurgePercentage := valid.DeepCopy()
+	validMaxSurgePercentage.Spec.Replicas = pointer.Int32Ptr(3)
+	validMaxSurgePercentage.Spec.RolloutStrategy.RollingUpdate.MaxSurge = &intstr.IntOrString{Type: intstr.String, StrVal: "33%"}
+
+	invalidMaxSurgePercentage := validMaxSurgePercentage.DeepCopy()
+	invalidMaxSurgePercentage.Spec.RolloutStrategy.RollingUpdate.MaxSurge.StrVal = "100%"
+
+	invalidMaxSurgeFormat := validMaxSurgePercentage.DeepCopy()
+	invalidMaxSurgeFormat.Spec.RolloutStrategy.RollingUpdate.MaxSurge.StrVal = "34"
+
 	invalidNamespace := valid.DeepCopy()
 	invalidNamespace.Spec.InfrastructureTemplate.Namespace = "bar"
 
@@ -107,12 +126,78 @@ func TestKubeadmControlPlaneValidateCreate(t *testing.T) {
 	validVersion := valid.DeepCopy()
 	validVersion.Spec.Version = "v1.16.6"
 
+	validFailureDomainTemplates := valid.DeepCopy()
+	validFailureDomainTemplates.Spec.FailureDomainInfrastructureTemplates = []FailureDomainInfrastructureTemplate{
+		{FailureDomain: "zone-a", InfrastructureTemplate: corev1.ObjectReference{Namespace: "foo", Name: "infraTemplate-a"}},
+		{FailureDomain: "zone-b", InfrastructureTemplate: corev1.ObjectReference{Namespace: "foo", Name: "infraTemplate-b"}},
+	}
+
+	duplicateFailureDomainTemplates := validFailureDomainTemplates.DeepCopy()
+	duplicateFailureDomainTemplates.Spec.FailureDomainInfrastructureTemplates[1].FailureDomain = "zone-a"
+
+	missingFailureDomain := validFailureDomainTemplates.DeepCopy()
+	missingFailureDomain.Spec.FailureDomainInfrastructureTemplates[1].FailureDomain = ""
+
+	invalidFailureDomainTemplateNamespace := validFailureDomainTemplates.DeepCopy()
+	invalidFailureDomainTemplateNamespace.Spec.FailureDomainInfrastructureTemplates[1].InfrastructureTemplate.Namespace = "bar"
+
+	validPreUpgradeEtcdBackup := valid.DeepCopy()
+	validPreUpgradeEtcdBackup.Spec.PreUpgradeEtcdBackup = &EtcdBackup{
+		Target: corev1.ObjectReference{APIVersion: "v1", Kind: "ConfigMap", Name: "etcd-backup"},
+	}
+
+	missingPreUpgradeEtcdBackupTargetName := validPreUpgradeEtcdBackup.DeepCopy()
+	missingPreUpgradeEtcdBackupTargetName.Spec.PreUpgradeEtcdBackup.Target.Name = ""
+
+	invalidPreUpgradeEtcdBackupTargetNamespace := validPreUpgradeEtcdBackup.DeepCopy()
+	invalidPreUpgradeEtcdBackupTargetNamespace.Spec.PreUpgradeEtcdBackup.Target.Namespace = "bar"
+
+	unsupportedPreUpgradeEtcdBackupTargetKind := validPreUpgradeEtcdBackup.DeepCopy()
+	unsupportedPreUpgradeEtcdBackupTargetKind.Spec.PreUpgradeEtcdBackup.Target.Kind = "Pod"
+
+	unsupportedPreUpgradeEtcdBackupSecret := validPreUpgradeEtcdBackup.DeepCopy()
+	unsupportedPreUpgradeEtcdBackupSecret.Spec.PreUpgradeEtcdBackup.Target.Kind = "Secret"
+
+	validPreUpgradeEtcdBackupCustomKind := validPreUpgradeEtcdBackup.DeepCopy()
+	validPreUpgradeEtcdBackupCustomKind.Spec.PreUpgradeEtcdBackup.Target = corev1.ObjectReference{APIVersion: "storage.example.com/v1", Kind: "Bucket", Name: "etcd-backup"}
+
+	validPreUpgradeEtcdBackupVolume := validPreUpgradeEtcdBackup.DeepCopy()
+	validPreUpgradeEtcdBackupVolume.Spec.PreUpgradeEtcdBackup.Target.Kind = "PersistentVolumeClaim"
+
+	invalidPreUpgradeEtcdBackupVolumeNamespace := validPreUpgradeEtcdBackupVolume.DeepCopy()
+	invalidPreUpgradeEtcdBackupVolumeNamespace.Spec.PreUpgradeEtcdBackup.Target.Namespace = validPreUpgradeEtcdBackupVolume.Namespace
+
+	invalidPreUpgradeEtcdBackupTargetName := validPreUpgradeEtcdBackup.DeepCopy()
+	invalidPreUpgradeEtcdBackupTargetName.Spec.PreUpgradeEtcdBackup.Target.Name = "../etcd-backup"
+
+	preUpgradeEtcdBackupExternalEtcd := validPreUpgradeEtcdBackup.DeepCopy()
+	preUpgradeEtcdBackupExternalEtcd.Spec.KubeadmConfigSpec = evenReplicasExternalEtcd.Spec.KubeadmConfigSpec
+
+	validMachineNamingStrategy := valid.DeepCopy()
+	validMachineNamingStrategy.Spec.MachineNamingStrategy = &MachineNamingStrategy{Template: "{{ .cluster }}-cp-{{ .random }}"}
+
+	machineNamingStrategyWithoutRandom := valid.DeepCopy()
+	machineNamingStrategyWithoutRandom.Spec.MachineNamingStrategy = &MachineNamingStrategy{Template: "{{ .cluster }}-cp"}
+
+	machineNamingStrategyWithInvalidCharacters := valid.DeepCopy()
+	machineNamingStrategyWithInvalidCharacters.Spec.MachineNamingStrategy = &MachineNamingStrategy{Template: "{{ .cluster }}_CP_{{ .random }}"}
+
+	machineNamingStrategyWithUnknownVariable := valid.DeepCopy()
+	machineNamingStrategyWithUnknownVariable.Spec.MachineNamingStrategy = &MachineNamingStrategy{Template: "{{ .foo }}-{{ .random }}"}
+
+	machineNamingStrategyTooLong := valid.DeepCopy()
+	machineNamingStrategyTooLong.Labels = map[string]string{clusterv1.ClusterLabelName: strings.Repeat("a", 50)}
+	machineNamingStrategyTooLong.Spec.MachineNamingStrategy = &MachineNamingStrategy{Template: "{{ .cluster }}-control-plane-{{ .random }}"}
+
 	invalidVersion1 := valid.DeepCopy()
 	invalidVersion1.Spec.Version = "vv1.16.6"
 
 	invalidVersion2 := valid.DeepCopy()
 	invalidVersion2.Spec.Version = "1.16.6"
 
+	windowsFormat := valid.DeepCopy()
+	windowsFormat.Spec.KubeadmConfigSpec.Format = bootstrapv1.CloudbaseInit
+
 	tests := []struct {
 		name      string
 		expectErr bool
@@ -168,6 +253,121 @@ func TestKubeadmControlPlaneValidateCreate(t *testing.T) {
 			expectErr: true,
 			kcp:       invalidMaxSurge,
 		},
+		{
+			name:      "should succeed when maxSurge is a percentage resolving to 1",
+			expectErr: false,
+			kcp:       validMaxSurgePercentage,
+		},
+		{
+			name:      "should return error when maxSurge is a percentage not resolving to 1 or 0",
+			expectErr: true,
+			kcp:       invalidMaxSurgePercentage,
+		},
+		{
+			name:      "should return error when maxSurge is neither an integer nor a percentage",
+			expectErr: true,
+			kcp:       invalidMaxSurgeFormat,
+		},
+		{
+			name:      "should succeed when given infrastructure templates for failure domains",
+			expectErr: false,
+			kcp:       validFailureDomainTemplates,
+		},
+		{
+			name:      "should return error when given multiple infrastructure templates for the same failure domain",
+			expectErr: true,
+			kcp:       duplicateFailureDomainTemplates,
+		},
+		{
+			name:      "should return error when given an infrastructure template without failure domain",
+			expectErr: true,
+			kcp:       missingFailureDomain,
+		},
+		{
+			name:      "should return error when kubeadmControlPlane namespace and failure domain infrastructureTemplate namespace mismatch",
+			expectErr: true,
+			kcp:       invalidFailureDomainTemplateNamespace,
+		},
+		{
+			name:      "should succeed when given a pre-upgrade etcd backup target",
+			expectErr: false,
+			kcp:       validPreUpgradeEtcdBackup,
+		},
+		{
+			name:      "should return error when the pre-upgrade etcd backup target has no name",
+			expectErr: true,
+			kcp:       missingPreUpgradeEtcdBackupTargetName,
+		},
+		{
+			name:      "should return error when kubeadmControlPlane namespace and pre-upgrade etcd backup target namespace mismatch",
+			expectErr: true,
+			kcp:       invalidPreUpgradeEtcdBackupTargetNamespace,
+		},
+		{
+			name:      "should return error when the pre-upgrade etcd backup target kind is not supported",
+			expectErr: true,
+			kcp:       unsupportedPreUpgradeEtcdBackupTargetKind,
+		},
+		{
+			name:      "should return error when the pre-upgrade etcd backup target is a Secret",
+			expectErr: true,
+			kcp:       unsupportedPreUpgradeEtcdBackupSecret,
+		},
+		{
+			name:      "should succeed when the pre-upgrade etcd backup target is in a non-core API group",
+			expectErr: false,
+			kcp:       validPreUpgradeEtcdBackupCustomKind,
+		},
+		{
+			name:      "should succeed when given a pre-upgrade etcd backup to a PersistentVolumeClaim",
+			expectErr: false,
+			kcp:       validPreUpgradeEtcdBackupVolume,
+		},
+		{
+			name:      "should return error when the pre-upgrade etcd backup PersistentVolumeClaim has a namespace",
+			expectErr: true,
+			kcp:       invalidPreUpgradeEtcdBackupVolumeNamespace,
+		},
+		{
+			name:      "should return error when the pre-upgrade etcd backup target name is invalid",
+			expectErr: true,
+			kcp:       invalidPreUpgradeEtcdBackupTargetName,
+		},
+		{
+			name:      "should return error when a pre-upgrade etcd backup is requested with external etcd",
+			expectErr: true,
+			kcp:       preUpgradeEtcdBackupExternalEtcd,
+		},
+		{
+			name:      "should succeed when given a machine naming strategy",
+			expectErr: false,
+			kcp:       validMachineNamingStrategy,
+		},
+		{
+			name:      "should return error when the machine naming strategy template does not use the random variable",
+			expectErr: true,
+			kcp:       machineNamingStrategyWithoutRandom,
+		},
+		{
+			name:      "should return error when the machine naming strategy template generates invalid characters",
+			expectErr: true,
+			kcp:       machineNamingStrategyWithInvalidCharacters,
+		},
+		{
+			name:      "should return error when the machine naming strategy template uses unknown variables",
+			expectErr: true,
+			kcp:       machineNamingStrategyWithUnknownVariable,
+		},
+		{
+			name:      "should return error when the machine naming strategy template generates names too long",
+			expectErr: true,
+			kcp:       machineNamingStrategyTooLong,
+		},
+		{
+			name:      "should return error when the bootstrap data format is for Windows worker nodes",
+			expectErr: true,
+			kcp:       windowsFormat,
+		},
 	}
 
 	for _, tt := range tests {
@@ -260,6 +460,36 @@ func TestKubeadmControlPlaneValidateUpdate(t *testing.T) {
 	updateMaxSurgeVal.Spec.RolloutStrategy.RollingUpdate.MaxSurge.IntVal = int32(0)
 	updateMaxSurgeVal.Spec.Replicas = pointer.Int32Ptr(3)
 
+	updateFailureDomainTemplates := before.DeepCopy()
+	updateFailureDomainTemplates.Spec.FailureDomainInfrastructureTemplates = []FailureDomainInfrastructureTemplate{
+		{FailureDomain: "zone-a", InfrastructureTemplate: corev1.ObjectReference{Namespace: "foo", Name: "infraTemplate-a"}},
+	}
+
+	updatePreUpgradeEtcdBackup := before.DeepCopy()
+	updatePreUpgradeEtcdBackup.Spec.PreUpgradeEtcdBackup = &EtcdBackup{
+		Target: corev1.ObjectReference{APIVersion: "v1", Kind: "ConfigMap", Name: "etcd-backup"},
+	}
+
+	removePreUpgradeEtcdBackup := before.DeepCopy()
+	beforeWithPreUpgradeEtcdBackup := updatePreUpgradeEtcdBackup.DeepCopy()
+
+	removeContainerRuntime := before.DeepCopy()
+	beforeWithContainerRuntime := before.DeepCopy()
+	beforeWithContainerRuntime.Spec.KubeadmConfigSpec.ContainerRuntime = &bootstrapv1.ContainerRuntime{
+		Containerd: &bootstrapv1.Containerd{SandboxImage: "k8s.gcr.io/pause:3.4.1"},
+	}
+
+	removeProxy := before.DeepCopy()
+	beforeWithProxy := before.DeepCopy()
+	beforeWithProxy.Spec.KubeadmConfigSpec.Proxy = &bootstrapv1.Proxy{
+		HTTPProxy:  "http://proxy.example.com:3128",
+		HTTPSProxy: "http://proxy.example.com:3128",
+		NoProxy:    []string{"10.0.0.0/8"},
+	}
+
+	updateMachineNamingStrategy := before.DeepCopy()
+	updateMachineNamingStrategy.Spec.MachineNamingStrategy = &MachineNamingStrategy{Template: "{{ .kubeadmControlPlane }}-{{ .random }}"}
+
 	wrongReplicaCountForScaleIn := before.DeepCopy()
 	wrongReplicaCountForScaleIn.Spec.RolloutStrategy.RollingUpdate.MaxSurge.IntVal = int32(0)
 
@@ -789,6 +1019,42 @@ func TestKubeadmControlPlaneValidateUpdate(t *testing.T) {
 			before:    before,
 			kcp:       wrongReplicaCountForScaleIn,
 		},
+		{
+			name:      "should succeed when changing the infrastructure templates for failure domains",
+			expectErr: false,
+			before:    before,
+			kcp:       updateFailureDomainTemplates,
+		},
+		{
+			name:      "should succeed when setting the pre-upgrade etcd backup",
+			expectErr: false,
+			before:    before,
+			kcp:       updatePreUpgradeEtcdBackup,
+		},
+		{
+			name:      "should succeed when removing the pre-upgrade etcd backup",
+			expectErr: false,
+			before:    beforeWithPreUpgradeEtcdBackup,
+			kcp:       removePreUpgradeEtcdBackup,
+		},
+		{
+			name:      "should succeed when removing the container runtime configuration",
+			expectErr: false,
+			before:    beforeWithContainerRuntime,
+			kcp:       removeContainerRuntime,
+		},
+		{
+			name:      "should succeed when removing the proxy configuration",
+			expectErr: false,
+			before:    beforeWithProxy,
+			kcp:       removeProxy,
+		},
+		{
+			name:      "should succeed when changing the machine naming strategy",
+			expectErr: false,
+			before:    before,
+			kcp:       updateMachineNamingStrategy,
+		},
 	}
 
 	for _, tt := range tests {
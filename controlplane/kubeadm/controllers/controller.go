@@ -28,11 +28,13 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
 	"sigs.k8s.io/cluster-api/controllers/remote"
 	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal"
@@ -43,6 +45,7 @@ import (
 	"sigs.k8s.io/cluster-api/util/predicates"
 	"sigs.k8s.io/cluster-api/util/secret"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -50,6 +53,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
+// kubeadmControlPlaneKind is the kind of the KubeadmControlPlane, used as owner kind in metrics.
+const kubeadmControlPlaneKind = "KubeadmControlPlane"
+
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;patch
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io;bootstrap.cluster.x-k8s.io;controlplane.cluster.x-k8s.io,resources=*,verbs=get;list;watch;create;update;patch;delete
@@ -63,6 +70,15 @@ type KubeadmControlPlaneReconciler struct {
 	recorder   record.EventRecorder
 	Tracker    *remote.ClusterCacheTracker
 
+	// EtcdSnapshotVolumesDir is the directory where the PersistentVolumeClaims used as spec.preUpgradeEtcdBackup
+	// targets are mounted, each one in a subdirectory named after the claim; if empty, etcd snapshots cannot be
+	// saved to PersistentVolumeClaims.
+	EtcdSnapshotVolumesDir string
+
+	// EtcdSnapshotters are additional EtcdSnapshotters for the kinds of objects used as spec.preUpgradeEtcdBackup
+	// targets; they take precedence over the built-in ones.
+	EtcdSnapshotters map[schema.GroupKind]EtcdSnapshotter
+
 	managementCluster         internal.ManagementCluster
 	managementClusterUncached internal.ManagementCluster
 }
@@ -71,9 +87,16 @@ func (r *KubeadmControlPlaneReconciler) SetupWithManager(ctx context.Context, mg
 	c, err := ctrl.NewControllerManagedBy(mgr).
 		For(&controlplanev1.KubeadmControlPlane{}).
 		Owns(&clusterv1.Machine{}).
+		// Watch the cluster CA Secrets, so the kubeconfig Secret is regenerated as soon as the CA is rotated;
+		// only metadata are watched, so the content of the Secrets in the management cluster is not cached.
+		Watches(
+			&source.Kind{Type: &corev1.Secret{}},
+			handler.EnqueueRequestsFromMapFunc(r.clusterCASecretToKubeadmControlPlane),
+			builder.OnlyMetadata,
+		).
 		WithOptions(options).
 		WithEventFilter(predicates.ResourceNotPaused(ctrl.LoggerFrom(ctx))).
-		Build(r)
+		Build(metrics.NewInstrumentedReconciler("kubeadmcontrolplane", r))
 	if err != nil {
 		return errors.Wrap(err, "failed setting up with a controller manager")
 	}
@@ -81,12 +104,20 @@ func (r *KubeadmControlPlaneReconciler) SetupWithManager(ctx context.Context, mg
 	err = c.Watch(
 		&source.Kind{Type: &clusterv1.Cluster{}},
 		handler.EnqueueRequestsFromMapFunc(r.ClusterToKubeadmControlPlane),
-		predicates.ClusterUnpausedAndInfrastructureReady(ctrl.LoggerFrom(ctx)),
+		predicates.Any(ctrl.LoggerFrom(ctx),
+			predicates.ClusterUnpausedAndInfrastructureReady(ctrl.LoggerFrom(ctx)),
+			predicates.ClusterUpdateFailureDomainsChanged(ctrl.LoggerFrom(ctx)),
+		),
 	)
 	if err != nil {
 		return errors.Wrap(err, "failed adding Watch for Clusters to controller manager")
 	}
 
+	// Add index to Machines for listing the Machines of a Cluster by cluster name.
+	if err := util.AddClusterNameIndex(ctx, mgr.GetFieldIndexer(), &clusterv1.Machine{}); err != nil {
+		return err
+	}
+
 	r.controller = c
 	r.recorder = mgr.GetEventRecorderFor("kubeadm-control-plane-controller")
 
@@ -98,7 +129,7 @@ func (r *KubeadmControlPlaneReconciler) SetupWithManager(ctx context.Context, mg
 	}
 
 	if r.managementClusterUncached == nil {
-		r.managementClusterUncached = &internal.Management{Client: mgr.GetAPIReader()}
+		r.managementClusterUncached = &internal.Management{Client: mgr.GetAPIReader(), Uncached: true}
 	}
 
 	return nil
@@ -223,6 +254,8 @@ func patchKubeadmControlPlane(ctx context.Context, patchHelper *patch.Helper, kc
 			controlplanev1.MachinesReadyCondition,
 			controlplanev1.AvailableCondition,
 			controlplanev1.CertificatesAvailableCondition,
+			controlplanev1.KubeconfigUpToDateCondition,
+			controlplanev1.EtcdSnapshotTakenCondition,
 		}},
 		patch.WithStatusObservedGeneration{},
 	)
@@ -233,10 +266,15 @@ func (r *KubeadmControlPlaneReconciler) reconcile(ctx context.Context, cluster *
 	log := ctrl.LoggerFrom(ctx, "cluster", cluster.Name)
 	log.Info("Reconcile KubeadmControlPlane")
 
-	// Make sure to reconcile the external infrastructure reference.
+	// Make sure to reconcile the external infrastructure references.
 	if err := r.reconcileExternalReference(ctx, cluster, kcp.Spec.InfrastructureTemplate); err != nil {
 		return ctrl.Result{}, err
 	}
+	for _, t := range kcp.Spec.FailureDomainInfrastructureTemplates {
+		if err := r.reconcileExternalReference(ctx, cluster, t.InfrastructureTemplate); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
 
 	// Generate Cluster Certificates if needed
 	config := kcp.Spec.KubeadmConfigSpec.DeepCopy()
@@ -433,7 +471,9 @@ func (r *KubeadmControlPlaneReconciler) reconcileDelete(ctx context.Context, clu
 		if err := r.Client.Delete(ctx, machinesToDelete[i]); err != nil && !apierrors.IsNotFound(err) {
 			logger.Error(err, "Failed to cleanup owned machine")
 			errs = append(errs, err)
+			continue
 		}
+		metrics.RecordMachineDeleted(kubeadmControlPlaneKind)
 	}
 	if len(errs) > 0 {
 		err := kerrors.NewAggregate(errs)
@@ -461,6 +501,21 @@ func (r *KubeadmControlPlaneReconciler) ClusterToKubeadmControlPlane(o client.Ob
 	return nil
 }
 
+// clusterCASecretToKubeadmControlPlane is a handler.ToRequestsFunc to be used to enqueue requests for reconciliation
+// for KubeadmControlPlane when the cluster CA Secret changes, e.g. when it is rotated.
+func (r *KubeadmControlPlaneReconciler) clusterCASecretToKubeadmControlPlane(o client.Object) []ctrl.Request {
+	clusterName, purpose, err := secret.ParseSecretName(o.GetName())
+	if err != nil || purpose != secret.ClusterCA {
+		return nil
+	}
+
+	cluster := &clusterv1.Cluster{}
+	if err := r.Client.Get(context.TODO(), client.ObjectKey{Namespace: o.GetNamespace(), Name: clusterName}, cluster); err != nil {
+		return nil
+	}
+	return r.ClusterToKubeadmControlPlane(cluster)
+}
+
 // reconcileControlPlaneConditions is responsible of reconciling conditions reporting the status of static pods and
 // the status of the etcd cluster.
 func (r *KubeadmControlPlaneReconciler) reconcileControlPlaneConditions(ctx context.Context, controlPlane *internal.ControlPlane) (ctrl.Result, error) {
@@ -83,6 +83,38 @@ func TestClusterToKubeadmControlPlane(t *testing.T) {
 	g.Expect(got).To(Equal(expectedResult))
 }
 
+func TestClusterCASecretToKubeadmControlPlane(t *testing.T) {
+	g := NewWithT(t)
+
+	cluster := newCluster(&types.NamespacedName{Name: "foo", Namespace: "test"})
+	cluster.Spec = clusterv1.ClusterSpec{
+		ControlPlaneRef: &corev1.ObjectReference{
+			Kind:       "KubeadmControlPlane",
+			Namespace:  "test",
+			Name:       "kcp-foo",
+			APIVersion: controlplanev1.GroupVersion.String(),
+		},
+	}
+	fakeClient := newFakeClient(g, cluster.DeepCopy())
+
+	r := &KubeadmControlPlaneReconciler{
+		Client:   fakeClient,
+		recorder: record.NewFakeRecorder(32),
+	}
+
+	// Secrets are watched as metadata only.
+	caSecret := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: secret.Name("foo", secret.ClusterCA)}}
+	g.Expect(r.clusterCASecretToKubeadmControlPlane(caSecret)).To(Equal([]ctrl.Request{
+		{NamespacedName: client.ObjectKey{Namespace: "test", Name: "kcp-foo"}},
+	}))
+
+	kubeconfigSecret := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: secret.Name("foo", secret.Kubeconfig)}}
+	g.Expect(r.clusterCASecretToKubeadmControlPlane(kubeconfigSecret)).To(BeNil())
+
+	otherClusterCASecret := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: secret.Name("bar", secret.ClusterCA)}}
+	g.Expect(r.clusterCASecretToKubeadmControlPlane(otherClusterCASecret)).To(BeNil())
+}
+
 func TestClusterToKubeadmControlPlaneNoControlPlane(t *testing.T) {
 	g := NewWithT(t)
 	fakeClient := newFakeClient(g)
@@ -0,0 +1,215 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal"
+	"sigs.k8s.io/cluster-api/util/collections"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// EtcdSnapshotEndpointConfigMapKey is the key of the ConfigMap data where the URL of the object storage bucket
+	// etcd snapshots are uploaded to is set.
+	EtcdSnapshotEndpointConfigMapKey = "endpoint"
+
+	// EtcdSnapshotCredentialsSecretConfigMapKey is the key of the ConfigMap data where the name of the Secret holding
+	// the credentials for the object storage bucket is set; the Secret must be in the same namespace as the ConfigMap.
+	EtcdSnapshotCredentialsSecretConfigMapKey = "credentialsSecret"
+
+	// EtcdSnapshotTokenSecretKey is the key of the Secret data where the bearer token used to upload etcd snapshots
+	// to the object storage bucket is saved.
+	EtcdSnapshotTokenSecretKey = "token"
+)
+
+// EtcdSnapshotter saves etcd snapshots to the kind of object referenced by spec.preUpgradeEtcdBackup.target,
+// e.g. a volume or an object storage bucket.
+type EtcdSnapshotter interface {
+	// SaveSnapshot saves an etcd snapshot, taken before upgrading the control plane to kcp.Spec.Version, to target.
+	// The snapshot is streamed from etcd, and it should not be read into memory.
+	SaveSnapshot(ctx context.Context, cluster *clusterv1.Cluster, kcp *controlplanev1.KubeadmControlPlane, target corev1.ObjectReference, snapshot io.Reader) error
+}
+
+// objectStorageEtcdSnapshotter uploads etcd snapshots to the object storage bucket configured in a ConfigMap in the
+// namespace of the KubeadmControlPlane; each snapshot is uploaded with an HTTP PUT request to an object named after
+// the KubeadmControlPlane and the Kubernetes version it is being upgraded to.
+type objectStorageEtcdSnapshotter struct {
+	Client     client.Client
+	HTTPClient *http.Client
+}
+
+func (s *objectStorageEtcdSnapshotter) SaveSnapshot(ctx context.Context, _ *clusterv1.Cluster, kcp *controlplanev1.KubeadmControlPlane, target corev1.ObjectReference, snapshot io.Reader) error {
+	config := &corev1.ConfigMap{}
+	configKey := client.ObjectKey{Namespace: kcp.Namespace, Name: target.Name}
+	if err := s.Client.Get(ctx, configKey, config); err != nil {
+		return errors.Wrapf(err, "failed to get ConfigMap %s", configKey)
+	}
+
+	endpoint, err := url.Parse(config.Data[EtcdSnapshotEndpointConfigMapKey])
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return errors.Errorf("ConfigMap %s must have an http or https URL in the %q key", configKey, EtcdSnapshotEndpointConfigMapKey)
+	}
+	object := fmt.Sprintf("%s/%s-%s-%s.db", strings.TrimSuffix(endpoint.String(), "/"), kcp.Namespace, kcp.Name, kcp.Spec.Version)
+
+	// The request body is wrapped so the HTTP client does not close the snapshot, which is owned by the caller.
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, object, ioutil.NopCloser(snapshot))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the request to upload etcd snapshot to %s", object)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	if secretName := config.Data[EtcdSnapshotCredentialsSecretConfigMapKey]; secretName != "" {
+		secret := &corev1.Secret{}
+		secretKey := client.ObjectKey{Namespace: kcp.Namespace, Name: secretName}
+		if err := s.Client.Get(ctx, secretKey, secret); err != nil {
+			return errors.Wrapf(err, "failed to get Secret %s", secretKey)
+		}
+		token := secret.Data[EtcdSnapshotTokenSecretKey]
+		if len(token) == 0 {
+			return errors.Errorf("Secret %s must have a bearer token in the %q key", secretKey, EtcdSnapshotTokenSecretKey)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	resp, err := s.HTTPClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to upload etcd snapshot to %s", object)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		// Object storage services usually describe the error in the response body, so a bounded part of it is reported.
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Errorf("failed to upload etcd snapshot to %s: %s: %s", object, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// volumeEtcdSnapshotter saves etcd snapshots to PersistentVolumeClaims mounted into the controller at <Dir>/<claim name>;
+// each snapshot is saved to a file named after the KubeadmControlPlane and the Kubernetes version it is being upgraded to.
+type volumeEtcdSnapshotter struct {
+	Dir string
+}
+
+func (s *volumeEtcdSnapshotter) SaveSnapshot(_ context.Context, _ *clusterv1.Cluster, kcp *controlplanev1.KubeadmControlPlane, target corev1.ObjectReference, snapshot io.Reader) error {
+	mountPath := filepath.Join(s.Dir, target.Name)
+	if info, err := os.Stat(mountPath); err != nil || !info.IsDir() {
+		return errors.Errorf("PersistentVolumeClaim %s is not mounted into the KubeadmControlPlane controller at %s", target.Name, mountPath)
+	}
+
+	// The snapshot is streamed to a temporary file first, so an incomplete snapshot never replaces a previous one.
+	f, err := ioutil.TempFile(mountPath, ".etcd-snapshot-")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create a file in %s", mountPath)
+	}
+	defer os.Remove(f.Name()) //nolint:errcheck
+
+	if _, err := io.Copy(f, snapshot); err != nil {
+		f.Close()
+		return errors.Wrapf(err, "failed to write etcd snapshot to %s", f.Name())
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrapf(err, "failed to write etcd snapshot to %s", f.Name())
+	}
+
+	path := filepath.Join(mountPath, fmt.Sprintf("%s-%s-%s.db", kcp.Namespace, kcp.Name, kcp.Spec.Version))
+	if err := os.Rename(f.Name(), path); err != nil {
+		return errors.Wrapf(err, "failed to write etcd snapshot to %s", path)
+	}
+	return nil
+}
+
+// etcdSnapshotterFor returns the EtcdSnapshotter for the kind of object referenced by target; the EtcdSnapshotters
+// registered on the reconciler take precedence over the built-in ones.
+func (r *KubeadmControlPlaneReconciler) etcdSnapshotterFor(target corev1.ObjectReference) (EtcdSnapshotter, error) {
+	gk := target.GroupVersionKind().GroupKind()
+	if snapshotter, ok := r.EtcdSnapshotters[gk]; ok {
+		return snapshotter, nil
+	}
+	switch gk {
+	case corev1.SchemeGroupVersion.WithKind("ConfigMap").GroupKind():
+		return &objectStorageEtcdSnapshotter{Client: r.Client, HTTPClient: http.DefaultClient}, nil
+	case corev1.SchemeGroupVersion.WithKind("PersistentVolumeClaim").GroupKind():
+		if r.EtcdSnapshotVolumesDir == "" {
+			return nil, errors.New("saving etcd snapshots to PersistentVolumeClaims requires the KubeadmControlPlane controller to be started with --etcd-snapshot-volumes-dir")
+		}
+		return &volumeEtcdSnapshotter{Dir: r.EtcdSnapshotVolumesDir}, nil
+	}
+	return nil, errors.Errorf("saving etcd snapshots to %s is not supported", target.Kind)
+}
+
+// reconcilePreUpgradeEtcdBackup takes a snapshot of the etcd cluster before rolling out control plane machines
+// for a Kubernetes version upgrade, if requested by spec.preUpgradeEtcdBackup; the snapshot is taken only once
+// for each upgrade, and the upgrade is blocked until the snapshot succeeds.
+func (r *KubeadmControlPlaneReconciler) reconcilePreUpgradeEtcdBackup(ctx context.Context, cluster *clusterv1.Cluster, kcp *controlplanev1.KubeadmControlPlane, controlPlane *internal.ControlPlane, workloadCluster internal.WorkloadCluster) error {
+	logger := controlPlane.Logger()
+
+	if kcp.Spec.PreUpgradeEtcdBackup == nil || !controlPlane.IsEtcdManaged() {
+		return nil
+	}
+
+	// Snapshots are taken only for Kubernetes version upgrades, not for other rollouts.
+	if len(controlPlane.Machines.Filter(collections.Not(collections.MatchesKubernetesVersion(kcp.Spec.Version)))) == 0 {
+		return nil
+	}
+
+	if kcp.Annotations[controlplanev1.EtcdSnapshotVersionAnnotation] == kcp.Spec.Version {
+		return nil
+	}
+
+	target := kcp.Spec.PreUpgradeEtcdBackup.Target
+	snapshotter, err := r.etcdSnapshotterFor(target)
+	if err != nil {
+		conditions.MarkFalse(kcp, controlplanev1.EtcdSnapshotTakenCondition, controlplanev1.EtcdSnapshotFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return err
+	}
+
+	logger.Info("Taking etcd snapshot before upgrading the control plane", "version", kcp.Spec.Version)
+	snapshot, err := workloadCluster.EtcdSnapshot(ctx)
+	if err != nil {
+		conditions.MarkFalse(kcp, controlplanev1.EtcdSnapshotTakenCondition, controlplanev1.EtcdSnapshotFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return errors.Wrap(err, "failed to take etcd snapshot")
+	}
+	defer snapshot.Close()
+
+	if err := snapshotter.SaveSnapshot(ctx, cluster, kcp, target, snapshot); err != nil {
+		conditions.MarkFalse(kcp, controlplanev1.EtcdSnapshotTakenCondition, controlplanev1.EtcdSnapshotFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return errors.Wrapf(err, "failed to save etcd snapshot to %s %s", target.Kind, target.Name)
+	}
+
+	if kcp.Annotations == nil {
+		kcp.Annotations = map[string]string{}
+	}
+	kcp.Annotations[controlplanev1.EtcdSnapshotVersionAnnotation] = kcp.Spec.Version
+	conditions.MarkTrue(kcp, controlplanev1.EtcdSnapshotTakenCondition)
+	r.recorder.Eventf(kcp, corev1.EventTypeNormal, "EtcdSnapshotTaken", "Saved etcd snapshot to %s %s before upgrading to %s", target.Kind, target.Name, kcp.Spec.Version)
+	return nil
+}
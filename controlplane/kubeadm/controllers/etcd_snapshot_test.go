@@ -0,0 +1,346 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal"
+	"sigs.k8s.io/cluster-api/util/collections"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestReconcilePreUpgradeEtcdBackup(t *testing.T) {
+	bucketTarget := &controlplanev1.EtcdBackup{
+		Target: corev1.ObjectReference{APIVersion: "v1", Kind: "ConfigMap", Name: "etcd-backup"},
+	}
+	volumeTarget := &controlplanev1.EtcdBackup{
+		Target: corev1.ObjectReference{APIVersion: "v1", Kind: "PersistentVolumeClaim", Name: "etcd-backup"},
+	}
+	secretTarget := &controlplanev1.EtcdBackup{
+		Target: corev1.ObjectReference{APIVersion: "v1", Kind: "Secret", Name: "etcd-backup"},
+	}
+	customTarget := &controlplanev1.EtcdBackup{
+		Target: corev1.ObjectReference{APIVersion: "storage.example.com/v1", Kind: "Bucket", Name: "etcd-backup"},
+	}
+	machine := func(version string) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "machine-" + version},
+			Spec:       clusterv1.MachineSpec{Version: pointer.StringPtr(version)},
+		}
+	}
+
+	tests := []struct {
+		name              string
+		backup            *controlplanev1.EtcdBackup
+		snapshotVersion   string
+		machines          []*clusterv1.Machine
+		workload          fakeWorkloadCluster
+		volumesDir        bool
+		customSnapshotter bool
+		expectErr         bool
+		expectSnapshot    bool
+		expectUpload      bool
+		expectFile        bool
+		expectCustom      bool
+		expectReason      string
+	}{
+		{
+			name:     "does nothing if a backup is not requested",
+			machines: []*clusterv1.Machine{machine("v1.16.6")},
+			workload: fakeWorkloadCluster{EtcdSnapshotResult: []byte("snapshot")},
+		},
+		{
+			name:           "uploads a snapshot to an object storage bucket before a version upgrade",
+			backup:         bucketTarget,
+			machines:       []*clusterv1.Machine{machine("v1.16.6")},
+			workload:       fakeWorkloadCluster{EtcdSnapshotResult: []byte("snapshot")},
+			expectSnapshot: true,
+			expectUpload:   true,
+		},
+		{
+			name:           "saves a snapshot to a PersistentVolumeClaim before a version upgrade",
+			backup:         volumeTarget,
+			machines:       []*clusterv1.Machine{machine("v1.16.6")},
+			workload:       fakeWorkloadCluster{EtcdSnapshotResult: []byte("snapshot")},
+			volumesDir:     true,
+			expectSnapshot: true,
+			expectFile:     true,
+		},
+		{
+			name:              "saves a snapshot with a registered EtcdSnapshotter before a version upgrade",
+			backup:            customTarget,
+			machines:          []*clusterv1.Machine{machine("v1.16.6")},
+			workload:          fakeWorkloadCluster{EtcdSnapshotResult: []byte("snapshot")},
+			customSnapshotter: true,
+			expectSnapshot:    true,
+			expectCustom:      true,
+		},
+		{
+			name:     "does nothing if the rollout is not a version upgrade",
+			backup:   bucketTarget,
+			machines: []*clusterv1.Machine{machine(UpdatedVersion)},
+			workload: fakeWorkloadCluster{EtcdSnapshotResult: []byte("snapshot")},
+		},
+		{
+			name:            "does nothing if a snapshot was already taken for the upgrade",
+			backup:          bucketTarget,
+			snapshotVersion: UpdatedVersion,
+			machines:        []*clusterv1.Machine{machine("v1.16.6"), machine(UpdatedVersion)},
+			workload:        fakeWorkloadCluster{EtcdSnapshotErr: errors.New("snapshot should not be taken")},
+		},
+		{
+			name:         "fails if the snapshot fails",
+			backup:       bucketTarget,
+			machines:     []*clusterv1.Machine{machine("v1.16.6")},
+			workload:     fakeWorkloadCluster{EtcdSnapshotErr: errors.New("snapshot failed")},
+			expectErr:    true,
+			expectReason: controlplanev1.EtcdSnapshotFailedReason,
+		},
+		{
+			name:         "fails if the controller has no directory for PersistentVolumeClaims",
+			backup:       volumeTarget,
+			machines:     []*clusterv1.Machine{machine("v1.16.6")},
+			workload:     fakeWorkloadCluster{EtcdSnapshotResult: []byte("snapshot")},
+			expectErr:    true,
+			expectReason: controlplanev1.EtcdSnapshotFailedReason,
+		},
+		{
+			name:         "fails if the target is a Secret",
+			backup:       secretTarget,
+			machines:     []*clusterv1.Machine{machine("v1.16.6")},
+			workload:     fakeWorkloadCluster{EtcdSnapshotResult: []byte("snapshot")},
+			expectErr:    true,
+			expectReason: controlplanev1.EtcdSnapshotFailedReason,
+		},
+		{
+			name:         "fails if no EtcdSnapshotter is registered for the target kind",
+			backup:       customTarget,
+			machines:     []*clusterv1.Machine{machine("v1.16.6")},
+			workload:     fakeWorkloadCluster{EtcdSnapshotResult: []byte("snapshot")},
+			expectErr:    true,
+			expectReason: controlplanev1.EtcdSnapshotFailedReason,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			var uploaded []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				uploaded, _ = ioutil.ReadAll(req.Body)
+			}))
+			defer server.Close()
+
+			cluster, kcp, _ := createClusterWithControlPlane()
+			kcp.Spec.Version = UpdatedVersion
+			kcp.Spec.PreUpgradeEtcdBackup = tt.backup
+			if tt.snapshotVersion != "" {
+				kcp.Annotations = map[string]string{controlplanev1.EtcdSnapshotVersionAnnotation: tt.snapshotVersion}
+			}
+			bucket := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: kcp.Namespace, Name: "etcd-backup"},
+				Data:       map[string]string{EtcdSnapshotEndpointConfigMapKey: server.URL},
+			}
+
+			fakeClient := newFakeClient(g, cluster.DeepCopy(), kcp.DeepCopy(), bucket)
+			r := &KubeadmControlPlaneReconciler{
+				Client:   fakeClient,
+				recorder: record.NewFakeRecorder(32),
+			}
+			if tt.volumesDir {
+				r.EtcdSnapshotVolumesDir = t.TempDir()
+				g.Expect(os.Mkdir(filepath.Join(r.EtcdSnapshotVolumesDir, "etcd-backup"), 0o755)).To(Succeed())
+			}
+			custom := &fakeEtcdSnapshotter{}
+			if tt.customSnapshotter {
+				r.EtcdSnapshotters = map[schema.GroupKind]EtcdSnapshotter{
+					{Group: "storage.example.com", Kind: "Bucket"}: custom,
+				}
+			}
+			controlPlane := &internal.ControlPlane{
+				KCP:      kcp,
+				Cluster:  cluster,
+				Machines: collections.FromMachines(tt.machines...),
+			}
+
+			err := r.reconcilePreUpgradeEtcdBackup(ctx, cluster, kcp, controlPlane, tt.workload)
+			if tt.expectErr {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(kcp.Annotations).ToNot(HaveKey(controlplanev1.EtcdSnapshotVersionAnnotation))
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+
+			if tt.expectReason != "" {
+				g.Expect(conditions.IsFalse(kcp, controlplanev1.EtcdSnapshotTakenCondition)).To(BeTrue())
+				g.Expect(conditions.GetReason(kcp, controlplanev1.EtcdSnapshotTakenCondition)).To(Equal(tt.expectReason))
+			}
+
+			if tt.expectSnapshot {
+				g.Expect(kcp.Annotations).To(HaveKeyWithValue(controlplanev1.EtcdSnapshotVersionAnnotation, UpdatedVersion))
+				g.Expect(conditions.IsTrue(kcp, controlplanev1.EtcdSnapshotTakenCondition)).To(BeTrue())
+			} else if tt.expectReason == "" {
+				g.Expect(conditions.Has(kcp, controlplanev1.EtcdSnapshotTakenCondition)).To(BeFalse())
+			}
+
+			if tt.expectUpload {
+				g.Expect(uploaded).To(Equal([]byte("snapshot")))
+			} else {
+				g.Expect(uploaded).To(BeNil())
+			}
+
+			if tt.expectFile {
+				path := filepath.Join(r.EtcdSnapshotVolumesDir, "etcd-backup", kcp.Namespace+"-"+kcp.Name+"-"+UpdatedVersion+".db")
+				g.Expect(ioutil.ReadFile(path)).To(Equal([]byte("snapshot")))
+			}
+
+			if tt.expectCustom {
+				g.Expect(custom.saved).To(Equal([]byte("snapshot")))
+			} else {
+				g.Expect(custom.saved).To(BeNil())
+			}
+		})
+	}
+}
+
+func TestObjectStorageEtcdSnapshotter(t *testing.T) {
+	tests := []struct {
+		name        string
+		configData  map[string]string
+		secret      *corev1.Secret
+		status      int
+		expectErr   string
+		expectToken string
+	}{
+		{
+			name:       "uploads the snapshot to an object named after the KubeadmControlPlane and the version",
+			configData: map[string]string{},
+			status:     http.StatusOK,
+		},
+		{
+			name:       "authenticates with the token in the credentials Secret",
+			configData: map[string]string{EtcdSnapshotCredentialsSecretConfigMapKey: "etcd-backup-credentials"},
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "etcd-backup-credentials"},
+				Data:       map[string][]byte{EtcdSnapshotTokenSecretKey: []byte("my-token\n")},
+			},
+			status:      http.StatusOK,
+			expectToken: "Bearer my-token",
+		},
+		{
+			name:       "fails if the credentials Secret does not exist",
+			configData: map[string]string{EtcdSnapshotCredentialsSecretConfigMapKey: "etcd-backup-credentials"},
+			expectErr:  "failed to get Secret",
+		},
+		{
+			name:       "fails if the endpoint is not an http URL",
+			configData: map[string]string{EtcdSnapshotEndpointConfigMapKey: "s3://etcd-backups"},
+			expectErr:  "must have an http or https URL",
+		},
+		{
+			name:       "fails if the upload is rejected",
+			configData: map[string]string{},
+			status:     http.StatusForbidden,
+			expectErr:  "403 Forbidden: access denied",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			var token, object string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				g.Expect(req.Method).To(Equal(http.MethodPut))
+				g.Expect(ioutil.ReadAll(req.Body)).To(Equal([]byte("snapshot")))
+				token = req.Header.Get("Authorization")
+				object = req.URL.Path
+				w.WriteHeader(tt.status)
+				if tt.status != http.StatusOK {
+					_, _ = w.Write([]byte("access denied"))
+				}
+			}))
+			defer server.Close()
+
+			cluster, kcp, _ := createClusterWithControlPlane()
+			kcp.Spec.Version = UpdatedVersion
+			config := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: kcp.Namespace, Name: "etcd-backup"},
+				Data:       map[string]string{EtcdSnapshotEndpointConfigMapKey: server.URL + "/etcd-backups/"},
+			}
+			for k, v := range tt.configData {
+				config.Data[k] = v
+			}
+			objs := []client.Object{config}
+			if tt.secret != nil {
+				objs = append(objs, tt.secret)
+			}
+
+			s := &objectStorageEtcdSnapshotter{Client: newFakeClient(g, objs...), HTTPClient: server.Client()}
+			err := s.SaveSnapshot(ctx, cluster, kcp, corev1.ObjectReference{Kind: "ConfigMap", Name: "etcd-backup"}, bytes.NewReader([]byte("snapshot")))
+			if tt.expectErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.expectErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(token).To(Equal(tt.expectToken))
+			g.Expect(object).To(Equal("/etcd-backups/" + kcp.Namespace + "-" + kcp.Name + "-" + UpdatedVersion + ".db"))
+		})
+	}
+}
+
+func TestVolumeEtcdSnapshotterRequiresMountedClaim(t *testing.T) {
+	g := NewWithT(t)
+
+	cluster, kcp, _ := createClusterWithControlPlane()
+	kcp.Spec.Version = UpdatedVersion
+
+	s := &volumeEtcdSnapshotter{Dir: t.TempDir()}
+	err := s.SaveSnapshot(ctx, cluster, kcp, corev1.ObjectReference{Kind: "PersistentVolumeClaim", Name: "etcd-backup"}, bytes.NewReader([]byte("snapshot")))
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("is not mounted"))
+}
+
+// fakeEtcdSnapshotter is an EtcdSnapshotter recording the snapshot it saves.
+type fakeEtcdSnapshotter struct {
+	saved []byte
+}
+
+func (s *fakeEtcdSnapshotter) SaveSnapshot(_ context.Context, _ *clusterv1.Cluster, _ *controlplanev1.KubeadmControlPlane, _ corev1.ObjectReference, snapshot io.Reader) error {
+	var err error
+	s.saved, err = ioutil.ReadAll(snapshot)
+	return err
+}
@@ -17,7 +17,11 @@ limitations under the License.
 package controllers
 
 import (
+	"bytes"
 	"context"
+	"io"
+	"io/ioutil"
+
 	"github.com/blang/semver"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal"
@@ -54,8 +58,10 @@ func (f *fakeManagementCluster) GetMachinesForCluster(c context.Context, cluster
 
 type fakeWorkloadCluster struct {
 	*internal.Workload
-	Status            internal.ClusterStatus
-	EtcdMembersResult []string
+	Status             internal.ClusterStatus
+	EtcdMembersResult  []string
+	EtcdSnapshotResult []byte
+	EtcdSnapshotErr    error
 }
 
 func (f fakeWorkloadCluster) ForwardEtcdLeadership(_ context.Context, _ *clusterv1.Machine, _ *clusterv1.Machine) error {
@@ -102,6 +108,13 @@ func (f fakeWorkloadCluster) RemoveMachineFromKubeadmConfigMap(ctx context.Conte
 	return nil
 }
 
+func (f fakeWorkloadCluster) EtcdSnapshot(_ context.Context) (io.ReadCloser, error) {
+	if f.EtcdSnapshotErr != nil {
+		return nil, f.EtcdSnapshotErr
+	}
+	return ioutil.NopCloser(bytes.NewReader(f.EtcdSnapshotResult)), nil
+}
+
 func (f fakeWorkloadCluster) EtcdMembers(_ context.Context) ([]string, error) {
 	return f.EtcdMembersResult, nil
 }
@@ -31,15 +31,18 @@ import (
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
 	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/certs"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/kubeconfig"
+	utilnames "sigs.k8s.io/cluster-api/util/names"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/cluster-api/util/secret"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func (r *KubeadmControlPlaneReconciler) reconcileKubeconfig(ctx context.Context, cluster *clusterv1.Cluster, kcp *controlplanev1.KubeadmControlPlane) (ctrl.Result, error) {
@@ -89,16 +92,52 @@ func (r *KubeadmControlPlaneReconciler) reconcileKubeconfig(ctx context.Context,
 		return ctrl.Result{}, err
 	}
 
-	if needsRotation {
-		log.Info("rotating kubeconfig secret")
+	caRotated, err := r.kubeconfigNeedsCARotation(ctx, clusterName, configSecret)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if needsRotation || caRotated {
+		conditions.MarkFalse(kcp, controlplanev1.KubeconfigUpToDateCondition, controlplanev1.KubeconfigRegenerationInProgressReason, clusterv1.ConditionSeverityInfo, "")
+		if caRotated {
+			log.Info("regenerating kubeconfig secret after cluster CA rotation")
+		} else {
+			log.Info("rotating kubeconfig secret")
+		}
 		if err := kubeconfig.RegenerateSecret(ctx, r.Client, configSecret); err != nil {
+			conditions.MarkFalse(kcp, controlplanev1.KubeconfigUpToDateCondition, controlplanev1.KubeconfigRegenerationFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
 			return ctrl.Result{}, errors.Wrap(err, "failed to regenerate kubeconfig")
 		}
+		if caRotated {
+			r.recorder.Eventf(kcp, corev1.EventTypeNormal, "KubeconfigRegenerated", "Regenerated kubeconfig Secret %s after cluster CA rotation", configSecret.Name)
+		}
 	}
+	conditions.MarkTrue(kcp, controlplanev1.KubeconfigUpToDateCondition)
 
 	return ctrl.Result{}, nil
 }
 
+// kubeconfigNeedsCARotation returns whether the kubeconfig Secret is not signed by the current cluster CA, e.g.
+// because the cluster CA Secret was rotated.
+func (r *KubeadmControlPlaneReconciler) kubeconfigNeedsCARotation(ctx context.Context, clusterName client.ObjectKey, configSecret *corev1.Secret) (bool, error) {
+	caSecret, err := secret.GetFromNamespacedName(ctx, r.Client, clusterName, secret.ClusterCA)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "failed to retrieve cluster CA Secret")
+	}
+
+	caCert, err := certs.DecodeCertPEM(caSecret.Data[secret.TLSCrtDataName])
+	if err != nil {
+		return false, errors.Wrap(err, "failed to decode cluster CA certificate")
+	}
+	if caCert == nil {
+		return false, nil
+	}
+	return kubeconfig.NeedsCARotation(configSecret, caCert)
+}
+
 func (r *KubeadmControlPlaneReconciler) adoptKubeconfigSecret(ctx context.Context, cluster *clusterv1.Cluster, configSecret *corev1.Secret, controllerOwnerRef metav1.OwnerReference) error {
 	log := ctrl.LoggerFrom(ctx)
 	log.Info("Adopting KubeConfig secret created by v1alpha2 controllers", "Name", configSecret.Name)
@@ -162,7 +201,7 @@ func (r *KubeadmControlPlaneReconciler) cloneConfigsAndGenerateMachine(ctx conte
 	// Clone the infrastructure template
 	infraRef, err := external.CloneTemplate(ctx, &external.CloneTemplateInput{
 		Client:      r.Client,
-		TemplateRef: &kcp.Spec.InfrastructureTemplate,
+		TemplateRef: kcp.InfrastructureTemplateForFailureDomain(failureDomain),
 		Namespace:   kcp.Namespace,
 		OwnerRef:    infraCloneOwner,
 		ClusterName: cluster.Name,
@@ -259,9 +298,14 @@ func (r *KubeadmControlPlaneReconciler) generateKubeadmConfig(ctx context.Contex
 }
 
 func (r *KubeadmControlPlaneReconciler) generateMachine(ctx context.Context, kcp *controlplanev1.KubeadmControlPlane, cluster *clusterv1.Cluster, infraRef, bootstrapRef *corev1.ObjectReference, failureDomain *string) error {
+	name, err := machineName(kcp, cluster)
+	if err != nil {
+		return err
+	}
+
 	machine := &clusterv1.Machine{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      names.SimpleNameGenerator.GenerateName(kcp.Name + "-"),
+			Name:      name,
 			Namespace: kcp.Namespace,
 			Labels:    internal.ControlPlaneLabelsForCluster(cluster.Name),
 			OwnerReferences: []metav1.OwnerReference{
@@ -291,5 +335,19 @@ func (r *KubeadmControlPlaneReconciler) generateMachine(ctx context.Context, kcp
 	if err := r.Client.Create(ctx, machine); err != nil {
 		return errors.Wrap(err, "failed to create machine")
 	}
+	metrics.RecordMachineCreated(kubeadmControlPlaneKind)
 	return nil
 }
+
+// machineName returns the name for a new control plane Machine, generated from the MachineNamingStrategy template if
+// defined, or from the name of the KubeadmControlPlane otherwise.
+func machineName(kcp *controlplanev1.KubeadmControlPlane, cluster *clusterv1.Cluster) (string, error) {
+	if kcp.Spec.MachineNamingStrategy == nil || kcp.Spec.MachineNamingStrategy.Template == "" {
+		return names.SimpleNameGenerator.GenerateName(kcp.Name + "-"), nil
+	}
+	template, err := utilnames.ParseTemplate(kcp.Spec.MachineNamingStrategy.Template)
+	if err != nil {
+		return "", err
+	}
+	return template.Generate(kcp.MachineNameTemplateVariables(cluster.Name))
+}
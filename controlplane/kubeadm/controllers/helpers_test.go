@@ -17,6 +17,7 @@ limitations under the License.
 package controllers
 
 import (
+	"strings"
 	"testing"
 
 	. "github.com/onsi/gomega"
@@ -24,6 +25,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/record"
 	utilpointer "k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
@@ -31,6 +33,7 @@ import (
 	"sigs.k8s.io/cluster-api/controllers/external"
 	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal"
+	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/kubeconfig"
 	"sigs.k8s.io/cluster-api/util/secret"
@@ -323,6 +326,69 @@ func TestKubeadmControlPlaneReconciler_reconcileKubeconfig(t *testing.T) {
 	g.Expect(kubeconfigSecret.Labels).To(HaveKeyWithValue(clusterv1.ClusterLabelName, cluster.Name))
 }
 
+func TestKubeadmControlPlaneReconciler_reconcileKubeconfigCARotation(t *testing.T) {
+	g := NewWithT(t)
+
+	cluster := &clusterv1.Cluster{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Cluster",
+			APIVersion: clusterv1.GroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "test",
+		},
+		Spec: clusterv1.ClusterSpec{
+			ControlPlaneEndpoint: clusterv1.APIEndpoint{Host: "test.local", Port: 8443},
+		},
+	}
+
+	kcp := &controlplanev1.KubeadmControlPlane{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "KubeadmControlPlane",
+			APIVersion: controlplanev1.GroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "test",
+		},
+		Spec: controlplanev1.KubeadmControlPlaneSpec{
+			Version: "v1.16.6",
+		},
+	}
+	controllerOwnerRef := *metav1.NewControllerRef(kcp, controlplanev1.GroupVersion.WithKind("KubeadmControlPlane"))
+
+	clusterCerts := secret.NewCertificatesForInitialControlPlane(&bootstrapv1.ClusterConfiguration{})
+	g.Expect(clusterCerts.Generate()).To(Succeed())
+	caCertSecret := clusterCerts.GetByPurpose(secret.ClusterCA).AsSecret(client.ObjectKey{Namespace: "test", Name: "foo"}, controllerOwnerRef)
+
+	fakeClient := newFakeClient(g, kcp.DeepCopy(), caCertSecret.DeepCopy())
+	g.Expect(kubeconfig.CreateSecretWithOwner(ctx, fakeClient, util.ObjectKey(cluster), cluster.Spec.ControlPlaneEndpoint.String(), controllerOwnerRef)).To(Succeed())
+
+	// Rotate the cluster CA.
+	rotatedCerts := secret.NewCertificatesForInitialControlPlane(&bootstrapv1.ClusterConfiguration{})
+	g.Expect(rotatedCerts.Generate()).To(Succeed())
+	rotatedCA := rotatedCerts.GetByPurpose(secret.ClusterCA)
+	g.Expect(fakeClient.Get(ctx, util.ObjectKey(caCertSecret), caCertSecret)).To(Succeed())
+	caCertSecret.Data = rotatedCA.AsSecret(client.ObjectKey{Namespace: "test", Name: "foo"}, controllerOwnerRef).Data
+	g.Expect(fakeClient.Update(ctx, caCertSecret)).To(Succeed())
+
+	r := &KubeadmControlPlaneReconciler{
+		Client:   fakeClient,
+		recorder: record.NewFakeRecorder(32),
+	}
+	result, err := r.reconcileKubeconfig(ctx, cluster, kcp)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(result).To(Equal(ctrl.Result{}))
+	g.Expect(conditions.IsTrue(kcp, controlplanev1.KubeconfigUpToDateCondition)).To(BeTrue())
+
+	kubeconfigSecret := &corev1.Secret{}
+	g.Expect(r.Client.Get(ctx, client.ObjectKey{Namespace: "test", Name: secret.Name(cluster.Name, secret.Kubeconfig)}, kubeconfigSecret)).To(Succeed())
+	config, err := clientcmd.Load(kubeconfigSecret.Data[secret.KubeconfigDataName])
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(config.Clusters[cluster.Name].CertificateAuthorityData).To(Equal(rotatedCA.KeyPair.Cert))
+}
+
 func TestCloneConfigsAndGenerateMachine(t *testing.T) {
 	g := NewWithT(t)
 
@@ -405,6 +471,93 @@ func TestCloneConfigsAndGenerateMachine(t *testing.T) {
 	}
 }
 
+func TestCloneConfigsAndGenerateMachineWithFailureDomainInfrastructureTemplate(t *testing.T) {
+	g := NewWithT(t)
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "test",
+		},
+	}
+
+	genericMachineTemplate := func(name string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind":       "GenericMachineTemplate",
+				"apiVersion": "generic.io/v1",
+				"metadata": map[string]interface{}{
+					"name":      name,
+					"namespace": cluster.Namespace,
+				},
+				"spec": map[string]interface{}{
+					"template": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"hello": name,
+						},
+					},
+				},
+			},
+		}
+	}
+	defaultTemplate := genericMachineTemplate("infra-foo")
+	zoneTemplate := genericMachineTemplate("infra-zone-b")
+
+	templateRef := func(template *unstructured.Unstructured) corev1.ObjectReference {
+		return corev1.ObjectReference{
+			Kind:       template.GetKind(),
+			APIVersion: template.GetAPIVersion(),
+			Name:       template.GetName(),
+			Namespace:  cluster.Namespace,
+		}
+	}
+	kcp := &controlplanev1.KubeadmControlPlane{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kcp-foo",
+			Namespace: cluster.Namespace,
+		},
+		Spec: controlplanev1.KubeadmControlPlaneSpec{
+			InfrastructureTemplate: templateRef(defaultTemplate),
+			FailureDomainInfrastructureTemplates: []controlplanev1.FailureDomainInfrastructureTemplate{
+				{FailureDomain: "zone-b", InfrastructureTemplate: templateRef(zoneTemplate)},
+			},
+			Version: "v1.16.6",
+		},
+	}
+
+	tests := []struct {
+		failureDomain *string
+		wantTemplate  string
+	}{
+		{failureDomain: nil, wantTemplate: defaultTemplate.GetName()},
+		{failureDomain: utilpointer.StringPtr("zone-a"), wantTemplate: defaultTemplate.GetName()},
+		{failureDomain: utilpointer.StringPtr("zone-b"), wantTemplate: zoneTemplate.GetName()},
+	}
+	for _, tt := range tests {
+		fakeClient := newFakeClient(g, cluster.DeepCopy(), kcp.DeepCopy(), defaultTemplate.DeepCopy(), zoneTemplate.DeepCopy())
+		r := &KubeadmControlPlaneReconciler{
+			Client:   fakeClient,
+			recorder: record.NewFakeRecorder(32),
+		}
+
+		bootstrapSpec := &bootstrapv1.KubeadmConfigSpec{
+			JoinConfiguration: &bootstrapv1.JoinConfiguration{},
+		}
+		g.Expect(r.cloneConfigsAndGenerateMachine(ctx, cluster, kcp, bootstrapSpec, tt.failureDomain)).To(Succeed())
+
+		machineList := &clusterv1.MachineList{}
+		g.Expect(fakeClient.List(ctx, machineList, client.InNamespace(cluster.Namespace))).To(Succeed())
+		g.Expect(machineList.Items).To(HaveLen(1))
+
+		m := machineList.Items[0]
+		g.Expect(m.Spec.FailureDomain).To(Equal(tt.failureDomain))
+		infraObj, err := external.Get(ctx, r.Client, &m.Spec.InfrastructureRef, m.Spec.InfrastructureRef.Namespace)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(infraObj.GetAnnotations()).To(HaveKeyWithValue(clusterv1.TemplateClonedFromNameAnnotation, tt.wantTemplate))
+		g.Expect(m.Spec.InfrastructureRef.Name).To(HavePrefix(tt.wantTemplate))
+	}
+}
+
 func TestCloneConfigsAndGenerateMachineFail(t *testing.T) {
 	g := NewWithT(t)
 
@@ -531,6 +684,57 @@ func TestKubeadmControlPlaneReconciler_generateMachine(t *testing.T) {
 	g.Expect(machine.Spec).To(Equal(expectedMachineSpec))
 }
 
+func TestKubeadmControlPlaneReconciler_generateMachineWithNamingStrategy(t *testing.T) {
+	g := NewWithT(t)
+	fakeClient := newFakeClient(g)
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-cluster",
+			Namespace: "test",
+		},
+	}
+
+	kcp := &controlplanev1.KubeadmControlPlane{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-control-plane",
+			Namespace: cluster.Namespace,
+		},
+		Spec: controlplanev1.KubeadmControlPlaneSpec{
+			Version:               "v1.16.6",
+			MachineNamingStrategy: &controlplanev1.MachineNamingStrategy{Template: "{{ .cluster }}-cp-{{ .random }}"},
+		},
+	}
+
+	infraRef := &corev1.ObjectReference{
+		Kind:       "InfraKind",
+		APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha4",
+		Name:       "infra",
+		Namespace:  cluster.Namespace,
+	}
+	bootstrapRef := &corev1.ObjectReference{
+		Kind:       "BootstrapKind",
+		APIVersion: "bootstrap.cluster.x-k8s.io/v1alpha4",
+		Name:       "bootstrap",
+		Namespace:  cluster.Namespace,
+	}
+	r := &KubeadmControlPlaneReconciler{
+		Client:            fakeClient,
+		managementCluster: &internal.Management{Client: fakeClient},
+		recorder:          record.NewFakeRecorder(32),
+	}
+	g.Expect(r.generateMachine(ctx, kcp, cluster, infraRef, bootstrapRef, nil)).To(Succeed())
+
+	machineList := &clusterv1.MachineList{}
+	g.Expect(fakeClient.List(ctx, machineList, client.InNamespace(cluster.Namespace))).To(Succeed())
+	g.Expect(machineList.Items).To(HaveLen(1))
+	g.Expect(machineList.Items[0].Name).To(MatchRegexp(`^test-cluster-cp-[a-z0-9]{5}$`))
+
+	// Names not valid for the Cluster are not generated.
+	cluster.Name = strings.Repeat("a", 60)
+	g.Expect(r.generateMachine(ctx, kcp, cluster, infraRef, bootstrapRef, nil)).NotTo(Succeed())
+}
+
 func TestKubeadmControlPlaneReconciler_generateKubeadmConfig(t *testing.T) {
 	g := NewWithT(t)
 	fakeClient := newFakeClient(g)
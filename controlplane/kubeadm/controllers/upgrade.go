@@ -21,6 +21,7 @@ import (
 
 	"github.com/blang/semver"
 	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal"
@@ -50,6 +51,10 @@ func (r *KubeadmControlPlaneReconciler) upgradeControlPlane(
 		return ctrl.Result{}, err
 	}
 
+	if err := r.reconcilePreUpgradeEtcdBackup(ctx, cluster, kcp, controlPlane, workloadCluster); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	parsedVersion, err := semver.ParseTolerant(kcp.Spec.Version)
 	if err != nil {
 		return ctrl.Result{}, errors.Wrapf(err, "failed to parse kubernetes version %q", kcp.Spec.Version)
@@ -109,7 +114,14 @@ func (r *KubeadmControlPlaneReconciler) upgradeControlPlane(
 	case controlplanev1.RollingUpdateStrategyType:
 		// RolloutStrategy is currently defaulted and validated to be RollingUpdate
 		// We can ignore MaxUnavailable because we are enforcing health checks before we get here.
-		maxNodes := *kcp.Spec.Replicas + int32(kcp.Spec.RolloutStrategy.RollingUpdate.MaxSurge.IntValue())
+		// MaxSurge can be a percentage of the desired replicas, rounded up as for MachineDeployments; the current
+		// number of Machines must not be used, because it already includes the Machines surged during the rollout.
+		desiredReplicas := *kcp.Spec.Replicas
+		maxSurge, err := intstr.GetValueFromIntOrPercent(kcp.Spec.RolloutStrategy.RollingUpdate.MaxSurge, int(desiredReplicas), true)
+		if err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "failed to resolve maxSurge")
+		}
+		maxNodes := desiredReplicas + int32(maxSurge)
 		if int32(controlPlane.Machines.Len()) < maxNodes {
 			// scaleUp ensures that we don't continue scaling up while waiting for Machines to have NodeRefs
 			return r.scaleUpControlPlane(ctx, cluster, kcp, controlPlane)
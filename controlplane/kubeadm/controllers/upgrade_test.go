@@ -27,6 +27,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
@@ -214,6 +215,82 @@ func TestKubeadmControlPlaneReconciler_RolloutStrategy_ScaleDown(t *testing.T) {
 	g.Expect(remainingMachines.Items).To(HaveLen(2))
 }
 
+func TestKubeadmControlPlaneReconciler_RolloutStrategy_MaxSurgePercentage(t *testing.T) {
+	version := "v1.17.3"
+	g := NewWithT(t)
+
+	cluster, kcp, tmpl := createClusterWithControlPlane()
+	cluster.Spec.ControlPlaneEndpoint.Host = Host
+	cluster.Spec.ControlPlaneEndpoint.Port = 6443
+	kcp.Spec.Replicas = pointer.Int32Ptr(3)
+	kcp.Spec.RolloutStrategy.RollingUpdate.MaxSurge = &intstr.IntOrString{Type: intstr.String, StrVal: "33%"}
+	setKCPHealthy(kcp)
+
+	// 4 Machines exist, because a Machine has already been surged during the rollout; 33% resolves to 1 Machine
+	// for the 3 desired replicas, so the surge is exhausted, while it would resolve to 2 Machines for the 4
+	// current ones, allowing one more Machine.
+	fmc := &fakeManagementCluster{
+		Machines: collections.Machines{},
+		Workload: fakeWorkloadCluster{
+			Status: internal.ClusterStatus{Nodes: 4},
+		},
+	}
+	objs := []client.Object{cluster.DeepCopy(), kcp.DeepCopy(), tmpl.DeepCopy()}
+	for i := 0; i < 4; i++ {
+		name := fmt.Sprintf("test-%d", i)
+		m := &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: cluster.Namespace,
+				Name:      name,
+				Labels:    internal.ControlPlaneLabelsForCluster(cluster.Name),
+			},
+			Spec: clusterv1.MachineSpec{
+				Bootstrap: clusterv1.Bootstrap{
+					ConfigRef: &corev1.ObjectReference{
+						APIVersion: bootstrapv1.GroupVersion.String(),
+						Kind:       "KubeadmConfig",
+						Name:       name,
+					},
+				},
+				Version: &version,
+			},
+		}
+		setMachineHealthy(m)
+		cfg := &bootstrapv1.KubeadmConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: cluster.Namespace,
+				Name:      name,
+			},
+		}
+		objs = append(objs, m, cfg)
+		fmc.Machines.Insert(m)
+	}
+	fakeClient := newFakeClient(g, objs...)
+	fmc.Reader = fakeClient
+	r := &KubeadmControlPlaneReconciler{
+		Client:                    fakeClient,
+		recorder:                  record.NewFakeRecorder(32),
+		managementCluster:         fmc,
+		managementClusterUncached: fmc,
+	}
+
+	// change the KCP spec so the machines become outdated
+	kcp.Spec.Version = UpdatedVersion
+
+	// run upgrade, expect we scale down
+	controlPlane := &internal.ControlPlane{
+		KCP:      kcp,
+		Cluster:  cluster,
+		Machines: fmc.Machines,
+	}
+	result, err := r.upgradeControlPlane(ctx, cluster, kcp, controlPlane, fmc.Machines)
+	g.Expect(result).To(Equal(ctrl.Result{Requeue: true}))
+	g.Expect(err).NotTo(HaveOccurred())
+	remainingMachines := &clusterv1.MachineList{}
+	g.Expect(fakeClient.List(ctx, remainingMachines, client.InNamespace(cluster.Namespace))).To(Succeed())
+	g.Expect(remainingMachines.Items).To(HaveLen(3))
+}
+
 type machineOpt func(*clusterv1.Machine)
 
 func machine(name string, opts ...machineOpt) *clusterv1.Machine {
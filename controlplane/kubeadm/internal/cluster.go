@@ -51,6 +51,10 @@ type ManagementCluster interface {
 type Management struct {
 	Client  ctrlclient.Reader
 	Tracker *remote.ClusterCacheTracker
+
+	// Uncached must be set when the Client reads from the API server instead of a cache; in this case Machines are
+	// listed by the cluster name label, given that the clusterv1.ClusterNameIndex exists only in the cache.
+	Uncached bool
 }
 
 // RemoteClusterConnectionError represents a failure to connect to a remote cluster.
@@ -75,7 +79,15 @@ func (m *Management) List(ctx context.Context, list client.ObjectList, opts ...c
 // GetMachinesForCluster returns a list of machines that can be filtered or not.
 // If no filter is supplied then all machines associated with the target cluster are returned.
 func (m *Management) GetMachinesForCluster(ctx context.Context, cluster *clusterv1.Cluster, filters ...collections.Func) (collections.Machines, error) {
-	return collections.GetFilteredMachinesForCluster(ctx, m.Client, cluster, filters...)
+	if !m.Uncached {
+		return collections.GetFilteredMachinesForCluster(ctx, m.Client, cluster, filters...)
+	}
+
+	ml := &clusterv1.MachineList{}
+	if err := m.Client.List(ctx, ml, client.InNamespace(cluster.Namespace), client.MatchingLabels{clusterv1.ClusterLabelName: cluster.Name}); err != nil {
+		return nil, errors.Wrap(err, "failed to list machines")
+	}
+	return collections.FromMachineList(ml).Filter(filters...), nil
 }
 
 // GetWorkloadCluster builds a cluster object.
@@ -36,43 +36,94 @@ import (
 	rbacv1 "k8s.io/api/rbac/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/controllers/remote"
+	"sigs.k8s.io/cluster-api/test/helpers"
+	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/certs"
 	"sigs.k8s.io/cluster-api/util/kubeconfig"
 	"sigs.k8s.io/cluster-api/util/secret"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestGetMachinesForCluster(t *testing.T) {
 	g := NewWithT(t)
 
-	m := Management{Client: &fakeClient{
-		list: machineListForTestGetMachinesForCluster(),
-	}}
+	scheme := runtime.NewScheme()
+	g.Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+
 	cluster := &clusterv1.Cluster{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: "my-namespace",
 			Name:      "my-cluster",
 		},
 	}
-	machines, err := m.GetMachinesForCluster(ctx, cluster)
-	g.Expect(err).NotTo(HaveOccurred())
-	g.Expect(machines).To(HaveLen(3))
-
-	// Test the ControlPlaneMachines works
-	machines, err = m.GetMachinesForCluster(ctx, cluster, collections.ControlPlaneMachines("my-cluster"))
-	g.Expect(err).NotTo(HaveOccurred())
-	g.Expect(machines).To(HaveLen(1))
-
-	// Test that the filters use AND logic instead of OR logic
-	nameFilter := func(cluster *clusterv1.Machine) bool {
-		return cluster.Name == "first-machine"
+
+	// The Machines of another Cluster are in the same namespace, and one of them is a control plane Machine owned
+	// by a control plane with the same name as the one of the Cluster.
+	objs := []client.Object{cluster}
+	machines := machineListForTestGetMachinesForCluster()
+	for i := range machines.Items {
+		objs = append(objs, &machines.Items[i])
+	}
+	for _, m := range machineListForTestGetMachinesForCluster().Items {
+		m := m
+		m.Name = "other-cluster-" + m.Name
+		m.Labels[clusterv1.ClusterLabelName] = "other-cluster"
+		m.Spec.ClusterName = "other-cluster"
+		objs = append(objs, &m)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+
+	tests := []struct {
+		name string
+		m    Management
+	}{
+		{
+			name: "cached, using the cluster name index",
+			m: Management{Client: helpers.NewFakeClientWithIndexes(c,
+				helpers.FakeIndex{Object: &clusterv1.Machine{}, Field: clusterv1.ClusterNameIndex, Extract: util.IndexByClusterName},
+			)},
+		},
+		{
+			name: "uncached, using the cluster name label",
+			m:    Management{Client: c, Uncached: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			machines, err := tt.m.GetMachinesForCluster(ctx, cluster)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(machines.Names()).To(ConsistOf("first-machine", "second-machine", "third-machine"))
+
+			// Test the ControlPlaneMachines works
+			machines, err = tt.m.GetMachinesForCluster(ctx, cluster, collections.ControlPlaneMachines("my-cluster"))
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(machines.Names()).To(ConsistOf("first-machine"))
+
+			// Test that only the Machines of the Cluster owned by the control plane are returned
+			controlPlane := &unstructured.Unstructured{}
+			controlPlane.SetKind("KubeadmControlPlane")
+			controlPlane.SetName("my-control-plane")
+			machines, err = tt.m.GetMachinesForCluster(ctx, cluster, collections.OwnedMachines(controlPlane))
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(machines.Names()).To(ConsistOf("first-machine"))
+
+			// Test that the filters use AND logic instead of OR logic
+			nameFilter := func(cluster *clusterv1.Machine) bool {
+				return cluster.Name == "first-machine"
+			}
+			machines, err = tt.m.GetMachinesForCluster(ctx, cluster, collections.ControlPlaneMachines("my-cluster"), nameFilter)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(machines).To(HaveLen(1))
+		})
 	}
-	machines, err = m.GetMachinesForCluster(ctx, cluster, collections.ControlPlaneMachines("my-cluster"), nameFilter)
-	g.Expect(err).NotTo(HaveOccurred())
-	g.Expect(machines).To(HaveLen(1))
 }
 
 func TestGetWorkloadCluster(t *testing.T) {
@@ -257,6 +308,9 @@ func machineListForTestGetMachinesForCluster() *clusterv1.MachineList {
 					clusterv1.ClusterLabelName: "my-cluster",
 				},
 			},
+			Spec: clusterv1.MachineSpec{
+				ClusterName: "my-cluster",
+			},
 		}
 	}
 	controlPlaneMachine := machine("first-machine")
@@ -19,6 +19,7 @@ package etcd
 import (
 	"context"
 	"crypto/tls"
+	"io"
 	"net"
 	"time"
 
@@ -45,6 +46,7 @@ type etcd interface {
 	MemberRemove(ctx context.Context, id uint64) (*clientv3.MemberRemoveResponse, error)
 	MemberUpdate(ctx context.Context, id uint64, peerURLs []string) (*clientv3.MemberUpdateResponse, error)
 	MoveLeader(ctx context.Context, id uint64) (*clientv3.MoveLeaderResponse, error)
+	Snapshot(ctx context.Context) (io.ReadCloser, error)
 	Status(ctx context.Context, endpoint string) (*clientv3.StatusResponse, error)
 }
 
@@ -205,6 +207,16 @@ func (c *Client) MoveLeader(ctx context.Context, newLeaderID uint64) error {
 	return errors.Wrapf(err, "failed to move etcd leader: %v", newLeaderID)
 }
 
+// Snapshot returns a stream of the snapshot of the etcd backend database, taken from the member the client is connected to;
+// the caller is responsible for closing the stream.
+func (c *Client) Snapshot(ctx context.Context) (io.ReadCloser, error) {
+	reader, err := c.EtcdClient.Snapshot(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to take etcd snapshot")
+	}
+	return reader, nil
+}
+
 // RemoveMember removes a given member.
 func (c *Client) RemoveMember(ctx context.Context, id uint64) error {
 	_, err := c.EtcdClient.MemberRemove(ctx, id)
@@ -58,9 +58,11 @@ func MatchesTemplateClonedFrom(infraConfigs map[string]*unstructured.Unstructure
 			return true
 		}
 
-		// Check if the machine's infrastructure reference has been created from the current KCP infrastructure template.
-		if clonedFromName != kcp.Spec.InfrastructureTemplate.Name ||
-			clonedFromGroupKind != kcp.Spec.InfrastructureTemplate.GroupVersionKind().GroupKind().String() {
+		// Check if the machine's infrastructure reference has been created from the current KCP infrastructure template
+		// for the machine's failure domain.
+		infraTemplate := kcp.InfrastructureTemplateForFailureDomain(machine.Spec.FailureDomain)
+		if clonedFromName != infraTemplate.Name ||
+			clonedFromGroupKind != infraTemplate.GroupVersionKind().GroupKind().String() {
 			return false
 		}
 		return true
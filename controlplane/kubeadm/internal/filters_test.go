@@ -23,6 +23,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha4"
 	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha4"
@@ -953,3 +954,99 @@ func TestMatchesTemplateClonedFrom_WithClonedFromAnnotations(t *testing.T) {
 		})
 	}
 }
+
+func TestMatchesTemplateClonedFrom_WithFailureDomainInfrastructureTemplates(t *testing.T) {
+	kcp := &controlplanev1.KubeadmControlPlane{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+		},
+		Spec: controlplanev1.KubeadmControlPlaneSpec{
+			InfrastructureTemplate: corev1.ObjectReference{
+				Kind:       "GenericMachineTemplate",
+				Namespace:  "default",
+				Name:       "infra-foo",
+				APIVersion: "generic.io/v1",
+			},
+			FailureDomainInfrastructureTemplates: []controlplanev1.FailureDomainInfrastructureTemplate{
+				{
+					FailureDomain: "zone-b",
+					InfrastructureTemplate: corev1.ObjectReference{
+						Kind:       "GenericMachineTemplate",
+						Namespace:  "default",
+						Name:       "infra-zone-b",
+						APIVersion: "generic.io/v1",
+					},
+				},
+			},
+		},
+	}
+	tests := []struct {
+		name          string
+		failureDomain *string
+		clonedFrom    string
+		expectMatch   bool
+	}{
+		{
+			name:        "returns true if a machine without failure domain is cloned from the default template",
+			clonedFrom:  "infra-foo",
+			expectMatch: true,
+		},
+		{
+			name:          "returns true if a machine is cloned from the default template and its failure domain has no override",
+			failureDomain: pointer.StringPtr("zone-a"),
+			clonedFrom:    "infra-foo",
+			expectMatch:   true,
+		},
+		{
+			name:          "returns true if a machine is cloned from the template for its failure domain",
+			failureDomain: pointer.StringPtr("zone-b"),
+			clonedFrom:    "infra-zone-b",
+			expectMatch:   true,
+		},
+		{
+			name:          "returns false if a machine is cloned from the default template but its failure domain has an override",
+			failureDomain: pointer.StringPtr("zone-b"),
+			clonedFrom:    "infra-foo",
+			expectMatch:   false,
+		},
+		{
+			name:          "returns false if a machine is cloned from the template for another failure domain",
+			failureDomain: pointer.StringPtr("zone-a"),
+			clonedFrom:    "infra-zone-b",
+			expectMatch:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			machine := &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "machine1",
+				},
+				Spec: clusterv1.MachineSpec{
+					FailureDomain: tt.failureDomain,
+				},
+			}
+			infraConfigs := map[string]*unstructured.Unstructured{
+				machine.Name: {
+					Object: map[string]interface{}{
+						"kind":       "InfrastructureMachine",
+						"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha4",
+						"metadata": map[string]interface{}{
+							"name":      "infra-config1",
+							"namespace": "default",
+							"annotations": map[string]interface{}{
+								clusterv1.TemplateClonedFromNameAnnotation:      tt.clonedFrom,
+								clusterv1.TemplateClonedFromGroupKindAnnotation: "GenericMachineTemplate.generic.io",
+							},
+						},
+					},
+				},
+			}
+			g.Expect(
+				MatchesTemplateClonedFrom(infraConfigs, kcp)(machine),
+			).To(Equal(tt.expectMatch))
+		})
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"fmt"
+	"io"
 	"math/big"
 	"time"
 
@@ -85,6 +86,7 @@ type WorkloadCluster interface {
 	RemoveMachineFromKubeadmConfigMap(ctx context.Context, machine *clusterv1.Machine) error
 	RemoveNodeFromKubeadmConfigMap(ctx context.Context, nodeName string) error
 	ForwardEtcdLeadership(ctx context.Context, machine *clusterv1.Machine, leaderCandidate *clusterv1.Machine) error
+	EtcdSnapshot(ctx context.Context) (io.ReadCloser, error)
 	AllowBootstrapTokensToGetNodes(ctx context.Context) error
 
 	// State recovery tasks.
@@ -18,6 +18,7 @@ package internal
 
 import (
 	"context"
+	"io"
 
 	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -234,3 +235,38 @@ func (w *Workload) EtcdMembers(ctx context.Context) ([]string, error) {
 	}
 	return names, nil
 }
+
+// EtcdSnapshot returns a stream of the snapshot of the etcd cluster, taken from the etcd leader;
+// the caller is responsible for closing the stream.
+func (w *Workload) EtcdSnapshot(ctx context.Context) (io.ReadCloser, error) {
+	nodes, err := w.getControlPlaneNodes(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list control plane nodes")
+	}
+	nodeNames := make([]string, 0, len(nodes.Items))
+	for _, node := range nodes.Items {
+		nodeNames = append(nodeNames, node.Name)
+	}
+	etcdClient, err := w.etcdClientGenerator.forLeader(ctx, nodeNames)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create etcd client")
+	}
+
+	snapshot, err := etcdClient.Snapshot(ctx)
+	if err != nil {
+		etcdClient.Close()
+		return nil, err
+	}
+	return &etcdSnapshotStream{ReadCloser: snapshot, etcdClient: etcdClient}, nil
+}
+
+// etcdSnapshotStream is a stream of an etcd snapshot that closes the etcd client it is read from when closed.
+type etcdSnapshotStream struct {
+	io.ReadCloser
+	etcdClient *etcd.Client
+}
+
+func (s *etcdSnapshotStream) Close() error {
+	defer s.etcdClient.Close()
+	return s.ReadCloser.Close()
+}
@@ -19,6 +19,7 @@ package internal
 import (
 	"context"
 	"errors"
+	"io/ioutil"
 	"testing"
 
 	. "github.com/onsi/gomega"
@@ -566,6 +567,69 @@ kind: ClusterStatus`,
 	}
 }
 
+func TestEtcdSnapshot(t *testing.T) {
+	cp1 := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cp1",
+			Labels: map[string]string{
+				labelNodeRoleControlPlane: "",
+			},
+		},
+	}
+
+	tests := []struct {
+		name                string
+		etcdClientGenerator etcdClientFor
+		expectErr           bool
+		expectSnapshot      []byte
+	}{
+		{
+			name: "returns the snapshot taken from the etcd leader",
+			etcdClientGenerator: &fakeEtcdClientGenerator{
+				forLeaderClient: &etcd.Client{
+					EtcdClient: &fake2.FakeEtcdClient{SnapshotResponse: []byte("snapshot")},
+				},
+			},
+			expectSnapshot: []byte("snapshot"),
+		},
+		{
+			name: "returns an error if the etcd client can't be created",
+			etcdClientGenerator: &fakeEtcdClientGenerator{
+				forLeaderErr: errors.New("no etcd leader"),
+			},
+			expectErr: true,
+		},
+		{
+			name: "returns an error if the snapshot fails",
+			etcdClientGenerator: &fakeEtcdClientGenerator{
+				forLeaderClient: &etcd.Client{
+					EtcdClient: &fake2.FakeEtcdClient{ErrorResponse: errors.New("snapshot failed")},
+				},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			w := &Workload{
+				Client:              fake.NewClientBuilder().WithObjects(cp1.DeepCopy()).Build(),
+				etcdClientGenerator: tt.etcdClientGenerator,
+			}
+			snapshot, err := w.EtcdSnapshot(ctx)
+			if tt.expectErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			defer snapshot.Close()
+			g.Expect(ioutil.ReadAll(snapshot)).To(Equal(tt.expectSnapshot))
+		})
+	}
+}
+
 type fakeEtcdClientGenerator struct {
 	forNodesClient     *etcd.Client
 	forNodesClientFunc func([]string) (*etcd.Client, error)
@@ -73,6 +73,7 @@ var (
 	webhookPort                    int
 	webhookCertDir                 string
 	healthAddr                     string
+	etcdSnapshotVolumesDir         string
 )
 
 // InitFlags initializes the flags.
@@ -112,6 +113,9 @@ func InitFlags(fs *pflag.FlagSet) {
 
 	fs.StringVar(&healthAddr, "health-addr", ":9440",
 		"The address the health endpoint binds to.")
+
+	fs.StringVar(&etcdSnapshotVolumesDir, "etcd-snapshot-volumes-dir", "",
+		"Directory where the PersistentVolumeClaims used as preUpgradeEtcdBackup targets are mounted, each one in a subdirectory named after the claim. If unspecified, etcd snapshots cannot be saved to PersistentVolumeClaims.")
 }
 func main() {
 	rand.Seed(time.Now().UnixNano())
@@ -201,8 +205,9 @@ func setupReconcilers(ctx context.Context, mgr ctrl.Manager) {
 	}
 
 	if err := (&kubeadmcontrolplanecontrollers.KubeadmControlPlaneReconciler{
-		Client:  mgr.GetClient(),
-		Tracker: tracker,
+		Client:                 mgr.GetClient(),
+		Tracker:                tracker,
+		EtcdSnapshotVolumesDir: etcdSnapshotVolumesDir,
 	}).SetupWithManager(ctx, mgr, concurrency(kubeadmControlPlaneConcurrency)); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "KubeadmControlPlane")
 		os.Exit(1)
@@ -116,6 +116,7 @@ type ClusterResourceSetBinding struct {
 // ClusterResourceSetBindingSpec defines the desired state of ClusterResourceSetBinding.
 type ClusterResourceSetBindingSpec struct {
 	// Bindings is a list of ClusterResourceSets and their resources.
+	// +k8s:conversion-gen=false
 	Bindings []*ResourceSetBinding `json:"bindings,omitempty"`
 }
 
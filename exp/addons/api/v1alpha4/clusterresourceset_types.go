@@ -46,6 +46,13 @@ type ClusterResourceSetSpec struct {
 	// +kubebuilder:validation:Enum=ApplyOnce
 	// +optional
 	Strategy string `json:"strategy,omitempty"`
+
+	// Mode defines what happens to the resources applied to a Cluster when the ClusterResourceSet no longer matches
+	// the Cluster or is deleted. In Orphan mode, the default, the resources are left in the Cluster; in Strict mode,
+	// the resources are deleted from the Cluster.
+	// +kubebuilder:validation:Enum=Orphan;Strict
+	// +optional
+	Mode string `json:"mode,omitempty"`
 }
 
 // ANCHOR_END: ClusterResourceSetSpec
@@ -67,6 +74,11 @@ type ResourceRef struct {
 	// Kind of the resource. Supported kinds are: Secrets and ConfigMaps.
 	// +kubebuilder:validation:Enum=Secret;ConfigMap
 	Kind string `json:"kind"`
+
+	// Templated defines if the data of the resource is a Go template, that is rendered with the details of
+	// each matching Cluster before being applied, e.g. {{ .ClusterName }} or {{ index .PodCIDRs 0 }}.
+	// +optional
+	Templated bool `json:"templated,omitempty"`
 }
 
 // ClusterResourceSetStrategy is a string representation of a ClusterResourceSet Strategy.
@@ -78,11 +90,30 @@ const (
 	ClusterResourceSetStrategyApplyOnce ClusterResourceSetStrategy = "ApplyOnce"
 )
 
+// ClusterResourceSetMode is a string representation of a ClusterResourceSet Mode.
+type ClusterResourceSetMode string
+
+const (
+	// ClusterResourceSetModeOrphan leaves the applied resources in a Cluster when the ClusterResourceSet
+	// no longer matches the Cluster or is deleted.
+	ClusterResourceSetModeOrphan ClusterResourceSetMode = "Orphan"
+
+	// ClusterResourceSetModeStrict deletes the applied resources from a Cluster when the ClusterResourceSet
+	// no longer matches the Cluster or is deleted.
+	ClusterResourceSetModeStrict ClusterResourceSetMode = "Strict"
+)
+
 // SetTypedStrategy sets the Strategy field to the string representation of ClusterResourceSetStrategy.
 func (c *ClusterResourceSetSpec) SetTypedStrategy(p ClusterResourceSetStrategy) {
 	c.Strategy = string(p)
 }
 
+// IsStrict returns true if the resources applied by the ClusterResourceSet are deleted from the Clusters
+// it no longer matches.
+func (c *ClusterResourceSetSpec) IsStrict() bool {
+	return c.Mode == string(ClusterResourceSetModeStrict)
+}
+
 // ANCHOR: ClusterResourceSetStatus
 
 // ClusterResourceSetStatus defines the observed state of ClusterResourceSet.
@@ -46,6 +46,10 @@ func (m *ClusterResourceSet) Default() {
 	if m.Spec.Strategy == "" {
 		m.Spec.Strategy = string(ClusterResourceSetStrategyApplyOnce)
 	}
+	// ClusterResourceSet Mode defaults to Orphan.
+	if m.Spec.Mode == "" {
+		m.Spec.Mode = string(ClusterResourceSetModeOrphan)
+	}
 }
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
@@ -39,10 +39,57 @@ type ResourceBinding struct {
 
 	// Applied is to track if a resource is applied to the cluster or not.
 	Applied bool `json:"applied"`
+
+	// Deleted is to track if a resource is deleted from the cluster or not, after the ClusterResourceSet
+	// stopped matching the cluster or was deleted in Strict mode.
+	// +optional
+	Deleted bool `json:"deleted,omitempty"`
+
+	// LastDeletionTime identifies when the deletion of this resource from the cluster was last attempted.
+	// +optional
+	LastDeletionTime *metav1.Time `json:"lastDeletionTime,omitempty"`
+
+	// DeletionMessage is a human readable message explaining why the resource could not be deleted from the cluster.
+	// +optional
+	DeletionMessage string `json:"deletionMessage,omitempty"`
+
+	// Objects is the list of objects created in the cluster when the resource was applied, in creation order.
+	// In Strict mode, these objects are deleted from the cluster, unless another ClusterResourceSet applied them too.
+	// +optional
+	Objects []AppliedObject `json:"objects,omitempty"`
 }
 
 // ANCHOR_END: ResourceBinding
 
+// AppliedObject identifies an object created in a cluster by a ClusterResourceSet resource.
+type AppliedObject struct {
+	// APIVersion of the object.
+	APIVersion string `json:"apiVersion"`
+
+	// Kind of the object.
+	Kind string `json:"kind"`
+
+	// Namespace of the object, empty for cluster-scoped objects.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name of the object.
+	Name string `json:"name"`
+}
+
+// HasObject returns true if the object was created in the cluster by the resource, and it is not deleted since.
+func (r *ResourceBinding) HasObject(obj AppliedObject) bool {
+	if r.Deleted {
+		return false
+	}
+	for _, o := range r.Objects {
+		if o == obj {
+			return true
+		}
+	}
+	return false
+}
+
 // ResourceSetBinding keeps info on all of the resources in a ClusterResourceSet.
 type ResourceSetBinding struct {
 	// ClusterResourceSetName is the name of the ClusterResourceSet that is applied to the owner cluster of the binding.
@@ -52,11 +99,11 @@ type ResourceSetBinding struct {
 	Resources []ResourceBinding `json:"resources,omitempty"`
 }
 
-// IsApplied returns true if the resource is applied to the cluster by checking the cluster's binding.
+// IsApplied returns true if the resource is applied to the cluster, and not deleted since, by checking the cluster's binding.
 func (r *ResourceSetBinding) IsApplied(resourceRef ResourceRef) bool {
 	for _, resource := range r.Resources {
 		if reflect.DeepEqual(resource.ResourceRef, resourceRef) {
-			if resource.Applied {
+			if resource.Applied && !resource.Deleted {
 				return true
 			}
 		}
@@ -64,6 +111,16 @@ func (r *ResourceSetBinding) IsApplied(resourceRef ResourceRef) bool {
 	return false
 }
 
+// GetResource returns the ResourceBinding of a resource if exists, otherwise nil.
+func (r *ResourceSetBinding) GetResource(resourceRef ResourceRef) *ResourceBinding {
+	for i := range r.Resources {
+		if reflect.DeepEqual(r.Resources[i].ResourceRef, resourceRef) {
+			return &r.Resources[i]
+		}
+	}
+	return nil
+}
+
 // SetBinding sets resourceBinding for a resource in resourceSetbinding either by updating the existing one or
 // creating a new one.
 func (r *ResourceSetBinding) SetBinding(resourceBinding ResourceBinding) {
@@ -76,14 +133,22 @@ func (r *ResourceSetBinding) SetBinding(resourceBinding ResourceBinding) {
 	r.Resources = append(r.Resources, resourceBinding)
 }
 
-// GetOrCreateBinding returns the ResourceSetBinding for a given ClusterResourceSet if exists,
-// otherwise creates one and updates ClusterResourceSet with it.
-func (c *ClusterResourceSetBinding) GetOrCreateBinding(clusterResourceSet *ClusterResourceSet) *ResourceSetBinding {
+// GetBinding returns the ResourceSetBinding for a given ClusterResourceSet if exists, otherwise nil.
+func (c *ClusterResourceSetBinding) GetBinding(clusterResourceSet *ClusterResourceSet) *ResourceSetBinding {
 	for _, binding := range c.Spec.Bindings {
 		if binding.ClusterResourceSetName == clusterResourceSet.Name {
 			return binding
 		}
 	}
+	return nil
+}
+
+// GetOrCreateBinding returns the ResourceSetBinding for a given ClusterResourceSet if exists,
+// otherwise creates one and updates ClusterResourceSet with it.
+func (c *ClusterResourceSetBinding) GetOrCreateBinding(clusterResourceSet *ClusterResourceSet) *ResourceSetBinding {
+	if binding := c.GetBinding(clusterResourceSet); binding != nil {
+		return binding
+	}
 	binding := &ResourceSetBinding{ClusterResourceSetName: clusterResourceSet.Name, Resources: []ResourceBinding{}}
 	c.Spec.Bindings = append(c.Spec.Bindings, binding)
 	return binding
@@ -117,6 +182,7 @@ type ClusterResourceSetBinding struct {
 // ClusterResourceSetBindingSpec defines the desired state of ClusterResourceSetBinding.
 type ClusterResourceSetBindingSpec struct {
 	// Bindings is a list of ClusterResourceSets and their resources.
+	// +k8s:conversion-gen=false
 	Bindings []*ResourceSetBinding `json:"bindings,omitempty"`
 }
 
@@ -45,6 +45,10 @@ func TestIsResourceApplied(t *testing.T) {
 		Name: "notExist",
 		Kind: "Secret",
 	}
+	resourceRefDeleted := ResourceRef{
+		Name: "deleted",
+		Kind: "Secret",
+	}
 	CRSBinding := &ResourceSetBinding{
 		ClusterResourceSetName: "test-clusterResourceSet",
 		Resources: []ResourceBinding{
@@ -60,6 +64,14 @@ func TestIsResourceApplied(t *testing.T) {
 				Hash:            "",
 				LastAppliedTime: &metav1.Time{Time: time.Now().UTC()},
 			},
+			{
+				ResourceRef:      resourceRefDeleted,
+				Applied:          true,
+				Hash:             "xyz",
+				LastAppliedTime:  &metav1.Time{Time: time.Now().UTC()},
+				Deleted:          true,
+				LastDeletionTime: &metav1.Time{Time: time.Now().UTC()},
+			},
 		},
 	}
 
@@ -87,6 +99,12 @@ func TestIsResourceApplied(t *testing.T) {
 			resourceRef:        resourceRefNotExist,
 			isApplied:          false,
 		},
+		{
+			name:               "should return false if the resource is deleted",
+			resourceSetBinding: CRSBinding,
+			resourceRef:        resourceRefDeleted,
+			isApplied:          false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -168,3 +186,37 @@ func TestSetResourceBinding(t *testing.T) {
 		})
 	}
 }
+
+func TestResourceBindingHasObject(t *testing.T) {
+	obj := AppliedObject{APIVersion: "v1", Kind: "ConfigMap", Namespace: "kube-system", Name: "calico-config"}
+	other := AppliedObject{APIVersion: "v1", Kind: "ConfigMap", Namespace: "kube-system", Name: "other"}
+
+	tests := []struct {
+		name            string
+		resourceBinding ResourceBinding
+		want            bool
+	}{
+		{
+			name:            "should return true if the object was created by the resource",
+			resourceBinding: ResourceBinding{Applied: true, Objects: []AppliedObject{other, obj}},
+			want:            true,
+		},
+		{
+			name:            "should return false if the object was not created by the resource",
+			resourceBinding: ResourceBinding{Applied: true, Objects: []AppliedObject{other}},
+			want:            false,
+		},
+		{
+			name:            "should return false if the resource was deleted",
+			resourceBinding: ResourceBinding{Applied: true, Deleted: true, Objects: []AppliedObject{obj}},
+			want:            false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := NewWithT(t)
+			gs.Expect(tt.resourceBinding.HasObject(obj)).To(Equal(tt.want))
+		})
+	}
+}
@@ -37,6 +37,9 @@ const (
 	// RetrievingResourceFailedReason (Severity=Warning) documents at least one of the resources are not successfully retrieved.
 	RetrievingResourceFailedReason = "RetrievingResourceFailed"
 
+	// RenderingResourceFailedReason (Severity=Warning) documents at least one of the templated resources failed to render.
+	RenderingResourceFailedReason = "RenderingResourceFailed"
+
 	// WrongSecretType (Severity=Warning) documents at least one of the Secret's type in the resource list is not supported.
 	WrongSecretTypeReason = "WrongSecretType"
 )
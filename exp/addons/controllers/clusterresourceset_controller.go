@@ -32,6 +32,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
 	"sigs.k8s.io/cluster-api/controllers/remote"
 	addonsv1 "sigs.k8s.io/cluster-api/exp/addons/api/v1alpha4"
 	resourcepredicates "sigs.k8s.io/cluster-api/exp/addons/controllers/predicates"
@@ -90,7 +91,7 @@ func (r *ClusterResourceSetReconciler) SetupWithManager(ctx context.Context, mgr
 		).
 		WithOptions(options).
 		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
-		Complete(r)
+		Complete(metrics.NewInstrumentedReconciler("clusterresourceset", r))
 
 	if err != nil {
 		return errors.Wrap(err, "failed setting up with a controller manager")
@@ -127,13 +128,6 @@ func (r *ClusterResourceSetReconciler) Reconcile(ctx context.Context, req ctrl.R
 		}
 	}()
 
-	clusters, err := r.getClustersByClusterResourceSetSelector(ctx, clusterResourceSet)
-	if err != nil {
-		log.Error(err, "Failed fetching clusters that matches ClusterResourceSet labels", "ClusterResourceSet", clusterResourceSet.Name)
-		conditions.MarkFalse(clusterResourceSet, addonsv1.ResourcesAppliedCondition, addonsv1.ClusterMatchFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
-		return ctrl.Result{}, err
-	}
-
 	// Add finalizer first if not exist to avoid the race condition between init and delete
 	if !controllerutil.ContainsFinalizer(clusterResourceSet, addonsv1.ClusterResourceSetFinalizer) {
 		controllerutil.AddFinalizer(clusterResourceSet, addonsv1.ClusterResourceSetFinalizer)
@@ -142,7 +136,14 @@ func (r *ClusterResourceSetReconciler) Reconcile(ctx context.Context, req ctrl.R
 
 	// Handle deletion reconciliation loop.
 	if !clusterResourceSet.ObjectMeta.DeletionTimestamp.IsZero() {
-		return r.reconcileDelete(ctx, clusters, clusterResourceSet)
+		return r.reconcileDelete(ctx, clusterResourceSet)
+	}
+
+	clusters, err := r.getClustersByClusterResourceSetSelector(ctx, clusterResourceSet)
+	if err != nil {
+		log.Error(err, "Failed fetching clusters that matches ClusterResourceSet labels", "ClusterResourceSet", clusterResourceSet.Name)
+		conditions.MarkFalse(clusterResourceSet, addonsv1.ResourcesAppliedCondition, addonsv1.ClusterMatchFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return ctrl.Result{}, err
 	}
 
 	for _, cluster := range clusters {
@@ -151,49 +152,196 @@ func (r *ClusterResourceSetReconciler) Reconcile(ctx context.Context, req ctrl.R
 		}
 	}
 
+	// In Strict mode, delete the resources from the Clusters the ClusterResourceSet no longer matches.
+	if clusterResourceSet.Spec.IsStrict() {
+		if err := r.reconcileUnmatchedClusters(ctx, clusters, clusterResourceSet); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	return ctrl.Result{}, nil
 }
 
-// reconcileDelete removes the deleted ClusterResourceSet from all the ClusterResourceSetBindings it is added to.
-func (r *ClusterResourceSetReconciler) reconcileDelete(ctx context.Context, clusters []*clusterv1.Cluster, crs *addonsv1.ClusterResourceSet) (ctrl.Result, error) {
-	log := ctrl.LoggerFrom(ctx)
+// reconcileDelete removes the deleted ClusterResourceSet from all the ClusterResourceSetBindings it is added to, and removes
+// the finalizer only once all of them are updated. In Strict mode, the resources are deleted from the Clusters first.
+func (r *ClusterResourceSetReconciler) reconcileDelete(ctx context.Context, crs *addonsv1.ClusterResourceSet) (ctrl.Result, error) {
+	// The ClusterResourceSetBindings are used instead of the Clusters matched by the selector, which might have changed
+	// since the resources were applied.
+	bindings := &addonsv1.ClusterResourceSetBindingList{}
+	if err := r.Client.List(ctx, bindings, client.InNamespace(crs.Namespace)); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to list ClusterResourceSetBindings during ClusterResourceSet deletion")
+	}
 
+	errList := []error{}
+	for i := range bindings.Items {
+		binding := &bindings.Items[i]
+		if binding.GetBinding(crs) == nil {
+			continue
+		}
+
+		// ClusterResourceSetBindings are named after the Cluster they belong to.
+		cluster := &clusterv1.Cluster{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Namespace: binding.Namespace, Name: binding.Name}, cluster); err != nil {
+			// The ClusterResourceSetBinding is deleted together with the Cluster.
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			errList = append(errList, errors.Wrapf(err, "failed to get Cluster %s/%s during ClusterResourceSet deletion", binding.Namespace, binding.Name))
+			continue
+		}
+
+		if err := r.removeClusterResourceSetFromCluster(ctx, cluster, crs, binding); err != nil {
+			errList = append(errList, err)
+		}
+	}
+	if len(errList) > 0 {
+		return ctrl.Result{}, kerrors.NewAggregate(errList)
+	}
+
+	controllerutil.RemoveFinalizer(crs, addonsv1.ClusterResourceSetFinalizer)
+	return ctrl.Result{}, nil
+}
+
+// reconcileUnmatchedClusters removes the ClusterResourceSet, and its resources, from the Clusters it is bound to but no longer matches.
+func (r *ClusterResourceSetReconciler) reconcileUnmatchedClusters(ctx context.Context, clusters []*clusterv1.Cluster, crs *addonsv1.ClusterResourceSet) error {
+	matched := make(map[string]bool, len(clusters))
 	for _, cluster := range clusters {
-		clusterResourceSetBinding := &addonsv1.ClusterResourceSetBinding{}
-		clusterResourceSetBindingKey := client.ObjectKey{
-			Namespace: cluster.Namespace,
-			Name:      cluster.Name,
-		}
-		if err := r.Client.Get(ctx, clusterResourceSetBindingKey, clusterResourceSetBinding); err != nil {
-			if !apierrors.IsNotFound(err) {
-				return ctrl.Result{}, errors.Wrapf(err, "failed to get ClusterResourceSetBinding during ClusterResourceSet deletion")
+		matched[cluster.Name] = true
+	}
+
+	bindings := &addonsv1.ClusterResourceSetBindingList{}
+	if err := r.Client.List(ctx, bindings, client.InNamespace(crs.Namespace)); err != nil {
+		return errors.Wrap(err, "failed to list ClusterResourceSetBindings")
+	}
+
+	errList := []error{}
+	for i := range bindings.Items {
+		binding := &bindings.Items[i]
+		// ClusterResourceSetBindings are named after the Cluster they belong to.
+		if matched[binding.Name] || binding.GetBinding(crs) == nil {
+			continue
+		}
+
+		cluster := &clusterv1.Cluster{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Namespace: binding.Namespace, Name: binding.Name}, cluster); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
 			}
-			controllerutil.RemoveFinalizer(crs, addonsv1.ClusterResourceSetFinalizer)
-			return ctrl.Result{}, nil
+			errList = append(errList, err)
+			continue
 		}
 
-		// Initialize the patch helper.
-		patchHelper, err := patch.NewHelper(clusterResourceSetBinding, r.Client)
-		if err != nil {
-			return ctrl.Result{}, err
+		// Resources are not deleted from Clusters being deleted, the ClusterResourceSetBinding is deleted together with the Cluster.
+		if !cluster.DeletionTimestamp.IsZero() {
+			continue
 		}
 
-		clusterResourceSetBinding.DeleteBinding(crs)
+		if err := r.removeClusterResourceSetFromCluster(ctx, cluster, crs, binding); err != nil {
+			errList = append(errList, err)
+		}
+	}
+	return kerrors.NewAggregate(errList)
+}
 
-		// If CRS list is empty in the binding, delete the binding else
-		// attempt to Patch the ClusterResourceSetBinding object after delete reconciliation if there is at least 1 binding left.
-		if len(clusterResourceSetBinding.Spec.Bindings) == 0 {
-			if r.Client.Delete(ctx, clusterResourceSetBinding) != nil {
-				log.Error(err, "failed to delete empty ClusterResourceSetBinding")
+// removeClusterResourceSetFromCluster removes the ClusterResourceSet from the ClusterResourceSetBinding of a Cluster, deleting the
+// ClusterResourceSetBinding if no other ClusterResourceSet is bound to the Cluster. In Strict mode, the resources are deleted from
+// the Cluster first; if this fails, the ClusterResourceSet is kept in the ClusterResourceSetBinding, which records the deletion status
+// of each resource, and the deletion is retried at the next reconcile.
+// Resources are not deleted from Clusters being deleted, nor from Clusters whose control plane is not initialized, which cannot be
+// reached and have no objects to delete.
+func (r *ClusterResourceSetReconciler) removeClusterResourceSetFromCluster(ctx context.Context, cluster *clusterv1.Cluster, crs *addonsv1.ClusterResourceSet, clusterResourceSetBinding *addonsv1.ClusterResourceSetBinding) error {
+	log := ctrl.LoggerFrom(ctx, "cluster", cluster.Name)
+
+	// Initialize the patch helper.
+	patchHelper, err := patch.NewHelper(clusterResourceSetBinding, r.Client)
+	if err != nil {
+		return err
+	}
+
+	deleteResources := crs.Spec.IsStrict() && cluster.DeletionTimestamp.IsZero() && conditions.IsTrue(cluster, clusterv1.ControlPlaneInitializedCondition)
+	if resourceSetBinding := clusterResourceSetBinding.GetBinding(crs); resourceSetBinding != nil && deleteResources {
+		if err := r.deleteClusterResourceSetResources(ctx, cluster, crs, clusterResourceSetBinding, resourceSetBinding); err != nil {
+			if patchErr := patchHelper.Patch(ctx, clusterResourceSetBinding); patchErr != nil {
+				log.Error(patchErr, "failed to patch ClusterResourceSetBinding")
 			}
-		} else if err := patchHelper.Patch(ctx, clusterResourceSetBinding); err != nil {
-			log.Error(err, "failed to patch ClusterResourceSetBinding")
-			return ctrl.Result{}, err
+			return err
 		}
 	}
 
-	controllerutil.RemoveFinalizer(crs, addonsv1.ClusterResourceSetFinalizer)
-	return ctrl.Result{}, nil
+	clusterResourceSetBinding.DeleteBinding(crs)
+
+	// If CRS list is empty in the binding, delete the binding else
+	// attempt to Patch the ClusterResourceSetBinding object after delete reconciliation if there is at least 1 binding left.
+	if len(clusterResourceSetBinding.Spec.Bindings) == 0 {
+		if err := r.Client.Delete(ctx, clusterResourceSetBinding); err != nil {
+			log.Error(err, "failed to delete empty ClusterResourceSetBinding")
+		}
+	} else if err := patchHelper.Patch(ctx, clusterResourceSetBinding); err != nil {
+		log.Error(err, "failed to patch ClusterResourceSetBinding")
+		return err
+	}
+	return nil
+}
+
+// deleteClusterResourceSetResources deletes the objects created by the resources of a ClusterResourceSet from a Cluster, and records
+// the deletion status of each resource in the ResourceSetBinding.
+// Objects also created by the resources of other ClusterResourceSets bound to the Cluster are left in the Cluster.
+func (r *ClusterResourceSetReconciler) deleteClusterResourceSetResources(ctx context.Context, cluster *clusterv1.Cluster, clusterResourceSet *addonsv1.ClusterResourceSet, clusterResourceSetBinding *addonsv1.ClusterResourceSetBinding, resourceSetBinding *addonsv1.ResourceSetBinding) error {
+	log := ctrl.LoggerFrom(ctx, "cluster", cluster.Name)
+
+	var remoteClient client.Client
+	errList := []error{}
+	for i := range resourceSetBinding.Resources {
+		resourceBinding := &resourceSetBinding.Resources[i]
+		if resourceBinding.Deleted {
+			continue
+		}
+
+		objs := []addonsv1.AppliedObject{}
+		for _, obj := range resourceBinding.Objects {
+			if !isAppliedByOtherClusterResourceSets(clusterResourceSetBinding, clusterResourceSet, obj) {
+				objs = append(objs, obj)
+			}
+		}
+
+		// The Cluster is reached only if there are objects to delete.
+		if len(objs) > 0 && remoteClient == nil {
+			var err error
+			remoteClient, err = r.Tracker.GetClient(ctx, util.ObjectKey(cluster))
+			if err != nil {
+				conditions.MarkFalse(clusterResourceSet, addonsv1.ResourcesAppliedCondition, addonsv1.RemoteClusterClientFailedReason, clusterv1.ConditionSeverityError, err.Error())
+				return err
+			}
+		}
+
+		resourceBinding.LastDeletionTime = &metav1.Time{Time: time.Now().UTC()}
+		if err := deleteObjects(ctx, remoteClient, objs); err != nil {
+			resourceBinding.DeletionMessage = err.Error()
+			log.Error(err, "failed to delete ClusterResourceSet resource", "Resource kind", resourceBinding.Kind, "Resource name", resourceBinding.Name)
+			errList = append(errList, err)
+			continue
+		}
+
+		resourceBinding.Deleted = true
+		resourceBinding.DeletionMessage = ""
+	}
+	return kerrors.NewAggregate(errList)
+}
+
+// isAppliedByOtherClusterResourceSets returns true if the object was created by a resource of a ClusterResourceSet bound to the Cluster,
+// other than the given one, and the resource was not deleted since.
+func isAppliedByOtherClusterResourceSets(clusterResourceSetBinding *addonsv1.ClusterResourceSetBinding, clusterResourceSet *addonsv1.ClusterResourceSet, obj addonsv1.AppliedObject) bool {
+	for _, binding := range clusterResourceSetBinding.Spec.Bindings {
+		if binding.ClusterResourceSetName == clusterResourceSet.Name {
+			continue
+		}
+		for i := range binding.Resources {
+			if binding.Resources[i].HasObject(obj) {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // getClustersByClusterResourceSetSelector fetches Clusters matched by the ClusterResourceSet's label selector that are in the same namespace as the ClusterResourceSet object.
@@ -285,6 +433,12 @@ func (r *ClusterResourceSetReconciler) ApplyClusterResourceSet(ctx context.Conte
 			continue
 		}
 
+		// Objects created by previous attempts to apply the resource already exist, so they are carried over to be deleted in Strict mode.
+		var objects []addonsv1.AppliedObject
+		if previous := resourceSetBinding.GetResource(resource); previous != nil && !previous.Deleted {
+			objects = previous.Objects
+		}
+
 		// Set status in ClusterResourceSetBinding in case of early continue due to a failure.
 		// Set only when resource is retrieved successfully.
 		resourceSetBinding.SetBinding(addonsv1.ResourceBinding{
@@ -292,6 +446,7 @@ func (r *ClusterResourceSetReconciler) ApplyClusterResourceSet(ctx context.Conte
 			Hash:            "",
 			Applied:         false,
 			LastAppliedTime: &metav1.Time{Time: time.Now().UTC()},
+			Objects:         objects,
 		})
 
 		if err := r.patchOwnerRefToResource(ctx, clusterResourceSet, unstructuredObj); err != nil {
@@ -314,6 +469,8 @@ func (r *ClusterResourceSetReconciler) ApplyClusterResourceSet(ctx context.Conte
 		}
 		sort.Strings(keys)
 
+		// Resources failing to render or to apply are not marked as applied, so they are retried at the next reconcile.
+		isSuccessful := true
 		dataList := make([][]byte, 0)
 		for _, key := range keys {
 			val, ok, err := unstructured.NestedString(unstructuredData, key)
@@ -328,16 +485,28 @@ func (r *ClusterResourceSetReconciler) ApplyClusterResourceSet(ctx context.Conte
 				byteArr, _ = base64.StdEncoding.DecodeString(val)
 			}
 
+			if resource.Templated {
+				rendered, err := renderTemplatedData(byteArr, cluster)
+				if err != nil {
+					isSuccessful = false
+					conditions.MarkFalse(clusterResourceSet, addonsv1.ResourcesAppliedCondition, addonsv1.RenderingResourceFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+					errList = append(errList, errors.Wrapf(err, "failed to render %s %s", resource.Kind, resource.Name))
+					continue
+				}
+				byteArr = rendered
+			}
+
 			dataList = append(dataList, byteArr)
 		}
 
 		// Apply all values in the key-value pair of the resource to the cluster.
 		// As there can be multiple key-value pairs in a resource, each value may have multiple objects in it.
-		isSuccessful := true
 		for i := range dataList {
 			data := dataList[i]
 
-			if err := apply(ctx, remoteClient, data); err != nil {
+			created, err := apply(ctx, remoteClient, data)
+			objects = mergeAppliedObjects(objects, created)
+			if err != nil {
 				isSuccessful = false
 				log.Error(err, "failed to apply ClusterResourceSet resource", "Resource kind", resource.Kind, "Resource name", resource.Name)
 				conditions.MarkFalse(clusterResourceSet, addonsv1.ResourcesAppliedCondition, addonsv1.ApplyFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
@@ -350,6 +519,7 @@ func (r *ClusterResourceSetReconciler) ApplyClusterResourceSet(ctx context.Conte
 			Hash:            computeHash(dataList),
 			Applied:         isSuccessful,
 			LastAppliedTime: &metav1.Time{Time: time.Now().UTC()},
+			Objects:         objects,
 		})
 	}
 	if len(errList) > 0 {
@@ -451,9 +621,33 @@ func (r *ClusterResourceSetReconciler) clusterToClusterResourceSet(o client.Obje
 		name := client.ObjectKey{Namespace: rs.Namespace, Name: rs.Name}
 		result = append(result, ctrl.Request{NamespacedName: name})
 	}
+
+	// Add the ClusterResourceSets bound to the cluster, so resources are deleted by the ClusterResourceSets
+	// in Strict mode the cluster no longer matches.
+	binding := &addonsv1.ClusterResourceSetBinding{}
+	if err := r.Client.Get(context.TODO(), client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Name}, binding); err != nil {
+		return result
+	}
+	for _, resourceSetBinding := range binding.Spec.Bindings {
+		name := client.ObjectKey{Namespace: cluster.Namespace, Name: resourceSetBinding.ClusterResourceSetName}
+		request := ctrl.Request{NamespacedName: name}
+		if !containsRequest(result, request) {
+			result = append(result, request)
+		}
+	}
 	return result
 }
 
+// containsRequest returns true if the list of requests contains the given request.
+func containsRequest(requests []ctrl.Request, request ctrl.Request) bool {
+	for i := range requests {
+		if requests[i] == request {
+			return true
+		}
+	}
+	return false
+}
+
 // resourceToClusterResourceSet is mapper function that maps resources to ClusterResourceSet.
 func (r *ClusterResourceSetReconciler) resourceToClusterResourceSet(o client.Object) []ctrl.Request {
 	result := []ctrl.Request{}
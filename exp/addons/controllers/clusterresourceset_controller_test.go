@@ -18,6 +18,7 @@ package controllers
 
 import (
 	"fmt"
+	"testing"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -27,10 +28,16 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/controllers/remote"
 	addonsv1 "sigs.k8s.io/cluster-api/exp/addons/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 const (
@@ -442,3 +449,154 @@ metadata:
 		}, timeout).Should(BeTrue())
 	})
 })
+
+func TestReconcileDeleteInStrictMode(t *testing.T) {
+	shared := addonsv1.AppliedObject{APIVersion: "v1", Kind: "ConfigMap", Namespace: metav1.NamespaceSystem, Name: "shared"}
+	owned := addonsv1.AppliedObject{APIVersion: "v1", Kind: "ConfigMap", Namespace: metav1.NamespaceSystem, Name: "owned"}
+
+	tests := []struct {
+		name                    string
+		deleting                bool
+		controlPlaneInitialized bool
+		expectOwnedDeleted      bool
+	}{
+		{
+			name:                    "deletes the objects created only by the ClusterResourceSet",
+			controlPlaneInitialized: true,
+			expectOwnedDeleted:      true,
+		},
+		{
+			name:                    "skips Clusters being deleted",
+			deleting:                true,
+			controlPlaneInitialized: true,
+		},
+		{
+			name: "skips Clusters whose control plane is not initialized",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			scheme := runtime.NewScheme()
+			g.Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+			g.Expect(addonsv1.AddToScheme(scheme)).To(Succeed())
+			g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+
+			cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "cluster"}}
+			if tt.deleting {
+				cluster.Finalizers = []string{clusterv1.ClusterFinalizer}
+				cluster.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+			}
+			if tt.controlPlaneInitialized {
+				conditions.MarkTrue(cluster, clusterv1.ControlPlaneInitializedCondition)
+			}
+			crs := &addonsv1.ClusterResourceSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:         metav1.NamespaceDefault,
+					Name:              "crs",
+					Finalizers:        []string{addonsv1.ClusterResourceSetFinalizer},
+					DeletionTimestamp: &metav1.Time{Time: time.Now()},
+				},
+				Spec: addonsv1.ClusterResourceSetSpec{Mode: string(addonsv1.ClusterResourceSetModeStrict)},
+			}
+			binding := &addonsv1.ClusterResourceSetBinding{
+				ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "cluster"},
+				Spec: addonsv1.ClusterResourceSetBindingSpec{
+					Bindings: []*addonsv1.ResourceSetBinding{
+						{
+							ClusterResourceSetName: "crs",
+							Resources: []addonsv1.ResourceBinding{
+								{ResourceRef: addonsv1.ResourceRef{Kind: "ConfigMap", Name: "resource"}, Applied: true, Objects: []addonsv1.AppliedObject{shared, owned}},
+							},
+						},
+						{
+							ClusterResourceSetName: "other-crs",
+							Resources: []addonsv1.ResourceBinding{
+								{ResourceRef: addonsv1.ResourceRef{Kind: "ConfigMap", Name: "other-resource"}, Applied: true, Objects: []addonsv1.AppliedObject{shared}},
+							},
+						},
+					},
+				},
+			}
+			sharedConfigMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: shared.Namespace, Name: shared.Name}}
+			ownedConfigMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: owned.Namespace, Name: owned.Name}}
+
+			// The fake client is used both as the management and as the workload cluster client.
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, crs, binding, sharedConfigMap, ownedConfigMap).Build()
+			r := &ClusterResourceSetReconciler{
+				Client:  c,
+				Tracker: remote.NewTestClusterCacheTracker(log.NullLogger{}, c, scheme, client.ObjectKeyFromObject(cluster)),
+			}
+
+			_, err := r.reconcileDelete(ctx, crs)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(controllerutil.ContainsFinalizer(crs, addonsv1.ClusterResourceSetFinalizer)).To(BeFalse())
+
+			g.Expect(c.Get(ctx, client.ObjectKeyFromObject(sharedConfigMap), &corev1.ConfigMap{})).To(Succeed())
+			err = c.Get(ctx, client.ObjectKeyFromObject(ownedConfigMap), &corev1.ConfigMap{})
+			if tt.expectOwnedDeleted {
+				g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+
+			gotBinding := &addonsv1.ClusterResourceSetBinding{}
+			g.Expect(c.Get(ctx, client.ObjectKeyFromObject(binding), gotBinding)).To(Succeed())
+			g.Expect(gotBinding.GetBinding(crs)).To(BeNil())
+			g.Expect(gotBinding.Spec.Bindings).To(HaveLen(1))
+		})
+	}
+}
+
+func TestReconcileDeleteKeepsFinalizerIfCleanupFails(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(addonsv1.AddToScheme(scheme)).To(Succeed())
+
+	cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "cluster"}}
+	conditions.MarkTrue(cluster, clusterv1.ControlPlaneInitializedCondition)
+	crs := &addonsv1.ClusterResourceSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         metav1.NamespaceDefault,
+			Name:              "crs",
+			Finalizers:        []string{addonsv1.ClusterResourceSetFinalizer},
+			DeletionTimestamp: &metav1.Time{Time: time.Now()},
+		},
+		Spec: addonsv1.ClusterResourceSetSpec{Mode: string(addonsv1.ClusterResourceSetModeStrict)},
+	}
+	binding := &addonsv1.ClusterResourceSetBinding{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "cluster"},
+		Spec: addonsv1.ClusterResourceSetBindingSpec{
+			Bindings: []*addonsv1.ResourceSetBinding{
+				{
+					ClusterResourceSetName: "crs",
+					Resources: []addonsv1.ResourceBinding{
+						{
+							ResourceRef: addonsv1.ResourceRef{Kind: "ConfigMap", Name: "resource"},
+							Applied:     true,
+							Objects:     []addonsv1.AppliedObject{{APIVersion: "v1", Kind: "ConfigMap", Namespace: metav1.NamespaceSystem, Name: "owned"}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// The workload cluster cannot be reached, because the tracker has no client for it.
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, crs, binding).Build()
+	r := &ClusterResourceSetReconciler{
+		Client:  c,
+		Tracker: remote.NewTestClusterCacheTracker(log.NullLogger{}, c, scheme, client.ObjectKey{Namespace: metav1.NamespaceDefault, Name: "other-cluster"}),
+	}
+
+	_, err := r.reconcileDelete(ctx, crs)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(controllerutil.ContainsFinalizer(crs, addonsv1.ClusterResourceSetFinalizer)).To(BeTrue())
+
+	gotBinding := &addonsv1.ClusterResourceSetBinding{}
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(binding), gotBinding)).To(Succeed())
+	g.Expect(gotBinding.GetBinding(crs)).NotTo(BeNil())
+}
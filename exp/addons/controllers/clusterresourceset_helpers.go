@@ -23,6 +23,7 @@ import (
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"text/template"
 	"unicode"
 
 	"github.com/pkg/errors"
@@ -54,10 +55,11 @@ func isJSONList(data []byte) (bool, error) {
 	return bytes.HasPrefix(trim, jsonListPrefix), nil
 }
 
-func apply(ctx context.Context, c client.Client, data []byte) error {
+// toUnstructured converts the data of a resource, either a json list or json/yaml documents, to unstructured objects.
+func toUnstructured(data []byte) ([]unstructured.Unstructured, error) {
 	isJSONList, err := isJSONList(data)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	objs := []unstructured.Unstructured{}
 	// If it is a json list, convert each list element to an unstructured object.
@@ -75,36 +77,106 @@ func apply(ctx context.Context, c client.Client, data []byte) error {
 		// If it is not a json list, data is either json or yaml format.
 		objs, err = utilyaml.ToUnstructured(data)
 		if err != nil {
-			return errors.Wrapf(err, "failed converting data to unstructured objects")
+			return nil, errors.Wrapf(err, "failed converting data to unstructured objects")
 		}
 	}
+	return objs, nil
+}
+
+// apply creates the objects in the data of a resource in the cluster, and returns the objects it created, in creation order.
+// Objects that already exist are not updated, and they are not returned.
+func apply(ctx context.Context, c client.Client, data []byte) ([]addonsv1.AppliedObject, error) {
+	objs, err := toUnstructured(data)
+	if err != nil {
+		return nil, err
+	}
 
 	errList := []error{}
+	created := []addonsv1.AppliedObject{}
 	sortedObjs := utilresource.SortForCreate(objs)
 	for i := range sortedObjs {
-		if err := applyUnstructured(ctx, c, &objs[i]); err != nil {
+		obj := &sortedObjs[i]
+		ok, err := applyUnstructured(ctx, c, obj)
+		if err != nil {
 			errList = append(errList, err)
+			continue
+		}
+		if ok {
+			created = append(created, appliedObjectFor(obj))
 		}
 	}
-	return kerrors.NewAggregate(errList)
+	return created, kerrors.NewAggregate(errList)
 }
 
-func applyUnstructured(ctx context.Context, c client.Client, obj *unstructured.Unstructured) error {
+// applyUnstructured creates an object in the cluster, and returns false if the object already exists.
+func applyUnstructured(ctx context.Context, c client.Client, obj *unstructured.Unstructured) (bool, error) {
 	// Create the object on the API server.
 	// TODO: Errors are only logged. If needed, exponential backoff or requeuing could be used here for remedying connection glitches etc.
 	if err := c.Create(ctx, obj); err != nil {
 		// The create call is idempotent, so if the object already exists
 		// then do not consider it to be an error.
 		if !apierrors.IsAlreadyExists(err) {
-			return errors.Wrapf(
+			return false, errors.Wrapf(
 				err,
 				"failed to create object %s %s/%s",
 				obj.GroupVersionKind(),
 				obj.GetNamespace(),
 				obj.GetName())
 		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// appliedObjectFor returns the AppliedObject identifying obj.
+func appliedObjectFor(obj *unstructured.Unstructured) addonsv1.AppliedObject {
+	return addonsv1.AppliedObject{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+	}
+}
+
+// mergeAppliedObjects returns the objects in previous followed by the objects in created that are not in previous.
+// This preserves the objects created by previous attempts to apply a resource, which already exist at the next attempt.
+func mergeAppliedObjects(previous, created []addonsv1.AppliedObject) []addonsv1.AppliedObject {
+	merged := append([]addonsv1.AppliedObject{}, previous...)
+	for _, o := range created {
+		found := false
+		for _, p := range previous {
+			if o == p {
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, o)
+		}
+	}
+	return merged
+}
+
+// deleteObjects deletes objects from the cluster, in the reverse of the creation order.
+// Objects that do not exist are ignored, so deleteObjects can be called multiple times.
+func deleteObjects(ctx context.Context, c client.Client, objs []addonsv1.AppliedObject) error {
+	errList := []error{}
+	for i := len(objs) - 1; i >= 0; i-- {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(objs[i].APIVersion)
+		obj.SetKind(objs[i].Kind)
+		obj.SetNamespace(objs[i].Namespace)
+		obj.SetName(objs[i].Name)
+		if err := c.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+			errList = append(errList, errors.Wrapf(
+				err,
+				"failed to delete object %s %s/%s",
+				obj.GroupVersionKind(),
+				obj.GetNamespace(),
+				obj.GetName()))
+		}
 	}
-	return nil
+	return kerrors.NewAggregate(errList)
 }
 
 // getOrCreateClusterResourceSetBinding retrieves ClusterResourceSetBinding resource owned by the cluster or create a new one if not found.
@@ -183,3 +255,51 @@ func computeHash(dataArr [][]byte) string {
 	}
 	return fmt.Sprintf("sha256:%x", hash.Sum(nil))
 }
+
+// clusterTemplateData defines the details of a Cluster available to templated ClusterResourceSet resources.
+type clusterTemplateData struct {
+	ClusterName          string
+	ClusterNamespace     string
+	Labels               map[string]string
+	Annotations          map[string]string
+	PodCIDRs             []string
+	ServiceCIDRs         []string
+	ServiceDomain        string
+	ControlPlaneEndpoint clusterv1.APIEndpoint
+}
+
+func newClusterTemplateData(cluster *clusterv1.Cluster) clusterTemplateData {
+	data := clusterTemplateData{
+		ClusterName:          cluster.Name,
+		ClusterNamespace:     cluster.Namespace,
+		Labels:               cluster.Labels,
+		Annotations:          cluster.Annotations,
+		ControlPlaneEndpoint: cluster.Spec.ControlPlaneEndpoint,
+	}
+	if network := cluster.Spec.ClusterNetwork; network != nil {
+		if network.Pods != nil {
+			data.PodCIDRs = network.Pods.CIDRBlocks
+		}
+		if network.Services != nil {
+			data.ServiceCIDRs = network.Services.CIDRBlocks
+		}
+		data.ServiceDomain = network.ServiceDomain
+	}
+	return data
+}
+
+// renderTemplatedData renders the data of a templated resource with the details of a Cluster.
+// Rendering fails if the template refers to details not available, e.g. a missing label, so
+// resources are not applied with empty values.
+func renderTemplatedData(data []byte, cluster *clusterv1.Cluster) ([]byte, error) {
+	tpl, err := template.New("resource").Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse template")
+	}
+
+	var out bytes.Buffer
+	if err := tpl.Execute(&out, newClusterTemplateData(cluster)); err != nil {
+		return nil, errors.Wrapf(err, "failed to render template for cluster %s/%s", cluster.Namespace, cluster.Name)
+	}
+	return out.Bytes(), nil
+}
@@ -24,11 +24,13 @@ import (
 	. "github.com/onsi/gomega"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	addonsv1 "sigs.k8s.io/cluster-api/exp/addons/api/v1alpha4"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -227,3 +229,137 @@ func TestGetConfigMapFromNamespacedName(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderTemplatedData(t *testing.T) {
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-cluster",
+			Namespace: "default",
+			Labels:    map[string]string{"cni": "calico"},
+		},
+		Spec: clusterv1.ClusterSpec{
+			ClusterNetwork: &clusterv1.ClusterNetwork{
+				Pods:          &clusterv1.NetworkRanges{CIDRBlocks: []string{"192.168.0.0/16"}},
+				Services:      &clusterv1.NetworkRanges{CIDRBlocks: []string{"10.128.0.0/12"}},
+				ServiceDomain: "cluster.local",
+			},
+			ControlPlaneEndpoint: clusterv1.APIEndpoint{Host: "10.0.0.1", Port: 6443},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		cluster *clusterv1.Cluster
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "should render the cluster details",
+			data:    "name: {{ .ClusterName }}/{{ .ClusterNamespace }}, cni: {{ .Labels.cni }}, pods: {{ index .PodCIDRs 0 }}, services: {{ index .ServiceCIDRs 0 }}, domain: {{ .ServiceDomain }}, endpoint: {{ .ControlPlaneEndpoint.Host }}:{{ .ControlPlaneEndpoint.Port }}",
+			cluster: cluster,
+			want:    "name: my-cluster/default, cni: calico, pods: 192.168.0.0/16, services: 10.128.0.0/12, domain: cluster.local, endpoint: 10.0.0.1:6443",
+		},
+		{
+			name:    "should leave data without placeholders unchanged",
+			data:    "kind: ConfigMap",
+			cluster: cluster,
+			want:    "kind: ConfigMap",
+		},
+		{
+			name:    "should render a cluster without cluster network",
+			data:    "pods: {{ range .PodCIDRs }}{{ . }}{{ end }}",
+			cluster: &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster", Namespace: "default"}},
+			want:    "pods: ",
+		},
+		{
+			name:    "should fail when referencing a missing label",
+			data:    "{{ .Labels.missing }}",
+			cluster: cluster,
+			wantErr: true,
+		},
+		{
+			name:    "should fail when referencing an unknown field",
+			data:    "{{ .PodCIDR }}",
+			cluster: cluster,
+			wantErr: true,
+		},
+		{
+			name:    "should fail with an invalid template",
+			data:    "{{ .ClusterName ",
+			cluster: cluster,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := renderTemplatedData([]byte(tt.data), tt.cluster)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(string(got)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestApplyAndDeleteObjects(t *testing.T) {
+	g := NewWithT(t)
+
+	existing := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "calico-config",
+			Namespace: metav1.NamespaceSystem,
+		},
+	}
+	c := fake.NewClientBuilder().WithObjects(existing).Build()
+
+	data := []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: calico-config
+  namespace: kube-system
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: calico-node
+  namespace: kube-system
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: calico-system
+`)
+
+	// Only the objects which did not exist are reported as created, in creation order.
+	created, err := apply(ctx, c, data)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(created).To(Equal([]addonsv1.AppliedObject{
+		{APIVersion: "v1", Kind: "Namespace", Name: "calico-system"},
+		{APIVersion: "v1", Kind: "ServiceAccount", Namespace: metav1.NamespaceSystem, Name: "calico-node"},
+	}))
+
+	// Objects which are already deleted are ignored.
+	g.Expect(deleteObjects(ctx, c, created)).To(Succeed())
+	g.Expect(deleteObjects(ctx, c, created)).To(Succeed())
+
+	g.Expect(apierrors.IsNotFound(c.Get(ctx, client.ObjectKey{Name: "calico-system"}, &corev1.Namespace{}))).To(BeTrue())
+	g.Expect(apierrors.IsNotFound(c.Get(ctx, client.ObjectKey{Namespace: metav1.NamespaceSystem, Name: "calico-node"}, &corev1.ServiceAccount{}))).To(BeTrue())
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(existing), &corev1.ConfigMap{})).To(Succeed())
+}
+
+func TestMergeAppliedObjects(t *testing.T) {
+	g := NewWithT(t)
+
+	a := addonsv1.AppliedObject{APIVersion: "v1", Kind: "ConfigMap", Namespace: metav1.NamespaceSystem, Name: "a"}
+	b := addonsv1.AppliedObject{APIVersion: "v1", Kind: "ConfigMap", Namespace: metav1.NamespaceSystem, Name: "b"}
+	c := addonsv1.AppliedObject{APIVersion: "v1", Kind: "ConfigMap", Namespace: metav1.NamespaceSystem, Name: "c"}
+
+	g.Expect(mergeAppliedObjects(nil, []addonsv1.AppliedObject{a})).To(Equal([]addonsv1.AppliedObject{a}))
+	g.Expect(mergeAppliedObjects([]addonsv1.AppliedObject{a, b}, []addonsv1.AppliedObject{b, c})).To(Equal([]addonsv1.AppliedObject{a, b, c}))
+}
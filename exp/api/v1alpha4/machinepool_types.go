@@ -112,6 +112,12 @@ type MachinePoolStatus struct {
 	// +optional
 	InfrastructureReady bool `json:"infrastructureReady"`
 
+	// InfrastructureMachineKind is the kind of the infrastructure resources backing the instances of this MachinePool,
+	// e.g. the kind of the virtual machines in a scale set, as reported by the infrastructure provider.
+	// It is used by the cluster-autoscaler for building the node templates when scaling from zero.
+	// +optional
+	InfrastructureMachineKind string `json:"infrastructureMachineKind,omitempty"`
+
 	// ObservedGeneration is the latest generation observed by the controller.
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
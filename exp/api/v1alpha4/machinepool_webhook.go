@@ -18,6 +18,7 @@ package v1alpha4
 
 import (
 	"fmt"
+	"strconv"
 
 	"k8s.io/utils/pointer"
 
@@ -118,6 +119,8 @@ func (m *MachinePool) validate(old *MachinePool) error {
 		)
 	}
 
+	allErrs = append(allErrs, m.validateAutoscalerAnnotations()...)
+
 	if old != nil && old.Spec.ClusterName != m.Spec.ClusterName {
 		allErrs = append(
 			allErrs,
@@ -130,3 +133,31 @@ func (m *MachinePool) validate(old *MachinePool) error {
 	}
 	return apierrors.NewInvalid(GroupVersion.WithKind("MachinePool").GroupKind(), m.Name, allErrs)
 }
+
+// validateAutoscalerAnnotations validates the cluster-autoscaler min and max size annotations, if set.
+func (m *MachinePool) validateAutoscalerAnnotations() field.ErrorList {
+	var allErrs field.ErrorList
+	annotationsPath := field.NewPath("metadata", "annotations")
+
+	sizes := map[string]int{}
+	for _, annotation := range []string{clusterv1.AutoscalerMinSizeAnnotation, clusterv1.AutoscalerMaxSizeAnnotation} {
+		value, ok := m.Annotations[annotation]
+		if !ok {
+			continue
+		}
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 0 {
+			allErrs = append(allErrs, field.Invalid(annotationsPath.Key(annotation), value, "must be a non-negative integer"))
+			continue
+		}
+		sizes[annotation] = size
+	}
+
+	minSize, hasMin := sizes[clusterv1.AutoscalerMinSizeAnnotation]
+	maxSize, hasMax := sizes[clusterv1.AutoscalerMaxSizeAnnotation]
+	if hasMin && hasMax && minSize > maxSize {
+		allErrs = append(allErrs, field.Invalid(annotationsPath.Key(clusterv1.AutoscalerMinSizeAnnotation), m.Annotations[clusterv1.AutoscalerMinSizeAnnotation],
+			fmt.Sprintf("must be less than or equal to %s", clusterv1.AutoscalerMaxSizeAnnotation)))
+	}
+	return allErrs
+}
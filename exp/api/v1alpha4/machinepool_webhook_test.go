@@ -219,3 +219,79 @@ func TestMachinePoolClusterNameImmutable(t *testing.T) {
 		})
 	}
 }
+
+func TestMachinePoolAutoscalerAnnotationsValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expectErr   bool
+	}{
+		{
+			name:        "should not return error without annotations",
+			annotations: nil,
+			expectErr:   false,
+		},
+		{
+			name: "should not return error if min size is less than max size",
+			annotations: map[string]string{
+				clusterv1.AutoscalerMinSizeAnnotation: "0",
+				clusterv1.AutoscalerMaxSizeAnnotation: "5",
+			},
+			expectErr: false,
+		},
+		{
+			name: "should not return error if only max size is set",
+			annotations: map[string]string{
+				clusterv1.AutoscalerMaxSizeAnnotation: "5",
+			},
+			expectErr: false,
+		},
+		{
+			name: "should return error if min size is not an integer",
+			annotations: map[string]string{
+				clusterv1.AutoscalerMinSizeAnnotation: "one",
+			},
+			expectErr: true,
+		},
+		{
+			name: "should return error if max size is negative",
+			annotations: map[string]string{
+				clusterv1.AutoscalerMaxSizeAnnotation: "-1",
+			},
+			expectErr: true,
+		},
+		{
+			name: "should return error if min size is greater than max size",
+			annotations: map[string]string{
+				clusterv1.AutoscalerMinSizeAnnotation: "3",
+				clusterv1.AutoscalerMaxSizeAnnotation: "2",
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			m := &MachinePool{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: tt.annotations,
+				},
+				Spec: MachinePoolSpec{
+					Template: clusterv1.MachineTemplateSpec{
+						Spec: clusterv1.MachineSpec{
+							Bootstrap: clusterv1.Bootstrap{DataSecretName: pointer.StringPtr("test")},
+						},
+					},
+				},
+			}
+			if tt.expectErr {
+				g.Expect(m.ValidateCreate()).NotTo(Succeed())
+				g.Expect(m.ValidateUpdate(m)).NotTo(Succeed())
+			} else {
+				g.Expect(m.ValidateCreate()).To(Succeed())
+				g.Expect(m.ValidateUpdate(m)).To(Succeed())
+			}
+		})
+	}
+}
@@ -261,6 +261,11 @@ func (r *MachinePoolReconciler) reconcileInfrastructure(ctx context.Context, clu
 
 	mp.Status.InfrastructureReady = ready
 
+	// Get and set Status.InfrastructureMachineKind from the infrastructure provider, if reported.
+	if err := util.UnstructuredUnmarshalField(infraConfig, &mp.Status.InfrastructureMachineKind, "status", "infrastructureMachineKind"); err != nil && err != util.ErrUnstructuredFieldNotFound {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to retrieve infrastructureMachineKind from infrastructure provider for MachinePool %q in namespace %q", mp.Name, mp.Namespace)
+	}
+
 	// Report a summary of current status of the infrastructure object defined for this machine pool.
 	conditions.SetMirror(mp, clusterv1.InfrastructureReadyCondition,
 		conditions.UnstructuredGetter(infraConfig),
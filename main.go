@@ -36,11 +36,13 @@ import (
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/controllers"
 	"sigs.k8s.io/cluster-api/controllers/remote"
+	"sigs.k8s.io/cluster-api/controllers/topology"
 	addonsv1 "sigs.k8s.io/cluster-api/exp/addons/api/v1alpha4"
 	addonscontrollers "sigs.k8s.io/cluster-api/exp/addons/controllers"
 	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha4"
 	expcontrollers "sigs.k8s.io/cluster-api/exp/controllers"
 	"sigs.k8s.io/cluster-api/feature"
+	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/version"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -54,25 +56,30 @@ var (
 	setupLog = ctrl.Log.WithName("setup")
 
 	// flags.
-	metricsBindAddr               string
-	enableLeaderElection          bool
-	leaderElectionLeaseDuration   time.Duration
-	leaderElectionRenewDeadline   time.Duration
-	leaderElectionRetryPeriod     time.Duration
-	watchNamespace                string
-	watchFilterValue              string
-	profilerAddress               string
-	clusterConcurrency            int
-	machineConcurrency            int
-	machineSetConcurrency         int
-	machineDeploymentConcurrency  int
-	machinePoolConcurrency        int
-	clusterResourceSetConcurrency int
-	machineHealthCheckConcurrency int
-	syncPeriod                    time.Duration
-	webhookPort                   int
-	webhookCertDir                string
-	healthAddr                    string
+	metricsBindAddr                 string
+	enableLeaderElection            bool
+	leaderElectionLeaseDuration     time.Duration
+	leaderElectionRenewDeadline     time.Duration
+	leaderElectionRetryPeriod       time.Duration
+	watchNamespace                  string
+	watchFilterValue                string
+	profilerAddress                 string
+	clusterConcurrency              int
+	orphanedInfrastructurePolicy    string
+	clusterTopologyConcurrency      int
+	machineConcurrency              int
+	machineSetConcurrency           int
+	machineSetCreationLimit         int
+	machineSetCreationLimitInterval time.Duration
+	machineSetResourceQuotaAware    bool
+	machineDeploymentConcurrency    int
+	machinePoolConcurrency          int
+	clusterResourceSetConcurrency   int
+	machineHealthCheckConcurrency   int
+	syncPeriod                      time.Duration
+	webhookPort                     int
+	webhookCertDir                  string
+	healthAddr                      string
 )
 
 func init() {
@@ -115,12 +122,27 @@ func InitFlags(fs *pflag.FlagSet) {
 	fs.IntVar(&clusterConcurrency, "cluster-concurrency", 10,
 		"Number of clusters to process simultaneously")
 
+	fs.StringVar(&orphanedInfrastructurePolicy, "orphaned-infrastructure-policy", string(controllers.ReportOrphanedInfrastructurePolicy),
+		"How infrastructure machines not owned by any Machine are handled: Report, Adopt (by the Machine referencing them, if any) or Delete (adopt if referenced by a Machine, delete otherwise).")
+
+	fs.IntVar(&clusterTopologyConcurrency, "clustertopology-concurrency", 10,
+		"Number of clusters to process simultaneously")
+
 	fs.IntVar(&machineConcurrency, "machine-concurrency", 10,
 		"Number of machines to process simultaneously")
 
 	fs.IntVar(&machineSetConcurrency, "machineset-concurrency", 10,
 		"Number of machine sets to process simultaneously")
 
+	fs.IntVar(&machineSetCreationLimit, "machineset-creation-limit", 0,
+		"Maximum number of machines a machine set creates within machineset-creation-limit-interval. If 0, machine creation is not limited.")
+
+	fs.DurationVar(&machineSetCreationLimitInterval, "machineset-creation-limit-interval", time.Minute,
+		"Interval the machineset-creation-limit applies to (e.g. 1m)")
+
+	fs.BoolVar(&machineSetResourceQuotaAware, "machineset-resource-quota-aware", false,
+		"If true, machine sets create only the machines allowed by the ResourceQuotas of their namespace, and wait for quota to be available before creating the others.")
+
 	fs.IntVar(&machineDeploymentConcurrency, "machinedeployment-concurrency", 10,
 		"Number of machine deployments to process simultaneously")
 
@@ -216,6 +238,13 @@ func setupChecks(mgr ctrl.Manager) {
 }
 
 func setupReconcilers(ctx context.Context, mgr ctrl.Manager) {
+	// Add the index for listing the MachineDeployments, MachineSets and Machines of a Cluster by cluster name,
+	// shared by the controllers.
+	if err := util.AddClusterNameIndex(ctx, mgr.GetFieldIndexer(), &clusterv1.MachineDeployment{}, &clusterv1.MachineSet{}, &clusterv1.Machine{}); err != nil {
+		setupLog.Error(err, "unable to add the cluster name index")
+		os.Exit(1)
+	}
+
 	// Set up a ClusterCacheTracker and ClusterCacheReconciler to provide to controllers
 	// requiring a connection to a remote cluster
 	tracker, err := remote.NewClusterCacheTracker(
@@ -236,8 +265,9 @@ func setupReconcilers(ctx context.Context, mgr ctrl.Manager) {
 	}
 
 	if err := (&controllers.ClusterReconciler{
-		Client:           mgr.GetClient(),
-		WatchFilterValue: watchFilterValue,
+		Client:                       mgr.GetClient(),
+		WatchFilterValue:             watchFilterValue,
+		OrphanedInfrastructurePolicy: controllers.OrphanedInfrastructurePolicy(orphanedInfrastructurePolicy),
 	}).SetupWithManager(ctx, mgr, concurrency(clusterConcurrency)); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Cluster")
 		os.Exit(1)
@@ -251,9 +281,12 @@ func setupReconcilers(ctx context.Context, mgr ctrl.Manager) {
 		os.Exit(1)
 	}
 	if err := (&controllers.MachineSetReconciler{
-		Client:           mgr.GetClient(),
-		Tracker:          tracker,
-		WatchFilterValue: watchFilterValue,
+		Client:                       mgr.GetClient(),
+		Tracker:                      tracker,
+		WatchFilterValue:             watchFilterValue,
+		MachineCreationLimit:         machineSetCreationLimit,
+		MachineCreationLimitInterval: machineSetCreationLimitInterval,
+		ResourceQuotaAware:           machineSetResourceQuotaAware,
 	}).SetupWithManager(ctx, mgr, concurrency(machineSetConcurrency)); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "MachineSet")
 		os.Exit(1)
@@ -266,6 +299,16 @@ func setupReconcilers(ctx context.Context, mgr ctrl.Manager) {
 		os.Exit(1)
 	}
 
+	if feature.Gates.Enabled(feature.ClusterTopology) {
+		if err := (&topology.ClusterReconciler{
+			Client:           mgr.GetClient(),
+			WatchFilterValue: watchFilterValue,
+		}).SetupWithManager(ctx, mgr, concurrency(clusterTopologyConcurrency)); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "ClusterTopology")
+			os.Exit(1)
+		}
+	}
+
 	if feature.Gates.Enabled(feature.MachinePool) {
 		if err := (&expcontrollers.MachinePoolReconciler{
 			Client:           mgr.GetClient(),
@@ -310,6 +353,13 @@ func setupWebhooks(mgr ctrl.Manager) {
 		os.Exit(1)
 	}
 
+	// NOTE: ClusterClass and managed topologies are behind ClusterTopology feature gate flag; the webhook
+	// is going to prevent creating or updating new objects in case the feature flag is disabled
+	if err := (&clusterv1.ClusterClass{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "ClusterClass")
+		os.Exit(1)
+	}
+
 	if err := (&clusterv1.Machine{}).SetupWebhookWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "Machine")
 		os.Exit(1)
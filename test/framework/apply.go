@@ -0,0 +1,104 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/cluster-api/test/framework/internal/log"
+	utilyaml "sigs.k8s.io/cluster-api/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// nativeApplyFieldOwner is the field manager used when applying resources without kubectl.
+const nativeApplyFieldOwner = "cluster-api-e2e"
+
+// nativeApply applies YAML to a Kubernetes cluster using server-side apply, as an alternative to `kubectl apply`
+// when kubectl is not installed. Namespaced objects without a namespace are applied to the default namespace.
+// Among the `kubectl apply` arguments, only --selector (-l) is supported.
+func nativeApply(ctx context.Context, c client.Client, resources []byte, args ...string) error {
+	selector, err := parseNativeApplyArgs(args)
+	if err != nil {
+		return err
+	}
+
+	objs, err := utilyaml.ToUnstructured(resources)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the resources to apply")
+	}
+
+	log.Logf("kubectl not found in PATH, applying resources using server-side apply")
+	for i := range objs {
+		obj := &objs[i]
+		if !selector.Matches(labels.Set(obj.GetLabels())) {
+			continue
+		}
+
+		gvk := obj.GroupVersionKind()
+		if obj.GetNamespace() == "" {
+			mapping, err := c.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+			if err != nil {
+				return errors.Wrapf(err, "failed to get the REST mapping for %s", gvk)
+			}
+			if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+				obj.SetNamespace(metav1.NamespaceDefault)
+			}
+		}
+
+		if err := c.Patch(ctx, obj, client.Apply, client.ForceOwnership, client.FieldOwner(nativeApplyFieldOwner)); err != nil {
+			return errors.Wrapf(err, "failed to apply %s %s", gvk.Kind, client.ObjectKeyFromObject(obj))
+		}
+		log.Logf("%s/%s serverside-applied", strings.ToLower(gvk.Kind), obj.GetName())
+	}
+	return nil
+}
+
+// parseNativeApplyArgs returns the label selector defined by the `kubectl apply` arguments, if any; it fails
+// for arguments not supported by nativeApply.
+func parseNativeApplyArgs(args []string) (labels.Selector, error) {
+	selector := labels.Everything()
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		var value string
+		switch {
+		case arg == "--selector" || arg == "-l":
+			if i+1 >= len(args) {
+				return nil, errors.Errorf("missing value for the %s argument", arg)
+			}
+			i++
+			value = args[i]
+		case strings.HasPrefix(arg, "--selector="):
+			value = strings.TrimPrefix(arg, "--selector=")
+		case strings.HasPrefix(arg, "-l="):
+			value = strings.TrimPrefix(arg, "-l=")
+		default:
+			return nil, errors.Errorf("the %q argument is not supported when applying resources without kubectl", arg)
+		}
+
+		var err error
+		selector, err = labels.Parse(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid selector %q", value)
+		}
+	}
+	return selector, nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestParseNativeApplyArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		matches   labels.Set
+		noMatches labels.Set
+		wantErr   bool
+	}{
+		{
+			name:    "no arguments selects everything",
+			matches: labels.Set{},
+		},
+		{
+			name:      "--selector",
+			args:      []string{"--selector", "kcp-adoption.step1"},
+			matches:   labels.Set{"kcp-adoption.step1": ""},
+			noMatches: labels.Set{"kcp-adoption.step2": ""},
+		},
+		{
+			name:      "-l=",
+			args:      []string{"-l=app=foo"},
+			matches:   labels.Set{"app": "foo"},
+			noMatches: labels.Set{"app": "bar"},
+		},
+		{
+			name:    "missing selector value",
+			args:    []string{"--selector"},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported argument",
+			args:    []string{"--prune"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			selector, err := parseNativeApplyArgs(tt.args)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(selector.Matches(tt.matches)).To(BeTrue())
+			if tt.noMatches != nil {
+				g.Expect(selector.Matches(tt.noMatches)).To(BeFalse())
+			}
+		})
+	}
+}
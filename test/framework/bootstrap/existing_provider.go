@@ -0,0 +1,56 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"context"
+
+	. "github.com/onsi/gomega"
+)
+
+// NewExistingClusterProvider returns a ClusterProvider for an existing Kubernetes cluster, e.g. a cluster
+// created outside of the test; the cluster is neither created nor deleted.
+// If kubeconfigPath is empty, default discovery rules apply.
+func NewExistingClusterProvider(kubeconfigPath string) ClusterProvider {
+	return &existingClusterProvider{
+		kubeconfigPath: kubeconfigPath,
+	}
+}
+
+// existingClusterProvider implements a ClusterProvider for an existing Kubernetes cluster.
+type existingClusterProvider struct {
+	kubeconfigPath string
+}
+
+// Create checks the kubeconfig file for the existing cluster, if any.
+func (e *existingClusterProvider) Create(ctx context.Context) {
+	Expect(ctx).NotTo(BeNil(), "ctx is required for Create")
+
+	if e.kubeconfigPath != "" {
+		Expect(e.kubeconfigPath).To(BeAnExistingFile(), "The kubeconfig file for the existing cluster does not exists at %q", e.kubeconfigPath)
+	}
+}
+
+// GetKubeconfigPath returns the path to the kubeconfig file for the cluster.
+func (e *existingClusterProvider) GetKubeconfigPath() string {
+	return e.kubeconfigPath
+}
+
+// Dispose is a no-op, because existing clusters are not managed by the test.
+func (e *existingClusterProvider) Dispose(ctx context.Context) {
+	Expect(ctx).NotTo(BeNil(), "ctx is required for Dispose")
+}
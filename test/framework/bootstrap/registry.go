@@ -0,0 +1,100 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"sort"
+	"sync"
+
+	. "github.com/onsi/gomega"
+)
+
+const (
+	// KindClusterProviderType is the type of the ClusterProvider creating a kind cluster.
+	KindClusterProviderType = "kind"
+
+	// ExistingClusterProviderType is the type of the ClusterProvider using an existing cluster.
+	ExistingClusterProviderType = "existing"
+)
+
+// NewClusterProviderInput is the input for NewClusterProvider.
+type NewClusterProviderInput struct {
+	// Name of the cluster.
+	Name string
+
+	// KubeconfigPath is the path to the kubeconfig file of an existing cluster.
+	KubeconfigPath string
+
+	// RequiresDockerSock defines if the cluster requires the docker sock.
+	RequiresDockerSock bool
+}
+
+// ClusterProviderFactory returns a ClusterProvider for the given input.
+type ClusterProviderFactory func(input NewClusterProviderInput) ClusterProvider
+
+var (
+	clusterProviderFactoriesLock sync.RWMutex
+	clusterProviderFactories     = map[string]ClusterProviderFactory{
+		KindClusterProviderType: func(input NewClusterProviderInput) ClusterProvider {
+			options := []KindClusterOption{}
+			if input.RequiresDockerSock {
+				options = append(options, WithDockerSockMount())
+			}
+			return NewKindClusterProvider(input.Name, options...)
+		},
+		ExistingClusterProviderType: func(input NewClusterProviderInput) ClusterProvider {
+			return NewExistingClusterProvider(input.KubeconfigPath)
+		},
+	}
+)
+
+// RegisterClusterProvider registers a factory for a type of ClusterProvider, so tools built on top of the
+// test framework can add other mechanisms for provisioning clusters, e.g. k3d or vcluster.
+// Registering a factory for an already registered type replaces the existing factory.
+func RegisterClusterProvider(providerType string, factory ClusterProviderFactory) {
+	Expect(providerType).ToNot(BeEmpty(), "providerType is required for RegisterClusterProvider")
+	Expect(factory).ToNot(BeNil(), "factory is required for RegisterClusterProvider")
+
+	clusterProviderFactoriesLock.Lock()
+	defer clusterProviderFactoriesLock.Unlock()
+	clusterProviderFactories[providerType] = factory
+}
+
+// RegisteredClusterProviders returns the sorted list of the registered types of ClusterProvider.
+func RegisteredClusterProviders() []string {
+	clusterProviderFactoriesLock.RLock()
+	defer clusterProviderFactoriesLock.RUnlock()
+
+	providerTypes := make([]string, 0, len(clusterProviderFactories))
+	for providerType := range clusterProviderFactories {
+		providerTypes = append(providerTypes, providerType)
+	}
+	sort.Strings(providerTypes)
+	return providerTypes
+}
+
+// NewClusterProvider returns a ClusterProvider of the given type.
+func NewClusterProvider(providerType string, input NewClusterProviderInput) ClusterProvider {
+	clusterProviderFactoriesLock.RLock()
+	factory, ok := clusterProviderFactories[providerType]
+	clusterProviderFactoriesLock.RUnlock()
+	Expect(ok).To(BeTrue(), "Unknown cluster provider type %q, supported types are %v", providerType, RegisteredClusterProviders())
+
+	clusterProvider := factory(input)
+	Expect(clusterProvider).ToNot(BeNil(), "Failed to get a cluster provider of type %q", providerType)
+	return clusterProvider
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+type fakeClusterProvider struct {
+	name string
+}
+
+func (f *fakeClusterProvider) Create(context.Context)      {}
+func (f *fakeClusterProvider) GetKubeconfigPath() string   { return f.name }
+func (f *fakeClusterProvider) Dispose(ctx context.Context) {}
+
+func TestNewClusterProvider(t *testing.T) {
+	RegisterTestingT(t)
+	g := NewWithT(t)
+
+	existing := NewClusterProvider(ExistingClusterProviderType, NewClusterProviderInput{KubeconfigPath: "kubeconfig"})
+	g.Expect(existing.GetKubeconfigPath()).To(Equal("kubeconfig"))
+
+	RegisterClusterProvider("fake", func(input NewClusterProviderInput) ClusterProvider {
+		return &fakeClusterProvider{name: input.Name}
+	})
+	g.Expect(RegisteredClusterProviders()).To(Equal([]string{ExistingClusterProviderType, "fake", KindClusterProviderType}))
+
+	fake := NewClusterProvider("fake", NewClusterProviderInput{Name: "foo"})
+	g.Expect(fake).To(Equal(&fakeClusterProvider{name: "foo"}))
+}
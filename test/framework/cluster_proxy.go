@@ -63,6 +63,7 @@ type ClusterProxy interface {
 	GetRESTConfig() *rest.Config
 
 	// Apply to apply YAML to the Kubernetes cluster, `kubectl apply`.
+	// If kubectl is not installed, YAML is applied using server-side apply, unless disabled with WithoutNativeApply.
 	Apply(ctx context.Context, resources []byte, args ...string) error
 
 	// GetWorkloadCluster returns a proxy to a workload cluster defined in the Kubernetes cluster.
@@ -93,6 +94,14 @@ func WithMachineLogCollector(logCollector ClusterLogCollector) Option {
 	}
 }
 
+// WithoutNativeApply disables the fallback to server-side apply when kubectl is not installed;
+// in this case Apply returns exec.ErrKubectlNotFound.
+func WithoutNativeApply() Option {
+	return func(c *clusterProxy) {
+		c.disableNativeApply = true
+	}
+}
+
 // clusterProxy provides a base implementation of the ClusterProxy interface.
 type clusterProxy struct {
 	name                    string
@@ -100,6 +109,8 @@ type clusterProxy struct {
 	scheme                  *runtime.Scheme
 	shouldCleanupKubeconfig bool
 	logCollector            ClusterLogCollector
+	kubectlAvailable        bool
+	disableNativeApply      bool
 }
 
 // NewClusterProxy returns a clusterProxy given a KubeconfigPath and the scheme defining the types hosted in the cluster.
@@ -116,6 +127,7 @@ func NewClusterProxy(name string, kubeconfigPath string, scheme *runtime.Scheme,
 		kubeconfigPath:          kubeconfigPath,
 		scheme:                  scheme,
 		shouldCleanupKubeconfig: false,
+		kubectlAvailable:        exec.LookPathKubectl() == nil,
 	}
 
 	for _, o := range options {
@@ -126,7 +138,7 @@ func NewClusterProxy(name string, kubeconfigPath string, scheme *runtime.Scheme,
 }
 
 // newFromAPIConfig returns a clusterProxy given a api.Config and the scheme defining the types hosted in the cluster.
-func newFromAPIConfig(name string, config *api.Config, scheme *runtime.Scheme) ClusterProxy {
+func newFromAPIConfig(name string, config *api.Config, scheme *runtime.Scheme) *clusterProxy {
 	// NB. the ClusterProvider is responsible for the cleanup of this file
 	f, err := os.CreateTemp("", "e2e-kubeconfig")
 	Expect(err).ToNot(HaveOccurred(), "Failed to create kubeconfig file for the kind cluster %q")
@@ -140,6 +152,7 @@ func newFromAPIConfig(name string, config *api.Config, scheme *runtime.Scheme) C
 		kubeconfigPath:          kubeconfigPath,
 		scheme:                  scheme,
 		shouldCleanupKubeconfig: true,
+		kubectlAvailable:        exec.LookPathKubectl() == nil,
 	}
 }
 
@@ -179,11 +192,18 @@ func (p *clusterProxy) GetClientSet() *kubernetes.Clientset {
 }
 
 // Apply wraps `kubectl apply ...` and prints the output so we can see what gets applied to the cluster.
+// If kubectl is not installed, resources are applied using server-side apply, unless the fallback is disabled.
 func (p *clusterProxy) Apply(ctx context.Context, resources []byte, args ...string) error {
 	Expect(ctx).NotTo(BeNil(), "ctx is required for Apply")
 	Expect(resources).NotTo(BeNil(), "resources is required for Apply")
 
-	return exec.KubectlApply(ctx, p.kubeconfigPath, resources, args...)
+	if p.kubectlAvailable {
+		return exec.KubectlApply(ctx, p.kubeconfigPath, resources, args...)
+	}
+	if p.disableNativeApply {
+		return exec.ErrKubectlNotFound
+	}
+	return nativeApply(ctx, p.GetClient(), resources, args...)
 }
 
 func (p *clusterProxy) GetRESTConfig() *rest.Config {
@@ -212,7 +232,9 @@ func (p *clusterProxy) GetWorkloadCluster(ctx context.Context, namespace, name s
 		p.fixConfig(ctx, name, config)
 	}
 
-	return newFromAPIConfig(name, config, p.scheme)
+	workloadCluster := newFromAPIConfig(name, config, p.scheme)
+	workloadCluster.disableNativeApply = p.disableNativeApply
+	return workloadCluster
 }
 
 // CollectWorkloadClusterLogs collects machines logs from the workload cluster.
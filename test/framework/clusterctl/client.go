@@ -82,6 +82,30 @@ func Init(ctx context.Context, input InitInput) {
 	Expect(err).ToNot(HaveOccurred(), "failed to run clusterctl init")
 }
 
+// WaitForProviderComponentsReadyInput is the input for WaitForProviderComponentsReady.
+type WaitForProviderComponentsReadyInput struct {
+	LogFolder            string
+	ClusterctlConfigPath string
+	KubeconfigPath       string
+	Namespace            string
+}
+
+// WaitForProviderComponentsReady waits for the Deployments of the providers installed in a namespace to be available,
+// and for their webhooks to be serving, so Cluster API objects can be created right after.
+func WaitForProviderComponentsReady(ctx context.Context, input WaitForProviderComponentsReadyInput, intervals ...interface{}) {
+	log.Logf("Waiting for the provider components in namespace %s to be ready", input.Namespace)
+
+	clusterctlClient, log := getClusterctlClientWithLogger(input.ClusterctlConfigPath, "clusterctl-wait-providers.log", input.LogFolder)
+	defer log.Close()
+
+	Eventually(func() error {
+		return clusterctlClient.WaitForProviderComponentsReady(clusterctlclient.WaitForProviderComponentsReadyOptions{
+			Kubeconfig: clusterctlclient.Kubeconfig{Path: input.KubeconfigPath, Context: ""},
+			Namespace:  input.Namespace,
+		})
+	}, intervals...).Should(Succeed(), "Provider components in namespace %s are not ready", input.Namespace)
+}
+
 // ConfigClusterInput is the input for ConfigCluster.
 type ConfigClusterInput struct {
 	LogFolder                string
@@ -174,7 +198,7 @@ func getClusterctlClientWithLogger(configPath, logName, logFolder string) (clust
 	})
 	clusterctllog.SetLogger(log.Logger())
 
-	c, err := clusterctlclient.New(configPath)
+	c, err := clusterctlclient.New(configPath, clusterctlclient.InjectLogger(log.Logger()))
 	Expect(err).ToNot(HaveOccurred(), "Failed to create the clusterctl client library")
 	return c, log
 }
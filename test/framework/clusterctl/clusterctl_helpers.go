@@ -22,6 +22,7 @@ import (
 	"path/filepath"
 
 	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
@@ -54,7 +55,8 @@ func InitManagementClusterAndWatchControllerLogs(ctx context.Context, input Init
 	controllersDeployments := framework.GetControllerDeployments(ctx, framework.GetControllerDeploymentsInput{
 		Lister: client,
 	})
-	if len(controllersDeployments) == 0 {
+	initialized := len(controllersDeployments) == 0
+	if initialized {
 		Init(ctx, InitInput{
 			// pass reference to the management cluster hosting this test
 			KubeconfigPath: input.ClusterProxy.GetKubeconfigPath(),
@@ -75,6 +77,23 @@ func InitManagementClusterAndWatchControllerLogs(ctx context.Context, input Init
 		Lister: client,
 	})
 	Expect(controllersDeployments).ToNot(BeEmpty(), "The list of controller deployments should not be empty")
+
+	// Waits for the webhooks of the providers installed by this test to be serving too, because otherwise the
+	// creation of the first Cluster API objects could fail while the webhook certificates are not trusted yet.
+	if initialized {
+		namespaces := sets.NewString()
+		for _, deployment := range controllersDeployments {
+			namespaces.Insert(deployment.Namespace)
+		}
+		for _, namespace := range namespaces.List() {
+			WaitForProviderComponentsReady(ctx, WaitForProviderComponentsReadyInput{
+				LogFolder:            input.LogFolder,
+				ClusterctlConfigPath: input.ClusterctlConfigPath,
+				KubeconfigPath:       input.ClusterProxy.GetKubeconfigPath(),
+				Namespace:            namespace,
+			}, intervals...)
+		}
+	}
 	for _, deployment := range controllersDeployments {
 		framework.WaitForDeploymentsAvailable(ctx, framework.WaitForDeploymentsAvailableInput{
 			Getter:     client,
@@ -110,7 +129,10 @@ type ApplyClusterTemplateAndWaitInput struct {
 	WaitForControlPlaneIntervals []interface{}
 	WaitForMachineDeployments    []interface{}
 	WaitForMachinePools          []interface{}
-	Args                         []string // extra args to be used during `kubectl apply`
+	// WaitForWorkloadClusterReady, if set, are the intervals used to wait for the workload cluster to be ready
+	// (API server, nodes and CoreDNS) before returning; see framework.WaitForWorkloadClusterReady.
+	WaitForWorkloadClusterReady []interface{}
+	Args                        []string // extra args to be used during `kubectl apply`
 }
 
 type ApplyClusterTemplateAndWaitResult struct {
@@ -197,4 +219,11 @@ func ApplyClusterTemplateAndWait(ctx context.Context, input ApplyClusterTemplate
 		Lister:  input.ClusterProxy.GetClient(),
 		Cluster: result.Cluster,
 	}, input.WaitForMachineDeployments...)
+
+	if input.WaitForWorkloadClusterReady != nil {
+		log.Logf("Waiting for the workload cluster to be ready")
+		framework.WaitForWorkloadClusterReady(ctx, framework.WaitForWorkloadClusterReadyInput{
+			WorkloadClusterProxy: input.ClusterProxy.GetWorkloadCluster(ctx, result.Cluster.Namespace, result.Cluster.Name),
+		}, input.WaitForWorkloadClusterReady...)
+	}
 }
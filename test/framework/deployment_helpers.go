@@ -63,12 +63,7 @@ func WaitForDeploymentsAvailable(ctx context.Context, input WaitForDeploymentsAv
 		if err := input.Getter.Get(ctx, key, deployment); err != nil {
 			return false
 		}
-		for _, c := range deployment.Status.Conditions {
-			if c.Type == appsv1.DeploymentAvailable && c.Status == corev1.ConditionTrue {
-				return true
-			}
-		}
-		return false
+		return isDeploymentAvailable(deployment)
 	}, intervals...).Should(BeTrue(), func() string { return DescribeFailedDeployment(input, deployment) })
 }
 
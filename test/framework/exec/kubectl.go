@@ -20,10 +20,27 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"os/exec"
+
+	"github.com/pkg/errors"
 )
 
+// ErrKubectlNotFound is returned when the kubectl binary cannot be found in the PATH.
+var ErrKubectlNotFound = errors.New("kubectl binary not found in PATH")
+
+// LookPathKubectl returns ErrKubectlNotFound if the kubectl binary cannot be found in the PATH.
+func LookPathKubectl() error {
+	if _, err := exec.LookPath("kubectl"); err != nil {
+		return ErrKubectlNotFound
+	}
+	return nil
+}
+
 // TODO: Remove this usage of kubectl and replace with a function from apply.go using the controller-runtime client.
 func KubectlApply(ctx context.Context, kubeconfigPath string, resources []byte, args ...string) error {
+	if err := LookPathKubectl(); err != nil {
+		return err
+	}
 	aargs := append([]string{"apply", "--kubeconfig", kubeconfigPath, "-f", "-"}, args...)
 	rbytes := bytes.NewReader(resources)
 	applyCmd := NewCommand(
@@ -41,6 +58,9 @@ func KubectlApply(ctx context.Context, kubeconfigPath string, resources []byte,
 }
 
 func KubectlWait(ctx context.Context, kubeconfigPath string, args ...string) error {
+	if err := LookPathKubectl(); err != nil {
+		return err
+	}
 	wargs := append([]string{"wait", "--kubeconfig", kubeconfigPath}, args...)
 	wait := NewCommand(
 		WithCommand("kubectl"),
@@ -19,6 +19,7 @@ package framework
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -26,8 +27,10 @@ import (
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/test/framework/internal/log"
+	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -263,3 +266,87 @@ func MachinePhaseCheck(expectedPhase string) MachineStatusCheck {
 		return nil
 	}
 }
+
+// CreateMachinesInput is the input for CreateMachines.
+type CreateMachinesInput struct {
+	Creator  Creator
+	Getter   Getter
+	Machines []*clusterv1.Machine
+
+	// Concurrency is the maximum number of Machines created at the same time; zero means no limit.
+	Concurrency int
+}
+
+// CreateMachines creates a mixed list of control plane and worker Machines in dependency order, matching the
+// provisioning constraints of a Cluster: the first control plane Machine is created and waited on until it gets a Node,
+// because it initializes the Cluster the other Machines join; then the other control plane Machines are created,
+// and finally the worker Machines, at most input.Concurrency at a time.
+func CreateMachines(ctx context.Context, input CreateMachinesInput, intervals ...interface{}) {
+	Expect(ctx).NotTo(BeNil(), "ctx is required for CreateMachines")
+	Expect(input.Creator).ToNot(BeNil(), "Invalid argument. input.Creator can't be nil when calling CreateMachines")
+	Expect(input.Getter).ToNot(BeNil(), "Invalid argument. input.Getter can't be nil when calling CreateMachines")
+	Expect(input.Concurrency).To(BeNumerically(">=", 0), "Invalid argument. input.Concurrency can't be negative when calling CreateMachines")
+
+	var controlPlaneMachines, workerMachines []*clusterv1.Machine
+	for _, machine := range input.Machines {
+		if util.IsControlPlaneMachine(machine) {
+			controlPlaneMachines = append(controlPlaneMachines, machine)
+			continue
+		}
+		workerMachines = append(workerMachines, machine)
+	}
+
+	if len(controlPlaneMachines) > 0 {
+		first := controlPlaneMachines[0]
+		log.Logf("Creating the first control plane Machine %s/%s", first.Namespace, first.Name)
+		Expect(input.Creator.Create(ctx, first)).To(Succeed(), "Failed to create Machine %s/%s", first.Namespace, first.Name)
+
+		log.Logf("Waiting for the first control plane Machine %s/%s to have a Node", first.Namespace, first.Name)
+		WaitForMachineStatusCheck(ctx, WaitForMachineStatusCheckInput{
+			Getter:       input.Getter,
+			Machine:      first,
+			StatusChecks: []MachineStatusCheck{MachineNodeRefCheck()},
+		}, intervals...)
+
+		controlPlaneMachines = controlPlaneMachines[1:]
+	}
+
+	log.Logf("Creating %d control plane Machines", len(controlPlaneMachines))
+	Expect(createMachinesConcurrently(ctx, input.Creator, controlPlaneMachines, input.Concurrency)).To(Succeed(), "Failed to create the control plane Machines")
+
+	log.Logf("Creating %d worker Machines", len(workerMachines))
+	Expect(createMachinesConcurrently(ctx, input.Creator, workerMachines, input.Concurrency)).To(Succeed(), "Failed to create the worker Machines")
+}
+
+// createMachinesConcurrently creates Machines, at most concurrency at a time, or all at once if concurrency is zero;
+// it returns the aggregate of the errors occurred.
+func createMachinesConcurrently(ctx context.Context, creator Creator, machines []*clusterv1.Machine, concurrency int) error {
+	if concurrency <= 0 || concurrency > len(machines) {
+		concurrency = len(machines)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	sem := make(chan struct{}, concurrency)
+	for _, machine := range machines {
+		machine := machine
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := creator.Create(ctx, machine); err != nil {
+				mu.Lock()
+				errs = append(errs, errors.Wrapf(err, "failed to create Machine %s/%s", machine.Namespace, machine.Name))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return kerrors.NewAggregate(errs)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/test/framework"
+)
+
+// orderRecordingCreator records the order in which objects are created, and the maximum number of concurrent creations.
+type orderRecordingCreator struct {
+	client.Client
+
+	mu          sync.Mutex
+	created     []string
+	inFlight    int
+	maxInFlight int
+}
+
+func (c *orderRecordingCreator) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	c.mu.Lock()
+	c.inFlight++
+	if c.inFlight > c.maxInFlight {
+		c.maxInFlight = c.inFlight
+	}
+	c.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+	err := c.Client.Create(ctx, obj, opts...)
+
+	c.mu.Lock()
+	c.inFlight--
+	c.created = append(c.created, obj.GetName())
+	c.mu.Unlock()
+	return err
+}
+
+func TestCreateMachines(t *testing.T) {
+	RegisterTestingT(t)
+
+	scheme := runtime.NewScheme()
+	Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+
+	newMachine := func(name string, controlPlane bool) *clusterv1.Machine {
+		m := &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      name,
+				Labels:    map[string]string{clusterv1.ClusterLabelName: "cluster1"},
+			},
+			// The Node is set in advance, given that there are no controllers running in this test.
+			Status: clusterv1.MachineStatus{NodeRef: &corev1.ObjectReference{Kind: "Node", Name: name}},
+		}
+		if controlPlane {
+			m.Labels[clusterv1.MachineControlPlaneLabelName] = ""
+		}
+		return m
+	}
+
+	machines := []*clusterv1.Machine{}
+	for i := 0; i < 4; i++ {
+		machines = append(machines, newMachine(fmt.Sprintf("worker-%d", i), false))
+		if i < 3 {
+			machines = append(machines, newMachine(fmt.Sprintf("cp-%d", i), true))
+		}
+	}
+
+	c := &orderRecordingCreator{Client: fake.NewClientBuilder().WithScheme(scheme).Build()}
+	framework.CreateMachines(context.Background(), framework.CreateMachinesInput{
+		Creator:     c,
+		Getter:      c,
+		Machines:    machines,
+		Concurrency: 2,
+	})
+
+	// The first control plane Machine is created first, then the other control plane Machines, and finally the workers.
+	Expect(c.created).To(HaveLen(len(machines)))
+	Expect(c.created[0]).To(Equal("cp-0"))
+	Expect(c.created[1:3]).To(ConsistOf("cp-1", "cp-2"))
+	Expect(c.created[3:]).To(ConsistOf("worker-0", "worker-1", "worker-2", "worker-3"))
+	Expect(c.maxInFlight).To(Equal(2))
+}
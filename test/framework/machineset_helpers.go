@@ -0,0 +1,130 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/controllers/mdutil"
+	"sigs.k8s.io/cluster-api/test/framework/internal/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// WaitForMachineSetOwnedMachinesUpToDateInput is the input for WaitForMachineSetOwnedMachinesUpToDate.
+type WaitForMachineSetOwnedMachinesUpToDateInput struct {
+	GetLister  GetLister
+	MachineSet *clusterv1.MachineSet
+}
+
+// WaitForMachineSetOwnedMachinesUpToDate waits until the Machines owned by a MachineSet match the current machine template
+// of the MachineSet, i.e. the template hash, the Kubernetes version, the kind of the infrastructure and bootstrap references
+// and the failure domain, and until the number of up-to-date Machines equals the desired replicas of the MachineSet.
+// It is intended to be used after updating a MachineSet, to assert that the update actually propagated to the Machines.
+func WaitForMachineSetOwnedMachinesUpToDate(ctx context.Context, input WaitForMachineSetOwnedMachinesUpToDateInput, intervals ...interface{}) {
+	Expect(ctx).NotTo(BeNil(), "ctx is required for WaitForMachineSetOwnedMachinesUpToDate")
+	Expect(input.GetLister).ToNot(BeNil(), "Invalid argument. input.GetLister can't be nil when calling WaitForMachineSetOwnedMachinesUpToDate")
+	Expect(input.MachineSet).ToNot(BeNil(), "Invalid argument. input.MachineSet can't be nil when calling WaitForMachineSetOwnedMachinesUpToDate")
+
+	log.Logf("Waiting for the Machines owned by MachineSet %s/%s to be up to date", input.MachineSet.Namespace, input.MachineSet.Name)
+	Eventually(func() error {
+		machineSet := &clusterv1.MachineSet{}
+		if err := input.GetLister.Get(ctx, client.ObjectKeyFromObject(input.MachineSet), machineSet); err != nil {
+			return err
+		}
+
+		machineList := &clusterv1.MachineList{}
+		if err := input.GetLister.List(ctx, machineList, client.InNamespace(machineSet.Namespace), client.MatchingLabels(machineSet.Spec.Selector.MatchLabels)); err != nil {
+			return err
+		}
+
+		upToDate := 0
+		for i := range machineList.Items {
+			machine := &machineList.Items[i]
+			if !metav1.IsControlledBy(machine, machineSet) {
+				continue
+			}
+			if err := MachineUpToDateWithMachineSet(machine, machineSet); err != nil {
+				return err
+			}
+			upToDate++
+		}
+
+		replicas := int32(1)
+		if machineSet.Spec.Replicas != nil {
+			replicas = *machineSet.Spec.Replicas
+		}
+		if int32(upToDate) != replicas {
+			return errors.Errorf("%d of %d Machines are up to date", upToDate, replicas)
+		}
+		return nil
+	}, intervals...).Should(Succeed())
+}
+
+// MachineUpToDateWithMachineSet returns an error describing why a Machine does not match the machine template of a MachineSet.
+// Infrastructure and bootstrap references are compared by group and kind only, because the referenced objects are
+// generated from the templates for each Machine.
+func MachineUpToDateWithMachineSet(machine *clusterv1.Machine, machineSet *clusterv1.MachineSet) error {
+	template := machineSet.Spec.Template
+
+	if hash, ok := template.Labels[mdutil.DefaultMachineDeploymentUniqueLabelKey]; ok {
+		if machine.Labels[mdutil.DefaultMachineDeploymentUniqueLabelKey] != hash {
+			return errors.Errorf("Machine %s has template hash %q, expected %q",
+				machine.Name, machine.Labels[mdutil.DefaultMachineDeploymentUniqueLabelKey], hash)
+		}
+	}
+
+	if got, want := stringValue(machine.Spec.Version), stringValue(template.Spec.Version); got != want {
+		return errors.Errorf("Machine %s has version %q, expected %q", machine.Name, got, want)
+	}
+
+	if got, want := machine.Spec.InfrastructureRef.GroupVersionKind().GroupKind(), template.Spec.InfrastructureRef.GroupVersionKind().GroupKind(); got != want {
+		return errors.Errorf("Machine %s has infrastructure %s, expected %s", machine.Name, got, want)
+	}
+
+	if template.Spec.Bootstrap.ConfigRef != nil {
+		if machine.Spec.Bootstrap.ConfigRef == nil {
+			return errors.Errorf("Machine %s does not have a bootstrap configuration", machine.Name)
+		}
+		if got, want := machine.Spec.Bootstrap.ConfigRef.GroupVersionKind().GroupKind(), template.Spec.Bootstrap.ConfigRef.GroupVersionKind().GroupKind(); got != want {
+			return errors.Errorf("Machine %s has bootstrap configuration %s, expected %s", machine.Name, got, want)
+		}
+	} else if template.Spec.Bootstrap.DataSecretName != nil {
+		if got, want := stringValue(machine.Spec.Bootstrap.DataSecretName), *template.Spec.Bootstrap.DataSecretName; got != want {
+			return errors.Errorf("Machine %s has bootstrap data secret %q, expected %q", machine.Name, got, want)
+		}
+	}
+
+	if template.Spec.FailureDomain != nil {
+		if got, want := stringValue(machine.Spec.FailureDomain), *template.Spec.FailureDomain; got != want {
+			return errors.Errorf("Machine %s has failure domain %q, expected %q", machine.Name, got, want)
+		}
+	}
+	return nil
+}
+
+// stringValue returns the value of a string pointer, or the empty string if nil.
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
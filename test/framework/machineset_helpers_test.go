@@ -0,0 +1,121 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/test/framework"
+)
+
+func TestMachineUpToDateWithMachineSet(t *testing.T) {
+	machineSet := &clusterv1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "ms"},
+		Spec: clusterv1.MachineSetSpec{
+			Template: clusterv1.MachineTemplateSpec{
+				ObjectMeta: clusterv1.ObjectMeta{Labels: map[string]string{"machine-template-hash": "12345"}},
+				Spec: clusterv1.MachineSpec{
+					Version: pointer.StringPtr("v1.21.1"),
+					InfrastructureRef: corev1.ObjectReference{
+						APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha4",
+						Kind:       "DockerMachineTemplate",
+						Name:       "template-2",
+					},
+					Bootstrap: clusterv1.Bootstrap{
+						ConfigRef: &corev1.ObjectReference{
+							APIVersion: "bootstrap.cluster.x-k8s.io/v1alpha4",
+							Kind:       "KubeadmConfigTemplate",
+							Name:       "template",
+						},
+					},
+				},
+			},
+		},
+	}
+	upToDateMachine := func() *clusterv1.Machine {
+		return &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "ms-abcde", Labels: map[string]string{"machine-template-hash": "12345"}},
+			Spec: clusterv1.MachineSpec{
+				Version: pointer.StringPtr("v1.21.1"),
+				InfrastructureRef: corev1.ObjectReference{
+					APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha3",
+					Kind:       "DockerMachineTemplate",
+					Name:       "ms-abcde",
+				},
+				Bootstrap: clusterv1.Bootstrap{
+					ConfigRef: &corev1.ObjectReference{
+						APIVersion: "bootstrap.cluster.x-k8s.io/v1alpha4",
+						Kind:       "KubeadmConfigTemplate",
+						Name:       "ms-abcde",
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(m *clusterv1.Machine)
+		wantErr bool
+	}{
+		{
+			name:   "up to date, ignoring the name and the version of the references",
+			mutate: func(m *clusterv1.Machine) {},
+		},
+		{
+			name:    "different template hash",
+			mutate:  func(m *clusterv1.Machine) { m.Labels["machine-template-hash"] = "67890" },
+			wantErr: true,
+		},
+		{
+			name:    "different Kubernetes version",
+			mutate:  func(m *clusterv1.Machine) { m.Spec.Version = pointer.StringPtr("v1.20.1") },
+			wantErr: true,
+		},
+		{
+			name:    "different infrastructure kind",
+			mutate:  func(m *clusterv1.Machine) { m.Spec.InfrastructureRef.Kind = "OtherMachineTemplate" },
+			wantErr: true,
+		},
+		{
+			name:    "missing bootstrap configuration",
+			mutate:  func(m *clusterv1.Machine) { m.Spec.Bootstrap.ConfigRef = nil },
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			machine := upToDateMachine()
+			tt.mutate(machine)
+
+			err := framework.MachineUpToDateWithMachineSet(machine, machineSet)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+		})
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api/test/framework/internal/log"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// coreDNSKey is the key of the CoreDNS Deployment installed by kubeadm.
+var coreDNSKey = client.ObjectKey{Namespace: metav1.NamespaceSystem, Name: "coredns"}
+
+// WaitForWorkloadClusterReadyInput is the input for WaitForWorkloadClusterReady.
+type WaitForWorkloadClusterReadyInput struct {
+	// WorkloadClusterProxy is the proxy for the workload cluster, e.g. as returned by ClusterProxy.GetWorkloadCluster.
+	WorkloadClusterProxy ClusterProxy
+
+	// Deployments is an optional list of Deployments in the workload cluster which must be available,
+	// in addition to CoreDNS.
+	Deployments []client.ObjectKey
+}
+
+// WaitForWorkloadClusterReady waits until the workload cluster is usable, which goes beyond all the machines being ready:
+// the API server must report itself as ready on /readyz, all the nodes must be Ready (which implies a working CNI),
+// and the CoreDNS Deployment as well as any additional Deployment in input.Deployments must be available.
+func WaitForWorkloadClusterReady(ctx context.Context, input WaitForWorkloadClusterReadyInput, intervals ...interface{}) {
+	Expect(ctx).NotTo(BeNil(), "ctx is required for WaitForWorkloadClusterReady")
+	Expect(input.WorkloadClusterProxy).ToNot(BeNil(), "Invalid argument. input.WorkloadClusterProxy can't be nil when calling WaitForWorkloadClusterReady")
+
+	By("Waiting for the workload cluster to be ready")
+	Eventually(func() error {
+		if err := checkWorkloadClusterReady(ctx, input); err != nil {
+			log.Logf("Workload cluster %s is not ready yet: %v", input.WorkloadClusterProxy.GetName(), err)
+			return err
+		}
+		return nil
+	}, intervals...).Should(Succeed(), "Workload cluster %s failed to become ready", input.WorkloadClusterProxy.GetName())
+}
+
+func checkWorkloadClusterReady(ctx context.Context, input WaitForWorkloadClusterReadyInput) error {
+	if _, err := input.WorkloadClusterProxy.GetClientSet().Discovery().RESTClient().Get().AbsPath("/readyz").DoRaw(ctx); err != nil {
+		return errors.Wrap(err, "API server is not ready")
+	}
+
+	c := input.WorkloadClusterProxy.GetClient()
+
+	nodes := &corev1.NodeList{}
+	if err := c.List(ctx, nodes); err != nil {
+		return errors.Wrap(err, "failed to list nodes")
+	}
+	if len(nodes.Items) == 0 {
+		return errors.New("there are no nodes")
+	}
+	for i := range nodes.Items {
+		if !util.IsNodeReady(&nodes.Items[i]) {
+			return errors.Errorf("node %s is not Ready", nodes.Items[i].Name)
+		}
+	}
+
+	for _, key := range append([]client.ObjectKey{coreDNSKey}, input.Deployments...) {
+		deployment := &appsv1.Deployment{}
+		if err := c.Get(ctx, key, deployment); err != nil {
+			return errors.Wrapf(err, "failed to get deployment %s", key)
+		}
+		if !isDeploymentAvailable(deployment) {
+			return errors.Errorf("deployment %s is not available", key)
+		}
+	}
+	return nil
+}
+
+func isDeploymentAvailable(deployment *appsv1.Deployment) bool {
+	for _, c := range deployment.Status.Conditions {
+		if c.Type == appsv1.DeploymentAvailable && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
@@ -17,11 +17,16 @@ limitations under the License.
 package helpers
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -47,3 +52,82 @@ func NewFakeClientWithScheme(clientScheme *runtime.Scheme, initObjs ...client.Ob
 	}
 	return fake.NewClientBuilder().WithScheme(clientScheme).WithObjects(initObjsWithResourceVersion...).Build()
 }
+
+// FakeIndex is an index honoured by the clients returned by NewFakeClientWithIndexes.
+type FakeIndex struct {
+	Object  client.Object
+	Field   string
+	Extract client.IndexerFunc
+}
+
+// NewFakeClientWithIndexes wraps a fake client, so that List honours the field selectors on the given indexes like a
+// client reading from a cache with the same indexes does; the fake client alone ignores field selectors.
+// Like with a cache, listing with a field selector on a field which is not indexed fails.
+func NewFakeClientWithIndexes(c client.Client, indexes ...FakeIndex) client.Client {
+	indexed := &indexedFakeClient{
+		Client:  c,
+		indexes: map[schema.GroupVersionKind]map[string]client.IndexerFunc{},
+	}
+	for _, index := range indexes {
+		gvk, err := apiutil.GVKForObject(index.Object, c.Scheme())
+		if err != nil {
+			panic(fmt.Errorf("failed to get the GroupVersionKind of %T: %v", index.Object, err))
+		}
+		if indexed.indexes[gvk] == nil {
+			indexed.indexes[gvk] = map[string]client.IndexerFunc{}
+		}
+		indexed.indexes[gvk][index.Field] = index.Extract
+	}
+	return indexed
+}
+
+type indexedFakeClient struct {
+	client.Client
+	indexes map[schema.GroupVersionKind]map[string]client.IndexerFunc
+}
+
+func (c *indexedFakeClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	fieldSelector := listOpts.FieldSelector
+	if fieldSelector == nil || fieldSelector.Empty() {
+		return c.Client.List(ctx, list, opts...)
+	}
+
+	gvk, err := apiutil.GVKForObject(list, c.Scheme())
+	if err != nil {
+		return err
+	}
+	gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+
+	listOpts.FieldSelector = nil
+	if err := c.Client.List(ctx, list, listOpts); err != nil {
+		return err
+	}
+
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	for _, requirement := range fieldSelector.Requirements() {
+		if requirement.Operator != selection.Equals && requirement.Operator != selection.DoubleEquals {
+			return fmt.Errorf("field selectors on indexes support only the equals operator, got %q", fieldSelector)
+		}
+		extract, ok := c.indexes[gvk][requirement.Field]
+		if !ok {
+			return fmt.Errorf("index with name field:%s does not exist", requirement.Field)
+		}
+
+		filtered := []runtime.Object{}
+		for _, item := range items {
+			for _, value := range extract(item.(client.Object)) {
+				if value == requirement.Value {
+					filtered = append(filtered, item)
+					break
+				}
+			}
+		}
+		items = filtered
+	}
+	return meta.SetList(list, items)
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+
+package util_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/test/helpers"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetClusterByUID(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+
+	myCluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-cluster",
+			Namespace: "my-ns",
+			UID:       "my-uid",
+		},
+	}
+	otherCluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-cluster",
+			Namespace: "other-ns",
+			UID:       "other-uid",
+		},
+	}
+
+	c := helpers.NewFakeClientWithIndexes(fake.NewClientBuilder().WithScheme(scheme).WithObjects(myCluster, otherCluster).Build(),
+		helpers.FakeIndex{Object: &clusterv1.Cluster{}, Field: clusterv1.ClusterUIDIndex, Extract: util.IndexClusterByUID},
+	)
+
+	cluster, err := util.GetClusterByUID(context.Background(), c, "my-ns", "my-uid")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cluster.Namespace).To(Equal("my-ns"))
+
+	// Clusters in other namespaces are not returned.
+	_, err = util.GetClusterByUID(context.Background(), c, "my-ns", "other-uid")
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+}
@@ -25,15 +25,15 @@ import (
 
 // GetFilteredMachinesForCluster returns a list of machines that can be filtered or not.
 // If no filter is supplied then all machines associated with the target cluster are returned.
+// The machines are listed using the clusterv1.ClusterNameIndex, so the reader must read from a cache
+// with the index added, see util.AddClusterNameIndex.
 func GetFilteredMachinesForCluster(ctx context.Context, c client.Reader, cluster *clusterv1.Cluster, filters ...Func) (Machines, error) {
 	ml := &clusterv1.MachineList{}
 	if err := c.List(
 		ctx,
 		ml,
 		client.InNamespace(cluster.Namespace),
-		client.MatchingLabels{
-			clusterv1.ClusterLabelName: cluster.Name,
-		},
+		client.MatchingFields{clusterv1.ClusterNameIndex: cluster.Name},
 	); err != nil {
 		return nil, errors.Wrap(err, "failed to list machines")
 	}
@@ -17,7 +17,10 @@ limitations under the License.
 package collections_test
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/cluster-api/test/helpers"
+	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/collections"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -300,23 +303,50 @@ func TestGetFilteredMachinesForCluster(t *testing.T) {
 			Name:      "my-cluster",
 		},
 	}
+	otherCluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "my-namespace",
+			Name:      "other-cluster",
+		},
+	}
+
+	// The Machines of the other Cluster are in the same namespace, and one of them is owned by the same control plane
+	// as the control plane Machine of the Cluster.
+	otherClusterMachine := testMachine("other-cluster-machine")
+	otherClusterMachine.Spec.ClusterName = otherCluster.Name
+	otherClusterMachine.Labels[clusterv1.ClusterLabelName] = otherCluster.Name
+	otherClusterControlPlaneMachine := testControlPlaneMachine("other-cluster-control-plane-machine")
+	otherClusterControlPlaneMachine.Spec.ClusterName = otherCluster.Name
+	otherClusterControlPlaneMachine.Labels[clusterv1.ClusterLabelName] = otherCluster.Name
 
-	c := fake.NewClientBuilder().
+	c := helpers.NewFakeClientWithIndexes(fake.NewClientBuilder().
 		WithScheme(scheme).
-		WithObjects(cluster,
+		WithObjects(cluster, otherCluster,
 			testControlPlaneMachine("first-machine"),
 			testMachine("second-machine"),
-			testMachine("third-machine")).
-		Build()
+			testMachine("third-machine"),
+			otherClusterMachine,
+			otherClusterControlPlaneMachine).
+		Build(),
+		helpers.FakeIndex{Object: &clusterv1.Machine{}, Field: clusterv1.ClusterNameIndex, Extract: util.IndexByClusterName},
+	)
 
 	machines, err := collections.GetFilteredMachinesForCluster(ctx, c, cluster)
 	g.Expect(err).NotTo(HaveOccurred())
-	g.Expect(machines).To(HaveLen(3))
+	g.Expect(machines.Names()).To(ConsistOf("first-machine", "second-machine", "third-machine"))
 
 	// Test the ControlPlaneMachines works
 	machines, err = collections.GetFilteredMachinesForCluster(ctx, c, cluster, collections.ControlPlaneMachines("my-cluster"))
 	g.Expect(err).NotTo(HaveOccurred())
-	g.Expect(machines).To(HaveLen(1))
+	g.Expect(machines.Names()).To(ConsistOf("first-machine"))
+
+	// Test that the Machines owned by the control plane are only the ones of the Cluster
+	controlPlane := &unstructured.Unstructured{}
+	controlPlane.SetKind("KubeadmControlPlane")
+	controlPlane.SetName("my-control-plane")
+	machines, err = collections.GetFilteredMachinesForCluster(ctx, c, cluster, collections.OwnedMachines(controlPlane))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(machines.Names()).To(ConsistOf("first-machine"))
 
 	// Test that the filters use AND logic instead of OR logic
 	nameFilter := func(cluster *clusterv1.Machine) bool {
@@ -353,5 +383,8 @@ func testMachine(name string) *clusterv1.Machine {
 				clusterv1.ClusterLabelName: "my-cluster",
 			},
 		},
+		Spec: clusterv1.MachineSpec{
+			ClusterName: "my-cluster",
+		},
 	}
 }
@@ -34,6 +34,8 @@ func ParseMajorMinorPatch(v string) (semver.Version, error) {
 }
 
 // GetMachinesForCluster returns a list of machines associated with the cluster.
+// The machines are listed using the clusterv1.ClusterNameIndex, so the client must read from a cache
+// with the index added, see AddClusterNameIndex.
 //
 // Deprecated: Please use util/collection GetFilteredMachinesForCluster(ctx, client, cluster).
 func GetMachinesForCluster(ctx context.Context, c client.Client, cluster *clusterv1.Cluster) (*clusterv1.MachineList, error) {
@@ -42,9 +44,7 @@ func GetMachinesForCluster(ctx context.Context, c client.Client, cluster *cluste
 		ctx,
 		&machines,
 		client.InNamespace(cluster.Namespace),
-		client.MatchingLabels{
-			clusterv1.ClusterLabelName: cluster.Name,
-		},
+		client.MatchingFields{clusterv1.ClusterNameIndex: cluster.Name},
 	); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,119 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/test/helpers"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetMachinesForCluster(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-cluster",
+			Namespace: "my-ns",
+		},
+	}
+	otherCluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "other-cluster",
+			Namespace: cluster.Namespace,
+		},
+	}
+
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-machine",
+			Namespace: cluster.Namespace,
+			Labels: map[string]string{
+				clusterv1.ClusterLabelName: cluster.Name,
+			},
+		},
+		Spec: clusterv1.MachineSpec{
+			ClusterName: cluster.Name,
+		},
+	}
+
+	// The Machine of the other Cluster is owned by the Cluster, but it is not one of its Machines.
+	machineOtherClusterSameNamespace := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "other-machine",
+			Namespace: cluster.Namespace,
+			Labels: map[string]string{
+				clusterv1.ClusterLabelName: otherCluster.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: clusterv1.GroupVersion.String(),
+					Kind:       "Cluster",
+					Name:       cluster.Name,
+				},
+			},
+		},
+		Spec: clusterv1.MachineSpec{
+			ClusterName: otherCluster.Name,
+		},
+	}
+
+	machineSameClusterNameDifferentNamespace := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "other-machine",
+			Namespace: "other-ns",
+			Labels: map[string]string{
+				clusterv1.ClusterLabelName: cluster.Name,
+			},
+		},
+		Spec: clusterv1.MachineSpec{
+			ClusterName: cluster.Name,
+		},
+	}
+
+	c := helpers.NewFakeClientWithIndexes(fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		cluster,
+		otherCluster,
+		machine,
+		machineOtherClusterSameNamespace,
+		machineSameClusterNameDifferentNamespace,
+	).Build(),
+		helpers.FakeIndex{Object: &clusterv1.Machine{}, Field: clusterv1.ClusterNameIndex, Extract: util.IndexByClusterName},
+	)
+
+	machines, err := util.GetMachinesForCluster(context.Background(), c, cluster)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(machines.Items).To(HaveLen(1))
+	g.Expect(machines.Items[0].Name).To(Equal(machine.Name))
+	g.Expect(machines.Items[0].Namespace).To(Equal(cluster.Namespace))
+
+	machines, err = util.GetMachinesForCluster(context.Background(), c, otherCluster)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(machines.Items).To(HaveLen(1))
+	g.Expect(machines.Items[0].Name).To(Equal(machineOtherClusterSameNamespace.Name))
+}
@@ -173,6 +173,42 @@ func NeedsClientCertRotation(configSecret *corev1.Secret, threshold time.Duratio
 	return false, nil
 }
 
+// NeedsCARotation returns whether the Kubeconfig secret refers to a certificate authority other than the given one,
+// or whether any of its client certificates is not signed by it, e.g. after the cluster CA has been rotated.
+func NeedsCARotation(configSecret *corev1.Secret, caCert *x509.Certificate) (bool, error) {
+	data, err := toKubeconfigBytes(configSecret)
+	if err != nil {
+		return false, err
+	}
+
+	config, err := clientcmd.Load(data)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to convert kubeconfig Secret into a clientcmdapi.Config")
+	}
+
+	for _, cluster := range config.Clusters {
+		cert, err := certs.DecodeCertPEM(cluster.CertificateAuthorityData)
+		if err != nil {
+			return false, errors.Wrap(err, "failed to decode kubeconfig certificate authority")
+		}
+		if cert == nil || !cert.Equal(caCert) {
+			return true, nil
+		}
+	}
+
+	for _, authInfo := range config.AuthInfos {
+		cert, err := certs.DecodeCertPEM(authInfo.ClientCertificateData)
+		if err != nil {
+			return false, errors.Wrap(err, "failed to decode kubeconfig client certificate")
+		}
+		if cert == nil || cert.CheckSignatureFrom(caCert) != nil {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
 // RegenerateSecret creates and stores a new Kubeconfig in the given secret.
 func RegenerateSecret(ctx context.Context, c client.Client, configSecret *corev1.Secret) error {
 	clusterName, _, err := secret.ParseSecretName(configSecret.Name)
@@ -0,0 +1,87 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package names implements the generation of object names from user defined templates.
+package names
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// RandomVariable is the template variable replaced by a random suffix; every template must use it,
+	// so names generated from the same template do not collide.
+	RandomVariable = "random"
+
+	// randomLength is the length of the random suffix, the same used by the API server for generateName.
+	randomLength = 5
+)
+
+// Template generates object names, e.g. {{ .cluster }}-cp-{{ .random }}.
+type Template struct {
+	text     string
+	template *template.Template
+}
+
+// ParseTemplate parses a name template; the template must reference the {{ .random }} variable, while the other
+// variables are defined by the caller when generating names.
+func ParseTemplate(text string) (*Template, error) {
+	if !strings.Contains(text, "."+RandomVariable) {
+		return nil, errors.Errorf("invalid name template %q: must contain {{ .%s }}", text, RandomVariable)
+	}
+	t, err := template.New("name").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid name template %q", text)
+	}
+	return &Template{text: text, template: t}, nil
+}
+
+// Generate returns a new name, rendering the template with the given variables and a random suffix.
+// Names which are not valid DNS-1123 labels, i.e. longer than 63 characters or not composed of lowercase
+// alphanumeric characters and '-', are rejected, so they can be used as host names.
+func (t *Template) Generate(variables map[string]string) (string, error) {
+	return t.render(variables, utilrand.String(randomLength))
+}
+
+// Validate checks that the names generated with the given variables are valid, without generating a random suffix.
+func (t *Template) Validate(variables map[string]string) error {
+	_, err := t.render(variables, strings.Repeat("x", randomLength))
+	return err
+}
+
+func (t *Template) render(variables map[string]string, random string) (string, error) {
+	data := make(map[string]string, len(variables)+1)
+	for k, v := range variables {
+		data[k] = v
+	}
+	data[RandomVariable] = random
+
+	var buf bytes.Buffer
+	if err := t.template.Execute(&buf, data); err != nil {
+		return "", errors.Wrapf(err, "failed to render name template %q", t.text)
+	}
+	name := buf.String()
+	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+		return "", errors.Errorf("name template %q generates the invalid name %q: %s", t.text, name, strings.Join(errs, ", "))
+	}
+	return name, nil
+}
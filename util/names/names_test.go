@@ -0,0 +1,113 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package names
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParseTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		wantErr bool
+	}{
+		{
+			name: "valid template",
+			text: "{{ .cluster }}-cp-{{ .random }}",
+		},
+		{
+			name:    "template without the random variable",
+			text:    "{{ .cluster }}-cp",
+			wantErr: true,
+		},
+		{
+			name:    "template with invalid syntax",
+			text:    "{{ .cluster -cp-{{ .random }}",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			_, err := ParseTemplate(tt.text)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+		})
+	}
+}
+
+func TestTemplate_Generate(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		variables  map[string]string
+		wantPrefix string
+		wantErr    bool
+	}{
+		{
+			name:       "renders the variables and the random suffix",
+			text:       "{{ .cluster }}-cp-{{ .random }}",
+			variables:  map[string]string{"cluster": "foo"},
+			wantPrefix: "foo-cp-",
+		},
+		{
+			name:      "fails for undefined variables",
+			text:      "{{ .cluster }}-{{ .random }}",
+			variables: map[string]string{},
+			wantErr:   true,
+		},
+		{
+			name:      "fails for names with invalid characters",
+			text:      "{{ .cluster }}.{{ .random }}",
+			variables: map[string]string{"cluster": "foo"},
+			wantErr:   true,
+		},
+		{
+			name:      "fails for names longer than 63 characters",
+			text:      "{{ .cluster }}-{{ .random }}",
+			variables: map[string]string{"cluster": strings.Repeat("a", 58)},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			template, err := ParseTemplate(tt.text)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			g.Expect(template.Validate(tt.variables) != nil).To(Equal(tt.wantErr))
+
+			name, err := template.Generate(tt.variables)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(name).To(HavePrefix(tt.wantPrefix))
+			g.Expect(name).To(HaveLen(len(tt.wantPrefix) + randomLength))
+		})
+	}
+}
@@ -17,8 +17,11 @@ limitations under the License.
 package predicates
 
 import (
+	"reflect"
+
 	"github.com/go-logr/logr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
@@ -111,6 +114,36 @@ func ClusterUpdateInfraReady(logger logr.Logger) predicate.Funcs {
 	}
 }
 
+// ClusterUpdateFailureDomainsChanged returns a predicate that returns true for an update event when an unpaused cluster has Status.FailureDomains changed,
+// so controllers placing Machines in failure domains, e.g. control plane controllers, pick up the new failure domains.
+func ClusterUpdateFailureDomainsChanged(logger logr.Logger) predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			log := logger.WithValues("predicate", "ClusterUpdateFailureDomainsChanged", "eventType", "update")
+
+			oldCluster, ok := e.ObjectOld.(*clusterv1.Cluster)
+			if !ok {
+				log.V(4).Info("Expected Cluster", "type", e.ObjectOld.GetObjectKind().GroupVersionKind().String())
+				return false
+			}
+			log = log.WithValues("namespace", oldCluster.Namespace, "cluster", oldCluster.Name)
+
+			newCluster := e.ObjectNew.(*clusterv1.Cluster)
+
+			if !newCluster.Spec.Paused && !reflect.DeepEqual(oldCluster.Status.FailureDomains, newCluster.Status.FailureDomains) {
+				log.V(4).Info("Cluster failure domains changed, allowing further processing")
+				return true
+			}
+
+			log.V(4).Info("Cluster failure domains did not change, blocking further processing")
+			return false
+		},
+		CreateFunc:  func(e event.CreateEvent) bool { return false },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+	}
+}
+
 // ClusterUpdateUnpaused returns a predicate that returns true for an update event when a cluster has Spec.Paused changed from true to false
 // it also returns true if the resource provided is not a Cluster to allow for use with controller-runtime NewControllerManagedBy.
 func ClusterUpdateUnpaused(logger logr.Logger) predicate.Funcs {
@@ -185,3 +218,40 @@ func ClusterUnpausedAndInfrastructureReady(logger logr.Logger) predicate.Funcs {
 	// Use any to ensure we process either create or update events we care about
 	return Any(log, createPredicates, updatePredicates)
 }
+
+// ClusterHasTopology returns a Predicate that returns true when cluster.Spec.Topology
+// is NOT nil and false otherwise.
+func ClusterHasTopology(logger logr.Logger) predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return processIfTopologyManaged(logger.WithValues("predicate", "ClusterHasTopology", "eventType", "update"), e.ObjectNew)
+		},
+		CreateFunc: func(e event.CreateEvent) bool {
+			return processIfTopologyManaged(logger.WithValues("predicate", "ClusterHasTopology", "eventType", "create"), e.Object)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return processIfTopologyManaged(logger.WithValues("predicate", "ClusterHasTopology", "eventType", "delete"), e.Object)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return processIfTopologyManaged(logger.WithValues("predicate", "ClusterHasTopology", "eventType", "generic"), e.Object)
+		},
+	}
+}
+
+func processIfTopologyManaged(logger logr.Logger, object client.Object) bool {
+	cluster, ok := object.(*clusterv1.Cluster)
+	if !ok {
+		logger.V(4).Info("Expected Cluster", "type", object.GetObjectKind().GroupVersionKind().String())
+		return false
+	}
+
+	log := logger.WithValues("namespace", cluster.Namespace, "cluster", cluster.Name)
+
+	if cluster.Spec.Topology != nil {
+		log.V(6).Info("Cluster has topology, allowing further processing")
+		return true
+	}
+
+	log.V(6).Info("Cluster does not have topology, blocking further processing")
+	return false
+}
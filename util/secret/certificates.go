@@ -301,6 +301,24 @@ func (c *Certificate) Hashes() ([]string, error) {
 	return out, nil
 }
 
+// ExpiryTime returns the earliest expiry time of the certificates stored in a CA certificate.
+func (c *Certificate) ExpiryTime() (time.Time, error) {
+	if c.KeyPair == nil {
+		return time.Time{}, errors.Wrapf(ErrMissingCertificate, "for certificate: %s", c.Purpose)
+	}
+	certificates, err := cert.ParseCertsPEM(c.KeyPair.Cert)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "unable to parse %s certificate", c.Purpose)
+	}
+	expiry := certificates[0].NotAfter
+	for _, certificate := range certificates[1:] {
+		if certificate.NotAfter.Before(expiry) {
+			expiry = certificate.NotAfter
+		}
+	}
+	return expiry, nil
+}
+
 // hashCert calculates the sha256 of certificate.
 func hashCert(certificate *x509.Certificate) string {
 	spkiHash := sha256.Sum256(certificate.RawSubjectPublicKeyInfo)
@@ -18,6 +18,7 @@ package secret_test
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 
@@ -45,3 +46,16 @@ func TestNewControlPlaneJoinCerts_External(t *testing.T) {
 	certs := secret.NewControlPlaneJoinCerts(config)
 	g.Expect(certs.GetByPurpose(secret.EtcdCA).KeyFile).To(BeEmpty())
 }
+
+func TestCertificate_ExpiryTime(t *testing.T) {
+	g := NewWithT(t)
+
+	c := &secret.Certificate{Purpose: secret.ClusterCA}
+	_, err := c.ExpiryTime()
+	g.Expect(err).To(HaveOccurred())
+
+	g.Expect(c.Generate()).To(Succeed())
+	expiry, err := c.ExpiryTime()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(expiry).To(BeTemporally("~", time.Now().Add(time.Hour*24*365*10), time.Minute))
+}
@@ -34,8 +34,10 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	k8sversion "k8s.io/apimachinery/pkg/version"
 	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/rest"
@@ -177,6 +179,83 @@ func GetClusterByName(ctx context.Context, c client.Client, namespace, name stri
 	return cluster, nil
 }
 
+// GetClusterByUID finds and return the Cluster object with the specified UID in a namespace, e.g. for telling apart
+// a Cluster from another one which has been deleted and then recreated with the same name.
+// A NotFound error is returned if no Cluster with the UID exists in the namespace.
+// The Cluster is listed using the clusterv1.ClusterUIDIndex, so the client must read from a cache with the index
+// added, see AddClusterUIDIndex.
+func GetClusterByUID(ctx context.Context, c client.Client, namespace string, uid types.UID) (*clusterv1.Cluster, error) {
+	clusters := &clusterv1.ClusterList{}
+	if err := c.List(ctx, clusters, client.InNamespace(namespace), client.MatchingFields{clusterv1.ClusterUIDIndex: string(uid)}); err != nil {
+		return nil, errors.Wrapf(err, "failed to list Clusters in namespace %q", namespace)
+	}
+
+	if len(clusters.Items) == 0 {
+		return nil, apierrors.NewNotFound(clusterv1.GroupVersion.WithResource("clusters").GroupResource(), string(uid))
+	}
+	return &clusters.Items[0], nil
+}
+
+// ListClusters returns the list of Cluster objects in a namespace matching the specified label selector.
+func ListClusters(ctx context.Context, c client.Client, namespace string, selector labels.Selector) (*clusterv1.ClusterList, error) {
+	clusters := &clusterv1.ClusterList{}
+	if err := c.List(ctx, clusters, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, errors.Wrapf(err, "failed to list Clusters in namespace %q", namespace)
+	}
+	return clusters, nil
+}
+
+// AddClusterNameIndex adds the clusterv1.ClusterNameIndex for the given MachineDeployments, MachineSets or Machines
+// to a field indexer, usually the one of a manager. The index is required for listing the objects of a Cluster by
+// cluster name, and it can be added only once for each kind to a manager.
+func AddClusterNameIndex(ctx context.Context, indexer client.FieldIndexer, objs ...client.Object) error {
+	for _, obj := range objs {
+		if err := indexer.IndexField(ctx, obj, clusterv1.ClusterNameIndex, IndexByClusterName); err != nil {
+			return errors.Wrapf(err, "error setting the %s index for %T", clusterv1.ClusterNameIndex, obj)
+		}
+	}
+	return nil
+}
+
+// IndexByClusterName indexes MachineDeployments, MachineSets and Machines by spec.clusterName.
+func IndexByClusterName(o client.Object) []string {
+	var clusterName string
+	switch obj := o.(type) {
+	case *clusterv1.MachineDeployment:
+		clusterName = obj.Spec.ClusterName
+	case *clusterv1.MachineSet:
+		clusterName = obj.Spec.ClusterName
+	case *clusterv1.Machine:
+		clusterName = obj.Spec.ClusterName
+	default:
+		panic(fmt.Sprintf("Expected a MachineDeployment, a MachineSet or a Machine but got a %T", o))
+	}
+
+	if clusterName != "" {
+		return []string{clusterName}
+	}
+
+	return nil
+}
+
+// AddClusterUIDIndex adds the clusterv1.ClusterUIDIndex for Clusters to a field indexer, usually the one of a manager.
+// The index is required by GetClusterByUID, and it can be added only once to a manager.
+func AddClusterUIDIndex(ctx context.Context, indexer client.FieldIndexer) error {
+	if err := indexer.IndexField(ctx, &clusterv1.Cluster{}, clusterv1.ClusterUIDIndex, IndexClusterByUID); err != nil {
+		return errors.Wrapf(err, "error setting the %s index for Clusters", clusterv1.ClusterUIDIndex)
+	}
+	return nil
+}
+
+// IndexClusterByUID is a client.IndexerFunc indexing Clusters by UID.
+func IndexClusterByUID(o client.Object) []string {
+	cluster, ok := o.(*clusterv1.Cluster)
+	if !ok {
+		panic(fmt.Sprintf("Expected a Cluster but got a %T", o))
+	}
+	return []string{string(cluster.UID)}
+}
+
 // ObjectKey returns client.ObjectKey for the object.
 func ObjectKey(object metav1.Object) client.ObjectKey {
 	return client.ObjectKey{
@@ -25,6 +25,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
@@ -433,6 +434,50 @@ func TestGetOwnerClusterSuccessByName(t *testing.T) {
 	g.Expect(cluster).NotTo(BeNil())
 }
 
+func TestListClusters(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+
+	prodCluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "prod-cluster",
+			Namespace: "my-ns",
+			Labels:    map[string]string{"env": "prod"},
+		},
+	}
+	devCluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dev-cluster",
+			Namespace: "my-ns",
+			Labels:    map[string]string{"env": "dev"},
+		},
+	}
+	otherNamespaceCluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "prod-cluster",
+			Namespace: "other-ns",
+			Labels:    map[string]string{"env": "prod"},
+		},
+	}
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(prodCluster, devCluster, otherNamespaceCluster).
+		Build()
+
+	clusters, err := ListClusters(ctx, c, "my-ns", labels.SelectorFromSet(labels.Set{"env": "prod"}))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(clusters.Items).To(HaveLen(1))
+	g.Expect(clusters.Items[0].Name).To(Equal("prod-cluster"))
+	g.Expect(clusters.Items[0].Namespace).To(Equal("my-ns"))
+
+	clusters, err = ListClusters(ctx, c, "my-ns", labels.Everything())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(clusters.Items).To(HaveLen(2))
+}
+
 func TestGetOwnerMachineSuccessByName(t *testing.T) {
 	g := NewWithT(t)
 
@@ -501,59 +546,40 @@ func TestGetOwnerMachineSuccessByNameFromDifferentVersion(t *testing.T) {
 	g.Expect(machine).NotTo(BeNil())
 }
 
-func TestGetMachinesForCluster(t *testing.T) {
-	g := NewWithT(t)
-
-	scheme := runtime.NewScheme()
-	g.Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
-
-	cluster := &clusterv1.Cluster{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "my-cluster",
-			Namespace: "my-ns",
+func TestIndexByClusterName(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  client.Object
+		want []string
+	}{
+		{
+			name: "MachineDeployment",
+			obj:  &clusterv1.MachineDeployment{Spec: clusterv1.MachineDeploymentSpec{ClusterName: "test-cluster"}},
+			want: []string{"test-cluster"},
 		},
-	}
-
-	machine := &clusterv1.Machine{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "my-machine",
-			Namespace: cluster.Namespace,
-			Labels: map[string]string{
-				clusterv1.ClusterLabelName: cluster.Name,
-			},
+		{
+			name: "MachineSet",
+			obj:  &clusterv1.MachineSet{Spec: clusterv1.MachineSetSpec{ClusterName: "test-cluster"}},
+			want: []string{"test-cluster"},
 		},
-	}
-
-	machineDifferentClusterNameSameNamespace := &clusterv1.Machine{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "other-machine",
-			Namespace: cluster.Namespace,
-			Labels: map[string]string{
-				clusterv1.ClusterLabelName: "other-cluster",
-			},
+		{
+			name: "Machine",
+			obj:  &clusterv1.Machine{Spec: clusterv1.MachineSpec{ClusterName: "test-cluster"}},
+			want: []string{"test-cluster"},
 		},
-	}
-
-	machineSameClusterNameDifferentNamespace := &clusterv1.Machine{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "other-machine",
-			Namespace: "other-ns",
-			Labels: map[string]string{
-				clusterv1.ClusterLabelName: cluster.Name,
-			},
+		{
+			name: "Machine without cluster name",
+			obj:  &clusterv1.Machine{},
+			want: nil,
 		},
 	}
 
-	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
-		machine,
-		machineDifferentClusterNameSameNamespace,
-		machineSameClusterNameDifferentNamespace,
-	).Build()
-
-	machines, err := GetMachinesForCluster(ctx, c, cluster)
-	g.Expect(err).NotTo(HaveOccurred())
-	g.Expect(machines.Items).To(HaveLen(1))
-	g.Expect(machines.Items[0].Labels[clusterv1.ClusterLabelName]).To(Equal(cluster.Name))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(IndexByClusterName(tt.obj)).To(Equal(tt.want))
+		})
+	}
 }
 
 func TestIsExternalManagedControlPlane(t *testing.T) {
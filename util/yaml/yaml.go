@@ -188,8 +188,21 @@ func NewYAMLDecoder(r io.ReadCloser) streaming.Decoder {
 // ToUnstructured takes a YAML and converts it to a list of Unstructured objects.
 func ToUnstructured(rawyaml []byte) ([]unstructured.Unstructured, error) {
 	var ret []unstructured.Unstructured
+	err := DecodeUnstructured(bytes.NewReader(rawyaml), func(u unstructured.Unstructured) error {
+		ret = append(ret, u)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
 
-	reader := apiyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(rawyaml)))
+// DecodeUnstructured reads a stream of YAML documents and calls fn with the corresponding Unstructured object
+// for each document, one document at a time, so the whole stream is never buffered in memory.
+// If fn returns an error, decoding stops and the error is returned.
+func DecodeUnstructured(r io.Reader, fn func(u unstructured.Unstructured) error) error {
+	reader := apiyaml.NewYAMLReader(bufio.NewReader(r))
 	count := 1
 	for {
 		// Read one YAML document at a time, until io.EOF is returned
@@ -198,7 +211,7 @@ func ToUnstructured(rawyaml []byte) ([]unstructured.Unstructured, error) {
 			if err == io.EOF {
 				break
 			}
-			return nil, errors.Wrapf(err, "failed to read yaml")
+			return errors.Wrapf(err, "failed to read yaml")
 		}
 		if len(b) == 0 {
 			break
@@ -206,7 +219,7 @@ func ToUnstructured(rawyaml []byte) ([]unstructured.Unstructured, error) {
 
 		var m map[string]interface{}
 		if err := yaml.Unmarshal(b, &m); err != nil {
-			return nil, errors.Wrapf(err, "failed to unmarshal the %s yaml document: %q", util.Ordinalize(count), string(b))
+			return errors.Wrapf(err, "failed to unmarshal the %s yaml document: %q", util.Ordinalize(count), string(b))
 		}
 
 		var u unstructured.Unstructured
@@ -218,11 +231,13 @@ func ToUnstructured(rawyaml []byte) ([]unstructured.Unstructured, error) {
 			continue
 		}
 
-		ret = append(ret, u)
+		if err := fn(u); err != nil {
+			return err
+		}
 		count++
 	}
 
-	return ret, nil
+	return nil
 }
 
 // JoinYaml takes a list of YAML files and join them ensuring
@@ -18,9 +18,11 @@ package yaml
 
 import (
 	"os"
+	"strings"
 	"testing"
 
 	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
@@ -450,6 +452,42 @@ func TestToUnstructured(t *testing.T) {
 	}
 }
 
+func TestDecodeUnstructured(t *testing.T) {
+	g := NewWithT(t)
+
+	rawyaml := "---\n" +
+		"apiVersion: v1\n" +
+		"kind: ConfigMap\n" +
+		"---\n" +
+		"---\n" +
+		"apiVersion: v1\n" +
+		"kind: Secret\n" +
+		"---\n" +
+		"apiVersion: v1\n" +
+		"kind: Namespace\n"
+
+	// Objects are decoded one at a time, in order.
+	var kinds []string
+	err := DecodeUnstructured(strings.NewReader(rawyaml), func(u unstructured.Unstructured) error {
+		kinds = append(kinds, u.GetKind())
+		return nil
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(kinds).To(Equal([]string{"ConfigMap", "Secret", "Namespace"}))
+
+	// Decoding stops at the first error returned by the callback.
+	kinds = nil
+	err = DecodeUnstructured(strings.NewReader(rawyaml), func(u unstructured.Unstructured) error {
+		kinds = append(kinds, u.GetKind())
+		if u.GetKind() == "Secret" {
+			return errors.New("failed to process Secret")
+		}
+		return nil
+	})
+	g.Expect(err).To(MatchError("failed to process Secret"))
+	g.Expect(kinds).To(Equal([]string{"ConfigMap", "Secret"}))
+}
+
 func TestFromUnstructured(t *testing.T) {
 	rawyaml := []byte("apiVersion: v1\n" +
 		"kind: ConfigMap")